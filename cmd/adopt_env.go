@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/claudesettings"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/envcheck"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/ui"
+)
+
+// adoptEnvFlag stores a key found in the environment without asking.
+var adoptEnvFlag bool
+
+// envKeyCandidates returns the variables that may hold providerName's key in
+// an env-var based setup, most specific first. The ANTHROPIC_ credentials
+// are only considered when ANTHROPIC_BASE_URL points at the provider, or is
+// unset and the provider is Anthropic itself: without a base URL they hold a
+// key for the native Anthropic API, not for another provider.
+func envKeyCandidates(providerName string, provider config.Provider) []string {
+	names := []string{harness.APIKeyEnvVar(providerName)}
+	if name, ok := providers.APIKeyEnvVarFor(providerName); ok {
+		names = append(names, name)
+	}
+	if provider.EnvKey != "" {
+		names = append(names, provider.EnvKey)
+	}
+
+	baseURL := strings.TrimRight(os.Getenv(constants.EnvBaseURL), "/")
+	if (baseURL != "" && baseURL == strings.TrimRight(provider.BaseURL, "/")) || matchBuiltInProvider(baseURL) == providerName {
+		names = append(names, constants.EnvAuthToken, claudesettings.EnvAPIKey)
+	}
+
+	return slices.Compact(names)
+}
+
+// envKeyFor returns the first candidate variable set in the environment for
+// providerName, and its value.
+func envKeyFor(providerName string, provider config.Provider) (string, string, bool) {
+	for _, name := range envKeyCandidates(providerName, provider) {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			return name, value, true
+		}
+	}
+
+	return "", "", false
+}
+
+// adoptEnvKey offers to store a key found in the environment when none is
+// stored for providerName, to ease moving from an env-var based workflow.
+// It asks first unless --adopt-env or --yes is given, and never asks when
+// stdin is not a terminal or prompts are off, nor with --explain-env, which
+// only reports the environment. It returns the key when it was stored.
+func adoptEnvKey(cliCtx *CLIContext, providerName string, provider config.Provider) (string, bool) {
+	if explainEnvFlag || !providers.RequiresAPIKey(providerName) {
+		return "", false
+	}
+	name, value, ok := envKeyFor(providerName, provider)
+	if !ok {
+		return "", false
+	}
+
+	if !adoptEnvFlag && !yesFlag {
+		if terminalWriter(os.Stdin) == nil || nonInteractive() {
+			ui.PrintInfo(fmt.Sprintf("No API key stored for '%s', but %s is set; run with --adopt-env to store it encrypted",
+				providerName, name))
+
+			return "", false
+		}
+		confirmed, err := ui.Confirm(fmt.Sprintf("No API key stored for '%s'. Store %s from the environment, encrypted?",
+			providerName, name))
+		if err != nil || !confirmed {
+			return "", false
+		}
+	}
+
+	if err := storeProviderSecret(cliCtx, cliCtx.ConfigDir(), providerName, value, "adopt_env_key"); err != nil {
+		ui.PrintWarn(fmt.Sprintf("Could not store %s: %s", name, kairoerrors.Describe(err)))
+
+		return "", false
+	}
+	ui.PrintSuccess(fmt.Sprintf("Stored %s as the API key for '%s' in %s", name, providerName, constants.SecretsFileName))
+	ui.PrintInfo(envKeyRemovalHint(name))
+
+	return value, true
+}
+
+// envKeyRemovalHint recommends removing the now redundant plaintext export,
+// naming the shell rc file that sets it when one does.
+func envKeyRemovalHint(name string) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if rc, ok := envcheck.ScanRCExports(envcheck.RCFiles(home), name)[name]; ok {
+			return fmt.Sprintf("kairo now supplies the key; remove the plaintext %s export from %s", name, rc)
+		}
+	}
+
+	return fmt.Sprintf("kairo now supplies the key; unset %s wherever your shell sets it", name)
+}
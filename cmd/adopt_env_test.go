@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+)
+
+func TestEnvKeyFor(t *testing.T) {
+	zai := config.Provider{BaseURL: "https://api.z.ai/api/anthropic"}
+	t.Setenv("ZAI_API_KEY", "")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "sk-from-token")
+	t.Setenv("ANTHROPIC_BASE_URL", "https://api.z.ai/api/anthropic/")
+
+	if name, value, ok := envKeyFor("zai", zai); !ok || name != "ANTHROPIC_AUTH_TOKEN" || value != "sk-from-token" {
+		t.Errorf("envKeyFor() = %q, %q, %v, want ANTHROPIC_AUTH_TOKEN", name, value, ok)
+	}
+
+	t.Setenv("ZAI_API_KEY", "sk-from-provider-var")
+	if name, _, _ := envKeyFor("zai", zai); name != "ZAI_API_KEY" {
+		t.Errorf("envKeyFor() = %q, want the provider's own variable first", name)
+	}
+
+	t.Setenv("ZAI_API_KEY", "")
+	t.Setenv("ANTHROPIC_BASE_URL", "https://api.minimax.io/anthropic")
+	if name, _, ok := envKeyFor("zai", zai); ok {
+		t.Errorf("envKeyFor() = %q, want nothing when ANTHROPIC_BASE_URL names another endpoint", name)
+	}
+
+	// Without a base URL the credentials are an Anthropic key.
+	t.Setenv("ANTHROPIC_BASE_URL", "")
+	if name, _, ok := envKeyFor("zai", zai); ok {
+		t.Errorf("envKeyFor(zai) = %q, want nothing when ANTHROPIC_BASE_URL is unset", name)
+	}
+	if name, _, ok := envKeyFor("anthropic", config.Provider{}); !ok || name != "ANTHROPIC_AUTH_TOKEN" {
+		t.Errorf("envKeyFor(anthropic) = %q, %v, want ANTHROPIC_AUTH_TOKEN", name, ok)
+	}
+}
+
+func TestAdoptEnvKey(t *testing.T) {
+	dir := t.TempDir()
+	cmd, _ := explainTestCmd(t, dir)
+	cliCtx := CLIContextFromCmd(cmd)
+	writeExplainConfig(t, dir, "providers:\n  zai:\n    name: Z.AI\n    base_url: https://api.z.ai/api/anthropic\n")
+
+	const key = "sk-zai-test-key-abcdefghijklmnopqrst"
+	t.Setenv("ZAI_API_KEY", key)
+	for _, name := range []string{"ANTHROPIC_BASE_URL", "ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_API_KEY", "MINIMAX_API_KEY"} {
+		t.Setenv(name, "")
+	}
+	defer func() { adoptEnvFlag = false }()
+	adoptEnvFlag = true
+
+	got, ok := adoptEnvKey(cliCtx, "zai", config.Provider{})
+	if !ok || got != key {
+		t.Fatalf("adoptEnvKey() = %q, %v, want the environment key", got, ok)
+	}
+	result, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Secrets["ZAI_API_KEY"] != key {
+		t.Errorf("stored secrets = %v, want ZAI_API_KEY", result.Secrets)
+	}
+
+	if _, ok := adoptEnvKey(cliCtx, "minimax", config.Provider{}); ok {
+		t.Error("adoptEnvKey() adopted a key with no candidate variable set")
+	}
+}
+
+func TestAdoptEnvKey_KeepsAnthropicKeyFromOtherProviders(t *testing.T) {
+	dir := t.TempDir()
+	cmd, _ := explainTestCmd(t, dir)
+	cliCtx := CLIContextFromCmd(cmd)
+	writeExplainConfig(t, dir, "providers:\n  zai:\n    name: Z.AI\n    base_url: https://api.z.ai/api/anthropic\n")
+
+	for _, name := range []string{"ZAI_API_KEY", "ANTHROPIC_BASE_URL", "ANTHROPIC_API_KEY"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "sk-ant-REDACTED")
+	defer func() { adoptEnvFlag = false }()
+	adoptEnvFlag = true
+
+	if _, ok := adoptEnvKey(cliCtx, "zai", config.Provider{BaseURL: "https://api.z.ai/api/anthropic"}); ok {
+		t.Error("adoptEnvKey() stored the Anthropic key as zai's")
+	}
+	if _, err := os.Stat(filepath.Join(dir, constants.SecretsFileName)); !os.IsNotExist(err) {
+		t.Errorf("secrets file stat error = %v, want nothing stored", err)
+	}
+}
+
+func TestAdoptEnvKey_ExplainEnv(t *testing.T) {
+	dir := t.TempDir()
+	cmd, _ := explainTestCmd(t, dir)
+	cliCtx := CLIContextFromCmd(cmd)
+	writeExplainConfig(t, dir, "providers:\n  zai:\n    name: Z.AI\n    base_url: https://api.z.ai/api/anthropic\n")
+
+	t.Setenv("ZAI_API_KEY", "sk-zai-test-key-abcdefghijklmnopqrst")
+	defer func() { adoptEnvFlag, explainEnvFlag = false, false }()
+	adoptEnvFlag, explainEnvFlag = true, true
+
+	if _, ok := adoptEnvKey(cliCtx, "zai", config.Provider{}); ok {
+		t.Error("adoptEnvKey() stored a key under --explain-env")
+	}
+	if _, err := os.Stat(filepath.Join(dir, constants.SecretsFileName)); !os.IsNotExist(err) {
+		t.Errorf("secrets file stat error = %v, want none written under --explain-env", err)
+	}
+}
+
+func TestEnvKeyRemovalHint(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	rc := filepath.Join(home, ".zshrc")
+	if err := os.WriteFile(rc, []byte("export ZAI_API_KEY=sk-abc\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if hint := envKeyRemovalHint("ZAI_API_KEY"); !strings.Contains(hint, rc) {
+		t.Errorf("hint = %q, want it to name %s", hint, rc)
+	}
+	if hint := envKeyRemovalHint("ANTHROPIC_AUTH_TOKEN"); !strings.Contains(hint, "unset ANTHROPIC_AUTH_TOKEN") {
+		t.Errorf("hint = %q, want a generic unset recommendation", hint)
+	}
+}
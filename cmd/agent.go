@@ -0,0 +1,395 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/agent"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/execution"
+	"github.com/dkmnx/kairo/internal/metrics"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// agentReady is written by a detached agent once it is listening.
+const agentReady = "ready"
+
+var (
+	agentTTL          time.Duration
+	agentForeground   bool
+	agentKeyFromStdin bool
+	agentMetricsAddr  string
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Keep the unlocked encryption key in memory for this session",
+	Long: `Run a background agent, like ssh-agent, that holds the unlocked age key
+for a limited time. While it runs, commands decrypt and encrypt secrets.age
+through it instead of reading age.key, so a passphrase-protected key is only
+unlocked once per session.
+
+The agent listens on agent.sock in the state directory, or on the path in
+KAIRO_AGENT_SOCK, readable only by you. It keeps the key in locked memory
+where the system allows it and wipes it when --ttl elapses or on
+'kairo agent stop'. Commands fall back to age.key when no agent is running.
+
+The agent section of config.yaml sets the cache policy: unlock_ttl is the
+default --ttl and the longest one accepted, lock_on_sleep wipes the key
+when the system wakes from sleep, and max_unlocks_per_hour bounds how many
+times the key decrypts secrets.age in any hour.
+
+With --metrics-listen, or agent.metrics_listen, the agent also serves
+Prometheus metrics on a loopback address while it runs: sessions and tokens
+per provider, health check outcomes, API key ages, and its own expiry and
+decryptions. They are computed from the audit log, usage, and health
+history at each scrape.
+
+To protect age.key with a passphrase, encrypt it with age:
+
+  age --passphrase -o age.key.new age.key && mv age.key.new age.key
+
+Key rotation needs the plain key file.`,
+	Args: cobra.NoArgs,
+}
+
+var agentStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Unlock the key and start the agent",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runAgentStart(cmd); err != nil {
+			if !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+				printError(err)
+			}
+			CLIContextFromCmd(cmd).Deps().Process.ExitProcess(1)
+		}
+	},
+}
+
+var agentStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the agent is running",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		dir := requireConfigDir(cmd)
+		if dir == "" {
+			return
+		}
+		cliCtx := CLIContextFromCmd(cmd)
+		socket := agentSocket(cliCtx, dir)
+
+		status, err := agent.Client{Socket: socket}.Status(cliCtx.RootCtx())
+		if stderrors.Is(err, agent.ErrNotRunning) {
+			ui.PrintInfo("kairo agent is not running")
+
+			return
+		}
+		if err != nil {
+			printError(err)
+
+			return
+		}
+
+		printAgentStatus(cmd.OutOrStdout(), socket, status, time.Now())
+	},
+}
+
+var agentStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Wipe the key and stop the agent",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		dir := requireConfigDir(cmd)
+		if dir == "" {
+			return
+		}
+		cliCtx := CLIContextFromCmd(cmd)
+
+		err := agent.Client{Socket: agentSocket(cliCtx, dir)}.Stop(cliCtx.RootCtx())
+		if stderrors.Is(err, agent.ErrNotRunning) {
+			ui.PrintInfo("kairo agent is not running")
+
+			return
+		}
+		if err != nil {
+			printError(err)
+
+			return
+		}
+
+		ui.PrintSuccess("kairo agent stopped")
+	},
+}
+
+func init() {
+	agentStartCmd.Flags().DurationVar(&agentTTL, "ttl", agent.DefaultTTL,
+		"How long the agent keeps the key; agent.unlock_ttl in config.yaml sets the default and the maximum")
+	agentStartCmd.Flags().BoolVar(&agentForeground, "foreground", false, "Run the agent in the foreground")
+	agentStartCmd.Flags().BoolVar(&agentKeyFromStdin, "key-from-stdin", false, "Read the unlocked key from stdin")
+	_ = agentStartCmd.Flags().MarkHidden("key-from-stdin")
+	agentStartCmd.Flags().StringVar(&agentMetricsAddr, "metrics-listen", "",
+		"Loopback address to serve Prometheus metrics on, such as 127.0.0.1:9464")
+	agentCmd.AddCommand(agentStartCmd, agentStatusCmd, agentStopCmd)
+	rootCmd.AddCommand(agentCmd)
+}
+
+// agentSocketPath returns KAIRO_AGENT_SOCK, or agent.sock in the state
+// directory of the config in configDir.
+func agentSocketPath(configDir string, cfg *config.Config) string {
+	if path := os.Getenv(agent.SocketEnv); path != "" {
+		return path
+	}
+
+	dir, err := config.StateDir(configDir, cfg)
+	if err != nil {
+		dir = configDir
+	}
+
+	return filepath.Join(dir, agent.SocketFileName)
+}
+
+func agentSocket(cliCtx *CLIContext, configDir string) string {
+	cfg, _ := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), configDir)
+
+	return agentSocketPath(configDir, cfg)
+}
+
+func runAgentStart(cmd *cobra.Command) error {
+	if runtime.GOOS == constants.WindowsGOOS {
+		return kairoerrors.NewError(kairoerrors.RuntimeError, "kairo agent is not supported on Windows")
+	}
+
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	ctx := cliCtx.RootCtx()
+
+	cfg, _ := cliCtx.ConfigCache().Get(ctx, dir)
+	if cfg != nil && cfg.Crypto != nil && cfg.Crypto.Backend != "" && cfg.Crypto.Backend != crypto.BackendAge {
+		return kairoerrors.NewError(kairoerrors.ConfigError,
+			"kairo agent only holds age keys, but crypto.backend is "+cfg.Crypto.Backend)
+	}
+	policy, err := agentPolicy(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	metricsAddr := agentMetricsListen(cfg)
+	if metricsAddr != "" {
+		if err := metrics.CheckLoopback(metricsAddr); err != nil {
+			return err
+		}
+	}
+
+	socket := agentSocketPath(dir, cfg)
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+
+	if !agentKeyFromStdin {
+		if status, err := (agent.Client{Socket: socket}).Status(ctx); err == nil {
+			ui.PrintInfo(fmt.Sprintf("kairo agent is already running (expires %s)",
+				ui.RelativeTime(status.Expires, time.Now())))
+
+			return nil
+		}
+	}
+
+	key, err := readAgentKey(cmd, keyPath)
+	if err != nil {
+		return err
+	}
+	defer crypto.ClearMemory(key)
+
+	if agentForeground || agentKeyFromStdin {
+		return serveAgent(cmd, key, dir, socket, policy, metricsAddr)
+	}
+
+	return spawnAgent(cliCtx, dir, socket, key, policy, metricsAddr)
+}
+
+// agentPolicy returns the policy the agent runs under: the agent section of
+// cfg, with --ttl choosing a TTL no longer than agent.unlock_ttl.
+func agentPolicy(cmd *cobra.Command, cfg *config.Config) (agent.Policy, error) {
+	policy := agent.Policy{TTL: agent.DefaultTTL}
+	var maxTTL time.Duration
+	if cfg != nil && cfg.Agent != nil {
+		if cfg.Agent.UnlockTTL != "" {
+			ttl, err := time.ParseDuration(cfg.Agent.UnlockTTL)
+			if err != nil || ttl <= 0 {
+				return agent.Policy{}, kairoerrors.NewError(kairoerrors.ConfigError,
+					fmt.Sprintf("invalid agent.unlock_ttl %q", cfg.Agent.UnlockTTL)).
+					WithContext("hint", "use a positive duration such as 15m or 8h")
+			}
+			policy.TTL, maxTTL = ttl, ttl
+		}
+		if cfg.Agent.MaxUnlocksPerHour < 0 {
+			return agent.Policy{}, kairoerrors.NewError(kairoerrors.ConfigError,
+				"agent.max_unlocks_per_hour must not be negative").
+				WithContext("hint", "use 0 for no limit")
+		}
+		policy.LockOnSleep = cfg.Agent.LockOnSleep
+		policy.MaxUnlocksPerHour = cfg.Agent.MaxUnlocksPerHour
+	}
+
+	if cmd.Flags().Changed("ttl") {
+		if agentTTL <= 0 {
+			return agent.Policy{}, kairoerrors.NewError(kairoerrors.ValidationError, "--ttl must be positive")
+		}
+		if maxTTL > 0 && agentTTL > maxTTL {
+			return agent.Policy{}, kairoerrors.NewError(kairoerrors.ValidationError,
+				fmt.Sprintf("--ttl %s is longer than agent.unlock_ttl %s", agentTTL, maxTTL)).
+				WithContext("hint", "pass a shorter --ttl, or raise agent.unlock_ttl in config.yaml")
+		}
+		policy.TTL = agentTTL
+	}
+
+	return policy, nil
+}
+
+// readAgentKey reads the unlocked key from stdin for a detached agent, and
+// otherwise from the key file, asking for its passphrase if it has one.
+func readAgentKey(cmd *cobra.Command, keyPath string) (crypto.KeyMaterial, error) {
+	if agentKeyFromStdin {
+		data, err := io.ReadAll(io.LimitReader(cmd.InOrStdin(), 64*1024))
+		if err != nil {
+			return nil, kairoerrors.WrapError(kairoerrors.RuntimeError, "failed to read key from stdin", err)
+		}
+
+		return data, nil
+	}
+
+	return crypto.ReadKeyFile(keyPath, promptKeyPassphrase)
+}
+
+// serveAgent runs the agent in this process until it expires or is stopped,
+// serving metrics on metricsAddr unless it is empty. A detached agent
+// reports agentReady on stdout instead of printing status.
+func serveAgent(
+	cmd *cobra.Command, key crypto.KeyMaterial, dir, socket string, policy agent.Policy, metricsAddr string,
+) error {
+	a, err := agent.New(key, filepath.Join(dir, constants.KeyFileName), policy)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel, stopSig := execution.StartSession(commandContext(cmd))
+	defer cancel()
+	defer stopSig()
+
+	listener, err := agent.Listen(ctx, socket)
+	if err != nil {
+		return err
+	}
+	if metricsAddr != "" {
+		if err := serveAgentMetrics(ctx, CLIContextFromCmd(cmd), dir, metricsAddr, a); err != nil {
+			return err
+		}
+	}
+
+	if agentKeyFromStdin {
+		fmt.Fprintln(cmd.OutOrStdout(), agentReady)
+	} else {
+		printAgentStarted(socket, a.Expires(), a.MemoryLocked(), metricsAddr)
+		ui.PrintInfo("Press Ctrl+C to stop")
+	}
+
+	return a.Serve(ctx, listener)
+}
+
+// spawnAgent starts a detached `kairo agent start --key-from-stdin`, hands it
+// the unlocked key over a pipe, and waits until it is listening.
+func spawnAgent(
+	cliCtx *CLIContext, configDir, socket string, key crypto.KeyMaterial, policy agent.Policy, metricsAddr string,
+) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return kairoerrors.WrapError(kairoerrors.RuntimeError, "cannot locate the kairo executable", err)
+	}
+
+	// The agent outlives this command, so it must not be killed when the
+	// command's context ends.
+	args := []string{"agent", "start", "--key-from-stdin", "--ttl", policy.TTL.String(), "--config", configDir}
+	if metricsAddr != "" {
+		args = append(args, "--metrics-listen", metricsAddr)
+	}
+	c := cliCtx.Deps().Process.ExecCommandContext(context.WithoutCancel(cliCtx.RootCtx()), exe, args...)
+	if c == nil {
+		return kairoerrors.NewError(kairoerrors.RuntimeError, "failed to start kairo agent")
+	}
+	c.Env = append(os.Environ(), agent.SocketEnv+"="+socket)
+	c.Stdin = bytes.NewReader(key)
+	agent.Detach(c)
+
+	out, err := c.StdoutPipe()
+	if err != nil {
+		return kairoerrors.WrapError(kairoerrors.RuntimeError, "failed to start kairo agent", err)
+	}
+	c.Stderr = c.Stdout
+	if err := c.Start(); err != nil {
+		return kairoerrors.WrapError(kairoerrors.RuntimeError, "failed to start kairo agent", err)
+	}
+
+	var output []string
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != agentReady {
+			output = append(output, line)
+
+			continue
+		}
+
+		_ = c.Process.Release()
+		status, err := agent.Client{Socket: socket}.Status(cliCtx.RootCtx())
+		if err != nil {
+			return err
+		}
+		printAgentStarted(socket, status.Expires, status.MemoryLocked, metricsAddr)
+
+		return nil
+	}
+	_ = c.Wait()
+
+	return kairoerrors.NewError(kairoerrors.RuntimeError, "kairo agent failed to start").
+		WithContext("output", strings.TrimSpace(strings.Join(output, "\n")))
+}
+
+func printAgentStarted(socket string, expires time.Time, memoryLocked bool, metricsAddr string) {
+	ui.PrintSuccess(fmt.Sprintf("kairo agent listening on %s until %s",
+		socket, ui.FormatTime(expires, utcFlag)))
+	if metricsAddr != "" {
+		ui.PrintInfo("Serving Prometheus metrics on http://" + metricsAddr + metrics.Path)
+	}
+	if !memoryLocked {
+		ui.PrintWarn("Could not lock the key into memory; it may be written to swap")
+	}
+}
+
+func printAgentStatus(w io.Writer, socket string, status agent.Status, now time.Time) {
+	ui.PrintValue(w, "Socket", socket)
+	ui.PrintValue(w, "PID", fmt.Sprint(status.PID))
+	ui.PrintValue(w, "Key", status.KeyPath)
+	ui.PrintValue(w, "Expires", ui.FormatTimeWithAge(status.Expires, now, utcFlag))
+	ui.PrintValue(w, "Memory locked", fmt.Sprint(status.MemoryLocked))
+	ui.PrintValue(w, "Lock on sleep", fmt.Sprint(status.LockOnSleep))
+	unlocks := fmt.Sprint(status.UnlocksLastHour)
+	if status.MaxUnlocksPerHour > 0 {
+		unlocks = fmt.Sprintf("%d of %d", status.UnlocksLastHour, status.MaxUnlocksPerHour)
+	}
+	ui.PrintValue(w, "Unlocks in the last hour", unlocks)
+}
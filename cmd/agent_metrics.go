@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/agent"
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/metrics"
+	"github.com/dkmnx/kairo/internal/usage"
+)
+
+// agentMetricsListen returns the address kairo agent serves metrics on:
+// --metrics-listen, or agent.metrics_listen, or "" for none.
+func agentMetricsListen(cfg *config.Config) string {
+	if agentMetricsAddr != "" || cfg == nil || cfg.Agent == nil {
+		return agentMetricsAddr
+	}
+
+	return cfg.Agent.MetricsListen
+}
+
+// serveAgentMetrics serves the metrics of dir and of a on addr until ctx
+// ends.
+func serveAgentMetrics(ctx context.Context, cliCtx *CLIContext, dir, addr string, a *agent.Agent) error {
+	return metrics.Serve(ctx, addr, metrics.Handler(func(ctx context.Context) ([]metrics.Family, error) {
+		cfg, err := cliCtx.ConfigCache().Get(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		families, err := gatherMetrics(cliCtx, dir, cfg, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		return append(families, agentStatusMetrics(a.Status())...), nil
+	}))
+}
+
+// gatherMetrics loads what kairo records in the state directory of dir and
+// computes its metrics. Nothing is printed, since a detached agent has no
+// terminal to print to.
+func gatherMetrics(cliCtx *CLIContext, dir string, cfg *config.Config, now time.Time) ([]metrics.Family, error) {
+	state := stateDir(cliCtx, dir)
+	_, auditEnabled, _ := auditPolicy(cfg)
+	var entries []audit.Entry
+	if auditEnabled {
+		identity, _ := auditIdentity(dir)
+		var err error
+		if entries, _, err = audit.Read(state, identity); err != nil {
+			return nil, err
+		}
+	}
+	records, err := usage.Load(state)
+	if err != nil {
+		return nil, err
+	}
+	history := map[string][]health.Result{}
+	for name := range cfg.Providers {
+		if history[name], err = health.LoadHistory(state, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return providerMetrics(cfg, auditEnabled, entries, records, history, now), nil
+}
+
+// providerMetrics computes the metrics kairo serves as kairo summary counts
+// its activity: sessions are the switches in entries when auditing is on,
+// and the usage records otherwise; tokens come from the usage records,
+// health check outcomes from history, API key ages from the last key change
+// in entries, and failures, by class, from the warnings and failed key
+// revocations in entries and the failed checks in history.
+func providerMetrics(cfg *config.Config, fromAudit bool, entries []audit.Entry, records []usage.Record,
+	history map[string][]health.Result, now time.Time,
+) []metrics.Family {
+	sessions := map[string]int{}
+	tokens := map[string]int64{}
+	lastKeyChange := map[string]time.Time{}
+	failures := map[failureKey]int{}
+	for _, e := range entries {
+		switch {
+		case e.Event == audit.EventSwitch:
+			sessions[e.Provider]++
+		case e.Event == audit.EventWarning:
+			failures[failureKey{e.Provider, failureWarning}]++
+		case e.Event == audit.EventRotate && e.Action == "revoke_failed":
+			failures[failureKey{e.Provider, failureRevoke}]++
+		case e.Event == audit.EventRotate && slices.Contains(keyChangeActions, e.Action):
+			lastKeyChange[e.Provider] = e.Timestamp
+		}
+	}
+	for _, r := range records {
+		tokens[r.Provider] += r.Total()
+		if !fromAudit {
+			sessions[r.Provider]++
+		}
+	}
+
+	var checks []metrics.Sample
+	for _, name := range slices.Sorted(maps.Keys(history)) {
+		byStatus := map[health.Status]int{}
+		for _, r := range history[name] {
+			byStatus[r.Status]++
+			if !r.OK() {
+				failures[failureKey{name, string(r.Status)}]++
+			}
+		}
+		for _, status := range slices.Sorted(maps.Keys(byStatus)) {
+			checks = append(checks, metrics.Sample{
+				Labels: []metrics.Label{{Name: "provider", Value: name}, {Name: "status", Value: string(status)}},
+				Value:  float64(byStatus[status]),
+			})
+		}
+	}
+
+	keyAges := map[string]float64{}
+	for name, changed := range lastKeyChange {
+		if _, ok := cfg.Providers[name]; ok {
+			keyAges[name] = now.Sub(changed).Seconds()
+		}
+	}
+
+	return []metrics.Family{
+		{
+			Name: "kairo_sessions_total", Type: metrics.Counter,
+			Help:    "Harness sessions started per provider.",
+			Samples: providerSamples(sessions),
+		},
+		{
+			Name: "kairo_tokens_total", Type: metrics.Counter,
+			Help:    "Tokens the harness reported using per provider.",
+			Samples: providerSamples(tokens),
+		},
+		{
+			Name: "kairo_health_checks", Type: metrics.Gauge,
+			Help:    "Health checks in the recorded history per provider and outcome.",
+			Samples: checks,
+		},
+		{
+			Name: "kairo_api_key_age_seconds", Type: metrics.Gauge,
+			Help:    "Seconds since a provider's API key was last changed, from the audit log.",
+			Samples: providerSamples(keyAges),
+		},
+		{
+			Name: "kairo_failures_total", Type: metrics.Counter,
+			Help:    "Failures per provider and class: warning, revoke, or the status of a failed health check.",
+			Samples: failureSamples(failures),
+		},
+	}
+}
+
+// Failure classes of kairo_failures_total besides the health check
+// statuses, as kairo summary counts them.
+const (
+	failureWarning = "warning"
+	failureRevoke  = "revoke"
+)
+
+// failureKey identifies a kairo_failures_total sample.
+type failureKey struct {
+	provider string
+	class    string
+}
+
+// failureSamples returns one sample per provider and class of failures,
+// sorted by provider and then class.
+func failureSamples(failures map[failureKey]int) []metrics.Sample {
+	keys := slices.SortedFunc(maps.Keys(failures), func(a, b failureKey) int {
+		return cmp.Or(cmp.Compare(a.provider, b.provider), cmp.Compare(a.class, b.class))
+	})
+	samples := make([]metrics.Sample, 0, len(keys))
+	for _, k := range keys {
+		samples = append(samples, metrics.Sample{
+			Labels: []metrics.Label{{Name: "provider", Value: k.provider}, {Name: "class", Value: k.class}},
+			Value:  float64(failures[k]),
+		})
+	}
+
+	return samples
+}
+
+// agentStatusMetrics returns the metrics of a running agent.
+func agentStatusMetrics(status agent.Status) []metrics.Family {
+	return []metrics.Family{
+		{
+			Name: "kairo_agent_expiry_timestamp_seconds", Type: metrics.Gauge,
+			Help:    "When the agent drops the unlocked key, in seconds since the epoch.",
+			Samples: []metrics.Sample{{Value: float64(status.Expires.Unix())}},
+		},
+		{
+			Name: "kairo_agent_unlocks_last_hour", Type: metrics.Gauge,
+			Help:    "Decryptions the agent performed in the last hour.",
+			Samples: []metrics.Sample{{Value: float64(status.UnlocksLastHour)}},
+		},
+	}
+}
+
+// providerSamples returns one sample per provider of values, sorted by
+// provider.
+func providerSamples[V int | int64 | float64](values map[string]V) []metrics.Sample {
+	samples := make([]metrics.Sample, 0, len(values))
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		samples = append(samples, metrics.Sample{
+			Labels: []metrics.Label{{Name: "provider", Value: name}},
+			Value:  float64(values[name]),
+		})
+	}
+
+	return samples
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/agent"
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/metrics"
+	"github.com/dkmnx/kairo/internal/usage"
+)
+
+func TestProviderMetrics(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	cfg := &config.Config{Providers: map[string]config.Provider{"zai": {}, "minimax": {}}}
+	entries := []audit.Entry{
+		{Timestamp: now.Add(-10 * day), Event: audit.EventRotate, Action: "setup_secret", Provider: "zai"},
+		{Timestamp: now.Add(-2 * day), Event: audit.EventRotate, Action: "rotate_key", Provider: "zai"},
+		{Timestamp: now.Add(-day), Event: audit.EventRotate, Action: "set_secret", Provider: "deleted"},
+		{Timestamp: now.Add(-day), Event: audit.EventSwitch, Provider: "zai"},
+		{Timestamp: now.Add(-day), Event: audit.EventSwitch, Provider: "zai"},
+		{Timestamp: now.Add(-day), Event: audit.EventSwitch, Provider: "minimax"},
+		{Timestamp: now.Add(-day), Event: audit.EventWarning, Action: "private_base_url", Provider: "minimax"},
+		{Timestamp: now.Add(-day), Event: audit.EventWarning, Action: "private_base_url", Provider: "minimax"},
+		{Timestamp: now.Add(-day), Event: audit.EventRotate, Action: "revoke_failed", Provider: "zai"},
+	}
+	records := []usage.Record{
+		{End: now.Add(-day), Provider: "zai", Tokens: usage.Tokens{Input: 1000, Output: 200}},
+		{End: now.Add(-day), Provider: "zai", Tokens: usage.Tokens{Input: 300}},
+	}
+	history := map[string][]health.Result{
+		"zai":     {{Status: health.StatusOK}, {Status: health.StatusOK}, {Status: health.StatusAuthError}},
+		"minimax": nil,
+	}
+
+	var out bytes.Buffer
+	if err := metrics.Write(&out, providerMetrics(cfg, true, entries, records, history, now)); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"# TYPE kairo_sessions_total counter\n" +
+			`kairo_sessions_total{provider="minimax"} 1` + "\n" + `kairo_sessions_total{provider="zai"} 2`,
+		`kairo_tokens_total{provider="zai"} 1500`,
+		`kairo_health_checks{provider="zai",status="auth_error"} 1`,
+		`kairo_health_checks{provider="zai",status="ok"} 2`,
+		`kairo_api_key_age_seconds{provider="zai"} 172800`,
+		"# TYPE kairo_failures_total counter\n" +
+			`kairo_failures_total{provider="minimax",class="warning"} 2` + "\n" +
+			`kairo_failures_total{provider="zai",class="auth_error"} 1` + "\n" +
+			`kairo_failures_total{provider="zai",class="revoke"} 1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("metrics missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "deleted") {
+		t.Errorf("metrics name a provider that is no longer configured:\n%s", got)
+	}
+
+	out.Reset()
+	if err := metrics.Write(&out, providerMetrics(cfg, false, nil, records, nil, now)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `kairo_sessions_total{provider="zai"} 2`) {
+		t.Errorf("without an audit log, sessions should come from usage:\n%s", out.String())
+	}
+
+	out.Reset()
+	status := agent.Status{Expires: now, UnlocksLastHour: 4}
+	if err := metrics.Write(&out, agentStatusMetrics(status)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"kairo_agent_expiry_timestamp_seconds 1792065600", "kairo_agent_unlocks_last_hour 4"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("agent metrics missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestAgentMetricsListen(t *testing.T) {
+	defer func() { agentMetricsAddr = "" }()
+	cfg := &config.Config{Agent: &config.AgentConfig{MetricsListen: "127.0.0.1:9464"}}
+
+	if got := agentMetricsListen(nil); got != "" {
+		t.Errorf("agentMetricsListen(nil) = %q, want none", got)
+	}
+	if got := agentMetricsListen(cfg); got != "127.0.0.1:9464" {
+		t.Errorf("agentMetricsListen() = %q, want agent.metrics_listen", got)
+	}
+	agentMetricsAddr = "[::1]:9000"
+	if got := agentMetricsListen(cfg); got != "[::1]:9000" {
+		t.Errorf("agentMetricsListen() = %q, want --metrics-listen", got)
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/agent"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func TestAgentSocketPath(t *testing.T) {
+	configDir := t.TempDir()
+	stateDir := t.TempDir()
+
+	t.Setenv(agent.SocketEnv, "")
+	if got, want := agentSocketPath(configDir, &config.Config{StateDir: stateDir}),
+		filepath.Join(stateDir, agent.SocketFileName); got != want {
+		t.Errorf("agentSocketPath() = %q, want %q", got, want)
+	}
+
+	t.Setenv(agent.SocketEnv, "/run/user/1000/kairo.sock")
+	if got := agentSocketPath(configDir, nil); got != "/run/user/1000/kairo.sock" {
+		t.Errorf("agentSocketPath() = %q, want %s", got, agent.SocketEnv)
+	}
+}
+
+func TestPrintAgentStatus(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	status := agent.Status{PID: 42, KeyPath: "/c/age.key", Expires: now.Add(30 * time.Minute), MemoryLocked: true}
+
+	var buf bytes.Buffer
+	printAgentStatus(&buf, "/s/agent.sock", status, now)
+
+	out := buf.String()
+	for _, want := range []string{"Socket: /s/agent.sock", "PID: 42", "Key: /c/age.key", "(in 30m)", "Memory locked: true"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintAgentStatus_Policy(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	status := agent.Status{Expires: now.Add(time.Minute), LockOnSleep: true, MaxUnlocksPerHour: 20, UnlocksLastHour: 3}
+
+	var buf bytes.Buffer
+	printAgentStatus(&buf, "/s/agent.sock", status, now)
+
+	out := buf.String()
+	for _, want := range []string{"Lock on sleep: true", "Unlocks in the last hour: 3 of 20"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestAgentPolicy(t *testing.T) {
+	defer func() { agentTTL = agent.DefaultTTL }()
+
+	tests := []struct {
+		name    string
+		cfg     *config.Config
+		ttl     string
+		want    agent.Policy
+		wantErr string
+	}{
+		{name: "defaults", want: agent.Policy{TTL: agent.DefaultTTL}},
+		{name: "ttl flag", ttl: "10m", want: agent.Policy{TTL: 10 * time.Minute}},
+		{
+			name: "config policy",
+			cfg: &config.Config{Agent: &config.AgentConfig{
+				UnlockTTL: "15m", LockOnSleep: true, MaxUnlocksPerHour: 20,
+			}},
+			want: agent.Policy{TTL: 15 * time.Minute, LockOnSleep: true, MaxUnlocksPerHour: 20},
+		},
+		{
+			name: "shorter ttl flag",
+			cfg:  &config.Config{Agent: &config.AgentConfig{UnlockTTL: "15m"}},
+			ttl:  "5m",
+			want: agent.Policy{TTL: 5 * time.Minute},
+		},
+		{
+			name:    "longer ttl flag",
+			cfg:     &config.Config{Agent: &config.AgentConfig{UnlockTTL: "15m"}},
+			ttl:     "1h",
+			wantErr: "longer than agent.unlock_ttl",
+		},
+		{
+			name:    "invalid unlock_ttl",
+			cfg:     &config.Config{Agent: &config.AgentConfig{UnlockTTL: "soon"}},
+			wantErr: "invalid agent.unlock_ttl",
+		},
+		{
+			name:    "negative limit",
+			cfg:     &config.Config{Agent: &config.AgentConfig{MaxUnlocksPerHour: -1}},
+			wantErr: "must not be negative",
+		},
+		{name: "zero ttl flag", ttl: "0s", wantErr: "--ttl must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agentTTL = agent.DefaultTTL
+			cmd := &cobra.Command{}
+			cmd.Flags().DurationVar(&agentTTL, "ttl", agent.DefaultTTL, "")
+			if tt.ttl != "" {
+				if err := cmd.Flags().Set("ttl", tt.ttl); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := agentPolicy(cmd, tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("agentPolicy() error = %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("agentPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,491 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/crypto"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditLimit      int
+	auditAnalyze    bool
+	auditPruneKeep  string
+	auditShowPatch  bool
+	auditShowFormat string
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show recent audit log entries",
+	Long: `Show the most recent entries of the audit log, newest last.
+
+Auditing is off by default; enable it with 'audit.enabled: true' in
+config.yaml. Timestamps are shown in local time unless --utc is set.
+Entries written with 'audit.encrypt: true' are decrypted with audit.key from
+the config directory. Use 'kairo audit prune' to drop old entries.
+
+With --analyze, the whole log is checked for activity that stands out from
+the rest of it instead: the first switch from a host or user after a
+baseline of others, bursts of warnings or failed key revocations, and
+activity at hours of the day the log is rarely active. The hints are
+informational, computed locally, and are no proof of misuse. Hosts and users
+are only recorded with 'audit.level: verbose'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := requireConfigDir(cmd)
+		if dir == "" {
+			return
+		}
+
+		entries, err := loadAuditEntries(CLIContextFromCmd(cmd), dir)
+		if err != nil {
+			printError(err)
+
+			return
+		}
+		if len(entries) == 0 {
+			ui.PrintInfo("No audit entries. Set 'audit.enabled: true' in config.yaml to record them.")
+
+			return
+		}
+
+		if auditAnalyze {
+			printAuditHints(cmd.OutOrStdout(), entries)
+
+			return
+		}
+		printAuditEntries(cmd.OutOrStdout(), entries, auditLimit, time.Now())
+	},
+}
+
+var auditPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old audit log entries",
+	Long: `Rewrite the audit log keeping only entries newer than --keep, such as
+90d, 2w, or 36h. Without --keep, 'audit.retention' from config.yaml is used;
+when it is set, the same pruning also runs each time an entry is written.
+
+The removed entries are replaced by one "prune" checkpoint entry holding the
+SHA-256 of the removed lines, so an archived copy of the old log can still be
+matched against the pruned one. Each checkpoint's hash covers the previous
+checkpoint as well.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runAuditPrune(cmd, auditPruneKeep, time.Now()); err != nil {
+			printError(err)
+			CLIContextFromCmd(cmd).Deps().Process.ExitProcess(1)
+		}
+	},
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show <n>",
+	Short: "Show one audit log entry in full",
+	Long: `Show entry n of the audit log, as numbered in the # column of
+'kairo audit', with all of its details.
+
+Provider changes also record a JSON patch (RFC 6902) of the provider entry,
+rooted at /providers/<name> in config.yaml. Each replaced or removed value is
+preceded by a "test" operation holding the old value, so replaying the patch
+fails on a config that has changed since. With --patch, only the patch is
+printed, one change per line; with --format json, it is printed as the JSON
+array a patch tool applies.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runAuditShow(cmd, args[0]); err != nil {
+			printError(err)
+			CLIContextFromCmd(cmd).Deps().Process.ExitProcess(1)
+		}
+	},
+}
+
+func init() {
+	auditCmd.Flags().IntVarP(&auditLimit, "limit", "n", 20, "Number of entries to show (0 for all)")
+	auditCmd.Flags().BoolVar(&auditAnalyze, "analyze", false, "Print hints about unusual activity instead of entries")
+	auditPruneCmd.Flags().StringVar(&auditPruneKeep, "keep", "",
+		"Keep entries newer than this period, such as 90d (default: audit.retention)")
+	auditShowCmd.Flags().BoolVar(&auditShowPatch, "patch", false, "Print only the JSON patch of a config change")
+	auditShowCmd.Flags().StringVar(&auditShowFormat, "format", "text", "Output format: text or json")
+	auditCmd.AddCommand(auditPruneCmd)
+	auditCmd.AddCommand(auditShowCmd)
+	rootCmd.AddCommand(auditCmd)
+}
+
+// runAuditPrune prunes the audit log to the period keep, falling back to the
+// configured retention when keep is empty.
+func runAuditPrune(cmd *cobra.Command, keep string, now time.Time) error {
+	cliCtx := CLIContextFromCmd(cmd)
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return nil
+	}
+
+	if keep == "" {
+		cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+		if err != nil {
+			return err
+		}
+		if cfg.Audit != nil {
+			keep = cfg.Audit.Retention
+		}
+	}
+	if keep == "" {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "no retention period given").
+			WithContext("hint", "pass --keep 90d or set 'audit.retention' in config.yaml")
+	}
+
+	retention, err := audit.ParseRetention(keep)
+	if err != nil {
+		return err
+	}
+
+	identity, err := auditIdentity(dir)
+	if err != nil {
+		return err
+	}
+	result, err := audit.Prune(stateDir(cliCtx, dir), now.Add(-retention), now, identity)
+	if err != nil {
+		return err
+	}
+	if result.Removed == 0 {
+		ui.PrintInfo(fmt.Sprintf("No audit entries older than %s", keep))
+
+		return nil
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Removed %d audit entries older than %s, kept %d", result.Removed, keep, result.Kept))
+	ui.PrintInfo("Checkpoint sha256: " + result.Checkpoint)
+
+	return nil
+}
+
+// runAuditShow prints entry arg of the audit log, counted from 1.
+func runAuditShow(cmd *cobra.Command, arg string) error {
+	if auditShowFormat != "text" && auditShowFormat != "json" {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("invalid format %q (use text or json)", auditShowFormat))
+	}
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return nil
+	}
+
+	entries, err := loadAuditEntries(CLIContextFromCmd(cmd), dir)
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(entries) {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("no audit entry %q (the log has %d)", arg, len(entries))).
+			WithContext("hint", "run 'kairo audit' to see entry numbers")
+	}
+	e := entries[n-1]
+
+	out := cmd.OutOrStdout()
+	switch {
+	case auditShowPatch && len(e.Patch) == 0:
+		ui.PrintInfo(fmt.Sprintf("Entry %d has no patch; only provider changes record one", n))
+	case auditShowPatch && auditShowFormat == "json":
+		return writeAuditJSON(out, e.Patch)
+	case auditShowPatch:
+		for _, line := range audit.FormatPatch(e.Patch) {
+			fmt.Fprintln(out, line)
+		}
+	case auditShowFormat == "json":
+		return writeAuditJSON(out, e)
+	default:
+		printAuditEntry(out, n, e)
+	}
+
+	return nil
+}
+
+// printAuditEntry prints every field of entry n.
+func printAuditEntry(out io.Writer, n int, e audit.Entry) {
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(out, "%-9s %s\n", name+":", value)
+		}
+	}
+	field("Entry", strconv.Itoa(n))
+	field("Time", ui.FormatTime(e.Timestamp, utcFlag))
+	field("Event", string(e.Event))
+	field("Action", e.Action)
+	field("Provider", e.Provider)
+	field("Host", e.Hostname)
+	field("User", e.User)
+	for _, k := range slices.Sorted(maps.Keys(e.Details)) {
+		field(k, e.Details[k])
+	}
+	if len(e.Patch) > 0 {
+		fmt.Fprintln(out, "Patch:")
+		for _, line := range audit.FormatPatch(e.Patch) {
+			fmt.Fprintln(out, "  "+line)
+		}
+	}
+}
+
+// writeAuditJSON writes v as indented JSON.
+func writeAuditJSON(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return kairoerrors.WrapError(kairoerrors.RuntimeError, "failed to encode audit entry", err)
+	}
+
+	return nil
+}
+
+// printAuditEntries prints the last limit entries as a table, with ages
+// relative to now. Entries are numbered from the start of the log, as
+// 'kairo audit show' counts them.
+func printAuditEntries(out io.Writer, entries []audit.Entry, limit int, now time.Time) {
+	first := 0
+	if limit > 0 && len(entries) > limit {
+		first = len(entries) - limit
+	}
+
+	fmt.Fprintf(out, "%5s  %-23s  %-9s  %-7s  %-12s  %s\n", "#", "TIME", "AGE", "EVENT", "PROVIDER", "DETAIL")
+	for i, e := range entries[first:] {
+		fmt.Fprintf(out, "%5d  %-23s  %-9s  %-7s  %-12s  %s\n", first+i+1,
+			ui.FormatTime(e.Timestamp, utcFlag), ui.RelativeTime(e.Timestamp, now),
+			e.Event, e.Provider, auditDetail(e))
+	}
+}
+
+// printAuditHints prints the hints audit.Analyze finds in entries, judging
+// hours of the day in local time unless --utc is set.
+func printAuditHints(out io.Writer, entries []audit.Entry) {
+	loc := time.Local
+	if utcFlag {
+		loc = time.UTC
+	}
+	hints := audit.Analyze(entries, loc)
+	if !slices.ContainsFunc(entries, func(e audit.Entry) bool { return e.Hostname != "" }) {
+		ui.PrintInfo("Entries carry no host or user; set 'audit.level: verbose' to check for new ones")
+	}
+	if len(hints) == 0 {
+		ui.PrintInfo(fmt.Sprintf("No unusual activity in %d audit entries", len(entries)))
+
+		return
+	}
+
+	fmt.Fprintf(out, "%-23s  %-8s  %s\n", "TIME", "HINT", "DETAIL")
+	for _, h := range hints {
+		fmt.Fprintf(out, "%-23s  %-8s  %s\n", ui.FormatTime(h.Time, utcFlag), h.Kind, h.Message)
+	}
+}
+
+// auditDetail joins the action and sorted details of e into one line.
+func auditDetail(e audit.Entry) string {
+	var parts []string
+	if e.Action != "" {
+		parts = append(parts, e.Action)
+	}
+	for _, k := range slices.Sorted(maps.Keys(e.Details)) {
+		parts = append(parts, k+"="+e.Details[k])
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// auditPolicy returns the audit policy configured in cfg, or false when
+// auditing is disabled.
+func auditPolicy(cfg *config.Config) (audit.Policy, bool, error) {
+	if cfg == nil || cfg.Audit == nil || !cfg.Audit.Enabled {
+		return audit.Policy{}, false, nil
+	}
+
+	policy, err := audit.ParsePolicy(cfg.Audit.Events, cfg.Audit.Level)
+	if err != nil {
+		return audit.Policy{}, false, err
+	}
+	policy.Mask, err = audit.NewMasker(cfg.Audit.Mask, cfg.Audit.MaskKeys, cfg.Audit.MaskPatterns)
+	if err != nil {
+		return audit.Policy{}, false, err
+	}
+
+	return policy, true, nil
+}
+
+// loadAuditEntries reads the audit log of dir, decrypting encrypted entries
+// with the audit key when it exists. Entries it cannot decrypt are left out
+// with a warning.
+func loadAuditEntries(cliCtx *CLIContext, dir string) ([]audit.Entry, error) {
+	identity, err := auditIdentity(dir)
+	if err != nil {
+		ui.PrintWarn("Encrypted audit entries cannot be read: " + kairoerrors.Describe(err))
+	}
+	entries, locked, err := audit.Read(stateDir(cliCtx, dir), identity)
+	if err != nil {
+		return nil, err
+	}
+	if locked > 0 {
+		ui.PrintWarn(fmt.Sprintf("%d encrypted audit entries could not be decrypted with %s", locked,
+			filepath.Join(dir, audit.KeyFileName)))
+	}
+
+	return entries, nil
+}
+
+// auditIdentity returns the audit key of dir, or nil when none was created.
+func auditIdentity(dir string) (age.Identity, error) {
+	key, err := readAuditKey(filepath.Join(dir, audit.KeyFileName))
+	if err != nil || key == nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// auditRecipient returns the recipient entries are encrypted to, creating
+// the audit key on first use.
+func auditRecipient(cliCtx *CLIContext, dir string) (age.Recipient, error) {
+	path := filepath.Join(dir, audit.KeyFileName)
+	key, err := readAuditKey(path)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		// Another process may create it first; its key is then used.
+		if err := crypto.GenerateKey(cliCtx.RootCtx(), path); err != nil && !errors.Is(err, kairoerrors.ErrKeyExists) {
+			return nil, err
+		}
+		if key, err = readAuditKey(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return key.Recipient(), nil
+}
+
+// readAuditKey parses the audit key at path, or returns nil when there is no
+// such file.
+func readAuditKey(path string) (*age.X25519Identity, error) {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	data, err := crypto.ReadKeyFile(path, func() (string, error) {
+		return "", kairoerrors.NewError(kairoerrors.CryptoError, "the audit key cannot be passphrase-protected").
+			WithContext("path", path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.ClearMemory(data)
+
+	return data.Identity()
+}
+
+// recordAudit appends e to the audit log of dir when auditing is enabled in
+// the config, then prunes the log when a retention period is configured.
+// Failures are reported as warnings and never abort the command.
+func recordAudit(cliCtx *CLIContext, dir string, e audit.Entry) {
+	if cliCtx == nil || dir == "" {
+		return
+	}
+
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil {
+		return
+	}
+
+	policy, enabled, err := auditPolicy(cfg)
+	if err != nil {
+		ui.PrintWarn(fmt.Sprintf("Audit log disabled: %v", err))
+
+		return
+	}
+	if !enabled {
+		return
+	}
+
+	if cfg.Audit.Encrypt {
+		if policy.Recipient, err = auditRecipient(cliCtx, dir); err != nil {
+			ui.PrintWarn(fmt.Sprintf("Could not write audit log: %v", kairoerrors.Describe(err)))
+
+			return
+		}
+	}
+
+	logDir := stateDir(cliCtx, dir)
+	if err := audit.NewLogger(logDir, policy).Log(e); err != nil {
+		ui.PrintWarn(fmt.Sprintf("Could not write audit log: %v", err))
+
+		return
+	}
+
+	if cfg.Audit.Retention == "" {
+		return
+	}
+	retention, err := audit.ParseRetention(cfg.Audit.Retention)
+	if err != nil {
+		ui.PrintWarn(fmt.Sprintf("Audit log not pruned: %v", err))
+
+		return
+	}
+	now := time.Now()
+	identity, _ := auditIdentity(dir)
+	if _, err := audit.Prune(logDir, now.Add(-retention), now, identity); err != nil {
+		ui.PrintWarn(fmt.Sprintf("Could not prune audit log: %v", err))
+	}
+}
+
+// providerPatch returns the JSON patch of provider name changing from before
+// to after, where nil stands for no entry. The patch is only informative, so
+// a value that cannot be encoded leaves it out.
+func providerPatch(name string, before, after *config.Provider) []audit.PatchOp {
+	var b, a any
+	if before != nil {
+		b = before
+	}
+	if after != nil {
+		a = after
+	}
+	ops, err := audit.Diff("/providers/"+audit.PointerToken(name), b, a)
+	if err != nil {
+		return nil
+	}
+
+	return ops
+}
+
+// recordSwitch audits a provider switch about to run the harness, noting
+// harness binary upgrades since the previous switch.
+func recordSwitch(cfg *ExecutionConfig) {
+	cliCtx := CLIContextFromCmd(cfg.Cmd)
+	if cliCtx == nil {
+		return
+	}
+	dir := cliCtx.ConfigDir()
+
+	details := map[string]string{
+		"harness": cfg.HarnessToUse,
+		"model":   cfg.Provider.Model,
+	}
+	maps.Copy(details, observeHarnessUpdate(cfg, dir))
+
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:    audit.EventSwitch,
+		Provider: cfg.ProviderName,
+		Details:  details,
+	})
+}
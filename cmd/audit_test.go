@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+)
+
+func TestRecordAudit(t *testing.T) {
+	tests := []struct {
+		name        string
+		audit       *config.AuditConfig
+		wantEntries int
+	}{
+		{"not configured", nil, 0},
+		{"disabled", &config.AuditConfig{Enabled: false}, 0},
+		{"all events", &config.AuditConfig{Enabled: true}, 2},
+		{"filtered", &config.AuditConfig{Enabled: true, Events: []string{"rotate"}}, 1},
+		{"invalid level", &config.AuditConfig{Enabled: true, Level: "loud"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := &config.Config{Providers: map[string]config.Provider{"zai": {Name: "Z.AI"}}, Audit: tt.audit}
+			if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+				t.Fatal(err)
+			}
+
+			cliCtx := NewCLIContext()
+			recordAudit(cliCtx, dir, audit.Entry{Event: audit.EventSwitch, Provider: "zai"})
+			recordAudit(cliCtx, dir, audit.Entry{Event: audit.EventRotate, Action: "reset_secrets"})
+
+			entries, err := audit.LoadEntries(dir)
+			if err != nil {
+				t.Fatalf("LoadEntries() error = %v", err)
+			}
+			if len(entries) != tt.wantEntries {
+				t.Errorf("len(entries) = %d, want %d", len(entries), tt.wantEntries)
+			}
+		})
+	}
+}
+
+func TestRecordAuditMinimalLevel(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Providers: map[string]config.Provider{},
+		Audit:     &config.AuditConfig{Enabled: true, Level: "minimal"},
+	}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	recordAudit(NewCLIContext(), dir, audit.Entry{
+		Event:   audit.EventConfig,
+		Action:  "set_harness",
+		Details: map[string]string{"harness": "qwen"},
+	})
+
+	entries, err := audit.LoadEntries(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("LoadEntries() = %v, %v; want one entry", entries, err)
+	}
+	if entries[0].Details != nil || entries[0].Action != "set_harness" {
+		t.Errorf("entry = %+v, want action without details", entries[0])
+	}
+}
+
+func TestRecordAuditPrunesWithRetention(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Providers: map[string]config.Provider{},
+		Audit:     &config.AuditConfig{Enabled: true, Retention: "30d"},
+	}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	cliCtx := NewCLIContext()
+	recordAudit(cliCtx, dir, audit.Entry{Timestamp: time.Now().AddDate(0, 0, -60), Event: audit.EventSwitch})
+	recordAudit(cliCtx, dir, audit.Entry{Event: audit.EventSwitch, Provider: "zai"})
+
+	entries, err := audit.LoadEntries(dir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("LoadEntries() = %v, %v; want checkpoint and new entry", entries, err)
+	}
+	if entries[0].Event != audit.EventPrune || entries[1].Provider != "zai" {
+		t.Errorf("entries = %+v, want old entry replaced by a checkpoint", entries)
+	}
+}
+
+func TestRecordAuditEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Providers: map[string]config.Provider{},
+		Audit:     &config.AuditConfig{Enabled: true, Encrypt: true, Retention: "30d"},
+	}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	cliCtx := NewCLIContext()
+	recordAudit(cliCtx, dir, audit.Entry{Timestamp: time.Now().AddDate(0, 0, -60), Event: audit.EventSwitch})
+	recordAudit(cliCtx, dir, audit.Entry{Event: audit.EventSwitch, Provider: "zai"})
+
+	if _, err := os.Stat(filepath.Join(dir, audit.KeyFileName)); err != nil {
+		t.Fatalf("audit key not created: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, audit.LogFileName))
+	if err != nil || strings.Contains(string(data), "zai") {
+		t.Fatalf("audit log = %q, %v; want the entry encrypted", data, err)
+	}
+	if entries, _ := audit.LoadEntries(dir); len(entries) != 1 || entries[0].Event != audit.EventPrune {
+		t.Errorf("LoadEntries() without key = %+v, want only the checkpoint", entries)
+	}
+
+	entries, err := loadAuditEntries(cliCtx, dir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("loadAuditEntries() = %+v, %v; want checkpoint and new entry", entries, err)
+	}
+	if entries[0].Event != audit.EventPrune || entries[1].Provider != "zai" {
+		t.Errorf("entries = %+v, want old entry pruned and new one decrypted", entries)
+	}
+}
+
+func TestPrintAuditEntries(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := []audit.Entry{
+		{Timestamp: now.Add(-48 * time.Hour), Event: audit.EventConfig, Action: "set_default", Provider: "kimi"},
+		{
+			Timestamp: now.Add(-2 * time.Hour),
+			Event:     audit.EventSwitch,
+			Provider:  "zai",
+			Details:   map[string]string{"model": "glm-5.1", "harness": "claude"},
+		},
+	}
+
+	originalUTC := utcFlag
+	utcFlag = true
+	defer func() { utcFlag = originalUTC }()
+
+	buf := new(bytes.Buffer)
+	printAuditEntries(buf, entries, 1, now)
+	out := buf.String()
+
+	if strings.Contains(out, "kimi") {
+		t.Errorf("limit not applied:\n%s", out)
+	}
+	for _, want := range []string{"    2  2026-03-01 10:00:00 UTC", "2h ago", "harness=claude model=glm-5.1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunAuditShow(t *testing.T) {
+	defer func() { auditShowPatch, auditShowFormat = false, "text" }()
+	t.Setenv("KAIRO_STATE_DIR", "")
+
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, "audit:\n  enabled: true\nproviders: {}\n")
+	cmd, out := explainTestCmd(t, dir)
+	cliCtx := CLIContextFromCmd(cmd)
+	for _, model := range []string{"glm-4.6", "glm-4.7"} {
+		cfg, err := LoadConfig(cliCtx, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := AddAndSaveProvider(AddProviderParams{
+			CLIContext: cliCtx, ConfigDir: dir, Cfg: cfg, ProviderName: "zai",
+			Provider: config.Provider{Name: "Z.AI", BaseURL: "https://api.z.ai/api/anthropic", Model: model},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	auditShowFormat = "text"
+	if err := runAuditShow(cmd, "2"); err != nil {
+		t.Fatalf("runAuditShow() error = %v", err)
+	}
+	for _, want := range []string{"Entry:    2", "Action:   save_provider", "model:    glm-4.7",
+		`  ~ /providers/zai/model: "glm-4.6" -> "glm-4.7"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	auditShowPatch, auditShowFormat = true, "json"
+	if err := runAuditShow(cmd, "2"); err != nil {
+		t.Fatalf("runAuditShow() --patch error = %v", err)
+	}
+	var ops []audit.PatchOp
+	if err := json.Unmarshal(out.Bytes(), &ops); err != nil {
+		t.Fatalf("--patch --format json output is not a patch: %v\n%s", err, out.String())
+	}
+	if len(ops) != 2 || ops[0].Op != audit.OpTest || ops[1].Op != audit.OpReplace || ops[1].Value != "glm-4.7" {
+		t.Errorf("patch = %+v", ops)
+	}
+
+	for _, arg := range []string{"0", "3", "two"} {
+		if err := runAuditShow(cmd, arg); err == nil || !strings.Contains(err.Error(), "no audit entry") {
+			t.Errorf("runAuditShow(%q) error = %v", arg, err)
+		}
+	}
+	auditShowFormat = "yaml"
+	if err := runAuditShow(cmd, "1"); err == nil {
+		t.Error("runAuditShow() with an unknown format succeeded")
+	}
+}
+
+func TestProviderPatch(t *testing.T) {
+	p := config.Provider{Name: "Z.AI", Model: "glm-4.7"}
+	if ops := providerPatch("zai", &p, &p); ops != nil {
+		t.Errorf("providerPatch() of an unchanged provider = %+v", ops)
+	}
+	ops := providerPatch("a/b", &p, nil)
+	if len(ops) != 2 || ops[1].Op != audit.OpRemove || ops[1].Path != "/providers/a~1b" {
+		t.Errorf("providerPatch() of a deleted provider = %+v", ops)
+	}
+}
+
+func TestPrintAuditHints(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var entries []audit.Entry
+	for i := range 3 {
+		entries = append(entries, audit.Entry{
+			Timestamp: at.Add(time.Duration(i) * time.Minute),
+			Event:     audit.EventWarning,
+			Action:    "refuse_base_url",
+		})
+	}
+
+	originalUTC := utcFlag
+	utcFlag = true
+	defer func() { utcFlag = originalUTC }()
+
+	buf := new(bytes.Buffer)
+	printAuditHints(buf, entries)
+	out := buf.String()
+
+	for _, want := range []string{"2026-03-01 12:00:00 UTC", "failures", "3 failures in 2m0s: refuse_base_url x3"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
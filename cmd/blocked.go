@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsperm"
+)
+
+// blockedMessage describes an operation refused although the file system
+// permissions allow it, as endpoint security (EDR) software on managed
+// machines does.
+const blockedMessage = "blocked by endpoint security or another system policy"
+
+// tempDirBlockedHint is the next step when the auth directory, or starting the
+// harness through files in it, is blocked. Many EDR products refuse scripts
+// and token reads in the system temp directory but allow them elsewhere.
+const tempDirBlockedHint = "set temp_dir in config.yaml to a directory your security software allows, " +
+	"such as ~/.kairo/tmp, or ask your IT team to allow kairo there"
+
+// blockedError wraps err, an operation in dir that fsperm.Blocked refused,
+// under msg with hint as the next step.
+func blockedError(msg, dir, hint string, err error) *kairoerrors.KairoError {
+	return kairoerrors.WrapError(kairoerrors.FileSystemError, msg,
+		kairoerrors.WrapError(kairoerrors.FileSystemError, blockedMessage, err).
+			WithContext("path", dir).
+			WithContext("hint", hint))
+}
+
+// authDirError wraps err from creating the auth directory in dir, or from
+// writing to or starting the harness from the auth directory dir.
+func authDirError(msg, dir string, err error) *kairoerrors.KairoError {
+	if fsperm.Blocked(dir, err) {
+		return blockedError(msg, dir, tempDirBlockedHint, err)
+	}
+
+	return kairoerrors.WrapError(kairoerrors.FileSystemError, msg, err).
+		WithContext("hint", "check that the temp directory ("+dir+") is writable")
+}
+
+// diagnoseBlocked returns err with the endpoint security diagnostic when a
+// file operation in its chain failed in a directory whose permissions allow
+// it, so the user is not told to fix permissions that are already right. It
+// returns err unchanged otherwise.
+func diagnoseBlocked(err error) error {
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || isBlockedError(err) {
+		return err
+	}
+	dir := filepath.Dir(pathErr.Path)
+	if !fsperm.Blocked(dir, pathErr) {
+		return err
+	}
+
+	return kairoerrors.WrapError(kairoerrors.FileSystemError, kairoerrors.Describe(err),
+		kairoerrors.WrapError(kairoerrors.FileSystemError, blockedMessage, nil).
+			WithContext("path", dir).
+			WithContext("hint", writeBlockedHint(dir)))
+}
+
+// writeBlockedHint is the next step when writes to dir, outside the auth
+// directory, are blocked.
+func writeBlockedHint(dir string) string {
+	return "ask your IT team to allow kairo to write to " + dir +
+		", or move kairo's files with KAIRO_CONFIG_DIR, state_dir, or temp_dir"
+}
+
+func isBlockedError(err error) bool {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if ke, ok := e.(*kairoerrors.KairoError); ok && ke.Message == blockedMessage {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+)
+
+func TestAuthDirError(t *testing.T) {
+	if runtime.GOOS == constants.WindowsGOOS {
+		t.Skip("Windows reports blocked writes with its own error codes")
+	}
+	dir := t.TempDir()
+	denied := &os.PathError{Op: "mkdir", Path: dir + "/kairo-auth-1", Err: syscall.EACCES}
+
+	err := authDirError("Error creating auth directory", dir, denied)
+	if got := kairoerrors.Describe(err); !strings.Contains(got, blockedMessage) {
+		t.Errorf("authDirError() = %q, want the endpoint security diagnostic", got)
+	}
+	if got := kairoerrors.Suggest(err); got != tempDirBlockedHint {
+		t.Errorf("authDirError() hint = %q, want %q", got, tempDirBlockedHint)
+	}
+
+	full := &os.PathError{Op: "mkdir", Path: dir + "/kairo-auth-1", Err: syscall.ENOSPC}
+	err = authDirError("Error creating auth directory", dir, full)
+	if got := kairoerrors.Describe(err); strings.Contains(got, blockedMessage) {
+		t.Errorf("authDirError() for a full disk = %q, want no endpoint security diagnostic", got)
+	}
+	if got := kairoerrors.Suggest(err); !strings.Contains(got, dir) {
+		t.Errorf("authDirError() hint = %q, want the temp directory", got)
+	}
+}
+
+func TestDiagnoseBlocked(t *testing.T) {
+	if runtime.GOOS == constants.WindowsGOOS {
+		t.Skip("Windows reports blocked writes with its own error codes")
+	}
+	dir := t.TempDir()
+	denied := &os.PathError{Op: "open", Path: dir + "/config.yaml", Err: syscall.EACCES}
+	err := diagnoseBlocked(kairoerrors.FileError("failed to write config", dir+"/config.yaml", denied))
+
+	if got := kairoerrors.Describe(err); !strings.Contains(got, "failed to write config") ||
+		!strings.Contains(got, blockedMessage) {
+		t.Errorf("diagnoseBlocked() = %q, want the original error and the diagnostic", got)
+	}
+	if got := kairoerrors.Suggest(err); got != writeBlockedHint(dir) {
+		t.Errorf("diagnoseBlocked() hint = %q, want %q", got, writeBlockedHint(dir))
+	}
+	if again := diagnoseBlocked(err); again != err {
+		t.Errorf("diagnoseBlocked() wrapped a diagnosed error again: %v", again)
+	}
+
+	notFound := &os.PathError{Op: "open", Path: dir + "/config.yaml", Err: syscall.ENOENT}
+	if got := diagnoseBlocked(notFound); got != error(notFound) {
+		t.Errorf("diagnoseBlocked() = %v, want a missing file unchanged", got)
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/wrapper"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanOlderThan time.Duration
+	cleanDryRun    bool
+)
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove temporary auth directories left by killed kairo processes",
+	Long: `Remove kairo-auth-* directories from the temp directory, or temp_dir when
+it is set in config.yaml, that were left behind when kairo was killed before
+it could clean up. Only directories owned by the current user, older than
+--older-than, and not belonging to a running kairo process are removed. The
+same cleanup runs automatically before each harness launch.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runClean(cmd, cleanOlderThan, cleanDryRun); err != nil {
+			printError(err)
+			CLIContextFromCmd(cmd).Deps().Process.ExitProcess(1)
+		}
+	},
+}
+
+func init() {
+	cleanCmd.Flags().DurationVar(&cleanOlderThan, "older-than", wrapper.DefaultStaleAuthDirAge,
+		"Only remove directories older than this")
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "List stale directories without removing them")
+	rootCmd.AddCommand(cleanCmd)
+}
+
+// runClean removes stale auth directories and prints each one on stdout.
+func runClean(cmd *cobra.Command, olderThan time.Duration, dryRun bool) error {
+	if olderThan < 0 {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "--older-than must not be negative")
+	}
+
+	cliCtx := CLIContextFromCmd(cmd)
+	dirs, err := cliCtx.Deps().Wrapper.CleanStaleAuthDirs(configuredTempDir(cliCtx), olderThan, dryRun)
+	for _, dir := range dirs {
+		fmt.Fprintln(cmd.OutOrStdout(), dir)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(dirs) == 0:
+		ui.PrintInfo("No stale auth directories found")
+	case dryRun:
+		ui.PrintInfo(fmt.Sprintf("Would remove %d stale auth directories", len(dirs)))
+	default:
+		ui.PrintSuccess(fmt.Sprintf("Removed %d stale auth directories", len(dirs)))
+	}
+
+	return nil
+}
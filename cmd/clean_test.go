@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRunClean(t *testing.T) {
+	var gotAge time.Duration
+	var gotDryRun bool
+	d := testDeps(func(_ *mockProcess, mw *mockWrapper, _ *mockUpdate) {
+		mw.CleanStaleAuthDirsFn = func(_ string, maxAge time.Duration, dryRun bool) ([]string, error) {
+			gotAge, gotDryRun = maxAge, dryRun
+
+			return []string{"/tmp/kairo-auth-123"}, nil
+		}
+	})
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(d)
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	if err := runClean(cmd, time.Hour, true); err != nil {
+		t.Fatalf("runClean() error = %v", err)
+	}
+	if gotAge != time.Hour || !gotDryRun {
+		t.Errorf("CleanStaleAuthDirs(%v, %v), want (1h, true)", gotAge, gotDryRun)
+	}
+	if !strings.Contains(buf.String(), "/tmp/kairo-auth-123") {
+		t.Errorf("output missing directory:\n%s", buf.String())
+	}
+
+	if err := runClean(cmd, -time.Minute, false); err == nil {
+		t.Error("runClean() expected error for a negative --older-than")
+	}
+}
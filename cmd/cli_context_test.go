@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
 )
 
 func TestCLIContextAccessors(t *testing.T) {
@@ -42,3 +47,35 @@ func TestCLIContextAccessors(t *testing.T) {
 		}
 	})
 }
+
+func TestCLIContextApplyTimeout(t *testing.T) {
+	t.Run("cancels the root context with the timeout cause", func(t *testing.T) {
+		cliCtx := NewCLIContext()
+		defer cliCtx.Close()
+
+		cliCtx.ApplyTimeout(10 * time.Millisecond)
+		select {
+		case <-cliCtx.RootCtx().Done():
+		case <-time.After(time.Second):
+			t.Fatal("root context not canceled after the timeout")
+		}
+		if !errors.Is(context.Cause(cliCtx.RootCtx()), errCommandTimeout) {
+			t.Errorf("cause = %v, want errCommandTimeout", context.Cause(cliCtx.RootCtx()))
+		}
+	})
+
+	t.Run("disarmed timeout leaves the harness session running", func(t *testing.T) {
+		cliCtx := NewCLIContext()
+		defer cliCtx.Close()
+
+		cliCtx.ApplyTimeout(20 * time.Millisecond)
+		cmd := &cobra.Command{}
+		cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+		ctx := harnessSessionContext(cmd)
+		time.Sleep(50 * time.Millisecond)
+		if err := ctx.Err(); err != nil {
+			t.Errorf("harness session context canceled by --timeout: %v", err)
+		}
+	})
+}
@@ -0,0 +1,330 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dkmnx/kairo/internal/compare"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// compareRequestTimeout bounds each provider's reply. Generating a long
+	// answer takes far longer than the health probe's request timeout.
+	compareRequestTimeout = 5 * time.Minute
+	// compareMaxPromptSize bounds the prompt file.
+	compareMaxPromptSize = 1024 * 1024
+	// compareMinColumn is the narrowest column replies are laid out in side
+	// by side; below it they are printed one after another.
+	compareMinColumn = 30
+	// compareDefaultWidth is the output width when --width is not set and
+	// COLUMNS is not exported.
+	compareDefaultWidth = 120
+)
+
+var (
+	compareProviders  []string
+	comparePromptFile string
+	compareMaxTokens  int
+	compareWidth      int
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare --providers <a,b,...> --prompt-file <file>",
+	Short: "Send one prompt to several providers and compare the replies",
+	Long: `Send the same prompt to each provider's Messages API at once, without
+starting a harness, and print the replies side by side with how long each
+took and how many input and output tokens it used, and the request ID and
+rate limits each provider reported in its response headers, which help with
+quota errors and support tickets.
+
+Each provider answers with its configured model in a single turn, limited to
+--max-tokens output tokens; a reply cut off by the limit is marked as
+truncated. Replies are laid out in columns that fit --width (default
+$COLUMNS, or 120), and printed one after another when the columns would be
+too narrow. Use '-' as the prompt file to read the prompt from stdin.`,
+	Example: `  kairo compare --providers zai,deepseek --prompt-file prompt.txt
+  echo "Explain CRDTs in one paragraph" | kairo compare --providers zai,minimax --prompt-file -`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runCompare(cmd); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	compareCmd.Flags().StringSliceVar(&compareProviders, "providers", nil, "Providers to send the prompt to (required)")
+	compareCmd.Flags().StringVar(&comparePromptFile, "prompt-file", "", "File holding the prompt, or - for stdin (required)")
+	compareCmd.Flags().IntVar(&compareMaxTokens, "max-tokens", 1024, "Output token limit for each reply")
+	compareCmd.Flags().IntVar(&compareWidth, "width", 0, "Output width in columns (default $COLUMNS, or 120)")
+	rootCmd.AddCommand(compareCmd)
+}
+
+func runCompare(cmd *cobra.Command) error {
+	if len(compareProviders) == 0 {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "--providers is required").
+			WithContext("hint", "name the providers to compare, e.g. --providers zai,deepseek")
+	}
+	if comparePromptFile == "" {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "--prompt-file is required")
+	}
+	if compareMaxTokens < 1 {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "--max-tokens must be at least 1")
+	}
+
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil {
+		if stderrors.Is(err, kairoerrors.ErrConfigNotFound) {
+			printNoProvidersMessage()
+
+			return errReported
+		}
+
+		return err
+	}
+	for _, name := range compareProviders {
+		provider, ok := cfg.Providers[name]
+		if !ok {
+			return kairoerrors.NewError(kairoerrors.ProviderError,
+				fmt.Sprintf("provider '%s' not configured", name)).
+				WithContext("hint", "run 'kairo list' to see configured providers")
+		}
+		if provider.Disabled {
+			return disabledProviderError(name)
+		}
+	}
+
+	prompt, err := readComparePrompt(cmd)
+	if err != nil {
+		return err
+	}
+
+	secretsResult, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+	resolver := newSecretResolver(cliCtx)
+
+	// Providers whose key cannot be resolved are reported in place of a
+	// reply rather than failing the whole comparison.
+	results := make([]compare.Result, len(compareProviders))
+	var targets []compare.Target
+	var slots []int
+	for i, name := range compareProviders {
+		provider := cfg.Providers[name]
+		results[i] = compare.Result{Provider: name, Model: provider.Model}
+
+		apiKey := ""
+		if providers.RequiresAPIKey(name) {
+			key, found, err := resolver.resolveAPIKey(secretsResult.Secrets, name)
+			switch {
+			case err != nil:
+				results[i].Err = stderrors.New(kairoerrors.Describe(err))
+
+				continue
+			case !found:
+				results[i].Err = fmt.Errorf("no API key stored; run 'kairo secrets set %s'", name)
+
+				continue
+			}
+			apiKey = key
+			notifySecretAccess(cliCtx, dir, name, accessPurposeCompare)
+		}
+
+		targets = append(targets, compare.Target{
+			Provider: name,
+			BaseURL:  provider.BaseURL,
+			APIKey:   apiKey,
+			Style:    providerAuthStyle(name, provider),
+			Model:    provider.Model,
+		})
+		slots = append(slots, i)
+	}
+
+	ui.PrintInfo(fmt.Sprintf("Sending the prompt to %s...", strings.Join(compareProviders, ", ")))
+	client := &http.Client{Timeout: compareRequestTimeout}
+	for j, res := range compare.Run(cliCtx.RootCtx(), client, targets, prompt, compareMaxTokens) {
+		results[slots[j]] = res
+	}
+
+	printComparison(cmd.OutOrStdout(), results, compareOutputWidth())
+
+	return nil
+}
+
+// readComparePrompt reads --prompt-file, or stdin when it is "-".
+func readComparePrompt(cmd *cobra.Command) (string, error) {
+	var r io.Reader
+	if comparePromptFile == "-" {
+		r = cmd.InOrStdin()
+	} else {
+		f, err := os.Open(comparePromptFile)
+		if err != nil {
+			return "", kairoerrors.FileError("failed to read prompt file", comparePromptFile, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, compareMaxPromptSize+1))
+	if err != nil {
+		return "", kairoerrors.FileError("failed to read prompt file", comparePromptFile, err)
+	}
+	if len(data) > compareMaxPromptSize {
+		return "", kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("prompt is larger than %d bytes", compareMaxPromptSize))
+	}
+	prompt := strings.TrimSpace(string(data))
+	if prompt == "" {
+		return "", kairoerrors.NewError(kairoerrors.ValidationError, "prompt is empty")
+	}
+
+	return prompt, nil
+}
+
+// compareOutputWidth returns --width, then $COLUMNS, then the default.
+func compareOutputWidth() int {
+	if compareWidth > 0 {
+		return compareWidth
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+
+	return compareDefaultWidth
+}
+
+// compareHeading names a result's provider and model.
+func compareHeading(res compare.Result) string {
+	if res.Model == "" {
+		return res.Provider
+	}
+
+	return res.Provider + " (" + res.Model + ")"
+}
+
+// compareMetrics summarizes a result's timing and token usage, or its error.
+func compareMetrics(res compare.Result) string {
+	switch {
+	case res.Err != nil && res.Latency == 0:
+		return "✗ not sent"
+	case res.Err != nil:
+		return "✗ failed after " + formatLatency(res.Latency)
+	}
+	metrics := fmt.Sprintf("%s · %d in / %d out tokens", formatLatency(res.Latency), res.InputTokens, res.OutputTokens)
+	if res.StopReason == "max_tokens" {
+		metrics += " · truncated"
+	}
+
+	return metrics
+}
+
+// compareQuota describes the request ID and rate limits of a result's
+// reply, or is empty when the provider sent none.
+func compareQuota(res compare.Result) string {
+	return res.Quota.Summary(time.Now())
+}
+
+// compareBody returns the reply text of a result, or its error.
+func compareBody(res compare.Result) string {
+	if res.Err != nil {
+		return res.Err.Error()
+	}
+
+	return res.Text
+}
+
+// printComparison lays results out in columns that fit width, or one after
+// another when the columns would be narrower than compareMinColumn.
+func printComparison(out io.Writer, results []compare.Result, width int) {
+	const sep = " │ "
+	n := len(results)
+	col := (width - (n-1)*utf8.RuneCountInString(sep)) / n
+	if col < compareMinColumn {
+		for i, res := range results {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "== %s — %s\n", compareHeading(res), compareMetrics(res))
+			if quota := compareQuota(res); quota != "" {
+				fmt.Fprintln(out, quota)
+			}
+			for _, line := range wrapText(compareBody(res), max(width, compareMinColumn)) {
+				fmt.Fprintln(out, line)
+			}
+		}
+
+		return
+	}
+
+	columns := make([][]string, n)
+	rows := 0
+	for i, res := range results {
+		columns[i] = append(wrapText(compareHeading(res), col), wrapText(compareMetrics(res), col)...)
+		if quota := compareQuota(res); quota != "" {
+			columns[i] = append(columns[i], wrapText(quota, col)...)
+		}
+		columns[i] = append(columns[i], strings.Repeat("─", col))
+		columns[i] = append(columns[i], wrapText(compareBody(res), col)...)
+		rows = max(rows, len(columns[i]))
+	}
+	for r := 0; r < rows; r++ {
+		cells := make([]string, n)
+		for i := range columns {
+			cell := ""
+			if r < len(columns[i]) {
+				cell = columns[i][r]
+			}
+			cells[i] = cell + strings.Repeat(" ", col-utf8.RuneCountInString(cell))
+		}
+		fmt.Fprintln(out, strings.TrimRight(strings.Join(cells, sep), " "))
+	}
+}
+
+// wrapText breaks s into lines of at most width runes at spaces, keeping
+// its line breaks and splitting words longer than a line.
+func wrapText(s string, width int) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			for utf8.RuneCountInString(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				runes := []rune(word)
+				lines = append(lines, string(runes[:width]))
+				word = string(runes[width:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/compare"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/mockprovider"
+)
+
+func TestRunCompare(t *testing.T) {
+	defer func() { compareProviders, comparePromptFile, compareWidth = nil, "", 0 }()
+
+	srv := httptest.NewServer(mockprovider.NewHandler(mockprovider.Options{Reply: "Four, as always."}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, "providers:\n"+
+		"  zai:\n    name: Z.AI\n    base_url: "+srv.URL+"\n    model: glm-4.7\n"+
+		"  deepseek:\n    name: DeepSeek\n    base_url: "+srv.URL+"\n    model: deepseek-chat\n")
+	cmd, out := explainTestCmd(t, dir)
+	cliCtx := CLIContextFromCmd(cmd)
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		t.Fatal(err)
+	}
+	err := SaveSecrets(cliCtx, filepath.Join(dir, constants.SecretsFileName), filepath.Join(dir, constants.KeyFileName),
+		map[string]string{"ZAI_API_KEY": "sk-zai-test-key-abcdefghijklmnopqrst"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comparePromptFile = filepath.Join(dir, "prompt.txt")
+	if err := os.WriteFile(comparePromptFile, []byte("What is 2+2?\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	compareProviders, compareWidth = []string{"zai", "deepseek"}, 100
+	if err := runCompare(cmd); err != nil {
+		t.Fatalf("runCompare() error = %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{"zai (glm-4.7)", "deepseek (deepseek-chat)", "1 in / 3 out tokens",
+		"Four, as always.", "no API key stored"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+
+	compareProviders = []string{"zai", "kimi"}
+	if err := runCompare(cmd); err == nil || !strings.Contains(err.Error(), "'kimi' not configured") {
+		t.Errorf("runCompare() with an unconfigured provider error = %v", err)
+	}
+	compareProviders, comparePromptFile = []string{"zai"}, filepath.Join(dir, "missing.txt")
+	if err := runCompare(cmd); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("runCompare() with a missing prompt file error = %v", err)
+	}
+}
+
+func TestPrintComparison(t *testing.T) {
+	results := []compare.Result{
+		{Provider: "zai", Model: "glm-4.7", Text: "one two three four five six seven eight nine ten eleven twelve",
+			InputTokens: 5, OutputTokens: 12, StopReason: "max_tokens"},
+		{Provider: "minimax", Err: errors.New("HTTP 529: Overloaded"),
+			Quota: health.Quota{RequestID: "req_mock_000002", RetryAfter: "1"}},
+	}
+
+	var side strings.Builder
+	printComparison(&side, results, 70)
+	lines := strings.Split(strings.TrimRight(side.String(), "\n"), "\n")
+	if !strings.HasPrefix(lines[0], "zai (glm-4.7)") || !strings.Contains(lines[0], " │ minimax") {
+		t.Errorf("first line = %q, want both headings side by side", lines[0])
+	}
+	if !strings.Contains(side.String(), "truncated") || !strings.Contains(side.String(), "HTTP 529: Overloaded") {
+		t.Errorf("side-by-side output:\n%s", side.String())
+	}
+
+	var stacked strings.Builder
+	printComparison(&stacked, results, 40)
+	if !strings.HasPrefix(stacked.String(), "== zai (glm-4.7) — ") || !strings.Contains(stacked.String(), "\n== minimax — ✗") {
+		t.Errorf("stacked output:\n%s", stacked.String())
+	}
+	if !strings.Contains(side.String(), "│ request req_mock_000002, retry") {
+		t.Errorf("side-by-side quota line missing:\n%s", side.String())
+	}
+	if !strings.Contains(stacked.String(), "\nrequest req_mock_000002, retry after 1s\n") {
+		t.Errorf("stacked quota line missing:\n%s", stacked.String())
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("alpha beta gamma\n\nabcdefghijkl", 10)
+	want := []string{"alpha beta", "gamma", "", "abcdefghij", "kl"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("wrapText() = %q, want %q", got, want)
+	}
+}
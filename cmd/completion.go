@@ -4,8 +4,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/dkmnx/kairo/internal/config"
 	"github.com/dkmnx/kairo/internal/constants"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/providers"
 	"github.com/spf13/cobra"
 )
 
@@ -121,7 +125,7 @@ PowerShell:
 		if completionOutput != "" {
 			f, err := os.Create(completionOutput)
 			if err != nil {
-				cmd.Printf("Error creating output file: %v\n", err)
+				printCmdError(cmd, kairoerrors.FileError("Error creating output file", completionOutput, err))
 
 				return
 			}
@@ -131,14 +135,14 @@ PowerShell:
 		} else if completionSave {
 			defaultPath := getDefaultCompletionPath(args[0])
 			if err := os.MkdirAll(filepath.Dir(defaultPath), constants.DirPermDefault); err != nil {
-				cmd.Printf("Error creating directory: %v\n", err)
+				printCmdError(cmd, kairoerrors.FileError("Error creating directory", filepath.Dir(defaultPath), err))
 
 				return
 			}
 
 			if args[0] == shellPowerShell {
 				if err := os.WriteFile(defaultPath, []byte(powerShellCompletionScript), constants.FilePermDefault); err != nil {
-					cmd.Printf("Error writing completion file: %v\n", err)
+					printCmdError(cmd, kairoerrors.FileError("Error writing completion file", defaultPath, err))
 
 					return
 				}
@@ -153,7 +157,7 @@ PowerShell:
 
 			f, err := os.Create(defaultPath)
 			if err != nil {
-				cmd.Printf("Error creating output file: %v\n", err)
+				printCmdError(cmd, kairoerrors.FileError("Error creating output file", defaultPath, err))
 
 				return
 			}
@@ -167,26 +171,26 @@ PowerShell:
 		switch args[0] {
 		case "bash":
 			if err := rootCmd.GenBashCompletion(out); err != nil {
-				cmd.Printf("Error generating bash completion: %v\n", err)
+				printCmdError(cmd, kairoerrors.WrapError(kairoerrors.RuntimeError, "Error generating bash completion", err))
 			}
 		case "zsh":
 			if err := rootCmd.GenZshCompletion(out); err != nil {
-				cmd.Printf("Error generating zsh completion: %v\n", err)
+				printCmdError(cmd, kairoerrors.WrapError(kairoerrors.RuntimeError, "Error generating zsh completion", err))
 			}
 		case "fish":
 			if err := rootCmd.GenFishCompletion(out, true); err != nil {
-				cmd.Printf("Error generating fish completion: %v\n", err)
+				printCmdError(cmd, kairoerrors.WrapError(kairoerrors.RuntimeError, "Error generating fish completion", err))
 			}
 		case shellPowerShell:
 			if err := rootCmd.GenPowerShellCompletionWithDesc(out); err != nil {
-				cmd.Printf("Error generating PowerShell completion: %v\n", err)
+				printCmdError(cmd, kairoerrors.WrapError(kairoerrors.RuntimeError, "Error generating PowerShell completion", err))
 			}
 		}
 
 		if closeOut {
 			if f, ok := out.(*os.File); ok {
 				if err := f.Close(); err != nil {
-					cmd.Printf("Error closing completion file: %v\n", err)
+					printCmdError(cmd, kairoerrors.WrapError(kairoerrors.FileSystemError, "Error closing completion file", err))
 				}
 			}
 		}
@@ -225,3 +229,61 @@ func getDefaultCompletionPath(shell string) string {
 		return defaultCompletionSuffix
 	}
 }
+
+// completeModels completes --model with the models known for the provider
+// named by the first argument, or the default provider: the configured
+// model, then the models named by the provider's env_vars and built-in
+// definition.
+func completeModels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg := completionConfig(cmd)
+	providerName := cfg.DefaultProvider
+	if len(args) > 0 && isKnownProvider(args[0], cfg) {
+		providerName = args[0]
+	}
+
+	var models []string
+	for _, m := range modelCandidates(cfg, providerName) {
+		if strings.HasPrefix(m, toComplete) {
+			models = append(models, m)
+		}
+	}
+
+	return models, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionConfig loads the config for a completion request, honoring
+// --config, or returns an empty config when there is none.
+func completionConfig(cmd *cobra.Command) *config.Config {
+	configDir, _ := cmd.Flags().GetString("config")
+	if configDir == "" {
+		if cliCtx := CLIContextFromCmd(cmd); cliCtx != nil {
+			configDir = cliCtx.ConfigDir()
+		}
+	}
+
+	var cfg *config.Config
+	if configDir != "" {
+		cfg, _ = config.LoadConfig(commandContext(cmd), configDir)
+	}
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	return cfg
+}
+
+func modelCandidates(cfg *config.Config, providerName string) []string {
+	if providerName == "" {
+		return nil
+	}
+
+	var models []string
+	if p, ok := cfg.Providers[providerName]; ok {
+		models = providers.AppendModels(models, p.Model, p.EnvVars)
+	}
+	if def, ok := providers.BuiltInProvider(providerName); ok {
+		models = providers.AppendModels(models, def.Model, def.EnvVars)
+	}
+
+	return models
+}
@@ -448,3 +448,55 @@ func TestCompletionPowerShellSaveWithRegisterArgumentCompleter(t *testing.T) {
 		t.Error("Saved PowerShell completion should create CompletionResult objects")
 	}
 }
+
+func TestCompleteModelFlag(t *testing.T) {
+	t.Cleanup(func() {
+		modelFlag = ""
+		_ = rootCmd.PersistentFlags().Set("config", "")
+		rootCmd.SetArgs(nil)
+	})
+
+	tmpDir := t.TempDir()
+	cfg := `default_provider: zai
+providers:
+  zai:
+    name: Z.AI
+    base_url: https://api.z.ai/api/anthropic
+    model: glm-4.7
+    env_vars:
+      - ANTHROPIC_DEFAULT_OPUS_MODEL=glm-5.1-air
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"default provider", []string{"--model", ""}, []string{"glm-4.7", "glm-5.1-air", "glm-5.1", "glm-4.7-flash"}},
+		{"prefix", []string{"--model", "glm-5"}, []string{"glm-5.1-air", "glm-5.1"}},
+		{"named provider", []string{"deepseek", "--model", "deepseek-v4-f"}, []string{"deepseek-v4-flash"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			rootCmd.SetOut(buf)
+			rootCmd.SetArgs(append([]string{"__complete", "--config", tmpDir}, tt.args...))
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				if !strings.HasPrefix(line, ":") {
+					got = append(got, line)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("completions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	configShowOrigin   bool
+	configSchemaFormat string
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the merged configuration",
+	Long: `Inspect the configuration kairo uses after merging override files.
+
+config.yaml is loaded first, then every conf.d/*.yaml in name order, then
+config.override.yaml. Mappings are merged key by key and any other value,
+including a list, replaces the one below it, so machine-specific settings
+can live outside a config.yaml managed with dotfiles. Commands that save the
+configuration leave values that came from override files out of config.yaml.`,
+	Args: cobra.NoArgs,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show [provider]",
+	Short: "Print the merged configuration",
+	Long: `Print the merged configuration as YAML. With --origin, print one line per
+field with the file that set it, or "default" when no file does.
+
+With a provider, print the values a switch to it resolves, each with where it
+came from: a flag, an environment variable, a config file, the built-in
+provider definition, or the default. --harness and --model show what those
+flags would change.`,
+	Example: `  kairo config show zai
+  kairo config show zai --harness qwen`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeProviders(false),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := requireConfigDir(cmd)
+		if dir == "" {
+			return
+		}
+
+		cfg, origins, err := config.LoadConfigOrigins(CLIContextFromCmd(cmd).RootCtx(), dir)
+		if err != nil {
+			if stderrors.Is(err, kairoerrors.ErrConfigNotFound) {
+				printNoProvidersMessage()
+
+				return
+			}
+			handleConfigError(cmd, err)
+
+			return
+		}
+
+		if len(args) == 1 {
+			fields, err := providerResolution(cmd, dir, cfg, origins, args[0])
+			if err == nil {
+				err = printFields(cmd.OutOrStdout(), fields)
+			}
+			if err != nil {
+				printError(err)
+			}
+
+			return
+		}
+		if err := printConfig(cmd.OutOrStdout(), cfg, origins, configShowOrigin); err != nil {
+			printError(err)
+		}
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the configuration schema",
+	Long: `Print every config.yaml field with its type, default, accepted values, and
+description, generated from the configuration types compiled into this
+binary. --format markdown (the default) prints a reference table;
+--format json prints a JSON Schema that editors can validate and complete
+config.yaml with. The schema for the latest release is published at
+` + config.SchemaURL + `; for example, with the YAML language server:
+
+  # yaml-language-server: $schema=` + config.SchemaURL,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := writeConfigSchema(cmd.OutOrStdout(), configSchemaFormat); err != nil {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	configShowCmd.Flags().BoolVar(&configShowOrigin, "origin", false, "Show the file that set each field")
+	configShowCmd.Flags().StringVar(&harnessFlag, "harness", "", "Resolve the provider for this harness")
+	configShowCmd.Flags().StringVar(&modelFlag, "model", "", "Resolve the provider with this --model")
+	configSchemaCmd.Flags().StringVar(&configSchemaFormat, "format", "markdown", "Output format: markdown or json")
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// printConfig writes cfg as YAML, or as one "path: value  # origin" line per
+// field when withOrigin is set.
+func printConfig(w io.Writer, cfg *config.Config, origins config.Origins, withOrigin bool) error {
+	if !withOrigin {
+		data, err := yaml.Marshal(cfg)
+		if err != nil {
+			return kairoerrors.WrapError(kairoerrors.ConfigError, "failed to encode configuration", err)
+		}
+		_, err = w.Write(data)
+
+		return err
+	}
+
+	fields, err := origins.Fields(cfg)
+	if err != nil {
+		return err
+	}
+
+	return printFields(w, fields)
+}
+
+// printFields writes one "path: value  # origin" line per field, with the
+// origins aligned.
+func printFields(w io.Writer, fields []config.Field) error {
+	width := 0
+	for _, f := range fields {
+		width = max(width, len(f.Path)+len(f.Value)+2)
+	}
+	for _, f := range fields {
+		line := f.Path + ": " + f.Value
+		if _, err := fmt.Fprintf(w, "%-*s  # %s\n", width, line, f.Origin); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// providerResolution returns the values a switch to the provider name
+// resolves, in the order kairo resolves them, each with its origin, followed
+// by the provider's remaining fields.
+func providerResolution(cmd *cobra.Command, dir string, cfg *config.Config, origins config.Origins, name string) ([]config.Field, error) {
+	provider, ok := cfg.Providers[name]
+	if !ok {
+		return nil, kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("provider '%s' not configured", name)).
+			WithContext("hint", "run 'kairo list' to see configured providers")
+	}
+	all, err := origins.Fields(cfg)
+	if err != nil {
+		return nil, err
+	}
+	prefix := "providers." + name + "."
+	own := make(map[string]config.Field)
+	var order []string
+	for _, f := range all {
+		if path, ok := strings.CutPrefix(f.Path, prefix); ok {
+			f.Path = path
+			own[path] = f
+			order = append(order, path)
+		}
+	}
+	fileOrigin := func(path string) string {
+		if origin, ok := origins[path]; ok {
+			return origin
+		}
+
+		return config.DefaultOrigin
+	}
+
+	configDirOrigin := "platform default"
+	switch {
+	case cmd.Flags().Changed("config"):
+		configDirOrigin = "--config flag"
+	case os.Getenv("KAIRO_CONFIG_DIR") != "":
+		configDirOrigin = "KAIRO_CONFIG_DIR"
+	}
+
+	h := resolveHarness(harnessFlag, cfg.DefaultHarness)
+	harnessOrigin := fileOrigin("default_harness")
+	switch {
+	case harnessFlag == h:
+		harnessOrigin = "--harness flag"
+	case cfg.DefaultHarness != h:
+		harnessOrigin = config.DefaultOrigin
+	}
+
+	model, modelOrigin := provider.Model, fileOrigin(prefix+"model")
+	switch {
+	case modelFlag != "":
+		model, modelOrigin = modelFlag, "--model flag"
+	case provider.Models[h] != "":
+		model, modelOrigin = provider.Models[h], fileOrigin(prefix+"models."+h)+" (models."+h+")"
+	}
+
+	envKey, envKeyOrigin := provider.EnvKey, fileOrigin(prefix+"env_key")
+	if envKey == "" {
+		envKey, envKeyOrigin = harness.APIKeyEnvVar(name), "derived from the provider name"
+	}
+
+	authStyle, authStyleOrigin := provider.AuthStyle, fileOrigin(prefix+"auth_style")
+	if authStyle == "" {
+		authStyle, authStyleOrigin = ProviderDefinition(name).AuthStyle, "built-in "+name+" definition"
+		if authStyle == "" {
+			authStyle, authStyleOrigin = "both", config.DefaultOrigin
+		}
+	}
+
+	fields := []config.Field{
+		{Path: "config_dir", Value: dir, Origin: configDirOrigin},
+		{Path: "harness", Value: h, Origin: harnessOrigin},
+		{Path: "base_url", Value: provider.BaseURL, Origin: fileOrigin(prefix + "base_url")},
+		{Path: "model", Value: model, Origin: modelOrigin},
+		{Path: "env_key", Value: envKey, Origin: envKeyOrigin},
+		{Path: "auth_style", Value: authStyle, Origin: authStyleOrigin},
+	}
+	for _, path := range order {
+		switch {
+		case path == "base_url", path == "model", path == "env_key", path == "auth_style":
+		case strings.HasPrefix(path, "models."):
+		default:
+			fields = append(fields, own[path])
+		}
+	}
+
+	return fields, nil
+}
+
+// writeConfigSchema writes the configuration schema to w in format,
+// "markdown" or "json".
+func writeConfigSchema(w io.Writer, format string) error {
+	switch format {
+	case "markdown":
+		return writeSchemaMarkdown(w, config.SchemaFields())
+	case "json":
+		data, err := config.JSONSchema()
+		if err != nil {
+			return kairoerrors.WrapError(kairoerrors.ConfigError, "failed to encode configuration schema", err)
+		}
+		_, err = w.Write(data)
+
+		return err
+	default:
+		return kairoerrors.NewError(kairoerrors.ValidationError, fmt.Sprintf("unknown schema format %q", format)).
+			WithContext("hint", "use --format markdown or --format json")
+	}
+}
+
+// writeSchemaMarkdown writes fields as a Markdown table.
+func writeSchemaMarkdown(w io.Writer, fields []config.SchemaField) error {
+	rows := [][]string{{"Field", "Type", "Default", "Description"}}
+	for _, f := range fields {
+		def := ""
+		if f.Default != "" {
+			def = "`" + f.Default + "`"
+		}
+		desc := f.Description
+		if len(f.Enum) > 0 {
+			desc += ". One of `" + strings.Join(f.Enum, "`, `") + "`"
+		}
+		rows = append(rows, []string{"`" + f.Path + "`", f.Type, def, desc})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	line := func(cells []string) error {
+		for i, cell := range cells {
+			if _, err := fmt.Fprintf(w, "| %-*s ", widths[i], cell); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintln(w, "|")
+
+		return err
+	}
+
+	if err := line(rows[0]); err != nil {
+		return err
+	}
+	rule := make([]string, len(widths))
+	for i, width := range widths {
+		rule[i] = strings.Repeat("-", width)
+	}
+	if err := line(rule); err != nil {
+		return err
+	}
+	for _, row := range rows[1:] {
+		if err := line(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+)
+
+func TestPrintConfigOrigin(t *testing.T) {
+	cfg := &config.Config{
+		DefaultProvider: "zai",
+		Providers:       map[string]config.Provider{"zai": {Name: "Z.AI", BaseURL: "https://proxy.example"}},
+	}
+	origins := config.Origins{
+		"default_provider":       config.BaseFileName,
+		"providers.zai.base_url": config.OverrideFileName,
+	}
+
+	var buf bytes.Buffer
+	if err := printConfig(&buf, cfg, origins, true); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		field, origin, ok := strings.Cut(line, "  # ")
+		if !ok {
+			t.Fatalf("line without origin: %q", line)
+		}
+		lines[strings.TrimSpace(field)] = origin
+	}
+
+	want := map[string]string{
+		"default_provider: zai":                         config.BaseFileName,
+		"providers.zai.base_url: https://proxy.example": config.OverrideFileName,
+		"providers.zai.name: Z.AI":                      config.DefaultOrigin,
+	}
+	for field, origin := range want {
+		if lines[field] != origin {
+			t.Errorf("origin of %q = %q, want %q\n%s", field, lines[field], origin, buf.String())
+		}
+	}
+}
+
+func TestWriteConfigSchema(t *testing.T) {
+	var md bytes.Buffer
+	if err := writeConfigSchema(&md, "markdown"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(md.String()), "\n")
+	if len(lines) != len(config.SchemaFields())+2 || !strings.HasPrefix(lines[1], "| ---") {
+		t.Fatalf("markdown is not a table with one row per field:\n%s", md.String())
+	}
+	for _, line := range lines {
+		if len(line) != len(lines[0]) {
+			t.Errorf("row %q is not aligned with the header", line)
+		}
+	}
+	if !strings.Contains(md.String(), "One of `claude`, `qwen`, `pi`, `crush`") {
+		t.Errorf("markdown does not list the values of default_harness:\n%s", md.String())
+	}
+
+	var js bytes.Buffer
+	if err := writeConfigSchema(&js, "json"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(js.String(), `"$schema"`) {
+		t.Errorf("json output is not a JSON Schema:\n%s", js.String())
+	}
+
+	if err := writeConfigSchema(&js, "yaml"); err == nil {
+		t.Error("writeConfigSchema(yaml) = nil, want an unknown format error")
+	}
+}
+
+func TestProviderResolution(t *testing.T) {
+	defer func() { harnessFlag, modelFlag = "", "" }()
+	cfg := &config.Config{
+		DefaultHarness: "claude",
+		Providers: map[string]config.Provider{"zai": {
+			Name:    "Z.AI",
+			BaseURL: "https://proxy.example",
+			Model:   "glm-5.1",
+			Models:  map[string]string{"qwen": "glm-4.6"},
+		}},
+	}
+	origins := config.Origins{
+		"default_harness":           config.BaseFileName,
+		"providers.zai.name":        config.BaseFileName,
+		"providers.zai.model":       config.BaseFileName,
+		"providers.zai.base_url":    config.OverrideFileName,
+		"providers.zai.models.qwen": config.OverrideFileName,
+	}
+	resolve := func() map[string]config.Field {
+		t.Helper()
+		fields, err := providerResolution(configShowCmd, "/cfg", cfg, origins, "zai")
+		if err != nil {
+			t.Fatalf("providerResolution() error = %v", err)
+		}
+		byPath := make(map[string]config.Field)
+		for _, f := range fields {
+			byPath[f.Path] = f
+		}
+
+		return byPath
+	}
+
+	fields := resolve()
+	want := map[string][2]string{
+		"harness":  {"claude", config.BaseFileName},
+		"base_url": {"https://proxy.example", config.OverrideFileName},
+		"model":    {"glm-5.1", config.BaseFileName},
+		"env_key":  {"ZAI_API_KEY", "derived from the provider name"},
+		"name":     {"Z.AI", config.BaseFileName},
+	}
+	for path, w := range want {
+		if f := fields[path]; f.Value != w[0] || f.Origin != w[1] {
+			t.Errorf("%s = %q (%s), want %q (%s)", path, f.Value, f.Origin, w[0], w[1])
+		}
+	}
+	if _, ok := fields["models.qwen"]; ok {
+		t.Error("models.qwen listed besides the resolved model")
+	}
+
+	harnessFlag = "qwen"
+	if f := resolve()["model"]; f.Value != "glm-4.6" || !strings.HasPrefix(f.Origin, config.OverrideFileName) {
+		t.Errorf("model under --harness qwen = %q (%s)", f.Value, f.Origin)
+	}
+	modelFlag = "glm-x"
+	if f := resolve()["model"]; f.Value != "glm-x" || f.Origin != "--model flag" {
+		t.Errorf("model with --model = %q (%s)", f.Value, f.Origin)
+	}
+
+	if _, err := providerResolution(configShowCmd, "/cfg", cfg, origins, "missing"); err == nil {
+		t.Error("providerResolution() of an unconfigured provider should fail")
+	}
+}
@@ -2,21 +2,28 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/dkmnx/kairo/internal/config"
 	"github.com/dkmnx/kairo/internal/constants"
 	"github.com/dkmnx/kairo/internal/crypto"
+	"github.com/dkmnx/kairo/internal/events"
+	"github.com/dkmnx/kairo/internal/session"
 	"github.com/spf13/cobra"
 )
 
 type cliContextKey struct{}
 
+// errCommandTimeout is the cancellation cause when --timeout expires.
+var errCommandTimeout = errors.New("command timed out")
+
 // ConfigDirResolver resolves the default configuration directory.
 type ConfigDirResolver func() (string, error)
 
-// CLIContext holds shared CLI state: config directory, verbosity, config cache,
-// root context, and external dependencies. It is safe for concurrent use.
+// CLIContext holds shared CLI state: config directory, verbosity, config and
+// secrets caches, root context, and external dependencies. It is safe for concurrent use.
 type CLIContext struct {
 	configDir         string
 	configDirMu       sync.RWMutex
@@ -24,12 +31,19 @@ type CLIContext struct {
 	verbose           bool
 	verboseMu         sync.RWMutex
 	configCache       *config.ConfigCache
+	secrets           *session.Secrets
 	rootCtx           context.Context
+	rootCtxMu         sync.RWMutex
+	timeoutTimer      *time.Timer
+	cancelRoot        context.CancelCauseFunc
 	deps              *Deps
 	depsMu            sync.RWMutex
 
 	defaultProviderExplicit   bool
 	defaultProviderExplicitMu sync.RWMutex
+
+	events   *events.Emitter
+	eventsMu sync.RWMutex
 }
 
 // NewCLIContext creates a CLIContext with default settings.
@@ -37,6 +51,7 @@ func NewCLIContext() *CLIContext {
 	return &CLIContext{
 		configDirResolver: config.DefaultConfigDir,
 		configCache:       config.NewConfigCache(constants.ConfigCacheTTL),
+		secrets:           session.NewSecrets(),
 		rootCtx:           context.Background(),
 		deps:              NewDeps(),
 	}
@@ -96,11 +111,65 @@ func (c *CLIContext) ConfigCache() *config.ConfigCache {
 	return c.configCache
 }
 
+// Secrets returns the memo of secrets files decrypted in this CLI session.
+func (c *CLIContext) Secrets() *session.Secrets {
+	return c.secrets
+}
+
 // RootCtx returns the root context for the CLI session.
 func (c *CLIContext) RootCtx() context.Context {
+	c.rootCtxMu.RLock()
+	defer c.rootCtxMu.RUnlock()
+
 	return c.rootCtx
 }
 
+// SetRootCtx replaces the root context for the CLI session.
+func (c *CLIContext) SetRootCtx(ctx context.Context) {
+	c.rootCtxMu.Lock()
+	defer c.rootCtxMu.Unlock()
+
+	c.rootCtx = ctx
+}
+
+// ApplyTimeout bounds the root context to d. When it expires, the root
+// context is canceled with errCommandTimeout as its cause.
+func (c *CLIContext) ApplyTimeout(d time.Duration) {
+	ctx, cancel := context.WithCancelCause(c.RootCtx())
+
+	c.rootCtxMu.Lock()
+	defer c.rootCtxMu.Unlock()
+
+	c.rootCtx = ctx
+	c.cancelRoot = cancel
+	c.timeoutTimer = time.AfterFunc(d, func() { cancel(errCommandTimeout) })
+}
+
+// DisarmTimeout stops a pending --timeout. It is called when control passes
+// to an interactive harness session, which must not be cut off.
+func (c *CLIContext) DisarmTimeout() {
+	c.rootCtxMu.RLock()
+	defer c.rootCtxMu.RUnlock()
+
+	if c.timeoutTimer != nil {
+		c.timeoutTimer.Stop()
+	}
+}
+
+// Close releases the resources held by ApplyTimeout and wipes the decrypted
+// secrets.
+func (c *CLIContext) Close() {
+	c.DisarmTimeout()
+	c.secrets.Clear()
+
+	c.rootCtxMu.RLock()
+	defer c.rootCtxMu.RUnlock()
+
+	if c.cancelRoot != nil {
+		c.cancelRoot(nil)
+	}
+}
+
 // Deps returns the external dependencies for this CLI session.
 func (c *CLIContext) Deps() *Deps {
 	c.depsMu.RLock()
@@ -120,6 +189,7 @@ func (c *CLIContext) SetDeps(d *Deps) {
 	defer c.depsMu.Unlock()
 
 	c.deps = d
+	c.secrets.Clear()
 }
 
 // InvalidateCache removes the cached configuration for the given directory.
@@ -144,6 +214,36 @@ func (c *CLIContext) DefaultProviderExplicit() bool {
 	return c.defaultProviderExplicit
 }
 
+// Events returns the switch lifecycle event stream, or nil when none was
+// requested with --events-fd.
+func (c *CLIContext) Events() *events.Emitter {
+	c.eventsMu.RLock()
+	defer c.eventsMu.RUnlock()
+
+	return c.events
+}
+
+// SetEvents sets the switch lifecycle event stream.
+func (c *CLIContext) SetEvents(em *events.Emitter) {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+
+	c.events = em
+}
+
+// commandContext returns the root context of cmd's CLI session, which carries
+// --timeout and Ctrl-C cancellation, falling back to cmd's own context.
+func commandContext(cmd *cobra.Command) context.Context {
+	if cliCtx := CLIContextFromCmd(cmd); cliCtx != nil {
+		return cliCtx.RootCtx()
+	}
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+
+	return context.Background()
+}
+
 // CLIContextFromCmd extracts the CLIContext from a cobra command's context.
 // Returns nil if no CLIContext is set (callers should use MustCLIContextFromCmd
 // when a cmd is always available, or handle nil gracefully).
@@ -3,7 +3,9 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/dkmnx/kairo/internal/audit"
 	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
 	"github.com/dkmnx/kairo/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -23,7 +25,9 @@ var defaultCmd = &cobra.Command{
 		cfg, err := loadConfigOrExit(cmd)
 		if err != nil || cfg == nil {
 			if len(args) > 0 {
-				ui.PrintError(fmt.Sprintf("Provider '%s' not found in config", args[0]))
+				printError(kairoerrors.NewError(kairoerrors.ProviderError,
+					fmt.Sprintf("provider '%s' not found in config", args[0])).
+					WithContext("provider", args[0]))
 			}
 
 			return
@@ -34,7 +38,7 @@ var defaultCmd = &cobra.Command{
 				ui.PrintWarn("No default provider configured")
 				ui.PrintInfo("Run 'kairo default <provider>' to set one")
 			} else {
-				ui.PrintSuccess(fmt.Sprintf("Default provider: %s", cfg.DefaultProvider))
+				ui.PrintValue(cmd.OutOrStdout(), "Default provider", cfg.DefaultProvider)
 			}
 
 			return
@@ -42,22 +46,33 @@ var defaultCmd = &cobra.Command{
 
 		providerName := args[0]
 		if _, ok := cfg.Providers[providerName]; !ok {
-			ui.PrintError(fmt.Sprintf("Provider '%s' not configured", providerName))
-			ui.PrintInfo("Run 'kairo setup' to configure")
+			printError(kairoerrors.NewError(kairoerrors.ProviderError,
+				fmt.Sprintf("provider '%s' not configured", providerName)).
+				WithContext("provider", providerName))
 
 			return
 		}
 
+		previous := cfg.DefaultProvider
 		cfg.DefaultProvider = providerName
 		if err := config.SaveConfig(cliCtx.RootCtx(), dir, cfg); err != nil {
-			ui.PrintError(fmt.Sprintf("Error saving config: %v", err))
+			printError(kairoerrors.WrapError(kairoerrors.ConfigError, "error saving config", err))
 
 			return
 		}
 
 		cliCtx.InvalidateCache(dir)
+		recordAudit(cliCtx, dir, audit.Entry{
+			Event:    audit.EventConfig,
+			Action:   "set_default",
+			Provider: providerName,
+			Details:  map[string]string{"previous": previous},
+		})
 
 		ui.PrintSuccess(fmt.Sprintf("Default provider set to: %s", providerName))
+		if cfg.Providers[providerName].Disabled {
+			ui.PrintWarn(fmt.Sprintf("Provider '%s' is disabled; run 'kairo providers enable %s' to use it", providerName, providerName))
+		}
 	},
 }
 
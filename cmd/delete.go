@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/dkmnx/kairo/internal/audit"
 	"github.com/dkmnx/kairo/internal/config"
 	"github.com/dkmnx/kairo/internal/constants"
 	"github.com/dkmnx/kairo/internal/crypto"
@@ -38,6 +39,12 @@ var deleteCmd = &cobra.Command{
 				return
 			}
 
+			if err := requireInteractive("the provider to delete", "name it: 'kairo delete <provider>'"); err != nil {
+				printError(err)
+
+				return
+			}
+
 			providerNames := make([]string, 0, len(cfg.Providers))
 			for name := range cfg.Providers {
 				providerNames = append(providerNames, name)
@@ -68,17 +75,24 @@ var deleteCmd = &cobra.Command{
 			target = args[0]
 		}
 
-		_, ok := cfg.Providers[target]
+		deleted, ok := cfg.Providers[target]
 		if !ok {
 			tap.Cancel(fmt.Sprintf("Provider '%s' not configured", target))
 
 			return
 		}
 
-		confirmed := tap.Confirm(cliCtx.RootCtx(), tap.ConfirmOptions{
-			Message: fmt.Sprintf("Are you sure you want to delete '%s'?", target),
+		ok, err = confirmed(fmt.Sprintf("deleting '%s'", target), func() (bool, error) {
+			return tap.Confirm(cliCtx.RootCtx(), tap.ConfirmOptions{
+				Message: fmt.Sprintf("Are you sure you want to delete '%s'?", target),
+			}), nil
 		})
-		if !confirmed {
+		if err != nil {
+			printError(err)
+
+			return
+		}
+		if !ok {
 			tap.Cancel("Operation canceled")
 
 			return
@@ -101,11 +115,29 @@ var deleteCmd = &cobra.Command{
 		secretsPath := filepath.Join(dir, constants.SecretsFileName)
 		keyPath := filepath.Join(dir, constants.KeyFileName)
 
-		if err := deleteProviderSecrets(cliCtx.RootCtx(), cliCtx.Crypto(), secretsPath, keyPath, target); err != nil {
+		svc, err := cryptoFor(cliCtx, dir)
+		if err != nil {
 			tap.Cancel(fmt.Sprintf("Failed to clean up secrets for '%s': %v", target, err))
 
 			return
 		}
+		if err := backupSecretsFile(cliCtx, secretsPath, keyPath); err != nil {
+			tap.Cancel(fmt.Sprintf("Failed to clean up secrets for '%s': %v", target, err))
+
+			return
+		}
+		if err := deleteProviderSecrets(cliCtx.RootCtx(), svc, secretsPath, keyPath, target); err != nil {
+			tap.Cancel(fmt.Sprintf("Failed to clean up secrets for '%s': %v", target, err))
+
+			return
+		}
+
+		recordAudit(cliCtx, dir, audit.Entry{
+			Event:    audit.EventConfig,
+			Action:   "delete_provider",
+			Provider: target,
+			Patch:    providerPatch(target, &deleted, nil),
+		})
 
 		tap.Outro(fmt.Sprintf("Provider '%s' deleted successfully", target))
 	},
@@ -120,7 +152,12 @@ func deleteProviderSecrets(ctx context.Context, svc crypto.Service, secretsPath,
 	}
 	defer crypto.ClearMemory(existingSecrets)
 
-	parsed := secrets.ParseWithStats(string(existingSecrets))
+	payload, err := secrets.Open(existingSecrets)
+	if err != nil {
+		return secretsCorruptedError(secretsPath, err)
+	}
+
+	parsed := secrets.ParseWithStats(string(payload))
 
 	ui.PrintWarnings(parsed.Warnings)
 
@@ -144,7 +181,7 @@ func deleteProviderSecrets(ctx context.Context, svc crypto.Service, secretsPath,
 		return nil
 	}
 
-	if err := svc.EncryptSecrets(ctx, secretsPath, keyPath, secretsContent); err != nil {
+	if err := svc.EncryptSecrets(ctx, secretsPath, keyPath, secrets.Seal(secretsContent)); err != nil {
 		return errors.WrapError(errors.CryptoError,
 			"could not update secrets", err).
 			WithContext("path", secretsPath)
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
@@ -11,6 +12,7 @@ import (
 	"github.com/dkmnx/kairo/internal/config"
 	"github.com/dkmnx/kairo/internal/constants"
 	"github.com/dkmnx/kairo/internal/crypto"
+	"github.com/dkmnx/kairo/internal/health"
 	"github.com/dkmnx/kairo/internal/integrity"
 	"github.com/dkmnx/kairo/internal/providers"
 	"github.com/dkmnx/kairo/internal/ui"
@@ -31,14 +33,25 @@ func (osProcessRunner) ExitProcess(code int) { os.Exit(code) }
 // prodWrapperService delegates wrapper operations to the wrapper package.
 type prodWrapperService struct{}
 
-func (prodWrapperService) CreateTempAuthDir() (string, error) {
-	return wrapper.CreateTempAuthDir()
+func (prodWrapperService) CreateTempAuthDir(parent string) (string, error) {
+	return wrapper.CreateTempAuthDir(parent)
 }
 func (prodWrapperService) WriteTempTokenFile(authDir, token string) (string, error) {
 	return wrapper.WriteTempTokenFile(authDir, token)
 }
-func (prodWrapperService) GenerateWrapperScript(cfg wrapper.ScriptConfig) (string, bool, error) {
-	return wrapper.GenerateWrapperScript(cfg)
+func (prodWrapperService) WriteSettingsFile(authDir, name, content string) (string, error) {
+	return wrapper.WriteSettingsFile(authDir, name, content)
+}
+func (prodWrapperService) PrepareLaunch(cfg wrapper.ScriptConfig) (wrapper.Launch, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return wrapper.Launch{}, err
+	}
+
+	return wrapper.PrepareLaunch(cfg, exe)
+}
+func (prodWrapperService) CleanStaleAuthDirs(tmpDir string, maxAge time.Duration, dryRun bool) ([]string, error) {
+	return wrapper.CleanStaleAuthDirs(tmpDir, maxAge, time.Now(), dryRun)
 }
 
 // prodUpdateService delegates update operations to the update and ui packages.
@@ -108,6 +121,27 @@ func catalogChecksumURL() string {
 		constants.GitHubRepo, catalogReleaseTag())
 }
 
+// catalogURLs returns the catalog, sigstore bundle, and checksum URLs that
+// 'kairo providers refresh' fetches, honouring the KAIRO_PROVIDER_CATALOG_*
+// overrides.
+func catalogURLs() (artifactURL, bundleURL, checksumURL string) {
+	artifactURL = catalogDownloadURL()
+	bundleURL = catalogBundleDownloadURL()
+	checksumURL = catalogChecksumURL()
+
+	if u, ok := os.LookupEnv("KAIRO_PROVIDER_CATALOG_URL"); ok && u != "" {
+		artifactURL = u
+	}
+	if u, ok := os.LookupEnv("KAIRO_PROVIDER_CATALOG_BUNDLE_URL"); ok && u != "" {
+		bundleURL = u
+	}
+	if u, ok := os.LookupEnv("KAIRO_PROVIDER_CATALOG_CHECKSUM_URL"); ok && u != "" {
+		checksumURL = u
+	}
+
+	return artifactURL, bundleURL, checksumURL
+}
+
 // prodCatalogService is the production CatalogService that delegates to
 // the providers.DefaultRegistry and fetches verified remote catalogs.
 type prodCatalogService struct{}
@@ -130,19 +164,7 @@ func (prodCatalogService) RefreshFromRemote(ctx context.Context) (int, error) {
 		return 0, err
 	}
 
-	artifactURL := catalogDownloadURL()
-	bundleURL := catalogBundleDownloadURL()
-	checksumURL := catalogChecksumURL()
-
-	if u, ok := os.LookupEnv("KAIRO_PROVIDER_CATALOG_URL"); ok && u != "" {
-		artifactURL = u
-	}
-	if u, ok := os.LookupEnv("KAIRO_PROVIDER_CATALOG_BUNDLE_URL"); ok && u != "" {
-		bundleURL = u
-	}
-	if u, ok := os.LookupEnv("KAIRO_PROVIDER_CATALOG_CHECKSUM_URL"); ok && u != "" {
-		checksumURL = u
-	}
+	artifactURL, bundleURL, checksumURL := catalogURLs()
 
 	data, err := integrity.FetchVerified(
 		ctx,
@@ -160,6 +182,15 @@ func (prodCatalogService) RefreshFromRemote(ctx context.Context) (int, error) {
 	return providers.DefaultRegistry.RefreshCacheFromBytes(data, cachePath)
 }
 
+// prodHealthService probes providers over HTTPS with the default request timeout.
+type prodHealthService struct {
+	client *http.Client
+}
+
+func (s prodHealthService) Check(ctx context.Context, baseURL, apiKey string, style health.AuthStyle) health.Result {
+	return health.Check(ctx, s.client, baseURL, apiKey, style)
+}
+
 func loadProviderCacheOrDisk() {
 	cachePath, err := providerCatalogCachePath()
 	if err != nil {
@@ -176,10 +207,12 @@ func init() {
 // NewDeps returns a Deps with production implementations.
 func NewDeps() *Deps {
 	return &Deps{
-		Process: osProcessRunner{},
-		Wrapper: prodWrapperService{},
-		Update:  &prodUpdateService{client: update.NewClient()},
-		Crypto:  crypto.DefaultService{},
-		Catalog: prodCatalogService{},
+		Process:  osProcessRunner{},
+		Wrapper:  prodWrapperService{},
+		Update:   &prodUpdateService{client: update.NewClient()},
+		Crypto:   crypto.DefaultService{},
+		Catalog:  prodCatalogService{},
+		Health:   prodHealthService{client: &http.Client{Timeout: constants.RequestTimeout}},
+		Resolver: net.DefaultResolver,
 	}
 }
@@ -33,6 +33,9 @@ func TestNewDeps(t *testing.T) {
 	if d.Crypto == nil {
 		t.Error("Crypto is nil")
 	}
+	if d.Health == nil {
+		t.Error("Health is nil")
+	}
 }
 
 // TestDepsProductionAdapters_Coverage invokes each production adapter so the
@@ -51,7 +54,7 @@ func TestDepsProductionAdapters_Coverage(t *testing.T) {
 	// Note: ExitProcess calls os.Exit and is therefore not exercised here.
 
 	// Wrapper adapter — CreateTempAuthDir is safe to call; the rest are too.
-	authDir, err := d.Wrapper.CreateTempAuthDir()
+	authDir, err := d.Wrapper.CreateTempAuthDir("")
 	if err != nil {
 		t.Errorf("CreateTempAuthDir: %v", err)
 	} else {
@@ -65,16 +68,13 @@ func TestDepsProductionAdapters_Coverage(t *testing.T) {
 		t.Cleanup(func() { _ = removeFile(tokenPath) })
 	}
 
-	scriptPath, _, err := d.Wrapper.GenerateWrapperScript(wrapper.ScriptConfig{
+	if _, err := d.Wrapper.PrepareLaunch(wrapper.ScriptConfig{
 		AuthDir:    authDir,
 		TokenPath:  tokenPath,
 		CliPath:    "/bin/echo",
 		EnvVarName: "X",
-	})
-	if err != nil {
-		t.Errorf("GenerateWrapperScript: %v", err)
-	} else {
-		t.Cleanup(func() { _ = removeFile(scriptPath) })
+	}); err != nil {
+		t.Errorf("PrepareLaunch: %v", err)
 	}
 
 	// Update adapter — exercise methods that don't require network or
@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/envcheck"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsperm"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/harnessver"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorHarness string
+	doctorDeep    bool
+	doctorGit     bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [provider]",
+	Short: "Check the setup for a provider",
+	Long: `Check that the configuration, API key, and harness needed to switch to a
+provider (the default provider if none is given) are in place, and that the
+config directory and the temp directory (temp_dir in config.yaml when set)
+can be written. A write that endpoint security software blocks is reported as
+such rather than as a permission problem.
+
+A provider can require a minimum harness version in config.yaml:
+
+  providers:
+    zai:
+      min_harness_version:
+        claude: 2.0.0
+
+For the claude harness, doctor also reads the env blocks of Claude Code's
+settings files (user, project, project local, and managed) and lists the
+variables that take precedence over the ones kairo sets, which is why a
+base URL or model configured in kairo can appear not to apply:
+
+  kairo doctor --harness claude
+
+When the config directory is inside a git worktree, such as a dotfiles
+repository, doctor checks that git ignores age.key, secrets.age, and the
+other key and secrets files in it. With --git, doctor runs only that check,
+also looks through the worktree holding the current directory for copies of
+those files, and offers to add the ones git would commit to .gitignore.
+
+With --deep, doctor also runs the real wrapper with kairo itself in place
+of the harness, checking that the API key reaches the harness in its
+environment, the token file is deleted before it starts, arguments arrive
+unchanged, and its exit status and signals are passed through.
+
+Exits with status 1 if any check fails.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if doctorGit {
+			runDoctorGit(cmd)
+
+			return
+		}
+		results := runDoctorChecks(cmd, args)
+		if cliCtx := CLIContextFromCmd(cmd); doctorDeep && cliCtx != nil {
+			results = append(results, checkDoctorWrapper(cliCtx)...)
+		}
+		printDoctorResults(cmd.OutOrStdout(), results)
+
+		if doctorFailed(results) {
+			if cliCtx := CLIContextFromCmd(cmd); cliCtx != nil && cliCtx.Deps() != nil {
+				cliCtx.Deps().Process.ExitProcess(1)
+			}
+		}
+	},
+}
+
+func init() {
+	doctorCmd.Flags().StringVar(&doctorHarness, "harness", "", "Harness to check instead of the configured default (claude also checks Claude Code settings)")
+	doctorCmd.Flags().BoolVar(&doctorDeep, "deep", false, "Also run the real wrapper to check how it hands over the API key, arguments, and signals")
+	doctorCmd.Flags().BoolVar(&doctorGit, "git", false, "Only check that git ignores kairo's key and secrets files, and offer to add them to .gitignore")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// runDoctorGit runs 'kairo doctor --git'.
+func runDoctorGit(cmd *cobra.Command) {
+	results, exposures := runDoctorGitChecks(cmd)
+	printDoctorResults(cmd.OutOrStdout(), results)
+	if err := offerGitIgnore(exposures); err != nil {
+		printError(err)
+	}
+
+	if doctorFailed(results) {
+		if cliCtx := CLIContextFromCmd(cmd); cliCtx != nil && cliCtx.Deps() != nil {
+			cliCtx.Deps().Process.ExitProcess(1)
+		}
+	}
+}
+
+type doctorStatus int
+
+const (
+	doctorOK doctorStatus = iota
+	doctorWarn
+	doctorFail
+)
+
+// doctorResult is the outcome of a single doctor check.
+type doctorResult struct {
+	Name   string
+	Status doctorStatus
+	Detail string
+}
+
+// runDoctorChecks runs the checks in order, stopping early when a check
+// leaves nothing for the later ones to inspect.
+func runDoctorChecks(cmd *cobra.Command, args []string) []doctorResult {
+	cliCtx := CLIContextFromCmd(cmd)
+	if cliCtx == nil {
+		return []doctorResult{{Name: "config", Status: doctorFail, Detail: "CLI context not available"}}
+	}
+
+	dir := cliCtx.ConfigDir()
+	if dir == "" {
+		return []doctorResult{{Name: "config", Status: doctorFail, Detail: "config directory not found"}}
+	}
+
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil {
+		detail := err.Error()
+		if errors.Is(err, kairoerrors.ErrConfigNotFound) {
+			detail = "no configuration found; run 'kairo setup'"
+		}
+
+		return []doctorResult{{Name: "config", Status: doctorFail, Detail: detail}}
+	}
+	results := []doctorResult{
+		{Name: "config", Status: doctorOK, Detail: dir},
+		checkDoctorWritable("config dir", dir, writeBlockedHint(dir)),
+		checkDoctorWritable("temp dir", configuredTempDir(cliCtx), tempDirBlockedHint),
+	}
+	gitResult, _ := checkDoctorGit(cliCtx, dir, "run 'kairo doctor --git' to add them to .gitignore")
+	results = append(results, gitResult)
+
+	providerName := cfg.DefaultProvider
+	if len(args) > 0 {
+		providerName = args[0]
+	}
+	provider, ok := cfg.Providers[providerName]
+	switch {
+	case providerName == "":
+		return append(results, doctorResult{Name: "provider", Status: doctorFail,
+			Detail: "no default provider; run 'kairo default <provider>'"})
+	case !ok:
+		return append(results, doctorResult{Name: "provider", Status: doctorFail,
+			Detail: fmt.Sprintf("'%s' is not configured", providerName)})
+	}
+	pol, id, err := loadPolicy()
+	if err == nil {
+		err = checkProviderPolicy(pol, id, providerName)
+	}
+	if err != nil {
+		return append(results, doctorResult{Name: "provider", Status: doctorFail, Detail: kairoerrors.Describe(err)})
+	}
+	results = append(results, doctorResult{Name: "provider", Status: doctorOK, Detail: providerName})
+
+	results = append(results, checkDoctorAPIKey(cliCtx, dir, providerName))
+
+	h := harness.Resolve(doctorHarness, cfg.DefaultHarness)
+	if h == harness.Claude {
+		results = append(results, checkClaudeSettings(providerName, provider)...)
+	}
+	path, err := cliCtx.Deps().Process.LookPath(h)
+	if err != nil || path == "" {
+		return append(results, doctorResult{Name: "harness", Status: doctorFail,
+			Detail: fmt.Sprintf("'%s' not found in PATH", h)})
+	}
+	results = append(results, doctorResult{Name: "harness", Status: doctorOK, Detail: path})
+
+	version := harnessBinaryVersion(cliCtx.RootCtx(), cliCtx.Deps(), path)
+
+	return append(results, checkHarnessVersion(h, version, providerName, provider))
+}
+
+// checkDoctorWritable creates and removes a file in dir, creating dir when it
+// is missing as a launch does for temp_dir. A write that endpoint security
+// refuses is told apart from one the permissions refuse, with hint as the
+// next step.
+func checkDoctorWritable(name, dir, hint string) doctorResult {
+	err := os.MkdirAll(dir, constants.DirPermSecure)
+	if err == nil {
+		var f *os.File
+		if f, err = os.CreateTemp(dir, ".kairo-doctor-"); err == nil {
+			err = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}
+	switch {
+	case err == nil:
+		return doctorResult{Name: name, Status: doctorOK, Detail: dir}
+	case fsperm.Blocked(dir, err):
+		return doctorResult{Name: name, Status: doctorFail,
+			Detail: doctorErrorDetail(blockedError("cannot write to "+dir, dir, hint, err))}
+	default:
+		return doctorResult{Name: name, Status: doctorFail, Detail: fmt.Sprintf("cannot write to %s: %v", dir, err)}
+	}
+}
+
+// doctorErrorDetail returns err's description followed by its suggested
+// next step.
+func doctorErrorDetail(err error) string {
+	return kairoerrors.Describe(err) + "; " + kairoerrors.Suggest(err)
+}
+
+func checkDoctorAPIKey(cliCtx *CLIContext, dir, providerName string) doctorResult {
+	if !providers.RequiresAPIKey(providerName) {
+		return doctorResult{Name: "api key", Status: doctorOK, Detail: "not required"}
+	}
+
+	secretsResult, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		return doctorResult{Name: "api key", Status: doctorFail, Detail: err.Error()}
+	}
+	key, ok, err := newSecretResolver(cliCtx).resolveAPIKey(secretsResult.Secrets, providerName)
+	if !ok {
+		return doctorResult{Name: "api key", Status: doctorFail,
+			Detail: fmt.Sprintf("not set; run 'kairo secrets set %s'", providerName)}
+	}
+	if err != nil {
+		return doctorResult{Name: "api key", Status: doctorFail, Detail: kairoerrors.Describe(err)}
+	}
+
+	if key != "" {
+		notifySecretAccess(cliCtx, dir, providerName, accessPurposeDoctor)
+		if warnings := ProviderDefinition(providerName).KeyStrengthWarnings(key); len(warnings) > 0 {
+			return doctorResult{Name: "api key", Status: doctorWarn, Detail: warnings[0]}
+		}
+	}
+
+	return doctorResult{Name: "api key", Status: doctorOK, Detail: "stored"}
+}
+
+// checkClaudeSettings reports the variables in Claude Code's settings files
+// that take precedence over the ones kairo injects for the provider.
+func checkClaudeSettings(providerName string, provider config.Provider) []doctorResult {
+	const name = "claude settings"
+
+	env, from, err := claudeSettingsEnv()
+	var results []doctorResult
+	if err != nil {
+		results = append(results, doctorResult{Name: name, Status: doctorWarn, Detail: kairoerrors.Describe(err)})
+	}
+
+	apiKey := ""
+	if providers.RequiresAPIKey(providerName) {
+		// Only the variable that carries the key matters, not its value.
+		apiKey = "****"
+	}
+	in := envcheck.Input{
+		Injected: injectedEnv(ExecutionConfig{
+			Provider: provider, ProviderName: providerName, HarnessToUse: harness.Claude, APIKey: apiKey,
+		}),
+		Settings:     env,
+		SettingsFrom: from,
+	}
+	for _, c := range envcheck.Check(in) {
+		results = append(results, doctorResult{Name: name, Status: doctorWarn, Detail: describeConflict(in, c)})
+	}
+	if len(results) == 0 {
+		results = append(results, doctorResult{Name: name, Status: doctorOK,
+			Detail: "no settings override kairo's environment"})
+	}
+
+	return results
+}
+
+// checkHarnessVersion compares the installed harness version with the
+// minimum the provider requires, if any.
+func checkHarnessVersion(h, version, providerName string, provider config.Provider) doctorResult {
+	minVersion := provider.MinHarnessVersion[h]
+	shown := version
+	if shown == "" {
+		shown = "unknown"
+	}
+
+	switch {
+	case minVersion == "":
+		return doctorResult{Name: "harness version", Status: doctorOK, Detail: shown}
+	case version == "":
+		return doctorResult{Name: "harness version", Status: doctorWarn,
+			Detail: fmt.Sprintf("could not determine %s version; %s requires %s or newer", h, providerName, minVersion)}
+	case harnessver.Compare(version, minVersion) < 0:
+		return doctorResult{Name: "harness version", Status: doctorFail,
+			Detail: fmt.Sprintf("%s %s is older than %s required by %s", h, version, minVersion, providerName)}
+	default:
+		return doctorResult{Name: "harness version", Status: doctorOK,
+			Detail: fmt.Sprintf("%s (requires %s)", version, minVersion)}
+	}
+}
+
+func printDoctorResults(out io.Writer, results []doctorResult) {
+	for _, r := range results {
+		mark := ui.Green + "✓" + ui.Reset
+		switch r.Status {
+		case doctorWarn:
+			mark = ui.Yellow + "⚠" + ui.Reset
+		case doctorFail:
+			mark = ui.Red + "✗" + ui.Reset
+		}
+		fmt.Fprintf(out, "%s %-16s %s\n", mark, r.Name, r.Detail)
+	}
+}
+
+func doctorFailed(results []doctorResult) bool {
+	for _, r := range results {
+		if r.Status == doctorFail {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/configsync"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/gitguard"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// gitExposure lists the kairo files of one git worktree that git would
+// commit or already has, relative to its root.
+type gitExposure struct {
+	root    string
+	exposed []string
+	tracked []string
+}
+
+// kairoSecretFile reports whether name is the name of a file kairo keeps
+// keys or API keys in: the age and audit keys, the secrets file, its
+// backups and the corrupt copies set aside by recovery, saved setup
+// answers, and the staged .new copy of any of them.
+func kairoSecretFile(name string) bool {
+	name = strings.TrimSuffix(name, ".new")
+	switch name {
+	case constants.KeyFileName, audit.KeyFileName, constants.SecretsFileName, constants.SetupProgressFileName:
+		return true
+	}
+	n, ok := strings.CutPrefix(name, constants.SecretsFileName+".")
+	if !ok {
+		return false
+	}
+	if strings.HasPrefix(n, "corrupt-") {
+		return true
+	}
+	_, err := strconv.Atoi(n)
+
+	return err == nil
+}
+
+// configDirSecretFiles returns the files of dir that must not be committed,
+// whether or not they exist yet, along with any other file of dir that
+// kairoSecretFile matches, such as secrets backups.
+func configDirSecretFiles(dir string) []string {
+	files := []string{
+		filepath.Join(dir, constants.KeyFileName),
+		filepath.Join(dir, constants.SecretsFileName),
+		filepath.Join(dir, audit.KeyFileName),
+		filepath.Join(dir, constants.SetupProgressFileName),
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		file := filepath.Join(dir, e.Name())
+		if !e.IsDir() && kairoSecretFile(e.Name()) && !slices.Contains(files, file) {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+// gitRunner returns the command runner for git, or nil when git is not
+// installed.
+func gitRunner(cliCtx *CLIContext) gitguard.Runner {
+	if path, err := cliCtx.Deps().Process.LookPath("git"); err != nil || path == "" {
+		return nil
+	}
+
+	return cliCtx.Deps().Process.ExecCommandContext
+}
+
+// configDirGitExposure checks the files of the config directory dir against
+// the git worktree at root that holds it.
+func configDirGitExposure(cliCtx *CLIContext, run gitguard.Runner, root, dir string) (gitExposure, error) {
+	exposure := gitExposure{root: root}
+	var rels []string
+	for _, file := range configDirSecretFiles(dir) {
+		rel, err := gitguard.Rel(root, file)
+		if err != nil {
+			return exposure, err
+		}
+		rels = append(rels, rel)
+	}
+	statuses, err := gitguard.Check(cliCtx.RootCtx(), run, root, rels)
+	if err != nil {
+		return exposure, err
+	}
+	for _, rel := range rels {
+		exposure.add(rel, statuses[rel])
+	}
+
+	return exposure, nil
+}
+
+// worktreeGitExposure looks through the git worktree holding the current
+// directory for kairo key and secrets files outside the config directory,
+// such as copies of it. The secrets file of a 'kairo sync' directory, which
+// is only written there with a KMS backend, is meant to be committed.
+func worktreeGitExposure(cliCtx *CLIContext, run gitguard.Runner, configDir string) (gitExposure, bool, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return gitExposure{}, false, nil
+	}
+	root, ok := gitguard.Worktree(cwd)
+	if !ok {
+		return gitExposure{}, false, nil
+	}
+	skipDir := ""
+	if configRoot, ok := gitguard.Worktree(configDir); ok && configRoot == root {
+		if rel, err := gitguard.Rel(root, filepath.Join(configDir, constants.KeyFileName)); err == nil {
+			skipDir = path.Dir(rel)
+		}
+	}
+
+	found, err := gitguard.Find(cliCtx.RootCtx(), run, root, func(p string) bool {
+		name := path.Base(p)
+		if !kairoSecretFile(name) || path.Dir(p) == skipDir {
+			return false
+		}
+		if name == constants.SecretsFileName {
+			_, err := os.Stat(filepath.Join(root, filepath.FromSlash(path.Dir(p)), configsync.ManifestName))
+
+			return err != nil
+		}
+
+		return true
+	})
+	exposure := gitExposure{root: root}
+	if err != nil {
+		return exposure, true, err
+	}
+	for _, p := range slices.Sorted(maps.Keys(found)) {
+		exposure.add(p, found[p])
+	}
+
+	return exposure, true, nil
+}
+
+func (g *gitExposure) add(rel string, status gitguard.Status) {
+	switch status {
+	case gitguard.Exposed:
+		g.exposed = append(g.exposed, rel)
+	case gitguard.Tracked:
+		g.tracked = append(g.tracked, rel)
+	}
+}
+
+// files returns the files git would commit or already has.
+func (g gitExposure) files() []string {
+	return slices.Concat(g.exposed, g.tracked)
+}
+
+// result describes the exposure as the doctor check name; fix is the next
+// step for files git would commit.
+func (g gitExposure) result(name, clean, fix string) doctorResult {
+	switch {
+	case len(g.tracked) > 0:
+		return doctorResult{Name: name, Status: doctorFail, Detail: fmt.Sprintf(
+			"%s already tracked in %s; run 'git rm --cached' on them and rotate the keys they hold, as they stay in the history",
+			strings.Join(g.tracked, ", "), g.root)}
+	case len(g.exposed) > 0:
+		return doctorResult{Name: name, Status: doctorWarn, Detail: fmt.Sprintf(
+			"%s not ignored by %s; %s", strings.Join(g.exposed, ", "), g.root, fix)}
+	default:
+		return doctorResult{Name: name, Status: doctorOK, Detail: clean}
+	}
+}
+
+// checkDoctorGit checks that the key and secrets files of the config
+// directory dir are ignored when it is inside a git worktree, with fix as
+// the next step for those that are not.
+func checkDoctorGit(cliCtx *CLIContext, dir, fix string) (doctorResult, gitExposure) {
+	const name = "git"
+
+	root, ok := gitguard.Worktree(dir)
+	if !ok {
+		return doctorResult{Name: name, Status: doctorOK, Detail: "config dir is not in a git worktree"}, gitExposure{}
+	}
+	run := gitRunner(cliCtx)
+	if run == nil {
+		return doctorResult{Name: name, Status: doctorWarn, Detail: fmt.Sprintf(
+			"config dir is in the git worktree %s, but git is not in PATH to check its ignore rules", root)}, gitExposure{}
+	}
+	exposure, err := configDirGitExposure(cliCtx, run, root, dir)
+	if err != nil {
+		return doctorResult{Name: name, Status: doctorWarn, Detail: doctorErrorDetail(err)}, gitExposure{}
+	}
+
+	return exposure.result(name, "key and secrets files ignored by "+root, fix), exposure
+}
+
+// runDoctorGitChecks runs the checks of 'kairo doctor --git': the config
+// directory, then the worktree holding the current directory.
+func runDoctorGitChecks(cmd *cobra.Command) ([]doctorResult, map[string][]string) {
+	cliCtx := CLIContextFromCmd(cmd)
+	if cliCtx == nil {
+		return []doctorResult{{Name: "git", Status: doctorFail, Detail: "CLI context not available"}}, nil
+	}
+	dir := cliCtx.ConfigDir()
+	if dir == "" {
+		return []doctorResult{{Name: "git", Status: doctorFail, Detail: "config directory not found"}}, nil
+	}
+
+	result, exposure := checkDoctorGit(cliCtx, dir, "add them to .gitignore")
+	results := []doctorResult{result}
+	// Tracked files are offered too, for after 'git rm --cached'.
+	exposures := map[string][]string{}
+	if files := exposure.files(); len(files) > 0 {
+		exposures[exposure.root] = files
+	}
+
+	const name = "git worktree"
+	run := gitRunner(cliCtx)
+	if run == nil {
+		return results, exposures
+	}
+	found, ok, err := worktreeGitExposure(cliCtx, run, dir)
+	switch {
+	case !ok:
+		results = append(results, doctorResult{Name: name, Status: doctorOK,
+			Detail: "current directory is not in a git worktree"})
+	case err != nil:
+		results = append(results, doctorResult{Name: name, Status: doctorWarn, Detail: doctorErrorDetail(err)})
+	default:
+		results = append(results, found.result(name, "no kairo key or secrets files in "+found.root,
+			"move them out of the worktree or add them to .gitignore"))
+		if files := found.files(); len(files) > 0 {
+			exposures[found.root] = append(exposures[found.root], files...)
+		}
+	}
+
+	return results, exposures
+}
+
+// offerGitIgnore adds the files git would commit, by worktree root, to the
+// .gitignore of their worktree, after asking. With prompts off and no --yes,
+// it prints the lines to add instead.
+func offerGitIgnore(exposed map[string][]string) error {
+	for _, root := range slices.Sorted(maps.Keys(exposed)) {
+		ignorePath := filepath.Join(root, gitguard.IgnoreFileName)
+		entries := gitguard.Entries(exposed[root])
+		if nonInteractive() && !yesFlag {
+			ui.PrintInfo(fmt.Sprintf("Add these lines to %s, or rerun with --yes to add them:", ignorePath))
+			for _, e := range entries {
+				fmt.Println("  " + e)
+			}
+
+			continue
+		}
+		ok, err := confirmed("writing "+ignorePath, func() (bool, error) {
+			return ui.Confirm(fmt.Sprintf("Add %d entries to %s", len(entries), ignorePath))
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := gitguard.AppendIgnore(root, entries); err != nil {
+			return err
+		}
+		ui.PrintSuccess(fmt.Sprintf("Added %s to %s", strings.Join(entries, ", "), ignorePath))
+	}
+
+	return nil
+}
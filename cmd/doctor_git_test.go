@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/gitguard"
+)
+
+func TestKairoSecretFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"age.key":                              true,
+		"audit.key":                            true,
+		"secrets.age":                          true,
+		"secrets.age.3":                        true,
+		"setup-progress.age":                   true,
+		"secrets.age.new":                      true,
+		"age.key.new":                          true,
+		"secrets.age.corrupt-20261015T120000Z": true,
+		"secrets.age.tmp":                      false,
+		"config.yaml.new":                      false,
+		"recipients":                           false,
+		"config.yaml":                          false,
+	} {
+		if got := kairoSecretFile(name); got != want {
+			t.Errorf("kairoSecretFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCheckDoctorGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not in PATH")
+	}
+	root := t.TempDir()
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	dir := filepath.Join(root, ".config", "kairo")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "age.key"), []byte("key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(testDeps(func(mp *mockProcess, _ *mockWrapper, _ *mockUpdate) {
+		mp.LookPathFn = exec.LookPath
+		mp.ExecCommandContextFn = exec.CommandContext
+	}))
+
+	result, exposure := checkDoctorGit(cliCtx, dir, "fix it")
+	if result.Status != doctorWarn || !strings.Contains(result.Detail, ".config/kairo/age.key") ||
+		!strings.HasSuffix(result.Detail, "fix it") {
+		t.Fatalf("checkDoctorGit() = %+v, want a warning naming age.key", result)
+	}
+
+	originalYes := yesFlag
+	yesFlag = true
+	defer func() { yesFlag = originalYes }()
+	if err := offerGitIgnore(map[string][]string{root: exposure.files()}); err != nil {
+		t.Fatalf("offerGitIgnore() error = %v", err)
+	}
+	if result, _ := checkDoctorGit(cliCtx, dir, "fix it"); result.Status != doctorOK {
+		t.Errorf("checkDoctorGit() after offerGitIgnore = %+v, want ok", result)
+	}
+
+	if out, err := exec.Command("git", "-C", root, "add", "-f", ".config/kairo/age.key").CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v: %s", err, out)
+	}
+	if result, _ := checkDoctorGit(cliCtx, dir, "fix it"); result.Status != doctorFail {
+		t.Errorf("checkDoctorGit() with age.key tracked = %+v, want failure", result)
+	}
+
+	if result, _ := checkDoctorGit(cliCtx, t.TempDir(), "fix it"); result.Status != doctorOK {
+		t.Errorf("checkDoctorGit() outside a worktree = %+v, want ok", result)
+	}
+	data, err := os.ReadFile(filepath.Join(root, gitguard.IgnoreFileName))
+	if err != nil || !strings.Contains(string(data), "/.config/kairo/age.key\n") {
+		t.Errorf(".gitignore = %q, %v", data, err)
+	}
+}
+
+func TestCheckDoctorGit_CorruptAndStagedSecrets(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not in PATH")
+	}
+	root := t.TempDir()
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	dir := filepath.Join(root, ".config", "kairo")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"secrets.age.corrupt-20261015T120000Z", "secrets.age.new"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("age"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(testDeps(func(mp *mockProcess, _ *mockWrapper, _ *mockUpdate) {
+		mp.LookPathFn = exec.LookPath
+		mp.ExecCommandContextFn = exec.CommandContext
+	}))
+
+	result, _ := checkDoctorGit(cliCtx, dir, "fix it")
+	for _, want := range []string{".config/kairo/secrets.age.corrupt-20261015T120000Z", ".config/kairo/secrets.age.new"} {
+		if result.Status != doctorWarn || !strings.Contains(result.Detail, want) {
+			t.Errorf("checkDoctorGit() = %+v, want a warning naming %s", result, want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func TestCheckHarnessVersion(t *testing.T) {
+	provider := config.Provider{MinHarnessVersion: map[string]string{"claude": "2.0.0"}}
+
+	tests := []struct {
+		name     string
+		harness  string
+		version  string
+		provider config.Provider
+		want     doctorStatus
+	}{
+		{"no minimum", "claude", "1.0.0", config.Provider{}, doctorOK},
+		{"minimum for other harness", "qwen", "0.1.0", provider, doctorOK},
+		{"meets minimum", "claude", "2.0.14", provider, doctorOK},
+		{"below minimum", "claude", "1.9.9", provider, doctorFail},
+		{"unknown version", "claude", "", provider, doctorWarn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkHarnessVersion(tt.harness, tt.version, "zai", tt.provider)
+			if got.Status != tt.want {
+				t.Errorf("checkHarnessVersion() = %+v, want status %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunDoctorChecks(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		DefaultProvider: "zai",
+		Providers: map[string]config.Provider{
+			"zai": {Name: "Z.AI", MinHarnessVersion: map[string]string{"claude": "2.0.0"}},
+		},
+	}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	d := testDeps(func(mp *mockProcess, _ *mockWrapper, _ *mockUpdate) {
+		mp.LookPathFn = func(string) (string, error) { return "/usr/bin/claude", nil }
+		mp.ExecCommandContextFn = func(ctx context.Context, _ string, _ ...string) *exec.Cmd {
+			return exec.CommandContext(ctx, "echo", "1.5.0 (Claude Code)")
+		}
+	})
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(dir)
+	cliCtx.SetDeps(d)
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	results := runDoctorChecks(cmd, nil)
+	byName := map[string]doctorResult{}
+	for _, r := range results {
+		byName[r.Name] = r
+	}
+
+	if byName["config"].Status != doctorOK || byName["provider"].Status != doctorOK {
+		t.Errorf("config/provider checks failed: %+v", results)
+	}
+	if byName["config dir"].Status != doctorOK || byName["temp dir"].Status != doctorOK {
+		t.Errorf("write checks failed: %+v, %+v", byName["config dir"], byName["temp dir"])
+	}
+	if byName["api key"].Status != doctorFail {
+		t.Errorf("api key check = %+v, want failure with no secrets", byName["api key"])
+	}
+	if byName["harness version"].Status != doctorFail {
+		t.Errorf("harness version check = %+v, want failure below minimum", byName["harness version"])
+	}
+	if !doctorFailed(results) {
+		t.Error("doctorFailed() = false, want true")
+	}
+}
+
+func TestRunDoctorChecksUnknownProvider(t *testing.T) {
+	dir := t.TempDir()
+	if err := config.SaveConfig(context.Background(), dir, &config.Config{Providers: map[string]config.Provider{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(dir)
+	cliCtx.SetDeps(testDeps())
+	cmd := &cobra.Command{}
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	results := runDoctorChecks(cmd, []string{"nope"})
+	last := results[len(results)-1]
+	if last.Name != "provider" || last.Status != doctorFail {
+		t.Errorf("last result = %+v, want provider failure", last)
+	}
+}
+
+func TestRunDoctorChecksProviderDeniedByPolicy(t *testing.T) {
+	setTestPolicy(t, "providers:\n  deny: [zai]\n")
+	dir := t.TempDir()
+	cfg := &config.Config{DefaultProvider: "zai", Providers: map[string]config.Provider{"zai": {Name: "Z.AI"}}}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(dir)
+	cliCtx.SetDeps(testDeps())
+	cmd := &cobra.Command{}
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	results := runDoctorChecks(cmd, nil)
+	last := results[len(results)-1]
+	if last.Name != "provider" || last.Status != doctorFail || !strings.Contains(last.Detail, "not allowed") {
+		t.Errorf("last result = %+v, want the denied default provider to stop the checks before its key is read", last)
+	}
+}
+
+func TestCheckClaudeSettings(t *testing.T) {
+	claudeDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", claudeDir)
+	t.Chdir(t.TempDir())
+	provider := config.Provider{Name: "Z.AI", BaseURL: "https://api.z.ai/api/anthropic", Model: "glm-5.1"}
+
+	results := checkClaudeSettings("zai", provider)
+	if len(results) != 1 || results[0].Status != doctorOK {
+		t.Errorf("checkClaudeSettings() without settings = %+v, want one ok result", results)
+	}
+
+	settings := `{"env": {"ANTHROPIC_BASE_URL": "https://proxy.example.com", "ANTHROPIC_API_KEY": "sk-ant-settings-key"}}`
+	if err := os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(settings), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	results = checkClaudeSettings("zai", provider)
+	var details []string
+	for _, r := range results {
+		if r.Status != doctorWarn {
+			t.Errorf("result = %+v, want a warning", r)
+		}
+		details = append(details, r.Detail)
+	}
+	got := strings.Join(details, "\n")
+	for _, want := range []string{
+		"the harness uses https://proxy.example.com, not kairo's https://api.z.ai/api/anthropic",
+		"ANTHROPIC_API_KEY from " + filepath.Join(claudeDir, "settings.json") + " competes with kairo",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("checkClaudeSettings() details missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "sk-ant-settings-key") {
+		t.Errorf("checkClaudeSettings() leaked a key:\n%s", got)
+	}
+}
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsutil"
+	"github.com/dkmnx/kairo/internal/secrets"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/yarlson/tap"
+)
+
+var (
+	escrowIdentity string
+	escrowSecrets  string
+	escrowReason   string
+)
+
+var escrowCmd = &cobra.Command{
+	Use:   "escrow",
+	Short: "Manage the organization's break-glass escrow recipient",
+	Long: `An organization can have every secrets.age also encrypted to an escrow
+recipient: the public key of an administrator, kept in the escrow file beside
+age.key. When someone leaves, the administrator can then recover the API keys
+they stored without their age.key.
+
+Set the recipient with 'kairo escrow set <recipient>' or
+'kairo setup --escrow-recipient <recipient>'. Once it is set, every write of
+secrets.age encrypts to it, and a write fails rather than leave it out when
+the escrow file cannot be read. Escrow only applies to the age crypto backend.
+
+'kairo escrow open' decrypts with the escrow identity. It refuses unless the
+decryption can first be recorded as an 'escrow' audit event, and
+'kairo audit --analyze' lists every such event. This is enforced by kairo,
+not by the encryption: anyone holding the escrow identity can decrypt a copy
+of secrets.age with age directly, so keep it offline.`,
+}
+
+var escrowShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the escrow recipient",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runEscrowShow(cmd); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+var escrowSetCmd = &cobra.Command{
+	Use:   "set <recipient>",
+	Short: "Also encrypt the secrets file to an escrow recipient",
+	Long: `Set the escrow recipient, replacing any earlier one, and re-encrypt an
+existing secrets.age to it. Copies of secrets.age taken before stay readable
+by the escrow identity they were encrypted to.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := requireConfigDirWritable(cmd)
+		if dir == "" {
+			return
+		}
+		if err := setEscrowRecipient(CLIContextFromCmd(cmd), dir, args[0]); err != nil {
+			printError(err)
+		}
+	},
+}
+
+var escrowOpenCmd = &cobra.Command{
+	Use:   "open",
+	Short: "Decrypt a secrets file with the escrow identity",
+	Long: `Decrypt secrets.age with the escrow identity and print its entries as
+KEY=value lines. --secrets names a copy of another person's file; by default
+the one in the config directory is opened.
+
+The decryption is recorded first as an 'escrow' audit event in the config
+directory's audit log, with --reason, the file, and the fingerprint of each
+key, never its value. When auditing is off, leaves out 'escrow' events, or
+cannot be written, nothing is decrypted.`,
+	Example: `  kairo escrow open --identity /media/admin/escrow.key --secrets ./alice/secrets.age \
+    --reason "offboarding alice, ticket IT-4821"`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runEscrowOpen(cmd); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+			CLIContextFromCmd(cmd).Deps().Process.ExitProcess(1)
+		}
+	},
+}
+
+func init() {
+	escrowOpenCmd.Flags().StringVar(&escrowIdentity, "identity", "", "File holding the escrow age identity")
+	escrowOpenCmd.Flags().StringVar(&escrowSecrets, "secrets", "",
+		"Secrets file to open (default: secrets.age in the config directory)")
+	escrowOpenCmd.Flags().StringVar(&escrowReason, "reason", "", "Why the secrets are recovered, recorded in the audit log")
+	_ = escrowOpenCmd.MarkFlagRequired("identity")
+	_ = escrowOpenCmd.MarkFlagRequired("reason")
+	escrowCmd.AddCommand(escrowShowCmd)
+	escrowCmd.AddCommand(escrowSetCmd)
+	escrowCmd.AddCommand(escrowOpenCmd)
+	rootCmd.AddCommand(escrowCmd)
+}
+
+func runEscrowShow(cmd *cobra.Command) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	recipient, err := crypto.ReadEscrow(filepath.Join(dir, constants.EscrowFileName))
+	if err != nil {
+		return err
+	}
+	if recipient == "" {
+		ui.PrintInfo("No escrow recipient; set one with 'kairo escrow set <recipient>'")
+
+		return nil
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), recipient)
+
+	return nil
+}
+
+// setEscrowRecipient writes recipient to the escrow file of dir and
+// re-encrypts an existing secrets file to it.
+func setEscrowRecipient(cliCtx *CLIContext, dir, recipient string) error {
+	recipient = strings.TrimSpace(recipient)
+	if _, err := age.ParseX25519Recipient(recipient); err != nil {
+		return kairoerrors.WrapError(kairoerrors.ValidationError, "not an age recipient", err).
+			WithContext("hint", "pass the age1... public key of the escrow identity")
+	}
+	if _, err := recipientsConfig(cliCtx, dir); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, constants.EscrowFileName)
+	// An unreadable escrow file is replaced.
+	previous, _ := crypto.ReadEscrow(path)
+	if previous == recipient {
+		ui.PrintInfo("Already the escrow recipient")
+
+		return nil
+	}
+
+	if err := fsutil.WriteAtomic(path, func(f *os.File) error {
+		_, err := fmt.Fprintf(f, "# Escrow age recipient secrets.age is always encrypted to; see 'kairo escrow'.\n%s\n",
+			recipient)
+
+		return err
+	}); err != nil {
+		return kairoerrors.FileError("failed to write escrow file", path, err)
+	}
+	// The audit masker hides age1... strings, so the recipients are recorded
+	// by fingerprint.
+	details := map[string]string{"recipient_fingerprint": secrets.Fingerprint(recipient)}
+	if previous != "" {
+		details["previous_fingerprint"] = secrets.Fingerprint(previous)
+	}
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:   audit.EventConfig,
+		Action:  "set_escrow",
+		Details: details,
+	})
+	ui.PrintSuccess(fmt.Sprintf("Set the escrow recipient (%s)", secrets.Fingerprint(recipient)))
+
+	if _, err := os.Stat(filepath.Join(dir, constants.SecretsFileName)); stderrors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err := rekeySecrets(cliCtx, dir); err != nil {
+		return err
+	}
+	ui.PrintSuccess("Re-encrypted secrets.age to the escrow recipient")
+
+	return nil
+}
+
+func runEscrowOpen(cmd *cobra.Command) error {
+	if strings.TrimSpace(escrowReason) == "" {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "--reason must not be empty")
+	}
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	secretsPath := escrowSecrets
+	if secretsPath == "" {
+		secretsPath = filepath.Join(dir, constants.SecretsFileName)
+	}
+
+	key, err := crypto.ReadKeyFile(escrowIdentity, promptEscrowPassphrase)
+	if err != nil {
+		return err
+	}
+	defer crypto.ClearMemory(key)
+	identity, err := key.Identity()
+	if err != nil {
+		return err
+	}
+	ciphertext, err := os.ReadFile(secretsPath)
+	if err != nil {
+		return kairoerrors.FileError("failed to read secrets file", secretsPath, err)
+	}
+	plaintext, err := crypto.DecryptWithIdentity(cliCtx.RootCtx(), ciphertext, identity)
+	if err != nil {
+		return kairoerrors.WrapError(kairoerrors.CryptoError, "the escrow identity cannot decrypt the secrets file", err).
+			WithContext("path", secretsPath).
+			WithContext("hint", "the file may have been written before the escrow recipient was set")
+	}
+	defer crypto.ClearMemory(plaintext)
+	payload, err := secrets.Open(plaintext)
+	if err != nil {
+		return secretsCorruptedError(secretsPath, err)
+	}
+
+	entries := secrets.Parse(string(payload))
+	details := map[string]string{"reason": escrowReason, "file": secretsPath}
+	for _, name := range slices.Sorted(maps.Keys(entries)) {
+		details[strings.ToLower(name)+"_fingerprint"] = secrets.Fingerprint(entries[name])
+	}
+	if err := requireAudit(cliCtx, dir, audit.Entry{
+		Event:   audit.EventEscrow,
+		Action:  "escrow_open",
+		Details: details,
+	}); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	for _, name := range slices.Sorted(maps.Keys(entries)) {
+		fmt.Fprintf(out, "%s=%s\n", name, entries[name])
+	}
+
+	return nil
+}
+
+// promptEscrowPassphrase asks for the passphrase of a protected escrow
+// identity.
+func promptEscrowPassphrase() (string, error) {
+	if err := requireInteractive("the passphrase of the escrow identity",
+		"run 'kairo escrow open' in a terminal"); err != nil {
+		return "", err
+	}
+	passphrase := tap.Password(promptContext(), tap.PasswordOptions{
+		Message: "Passphrase for " + filepath.Base(escrowIdentity),
+	})
+	if passphrase == "" {
+		return "", kairoerrors.ErrUserCancelled
+	}
+
+	return passphrase, nil
+}
+
+// requireAudit writes e to the audit log of dir, returning an error instead
+// of a warning when auditing is off, leaves out e's event, or fails.
+func requireAudit(cliCtx *CLIContext, dir string, e audit.Entry) error {
+	hint := fmt.Sprintf("set 'audit.enabled: true' in config.yaml, with %q among audit.events if they are listed", e.Event)
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil && !stderrors.Is(err, kairoerrors.ErrConfigNotFound) {
+		return err
+	}
+	policy, enabled, err := auditPolicy(cfg)
+	if err != nil {
+		return err
+	}
+	if !enabled || !policy.Allows(e.Event) {
+		return kairoerrors.NewError(kairoerrors.ConfigError,
+			fmt.Sprintf("refusing to continue: the %q audit event cannot be recorded", e.Event)).
+			WithContext("hint", hint)
+	}
+	if cfg.Audit.Encrypt {
+		if policy.Recipient, err = auditRecipient(cliCtx, dir); err != nil {
+			return err
+		}
+	}
+
+	return audit.NewLogger(stateDir(cliCtx, dir), policy).Log(e)
+}
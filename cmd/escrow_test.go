@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/secrets"
+)
+
+func TestEscrowSetAndOpen(t *testing.T) {
+	defer func() { escrowIdentity, escrowSecrets, escrowReason = "", "", "" }()
+	t.Setenv("KAIRO_STATE_DIR", "")
+
+	dir := t.TempDir()
+	cfg := &config.Config{Providers: map[string]config.Provider{"zai": {Name: "Z.AI"}}}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+	key := "zai-test-key-0123456789abcdef0123456789"
+	if err := storeProviderSecret(NewCLIContext(), dir, "zai", key, "set_secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	admin, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityPath := filepath.Join(t.TempDir(), "escrow.key")
+	identityFile := "# public key: " + admin.Recipient().String() + "\n" + admin.String() + "\n"
+	if err := os.WriteFile(identityPath, []byte(identityFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := setEscrowRecipient(NewCLIContext(), dir, admin.Recipient().String()); err != nil {
+		t.Fatalf("setEscrowRecipient() error = %v", err)
+	}
+
+	escrowIdentity, escrowReason = identityPath, "offboarding"
+	open := func() (string, error) {
+		cmd, out := explainTestCmd(t, dir)
+		err := runEscrowOpen(cmd)
+
+		return out.String(), err
+	}
+
+	// Without an audit log to record it, nothing is decrypted.
+	if out, err := open(); err == nil || !strings.Contains(err.Error(), "audit event") || out != "" {
+		t.Fatalf("runEscrowOpen() without auditing = %q, %v; want a refusal", out, err)
+	}
+
+	cfg.Audit = &config.AuditConfig{Enabled: true}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+	out, err := open()
+	if err != nil {
+		t.Fatalf("runEscrowOpen() error = %v", err)
+	}
+	if out != "ZAI_API_KEY="+key+"\n" {
+		t.Errorf("runEscrowOpen() printed %q", out)
+	}
+
+	entries, err := audit.LoadEntries(dir)
+	if err != nil || len(entries) == 0 {
+		t.Fatalf("LoadEntries() = %v, %v", entries, err)
+	}
+	last := entries[len(entries)-1]
+	if last.Event != audit.EventEscrow || last.Details["reason"] != "offboarding" ||
+		last.Details["zai_api_key_fingerprint"] != secrets.Fingerprint(key) {
+		t.Errorf("last audit entry = %+v, want the escrow open", last)
+	}
+
+	// The owner's own key still decrypts, and so does the rest of kairo.
+	result, err := LoadSecrets(NewCLIContext(), dir)
+	if err != nil || result.Secrets["ZAI_API_KEY"] != key {
+		t.Errorf("LoadSecrets() after setting escrow = %v, %v", result.Secrets, err)
+	}
+}
+
+func TestSetEscrowRecipient_AuditsFingerprints(t *testing.T) {
+	t.Setenv("KAIRO_STATE_DIR", "")
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Providers: map[string]config.Provider{"zai": {Name: "Z.AI"}},
+		Audit:     &config.AuditConfig{Enabled: true, Level: "verbose"},
+	}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	var recipients []string
+	for range 2 {
+		id, err := age.GenerateX25519Identity()
+		if err != nil {
+			t.Fatal(err)
+		}
+		recipients = append(recipients, id.Recipient().String())
+		if err := setEscrowRecipient(NewCLIContext(), dir, id.Recipient().String()); err != nil {
+			t.Fatalf("setEscrowRecipient() error = %v", err)
+		}
+	}
+
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(dir)
+	entries, err := loadAuditEntries(cliCtx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sets []map[string]string
+	for _, e := range entries {
+		if e.Action == "set_escrow" {
+			sets = append(sets, e.Details)
+		}
+	}
+	if len(sets) != 2 {
+		t.Fatalf("set_escrow entries = %v, want 2", sets)
+	}
+	if got := sets[1]["recipient_fingerprint"]; got != secrets.Fingerprint(recipients[1]) {
+		t.Errorf("recipient_fingerprint = %q, want %q", got, secrets.Fingerprint(recipients[1]))
+	}
+	if got := sets[1]["previous_fingerprint"]; got != secrets.Fingerprint(recipients[0]) {
+		t.Errorf("previous_fingerprint = %q, want %q", got, secrets.Fingerprint(recipients[0]))
+	}
+	if _, ok := sets[0]["previous_fingerprint"]; ok {
+		t.Errorf("first set_escrow = %v, want no previous recipient", sets[0])
+	}
+}
+
+func TestSetEscrowRecipient_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := setEscrowRecipient(NewCLIContext(), dir, "age1notarecipient"); err == nil {
+		t.Error("setEscrowRecipient() accepted an invalid recipient")
+	}
+	if _, err := os.Stat(filepath.Join(dir, constants.EscrowFileName)); err == nil {
+		t.Error("setEscrowRecipient() wrote the escrow file for an invalid recipient")
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"os/exec"
+
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/events"
+	"github.com/dkmnx/kairo/internal/execution"
+	"github.com/spf13/cobra"
+)
+
+// eventsFDFlag is where --events-fd writes the switch lifecycle events: a
+// file descriptor number or the path of a file or named pipe.
+var eventsFDFlag string
+
+// openSwitchEvents opens the --events-fd stream for the switch cmd runs and
+// keeps it on the CLI context. It returns false after printing the error
+// when the stream cannot be opened, as whoever asked for it would otherwise
+// wait for events that never come.
+func openSwitchEvents(cmd *cobra.Command, cliCtx *CLIContext) bool {
+	if eventsFDFlag == "" || cliCtx == nil {
+		return true
+	}
+	em, err := events.Open(eventsFDFlag)
+	if err != nil {
+		printCmdError(cmd, err)
+
+		return false
+	}
+	cliCtx.SetEvents(em)
+
+	return true
+}
+
+// closeSwitchEvents ends the event stream of a switch that stopped before
+// the harness ran, and closes it.
+func closeSwitchEvents(cliCtx *CLIContext) {
+	if cliCtx == nil {
+		return
+	}
+	em := cliCtx.Events()
+	em.Finish("kairo stopped before the harness started; see its output")
+	_ = em.Close()
+	cliCtx.SetEvents(nil)
+}
+
+// switchEvents returns the event stream of the switch cmd runs, or nil when
+// none was requested.
+func switchEvents(cmd *cobra.Command) *events.Emitter {
+	if cliCtx := CLIContextFromCmd(cmd); cliCtx != nil {
+		return cliCtx.Events()
+	}
+
+	return nil
+}
+
+// emitSwitchError reports err as the stage that stopped the switch.
+func emitSwitchError(em *events.Emitter, stage string, err error) {
+	em.Emit(events.Event{Event: events.Error, Stage: stage, Error: kairoerrors.Describe(err)})
+}
+
+// runHarnessCommand emits the exec event, runs c with execution.Run, and
+// emits how it ended: the exit code, or the error that kept it from
+// running.
+func runHarnessCommand(em *events.Emitter, wrapped bool, c *exec.Cmd) error {
+	em.Emit(events.Event{Event: events.Exec, Wrapper: &wrapped})
+	err := execution.Run(c)
+
+	code := 0
+	var exitErr *execution.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		code = exitErr.Code
+		ev := events.Event{Event: events.ChildExit, Code: &code}
+		if exitErr.Signal != nil {
+			ev.Signal = exitErr.Signal.String()
+		}
+		em.Emit(ev)
+	case err != nil:
+		emitSwitchError(em, events.Exec, err)
+	default:
+		em.Emit(events.Event{Event: events.ChildExit, Code: &code})
+	}
+
+	return err
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/events"
+)
+
+// readEvents decodes the JSON lines written to path.
+func readEvents(t *testing.T, path string) []events.Event {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []events.Event
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var ev events.Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		out = append(out, ev)
+	}
+
+	return out
+}
+
+func TestRunHarnessCommand(t *testing.T) {
+	if runtime.GOOS == constants.WindowsGOOS {
+		t.Skip("needs a POSIX shell")
+	}
+	tests := []struct {
+		name     string
+		script   string
+		wantCode int
+	}{
+		{"success", "exit 0", 0},
+		{"exit status", "exit 3", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "events.jsonl")
+			em, err := events.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = runHarnessCommand(em, true, exec.Command("sh", "-c", tt.script))
+			_ = em.Close()
+
+			got := readEvents(t, path)
+			if len(got) != 2 {
+				t.Fatalf("got %d events, want exec and child-exit", len(got))
+			}
+			if got[0].Event != events.Exec || got[0].Wrapper == nil || !*got[0].Wrapper {
+				t.Errorf("first event = %+v, want exec through the wrapper", got[0])
+			}
+			if got[1].Event != events.ChildExit || got[1].Code == nil || *got[1].Code != tt.wantCode {
+				t.Errorf("second event = %+v, want child-exit with code %d", got[1], tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRunHarnessCommand_StartFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	em, err := events.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "no-such-harness")
+	if err := runHarnessCommand(em, false, exec.Command(missing)); err == nil {
+		t.Fatal("runHarnessCommand() of a missing binary succeeded")
+	}
+	_ = em.Close()
+
+	got := readEvents(t, path)
+	if len(got) != 2 || got[1].Event != events.Error || got[1].Stage != events.Exec {
+		t.Errorf("events = %+v, want exec then an error at the exec stage", got)
+	}
+}
+
+func TestCloseSwitchEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	eventsFDFlag = path
+	defer func() { eventsFDFlag = "" }()
+
+	cliCtx := NewCLIContext()
+	cmd := testCmd()
+	if !openSwitchEvents(cmd, cliCtx) {
+		t.Fatalf("openSwitchEvents() failed: %s", outputOf(cmd))
+	}
+	cliCtx.Events().Emit(events.Event{Event: events.Prepare, Provider: "zai"})
+	closeSwitchEvents(cliCtx)
+
+	if cliCtx.Events() != nil {
+		t.Error("closeSwitchEvents() left the stream on the context")
+	}
+	got := readEvents(t, path)
+	if len(got) != 2 || got[1].Event != events.Error || got[1].Stage != events.Decrypt {
+		t.Errorf("events = %+v, want prepare then an error at the decrypt stage", got)
+	}
+}
+
+func TestOpenSwitchEvents_BadTarget(t *testing.T) {
+	eventsFDFlag = filepath.Join(t.TempDir(), "missing", "events.jsonl")
+	defer func() { eventsFDFlag = "" }()
+
+	cliCtx := NewCLIContext()
+	cmd := testCmd()
+	if openSwitchEvents(cmd, cliCtx) {
+		t.Error("openSwitchEvents() succeeded for a path in a missing directory")
+	}
+	if cliCtx.Events() != nil {
+		t.Error("openSwitchEvents() set a stream after failing")
+	}
+}
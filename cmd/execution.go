@@ -1,8 +1,8 @@
 package cmd
 
 import (
-	"context"
-	"os/exec"
+	"cmp"
+	"os"
 
 	"github.com/dkmnx/kairo/internal/config"
 	"github.com/spf13/cobra"
@@ -20,27 +20,14 @@ type ExecutionConfig struct {
 	APIKey        string
 	Yolo          bool
 	Deps          *Deps
+	// TempDir is the directory the auth directory is created in; empty means
+	// the system temp directory.
+	TempDir string
+	// Notices are printed just before the harness starts, after the banner.
+	Notices []string
 }
 
-// WrapperCmd holds parameters for building a wrapper shell command.
-type WrapperCmd struct {
-	Ctx           context.Context
-	WrapperScript string
-	IsWindows     bool
-}
-
-func buildWrapperCommand(deps *Deps, params WrapperCmd) *exec.Cmd {
-	if params.IsWindows {
-		return deps.Process.ExecCommandContext(
-			params.Ctx,
-			"powershell",
-			"-NoProfile",
-			"-ExecutionPolicy",
-			"Bypass",
-			"-File",
-			params.WrapperScript,
-		)
-	}
-
-	return deps.Process.ExecCommandContext(params.Ctx, params.WrapperScript)
+// tempDir returns TempDir, or the system temp directory when it is empty.
+func (c ExecutionConfig) tempDir() string {
+	return cmp.Or(c.TempDir, os.TempDir())
 }
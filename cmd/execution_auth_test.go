@@ -3,13 +3,18 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
 	"github.com/dkmnx/kairo/internal/harness"
 	"github.com/dkmnx/kairo/internal/wrapper"
 )
@@ -17,7 +22,7 @@ import (
 func TestExecuteWithAuth_TokenFileWriteFails(t *testing.T) {
 	tmpDir := t.TempDir()
 	d := testDeps(func(mp *mockProcess, mw *mockWrapper, mu *mockUpdate) {
-		mw.CreateTempAuthDirFn = func() (string, error) {
+		mw.CreateTempAuthDirFn = func(string) (string, error) {
 			return tmpDir, nil
 		}
 		mw.WriteTempTokenFileFn = func(authDir, token string) (string, error) {
@@ -50,6 +55,44 @@ func TestExecuteWithAuth_TokenFileWriteFails(t *testing.T) {
 	}
 }
 
+func TestExecuteWithAuth_TempDirBlocked(t *testing.T) {
+	if runtime.GOOS == constants.WindowsGOOS {
+		t.Skip("Windows reports blocked writes with its own error codes")
+	}
+	tempDir := t.TempDir()
+	var parent, cleaned string
+	d := testDeps(func(mp *mockProcess, mw *mockWrapper, mu *mockUpdate) {
+		mw.CleanStaleAuthDirsFn = func(tmpDir string, _ time.Duration, _ bool) ([]string, error) {
+			cleaned = tmpDir
+
+			return nil, nil
+		}
+		mw.CreateTempAuthDirFn = func(dir string) (string, error) {
+			parent = dir
+
+			return "", &os.PathError{Op: "mkdir", Path: filepath.Join(dir, "kairo-auth-1"), Err: syscall.EPERM}
+		}
+	})
+
+	cmd := testCmd()
+	executeWithAuth(ExecutionConfig{
+		Cmd:           cmd,
+		HarnessToUse:  "claude",
+		HarnessBinary: "claude",
+		APIKey:        "test-api-key",
+		Deps:          d,
+		TempDir:       tempDir,
+	})
+
+	if parent != tempDir || cleaned != tempDir {
+		t.Errorf("auth directory created in %q and cleaned in %q, want %q", parent, cleaned, tempDir)
+	}
+	result := outputOf(cmd)
+	if !strings.Contains(result, blockedMessage) || !strings.Contains(result, "temp_dir") {
+		t.Errorf("output should diagnose endpoint security and suggest temp_dir, got: %s", result)
+	}
+}
+
 func TestExecuteWithAuth_QwenHarness(t *testing.T) {
 	tmpDir := t.TempDir()
 	var execCalled atomic.Bool
@@ -57,7 +100,7 @@ func TestExecuteWithAuth_QwenHarness(t *testing.T) {
 		mp.LookPathFn = func(file string) (string, error) {
 			return "/usr/bin/" + file, nil
 		}
-		mw.CreateTempAuthDirFn = func() (string, error) {
+		mw.CreateTempAuthDirFn = func(string) (string, error) {
 			return tmpDir, nil
 		}
 		tokenPath := filepath.Join(tmpDir, "token")
@@ -104,7 +147,7 @@ func TestExecuteWithAuth_ClaudeHarness(t *testing.T) {
 		mp.LookPathFn = func(file string) (string, error) {
 			return "/usr/bin/" + file, nil
 		}
-		mw.CreateTempAuthDirFn = func() (string, error) {
+		mw.CreateTempAuthDirFn = func(string) (string, error) {
 			return tmpDir, nil
 		}
 		tokenPath := filepath.Join(tmpDir, "token")
@@ -151,17 +194,16 @@ func TestExecuteWithAuth_YoloModeClaude(t *testing.T) {
 		mp.LookPathFn = func(file string) (string, error) {
 			return "/usr/bin/" + file, nil
 		}
-		mw.CreateTempAuthDirFn = func() (string, error) {
+		mw.CreateTempAuthDirFn = func(string) (string, error) {
 			return tmpDir, nil
 		}
 		tokenPath := filepath.Join(tmpDir, "token")
 		mw.WriteTempTokenFileFn = func(authDir, token string) (string, error) {
 			return tokenPath, nil
 		}
-		mw.GenerateWrapperScriptFn = func(cfg wrapper.ScriptConfig) (string, bool, error) {
+		mw.PrepareLaunchFn = func(cfg wrapper.ScriptConfig) (wrapper.Launch, error) {
 			capturedCfg = cfg
-			scriptPath := filepath.Join(tmpDir, "test-wrapper.ps1")
-			return scriptPath, true, nil
+			return wrapper.Launch{Name: "powershell", Args: []string{"-File", filepath.Join(tmpDir, "test-wrapper.ps1")}}, nil
 		}
 		mp.ExecCommandContextFn = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
 			cmd := testEchoCmd()
@@ -210,17 +252,16 @@ func TestExecuteWithAuth_YoloModeQwen(t *testing.T) {
 		mp.LookPathFn = func(file string) (string, error) {
 			return "/usr/bin/" + file, nil
 		}
-		mw.CreateTempAuthDirFn = func() (string, error) {
+		mw.CreateTempAuthDirFn = func(string) (string, error) {
 			return tmpDir, nil
 		}
 		tokenPath := filepath.Join(tmpDir, "token")
 		mw.WriteTempTokenFileFn = func(authDir, token string) (string, error) {
 			return tokenPath, nil
 		}
-		mw.GenerateWrapperScriptFn = func(cfg wrapper.ScriptConfig) (string, bool, error) {
+		mw.PrepareLaunchFn = func(cfg wrapper.ScriptConfig) (wrapper.Launch, error) {
 			capturedCfg = cfg
-			scriptPath := filepath.Join(tmpDir, "test-wrapper.ps1")
-			return scriptPath, true, nil
+			return wrapper.Launch{Name: "powershell", Args: []string{"-File", filepath.Join(tmpDir, "test-wrapper.ps1")}}, nil
 		}
 		mp.ExecCommandContextFn = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
 			cmd := testEchoCmd()
@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"cmp"
 	"fmt"
 	"os"
 
 	"github.com/dkmnx/kairo/internal/config"
 	"github.com/dkmnx/kairo/internal/constants"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
 	"github.com/dkmnx/kairo/internal/providers"
 )
 
@@ -52,6 +55,8 @@ func BuildProviderEnv(
 			return EnvBuildResult{}, err
 		}
 		secretsResult.Secrets = make(map[string]string)
+	} else if cfg, cfgErr := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), configDir); cfgErr == nil {
+		migrateLegacySecrets(cliCtx, configDir, cfg, &secretsResult)
 	}
 
 	providerEnv := mergeEnvVars(os.Environ(), builtIn, provider.EnvVars)
@@ -61,3 +66,55 @@ func BuildProviderEnv(
 		Secrets:     secretsResult.Secrets,
 	}, nil
 }
+
+// qwenLaunch is how Qwen Code is started for one provider.
+type qwenLaunch struct {
+	AuthType string
+	// KeyEnvVar is the variable the wrapper exports the API key as.
+	KeyEnvVar string
+	// Env holds the base URL and model variables for AuthType.
+	Env  []string
+	Args []string
+	// Settings is the settings.json to write, empty unless the provider
+	// sets qwen.write_settings.
+	Settings string
+}
+
+// buildQwenLaunch maps provider's qwen settings onto Qwen Code's variables
+// and arguments.
+func buildQwenLaunch(provider config.Provider) (qwenLaunch, error) {
+	var qwenCfg config.QwenConfig
+	if provider.Qwen != nil {
+		qwenCfg = *provider.Qwen
+	}
+
+	authType := cmp.Or(qwenCfg.AuthType, harness.QwenAuthAnthropic)
+	vars, ok := harness.QwenEnv(authType)
+	if !ok {
+		return qwenLaunch{}, kairoerrors.NewError(kairoerrors.ConfigError,
+			fmt.Sprintf("invalid qwen.auth_type %q", authType)).
+			WithContext("hint", "use anthropic or openai")
+	}
+
+	baseURL := cmp.Or(qwenCfg.BaseURL, provider.BaseURL)
+	launch := qwenLaunch{
+		AuthType:  authType,
+		KeyEnvVar: vars.APIKey,
+		Env: []string{
+			fmt.Sprintf("%s=%s", vars.BaseURL, baseURL),
+			fmt.Sprintf("%s=%s", vars.Model, provider.Model),
+		},
+		Args: harness.QwenArgs(authType, provider.Model),
+	}
+
+	if qwenCfg.WriteSettings {
+		settings, err := harness.QwenSettings(authType, baseURL, provider.Model)
+		if err != nil {
+			return qwenLaunch{}, kairoerrors.WrapError(kairoerrors.ConfigError,
+				"failed to render Qwen Code settings", err)
+		}
+		launch.Settings = string(settings)
+	}
+
+	return launch, nil
+}
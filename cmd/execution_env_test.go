@@ -272,3 +272,39 @@ func TestYoloModeFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildQwenLaunch(t *testing.T) {
+	provider := config.Provider{BaseURL: "https://api.example.com/anthropic", Model: "m1"}
+
+	launch, err := buildQwenLaunch(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if launch.KeyEnvVar != "ANTHROPIC_API_KEY" || launch.Settings != "" {
+		t.Errorf("default launch = %+v", launch)
+	}
+	if strings.Join(launch.Args, " ") != "--auth-type anthropic --model m1" {
+		t.Errorf("default args = %v", launch.Args)
+	}
+
+	provider.Qwen = &config.QwenConfig{AuthType: harness.QwenAuthOpenAI, BaseURL: "https://api.example.com/v1", WriteSettings: true}
+	launch, err = buildQwenLaunch(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if launch.KeyEnvVar != "OPENAI_API_KEY" || !strings.Contains(launch.Settings, `"selectedType": "openai"`) {
+		t.Errorf("openai launch = %+v", launch)
+	}
+	wantEnv := []string{"OPENAI_BASE_URL=https://api.example.com/v1", "OPENAI_MODEL=m1"}
+	if strings.Join(launch.Env, " ") != strings.Join(wantEnv, " ") {
+		t.Errorf("openai env = %v, want %v", launch.Env, wantEnv)
+	}
+	if got := authEnvVarName(ExecutionConfig{HarnessToUse: harness.Qwen, Provider: provider}); got != "OPENAI_API_KEY" {
+		t.Errorf("authEnvVarName() = %q, want OPENAI_API_KEY", got)
+	}
+
+	provider.Qwen.AuthType = "gemini"
+	if _, err := buildQwenLaunch(provider); err == nil {
+		t.Error("buildQwenLaunch() accepted an unknown auth type")
+	}
+}
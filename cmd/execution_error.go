@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"errors"
-	"fmt"
 	"runtime"
 	"strings"
 
@@ -19,7 +18,7 @@ func handleConfigError(cmd *cobra.Command, err error) {
 
 		return
 	}
-	cmd.Printf("Error loading config: %v\n", err)
+	printCmdError(cmd, kairoerrors.WrapError(kairoerrors.ConfigError, "Error loading config", err))
 }
 
 func isBinaryOutdatedError(err error) bool {
@@ -67,6 +66,43 @@ func promptUpgrade(cmd *cobra.Command, err error) {
 }
 
 func handleSecretsError(err error) {
-	ui.PrintError(fmt.Sprintf("Failed to decrypt secrets file: %v", err))
-	printSecretsRecoveryHelp()
+	printError(kairoerrors.WrapError(kairoerrors.CryptoError, "failed to decrypt secrets file", err).
+		WithContext("hint", secretsRecoveryHint))
+}
+
+// secretsRecoveryHint is the next step when the secrets file cannot be decrypted.
+const secretsRecoveryHint = "run 'kairo secrets recover' to restore a backup of secrets.age, restore " +
+	"age.key and secrets.age from your own backup, or run 'kairo setup --reset-secrets' to re-enter API keys"
+
+// errReported is returned by code that has already printed why it stopped,
+// such as a missing config directory, so that callers stop without printing
+// it again. Unlike kairoerrors.ErrUserCancelled, it does not mean the user
+// declined anything; printError and printCmdError print nothing for it.
+var errReported = errors.New("already reported")
+
+// printError prints err on stderr, followed by exactly one suggested next
+// step from kairoerrors.Suggest. A write refused by endpoint security is
+// reported as such (see diagnoseBlocked).
+func printError(err error) {
+	if errors.Is(err, errReported) {
+		return
+	}
+	err = diagnoseBlocked(err)
+	ui.PrintError(kairoerrors.Describe(err))
+	if s := kairoerrors.Suggest(err); s != "" {
+		ui.PrintSuggestion(s)
+	}
+}
+
+// printCmdError is printError for code paths that report through the
+// command's output stream.
+func printCmdError(cmd *cobra.Command, err error) {
+	if errors.Is(err, errReported) {
+		return
+	}
+	err = diagnoseBlocked(err)
+	cmd.Println(kairoerrors.Describe(err))
+	if s := kairoerrors.Suggest(err); s != "" {
+		cmd.Printf("  → %s\n", s)
+	}
 }
@@ -2,17 +2,24 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"runtime"
+	"slices"
 	"sync"
 
 	"github.com/dkmnx/kairo/internal/config"
 	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/events"
 	"github.com/dkmnx/kairo/internal/execution"
+	"github.com/dkmnx/kairo/internal/fsperm"
 	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/health"
 	"github.com/dkmnx/kairo/internal/ui"
 	"github.com/dkmnx/kairo/internal/version"
 	"github.com/dkmnx/kairo/internal/wrapper"
+	"github.com/spf13/cobra"
 )
 
 // HarnessRun holds the state for a single harness execution.
@@ -23,14 +30,17 @@ type HarnessRun struct {
 	CliArgs       []string
 	ProviderEnv   []string
 	Provider      config.Provider
+	ProviderName  string
 	EnvVarName    string
 	Harness       string
+	// Events is the switch's event stream, if any.
+	Events *events.Emitter
 }
 
 // runHarnessExec is the shared harness-execution primitive. It locates the
 // binary in PATH, prints the Kairo banner (skipped for Crush which has its
-// own), starts a signal-aware session, and runs the binary with the standard
-// stdin/stdout/stderr wiring. On error it returns the error so the caller can
+// own), and runs the binary with the standard stdin/stdout/stderr wiring,
+// forwarding signals to it. On error it returns the error so the caller can
 // decide whether to exit or recover.
 func runHarnessExec(cfg ExecutionConfig, harnessPath string, cliArgs []string) error {
 	if cfg.HarnessToUse != harness.Crush {
@@ -42,39 +52,79 @@ func runHarnessExec(cfg ExecutionConfig, harnessPath string, cliArgs []string) e
 			Harness:      cfg.HarnessToUse,
 		})
 	}
+	printNotices(cfg)
 
-	rootCtx := context.Background()
-	if cliCtx := CLIContextFromCmd(cfg.Cmd); cliCtx != nil {
-		rootCtx = cliCtx.RootCtx()
-	}
-
-	ctx, cancel, stopSig := execution.StartSession(rootCtx)
-	defer cancel()
-	defer stopSig()
+	ctx := harnessSessionContext(cfg.Cmd)
 
+	usageEnv, finishUsage := startUsageCapture(cfg)
 	execCmd := cfg.Deps.Process.ExecCommandContext(ctx, harnessPath, cliArgs...)
-	execCmd.Env = cfg.ProviderEnv
+	execCmd.Env = slices.Concat(cfg.ProviderEnv, usageEnv)
 	execCmd.Stdin = os.Stdin
 	execCmd.Stdout = os.Stdout
 	execCmd.Stderr = os.Stderr
 
-	return execCmd.Run()
+	err := runHarnessCommand(switchEvents(cfg.Cmd), false, execCmd)
+	finishUsage()
+
+	return err
+}
+
+// harnessSessionContext returns the context for an interactive harness
+// session. Any --timeout is disarmed first: it bounds kairo's own work, not
+// the session the user is working in. The context is not cancelled by the
+// signals that cancel kairo's root context either, since cancelling would
+// kill the harness outright; execution.Run forwards them to it instead.
+func harnessSessionContext(cmd *cobra.Command) context.Context {
+	cliCtx := CLIContextFromCmd(cmd)
+	if cliCtx == nil {
+		return context.Background()
+	}
+	cliCtx.DisarmTimeout()
+
+	return context.WithoutCancel(cliCtx.RootCtx())
+}
+
+// printNotices prints the notices queued on cfg before the harness starts.
+func printNotices(cfg ExecutionConfig) {
+	for _, n := range cfg.Notices {
+		ui.PrintInfo(n)
+	}
 }
 
-// reportHarnessError prints a uniform harness-error line and exits the
-// process. It is the standard post-exec failure path.
+// reportHarnessError is the standard post-exec failure path. A harness that
+// ran and exited unsuccessfully has reported its own error, so kairo exits
+// with the same status; any other error gets a uniform harness-error line
+// and status 1.
 func reportHarnessError(cfg ExecutionConfig, displayName string, err error) {
-	cfg.Cmd.Printf("Error running %s: %v\n", displayName, err)
+	var exitErr *execution.ExitError
+	if errors.As(err, &exitErr) {
+		if verbose(cfg.Cmd) {
+			ui.PrintInfo(fmt.Sprintf("%s %v", displayName, exitErr))
+		}
+		cfg.Deps.Process.ExitProcess(exitErr.Code)
+
+		return
+	}
+	printCmdError(cfg.Cmd, kairoerrors.WrapError(kairoerrors.RuntimeError, "Error running "+displayName, err))
 	cfg.Deps.Process.ExitProcess(1)
 }
 
+// harnessNotFoundError reports a harness binary missing from PATH, with the
+// install command for the current OS as its hint.
+func harnessNotFoundError(binary string, err error) *kairoerrors.KairoError {
+	return kairoerrors.WrapError(kairoerrors.RuntimeError, fmt.Sprintf("'%s' command not found in PATH", binary), err).
+		WithContext("hint", harness.InstallHint(binary, runtime.GOOS))
+}
+
 // lookUpHarnessBinary resolves the binary in PATH. On miss it prints an error
 // via the cobra command and returns the empty string so callers can early-out
 // without printing a second time.
 func lookUpHarnessBinary(cfg ExecutionConfig) string {
 	path, err := cfg.Deps.Process.LookPath(cfg.HarnessBinary)
 	if err != nil {
-		cfg.Cmd.Printf("Error: '%s' command not found in PATH\n", cfg.HarnessBinary)
+		err := harnessNotFoundError(cfg.HarnessBinary, err)
+		emitSwitchError(switchEvents(cfg.Cmd), events.Exec, err)
+		printCmdError(cfg.Cmd, err)
 
 		return ""
 	}
@@ -83,7 +133,7 @@ func lookUpHarnessBinary(cfg ExecutionConfig) string {
 }
 
 // executePi handles the Pi harness, which injects --provider/--model and runs
-// directly without the wrapper script. It returns the run error so callers
+// directly without the wrapper. It returns the run error so callers
 // can decide whether to surface it and exit.
 func executePi(cfg ExecutionConfig) error {
 	cliArgs := applyYoloFlag(cfg, cfg.HarnessArgs)
@@ -103,8 +153,10 @@ func executePi(cfg ExecutionConfig) error {
 func runHarnessWithWrapper(ctx context.Context, deps *Deps, params HarnessRun) error {
 	harnessPath, err := deps.Process.LookPath(params.HarnessBinary)
 	if err != nil {
-		return kairoerrors.WrapError(kairoerrors.RuntimeError,
-			fmt.Sprintf("'%s' command not found in PATH", params.HarnessBinary), err)
+		err := harnessNotFoundError(params.HarnessBinary, err)
+		emitSwitchError(params.Events, events.Exec, err)
+
+		return err
 	}
 
 	wrapperCfg := wrapper.ScriptConfig{
@@ -114,23 +166,34 @@ func runHarnessWithWrapper(ctx context.Context, deps *Deps, params HarnessRun) e
 		CliArgs:    params.CliArgs,
 		EnvVarName: params.EnvVarName,
 	}
-	wrapperScript, useCmdExe, err := deps.Wrapper.GenerateWrapperScript(wrapperCfg)
+	if params.Provider.WrapperPing {
+		wrapperCfg.Ping = &wrapper.Ping{
+			Provider:  params.ProviderName,
+			URL:       health.ModelsEndpoint(params.Provider.BaseURL),
+			AuthStyle: providerAuthStyle(params.ProviderName, params.Provider),
+		}
+	}
+	launch, err := deps.Wrapper.PrepareLaunch(wrapperCfg)
 	if err != nil {
-		return kairoerrors.WrapError(kairoerrors.RuntimeError,
-			"generating wrapper script", err)
+		err := kairoerrors.WrapError(kairoerrors.RuntimeError, "preparing harness launch", err)
+		emitSwitchError(params.Events, events.WrapperCreated, err)
+
+		return err
 	}
+	params.Events.Emit(events.Event{Event: events.WrapperCreated})
 
-	execCmd := buildWrapperCommand(deps, WrapperCmd{
-		Ctx:           ctx,
-		WrapperScript: wrapperScript,
-		IsWindows:     useCmdExe,
-	})
+	execCmd := deps.Process.ExecCommandContext(ctx, launch.Name, launch.Args...)
 	execCmd.Env = params.ProviderEnv
 	execCmd.Stdin = os.Stdin
 	execCmd.Stdout = os.Stdout
 	execCmd.Stderr = os.Stderr
 
-	return execCmd.Run()
+	err = runHarnessCommand(params.Events, true, execCmd)
+	if err != nil && fsperm.Blocked(params.AuthDir, err) {
+		return blockedError("starting the harness", params.AuthDir, tempDirBlockedHint, err)
+	}
+
+	return err
 }
 
 // applyYoloFlag prepends the yolo flag to cliArgs when cfg.Yolo is set.
@@ -144,6 +207,23 @@ func applyYoloFlag(cfg ExecutionConfig, cliArgs []string) []string {
 	return cliArgs
 }
 
+// applyResumeFlag prepends the harness's own arguments for --resume or
+// --continue to harnessArgs, or fails for a harness that cannot resume a
+// session from its command line.
+func applyResumeFlag(harnessToUse string, harnessArgs []string) ([]string, error) {
+	if resumeFlag == "" && !continueFlag {
+		return harnessArgs, nil
+	}
+	resumeArgs, ok := harness.ResumeArgs(harnessToUse, resumeFlag)
+	if !ok {
+		return nil, kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("%s cannot resume a session from its command line", harnessToUse)).
+			WithContext("hint", "start it without --resume or --continue and open the session from inside it")
+	}
+
+	return append(resumeArgs, harnessArgs...), nil
+}
+
 // handlePi returns true if the execution was handled by the Pi harness.
 func handlePi(cfg ExecutionConfig) bool {
 	if cfg.HarnessToUse != harness.Pi {
@@ -165,18 +245,32 @@ func executeWithAuth(cfg ExecutionConfig) {
 	executeWrapperWithAuth(cfg)
 }
 
-func executeWrapperWithAuth(cfg ExecutionConfig) {
-	rootCtx := context.Background()
-	if cliCtx := CLIContextFromCmd(cfg.Cmd); cliCtx != nil {
-		rootCtx = cliCtx.RootCtx()
+// cleanStaleAuthDirs removes auth directories left behind by kairo processes
+// that were killed before they could clean up. Failures are not fatal.
+func cleanStaleAuthDirs(cfg ExecutionConfig) {
+	removed, err := cfg.Deps.Wrapper.CleanStaleAuthDirs(cfg.tempDir(), wrapper.DefaultStaleAuthDirAge, false)
+	if !verbose(cfg.Cmd) {
+		return
+	}
+	if err != nil {
+		ui.PrintWarn(fmt.Sprintf("Could not clean stale auth directories: %v", err))
+	}
+	for _, dir := range removed {
+		ui.PrintInfo("Removed stale auth directory " + dir)
 	}
-	ctx, cancel, stopSig := execution.StartSession(rootCtx)
-	defer cancel()
-	defer stopSig()
+}
+
+func executeWrapperWithAuth(cfg ExecutionConfig) {
+	ctx := harnessSessionContext(cfg.Cmd)
+	em := switchEvents(cfg.Cmd)
+
+	cleanStaleAuthDirs(cfg)
 
-	authDir, err := cfg.Deps.Wrapper.CreateTempAuthDir()
+	authDir, err := cfg.Deps.Wrapper.CreateTempAuthDir(cfg.TempDir)
 	if err != nil {
-		cfg.Cmd.Printf("Error creating auth directory: %v\n", err)
+		err := authDirError("Error creating auth directory", cfg.tempDir(), err)
+		emitSwitchError(em, events.WrapperCreated, err)
+		printCmdError(cfg.Cmd, err)
 
 		return
 	}
@@ -193,32 +287,134 @@ func executeWrapperWithAuth(cfg ExecutionConfig) {
 
 	tokenPath, err := cfg.Deps.Wrapper.WriteTempTokenFile(authDir, cfg.APIKey)
 	if err != nil {
-		cfg.Cmd.Printf("Error creating secure token file: %v\n", err)
+		err := authDirError("Error creating secure token file", authDir, err)
+		emitSwitchError(em, events.WrapperCreated, err)
+		printCmdError(cfg.Cmd, err)
 
 		return
 	}
 
-	cliArgs := applyYoloFlag(cfg, cfg.HarnessArgs)
+	displayName, _, _ := harness.Dispatch(cfg.HarnessToUse, cfg.ProviderName, cfg.Provider.Model)
+	setup, err := harnessLaunch(cfg)
+	if err != nil {
+		emitSwitchError(em, events.WrapperCreated, err)
+		printCmdError(cfg.Cmd, err)
 
-	displayName, envVarName, extraArgs := harness.Dispatch(cfg.HarnessToUse, cfg.ProviderName, cfg.Provider.Model)
-	cliArgs = append(extraArgs, cliArgs...)
+		return
+	}
+
+	settingsEnv, err := writeSettingsFiles(cfg, authDir, setup.QwenSettings)
+	if err != nil {
+		err := kairoerrors.WrapError(kairoerrors.ConfigError, "Error writing settings file", err).
+			WithContext("hint", "check settings_files for provider '"+cfg.ProviderName+"' in config.yaml")
+		emitSwitchError(em, events.WrapperCreated, err)
+		printCmdError(cfg.Cmd, err)
+
+		return
+	}
 
+	usageEnv, finishUsage := startUsageCapture(cfg)
 	run := HarnessRun{
 		AuthDir:       authDir,
 		TokenPath:     tokenPath,
 		HarnessBinary: cfg.HarnessBinary,
-		CliArgs:       cliArgs,
-		ProviderEnv:   cfg.ProviderEnv,
+		CliArgs:       setup.Args,
+		ProviderEnv:   slices.Concat(mergeEnvVars(cfg.ProviderEnv, setup.Env), settingsEnv, usageEnv),
 		Provider:      cfg.Provider,
-		EnvVarName:    envVarName,
+		ProviderName:  cfg.ProviderName,
+		EnvVarName:    authEnvVarName(cfg),
 		Harness:       cfg.HarnessToUse,
+		Events:        em,
 	}
 
-	if err := runHarnessWithWrapper(ctx, cfg.Deps, run); err != nil {
+	printNotices(cfg)
+	err = runHarnessWithWrapper(ctx, cfg.Deps, run)
+	finishUsage()
+	if err != nil {
+		// reportHarnessError exits, which skips the deferred cleanup.
+		cleanup()
 		reportHarnessError(cfg, displayName, err)
 	}
 }
 
+// harnessSetup is what the wrapper adds to a harness launch: the arguments
+// it passes, the harness-specific variables, and the Qwen Code settings.json
+// to write, if any.
+type harnessSetup struct {
+	Args         []string
+	Env          []string
+	QwenSettings string
+}
+
+// harnessLaunch returns the harness setup for cfg. Qwen Code's comes from
+// buildQwenLaunch alone, so a launch and its --explain plan agree on it.
+func harnessLaunch(cfg ExecutionConfig) (harnessSetup, error) {
+	cliArgs := applyYoloFlag(cfg, cfg.HarnessArgs)
+
+	_, _, extraArgs := harness.Dispatch(cfg.HarnessToUse, cfg.ProviderName, cfg.Provider.Model)
+	setup := harnessSetup{}
+	if cfg.HarnessToUse == harness.Qwen {
+		launch, err := buildQwenLaunch(cfg.Provider)
+		if err != nil {
+			return harnessSetup{}, err
+		}
+		extraArgs = launch.Args
+		setup.Env = launch.Env
+		setup.QwenSettings = launch.Settings
+	}
+	setup.Args = append(extraArgs, cliArgs...)
+
+	return setup, nil
+}
+
+// writeSettingsFiles renders the provider's settings files for the current
+// harness, and qwenSettings as the Qwen Code settings.json when it is set,
+// into authDir, which is removed when the harness exits, and returns the
+// environment entries that point the harness at them.
+func writeSettingsFiles(cfg ExecutionConfig, authDir, qwenSettings string) ([]string, error) {
+	data := wrapper.SettingsData{
+		Provider: cfg.ProviderName,
+		APIKey:   cfg.APIKey,
+		BaseURL:  cfg.Provider.BaseURL,
+		Model:    cfg.Provider.Model,
+	}
+
+	var env []string
+	for _, sf := range cfg.Provider.SettingsFiles {
+		if sf.Harness != "" && sf.Harness != cfg.HarnessToUse {
+			continue
+		}
+		if sf.Env == "" {
+			return nil, kairoerrors.NewError(kairoerrors.ConfigError,
+				"settings file needs an env variable to point the harness at it").
+				WithContext("name", sf.Name)
+		}
+
+		content, err := wrapper.RenderSettings(sf.Template, data)
+		if err != nil {
+			return nil, err
+		}
+		path, err := cfg.Deps.Wrapper.WriteSettingsFile(authDir, sf.Name, content)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, sf.Env+"="+path)
+	}
+
+	if qwenSettings != "" {
+		path, err := cfg.Deps.Wrapper.WriteSettingsFile(authDir, qwenSettingsFileName, qwenSettings)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, harness.QwenSettingsEnv+"="+path)
+	}
+
+	return env, nil
+}
+
+// qwenSettingsFileName is the settings.json written for qwen.write_settings.
+const qwenSettingsFileName = "qwen-settings.json"
+
 func executeWithoutAuth(cfg ExecutionConfig) {
 	if handlePi(cfg) {
 		return
@@ -227,8 +423,8 @@ func executeWithoutAuth(cfg ExecutionConfig) {
 	cliArgs := applyYoloFlag(cfg, cfg.HarnessArgs)
 
 	if cfg.HarnessToUse == harness.Qwen {
-		ui.PrintError("API key not found for provider")
-		ui.PrintInfo("Qwen Code requires API keys to be set in environment variables.")
+		printError(kairoerrors.NewError(kairoerrors.ProviderError, "API key not found for provider").
+			WithContext("hint", fmt.Sprintf("Qwen Code needs an API key; run 'kairo secrets set %s'", cfg.ProviderName)))
 
 		return
 	}
@@ -3,27 +3,54 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"testing"
 
 	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
 	"github.com/dkmnx/kairo/internal/harness"
 	"github.com/dkmnx/kairo/internal/wrapper"
 )
 
-func TestQwenAuthArgs(t *testing.T) {
-	_, _, extraArgs := harness.Dispatch(harness.Qwen, "test", "qwen-plus")
-	if len(extraArgs) != 4 {
-		t.Fatalf("Dispatch should return 4 elements, got %d", len(extraArgs))
+func TestHarnessLaunch_Qwen(t *testing.T) {
+	cfg := ExecutionConfig{
+		HarnessToUse: harness.Qwen,
+		ProviderName: "test",
+		HarnessArgs:  []string{"--debug"},
+		Provider:     config.Provider{BaseURL: "https://api.example.com", Model: "qwen-plus"},
+	}
+
+	setup, err := harnessLaunch(cfg)
+	if err != nil {
+		t.Fatalf("harnessLaunch() error = %v", err)
+	}
+	want := []string{"--auth-type", "anthropic", "--model", "qwen-plus", "--debug"}
+	if !slices.Equal(setup.Args, want) {
+		t.Errorf("Args = %v, want %v", setup.Args, want)
 	}
-	if extraArgs[0] != "--auth-type" || extraArgs[1] != "anthropic" {
-		t.Errorf("first two args should be --auth-type anthropic, got %v", extraArgs[:2])
+	if setup.QwenSettings != "" {
+		t.Errorf("QwenSettings = %q, want none without qwen.write_settings", setup.QwenSettings)
 	}
-	if extraArgs[2] != "--model" || extraArgs[3] != "qwen-plus" {
-		t.Errorf("last two args should be --model qwen-plus, got %v", extraArgs[2:])
+
+	cfg.Provider.Qwen = &config.QwenConfig{AuthType: harness.QwenAuthOpenAI, WriteSettings: true}
+	setup, err = harnessLaunch(cfg)
+	if err != nil {
+		t.Fatalf("harnessLaunch() error = %v", err)
+	}
+	if setup.Args[1] != harness.QwenAuthOpenAI {
+		t.Errorf("Args = %v, want --auth-type openai", setup.Args)
+	}
+	if !slices.Contains(setup.Env, "OPENAI_BASE_URL=https://api.example.com") {
+		t.Errorf("Env = %v, want OPENAI_BASE_URL", setup.Env)
+	}
+	if !strings.Contains(setup.QwenSettings, `"selectedType": "openai"`) {
+		t.Errorf("QwenSettings = %s, want the openai auth type", setup.QwenSettings)
 	}
 }
 
@@ -89,7 +116,7 @@ func TestExecutePi_ExecutionFails(t *testing.T) {
 
 func TestExecuteWrapperWithAuth_AuthDirFails(t *testing.T) {
 	d := testDeps(func(mp *mockProcess, mw *mockWrapper, mu *mockUpdate) {
-		mw.CreateTempAuthDirFn = func() (string, error) {
+		mw.CreateTempAuthDirFn = func(string) (string, error) {
 			return "", fmt.Errorf("auth dir creation failed")
 		}
 	})
@@ -117,7 +144,7 @@ func TestExecuteWrapperWithAuth_AuthDirFails(t *testing.T) {
 func TestExecuteWrapperWithAuth_TokenFileFails(t *testing.T) {
 	tmpDir := t.TempDir()
 	d := testDeps(func(mp *mockProcess, mw *mockWrapper, mu *mockUpdate) {
-		mw.CreateTempAuthDirFn = func() (string, error) {
+		mw.CreateTempAuthDirFn = func(string) (string, error) {
 			return tmpDir, nil
 		}
 		mw.WriteTempTokenFileFn = func(authDir, token string) (string, error) {
@@ -150,7 +177,7 @@ func TestExecuteWrapperWithAuth_WrapperExecFails_Claude(t *testing.T) {
 	tmpDir := t.TempDir()
 	exitCalled := false
 	d := testDeps(func(mp *mockProcess, mw *mockWrapper, mu *mockUpdate) {
-		mw.CreateTempAuthDirFn = func() (string, error) {
+		mw.CreateTempAuthDirFn = func(string) (string, error) {
 			return tmpDir, nil
 		}
 		tokenPath := filepath.Join(tmpDir, "token")
@@ -192,7 +219,7 @@ func TestExecuteWrapperWithAuth_QwenPassesAuthArgs(t *testing.T) {
 	tmpDir := t.TempDir()
 	var capturedArgs []string
 	d := testDeps(func(mp *mockProcess, mw *mockWrapper, mu *mockUpdate) {
-		mw.CreateTempAuthDirFn = func() (string, error) {
+		mw.CreateTempAuthDirFn = func(string) (string, error) {
 			return tmpDir, nil
 		}
 		tokenPath := filepath.Join(tmpDir, "token")
@@ -202,10 +229,9 @@ func TestExecuteWrapperWithAuth_QwenPassesAuthArgs(t *testing.T) {
 		mp.LookPathFn = func(file string) (string, error) {
 			return "/usr/bin/" + file, nil
 		}
-		mw.GenerateWrapperScriptFn = func(cfg wrapper.ScriptConfig) (string, bool, error) {
+		mw.PrepareLaunchFn = func(cfg wrapper.ScriptConfig) (wrapper.Launch, error) {
 			capturedArgs = cfg.CliArgs
-			scriptPath := filepath.Join(tmpDir, "wrapper.sh")
-			return scriptPath, false, nil
+			return wrapper.Launch{Name: "/usr/local/bin/kairo", Args: wrapper.ExecArgs(cfg)}, nil
 		}
 		mp.ExecCommandContextFn = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
 			return testEchoCmd()
@@ -281,6 +307,40 @@ func TestExecuteWithAuth_PiExecutionError(t *testing.T) {
 	}
 }
 
+func TestExecuteWithAuth_PiExitStatus(t *testing.T) {
+	if runtime.GOOS == constants.WindowsGOOS {
+		t.Skip("needs a POSIX shell")
+	}
+	exitCode := -1
+	d := testDeps(func(mp *mockProcess, mw *mockWrapper, mu *mockUpdate) {
+		mp.LookPathFn = func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		}
+		mp.ExecCommandContextFn = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+			return exec.CommandContext(ctx, "sh", "-c", "exit 7")
+		}
+		mp.ExitProcessFn = func(code int) { exitCode = code }
+	})
+
+	cmd := testCmd()
+	executeWithAuth(ExecutionConfig{
+		Cmd:           cmd,
+		HarnessToUse:  harness.Pi,
+		HarnessBinary: "pi",
+		Provider:      config.Provider{Name: "Test", Model: "test-model"},
+		ProviderName:  "test",
+		APIKey:        "test-key",
+		Deps:          d,
+	})
+
+	if exitCode != 7 {
+		t.Errorf("ExitProcess(%d), want the harness's status 7", exitCode)
+	}
+	if out := outputOf(cmd); out != "" {
+		t.Errorf("a harness exit status should not print an error, got %q", out)
+	}
+}
+
 func TestExecuteWithoutAuth_YoloModeQwen(t *testing.T) {
 	cmd := testCmd()
 	cfg := ExecutionConfig{
@@ -302,12 +362,12 @@ func TestExecuteWrapperWithAuth_QwenWrapperFails(t *testing.T) {
 	tmpDir := t.TempDir()
 	exitCalled := false
 	d := testDeps(func(mp *mockProcess, mw *mockWrapper, mu *mockUpdate) {
-		mw.CreateTempAuthDirFn = func() (string, error) { return tmpDir, nil }
+		mw.CreateTempAuthDirFn = func(string) (string, error) { return tmpDir, nil }
 		mw.WriteTempTokenFileFn = func(authDir, token string) (string, error) {
 			return filepath.Join(authDir, "token"), nil
 		}
-		mw.GenerateWrapperScriptFn = func(cfg wrapper.ScriptConfig) (string, bool, error) {
-			return filepath.Join(tmpDir, "wrapper.sh"), false, nil
+		mw.PrepareLaunchFn = func(cfg wrapper.ScriptConfig) (wrapper.Launch, error) {
+			return wrapper.Launch{Name: "/usr/local/bin/kairo", Args: wrapper.ExecArgs(cfg)}, nil
 		}
 		mp.LookPathFn = func(file string) (string, error) {
 			return "/usr/bin/" + file, nil
@@ -374,7 +434,7 @@ func TestExecuteWithAuth_AuthDirCleanup(t *testing.T) {
 	tmpDir := t.TempDir()
 	var execCalled atomic.Bool
 	d := testDeps(func(mp *mockProcess, mw *mockWrapper, mu *mockUpdate) {
-		mw.CreateTempAuthDirFn = func() (string, error) {
+		mw.CreateTempAuthDirFn = func(string) (string, error) {
 			return tmpDir, nil
 		}
 		tokenPath := filepath.Join(tmpDir, "token")
@@ -445,3 +505,44 @@ func TestExecuteWithoutAuth_ClaudeSuccess(t *testing.T) {
 		t.Errorf("executeWithoutAuth successful Claude run should not print errors, got: %s", output)
 	}
 }
+
+func TestWriteSettingsFiles(t *testing.T) {
+	authDir := t.TempDir()
+	d := testDeps()
+	d.Wrapper = prodWrapperService{}
+
+	cfg := ExecutionConfig{
+		Deps:         d,
+		HarnessToUse: harness.Qwen,
+		ProviderName: "zai",
+		APIKey:       "sk-settings-key",
+		Provider: config.Provider{
+			Model: "glm-5.1",
+			SettingsFiles: []config.SettingsFile{
+				{Name: "settings.json", Env: "QWEN_SETTINGS", Template: `{"key": {{json .APIKey}}}`, Harness: harness.Qwen},
+				{Name: "claude.json", Env: "CLAUDE_SETTINGS", Template: "{}", Harness: harness.Claude},
+			},
+		},
+	}
+
+	env, err := writeSettingsFiles(cfg, authDir, "")
+	if err != nil {
+		t.Fatalf("writeSettingsFiles() error = %v", err)
+	}
+	want := "QWEN_SETTINGS=" + filepath.Join(authDir, "settings.json")
+	if len(env) != 1 || env[0] != want {
+		t.Fatalf("writeSettingsFiles() env = %v, want [%s]", env, want)
+	}
+	data, err := os.ReadFile(filepath.Join(authDir, "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"key": "sk-settings-key"}` {
+		t.Errorf("settings file = %s", data)
+	}
+
+	cfg.Provider.SettingsFiles = []config.SettingsFile{{Name: "x.json", Template: "{}"}}
+	if _, err := writeSettingsFiles(cfg, authDir, ""); err == nil {
+		t.Error("writeSettingsFiles() expected error when env is missing")
+	}
+}
@@ -2,11 +2,15 @@ package cmd
 
 import (
 	stderrors "errors"
+	"fmt"
 	"io/fs"
+	"sort"
 	"strings"
 
 	"github.com/dkmnx/kairo/internal/config"
-	"github.com/dkmnx/kairo/internal/harness"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/events"
+	"github.com/dkmnx/kairo/internal/fuzzy"
 	"github.com/dkmnx/kairo/internal/providers"
 	"github.com/spf13/cobra"
 )
@@ -15,36 +19,34 @@ import (
 // provider/harness, and dispatch to the appropriate execution path.
 func OrchestrateExecution(cmd *cobra.Command, args []string) {
 	cliCtx := CLIContextFromCmd(cmd)
+	if !openSwitchEvents(cmd, cliCtx) {
+		return
+	}
+	defer closeSwitchEvents(cliCtx)
 
 	cfg, ok := loadRootConfig(cmd, cliCtx)
 	if !ok {
 		return
 	}
 
-	harnessArgs, providerName := resolveProviderAndArgs(cmd, cliCtx, cfg, args)
-	if providerName == "" {
-		return
-	}
+	t, err := resolveSwitchLaunch(cmd, cliCtx, cfg, args)
+	if err != nil {
+		printCmdError(cmd, err)
 
-	provider, ok := lookupProvider(cmd, cfg, providerName)
-	if !ok {
 		return
 	}
+	cliCtx.Events().Emit(events.Event{
+		Event: events.Prepare, Provider: t.ProviderName, Harness: t.Harness, Model: t.Provider.Model,
+	})
 
-	harnessToUse := resolveHarness(harnessFlag, cfg.DefaultHarness)
-
-	if harnessToUse == harness.Pi {
-		runPiProvider(cmd, cliCtx, cfg, provider, providerName, harnessToUse, harnessArgs)
-	} else {
-		runStandardProvider(cmd, cliCtx, provider, providerName, harnessToUse, harnessArgs)
-	}
+	startLaunch(cmd, cliCtx, cfg, t)
 }
 
 // loadRootConfig loads and validates the configuration. Returns nil config on
 // error after printing an appropriate message.
 func loadRootConfig(cmd *cobra.Command, cliCtx *CLIContext) (*config.Config, bool) {
 	if cliCtx == nil {
-		cmd.Println("Error: no CLI context available")
+		printCmdError(cmd, kairoerrors.NewError(kairoerrors.RuntimeError, "Error: no CLI context available"))
 		if err := cmd.Help(); err != nil {
 			cmd.Println(err)
 		}
@@ -54,7 +56,8 @@ func loadRootConfig(cmd *cobra.Command, cliCtx *CLIContext) (*config.Config, boo
 
 	configDir := cliCtx.ConfigDir()
 	if configDir == "" {
-		cmd.Println("Error: config directory not found")
+		printCmdError(cmd, kairoerrors.NewError(kairoerrors.ConfigError, "Error: config directory not found").
+			WithContext("hint", "set KAIRO_CONFIG_DIR or pass --config <dir>"))
 		if err := cmd.Help(); err != nil {
 			cmd.Println(err)
 		}
@@ -64,6 +67,12 @@ func loadRootConfig(cmd *cobra.Command, cliCtx *CLIContext) (*config.Config, boo
 
 	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), configDir)
 	if err != nil {
+		missing := stderrors.Is(err, fs.ErrNotExist) || stderrors.Is(err, kairoerrors.ErrConfigNotFound)
+		if missing && shouldOnboard() {
+			runOnboarding(cmd, cliCtx, configDir)
+
+			return nil, false
+		}
 		if stderrors.Is(err, fs.ErrNotExist) {
 			cmd.Println("No providers configured. Run 'kairo setup' to get started.")
 
@@ -83,18 +92,55 @@ func loadRootConfig(cmd *cobra.Command, cliCtx *CLIContext) (*config.Config, boo
 	return cfg, true
 }
 
-// lookupProvider finds the named provider in the configuration.
-// Prints an error and returns false if not found.
-func lookupProvider(cmd *cobra.Command, cfg *config.Config, providerName string) (config.Provider, bool) {
+// lookupProvider finds the named provider in the configuration, or returns
+// an error if it is not found or disabled.
+func lookupProvider(cfg *config.Config, providerName string) (config.Provider, error) {
 	provider, ok := cfg.Providers[providerName]
 	if !ok {
-		cmd.Printf("Error: provider '%s' not configured\n", providerName)
-		cmd.Println("Run 'kairo list' to see configured providers")
+		return config.Provider{}, providerNotConfiguredError(cfg, providerName)
+	}
+	if provider.Disabled {
+		return config.Provider{}, disabledProviderError(providerName)
+	}
+
+	return provider, nil
+}
+
+// providerNotConfiguredError reports that providerName is not configured,
+// naming the enabled providers closest to it and listing the rest, so that a
+// mistyped name is easy to correct.
+func providerNotConfiguredError(cfg *config.Config, providerName string) error {
+	msg := fmt.Sprintf("Error: provider '%s' not configured", providerName)
+	enabled := enabledProviderNames(cfg)
+	if len(enabled) == 0 {
+		return kairoerrors.NewError(kairoerrors.ProviderError, msg).WithContext("provider", providerName)
+	}
 
-		return config.Provider{}, false
+	hint := "available providers: " + strings.Join(enabled, ", ")
+	if matches := fuzzy.Closest(providerName, enabled); len(matches) > 0 {
+		msg += fmt.Sprintf("; did you mean '%s'?", strings.Join(matches, "' or '"))
+		hint = fmt.Sprintf("run 'kairo %s', or use one of the %s", matches[0], hint)
+	} else {
+		hint += fmt.Sprintf("; run 'kairo setup --provider %s' to add it", providerName)
+	}
+
+	return kairoerrors.NewError(kairoerrors.ProviderError, msg).
+		WithContext("provider", providerName).
+		WithContext("hint", hint)
+}
+
+// enabledProviderNames returns the configured providers that are not
+// disabled, sorted.
+func enabledProviderNames(cfg *config.Config) []string {
+	var names []string
+	for name, p := range cfg.Providers {
+		if !p.Disabled {
+			names = append(names, name)
+		}
 	}
+	sort.Strings(names)
 
-	return provider, true
+	return names
 }
 
 // resolveProviderAndArgs resolves the provider name and harness arguments from
@@ -152,8 +198,9 @@ func providerFromArgs(cmd *cobra.Command, cfg *config.Config, args []string) (st
 		return cfg.DefaultProvider, kairoArgs
 	}
 
-	cmd.Println("Error: No default provider set and first argument looks like a flag")
-	cmd.Println("Run 'kairo setup' to configure a provider")
+	printCmdError(cmd, kairoerrors.NewError(kairoerrors.ConfigError,
+		"Error: No default provider set and first argument looks like a flag").
+		WithContext("hint", "run 'kairo default <provider>' to set one, or 'kairo setup' to configure a provider"))
 
 	return "", nil
 }
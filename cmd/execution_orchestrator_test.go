@@ -3,9 +3,11 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
 )
 
 func TestSplitArgsOrchestrator(t *testing.T) {
@@ -103,19 +105,50 @@ func TestLookupProvider(t *testing.T) {
 		},
 	}
 
-	cmd := testCmd()
-
-	provider, ok := lookupProvider(cmd, cfg, "anthropic")
-	if !ok {
-		t.Error("lookupProvider() should return true for existing provider")
+	provider, err := lookupProvider(cfg, "anthropic")
+	if err != nil {
+		t.Errorf("lookupProvider() error = %v for existing provider", err)
 	}
 	if provider.Name != "Anthropic" {
 		t.Errorf("lookupProvider() returned provider with name %q, want %q", provider.Name, "Anthropic")
 	}
 
-	_, ok = lookupProvider(cmd, cfg, "nonexistent")
-	if ok {
-		t.Error("lookupProvider() should return false for non-existent provider")
+	if _, err := lookupProvider(cfg, "nonexistent"); err == nil {
+		t.Error("lookupProvider() should return an error for non-existent provider")
+	}
+
+	cfg.Providers["zai"] = config.Provider{Name: "Z.AI", Disabled: true}
+	if _, err := lookupProvider(cfg, "zai"); err == nil {
+		t.Error("lookupProvider() should return an error for a disabled provider")
+	}
+}
+
+func TestProviderNotConfiguredError(t *testing.T) {
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"zai":     {Name: "Z.AI"},
+		"minimax": {Name: "MiniMax"},
+		"kimi":    {Name: "Kimi", Disabled: true},
+	}}
+
+	err := providerNotConfiguredError(cfg, "zia")
+	if got := kairoerrors.Describe(err); !strings.Contains(got, "did you mean 'zai'?") {
+		t.Errorf("Describe() = %q, want a suggestion of zai", got)
+	}
+	if got := kairoerrors.Suggest(err); got != "run 'kairo zai', or use one of the available providers: minimax, zai" {
+		t.Errorf("Suggest() = %q", got)
+	}
+
+	err = providerNotConfiguredError(cfg, "openrouter")
+	if got := kairoerrors.Describe(err); strings.Contains(got, "did you mean") {
+		t.Errorf("Describe() = %q, want no suggestion", got)
+	}
+	if got := kairoerrors.Suggest(err); !strings.Contains(got, "available providers: minimax, zai;") ||
+		!strings.Contains(got, "kairo setup --provider openrouter") {
+		t.Errorf("Suggest() = %q", got)
+	}
+
+	if got := kairoerrors.Describe(providerNotConfiguredError(cfg, "kim")); strings.Contains(got, "kimi") {
+		t.Errorf("Describe() = %q, suggests a disabled provider", got)
 	}
 }
 
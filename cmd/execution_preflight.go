@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/claudesettings"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/envcheck"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/validate"
+	"github.com/dkmnx/kairo/internal/warnlimit"
+)
+
+var (
+	explainEnvFlag  bool
+	waitHealthyFlag time.Duration
+)
+
+// waitHealthyDefault is the --wait-healthy budget when no duration is given.
+const waitHealthyDefault = time.Minute
+
+// Backoff between --wait-healthy probes. Variables so tests can shorten them.
+var (
+	waitHealthyInitialBackoff = 500 * time.Millisecond
+	waitHealthyMaxBackoff     = 8 * time.Second
+)
+
+// runPreflight runs the checks that precede launching a harness. It returns
+// false when the harness must not be started, e.g. because --explain-env
+// only asked for the effective environment.
+func runPreflight(cfg ExecutionConfig) bool {
+	in := envCheckInput(cfg)
+	conflicts := envcheck.Check(in)
+
+	if explainEnvFlag {
+		printExplainEnv(cfg, in, conflicts)
+
+		return false
+	}
+
+	warnEnvConflicts(warningLimiter(CLIContextFromCmd(cfg.Cmd)), in, conflicts)
+
+	if !checkBaseURL(cfg) {
+		return false
+	}
+
+	if waitHealthyFlag > 0 {
+		return waitHealthy(cfg, waitHealthyFlag)
+	}
+
+	return true
+}
+
+// baseURLLookupTimeout bounds the DNS lookup of checkBaseURL.
+const baseURLLookupTimeout = 3 * time.Second
+
+// checkBaseURL checks the provider's base URL again before launch, since
+// config.yaml may have been edited since the provider was saved. A plain
+// HTTP or private URL stops the launch unless the provider sets
+// allow_insecure. A public host name that resolves to a private address
+// only warns, as a local DNS override can be intended, but it is also what
+// a hijacked lookup of a public gateway looks like. Both are audited.
+func checkBaseURL(cfg ExecutionConfig) bool {
+	baseURL := cfg.Provider.BaseURL
+	if baseURL == "" {
+		return true
+	}
+	cliCtx := CLIContextFromCmd(cfg.Cmd)
+	dir := ""
+	if cliCtx != nil {
+		dir = cliCtx.ConfigDir()
+	}
+
+	if err := validate.ValidateBaseURL(baseURL, cfg.ProviderName, cfg.Provider.AllowInsecure); err != nil {
+		recordAudit(cliCtx, dir, audit.Entry{
+			Event:    audit.EventWarning,
+			Action:   "refuse_base_url",
+			Provider: cfg.ProviderName,
+			Details:  map[string]string{"base_url": baseURL},
+		})
+		printError(err)
+
+		return false
+	}
+	if cfg.Provider.AllowInsecure || cfg.Deps == nil || cfg.Deps.Resolver == nil {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(commandContext(cfg.Cmd), baseURLLookupTimeout)
+	defer cancel()
+	// A failed lookup is left to the harness, which reports it when it
+	// connects.
+	addrs, err := validate.PrivateAddrs(ctx, cfg.Deps.Resolver, baseURL)
+	if err != nil || len(addrs) == 0 {
+		return true
+	}
+
+	shown := make([]string, len(addrs))
+	for i, ip := range addrs {
+		shown[i] = ip.String()
+	}
+	ui.PrintWarn(fmt.Sprintf("%s resolves to the private address %s; check your DNS if this provider is not a local gateway, "+
+		"or set 'allow_insecure: true' on it if it is", baseURL, strings.Join(shown, ", ")))
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:    audit.EventWarning,
+		Action:   "private_base_url",
+		Provider: cfg.ProviderName,
+		Details:  map[string]string{"base_url": baseURL, "addresses": strings.Join(shown, ",")},
+	})
+
+	return true
+}
+
+// waitHealthy probes the provider until it answers, retrying with exponential
+// backoff for up to budget, so the harness is only launched once the gateway
+// is reachable. Authentication failures stop immediately since retrying
+// cannot fix them.
+func waitHealthy(cfg ExecutionConfig, budget time.Duration) bool {
+	if cfg.Deps == nil || cfg.Deps.Health == nil {
+		return true
+	}
+
+	rootCtx := commandContext(cfg.Cmd)
+	ctx, cancel := context.WithTimeout(rootCtx, budget)
+	defer cancel()
+
+	style := providerAuthStyle(cfg.ProviderName, cfg.Provider)
+	backoff := waitHealthyInitialBackoff
+	for attempt := 1; ; attempt++ {
+		res := cfg.Deps.Health.Check(ctx, cfg.Provider.BaseURL, cfg.APIKey, style)
+		switch res.Status {
+		case health.StatusOK:
+			if attempt > 1 {
+				ui.PrintSuccess(fmt.Sprintf("%s is reachable", cfg.ProviderName))
+			}
+
+			return true
+		case health.StatusAuthError:
+			printError(kairoerrors.NewError(kairoerrors.ProviderError,
+				fmt.Sprintf("%s rejected the API key (%s); not starting %s", cfg.ProviderName, res.Error, cfg.HarnessToUse)).
+				WithContext("hint", fmt.Sprintf("run 'kairo secrets set %s' to store a valid key", cfg.ProviderName)))
+
+			return false
+		}
+
+		ui.PrintWarn(fmt.Sprintf("%s not reachable (%s), retrying in %s", cfg.ProviderName, res.Error, backoff))
+		select {
+		case <-ctx.Done():
+			printError(kairoerrors.NewError(kairoerrors.NetworkError,
+				fmt.Sprintf("%s did not become reachable within %s; not starting %s", cfg.ProviderName, budget, cfg.HarnessToUse)).
+				WithContext("hint", fmt.Sprintf("check %s, or retry with a longer --wait-healthy", cfg.Provider.BaseURL)))
+
+			return false
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > waitHealthyMaxBackoff {
+			backoff = waitHealthyMaxBackoff
+		}
+	}
+}
+
+// authEnvVarName returns the variable the wrapper exports the API key as.
+// Claude sends ANTHROPIC_API_KEY as x-api-key and ANTHROPIC_AUTH_TOKEN as a
+// bearer token, so an x-api-key auth style selects the former. Qwen Code
+// reads the variable of its auth type.
+func authEnvVarName(cfg ExecutionConfig) string {
+	if cfg.HarnessToUse == harness.Qwen {
+		if launch, err := buildQwenLaunch(cfg.Provider); err == nil {
+			return launch.KeyEnvVar
+		}
+	}
+
+	_, envVarName, _ := harness.Dispatch(cfg.HarnessToUse, cfg.ProviderName, cfg.Provider.Model)
+	if envVarName != "" {
+		return envVarName
+	}
+	if providerAuthStyle(cfg.ProviderName, cfg.Provider) == health.AuthStyleAPIKey {
+		return claudesettings.EnvAPIKey
+	}
+
+	return constants.EnvAuthToken
+}
+
+// providerAuthStyle returns the auth header style for a provider: its
+// auth_style in config.yaml, else the one in its provider definition. An
+// unrecognised value falls back to sending both headers with a warning.
+func providerAuthStyle(name string, provider config.Provider) health.AuthStyle {
+	style, err := health.ParseAuthStyle(cmp.Or(provider.AuthStyle, ProviderDefinition(name).AuthStyle))
+	if err != nil {
+		ui.PrintWarn(fmt.Sprintf("%s: %v; sending both auth headers", name, err))
+	}
+
+	return style
+}
+
+// injectedEnv returns the variables kairo sets for the harness, including the
+// credential variable the wrapper exports when an API key is present.
+func injectedEnv(cfg ExecutionConfig) map[string]string {
+	injected := claudesettings.EnvMap(mergeEnvVars(BuildBuiltInEnvVars(cfg.Provider), cfg.Provider.EnvVars))
+	if cfg.HarnessToUse == harness.Qwen {
+		if launch, err := buildQwenLaunch(cfg.Provider); err == nil {
+			for k, v := range claudesettings.EnvMap(launch.Env) {
+				injected[k] = v
+			}
+		}
+	}
+	if cfg.APIKey != "" && cfg.HarnessToUse != harness.Pi {
+		injected[authEnvVarName(cfg)] = cfg.APIKey
+	}
+
+	return injected
+}
+
+// envConflicts compares kairo's injected variables with the ambient
+// environment, shell rc exports, and (for Claude) Claude Code's settings.
+func envConflicts(cfg ExecutionConfig) []envcheck.Conflict {
+	return envcheck.Check(envCheckInput(cfg))
+}
+
+// envCheckInput gathers kairo's injected variables and the external
+// definitions they compete with. For Claude these include the env blocks of
+// the Claude Code settings files that apply in the current directory;
+// unreadable settings files are left out.
+func envCheckInput(cfg ExecutionConfig) envcheck.Input {
+	in := envcheck.Input{
+		Injected: injectedEnv(cfg),
+		Ambient:  claudesettings.EnvMap(os.Environ()),
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		in.RCExports = envcheck.ScanRCExports(envcheck.RCFiles(home), "ANTHROPIC_")
+	}
+
+	if cfg.HarnessToUse == harness.Claude {
+		in.Settings, in.SettingsFrom, _ = claudeSettingsEnv()
+	}
+
+	return in
+}
+
+// claudeSettingsPaths returns the Claude Code settings files that apply to a
+// session started in the current directory.
+func claudeSettingsPaths() ([]string, error) {
+	cwd, _ := os.Getwd()
+
+	return claudesettings.Paths(cwd)
+}
+
+// claudeSettingsEnv merges the env blocks of the Claude Code settings files
+// that apply in the current directory, with the file each variable is from.
+func claudeSettingsEnv() (map[string]string, map[string]string, error) {
+	paths, err := claudeSettingsPaths()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return claudesettings.LoadEnv(paths)
+}
+
+// describeConflict renders c for a warning. When an external value takes
+// precedence, both values are shown, secrets masked, so a base URL or model
+// that "did not apply" shows what the harness uses instead.
+func describeConflict(in envcheck.Input, c envcheck.Conflict) string {
+	if c.Effect != envcheck.Overrides {
+		return c.String()
+	}
+	external, injected := in.Value(c), in.Injected[c.Key]
+	if envcheck.IsSensitive(c.Key) {
+		external, injected = envcheck.Mask(external), envcheck.Mask(injected)
+	}
+
+	return fmt.Sprintf("%s: the harness uses %s, not kairo's %s", c, external, injected)
+}
+
+// warnEnvConflicts prints each conflict that limiter allows, followed by
+// the precedence order when any was printed.
+func warnEnvConflicts(limiter *warnlimit.Limiter, in envcheck.Input, conflicts []envcheck.Conflict) {
+	printed := false
+	for _, c := range conflicts {
+		if limiter.Allow(warnlimit.EnvConflict, c.String()) {
+			ui.PrintWarn(describeConflict(in, c))
+			printed = true
+		}
+	}
+	if !printed {
+		return
+	}
+	printEnvPrecedence()
+	ui.PrintInfo("Run with --explain-env to see the effective environment.")
+}
+
+func printEnvPrecedence() {
+	ui.PrintInfo("Precedence: Claude Code settings env (managed > project local > project > user) > " +
+		"kairo provider settings > shell environment")
+}
+
+// printExplainEnv prints the environment the harness would receive, with
+// secret values masked and each variable labeled with its origin.
+func printExplainEnv(cfg ExecutionConfig, in envcheck.Input, conflicts []envcheck.Conflict) {
+	injected := in.Injected
+	merged := claudesettings.EnvMap(cfg.ProviderEnv)
+	for k, v := range injected {
+		merged[k] = v
+	}
+	// Claude Code applies its settings' env on top of the environment it
+	// is started with.
+	for k, v := range in.Settings {
+		merged[k] = v
+	}
+
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	cfg.Cmd.Printf("Effective environment for %s (provider: %s):\n", cfg.HarnessToUse, cfg.ProviderName)
+	for _, k := range keys {
+		v := merged[k]
+		if envcheck.IsSensitive(k) {
+			v = envcheck.Mask(v)
+		}
+		origin := "environment"
+		if _, ok := in.Settings[k]; ok {
+			origin = cmp.Or(in.SettingsFrom[k], "settings.json")
+		} else if _, ok := injected[k]; ok {
+			origin = "kairo"
+		}
+		cfg.Cmd.Printf("  %s=%s [%s]\n", k, v, origin)
+	}
+
+	if len(conflicts) > 0 {
+		cfg.Cmd.Println()
+		cfg.Cmd.Println("Conflicts:")
+		for _, c := range conflicts {
+			cfg.Cmd.Printf("  %s\n", describeConflict(in, c))
+		}
+	}
+	cfg.Cmd.Println()
+	printEnvPrecedence()
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/envcheck"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/spf13/cobra"
+)
+
+func TestInjectedEnv(t *testing.T) {
+	provider := config.Provider{
+		BaseURL: "https://api.z.ai/api/anthropic",
+		Model:   "glm-5.1",
+		EnvVars: []string{"EXTRA=1"},
+	}
+
+	tests := []struct {
+		name      string
+		harness   string
+		authStyle string
+		apiKey    string
+		wantKey   string
+		absent    string
+	}{
+		{"claude exports auth token", "claude", "", "secret", "ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_API_KEY"},
+		{"qwen exports api key", "qwen", "", "secret", "ANTHROPIC_API_KEY", "ANTHROPIC_AUTH_TOKEN"},
+		{"crush exports provider key", "crush", "", "secret", "ZAI_API_KEY", "ANTHROPIC_AUTH_TOKEN"},
+		{"no key", "claude", "", "", "", "ANTHROPIC_AUTH_TOKEN"},
+		{"claude x-api-key style", "claude", "x-api-key", "secret", "ANTHROPIC_API_KEY", "ANTHROPIC_AUTH_TOKEN"},
+		{"claude bearer style", "claude", "bearer", "secret", "ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_API_KEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := provider
+			p.AuthStyle = tt.authStyle
+			got := injectedEnv(ExecutionConfig{
+				Provider: p, ProviderName: "zai", HarnessToUse: tt.harness, APIKey: tt.apiKey,
+			})
+			if got["ANTHROPIC_BASE_URL"] != provider.BaseURL || got["EXTRA"] != "1" {
+				t.Errorf("injectedEnv() missing provider vars: %v", got)
+			}
+			if tt.wantKey != "" && got[tt.wantKey] != tt.apiKey {
+				t.Errorf("injectedEnv()[%s] = %q, want %q", tt.wantKey, got[tt.wantKey], tt.apiKey)
+			}
+			if _, ok := got[tt.absent]; ok {
+				t.Errorf("injectedEnv() unexpectedly set %s", tt.absent)
+			}
+		})
+	}
+}
+
+func TestEnvConflictsReadsClaudeSettings(t *testing.T) {
+	claudeDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", claudeDir)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ANTHROPIC_BASE_URL", "https://ambient.example.com")
+	userSettings := filepath.Join(claudeDir, "settings.json")
+	settings := `{"env": {"ANTHROPIC_BASE_URL": "https://settings.example.com"}}`
+	if err := os.WriteFile(userSettings, []byte(settings), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	project := t.TempDir()
+	t.Chdir(project)
+	localSettings := filepath.Join(project, ".claude", "settings.local.json")
+	if err := os.MkdirAll(filepath.Dir(localSettings), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(localSettings, []byte(`{"env": {"ANTHROPIC_MODEL": "claude-opus"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := ExecutionConfig{
+		Provider:     config.Provider{BaseURL: "https://api.z.ai/api/anthropic", Model: "glm-5.1"},
+		ProviderName: "zai",
+		HarnessToUse: "claude",
+	}
+
+	in := envCheckInput(cfg)
+	got := envcheck.Check(in)
+	want := []envcheck.Conflict{
+		{Key: "ANTHROPIC_BASE_URL", Source: userSettings, Effect: envcheck.Overrides},
+		{Key: "ANTHROPIC_BASE_URL", Source: "environment", Effect: envcheck.Overridden},
+		{Key: "ANTHROPIC_MODEL", Source: localSettings, Effect: envcheck.Overrides},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("envConflicts() = %+v, want %+v", got, want)
+	}
+	if msg := describeConflict(in, got[0]); !strings.HasSuffix(msg,
+		"the harness uses https://settings.example.com, not kairo's https://api.z.ai/api/anthropic") {
+		t.Errorf("describeConflict() = %q", msg)
+	}
+
+	cfg.HarnessToUse = "qwen"
+	if got := envConflicts(cfg); len(got) != 1 {
+		t.Errorf("envConflicts() for qwen = %+v, want only the ambient conflict", got)
+	}
+}
+
+func TestRunPreflightExplainEnv(t *testing.T) {
+	t.Setenv("CLAUDE_CONFIG_DIR", t.TempDir())
+	explainEnvFlag = true
+	defer func() { explainEnvFlag = false }()
+
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+
+	cfg := ExecutionConfig{
+		Cmd:          cmd,
+		ProviderEnv:  []string{"PATH=/bin", "ANTHROPIC_BASE_URL=https://api.z.ai/api/anthropic"},
+		Provider:     config.Provider{BaseURL: "https://api.z.ai/api/anthropic", Model: "glm-5.1"},
+		ProviderName: "zai",
+		HarnessToUse: "claude",
+		APIKey:       "sk-verysecretvalue-wxyz",
+	}
+
+	if runPreflight(cfg) {
+		t.Error("runPreflight() = true, want false with --explain-env")
+	}
+
+	got := out.String()
+	if strings.Contains(got, "sk-verysecretvalue") {
+		t.Errorf("explain output leaked the API key:\n%s", got)
+	}
+	for _, want := range []string{
+		"ANTHROPIC_AUTH_TOKEN=****wxyz [kairo]",
+		"ANTHROPIC_BASE_URL=https://api.z.ai/api/anthropic [kairo]",
+		"PATH=/bin [environment]",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("explain output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+// staticResolver answers every lookup with the same addresses.
+type staticResolver []string
+
+func (r staticResolver) LookupIPAddr(context.Context, string) ([]net.IPAddr, error) {
+	addrs := make([]net.IPAddr, len(r))
+	for i, ip := range r {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(ip)}
+	}
+
+	return addrs, nil
+}
+
+func TestCheckBaseURL(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, "audit:\n  enabled: true\nproviders: {}\n")
+	cmd, _ := explainTestCmd(t, dir)
+	deps := CLIContextFromCmd(cmd).Deps()
+
+	tests := []struct {
+		name     string
+		provider config.Provider
+		resolver staticResolver
+		want     bool
+		action   string
+	}{
+		{"public host", config.Provider{BaseURL: "https://api.z.ai/api/anthropic"}, staticResolver{"203.0.113.9"}, true, ""},
+		{"plain http", config.Provider{BaseURL: "http://api.z.ai/api/anthropic"}, nil, false, "refuse_base_url"},
+		{"private address", config.Provider{BaseURL: "https://api.z.ai/api/anthropic"},
+			staticResolver{"203.0.113.9", "10.1.2.3"}, true, "private_base_url"},
+		{"local gateway allowed", config.Provider{BaseURL: "http://127.0.0.1:8899", AllowInsecure: true},
+			staticResolver{"127.0.0.1"}, true, ""},
+		{"no base url", config.Provider{}, nil, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statePath := filepath.Join(stateDir(CLIContextFromCmd(cmd), dir), audit.LogFileName)
+			_ = os.Remove(statePath)
+			deps.Resolver = tt.resolver
+			cfg := ExecutionConfig{Cmd: cmd, Deps: deps, Provider: tt.provider, ProviderName: "zai"}
+
+			if got := checkBaseURL(cfg); got != tt.want {
+				t.Errorf("checkBaseURL() = %v, want %v", got, tt.want)
+			}
+			entries, err := audit.LoadEntries(stateDir(CLIContextFromCmd(cmd), dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case tt.action == "" && len(entries) != 0:
+				t.Errorf("audit entries = %+v, want none", entries)
+			case tt.action != "" && (len(entries) != 1 || entries[0].Event != audit.EventWarning ||
+				entries[0].Action != tt.action):
+				t.Errorf("audit entries = %+v, want one %s warning", entries, tt.action)
+			}
+		})
+	}
+}
+
+func TestWaitHealthy(t *testing.T) {
+	origInitial, origMax := waitHealthyInitialBackoff, waitHealthyMaxBackoff
+	waitHealthyInitialBackoff, waitHealthyMaxBackoff = time.Millisecond, 2*time.Millisecond
+	t.Cleanup(func() { waitHealthyInitialBackoff, waitHealthyMaxBackoff = origInitial, origMax })
+
+	tests := []struct {
+		name     string
+		statuses []health.Status
+		budget   time.Duration
+		want     bool
+		wantCall int
+	}{
+		{"healthy at once", []health.Status{health.StatusOK}, time.Second, true, 1},
+		{"recovers after retries", []health.Status{health.StatusError, health.StatusError, health.StatusOK}, time.Second, true, 3},
+		{"auth error stops", []health.Status{health.StatusAuthError}, time.Second, false, 1},
+		{"gives up after budget", []health.Status{health.StatusError}, 20 * time.Millisecond, false, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			d := testDeps()
+			d.Health = &mockHealth{CheckFn: func(context.Context, string, string, health.AuthStyle) health.Result {
+				status := tt.statuses[min(calls, len(tt.statuses)-1)]
+				calls++
+
+				return health.Result{Status: status, Error: "HTTP 503"}
+			}}
+
+			got := waitHealthy(ExecutionConfig{Deps: d, Cmd: &cobra.Command{}, ProviderName: "zai"}, tt.budget)
+			if got != tt.want {
+				t.Errorf("waitHealthy() = %v, want %v", got, tt.want)
+			}
+			if tt.wantCall >= 0 && calls != tt.wantCall {
+				t.Errorf("health checks = %d, want %d", calls, tt.wantCall)
+			}
+		})
+	}
+}
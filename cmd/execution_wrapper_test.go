@@ -7,10 +7,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/health"
 	"github.com/dkmnx/kairo/internal/wrapper"
 	"github.com/spf13/cobra"
 )
@@ -70,8 +72,8 @@ func TestRunHarnessWithWrapper_WrapperGenerationFails(t *testing.T) {
 		mp.LookPathFn = func(file string) (string, error) {
 			return "/usr/bin/" + file, nil
 		}
-		mw.GenerateWrapperScriptFn = func(cfg wrapper.ScriptConfig) (string, bool, error) {
-			return "", false, fmt.Errorf("wrapper: token path cannot be empty")
+		mw.PrepareLaunchFn = func(cfg wrapper.ScriptConfig) (wrapper.Launch, error) {
+			return wrapper.Launch{}, fmt.Errorf("wrapper: token path cannot be empty")
 		}
 	})
 
@@ -94,7 +96,7 @@ func TestRunHarnessWithWrapper_WrapperGenerationFails(t *testing.T) {
 		t.Fatal("runHarnessWithWrapper() should return error when wrapper generation fails")
 	}
 
-	expectedSubstr := "generating wrapper script"
+	expectedSubstr := "preparing harness launch"
 	if !strings.Contains(err.Error(), expectedSubstr) {
 		t.Errorf("Error should contain %q, got: %v", expectedSubstr, err)
 	}
@@ -140,58 +142,81 @@ func TestRunHarnessWithWrapper_Success(t *testing.T) {
 	}
 }
 
-func TestBuildWrapperCommand_Windows(t *testing.T) {
-	var capturedCmd *exec.Cmd
+func TestRunHarnessWithWrapper_WrapperPing(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		var got *wrapper.Ping
+		d := testDeps(func(mp *mockProcess, mw *mockWrapper, mu *mockUpdate) {
+			mp.LookPathFn = func(file string) (string, error) {
+				return "/usr/bin/" + file, nil
+			}
+			mw.PrepareLaunchFn = func(cfg wrapper.ScriptConfig) (wrapper.Launch, error) {
+				got = cfg.Ping
+				return wrapper.Launch{}, fmt.Errorf("stop")
+			}
+		})
+
+		_ = runHarnessWithWrapper(context.Background(), d, HarnessRun{
+			TokenPath:     "/tmp/test-auth/token",
+			HarnessBinary: "claude",
+			ProviderName:  "zai",
+			Provider: config.Provider{
+				BaseURL:     "https://api.z.ai/api/anthropic/",
+				AuthStyle:   "bearer",
+				WrapperPing: enabled,
+			},
+			Harness: "claude",
+		})
+
+		if !enabled {
+			if got != nil {
+				t.Errorf("Ping = %+v without wrapper_ping", got)
+			}
+			continue
+		}
+		want := wrapper.Ping{Provider: "zai", URL: "https://api.z.ai/api/anthropic/v1/models", AuthStyle: health.AuthStyleBearer}
+		if got == nil || *got != want {
+			t.Errorf("Ping = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestRunHarnessWithWrapper_StartsLaunch(t *testing.T) {
+	var gotName string
+	var gotArgs []string
 	d := testDeps(func(mp *mockProcess, mw *mockWrapper, mu *mockUpdate) {
+		mp.LookPathFn = func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		}
+		mw.PrepareLaunchFn = func(cfg wrapper.ScriptConfig) (wrapper.Launch, error) {
+			return wrapper.Launch{Name: "/usr/local/bin/kairo", Args: wrapper.ExecArgs(cfg)}, nil
+		}
 		mp.ExecCommandContextFn = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
-			capturedCmd = exec.CommandContext(ctx, name, arg...)
-			return capturedCmd
+			gotName, gotArgs = name, arg
+			return testEchoCmd()
 		}
 	})
 
-	ctx := context.Background()
-	params := WrapperCmd{
-		Ctx:           ctx,
-		WrapperScript: "C:\\temp\\wrapper.ps1",
-		IsWindows:     true,
-	}
-
-	cmd := buildWrapperCommand(d, params)
-
-	if cmd == nil {
-		t.Fatal("buildWrapperCommand() should return non-nil cmd for Windows")
-	}
-
-	cmdName := ""
-	if len(cmd.Args) > 0 {
-		cmdName = cmd.Args[0]
-	}
-	if cmdName != "powershell" {
-		t.Errorf("cmd.Args[0] = %q, want 'powershell'", cmdName)
-	}
-
-	expectedArgs := []string{"powershell", "-NoProfile", "-ExecutionPolicy", "Bypass", "-File", "C:\\temp\\wrapper.ps1"}
-	if len(cmd.Args) != len(expectedArgs) {
-		t.Errorf("cmd.Args length = %d, want %d", len(cmd.Args), len(expectedArgs))
+	err := runHarnessWithWrapper(context.Background(), d, HarnessRun{
+		AuthDir:       "/tmp/test-auth",
+		TokenPath:     "/tmp/test-auth/token",
+		HarnessBinary: "claude",
+		CliArgs:       []string{"-p", "it's $HOME"},
+		EnvVarName:    "ANTHROPIC_API_KEY",
+		Harness:       "claude",
+	})
+	if err != nil {
+		t.Fatalf("runHarnessWithWrapper() error = %v", err)
 	}
-}
 
-func TestBuildWrapperCommand_Unix(t *testing.T) {
-	d := NewDeps()
-	ctx := context.Background()
-	params := WrapperCmd{
-		Ctx:           ctx,
-		WrapperScript: "/tmp/wrapper.sh",
-		IsWindows:     false,
+	if gotName != "/usr/local/bin/kairo" {
+		t.Errorf("started %q, want the launch from PrepareLaunch", gotName)
 	}
-
-	cmd := buildWrapperCommand(d, params)
-
-	if cmd == nil {
-		t.Fatal("buildWrapperCommand() should return non-nil cmd for Unix")
+	cfg, err := wrapper.ParseExecArgs(gotArgs[1:])
+	if err != nil {
+		t.Fatalf("ParseExecArgs(%q) error = %v", gotArgs, err)
 	}
-
-	if len(cmd.Args) < 1 {
-		t.Error("cmd.Args should have at least one element")
+	if cfg.CliPath != "/usr/bin/claude" || !slices.Equal(cfg.CliArgs, []string{"-p", "it's $HOME"}) ||
+		cfg.EnvVarName != "ANTHROPIC_API_KEY" {
+		t.Errorf("launch = %+v", cfg)
 	}
 }
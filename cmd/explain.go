@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/journal"
+	"github.com/dkmnx/kairo/internal/plan"
+	"github.com/dkmnx/kairo/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+var explainFlag bool
+
+// planFunc fills p with what cmd would do when run with args, without doing
+// any of it. Planners may read configuration but never decrypt secrets,
+// write files, run processes, or contact the network.
+type planFunc func(cmd *cobra.Command, args []string, p *plan.Plan) error
+
+// planners maps each command to its planner for --explain.
+var planners = map[*cobra.Command]planFunc{}
+
+var installExplainOnce sync.Once
+
+// installExplain makes every runnable command under root print its plan
+// instead of running when --explain is set. It runs once the command tree is
+// complete, since commands register themselves in init functions.
+func installExplain(root *cobra.Command) {
+	installExplainOnce.Do(func() { wrapForExplain(root) })
+}
+
+func wrapForExplain(c *cobra.Command) {
+	for _, sub := range c.Commands() {
+		wrapForExplain(sub)
+	}
+
+	switch {
+	case c.Run != nil:
+		run := c.Run
+		c.Run = func(cmd *cobra.Command, args []string) {
+			if !explainFlag {
+				run(cmd, args)
+
+				return
+			}
+			explainCommand(cmd, args)
+		}
+	case c.RunE != nil:
+		runE := c.RunE
+		c.RunE = func(cmd *cobra.Command, args []string) error {
+			if !explainFlag {
+				return runE(cmd, args)
+			}
+			explainCommand(cmd, args)
+
+			return nil
+		}
+	}
+}
+
+// explainCommand prints the plan for cmd, or the reason it cannot be made,
+// exiting with status 1 in that case.
+func explainCommand(cmd *cobra.Command, args []string) {
+	err := writePlan(cmd, args)
+	if err == nil {
+		return
+	}
+	printError(err)
+	if cliCtx := CLIContextFromCmd(cmd); cliCtx != nil && cliCtx.Deps() != nil {
+		cliCtx.Deps().Process.ExitProcess(1)
+	}
+}
+
+func writePlan(cmd *cobra.Command, args []string) error {
+	fn, ok := planners[cmd]
+	if !ok {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("--explain is not supported by '%s'", cmd.CommandPath()))
+	}
+
+	p := plan.New(cmd.CommandPath(), args)
+	if _, err := os.Stat(policy.Path); err == nil {
+		p.Read(policy.Path)
+	}
+	if err := fn(cmd, args, p); err != nil {
+		return err
+	}
+
+	return p.Encode(cmd.OutOrStdout())
+}
+
+// registerPlanner sets the planner --explain uses for c.
+func registerPlanner(c *cobra.Command, fn planFunc) {
+	planners[c] = fn
+}
+
+// planEnv is the configuration a planner describes effects against.
+type planEnv struct {
+	cliCtx *CLIContext
+	dir    string
+	// cfg is nil when the config directory holds no configuration yet.
+	cfg *config.Config
+}
+
+// loadPlanEnv resolves the config directory and loads its configuration.
+// A missing configuration is not an error: commands such as setup create it.
+func loadPlanEnv(cmd *cobra.Command) (planEnv, error) {
+	cliCtx := CLIContextFromCmd(cmd)
+	if cliCtx == nil {
+		return planEnv{}, kairoerrors.NewError(kairoerrors.RuntimeError, "CLI context not available")
+	}
+	dir := cliCtx.ConfigDir()
+	if dir == "" {
+		return planEnv{}, kairoerrors.NewError(kairoerrors.ConfigError, "config directory not found").
+			WithContext("hint", "set KAIRO_CONFIG_DIR or pass --config <dir>")
+	}
+
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil && !stderrors.Is(err, kairoerrors.ErrConfigNotFound) {
+		return planEnv{}, err
+	}
+
+	return planEnv{cliCtx: cliCtx, dir: dir, cfg: cfg}, nil
+}
+
+// loadPlanConfig is loadPlanEnv for commands that need configured providers.
+func loadPlanConfig(cmd *cobra.Command) (planEnv, error) {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return planEnv{}, err
+	}
+
+	return e, e.requireConfig()
+}
+
+// requireConfig returns an error when there is no configuration to act on.
+func (e planEnv) requireConfig() error {
+	if e.cfg == nil || len(e.cfg.Providers) == 0 {
+		return kairoerrors.NewError(kairoerrors.ConfigError, "no providers configured").
+			WithContext("hint", "run 'kairo setup' to get started")
+	}
+
+	return nil
+}
+
+// provider returns the configured provider called name.
+func (e planEnv) provider(name string) (config.Provider, error) {
+	if err := e.requireConfig(); err != nil {
+		return config.Provider{}, err
+	}
+	provider, ok := e.cfg.Providers[name]
+	if !ok {
+		return config.Provider{}, kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("provider '%s' not configured", name)).
+			WithContext("hint", "run 'kairo list' to see configured providers")
+	}
+
+	return provider, nil
+}
+
+func (e planEnv) readConfig(p *plan.Plan) {
+	p.Read(filepath.Join(e.dir, config.BaseFileName))
+	if files, err := config.OverrideFiles(e.dir); err == nil {
+		p.Read(files...)
+	}
+}
+
+func (e planEnv) writeConfig(p *plan.Plan) {
+	p.Write(filepath.Join(e.dir, config.BaseFileName))
+}
+
+func (e planEnv) secretsPath() string {
+	return filepath.Join(e.dir, constants.SecretsFileName)
+}
+
+func (e planEnv) keyPath() string {
+	return filepath.Join(e.dir, constants.KeyFileName)
+}
+
+func (e planEnv) recipientsPath() string {
+	return filepath.Join(e.dir, constants.RecipientsFileName)
+}
+
+func (e planEnv) escrowPath() string {
+	return filepath.Join(e.dir, constants.EscrowFileName)
+}
+
+func (e planEnv) trustedSignersPath() string {
+	return filepath.Join(e.dir, constants.TrustedSignersFileName)
+}
+
+func (e planEnv) stateDir() string {
+	dir, err := config.StateDir(e.dir, e.cfg)
+	if err != nil {
+		return e.dir
+	}
+
+	return dir
+}
+
+// readSecrets records decrypting the secrets file, if there is one.
+func (e planEnv) readSecrets(p *plan.Plan) {
+	if _, err := os.Stat(e.secretsPath()); err != nil {
+		p.Note(constants.SecretsFileName + " does not exist yet; no secrets are decrypted")
+
+		return
+	}
+	p.Read(e.secretsPath())
+	e.useKey(p, "decrypt")
+}
+
+// writeSecrets records re-encrypting the secrets file, after keeping the
+// current one as the newest backup.
+func (e planEnv) writeSecrets(p *plan.Plan) {
+	if _, err := os.Stat(e.secretsPath()); err == nil {
+		backups := make([]string, 0, secretsBackupCount)
+		for n := 1; n <= secretsBackupCount; n++ {
+			backups = append(backups, secretsBackupPath(e.secretsPath(), n))
+		}
+		p.Write(backups...)
+	}
+	e.useKey(p, "encrypt")
+	p.Write(e.secretsPath())
+}
+
+// journal records the journal through which op swaps in its staged files.
+func (e planEnv) journal(p *plan.Plan, op string) {
+	p.Write(journal.Path(e.dir))
+	p.Remove(journal.Path(e.dir))
+	p.Note("new files are staged with a .new suffix and swapped in through " + constants.JournalFileName +
+		", so the next kairo command finishes or undoes an interrupted " + op)
+}
+
+// ensureKey records generating the age key when there is none yet.
+func (e planEnv) ensureKey(p *plan.Plan) {
+	if e.backend() != crypto.BackendAge {
+		return
+	}
+	if _, err := os.Stat(e.keyPath()); err != nil {
+		p.Write(e.keyPath(), filepath.Join(e.dir, constants.LockFileName))
+		p.Note(constants.KeyFileName + " does not exist yet and is generated")
+	}
+}
+
+func (e planEnv) backend() string {
+	if e.cfg == nil || e.cfg.Crypto == nil || e.cfg.Crypto.Backend == "" {
+		return crypto.BackendAge
+	}
+
+	return e.cfg.Crypto.Backend
+}
+
+// useKey records how the secrets key is reached for op, "encrypt" or
+// "decrypt": through the KMS CLI, a running kairo agent, or age.key.
+func (e planEnv) useKey(p *plan.Plan, op string) {
+	switch e.backend() {
+	case crypto.BackendAWSKMS:
+		p.Exec(op+" the data key with AWS KMS", "aws", "kms", op, "--key-id", e.cfg.Crypto.KeyID)
+	case crypto.BackendGCPKMS:
+		p.Exec(op+" the data key with Cloud KMS", "gcloud", "kms", op, "--key", e.cfg.Crypto.KeyID)
+	default:
+		socket := agentSocketPath(e.dir, e.cfg)
+		if runtime.GOOS != constants.WindowsGOOS {
+			if _, err := os.Stat(socket); err == nil {
+				p.Connect("unix:" + socket)
+				p.Note("the key is used through the kairo agent at " + socket +
+					" while it runs, and read from " + constants.KeyFileName + " otherwise")
+			}
+		}
+		p.Read(e.keyPath())
+		if op == "encrypt" {
+			for _, path := range []string{e.recipientsPath(), e.escrowPath()} {
+				if _, err := os.Stat(path); err == nil {
+					p.Read(path)
+				}
+			}
+		}
+	}
+}
+
+// recordAudit records an audit log write for event when the config audits it.
+func (e planEnv) recordAudit(p *plan.Plan, event audit.Event) {
+	policy, enabled, err := auditPolicy(e.cfg)
+	if err != nil || !enabled || !policy.Allows(event) {
+		return
+	}
+	if e.cfg.Audit.Encrypt {
+		if _, err := os.Stat(e.auditKeyPath()); err != nil {
+			p.Write(e.auditKeyPath())
+			p.Note(audit.KeyFileName + " does not exist yet and is generated to encrypt audit entries")
+		} else {
+			p.Read(e.auditKeyPath())
+		}
+	}
+	p.Write(filepath.Join(e.stateDir(), audit.LogFileName))
+}
+
+func (e planEnv) auditKeyPath() string {
+	return filepath.Join(e.dir, audit.KeyFileName)
+}
+
+// readAuditKey records reading the audit key to decrypt entries, if there is
+// one.
+func (e planEnv) readAuditKey(p *plan.Plan) {
+	if _, err := os.Stat(e.auditKeyPath()); err == nil {
+		p.Read(e.auditKeyPath())
+	}
+}
+
+// notifySecretAccess records the hooks.secret_access command run when the
+// provider's key is decrypted.
+func (e planEnv) notifySecretAccess(p *plan.Plan, provider string) {
+	if e.cfg == nil || e.cfg.Hooks == nil || e.cfg.Hooks.SecretAccess == "" {
+		return
+	}
+	shell, flag := hookShell()
+	p.Exec("hooks.secret_access for "+provider, shell, flag, e.cfg.Hooks.SecretAccess)
+	p.SetEnv("KAIRO_EVENT", "KAIRO_PROVIDER", "KAIRO_PURPOSE", "KAIRO_TIMESTAMP")
+}
+
+// healthWebhook records the hooks.health_webhook post, naming only the
+// host, since the URL usually carries the token that authorizes posting.
+func (e planEnv) healthWebhook(p *plan.Plan, note string) {
+	webhook, ok := healthWebhook(e.cfg)
+	if !ok {
+		return
+	}
+	if u, err := url.Parse(webhook.URL); err == nil {
+		p.Connect(u.Scheme + "://" + u.Host)
+	}
+	p.Note(note)
+}
+
+// planStatic returns a planner for a command that only reads its
+// configuration and prints, plus whatever extra adds.
+func planStatic(extra func(e planEnv, p *plan.Plan)) planFunc {
+	return func(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+		e, err := loadPlanEnv(cmd)
+		if err != nil {
+			return err
+		}
+		e.readConfig(p)
+		if extra != nil {
+			extra(e, p)
+		}
+
+		return nil
+	}
+}
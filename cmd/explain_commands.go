@@ -0,0 +1,1137 @@
+package cmd
+
+import (
+	"cmp"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"github.com/dkmnx/kairo/internal/agent"
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/claudesettings"
+	"github.com/dkmnx/kairo/internal/compare"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/configsync"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/gitguard"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/metrics"
+	"github.com/dkmnx/kairo/internal/plan"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/update"
+	"github.com/dkmnx/kairo/internal/usage"
+	"github.com/dkmnx/kairo/internal/version"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	registerPlanner(setupCmd, planSetup)
+	registerPlanner(secretsSetCmd, planSecretsSet)
+	registerPlanner(secretsValidateCmd, planSecretsValidate)
+	registerPlanner(secretsRecoverCmd, planSecretsRecover)
+	registerPlanner(secretsHistoryCmd, planStatic(planAuditRead))
+	registerPlanner(secretsRevealCmd, planSecretsReveal)
+	registerPlanner(rotateCmd, planRotate)
+	registerPlanner(deleteCmd, planDelete)
+	registerPlanner(defaultCmd, planDefault)
+	registerPlanner(harnessCmd, planHarness)
+	registerPlanner(harnessGetCmd, planStatic(nil))
+	registerPlanner(harnessSetCmd, planHarness)
+	registerPlanner(importCmd, planImport)
+	registerPlanner(agentStartCmd, planAgentStart)
+	registerPlanner(agentStatusCmd, planAgentConnect)
+	registerPlanner(agentStopCmd, planAgentConnect)
+	registerPlanner(keyShowCmd, planKeyShow)
+	registerPlanner(recipientsListCmd, planRecipientsList)
+	registerPlanner(recipientsAddCmd, planRecipientsEdit)
+	registerPlanner(recipientsRemoveCmd, planRecipientsEdit)
+	registerPlanner(recipientsRekeyCmd, planRecipientsRekey)
+	registerPlanner(escrowShowCmd, planEscrowShow)
+	registerPlanner(escrowSetCmd, planEscrowSet)
+	registerPlanner(escrowOpenCmd, planEscrowOpen)
+	registerPlanner(configShowCmd, planStatic(nil))
+	registerPlanner(configSchemaCmd, planNothing)
+	registerPlanner(listCmd, planStatic(planList))
+	registerPlanner(statusCmd, planStatus)
+	registerPlanner(doctorCmd, planDoctor)
+	registerPlanner(selfTestCmd, planSelfTest)
+	registerPlanner(auditCmd, planStatic(planAuditRead))
+	registerPlanner(auditPruneCmd, planStatic(planAuditPrune))
+	registerPlanner(auditShowCmd, planStatic(planAuditRead))
+	registerPlanner(snapshotEnvCmd, planSnapshotEnv)
+	registerPlanner(lockCmd, planLock)
+	registerPlanner(cleanCmd, planClean)
+	registerPlanner(completionCmd, planCompletion)
+	registerPlanner(manCmd, planMan)
+	registerPlanner(mockProviderCmd, planMockProvider)
+	registerPlanner(promptSegmentCmd, planPromptSegment)
+	registerPlanner(shellInitCmd, planNothing)
+	registerPlanner(helpCmd, planNothing)
+	registerPlanner(verifyReleaseCmd, planVerifyRelease)
+	registerPlanner(versionCmd, planVersion)
+	registerPlanner(updateCmd, planUpdate)
+	registerPlanner(providersListCmd, planProvidersList)
+	registerPlanner(providersRefreshCmd, planProvidersRefresh)
+	registerPlanner(providersInfoCmd, planProvidersList)
+	registerPlanner(providersTemplateCmd, planNothing)
+	registerPlanner(providersAddCmd, planProvidersAdd)
+	registerPlanner(providersShowCmd, planProvidersShow)
+	registerPlanner(providersDisableCmd, planProvidersDisable)
+	registerPlanner(providersEnableCmd, planProvidersDisable)
+	registerPlanner(providersExportCmd, planStatic(func(_ planEnv, p *plan.Plan) {
+		p.Note("the API key is never exported; no secrets are decrypted")
+	}))
+	registerPlanner(providersImportCmd, planProvidersImport)
+	registerPlanner(providersBundleCmd, planStatic(func(_ planEnv, p *plan.Plan) {
+		p.Note("the bundle is printed unsigned and without API keys; no secrets are decrypted")
+	}))
+	registerPlanner(providersTrustCmd, planProvidersTrust)
+	registerPlanner(providersUntrustCmd, planProvidersTrust)
+	registerPlanner(spawnCmd, planSpawn)
+	registerPlanner(compareCmd, planCompare)
+	registerPlanner(syncExportCmd, planSyncExport)
+	registerPlanner(syncImportCmd, planSyncImport)
+	registerPlanner(usageCmd, planStatic(planUsage))
+	registerPlanner(summaryCmd, planStatic(planSummary))
+}
+
+// planNothing plans a command that only prints.
+func planNothing(*cobra.Command, []string, *plan.Plan) error {
+	return nil
+}
+
+func planSetup(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	e.ensureKey(p)
+	e.readSecrets(p)
+
+	if setupResetSecrets {
+		p.Remove(e.secretsPath(), setupProgressPath(e.dir))
+		p.Write(e.keyPath())
+		p.Note("with --reset-secrets, the encryption key is only regenerated if the secrets cannot be decrypted")
+	}
+	if setupEscrowRecipient != "" {
+		planEscrowWrite(e, p)
+	}
+
+	progressPath := setupProgressPath(e.dir)
+	if _, err := os.Stat(progressPath); err == nil {
+		p.Read(progressPath)
+	}
+	if !setupAPIKeyStdin {
+		e.useKey(p, "encrypt")
+		p.Write(progressPath)
+		p.Remove(progressPath)
+		p.Note("each answer is saved to " + constants.SetupProgressFileName +
+			" until the provider is saved, so 'kairo setup --resume' can continue an interrupted setup")
+		p.Note("once the provider is saved, setup offers to send it a short test prompt; answering yes" +
+			" makes one request to its base URL")
+	}
+
+	if setupProvider != "" {
+		p.Secret(harness.APIKeyEnvVar(setupProvider))
+		if providers.RequiresAPIKey(setupProvider) {
+			e.writeSecrets(p)
+		}
+	} else {
+		p.Note("the provider is chosen interactively; its API key is stored in " + constants.SecretsFileName)
+		e.writeSecrets(p)
+	}
+	e.writeConfig(p)
+	e.recordAudit(p, audit.EventConfig)
+
+	return nil
+}
+
+func planSecretsSet(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	if _, err := e.provider(args[0]); err != nil {
+		return err
+	}
+	if !providers.RequiresAPIKey(args[0]) {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "provider '"+args[0]+"' does not use an API key")
+	}
+	e.readConfig(p)
+
+	if secretsViaBrowser {
+		p.Connect("https://" + secretsBrowserListen)
+	}
+	e.ensureKey(p)
+	e.readSecrets(p)
+	if secretsSetForce {
+		p.Note("if " + constants.SecretsFileName + " cannot be decrypted, it is renamed to " +
+			constants.SecretsFileName + ".corrupt-<time> and a new one is started")
+	}
+	p.Secret(harness.APIKeyEnvVar(args[0]))
+	e.writeSecrets(p)
+	e.recordAudit(p, audit.EventRotate)
+
+	return nil
+}
+
+func planSecretsRecover(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readSecrets(p)
+	for n := 1; n <= secretsBackupCount; n++ {
+		backup := secretsBackupPath(e.secretsPath(), n)
+		if _, err := os.Stat(backup); err == nil {
+			p.Read(backup)
+		}
+	}
+	p.Note("if " + constants.SecretsFileName + " does not decrypt, it is renamed to " +
+		constants.SecretsFileName + ".corrupt-<time> and the newest backup that decrypts is copied in its place")
+	p.Write(e.secretsPath())
+	e.journal(p, "secrets recover")
+	e.recordAudit(p, audit.EventRotate)
+
+	return nil
+}
+
+func planSecretsValidate(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	e.readSecrets(p)
+
+	names := args
+	if len(names) == 0 {
+		for _, name := range sortProviderNames(e.cfg.Providers, e.cfg.DefaultProvider) {
+			if providers.RequiresAPIKey(name) {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, name := range names {
+		if _, err := e.provider(name); err != nil {
+			return err
+		}
+		if providers.RequiresAPIKey(name) {
+			p.Secret(harness.APIKeyEnvVar(name))
+			e.notifySecretAccess(p, name)
+		}
+	}
+
+	return nil
+}
+
+// planSecretsReveal plans `kairo secrets reveal`, mirroring runSecretsReveal.
+func planSecretsReveal(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	e.readSecrets(p)
+
+	name, providerName := args[0], ""
+	if _, ok := e.cfg.Providers[args[0]]; ok {
+		name, providerName = harness.APIKeyEnvVar(args[0]), args[0]
+	}
+	p.Secret(name)
+	if providerName != "" {
+		e.notifySecretAccess(p, providerName)
+	}
+	e.recordAudit(p, audit.EventReveal)
+	p.Note("the value is shown on the terminal's alternate screen for " + revealFor.String() +
+		", then cleared; redirected output is refused")
+
+	return nil
+}
+
+func planRotate(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+
+	if rotateProvider == "" {
+		if rotateNewKeyStdin {
+			return kairoerrors.NewError(kairoerrors.ValidationError, "--new-key-stdin requires --provider")
+		}
+		e.readSecrets(p)
+		if e.backend() == crypto.BackendAge {
+			p.Write(e.keyPath())
+			e.journal(p, "rotate")
+		}
+		e.writeSecrets(p)
+		e.recordAudit(p, audit.EventRotate)
+
+		return nil
+	}
+
+	provider, err := e.provider(rotateProvider)
+	if err != nil {
+		return err
+	}
+	e.readSecrets(p)
+	p.Secret(harness.APIKeyEnvVar(rotateProvider))
+	e.writeSecrets(p)
+	e.recordAudit(p, audit.EventRotate)
+
+	if provider.RevokeHook != "" {
+		e.notifySecretAccess(p, rotateProvider)
+		shell, flag := hookShell()
+		p.Exec("revoke_hook for "+rotateProvider+", given the old key on stdin", shell, flag, provider.RevokeHook)
+		p.SetEnv("KAIRO_PROVIDER")
+	}
+
+	return nil
+}
+
+func planDelete(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		if _, err := e.provider(args[0]); err != nil {
+			return err
+		}
+		p.Secret(harness.APIKeyEnvVar(args[0]))
+	} else {
+		p.Note("the provider is chosen interactively")
+	}
+	e.readConfig(p)
+	e.writeConfig(p)
+	e.readSecrets(p)
+	e.writeSecrets(p)
+	e.recordAudit(p, audit.EventConfig)
+
+	return nil
+}
+
+func planDefault(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	if len(args) == 0 {
+		return nil
+	}
+	if _, err := e.provider(args[0]); err != nil {
+		return err
+	}
+	e.writeConfig(p)
+	e.recordAudit(p, audit.EventConfig)
+
+	return nil
+}
+
+func planProvidersDisable(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	if _, err := e.provider(args[0]); err != nil {
+		return err
+	}
+	e.writeConfig(p)
+	e.recordAudit(p, audit.EventConfig)
+
+	return nil
+}
+
+func planHarness(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	if len(args) == 0 {
+		return nil
+	}
+	if !isValidHarness(args[0]) {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "invalid harness: '"+args[0]+"'").
+			WithContext("hint", "use one of: claude, qwen, pi, crush")
+	}
+	e.writeConfig(p)
+	e.recordAudit(p, audit.EventConfig)
+
+	return nil
+}
+
+func planImport(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	if !importFromClaudeSettings {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "no import source specified").
+			WithContext("hint", "use --from-claude-settings to import from Claude Code")
+	}
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+
+	settingsPath, err := claudesettings.Path()
+	if err != nil {
+		return err
+	}
+	p.Read(settingsPath)
+	settings, err := claudesettings.Load(settingsPath)
+	if err != nil {
+		return err
+	}
+	detections := claudesettings.Detect(settings, os.Environ())
+	if len(detections) == 0 {
+		p.Note("no Claude Code provider settings were detected; nothing is imported")
+
+		return nil
+	}
+
+	e.readConfig(p)
+	e.ensureKey(p)
+	e.readSecrets(p)
+	for _, d := range detections {
+		name, _, err := importedProvider(d, importName)
+		if err != nil {
+			continue
+		}
+		if d.AuthToken != "" {
+			p.Secret(harness.APIKeyEnvVar(name))
+			e.writeSecrets(p)
+			e.journal(p, "import")
+		}
+	}
+	e.writeConfig(p)
+	e.recordAudit(p, audit.EventConfig)
+
+	return nil
+}
+
+func planAgentStart(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	if runtime.GOOS == constants.WindowsGOOS {
+		return kairoerrors.NewError(kairoerrors.RuntimeError, "kairo agent is not supported on Windows")
+	}
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	if e.backend() != crypto.BackendAge {
+		return kairoerrors.NewError(kairoerrors.ConfigError,
+			"kairo agent only holds age keys, but crypto.backend is "+e.backend())
+	}
+	policy, err := agentPolicy(cmd, e.cfg)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+
+	metricsAddr := agentMetricsListen(e.cfg)
+	if metricsAddr != "" {
+		if err := metrics.CheckLoopback(metricsAddr); err != nil {
+			return err
+		}
+	}
+
+	socket := agentSocketPath(e.dir, e.cfg)
+	p.Read(e.keyPath())
+	p.Connect("unix:" + socket)
+	p.Write(socket)
+	if !agentForeground {
+		exe, err := os.Executable()
+		if err != nil {
+			exe = "kairo"
+		}
+		args := []string{"agent", "start", "--key-from-stdin", "--ttl", policy.TTL.String(), "--config", e.dir}
+		if metricsAddr != "" {
+			args = append(args, "--metrics-listen", metricsAddr)
+		}
+		p.Exec("run the agent in the background, given the unlocked key on stdin", exe, args...)
+		p.SetEnv(agent.SocketEnv)
+	}
+	if metricsAddr != "" {
+		p.Note("serves Prometheus metrics on http://" + metricsAddr + metrics.Path +
+			", computed from the audit log, usage, and health history at each scrape")
+	}
+
+	return nil
+}
+
+func planAgentConnect(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	p.Connect("unix:" + agentSocketPath(e.dir, e.cfg))
+
+	return nil
+}
+
+func planKeyShow(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	if !keyShowPublic {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "kairo never shows the private key")
+	}
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	if socket := agentSocketPath(e.dir, e.cfg); runtime.GOOS != constants.WindowsGOOS {
+		if _, err := os.Stat(socket); err == nil {
+			p.Connect("unix:" + socket)
+		}
+	}
+	p.Read(e.keyPath())
+	if keyShowCopy {
+		p.Note("the public recipient is piped to the platform clipboard command if one is installed, " +
+			"and otherwise sent to the terminal as an OSC 52 escape sequence")
+	}
+
+	return nil
+}
+
+func planRecipientsList(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	if socket := agentSocketPath(e.dir, e.cfg); runtime.GOOS != constants.WindowsGOOS {
+		if _, err := os.Stat(socket); err == nil {
+			p.Connect("unix:" + socket)
+		}
+	}
+	p.Read(e.keyPath(), e.recipientsPath(), e.escrowPath())
+
+	return nil
+}
+
+func planEscrowShow(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	p.Read(e.escrowPath())
+
+	return nil
+}
+
+func planEscrowSet(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	planEscrowWrite(e, p)
+
+	return nil
+}
+
+// planEscrowWrite records setting the escrow recipient, which re-encrypts an
+// existing secrets file to it.
+func planEscrowWrite(e planEnv, p *plan.Plan) {
+	p.Write(e.escrowPath())
+	e.recordAudit(p, audit.EventConfig)
+	if _, err := os.Stat(e.secretsPath()); err != nil {
+		return
+	}
+	e.readSecrets(p)
+	e.writeSecrets(p)
+	e.recordAudit(p, audit.EventRotate)
+}
+
+func planEscrowOpen(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	p.Read(escrowIdentity, cmp.Or(escrowSecrets, e.secretsPath()))
+	if policy, enabled, err := auditPolicy(e.cfg); err != nil || !enabled || !policy.Allows(audit.EventEscrow) {
+		p.Note("auditing does not record 'escrow' events, so the command refuses without decrypting")
+
+		return nil
+	}
+	e.recordAudit(p, audit.EventEscrow)
+	p.Note("the secrets are printed to stdout, after the audit entry is written")
+
+	return nil
+}
+
+func planRecipientsEdit(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	p.Read(e.recipientsPath())
+	p.Write(e.recipientsPath())
+	e.recordAudit(p, audit.EventConfig)
+	p.Note("secrets.age is not re-encrypted until 'kairo recipients rekey' or the next write of a secret")
+
+	return nil
+}
+
+func planRecipientsRekey(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	if _, err := os.Stat(e.secretsPath()); err != nil {
+		p.Note(constants.SecretsFileName + " does not exist yet; nothing is re-encrypted")
+
+		return nil
+	}
+	e.readSecrets(p)
+	e.writeSecrets(p)
+	e.recordAudit(p, audit.EventRotate)
+
+	return nil
+}
+
+func planList(e planEnv, p *plan.Plan) {
+	if e.cfg == nil {
+		return
+	}
+	for _, name := range sortProviderNames(e.cfg.Providers, e.cfg.DefaultProvider) {
+		p.Read(health.HistoryPath(e.stateDir(), name))
+	}
+}
+
+func planStatus(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	if statusHistory != "" {
+		p.Read(health.HistoryPath(e.stateDir(), statusHistory))
+
+		return nil
+	}
+	if err := e.requireConfig(); err != nil {
+		return err
+	}
+	e.readConfig(p)
+	if statusTestWebhook {
+		e.healthWebhook(p, "a sample event is posted to hooks.health_webhook")
+
+		return nil
+	}
+
+	names := args
+	if len(names) == 0 {
+		names = sortProviderNames(e.cfg.Providers, e.cfg.DefaultProvider)
+	}
+	e.readSecrets(p)
+	for _, name := range names {
+		provider, err := e.provider(name)
+		if err != nil {
+			return err
+		}
+		if providers.RequiresAPIKey(name) {
+			p.Secret(harness.APIKeyEnvVar(name))
+			e.notifySecretAccess(p, name)
+		}
+		p.Connect(health.ModelsEndpoint(provider.BaseURL))
+		p.Write(health.HistoryPath(e.stateDir(), name))
+	}
+	e.healthWebhook(p, "providers that went down or came back up since their last check are posted to hooks.health_webhook")
+
+	return nil
+}
+
+func planDoctor(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	if doctorGit {
+		e, err := loadPlanEnv(cmd)
+		if err != nil {
+			return err
+		}
+		planDoctorGit(e, p, true)
+
+		return nil
+	}
+
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	planDoctorGit(e, p, false)
+
+	providerName := e.cfg.DefaultProvider
+	if len(args) > 0 {
+		providerName = args[0]
+	}
+	if _, err := e.provider(providerName); err != nil {
+		return err
+	}
+	if providers.RequiresAPIKey(providerName) {
+		e.readSecrets(p)
+		p.Secret(harness.APIKeyEnvVar(providerName))
+		e.notifySecretAccess(p, providerName)
+	}
+
+	h := harness.Resolve(doctorHarness, e.cfg.DefaultHarness)
+	if h == harness.Claude {
+		if paths, err := claudeSettingsPaths(); err == nil {
+			p.Read(paths...)
+		}
+	}
+	if path, err := e.cliCtx.Deps().Process.LookPath(h); err == nil && path != "" {
+		p.Exec("read the "+h+" version", path, "--version")
+	}
+	if doctorDeep {
+		if exe, err := os.Executable(); err == nil {
+			p.Exec("run a wrapper test with a dummy token", exe, wrapperStubName)
+		}
+	}
+
+	return nil
+}
+
+// planDoctorGit adds the git commands the git checks run, and with offer,
+// the .gitignore files 'doctor --git' may write.
+func planDoctorGit(e planEnv, p *plan.Plan, offer bool) {
+	gitPath, err := e.cliCtx.Deps().Process.LookPath("git")
+	if err != nil || gitPath == "" {
+		return
+	}
+	var roots []string
+	if root, ok := gitguard.Worktree(e.dir); ok {
+		roots = append(roots, root)
+	}
+	if cwd, err := os.Getwd(); err == nil && offer {
+		if root, ok := gitguard.Worktree(cwd); ok && !slices.Contains(roots, root) {
+			roots = append(roots, root)
+		}
+	}
+
+	for _, root := range roots {
+		p.Exec("check which kairo files git ignores", gitPath, "-C", root, "check-ignore", "-z", "--stdin")
+		p.Exec("check which kairo files git tracks", gitPath, "-C", root, "ls-files", "-z")
+		if offer {
+			p.Write(filepath.Join(root, gitguard.IgnoreFileName))
+		}
+	}
+	if offer && len(roots) > 0 {
+		p.Note(gitguard.IgnoreFileName + " is only written after confirmation, or with --yes")
+	}
+}
+
+func planSelfTest(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	dir := filepath.Join(configuredTempDir(CLIContextFromCmd(cmd)), selfTestDirPrefix+"*")
+	p.Write(filepath.Join(dir, constants.KeyFileName), filepath.Join(dir, constants.SecretsFileName))
+	p.Note("the key and secrets are throwaway samples; your own config and secrets are not read")
+	if exe, err := os.Executable(); err == nil {
+		p.Exec("run a wrapper test with a dummy token", exe, wrapperStubName)
+	}
+	p.Remove(dir)
+
+	return nil
+}
+
+func planAuditRead(e planEnv, p *plan.Plan) {
+	p.Read(filepath.Join(e.stateDir(), audit.LogFileName))
+	e.readAuditKey(p)
+}
+
+func planUsage(e planEnv, p *plan.Plan) {
+	p.Read(usage.Path(e.stateDir()))
+}
+
+func planSummary(e planEnv, p *plan.Plan) {
+	if _, enabled, _ := auditPolicy(e.cfg); enabled {
+		planAuditRead(e, p)
+	}
+	planUsage(e, p)
+	if e.cfg != nil {
+		for _, name := range slices.Sorted(maps.Keys(e.cfg.Providers)) {
+			p.Read(health.HistoryPath(e.stateDir(), name))
+		}
+	}
+}
+
+func planAuditPrune(e planEnv, p *plan.Plan) {
+	path := filepath.Join(e.stateDir(), audit.LogFileName)
+	p.Read(path)
+	e.readAuditKey(p)
+	p.Write(path)
+}
+
+func planLock(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+
+	providerName := e.cfg.DefaultProvider
+	if len(args) > 0 {
+		providerName = args[0]
+	}
+	if _, err := e.provider(providerName); err != nil {
+		return err
+	}
+	if providers.RequiresAPIKey(providerName) {
+		e.readSecrets(p)
+		p.Note("only the fingerprint of the stored API key is written to the lock file")
+	}
+	p.Write(lockFilePath)
+
+	return nil
+}
+
+func planSnapshotEnv(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+
+	providerName := e.cfg.DefaultProvider
+	if len(args) > 0 {
+		providerName = args[0]
+	}
+	if _, err := e.provider(providerName); err != nil {
+		return err
+	}
+
+	h := resolveHarness(snapshotHarness, e.cfg.DefaultHarness)
+	if path, err := e.cliCtx.Deps().Process.LookPath(h); err == nil && path != "" {
+		p.Exec("record the "+h+" version in the snapshot", path, "--version")
+	}
+	if snapshotOutput != "" {
+		p.Write(snapshotOutput)
+	}
+	p.Note("credential values are left out of the snapshot; no secrets are decrypted")
+
+	return nil
+}
+
+func planClean(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	if cleanOlderThan < 0 {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "--older-than must not be negative")
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	dirs, err := cliCtx.Deps().Wrapper.CleanStaleAuthDirs(configuredTempDir(cliCtx), cleanOlderThan, true)
+	if err != nil {
+		return err
+	}
+	if !cleanDryRun {
+		p.Remove(dirs...)
+	}
+
+	return nil
+}
+
+func planCompletion(_ *cobra.Command, args []string, p *plan.Plan) error {
+	switch {
+	case completionOutput != "":
+		p.Write(completionOutput)
+	case completionSave && len(args) > 0:
+		p.Write(getDefaultCompletionPath(args[0]))
+	}
+
+	return nil
+}
+
+func planMan(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	for _, page := range manPages(cmd.Root(), manDate()) {
+		p.Write(filepath.Join(manDir, page.name))
+	}
+
+	return nil
+}
+
+func planMockProvider(_ *cobra.Command, _ []string, p *plan.Plan) error {
+	if err := validateMockProviderFlags(); err != nil {
+		return err
+	}
+	p.Connect("http://" + mockListen)
+
+	return nil
+}
+
+func planPromptSegment(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	if cachePath, err := promptCachePath(e.dir); err == nil {
+		p.Read(cachePath)
+		p.Write(cachePath)
+		p.Note("the prompt cache is only rewritten when the config changed since it was built")
+	}
+
+	return nil
+}
+
+func planVerifyRelease(_ *cobra.Command, args []string, p *plan.Plan) error {
+	p.Read(args[0], verifyChecksums, verifySignature)
+	if verifySignature != "" {
+		if _, err := os.Stat(verifyPublicKey); err == nil {
+			p.Read(verifyPublicKey)
+		}
+	}
+
+	return nil
+}
+
+func planVersion(_ *cobra.Command, _ []string, p *plan.Plan) error {
+	if versionCheckEnabled() {
+		p.Connect(latestReleaseURL())
+	}
+
+	return nil
+}
+
+func planUpdate(_ *cobra.Command, _ []string, p *plan.Plan) error {
+	if !versionCheckEnabled() {
+		p.Note("development builds cannot be updated")
+
+		return nil
+	}
+
+	const tag = "<latest>"
+	p.Connect(latestReleaseURL(), update.InstallScriptURL(runtime.GOOS, tag), update.ChecksumsURL(tag))
+	p.Note(tag + " is the release tag the GitHub API reports")
+
+	script := filepath.Join(os.TempDir(), "kairo-install-*.sh")
+	if runtime.GOOS == constants.WindowsGOOS {
+		script = filepath.Join(os.TempDir(), "kairo-install-*.ps1")
+		p.Write(script)
+		p.Exec("run the verified install script", "powershell", "-ExecutionPolicy", "Bypass", "-File", script)
+	} else {
+		p.Write(script)
+		p.Exec("run the verified install script", "sh", script)
+	}
+	p.Remove(script)
+	p.Connect(update.ChecksumsBundleURL(tag))
+	p.Exec("verify the checksums file when cosign is installed", "cosign", "verify-blob")
+
+	return nil
+}
+
+func planProvidersList(_ *cobra.Command, _ []string, p *plan.Plan) error {
+	if path, err := providerCatalogCachePath(); err == nil {
+		p.Read(path)
+	}
+
+	return nil
+}
+
+func planProvidersRefresh(_ *cobra.Command, _ []string, p *plan.Plan) error {
+	artifactURL, bundleURL, checksumURL := catalogURLs()
+	p.Connect(artifactURL, bundleURL, checksumURL)
+	p.Exec("verify the catalog when cosign is installed", "cosign", "verify-blob")
+	if path, err := providerCatalogCachePath(); err == nil {
+		p.Write(path)
+	}
+
+	return nil
+}
+
+func planProvidersAdd(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	if providersAddFile != "-" {
+		p.Read(providersAddFile)
+	}
+	e.readConfig(p)
+	e.writeConfig(p)
+
+	return nil
+}
+
+func planProvidersImport(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 && args[0] != "-" {
+		p.Read(args[0])
+		if data, err := os.ReadFile(args[0]); err == nil && isProviderBundle(data) {
+			p.Read(cmp.Or(providersImportSignature, args[0]+".minisig"))
+			p.Read(e.trustedSignersPath())
+			p.Note("the bundle is imported only if its signature verifies against a trusted signer")
+		}
+	}
+	e.readConfig(p)
+	e.writeConfig(p)
+	e.recordAudit(p, audit.EventConfig)
+	p.Note("the snippet holds no API key; no secrets are read or written")
+
+	return nil
+}
+
+func planProvidersTrust(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	p.Read(e.trustedSignersPath())
+	if cmd == providersTrustCmd && len(args) == 0 {
+		return nil
+	}
+	p.Write(e.trustedSignersPath())
+	e.recordAudit(p, audit.EventConfig)
+
+	return nil
+}
+
+// versionCheckEnabled reports whether this build looks up the latest release.
+func versionCheckEnabled() bool {
+	return version.Version != "dev"
+}
+
+// latestReleaseURL is the endpoint update.Client.LatestReleaseURL queries.
+func latestReleaseURL() string {
+	return cmp.Or(os.Getenv("KAIRO_UPDATE_URL"), constants.GitHubAPIReleasesLatest)
+}
+
+func planProvidersShow(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if _, err := e.provider(args[0]); err != nil {
+		return err
+	}
+	e.readConfig(p)
+	if providers.RequiresAPIKey(args[0]) {
+		e.readSecrets(p)
+		p.Secret(harness.APIKeyEnvVar(args[0]))
+		p.Note("only the key's fingerprint is printed; a key stored as a command is not fetched")
+	}
+	p.Read(filepath.Join(e.stateDir(), audit.LogFileName), health.HistoryPath(e.stateDir(), args[0]))
+	e.readAuditKey(p)
+
+	return nil
+}
+
+func planSpawn(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+	promptPath, err := spawnPromptPath(args)
+	if err != nil {
+		return err
+	}
+	panes, err := spawnPanes(e.cfg, e.dir, promptPath, runtime.GOOS == constants.WindowsGOOS)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+
+	tmux, err := e.cliCtx.Deps().Process.LookPath("tmux")
+	if err != nil || runtime.GOOS == constants.WindowsGOOS {
+		p.Note("tmux is not available; the command for each provider is printed instead")
+
+		return nil
+	}
+	for _, pane := range panes {
+		p.Exec("open a tmux pane that switches to "+pane.provider, tmux, pane.command)
+	}
+	if os.Getenv("TMUX") == "" {
+		p.Exec("attach to the new session", tmux, "attach-session", "-t", "="+spawnSession)
+	}
+	p.Note("each pane reads secrets and records its switch as 'kairo <provider>' does")
+	if promptPath != "" {
+		p.Note("each pane's shell reads the prompt file when the pane starts")
+	}
+
+	return nil
+}
+
+func planCompare(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if len(compareProviders) == 0 {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "--providers is required")
+	}
+	e.readConfig(p)
+	if comparePromptFile != "" && comparePromptFile != "-" {
+		p.Read(comparePromptFile)
+	}
+
+	e.readSecrets(p)
+	for _, name := range compareProviders {
+		provider, err := e.provider(name)
+		if err != nil {
+			return err
+		}
+		if providers.RequiresAPIKey(name) {
+			p.Secret(harness.APIKeyEnvVar(name))
+			e.notifySecretAccess(p, name)
+		}
+		p.Connect(compare.MessagesEndpoint(provider.BaseURL))
+	}
+	p.Note("every provider is sent the prompt at once, which uses tokens on each")
+
+	return nil
+}
+
+func planSyncExport(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	p.Read(syncStatePath(e.dir), filepath.Join(syncDir, configsync.ManifestName))
+	written := []string{filepath.Join(syncDir, config.BaseFileName)}
+	withSecrets, err := syncsSecrets(e.cliCtx, e.dir, e.cfg)
+	if err != nil {
+		return err
+	}
+	if withSecrets {
+		p.Read(e.secretsPath())
+		written = append(written, filepath.Join(syncDir, constants.SecretsFileName))
+		p.Note(constants.SecretsFileName + " is copied as stored, without decrypting it")
+	} else {
+		p.Note(constants.SecretsFileName + " is only encrypted to this machine's " + constants.KeyFileName + " and is not exported")
+	}
+	p.Write(append(written, filepath.Join(syncDir, configsync.ManifestName), syncStatePath(e.dir))...)
+
+	return nil
+}
+
+func planSyncImport(cmd *cobra.Command, _ []string, p *plan.Plan) error {
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+	manifestPath := filepath.Join(syncDir, configsync.ManifestName)
+	m, err := configsync.ReadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	p.Read(manifestPath, syncStatePath(e.dir))
+	names := slices.Sorted(maps.Keys(m))
+	for _, name := range names {
+		p.Read(filepath.Join(syncDir, name), filepath.Join(e.dir, name))
+	}
+	e.journal(p, "sync import")
+	for _, name := range names {
+		p.Write(filepath.Join(e.dir, name))
+	}
+	p.Write(syncStatePath(e.dir))
+	p.Note("only files that changed in " + syncDir + " since the last sync are replaced")
+
+	return nil
+}
@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/claudesettings"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/envcheck"
+	"github.com/dkmnx/kairo/internal/envsnapshot"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/harnessver"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/plan"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/usage"
+	"github.com/dkmnx/kairo/internal/warnlimit"
+	"github.com/dkmnx/kairo/internal/wrapper"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	registerPlanner(rootCmd, planSwitch)
+	registerPlanner(runCmd, planRunFromSnapshot)
+}
+
+// planSwitch plans `kairo [provider]`, resolving the launch as
+// OrchestrateExecution does.
+func planSwitch(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+
+	t, err := resolveSwitchLaunch(cmd, e.cliCtx, e.cfg, args)
+	if err != nil {
+		return err
+	}
+	planSwitchEvents(p)
+
+	planLaunch(cmd, e, p, t)
+
+	return nil
+}
+
+// planSwitchEvents records where --events-fd writes the switch's lifecycle
+// events.
+func planSwitchEvents(p *plan.Plan) {
+	if eventsFDFlag == "" {
+		return
+	}
+	if _, err := strconv.Atoi(eventsFDFlag); err == nil {
+		p.Note("lifecycle events are written as JSON lines to file descriptor " + eventsFDFlag)
+
+		return
+	}
+	p.Write(eventsFDFlag)
+}
+
+// planRunFromSnapshot plans `kairo run --from-snapshot`, resolving the
+// launch as runFromSnapshotFile does.
+func planRunFromSnapshot(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	if runLocked {
+		return planRunLocked(cmd, args, p)
+	}
+	if runFromSnapshot == "" {
+		return planRunCommand(cmd, args, p)
+	}
+	e, err := loadPlanEnv(cmd)
+	if err != nil {
+		return err
+	}
+
+	p.Read(runFromSnapshot)
+	f, err := os.Open(runFromSnapshot)
+	if err != nil {
+		return kairoerrors.FileError("failed to open snapshot file", runFromSnapshot, err)
+	}
+	snap, err := envsnapshot.Read(f)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+
+	e.readConfig(p)
+	if e.cfg == nil {
+		e.cfg = &config.Config{Providers: map[string]config.Provider{}}
+	}
+	t, _ := resolveSnapshotLaunch(e.cfg, snap, args)
+	planLaunch(cmd, e, p, t)
+
+	return nil
+}
+
+// planRunLocked plans `kairo run --locked`, resolving the launch as
+// runLockedProvider does.
+// A configuration that drifted from the lock stops the plan as it would
+// stop the run.
+func planRunLocked(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	p.Read(lockFilePath)
+	lock, err := readLock(lockFilePath)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	t, err := resolveLockedLaunch(e.cfg, lock, args)
+	if err != nil {
+		return err
+	}
+	p.Note("the fingerprint of the stored API key is compared with the lock, and a change is only warned about")
+
+	planLaunch(cmd, e, p, t)
+
+	return nil
+}
+
+// planRunCommand plans `kairo run -- <command>`, mirroring
+// runCommandWithProvider.
+func planRunCommand(cmd *cobra.Command, args []string, p *plan.Plan) error {
+	e, err := loadPlanConfig(cmd)
+	if err != nil {
+		return err
+	}
+	e.readConfig(p)
+	providerName, provider, err := runCommandProvider(e.cfg, args)
+	if err != nil {
+		return err
+	}
+	cfg := ExecutionConfig{
+		Cmd:           cmd,
+		HarnessToUse:  harness.Claude,
+		HarnessBinary: args[0],
+		Provider:      provider,
+		ProviderName:  providerName,
+		Deps:          e.cliCtx.Deps(),
+		TempDir:       configuredTempDir(e.cliCtx),
+	}
+
+	_, statErr := os.Stat(e.secretsPath())
+	hasKey := statErr == nil
+	e.readSecrets(p)
+	p.Secret(harness.APIKeyEnvVar(providerName))
+	if hasKey {
+		cfg.APIKey = snapshotKeyPlaceholder
+	}
+	p.SetEnv(slices.Sorted(maps.Keys(injectedEnv(cfg)))...)
+	if len(provider.RunEnvAllow) > 0 {
+		p.Note("run_env_allow withholds every variable of kairo's environment except " +
+			strings.Join(slices.Concat(provider.RunEnvAllow, runEnvAlwaysAllowed), ", "))
+	}
+	if provider.BaseURL != "" && !provider.AllowInsecure {
+		p.Note("the base URL's host is looked up in DNS; an answer with a private address is warned about " +
+			"and recorded as a warning audit event")
+	}
+	e.recordAudit(p, audit.EventSwitch)
+
+	commandPath := args[0]
+	if path, err := cfg.Deps.Process.LookPath(args[0]); err == nil && path != "" {
+		commandPath = path
+	} else {
+		p.Note(args[0] + " was not found on PATH; kairo stops without running it")
+	}
+	if !hasKey {
+		p.Exec("run the command", commandPath, args[1:]...)
+
+		return nil
+	}
+	e.notifySecretAccess(p, providerName)
+	authDir := filepath.Join(cfg.tempDir(), wrapper.AuthDirPrefix+"*")
+	p.Write(filepath.Join(authDir, "token-*"))
+	planWrapperStart(p, authDir)
+	p.Exec("run the command from the wrapper", commandPath, args[1:]...)
+	p.Remove(authDir)
+
+	return nil
+}
+
+// planLaunch records what starting t does: the
+// secrets decrypted, the preflight checks, the audit entry, and the harness
+// itself, run through the wrapper when there is an API key to hand
+// over.
+func planLaunch(cmd *cobra.Command, e planEnv, p *plan.Plan, t launchTarget) {
+	provider, providerName, harnessToUse, harnessArgs := t.Provider, t.ProviderName, t.Harness, t.HarnessArgs
+	cfg := ExecutionConfig{
+		Cmd:           cmd,
+		HarnessToUse:  harnessToUse,
+		HarnessBinary: harnessToUse,
+		Provider:      provider,
+		ProviderName:  providerName,
+		HarnessArgs:   harnessArgs,
+		Yolo:          skipPermissionsFlag,
+		Deps:          e.cliCtx.Deps(),
+		TempDir:       configuredTempDir(e.cliCtx),
+	}
+
+	// Whether a key is stored cannot be known without decrypting, so the plan
+	// assumes one is whenever there is a secrets file to hold it.
+	_, statErr := os.Stat(e.secretsPath())
+	hasKey := statErr == nil
+	e.readSecrets(p)
+
+	keyProviders := []string{providerName}
+	if harnessToUse == harness.Pi {
+		if names, err := piKeyProviders(e.cfg); err == nil {
+			keyProviders = names
+		}
+	}
+	for _, name := range keyProviders {
+		p.Secret(harness.APIKeyEnvVar(name))
+	}
+	if harnessToUse != harness.Pi && providerName != customProviderName {
+		p.Secret(harness.APIKeyEnvVar(customProviderName))
+		p.Note(harness.APIKeyEnvVar(customProviderName) + " is used when " +
+			harness.APIKeyEnvVar(providerName) + " is not stored")
+	}
+	if hasKey {
+		cfg.APIKey = snapshotKeyPlaceholder
+		shell, flag := hookShell()
+		p.Note("a key stored with 'kairo secrets set --command' is fetched by running its command with " +
+			shell + " " + flag)
+	} else if name, _, ok := envKeyFor(providerName, provider); ok && providers.RequiresAPIKey(providerName) {
+		if adoptEnvFlag {
+			p.Note("no key is stored; " + name + " from the environment is stored in " + constants.SecretsFileName)
+			e.ensureKey(p)
+			e.writeSecrets(p)
+			e.recordAudit(p, audit.EventRotate)
+			cfg.APIKey = snapshotKeyPlaceholder
+		} else {
+			p.Note("no key is stored; kairo offers to store " + name + " from the environment in " +
+				constants.SecretsFileName + " (--adopt-env stores it without asking)")
+		}
+	}
+
+	p.SetEnv(slices.Sorted(maps.Keys(injectedEnv(cfg)))...)
+	if harnessToUse == harness.Pi && hasKey {
+		for _, name := range keyProviders {
+			p.SetEnv(piKeyEnvVar(name, e.cfg.Providers[name]))
+		}
+	}
+
+	planPreflight(p, cfg)
+	if explainEnvFlag {
+		p.Note("--explain-env prints the environment and exits before the harness starts")
+
+		return
+	}
+
+	harnessPath := harnessToUse
+	if path, err := cfg.Deps.Process.LookPath(harnessToUse); err == nil && path != "" {
+		harnessPath = path
+		p.Exec("record the "+harnessToUse+" version for the audit log", path, "--version")
+	} else {
+		p.Note(harnessToUse + " was not found on PATH")
+	}
+	p.Write(filepath.Join(e.stateDir(), harnessver.StateFileName))
+	e.recordAudit(p, audit.EventSwitch)
+
+	if hasKey {
+		for _, name := range keyProviders {
+			e.notifySecretAccess(p, name)
+		}
+	}
+	if note := usageCaptureNote(e.cliCtx, harnessToUse); note != "" {
+		for _, kv := range usage.ClaudeTelemetryEnv("") {
+			name, _, _ := strings.Cut(kv, "=")
+			p.SetEnv(name)
+		}
+		p.Write(usage.Path(e.stateDir()))
+		p.Note(note)
+	}
+
+	switch {
+	case harnessToUse == harness.Pi:
+		cliArgs := append([]string{"--provider", providerName, "--model", provider.Model},
+			applyYoloFlag(cfg, harnessArgs)...)
+		p.Exec("run "+harnessToUse, harnessPath, cliArgs...)
+	case hasKey:
+		planWrapper(p, cfg, harnessPath)
+	case harnessToUse == harness.Qwen:
+		p.Note("Qwen Code needs an API key; kairo stops without one")
+	default:
+		p.Exec("run "+harnessToUse, harnessPath, applyYoloFlag(cfg, harnessArgs)...)
+	}
+}
+
+// planPreflight records the checks runPreflight makes before the harness
+// starts.
+func planPreflight(p *plan.Plan, cfg ExecutionConfig) {
+	if home, err := os.UserHomeDir(); err == nil {
+		p.Read(envcheck.RCFiles(home)...)
+	}
+	if cfg.HarnessToUse == harness.Claude {
+		if paths, err := claudeSettingsPaths(); err == nil {
+			p.Read(paths...)
+		}
+	}
+	if explainEnvFlag {
+		return
+	}
+	p.Note("a variable competing with one kairo sets is warned about at most once per warnings.interval, " +
+		"recorded in " + warnlimit.StateFileName + " in the state directory")
+	if cfg.Provider.BaseURL != "" && !cfg.Provider.AllowInsecure {
+		p.Note("the base URL's host is looked up in DNS; an answer with a private address is warned about " +
+			"and recorded as a warning audit event")
+	}
+	if waitHealthyFlag > 0 {
+		p.Connect(health.ModelsEndpoint(cfg.Provider.BaseURL))
+	}
+}
+
+// planWrapper records the auth directory and wrapper that hand the API key to
+// the harness without putting it on a command line.
+func planWrapper(p *plan.Plan, cfg ExecutionConfig, harnessPath string) {
+	authDir := filepath.Join(cfg.tempDir(), wrapper.AuthDirPrefix+"*")
+	p.Note("stale " + wrapper.AuthDirPrefix + "* directories left by killed kairo processes are removed")
+
+	p.Write(filepath.Join(authDir, "token-*"))
+
+	for _, sf := range cfg.Provider.SettingsFiles {
+		if sf.Harness != "" && sf.Harness != cfg.HarnessToUse {
+			continue
+		}
+		p.Write(filepath.Join(authDir, sf.Name))
+		p.SetEnv(sf.Env)
+	}
+
+	setup, err := harnessLaunch(cfg)
+	if err != nil {
+		p.Note(err.Error())
+
+		return
+	}
+	if setup.QwenSettings != "" {
+		p.Write(filepath.Join(authDir, qwenSettingsFileName))
+		p.SetEnv(harness.QwenSettingsEnv)
+	}
+	p.SetEnv(slices.Sorted(maps.Keys(claudesettings.EnvMap(setup.Env)))...)
+	p.SetEnv(authEnvVarName(cfg))
+
+	planWrapperStart(p, authDir)
+	if cfg.Provider.WrapperPing {
+		p.Connect(health.ModelsEndpoint(cfg.Provider.BaseURL))
+	}
+	p.Exec("run "+cfg.HarnessToUse+" from the wrapper", harnessPath, setup.Args...)
+	p.Remove(authDir)
+}
+
+// planWrapperStart records starting the wrapper that reads the token file in
+// authDir: PowerShell running a generated script on Windows, and kairo's
+// hidden exec command everywhere else.
+func planWrapperStart(p *plan.Plan, authDir string) {
+	if runtime.GOOS == constants.WindowsGOOS {
+		wrapperScript := filepath.Join(authDir, "wrapper-*.ps1")
+		p.Write(wrapperScript)
+		p.Exec("run the wrapper script", "powershell", "-NoProfile", "-ExecutionPolicy", "Bypass", "-File", wrapperScript)
+
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "kairo"
+	}
+	p.Exec("hand the API key to the harness in its environment", exe, wrapper.ExecCommand)
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/plan"
+	"github.com/spf13/cobra"
+)
+
+// explainTestCmd returns a command whose CLIContext uses dir and test deps
+// that find every harness at /usr/bin/<name>.
+func explainTestCmd(t *testing.T, dir string) (*cobra.Command, *bytes.Buffer) {
+	t.Helper()
+
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(dir)
+	cliCtx.SetDeps(testDeps(func(mp *mockProcess, _ *mockWrapper, _ *mockUpdate) {
+		mp.LookPathFn = func(name string) (string, error) { return "/usr/bin/" + name, nil }
+	}))
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{Use: "kairo"}
+	cmd.SetOut(&out)
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	return cmd, &out
+}
+
+func writeExplainConfig(t *testing.T, dir, yaml string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlanSwitch(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, `default_provider: zai
+providers:
+  zai:
+    name: Z.AI
+    base_url: https://api.z.ai/api/anthropic
+    model: glm-4.7
+    env_vars:
+      - API_TIMEOUT_MS=3000000
+hooks:
+  secret_access: notify-send kairo
+`)
+	for _, name := range []string{constants.SecretsFileName, constants.KeyFileName} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("not a real key"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd, _ := explainTestCmd(t, dir)
+	p := plan.New("kairo", []string{"zai"})
+	if err := planSwitch(cmd, []string{"zai", "--", "-p", "hi"}, p); err != nil {
+		t.Fatalf("planSwitch() error = %v", err)
+	}
+
+	for _, want := range []string{filepath.Join(dir, "config.yaml"), filepath.Join(dir, constants.SecretsFileName)} {
+		if !slices.Contains(p.FilesRead, want) {
+			t.Errorf("FilesRead = %v, want %s", p.FilesRead, want)
+		}
+	}
+	if !slices.Contains(p.Secrets, "ZAI_API_KEY") {
+		t.Errorf("Secrets = %v, want ZAI_API_KEY", p.Secrets)
+	}
+	for _, want := range []string{"ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_BASE_URL", "API_TIMEOUT_MS", "KAIRO_EVENT"} {
+		if !slices.Contains(p.EnvSet, want) {
+			t.Errorf("EnvSet = %v, want %s", p.EnvSet, want)
+		}
+	}
+	if len(p.FilesRemoved) != 1 || !strings.Contains(p.FilesRemoved[0], "kairo-auth-") {
+		t.Errorf("FilesRemoved = %v, want the auth directory", p.FilesRemoved)
+	}
+
+	var paths []string
+	for _, proc := range p.Processes {
+		paths = append(paths, proc.Path)
+	}
+	last := p.Processes[len(p.Processes)-1]
+	if last.Path != "/usr/bin/claude" || !slices.Equal(last.Args, []string{"-p", "hi"}) {
+		t.Errorf("last process = %+v, want claude with the harness args", last)
+	}
+	if shell, _ := hookShell(); !slices.Contains(paths, shell) {
+		t.Errorf("processes = %v, want the secret_access hook", paths)
+	}
+	if len(p.Network) != 0 {
+		t.Errorf("Network = %v, want none without --wait-healthy", p.Network)
+	}
+}
+
+func TestPlanSwitch_Resume(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, `default_provider: zai
+providers:
+  zai:
+    name: Z.AI
+    base_url: https://api.z.ai/api/anthropic
+    model: glm-4.7
+`)
+	t.Cleanup(func() { resumeFlag, harnessFlag = "", "" })
+
+	resumeFlag = "3f2a9c"
+	cmd, _ := explainTestCmd(t, dir)
+	p := plan.New("kairo", nil)
+	if err := planSwitch(cmd, []string{"zai", "--", "-p", "hi"}, p); err != nil {
+		t.Fatalf("planSwitch() error = %v", err)
+	}
+	last := p.Processes[len(p.Processes)-1]
+	if last.Path != "/usr/bin/claude" || !slices.Equal(last.Args, []string{"--resume", "3f2a9c", "-p", "hi"}) {
+		t.Errorf("last process = %+v, want claude's resume flag before the harness args", last)
+	}
+
+	harnessFlag = "crush"
+	err := planSwitch(cmd, []string{"zai"}, plan.New("kairo", nil))
+	if err == nil || !strings.Contains(err.Error(), "crush cannot resume a session") {
+		t.Errorf("planSwitch() error = %v, want crush to be refused", err)
+	}
+}
+
+func TestPlanSwitch_UnknownProvider(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, `default_provider: zai
+providers:
+  zai:
+    name: Z.AI
+    base_url: https://api.z.ai/api/anthropic
+    model: glm-4.7
+`)
+
+	cmd, _ := explainTestCmd(t, dir)
+	err := planSwitch(cmd, []string{"nope"}, plan.New("kairo", nil))
+	if err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("planSwitch() error = %v, want provider not configured", err)
+	}
+}
+
+func TestPlanSwitch_DisabledProvider(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, `default_provider: zai
+providers:
+  zai:
+    name: Z.AI
+    base_url: https://api.z.ai/api/anthropic
+    model: glm-4.7
+  minimax:
+    name: MiniMax
+    base_url: https://api.minimax.io/anthropic
+    model: MiniMax-M2
+    disabled: true
+`)
+
+	cmd, _ := explainTestCmd(t, dir)
+	err := planSwitch(cmd, []string{"minimax"}, plan.New("kairo", nil))
+	if err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("planSwitch() error = %v, want the disabled provider to be refused as the switch refuses it", err)
+	}
+}
+
+func TestWritePlan_Unsupported(t *testing.T) {
+	cmd, out := explainTestCmd(t, t.TempDir())
+
+	err := writePlan(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "--explain is not supported") {
+		t.Errorf("writePlan() error = %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("output = %q, want nothing", out.String())
+	}
+}
+
+func TestWrapForExplain(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, "providers: {}\n")
+
+	ran := false
+	root := &cobra.Command{Use: "kairo"}
+	child := &cobra.Command{Use: "child", Run: func(*cobra.Command, []string) { ran = true }}
+	root.AddCommand(child)
+	registerPlanner(child, func(_ *cobra.Command, _ []string, p *plan.Plan) error {
+		p.Write("/tmp/out")
+
+		return nil
+	})
+	defer delete(planners, child)
+	wrapForExplain(root)
+
+	cmd, out := explainTestCmd(t, dir)
+	child.SetOut(out)
+	child.SetContext(cmd.Context())
+
+	defer func() { explainFlag = false }()
+	explainFlag = true
+	child.Run(child, []string{"arg"})
+
+	if ran {
+		t.Error("command ran with --explain")
+	}
+	var got plan.Plan
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a plan: %v\n%s", err, out.String())
+	}
+	if got.Command != "kairo child" || !slices.Equal(got.Args, []string{"arg"}) ||
+		!slices.Equal(got.FilesWritten, []string{"/tmp/out"}) {
+		t.Errorf("plan = %+v", got)
+	}
+
+	explainFlag = false
+	child.Run(child, nil)
+	if !ran {
+		t.Error("command did not run without --explain")
+	}
+}
+
+func TestEveryCommandHasPlanner(t *testing.T) {
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		if c.Runnable() && !c.Hidden {
+			if _, ok := planners[c]; !ok {
+				t.Errorf("%s has no --explain planner", c.CommandPath())
+			}
+		}
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(rootCmd)
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/dkmnx/kairo/internal/audit"
 	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
 	"github.com/dkmnx/kairo/internal/harness"
 	"github.com/dkmnx/kairo/internal/ui"
 	"github.com/spf13/cobra"
@@ -20,19 +22,27 @@ var harnessGetCmd = &cobra.Command{
 	Long:  "Get the currently configured default harness",
 	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := loadConfigOrExit(cmd)
-		if err != nil || cfg == nil {
-			return
-		}
+		showDefaultHarness(cmd)
+	},
+}
 
-		if cfg.DefaultHarness == "" {
-			ui.PrintInfo("No default harness configured (using claude)")
+// showDefaultHarness prints the configured default harness.
+func showDefaultHarness(cmd *cobra.Command) {
+	cfg, err := loadConfigOrExit(cmd)
+	if err != nil || cfg == nil {
+		return
+	}
 
-			return
+	if cfg.DefaultHarness == "" {
+		ui.PrintInfo("No default harness configured (using claude)")
+		if ui.Quiet() {
+			ui.PrintValue(cmd.OutOrStdout(), "Default harness", harness.Claude)
 		}
 
-		ui.PrintInfo(fmt.Sprintf("Default harness: %s", cfg.DefaultHarness))
-	},
+		return
+	}
+
+	ui.PrintValue(cmd.OutOrStdout(), "Default harness", cfg.DefaultHarness)
 }
 
 var harnessSetCmd = &cobra.Command{
@@ -41,49 +51,71 @@ var harnessSetCmd = &cobra.Command{
 	Long:  "Set the default CLI harness to use (claude, qwen, pi, or crush)",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		harnessName := strings.ToLower(args[0])
+		setDefaultHarness(cmd, args[0])
+	},
+}
 
-		if !isValidHarness(harnessName) {
-			ui.PrintError(fmt.Sprintf("Invalid harness: '%s'", args[0]))
-			ui.PrintInfo("Valid harnesses: claude, qwen, pi, crush")
+// setDefaultHarness validates name and stores it as the default harness.
+func setDefaultHarness(cmd *cobra.Command, name string) {
+	harnessName := strings.ToLower(name)
 
-			return
-		}
+	if !isValidHarness(harnessName) {
+		printError(kairoerrors.NewError(kairoerrors.ValidationError, fmt.Sprintf("invalid harness: '%s'", name)).
+			WithContext("hint", "use one of: claude, qwen, pi, crush"))
 
-		dir := requireConfigDirWritable(cmd)
-		if dir == "" {
-			return
-		}
+		return
+	}
 
-		cliCtx := CLIContextFromCmd(cmd)
+	dir := requireConfigDirWritable(cmd)
+	if dir == "" {
+		return
+	}
 
-		cfg, err := loadConfigOrEmpty(cmd)
-		if err != nil {
-			ui.PrintError(fmt.Sprintf("Error loading config: %v", err))
+	cliCtx := CLIContextFromCmd(cmd)
 
-			return
-		}
-		if cfg == nil {
-			return
-		}
+	cfg, err := loadConfigOrEmpty(cmd)
+	if err != nil {
+		printError(kairoerrors.WrapError(kairoerrors.ConfigError, "error loading config", err))
 
-		cfg.DefaultHarness = harnessName
-		if err := config.SaveConfig(cliCtx.RootCtx(), dir, cfg); err != nil {
-			ui.PrintError(fmt.Sprintf("Error saving config: %v", err))
+		return
+	}
+	if cfg == nil {
+		return
+	}
 
-			return
-		}
+	previous := harness.Resolve("", cfg.DefaultHarness)
+	cfg.DefaultHarness = harnessName
+	if err := config.SaveConfig(cliCtx.RootCtx(), dir, cfg); err != nil {
+		printError(kairoerrors.WrapError(kairoerrors.ConfigError, "error saving config", err))
 
-		cliCtx.InvalidateCache(dir)
+		return
+	}
 
-		ui.PrintSuccess(fmt.Sprintf("Default harness set to: %s", harnessName))
-	},
+	cliCtx.InvalidateCache(dir)
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:   audit.EventConfig,
+		Action:  "set_harness",
+		Details: map[string]string{"harness": harnessName, "previous": previous},
+	})
+
+	ui.PrintSuccess(fmt.Sprintf("Default harness set to: %s", harnessName))
 }
 
 var harnessCmd = &cobra.Command{
-	Use:   "harness",
-	Short: "Manage CLI harness",
-	Long:  "Manage the CLI harness (claude, qwen, pi, or crush)",
+	Use:   "harness [harness]",
+	Short: "Get or set the default CLI harness",
+	Long: "Manage the CLI harness (claude, qwen, pi, or crush). With no arguments, shows the " +
+		"current default harness. With a harness name, sets it as the default " +
+		"(shorthand for 'kairo harness set <harness>').",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			showDefaultHarness(cmd)
+
+			return
+		}
+		setDefaultHarness(cmd, args[0])
+	},
 }
 
 func init() {
@@ -289,3 +289,36 @@ func TestGetHarnessWithExistingConfig(t *testing.T) {
 		t.Errorf("resolveHarness() = %q, want %q", result, "qwen")
 	}
 }
+
+func TestHarnessShorthandSetsDefault(t *testing.T) {
+	originalConfigDir := testCLI.ConfigDir()
+	defer func() { testCLI.SetConfigDir(originalConfigDir) }()
+
+	tmpDir := t.TempDir()
+	testCLI.SetConfigDir(tmpDir)
+
+	rootCmd.SetArgs([]string{"--config", tmpDir, "harness", "qwen"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	cfg, err := config.LoadConfig(context.Background(), tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.DefaultHarness != "qwen" {
+		t.Errorf("DefaultHarness = %q, want %q", cfg.DefaultHarness, "qwen")
+	}
+
+	rootCmd.SetArgs([]string{"--config", tmpDir, "harness", "nope"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	cfg, err = config.LoadConfig(context.Background(), tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.DefaultHarness != "qwen" {
+		t.Errorf("invalid harness changed DefaultHarness to %q", cfg.DefaultHarness)
+	}
+}
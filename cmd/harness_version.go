@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/harnessver"
+)
+
+// harnessVersionTimeout bounds `<harness> --version`, which some harnesses
+// implement by booting a full Node.js runtime.
+const harnessVersionTimeout = 5 * time.Second
+
+// harnessBinaryVersion runs the binary at path with --version and returns the
+// parsed version, or "" if it cannot be determined.
+func harnessBinaryVersion(ctx context.Context, deps *Deps, path string) string {
+	ctx, cancel := context.WithTimeout(ctx, harnessVersionTimeout)
+	defer cancel()
+
+	c := deps.Process.ExecCommandContext(ctx, path, "--version")
+	if c == nil {
+		return ""
+	}
+	out, err := c.Output()
+	if err != nil {
+		return ""
+	}
+
+	return harnessver.ParseVersion(string(out))
+}
+
+// observeHarnessUpdate records the harness binary used for this switch. When
+// its version differs from the one seen last time, a notice is queued on cfg.
+// The returned details are added to the switch audit entry.
+func observeHarnessUpdate(cfg *ExecutionConfig, configDir string) map[string]string {
+	if cfg.Deps == nil || configDir == "" {
+		return nil
+	}
+
+	path, err := cfg.Deps.Process.LookPath(cfg.HarnessBinary)
+	if err != nil || path == "" {
+		return nil
+	}
+	cur, err := harnessver.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	ctx := commandContext(cfg.Cmd)
+
+	change, err := harnessver.Observe(stateDir(CLIContextFromCmd(cfg.Cmd), configDir), cfg.HarnessToUse, cur, func() string {
+		return harnessBinaryVersion(ctx, cfg.Deps, path)
+	})
+	if err != nil {
+		return nil
+	}
+
+	details := map[string]string{}
+	if change.Current.Version != "" {
+		details["harness_version"] = change.Current.Version
+	}
+	if change.Updated {
+		details["harness_previous_version"] = change.Previous.Version
+		cfg.Notices = append(cfg.Notices, fmt.Sprintf("%s updated from %s to %s",
+			cfg.HarnessToUse, change.Previous.Version, change.Current.Version))
+	}
+
+	return details
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestObserveHarnessUpdate(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(bin, []byte("one"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	version := "1.0.0"
+	d := testDeps(func(mp *mockProcess, _ *mockWrapper, _ *mockUpdate) {
+		mp.LookPathFn = func(string) (string, error) { return bin, nil }
+		mp.ExecCommandContextFn = func(ctx context.Context, _ string, _ ...string) *exec.Cmd {
+			return exec.CommandContext(ctx, "echo", version)
+		}
+	})
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(d)
+	cmd := &cobra.Command{}
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	cfg := &ExecutionConfig{Cmd: cmd, HarnessToUse: "claude", HarnessBinary: "claude", Deps: d}
+	details := observeHarnessUpdate(cfg, dir)
+	if details["harness_version"] != "1.0.0" || len(cfg.Notices) != 0 {
+		t.Fatalf("first switch: details=%v notices=%v", details, cfg.Notices)
+	}
+
+	version = "1.1.0"
+	if err := os.WriteFile(bin, []byte("two!"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(bin, time.Now(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg = &ExecutionConfig{Cmd: cmd, HarnessToUse: "claude", HarnessBinary: "claude", Deps: d}
+	details = observeHarnessUpdate(cfg, dir)
+	if details["harness_previous_version"] != "1.0.0" || details["harness_version"] != "1.1.0" {
+		t.Errorf("upgrade details = %v", details)
+	}
+	if len(cfg.Notices) != 1 || !strings.Contains(cfg.Notices[0], "claude updated from 1.0.0 to 1.1.0") {
+		t.Errorf("notices = %v", cfg.Notices)
+	}
+}
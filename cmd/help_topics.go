@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// helpTopic is a long-form help page shown by `kairo help <topic>` and
+// rendered as a section 7 man page by `kairo man`.
+type helpTopic struct {
+	Name     string
+	Short    string
+	Sections []helpSection
+	// SeeAlso lists related commands ("secrets set") and topics.
+	SeeAlso []string
+}
+
+// helpSection is one titled part of a help topic. Body paragraphs are
+// separated by blank lines; lines indented by two spaces are shown verbatim.
+type helpSection struct {
+	Title string
+	Body  string
+}
+
+var helpTopics = []helpTopic{
+	{
+		Name:  "security-model",
+		Short: "How kairo stores API keys and hands them to harnesses",
+		Sections: []helpSection{
+			{"Storage", `API keys are kept in secrets.age in the config directory, encrypted
+with the age X25519 key in age.key. Both files are created with mode 0600
+inside a 0700 directory. On Windows age.key instead gets an access list
+granting only your account, SYSTEM, and Administrators, and on every
+platform kairo refuses a key file that other accounts can read. With a crypto section in config.yaml the secrets
+file is instead envelope-encrypted with a fresh data key that AWS KMS or
+Google Cloud KMS wraps, and no local key file is used.
+
+Anyone who can read age.key as your user can decrypt secrets.age unless
+age.key is itself encrypted with 'age --passphrase'. Such a key is unlocked
+once per session by 'kairo agent start', which holds it in locked memory
+until its --ttl elapses and decrypts for other commands over a socket only
+your user can open.`},
+			{"At run time", `Secrets are decrypted in memory only. When switching to a provider, kairo
+writes the key to a 0600 token file in a private 0700 temporary directory
+and starts the harness through a wrapper that reads the file, deletes it,
+and sets the key in the harness's environment: on Unix a second kairo
+process that then execs the harness, so no shell ever parses the command,
+and on Windows a short PowerShell script. The key never appears on a
+command line and is never set in kairo's own environment.
+
+The harness process does hold the key in its environment, so other
+processes running as the same user (and root) can read it while the
+session lasts.`},
+			{"Auditing and updates", `With audit.enabled set in config.yaml, switches, key changes, and config
+edits are appended to audit.log without key material. A hooks.secret_access
+command in config.yaml is run each time a key is decrypted for use, for
+example to show a desktop notification.
+
+Updates are checked against the published SHA-256 checksums; use
+'kairo verify-release' to verify a downloaded archive offline.`},
+		},
+		SeeAlso: []string{"secrets", "audit", "verify-release"},
+	},
+	{
+		Name:  "secrets",
+		Short: "Storing, checking, and rotating provider API keys",
+		Sections: []helpSection{
+			{"Storing keys", `'kairo setup' asks for a provider's key when it is configured. To change
+it later, run:
+
+  kairo secrets set <provider>
+  kairo secrets set <provider> --via-browser
+
+Each key is stored as <PROVIDER>_API_KEY=value in the encrypted secrets
+file. Keys are checked against the provider's format rules, and kairo warns
+about values that look weak or truncated but stores them anyway.`},
+			{"Checking keys", `'kairo secrets validate' re-checks every stored key against the format
+rules and strength heuristics: entropy, repeated characters, placeholder
+text, stray whitespace or quotes, and a length below the provider's usual
+key length. 'kairo doctor' also reports a missing or weak key.`},
+			{"Rotation", `'kairo rotate' re-encrypts the secrets file with a new encryption key.
+'kairo rotate --provider <name>' swaps one provider's API key and can run
+the provider's revoke_hook to revoke the old one.`},
+		},
+		SeeAlso: []string{"secrets set", "secrets validate", "rotate", "security-model"},
+	},
+	{
+		Name:  "providers",
+		Short: "Built-in and custom providers and how switching works",
+		Sections: []helpSection{
+			{"Switching", `Run 'kairo <provider> [args...]' to start the harness against a
+configured provider, or plain 'kairo' for the default provider. Arguments
+after the provider name are passed to the harness.
+
+'kairo list' shows the configured providers and 'kairo default <provider>'
+changes the default.`},
+			{"Built-in and custom providers", `Built-in providers come with a base URL, default model, and key format.
+Others can be described in config.yaml under custom_providers, or added
+from a file:
+
+  kairo providers template > my-llm.yaml
+  kairo providers add -f my-llm.yaml
+
+A custom entry with the same name as a built-in provider overrides it.`},
+			{"Per-provider settings", `Each entry under providers in config.yaml can set base_url, model,
+env_vars, auth_style, wrapper_ping, min_harness_version, revoke_hook,
+and settings_files. See docs/reference/configuration.md for the details.`},
+		},
+		SeeAlso: []string{"setup", "list", "providers add", "doctor"},
+	},
+	{
+		Name:  "exit-codes",
+		Short: "What kairo's exit status means",
+		Sections: []helpSection{
+			{"Status 0", `The command ran. Interactive prompts cancelled with Ctrl-C or Esc also
+exit 0. Most commands print an error on stderr and still exit 0, so
+scripts should use the check commands below when they need a status.`},
+			{"Status 1", `Returned when:
+
+  - a flag or argument is invalid
+  - --timeout expired before kairo finished its own work
+  - a check failed: doctor, secrets validate, or verify-release
+  - the harness could not be started or exited with an error
+  - kairo had to prompt but --non-interactive or KAIRO_NON_INTERACTIVE was set`},
+		},
+		SeeAlso: []string{"doctor", "secrets validate", "verify-release"},
+	},
+}
+
+// lookupHelpTopic returns the help topic called name.
+func lookupHelpTopic(name string) (helpTopic, bool) {
+	i := slices.IndexFunc(helpTopics, func(t helpTopic) bool { return t.Name == name })
+	if i < 0 {
+		return helpTopic{}, false
+	}
+
+	return helpTopics[i], true
+}
+
+// paragraphs splits a section body on blank lines.
+func (s helpSection) paragraphs() []string {
+	return strings.Split(strings.TrimSpace(s.Body), "\n\n")
+}
+
+// render writes the topic as plain text for the terminal.
+func (t helpTopic) render(w io.Writer) {
+	fmt.Fprintf(w, "%s - %s\n", t.Name, t.Short)
+	for _, s := range t.Sections {
+		fmt.Fprintf(w, "\n%s\n\n", strings.ToUpper(s.Title))
+		for i, p := range s.paragraphs() {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			for _, line := range strings.Split(p, "\n") {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+	if len(t.SeeAlso) > 0 {
+		fmt.Fprintf(w, "\nSEE ALSO\n\n  %s\n", strings.Join(seeAlsoCommands(t.SeeAlso), ", "))
+	}
+}
+
+// seeAlsoCommands turns SeeAlso entries into the command lines that show
+// them: 'kairo help <topic>' for topics, 'kairo <command> --help' otherwise.
+func seeAlsoCommands(entries []string) []string {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if _, ok := lookupHelpTopic(e); ok {
+			out = append(out, "kairo help "+e)
+		} else {
+			out = append(out, "kairo "+e+" --help")
+		}
+	}
+
+	return out
+}
+
+var helpCmd = &cobra.Command{
+	Use:   "help [command | topic]",
+	Short: "Help about any command or topic",
+	Long:  "Show help for a command, or one of these topics:\n\n" + helpTopicList(),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, t := range helpTopics {
+			names = append(names, t.Name+"\t"+t.Short)
+		}
+		for _, c := range cmd.Root().Commands() {
+			if c.IsAvailableCommand() {
+				names = append(names, c.Name()+"\t"+c.Short)
+			}
+		}
+
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 1 {
+			if t, ok := lookupHelpTopic(args[0]); ok {
+				t.render(cmd.OutOrStdout())
+
+				return
+			}
+		}
+
+		target, _, err := cmd.Root().Find(args)
+		if target == nil || err != nil || (target == cmd.Root() && len(args) > 0) {
+			cmd.Printf("Unknown help topic %q\n\n", strings.Join(args, " "))
+			cmd.Println("Topics:\n\n" + helpTopicList())
+			_ = cmd.Root().Usage()
+
+			return
+		}
+		target.InitDefaultHelpFlag()
+		target.InitDefaultVersionFlag()
+		_ = target.Help()
+		if target == cmd.Root() {
+			cmd.Println("\nHelp topics (kairo help <topic>):\n\n" + helpTopicList())
+		}
+	},
+}
+
+// helpTopicList returns one indented line per help topic.
+func helpTopicList() string {
+	var b strings.Builder
+	for _, t := range helpTopics {
+		fmt.Fprintf(&b, "  %-16s %s\n", t.Name, t.Short)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func init() {
+	rootCmd.SetHelpCommand(helpCmd)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHelpTopicsRender(t *testing.T) {
+	for _, topic := range helpTopics {
+		t.Run(topic.Name, func(t *testing.T) {
+			if topic.Short == "" || len(topic.Sections) == 0 {
+				t.Fatalf("topic %q has no summary or sections", topic.Name)
+			}
+
+			var out bytes.Buffer
+			topic.render(&out)
+			if !strings.HasPrefix(out.String(), topic.Name+" - ") {
+				t.Errorf("render() output starts with %q", strings.SplitN(out.String(), "\n", 2)[0])
+			}
+
+			for _, ref := range topic.SeeAlso {
+				if _, ok := lookupHelpTopic(ref); ok {
+					continue
+				}
+				if c, _, err := rootCmd.Find(strings.Fields(ref)); err != nil || c == rootCmd {
+					t.Errorf("see-also entry %q is neither a topic nor a command", ref)
+				}
+			}
+		})
+	}
+}
+
+func TestHelpCommandTopic(t *testing.T) {
+	var out bytes.Buffer
+	helpCmd.SetOut(&out)
+	defer helpCmd.SetOut(nil)
+
+	helpCmd.Run(helpCmd, []string{"exit-codes"})
+	if !strings.Contains(out.String(), "STATUS 1") {
+		t.Errorf("help exit-codes output:\n%s", out.String())
+	}
+
+	out.Reset()
+	helpCmd.Run(helpCmd, []string{"no-such-topic"})
+	if !strings.Contains(out.String(), `Unknown help topic "no-such-topic"`) {
+		t.Errorf("help no-such-topic output:\n%s", out.String())
+	}
+}
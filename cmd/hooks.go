@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/ui"
+)
+
+// secretAccessHookTimeout bounds the hooks.secret_access command, which runs
+// before the secret is used and should only send a notification.
+const secretAccessHookTimeout = 5 * time.Second
+
+// The health webhook is tried this many times, waiting healthWebhookBackoff,
+// doubled after each try, between them.
+const (
+	healthWebhookAttempts = 3
+	healthWebhookBackoff  = time.Second
+)
+
+// Purposes reported to the secret_access hook.
+const (
+	accessPurposeSwitch   = "switch"
+	accessPurposeHealth   = "health-check"
+	accessPurposeDoctor   = "doctor"
+	accessPurposeValidate = "validate"
+	accessPurposeRevoke   = "revoke"
+	accessPurposeCompare  = "compare"
+	accessPurposeRun      = "run"
+	accessPurposeReveal   = "reveal"
+)
+
+// secretAccessEvent is the JSON document written to the secret_access hook's
+// stdin. It never contains key material.
+type secretAccessEvent struct {
+	Event     string    `json:"event"`
+	Provider  string    `json:"provider"`
+	Purpose   string    `json:"purpose"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// hookCommand returns a command that runs hook through the platform shell.
+// It returns nil if the command cannot be created.
+func hookCommand(ctx context.Context, deps *Deps, hook string) *exec.Cmd {
+	shell, flag := hookShell()
+
+	return deps.Process.ExecCommandContext(ctx, shell, flag, hook)
+}
+
+// hookShell returns the shell hooks run through and its command flag.
+func hookShell() (string, string) {
+	if runtime.GOOS == "windows" {
+		return "cmd", "/C"
+	}
+
+	return "sh", "-c"
+}
+
+// notifySecretAccess runs the hooks.secret_access command configured in dir,
+// if any, to report that provider's API key was decrypted for purpose.
+// Failures are reported as warnings and never abort the command.
+func notifySecretAccess(cliCtx *CLIContext, dir, provider, purpose string) {
+	if cliCtx == nil || dir == "" {
+		return
+	}
+
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil || cfg.Hooks == nil || cfg.Hooks.SecretAccess == "" {
+		return
+	}
+
+	event := secretAccessEvent{
+		Event:     "secret_access",
+		Provider:  provider,
+		Purpose:   purpose,
+		Timestamp: time.Now().UTC(),
+	}
+	if err := runSecretAccessHook(cliCtx.RootCtx(), cliCtx.Deps(), cfg.Hooks.SecretAccess, event); err != nil {
+		ui.PrintWarn(err.Error())
+	}
+}
+
+// runSecretAccessHook runs hook with event as JSON on stdin and as
+// KAIRO_EVENT, KAIRO_PROVIDER, KAIRO_PURPOSE, and KAIRO_TIMESTAMP.
+func runSecretAccessHook(ctx context.Context, deps *Deps, hook string, event secretAccessEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, secretAccessHookTimeout)
+	defer cancel()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	c := hookCommand(ctx, deps, hook)
+	if c == nil {
+		return kairoerrors.NewError(kairoerrors.RuntimeError, "failed to start secret_access hook")
+	}
+	c.Stdin = strings.NewReader(string(payload) + "\n")
+	c.Stdout = os.Stderr
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(),
+		"KAIRO_EVENT="+event.Event,
+		"KAIRO_PROVIDER="+event.Provider,
+		"KAIRO_PURPOSE="+event.Purpose,
+		"KAIRO_TIMESTAMP="+event.Timestamp.Format(time.RFC3339),
+	)
+
+	if err := c.Run(); err != nil {
+		return kairoerrors.WrapError(kairoerrors.RuntimeError, "secret_access hook failed", err).
+			WithContext("provider", event.Provider)
+	}
+
+	return nil
+}
+
+// healthWebhook returns the hooks.health_webhook configured in cfg, if any.
+func healthWebhook(cfg *config.Config) (health.Webhook, bool) {
+	if cfg == nil || cfg.Hooks == nil || cfg.Hooks.HealthWebhook == "" {
+		return health.Webhook{}, false
+	}
+
+	return health.Webhook{
+		URL:      cfg.Hooks.HealthWebhook,
+		Client:   &http.Client{Timeout: constants.RequestTimeout},
+		Attempts: healthWebhookAttempts,
+		Backoff:  healthWebhookBackoff,
+	}, true
+}
+
+// notifyHealthChange posts to the health webhook configured in cfg when
+// provider went from prev to cur between two checks. Failures are reported as
+// warnings and never abort the command.
+func notifyHealthChange(ctx context.Context, cfg *config.Config, provider string, prev, cur health.Result) {
+	webhook, ok := healthWebhook(cfg)
+	if !ok {
+		return
+	}
+	host, _ := os.Hostname()
+	event, changed := health.TransitionEvent(provider, host, prev, cur)
+	if !changed {
+		return
+	}
+	if err := webhook.Send(ctx, event); err != nil {
+		ui.PrintWarn(kairoerrors.Describe(err))
+	}
+}
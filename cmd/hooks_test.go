@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+)
+
+func TestNotifySecretAccess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("secret_access hook test uses a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "events")
+	cfg := &config.Config{
+		Providers: map[string]config.Provider{"zai": {Name: "Z.AI"}},
+		Hooks: &config.HooksConfig{
+			SecretAccess: `printf '%s %s ' "$KAIRO_PROVIDER" "$KAIRO_PURPOSE" >> ` + out + ` && cat >> ` + out,
+		},
+	}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(NewDeps())
+	notifySecretAccess(cliCtx, dir, "zai", accessPurposeSwitch)
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("hook did not run: %v", err)
+	}
+	env, payload, ok := strings.Cut(string(data), "{")
+	if !ok || env != "zai switch " {
+		t.Fatalf("hook output = %q, want provider and purpose in the environment", data)
+	}
+	var event secretAccessEvent
+	if err := json.Unmarshal([]byte("{"+payload), &event); err != nil {
+		t.Fatalf("hook stdin is not JSON: %v", err)
+	}
+	if event.Event != "secret_access" || event.Provider != "zai" || event.Purpose != accessPurposeSwitch || event.Timestamp.IsZero() {
+		t.Errorf("event = %+v", event)
+	}
+
+	if err := runSecretAccessHook(context.Background(), NewDeps(), "exit 3", event); err == nil {
+		t.Error("runSecretAccessHook() expected error for failing hook")
+	}
+}
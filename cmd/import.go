@@ -0,0 +1,369 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/claudesettings"
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/secrets"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFromClaudeSettings bool
+	importName               string
+	importStrategyFlag       string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import provider settings from other tools",
+	Long: `Detect provider setups configured outside kairo and convert them into
+kairo providers.
+
+With --from-claude-settings, reads the "env" block of Claude Code's
+settings.json (~/.claude/settings.json, or $CLAUDE_CONFIG_DIR/settings.json)
+and the current environment for ANTHROPIC_BASE_URL, ANTHROPIC_AUTH_TOKEN,
+ANTHROPIC_API_KEY, and ANTHROPIC_MODEL.
+
+When a detected provider is already configured with a different base URL,
+model, or API key, kairo lists the differences and asks whether to keep the
+local provider, take the incoming one, or merge them field by field.
+--strategy makes the same choice without asking: keep-local, take-incoming,
+or merge, which takes settings only the detection has and keeps the local
+value of the others. Without a terminal or --strategy, such providers are
+skipped.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !importFromClaudeSettings {
+			printError(kairoerrors.NewError(kairoerrors.ValidationError, "no import source specified").
+				WithContext("hint", "use --from-claude-settings to import from Claude Code"))
+
+			return
+		}
+
+		runImportClaudeSettings(cmd)
+	},
+}
+
+func init() {
+	importCmd.Flags().BoolVar(&importFromClaudeSettings, "from-claude-settings", false,
+		"Import from Claude Code settings.json and the environment")
+	importCmd.Flags().StringVar(&importName, "name", "",
+		"Provider name to use for a custom (non built-in) base URL")
+	importCmd.Flags().StringVar(&importStrategyFlag, "strategy", "", importStrategyUsage)
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImportClaudeSettings(cmd *cobra.Command) {
+	settingsPath, err := claudesettings.Path()
+	if err != nil {
+		printError(err)
+
+		return
+	}
+
+	settings, err := claudesettings.Load(settingsPath)
+	if err != nil {
+		printError(err)
+
+		return
+	}
+
+	strategy, err := parseImportStrategy(importStrategyFlag)
+	if err != nil {
+		printError(err)
+
+		return
+	}
+
+	detections := claudesettings.Detect(settings, os.Environ())
+	if len(detections) == 0 {
+		ui.PrintInfo("No existing Claude Code provider settings detected")
+
+		return
+	}
+
+	cliCtx := CLIContextFromCmd(cmd)
+	dir := requireConfigDirWritable(cmd)
+	if dir == "" {
+		return
+	}
+
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		printError(err)
+
+		return
+	}
+
+	cfg, err := LoadConfig(cliCtx, dir)
+	if err != nil {
+		printError(kairoerrors.WrapError(kairoerrors.ConfigError, "error loading config", err))
+
+		return
+	}
+
+	secretsResult, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		handleSecretsError(err)
+
+		return
+	}
+
+	imported := 0
+	for _, d := range detections {
+		name, provider, err := importedProvider(d, importName)
+		if err != nil {
+			ui.PrintWarn(fmt.Sprintf("Skipping %s entry: %v", d.Source, err))
+
+			continue
+		}
+		authToken := d.AuthToken
+		if local, exists := cfg.Providers[name]; exists {
+			var ok bool
+			provider, authToken, ok, err = mergeDetection(cliCtx, name, local, provider, d, secretsResult.Secrets, strategy)
+			if err != nil {
+				if stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+					return
+				}
+				ui.PrintWarn(fmt.Sprintf("Skipping %s entry: %s", d.Source, kairoerrors.Describe(err)))
+				ui.PrintSuggestion(kairoerrors.Suggest(err))
+
+				continue
+			}
+			if !ok {
+				continue
+			}
+		} else {
+			printDetection(name, provider, d)
+
+			ok, err := confirmed(fmt.Sprintf("importing '%s'", name), func() (bool, error) {
+				return ui.Confirm(fmt.Sprintf("Import as provider '%s'", name))
+			})
+			if stderrors.Is(err, errPromptsOff) {
+				printError(err)
+
+				return
+			}
+			if err != nil || !ok {
+				continue
+			}
+		}
+
+		if err := saveImportedProvider(cliCtx, dir, cfg, secretsResult, name, provider, authToken); err != nil {
+			printError(err)
+
+			return
+		}
+
+		imported++
+		ui.PrintSuccess(fmt.Sprintf("Imported provider '%s'", name))
+	}
+
+	if imported > 0 {
+		ui.PrintInfo("Run 'kairo list' to review imported providers")
+	}
+}
+
+// mergeDetection resolves a detection of provider name, which is already
+// configured as local, with strategy. It returns the provider to save, the
+// API key to store, if any, and false when nothing changes.
+func mergeDetection(cliCtx *CLIContext, name string, local, incoming config.Provider,
+	d claudesettings.Detection, stored map[string]string, strategy importStrategy,
+) (config.Provider, string, bool, error) {
+	conflicts := providerConflicts(local, incoming)
+	if d.AuthToken != "" {
+		key, _, _ := secrets.NormalizeKey(d.AuthToken)
+		if old := stored[harness.APIKeyEnvVar(name)]; old != key {
+			c := fieldConflict{Field: "api_key", Incoming: "key " + secrets.Fingerprint(key)}
+			if old != "" {
+				c.Local = "key " + secrets.Fingerprint(old)
+			}
+			conflicts = append(conflicts, c)
+		}
+	}
+	if len(conflicts) == 0 {
+		ui.PrintInfo(fmt.Sprintf("Provider '%s' is already configured with the settings in %s", name, d.Source))
+
+		return config.Provider{}, "", false, nil
+	}
+
+	taken, err := resolveImportConflicts(cliCtx.RootCtx(), name, conflicts, strategy)
+	if err != nil {
+		return config.Provider{}, "", false, err
+	}
+	if len(taken) == 0 {
+		ui.PrintInfo(fmt.Sprintf("Kept the configured provider '%s'", name))
+
+		return config.Provider{}, "", false, nil
+	}
+	authToken := ""
+	if taken["api_key"] {
+		authToken = d.AuthToken
+	}
+
+	return applyTaken(local, incoming, taken), authToken, true, nil
+}
+
+// saveImportedProvider adds an imported provider as the default and stores
+// its API key, if the detection found one.
+func saveImportedProvider(cliCtx *CLIContext, dir string, cfg *config.Config, secretsResult SecretsResult,
+	name string, provider config.Provider, authToken string,
+) error {
+	authToken, err := normalizeAPIKey(name, authToken)
+	if err != nil {
+		return err
+	}
+	params := AddProviderParams{
+		CLIContext:   cliCtx,
+		ConfigDir:    dir,
+		Cfg:          cfg,
+		ProviderName: name,
+		Provider:     provider,
+		SetAsDefault: true,
+	}
+	if authToken == "" {
+		return AddAndSaveProvider(params)
+	}
+
+	envVar := harness.APIKeyEnvVar(name)
+	oldKey := secretsResult.Secrets[envVar]
+	secretsResult.Secrets[envVar] = authToken
+	if err := AddAndSaveProviderWithSecrets(params, "import", secretsResult); err != nil {
+		return err
+	}
+	if authToken != oldKey {
+		recordAudit(cliCtx, dir, audit.Entry{
+			Event:    audit.EventRotate,
+			Action:   "import_secret",
+			Provider: name,
+			Details:  secretChangeDetails(oldKey, authToken),
+		})
+	}
+
+	return nil
+}
+
+func printDetection(name string, provider config.Provider, d claudesettings.Detection) {
+	ui.PrintWhite(fmt.Sprintf("Found provider setup in %s:", d.Source))
+	ui.PrintWhite(fmt.Sprintf("  Provider : %s", name))
+	if provider.BaseURL != "" {
+		ui.PrintWhite(fmt.Sprintf("  URL      : %s", provider.BaseURL))
+	}
+	if provider.Model != "" {
+		ui.PrintWhite(fmt.Sprintf("  Model    : %s", provider.Model))
+	}
+	if d.AuthToken != "" {
+		ui.PrintWhite("  API key  : (detected)")
+	}
+}
+
+// importedProvider converts a detection into a provider name and config
+// entry. Base URLs matching a built-in provider reuse that provider's name;
+// other URLs become a custom provider named nameOverride, or one derived
+// from the URL host.
+func importedProvider(d claudesettings.Detection, nameOverride string) (string, config.Provider, error) {
+	name := matchBuiltInProvider(d.BaseURL)
+	if name == "" {
+		var err error
+		name, err = customImportName(d.BaseURL, nameOverride)
+		if err != nil {
+			return "", config.Provider{}, err
+		}
+	}
+
+	definition := ProviderDefinition(name)
+
+	if d.BaseURL != "" {
+		if err := validate.ValidateURL(d.BaseURL, definition.Name); err != nil {
+			return "", config.Provider{}, err
+		}
+	}
+
+	model := d.Model
+	if model == "" {
+		model = definition.Model
+	}
+	if err := validateConfiguredModel(modelValidationConfig{
+		Model:        model,
+		ProviderName: name,
+		DisplayName:  definition.Name,
+	}); err != nil {
+		return "", config.Provider{}, err
+	}
+
+	if d.AuthToken != "" {
+		key, _, err := secrets.NormalizeKey(d.AuthToken)
+		if err != nil {
+			return "", config.Provider{}, kairoerrors.WrapError(kairoerrors.ValidationError,
+				fmt.Sprintf("%s: API key cannot be imported", definition.Name), err)
+		}
+		if err := definition.ValidateAPIKey(key); err != nil {
+			return "", config.Provider{}, err
+		}
+		warnKeyStrength(name, key)
+	}
+
+	baseURL := d.BaseURL
+	if baseURL == "" {
+		baseURL = definition.BaseURL
+	}
+
+	return name, BuildProviderConfig(ProviderBuildConfig{
+		Definition: definition,
+		BaseURL:    baseURL,
+		Model:      model,
+	}), nil
+}
+
+// matchBuiltInProvider returns the built-in provider whose default base URL
+// matches baseURL, ignoring trailing slashes. An empty baseURL means the
+// native Anthropic API.
+func matchBuiltInProvider(baseURL string) string {
+	if baseURL == "" {
+		return "anthropic"
+	}
+
+	want := strings.TrimRight(baseURL, "/")
+	for _, name := range providers.ProviderList() {
+		def, ok := providers.BuiltInProvider(name)
+		if !ok || def.BaseURL == "" {
+			continue
+		}
+		if strings.TrimRight(def.BaseURL, "/") == want {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// customImportName returns nameOverride when set, otherwise the first
+// significant label of the base URL host (api.example.com -> example).
+func customImportName(baseURL, nameOverride string) (string, error) {
+	if nameOverride != "" {
+		return ValidateCustomProviderName(nameOverride)
+	}
+
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+
+	labels := strings.Split(parsed.Hostname(), ".")
+	if len(labels) > 2 && labels[0] == "api" {
+		labels = labels[1:]
+	}
+
+	return ValidateCustomProviderName(labels[0])
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/yarlson/tap"
+)
+
+// importStrategy decides what importing a provider that is already
+// configured with different settings does.
+type importStrategy string
+
+// Import strategies. importAsk prompts on a terminal.
+const (
+	importAsk          importStrategy = ""
+	importKeepLocal    importStrategy = "keep-local"
+	importTakeIncoming importStrategy = "take-incoming"
+	importMerge        importStrategy = "merge"
+)
+
+// importStrategyUsage describes the --strategy flag of the import commands.
+const importStrategyUsage = "What to do with a provider already configured differently: " +
+	"keep-local, take-incoming, or merge (default: ask on a terminal)"
+
+func parseImportStrategy(s string) (importStrategy, error) {
+	switch strategy := importStrategy(s); strategy {
+	case importAsk, importKeepLocal, importTakeIncoming, importMerge:
+		return strategy, nil
+	default:
+		return importAsk, kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("unknown --strategy %q", s)).
+			WithContext("hint", "use keep-local, take-incoming, or merge")
+	}
+}
+
+// fieldConflict is a setting an imported provider holds differently from
+// the configured one. Local and Incoming are the values as shown to the
+// user; an empty one means that side does not set it.
+type fieldConflict struct {
+	Field    string
+	Local    string
+	Incoming string
+}
+
+// providerField is a provider setting that imports can carry.
+type providerField struct {
+	name string
+	get  func(config.Provider) string
+	take func(dst *config.Provider, src config.Provider)
+}
+
+var importedProviderFields = []providerField{
+	{"name", func(p config.Provider) string { return p.Name },
+		func(dst *config.Provider, src config.Provider) { dst.Name = src.Name }},
+	{"base_url", func(p config.Provider) string { return p.BaseURL },
+		func(dst *config.Provider, src config.Provider) { dst.BaseURL = src.BaseURL }},
+	{"model", func(p config.Provider) string { return p.Model },
+		func(dst *config.Provider, src config.Provider) { dst.Model = src.Model }},
+	{"env_vars", func(p config.Provider) string { return strings.Join(p.EnvVars, " ") },
+		func(dst *config.Provider, src config.Provider) { dst.EnvVars = src.EnvVars }},
+	{"auth_style", func(p config.Provider) string { return p.AuthStyle },
+		func(dst *config.Provider, src config.Provider) { dst.AuthStyle = src.AuthStyle }},
+}
+
+// bundlePolicyFields are the provider settings only provider bundles carry,
+// compared by policyConflicts.
+var bundlePolicyFields = []providerField{
+	{"min_harness_version", func(p config.Provider) string { return harnessVersionsString(p.MinHarnessVersion) },
+		func(dst *config.Provider, src config.Provider) { dst.MinHarnessVersion = src.MinHarnessVersion }},
+	{"wrapper_ping", func(p config.Provider) string {
+		if p.WrapperPing {
+			return "true"
+		}
+
+		return ""
+	},
+		func(dst *config.Provider, src config.Provider) { dst.WrapperPing = src.WrapperPing }},
+	{"run_env_allow", func(p config.Provider) string { return strings.Join(p.RunEnvAllow, " ") },
+		func(dst *config.Provider, src config.Provider) { dst.RunEnvAllow = src.RunEnvAllow }},
+}
+
+// harnessVersionsString shows a min_harness_version map as "claude>=2.0.0",
+// sorted by harness.
+func harnessVersionsString(versions map[string]string) string {
+	parts := make([]string, 0, len(versions))
+	for _, h := range slices.Sorted(maps.Keys(versions)) {
+		parts = append(parts, h+">="+versions[h])
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// providerConflicts lists the imported settings of incoming that differ
+// from local.
+func providerConflicts(local, incoming config.Provider) []fieldConflict {
+	return fieldConflicts(importedProviderFields, local, incoming)
+}
+
+// policyConflicts lists the bundle policy settings of incoming that differ
+// from local.
+func policyConflicts(local, incoming config.Provider) []fieldConflict {
+	return fieldConflicts(bundlePolicyFields, local, incoming)
+}
+
+func fieldConflicts(fields []providerField, local, incoming config.Provider) []fieldConflict {
+	var conflicts []fieldConflict
+	for _, f := range fields {
+		if l, in := f.get(local), f.get(incoming); l != in {
+			conflicts = append(conflicts, fieldConflict{Field: f.name, Local: l, Incoming: in})
+		}
+	}
+
+	return conflicts
+}
+
+// applyTaken returns local with the fields in taken copied from incoming.
+func applyTaken(local, incoming config.Provider, taken map[string]bool) config.Provider {
+	for _, f := range slices.Concat(importedProviderFields, bundlePolicyFields) {
+		if taken[f.name] {
+			f.take(&local, incoming)
+		}
+	}
+
+	return local
+}
+
+// resolveImportConflicts returns the fields of conflicts to take from the
+// incoming side for provider. keep-local takes none and take-incoming all.
+// merge takes a setting only the incoming side has and keeps one only the
+// local side has; one both sides set is asked about on a terminal and kept
+// otherwise. importAsk prompts for one of the three, and returns an error
+// without a terminal.
+func resolveImportConflicts(
+	ctx context.Context, provider string, conflicts []fieldConflict, strategy importStrategy,
+) (map[string]bool, error) {
+	interactive := !nonInteractive() && terminalWriter(os.Stdin) != nil && terminalWriter(os.Stdout) != nil
+	if strategy == importAsk {
+		if !interactive {
+			return nil, kairoerrors.NewError(kairoerrors.ValidationError,
+				fmt.Sprintf("provider '%s' is already configured with different settings", provider)).
+				WithContext("hint", "pass --strategy keep-local, take-incoming, or merge")
+		}
+		printFieldConflicts(provider, conflicts)
+		strategy = importStrategy(tap.Select(ctx, tap.SelectOptions[importStrategy]{
+			Message: fmt.Sprintf("'%s' is already configured differently", provider),
+			Options: []tap.SelectOption[importStrategy]{
+				{Value: importKeepLocal, Label: "Keep local", Hint: "leave the configured provider as it is"},
+				{Value: importTakeIncoming, Label: "Take incoming", Hint: "replace the settings listed above"},
+				{Value: importMerge, Label: "Merge field by field", Hint: "choose each setting both sides hold"},
+			},
+		}))
+		if strategy == importAsk {
+			return nil, kairoerrors.ErrUserCancelled
+		}
+	}
+
+	taken := make(map[string]bool, len(conflicts))
+	for _, c := range conflicts {
+		switch strategy {
+		case importTakeIncoming:
+			taken[c.Field] = true
+		case importMerge:
+			take, err := mergeField(ctx, c, interactive)
+			if err != nil {
+				return nil, err
+			}
+			if take {
+				taken[c.Field] = true
+			}
+		}
+	}
+
+	return taken, nil
+}
+
+// mergeField reports whether merging takes the incoming value of c.
+func mergeField(ctx context.Context, c fieldConflict, interactive bool) (bool, error) {
+	switch {
+	case c.Local == "":
+		return true, nil
+	case c.Incoming == "" || !interactive:
+		return false, nil
+	default:
+		return chooseIncoming(ctx, c)
+	}
+}
+
+// chooseIncoming asks which side's value of c to keep.
+func chooseIncoming(ctx context.Context, c fieldConflict) (bool, error) {
+	choice := tap.Select(ctx, tap.SelectOptions[string]{
+		Message: c.Field,
+		Options: []tap.SelectOption[string]{
+			{Value: "local", Label: c.Local, Hint: "local"},
+			{Value: "incoming", Label: c.Incoming, Hint: "incoming"},
+		},
+	})
+	if choice == "" {
+		return false, kairoerrors.ErrUserCancelled
+	}
+
+	return choice == "incoming", nil
+}
+
+func printFieldConflicts(provider string, conflicts []fieldConflict) {
+	ui.PrintWhite(fmt.Sprintf("'%s' differs from the configured provider:", provider))
+	for _, c := range conflicts {
+		ui.PrintWhite(fmt.Sprintf("  %-19s local:    %s", c.Field, displayImportValue(c.Local)))
+		ui.PrintWhite(fmt.Sprintf("  %-19s incoming: %s", "", displayImportValue(c.Incoming)))
+	}
+}
+
+func displayImportValue(v string) string {
+	if v == "" {
+		return "(not set)"
+	}
+
+	return v
+}
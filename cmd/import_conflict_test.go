@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+)
+
+func TestParseImportStrategy(t *testing.T) {
+	for _, s := range []string{"", "keep-local", "take-incoming", "merge"} {
+		if got, err := parseImportStrategy(s); err != nil || string(got) != s {
+			t.Errorf("parseImportStrategy(%q) = %q, %v", s, got, err)
+		}
+	}
+	if _, err := parseImportStrategy("theirs"); err == nil {
+		t.Error("parseImportStrategy(\"theirs\") succeeded, want an error")
+	}
+}
+
+func TestResolveImportConflicts(t *testing.T) {
+	local := config.Provider{
+		Name:        "Gateway",
+		BaseURL:     "https://llm.example.com/anthropic",
+		Model:       "gw-large",
+		EnvVars:     []string{"API_TIMEOUT_MS=600000"},
+		RunEnvAllow: []string{"LC_*"},
+	}
+	incoming := config.Provider{
+		Name:      "Gateway",
+		BaseURL:   "https://llm2.example.com/anthropic",
+		Model:     "gw-small",
+		AuthStyle: "bearer",
+	}
+	conflicts := providerConflicts(local, incoming)
+	var fields []string
+	for _, c := range conflicts {
+		fields = append(fields, c.Field)
+	}
+	if want := []string{"base_url", "model", "env_vars", "auth_style"}; !slices.Equal(fields, want) {
+		t.Fatalf("providerConflicts() fields = %v, want %v", fields, want)
+	}
+
+	tests := []struct {
+		strategy importStrategy
+		want     []string
+	}{
+		{strategy: importKeepLocal},
+		{strategy: importTakeIncoming, want: []string{"auth_style", "base_url", "env_vars", "model"}},
+		// Without a terminal, merge only takes what the local side leaves unset.
+		{strategy: importMerge, want: []string{"auth_style"}},
+	}
+	for _, tt := range tests {
+		taken, err := resolveImportConflicts(context.Background(), "gateway", conflicts, tt.strategy)
+		if err != nil {
+			t.Fatalf("resolveImportConflicts(%s) error = %v", tt.strategy, err)
+		}
+		if got := slices.Sorted(maps.Keys(taken)); !slices.Equal(got, tt.want) {
+			t.Errorf("resolveImportConflicts(%s) = %v, want %v", tt.strategy, got, tt.want)
+		}
+	}
+
+	taken, _ := resolveImportConflicts(context.Background(), "gateway", conflicts, importMerge)
+	merged := applyTaken(local, incoming, taken)
+	if merged.BaseURL != local.BaseURL || merged.AuthStyle != "bearer" ||
+		len(merged.EnvVars) != 1 || len(merged.RunEnvAllow) != 1 {
+		t.Errorf("merged provider = %+v", merged)
+	}
+
+	_, err := resolveImportConflicts(context.Background(), "gateway", conflicts, importAsk)
+	if err == nil || errors.Is(err, kairoerrors.ErrUserCancelled) {
+		t.Errorf("resolveImportConflicts(ask) without a terminal error = %v, want a --strategy error", err)
+	}
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/claudesettings"
+	"github.com/dkmnx/kairo/internal/config"
+)
+
+func TestMatchBuiltInProvider(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+	}{
+		{"", "anthropic"},
+		{"https://api.z.ai/api/anthropic", "zai"},
+		{"https://api.z.ai/api/anthropic/", "zai"},
+		{"https://api.kimi.com/coding", "kimi"},
+		{"https://llm.example.com/v1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			if got := matchBuiltInProvider(tt.baseURL); got != tt.want {
+				t.Errorf("matchBuiltInProvider(%q) = %q, want %q", tt.baseURL, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomImportName(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		override string
+		want     string
+		wantErr  bool
+	}{
+		{"strips api label", "https://api.example.com/v1", "", "example", false},
+		{"keeps first label", "https://gateway.example.com", "", "gateway", false},
+		{"override wins", "https://api.example.com", "corp", "corp", false},
+		{"reserved override", "https://api.example.com", "zai", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := customImportName(tt.baseURL, tt.override)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("customImportName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("customImportName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportedProvider(t *testing.T) {
+	t.Run("built-in uses default model", func(t *testing.T) {
+		name, p, err := importedProvider(claudesettings.Detection{
+			BaseURL:   "https://api.z.ai/api/anthropic",
+			AuthToken: strings.Repeat("a", 40),
+		}, "")
+		if err != nil {
+			t.Fatalf("importedProvider() error = %v", err)
+		}
+		if name != "zai" || p.Model != "glm-5.1" {
+			t.Errorf("importedProvider() = %q, %+v", name, p)
+		}
+	})
+
+	t.Run("custom requires model", func(t *testing.T) {
+		_, _, err := importedProvider(claudesettings.Detection{BaseURL: "https://api.example.com"}, "")
+		if err == nil {
+			t.Error("importedProvider() error = nil, want model required error")
+		}
+	})
+
+	t.Run("rejects plain HTTP", func(t *testing.T) {
+		_, _, err := importedProvider(claudesettings.Detection{
+			BaseURL: "http://api.example.com", Model: "m",
+		}, "")
+		if err == nil {
+			t.Error("importedProvider() error = nil, want HTTPS error")
+		}
+	})
+
+	t.Run("rejects short key", func(t *testing.T) {
+		_, _, err := importedProvider(claudesettings.Detection{
+			BaseURL: "https://api.z.ai/api/anthropic", AuthToken: "short",
+		}, "")
+		if err == nil {
+			t.Error("importedProvider() error = nil, want key validation error")
+		}
+	})
+}
+
+func TestImportCommandFromClaudeSettings(t *testing.T) {
+	originalConfigDir := testCLI.ConfigDir()
+	defer func() { testCLI.SetConfigDir(originalConfigDir) }()
+	defer func() {
+		importFromClaudeSettings, yesFlag, importName, importStrategyFlag = false, false, "", ""
+	}()
+
+	tmpDir := t.TempDir()
+	testCLI.SetConfigDir(tmpDir)
+
+	claudeDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", claudeDir)
+	t.Setenv("ANTHROPIC_BASE_URL", "")
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	settings := `{"env": {
+  "ANTHROPIC_BASE_URL": "https://api.example.com/anthropic",
+  "ANTHROPIC_AUTH_TOKEN": "` + strings.Repeat("k", 40) + `",
+  "ANTHROPIC_MODEL": "example-large"
+}}`
+	if err := os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(settings), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"--config", tmpDir, "import", "--from-claude-settings", "--yes"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	cfg, err := config.LoadConfig(context.Background(), tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	p, ok := cfg.Providers["example"]
+	if !ok {
+		t.Fatalf("provider 'example' not imported; providers = %v", cfg.Providers)
+	}
+	if p.BaseURL != "https://api.example.com/anthropic" || p.Model != "example-large" {
+		t.Errorf("imported provider = %+v", p)
+	}
+	if cfg.DefaultProvider != "example" {
+		t.Errorf("DefaultProvider = %q, want %q", cfg.DefaultProvider, "example")
+	}
+
+	result, err := LoadSecrets(testCLI, tmpDir)
+	if err != nil {
+		t.Fatalf("LoadSecrets() error = %v", err)
+	}
+	if result.Secrets["EXAMPLE_API_KEY"] != strings.Repeat("k", 40) {
+		t.Errorf("EXAMPLE_API_KEY not stored; secrets keys = %d", len(result.Secrets))
+	}
+
+	settings = strings.NewReplacer("example-large", "example-small", strings.Repeat("k", 40), strings.Repeat("n", 40)).
+		Replace(settings)
+	if err := os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(settings), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		strategy  string
+		wantModel string
+		wantKey   string
+	}{
+		{strategy: "merge", wantModel: "example-large", wantKey: strings.Repeat("k", 40)},
+		{strategy: "take-incoming", wantModel: "example-small", wantKey: strings.Repeat("n", 40)},
+	} {
+		rootCmd.SetArgs([]string{"--config", tmpDir, "import", "--from-claude-settings", "--strategy", tt.strategy})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("Execute() with --strategy %s error = %v", tt.strategy, err)
+		}
+		cfg, err := config.LoadConfig(context.Background(), tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := LoadSecrets(testCLI, tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Providers["example"].Model != tt.wantModel || result.Secrets["EXAMPLE_API_KEY"] != tt.wantKey {
+			t.Errorf("--strategy %s: model %q, key changed %v; want model %q", tt.strategy,
+				cfg.Providers["example"].Model, result.Secrets["EXAMPLE_API_KEY"] != strings.Repeat("k", 40), tt.wantModel)
+		}
+	}
+}
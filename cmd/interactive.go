@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"os"
+	"sync/atomic"
+
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+)
+
+// nonInteractiveEnv turns prompts off like --non-interactive, for scripts
+// and configuration management that cannot add a flag to every call.
+const nonInteractiveEnv = "KAIRO_NON_INTERACTIVE"
+
+var (
+	yesFlag            bool
+	nonInteractiveFlag bool
+)
+
+// errPromptsOff is the cause of the errors requireInteractive returns.
+var errPromptsOff = stderrors.New("prompts are turned off")
+
+// promptRefused records that requireInteractive stopped a command, so that
+// Execute can exit with status 1 even though the command itself only
+// printed the error.
+var promptRefused atomic.Bool
+
+// errPromptRefused is returned by Execute after a prompt was refused.
+var errPromptRefused = kairoerrors.NewError(kairoerrors.ValidationError,
+	"stopped at a prompt; kairo runs non-interactively (--non-interactive or "+nonInteractiveEnv+")")
+
+// nonInteractive reports whether kairo must not prompt: --non-interactive
+// was given or KAIRO_NON_INTERACTIVE is set to 1 or true.
+func nonInteractive() bool {
+	switch os.Getenv(nonInteractiveEnv) {
+	case "1", "true":
+		return true
+	}
+
+	return nonInteractiveFlag
+}
+
+// requireInteractive returns an error when prompts are off, naming what
+// kairo would have asked for and, in hint, how to supply it instead.
+func requireInteractive(what, hint string) error {
+	if !nonInteractive() {
+		return nil
+	}
+	promptRefused.Store(true)
+
+	return kairoerrors.WrapError(kairoerrors.ValidationError, "cannot ask for "+what, errPromptsOff).
+		WithContext("hint", hint)
+}
+
+// confirmed answers a confirmation of action: yes without asking with
+// --yes, an error when prompts are off, and otherwise whatever ask returns.
+func confirmed(action string, ask func() (bool, error)) (bool, error) {
+	if yesFlag {
+		return true, nil
+	}
+	if err := requireInteractive("confirmation before "+action, "pass --yes to confirm"); err != nil {
+		return false, err
+	}
+
+	return ask()
+}
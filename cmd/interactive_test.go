@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+)
+
+func resetInteractiveFlags(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		yesFlag = false
+		nonInteractiveFlag = false
+		promptRefused.Store(false)
+	})
+}
+
+func TestNonInteractive(t *testing.T) {
+	resetInteractiveFlags(t)
+
+	tests := []struct {
+		name string
+		env  string
+		flag bool
+		want bool
+	}{
+		{"default", "", false, false},
+		{"flag", "", true, true},
+		{"env 1", "1", false, true},
+		{"env true", "true", false, true},
+		{"env other", "no", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(nonInteractiveEnv, tt.env)
+			nonInteractiveFlag = tt.flag
+			if got := nonInteractive(); got != tt.want {
+				t.Errorf("nonInteractive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmed(t *testing.T) {
+	resetInteractiveFlags(t)
+	t.Setenv(nonInteractiveEnv, "")
+
+	asked := false
+	ask := func() (bool, error) {
+		asked = true
+
+		return false, nil
+	}
+
+	ok, err := confirmed("testing", ask)
+	if err != nil || ok || !asked {
+		t.Errorf("interactive: confirmed() = %v, %v, asked %v; want false, nil, asked", ok, err, asked)
+	}
+
+	asked = false
+	yesFlag = true
+	ok, err = confirmed("testing", ask)
+	if err != nil || !ok || asked {
+		t.Errorf("--yes: confirmed() = %v, %v, asked %v; want true, nil, not asked", ok, err, asked)
+	}
+
+	yesFlag = false
+	nonInteractiveFlag = true
+	ok, err = confirmed("testing", ask)
+	if !errors.Is(err, errPromptsOff) || ok || asked {
+		t.Errorf("--non-interactive: confirmed() = %v, %v, asked %v; want errPromptsOff", ok, err, asked)
+	}
+	if !promptRefused.Load() {
+		t.Error("refused confirmation was not recorded")
+	}
+}
+
+func TestSecretsSet_NonInteractive(t *testing.T) {
+	resetInteractiveFlags(t)
+	t.Setenv(nonInteractiveEnv, "")
+	originalConfigDir := testCLI.ConfigDir()
+	defer func() { testCLI.SetConfigDir(originalConfigDir) }()
+	oldArgs, oldCtx := os.Args, rootCmd.Context()
+	defer func() {
+		os.Args = oldArgs
+		rootCmd.SetContext(oldCtx)
+		secretsSetAPIKeyStdin = false
+		secretsSetCmd.Flags().Lookup("api-key-stdin").Changed = false
+		rootCmd.SetIn(nil)
+		// Execute leaves its canceled context on the commands it ran.
+		secretsSetCmd.SetContext(oldCtx)
+	}()
+
+	dir := t.TempDir()
+	cfg := &config.Config{Providers: map[string]config.Provider{"zai": {Name: "Z.AI"}}}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+	testCLI.SetConfigDir(dir)
+
+	key := "zai-test-key-0123456789abcdef0123456789"
+	rootCmd.SetIn(strings.NewReader(key + "\n"))
+	rootCmd.SetArgs([]string{"--config", dir, "--non-interactive", "secrets", "set", "zai", "--api-key-stdin"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	secretsSetAPIKeyStdin = false
+	secretsSetCmd.Flags().Lookup("api-key-stdin").Changed = false
+
+	result, err := LoadSecrets(NewCLIContext(), dir)
+	if err != nil {
+		t.Fatalf("LoadSecrets() error = %v", err)
+	}
+	if got := result.Secrets["ZAI_API_KEY"]; got != key {
+		t.Errorf("ZAI_API_KEY = %q, want %q", got, key)
+	}
+
+	// Without a way to supply the key, kairo refuses to prompt and exits 1.
+	os.Args = []string{"kairo", "--config", dir, "--non-interactive", "secrets", "set", "zai"}
+	if err := Execute(); !errors.Is(err, errPromptRefused) {
+		t.Fatalf("Execute() error = %v, want errPromptRefused", err)
+	}
+}
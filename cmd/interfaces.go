@@ -3,10 +3,13 @@ package cmd
 import (
 	"context"
 	"os/exec"
+	"time"
 
 	"github.com/dkmnx/kairo/internal/crypto"
+	"github.com/dkmnx/kairo/internal/health"
 	"github.com/dkmnx/kairo/internal/providers"
 	"github.com/dkmnx/kairo/internal/update"
+	"github.com/dkmnx/kairo/internal/validate"
 	"github.com/dkmnx/kairo/internal/wrapper"
 )
 
@@ -17,11 +20,13 @@ type ProcessRunner interface {
 	ExitProcess(code int)
 }
 
-// WrapperService provides wrapper script generation and temp auth operations.
+// WrapperService provides harness launch and temp auth operations.
 type WrapperService interface {
-	CreateTempAuthDir() (string, error)
+	CreateTempAuthDir(parent string) (string, error)
 	WriteTempTokenFile(authDir, token string) (string, error)
-	GenerateWrapperScript(cfg wrapper.ScriptConfig) (string, bool, error)
+	WriteSettingsFile(authDir, name, content string) (string, error)
+	PrepareLaunch(cfg wrapper.ScriptConfig) (wrapper.Launch, error)
+	CleanStaleAuthDirs(tmpDir string, maxAge time.Duration, dryRun bool) ([]string, error)
 }
 
 // UpdateService provides version checking and self-update operations.
@@ -43,6 +48,11 @@ type CatalogService interface {
 	RefreshFromRemote(ctx context.Context) (int, error)
 }
 
+// HealthService probes provider endpoints.
+type HealthService interface {
+	Check(ctx context.Context, baseURL, apiKey string, style health.AuthStyle) health.Result
+}
+
 // Deps holds all external dependencies as interfaces.
 // Production code uses NewDeps(); tests inject mocks via CLIContext.SetDeps.
 type Deps struct {
@@ -51,4 +61,8 @@ type Deps struct {
 	Update  UpdateService
 	Crypto  crypto.Service
 	Catalog CatalogService
+	Health  HealthService
+	// Resolver looks up the base URL's host before launch. Nil skips the
+	// check for a private address.
+	Resolver validate.Resolver
 }
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/dkmnx/kairo/internal/agent"
+	"github.com/dkmnx/kairo/internal/clipboard"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/yarlson/tap"
+)
+
+var (
+	keyShowPublic bool
+	keyShowCopy   bool
+)
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Inspect the encryption key",
+}
+
+var keyShowCmd = &cobra.Command{
+	Use:   "show --public",
+	Short: "Print the public age recipient of the encryption key",
+	Long: `Print the age recipient (age1...) of the key in age.key, so that a teammate
+can encrypt secrets to you. The private key is never shown.
+
+With --copy, the recipient is copied to the clipboard instead: through
+pbcopy, clip.exe, wl-copy, xclip, or xsel when one is installed, and
+otherwise with the OSC 52 terminal escape sequence, which also works over
+SSH in terminals that support it. If neither is available, the recipient is
+printed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runKeyShow(cmd); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	keyShowCmd.Flags().BoolVar(&keyShowPublic, "public", false, "Show the public recipient (required)")
+	keyShowCmd.Flags().BoolVar(&keyShowCopy, "copy", false, "Copy the recipient to the clipboard")
+	keyCmd.AddCommand(keyShowCmd)
+	rootCmd.AddCommand(keyCmd)
+}
+
+func runKeyShow(cmd *cobra.Command) error {
+	if !keyShowPublic {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "kairo never shows the private key").
+			WithContext("hint", "pass --public to show the recipient you can share")
+	}
+
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil && !stderrors.Is(err, kairoerrors.ErrConfigNotFound) {
+		return err
+	}
+	if cfg != nil && cfg.Crypto != nil && cfg.Crypto.Backend != "" && cfg.Crypto.Backend != crypto.BackendAge {
+		return kairoerrors.NewError(kairoerrors.ConfigError,
+			fmt.Sprintf("crypto.backend is %s, which has no age recipient", cfg.Crypto.Backend))
+	}
+
+	recipient, err := publicRecipient(cliCtx.RootCtx(), dir, cfg)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if !keyShowCopy {
+		fmt.Fprintln(out, recipient)
+
+		return nil
+	}
+
+	method, err := clipboard.New(terminalWriter(os.Stderr)).Copy(cliCtx.RootCtx(), recipient)
+	switch {
+	case err != nil:
+		ui.PrintWarn(fmt.Sprintf("Could not copy to the clipboard (%v); copy the recipient below", err))
+		fmt.Fprintln(out, recipient)
+	case method == clipboard.MethodOSC52:
+		ui.PrintInfo("Sent the recipient to the terminal clipboard (OSC 52); if your terminal ignores it, copy it below")
+		fmt.Fprintln(out, recipient)
+	default:
+		ui.PrintSuccess(fmt.Sprintf("Public recipient copied to the clipboard with %s", method))
+	}
+
+	return nil
+}
+
+// publicRecipient returns the age recipient of the key in dir, from a
+// running kairo agent if it holds the key, and otherwise from the key file,
+// asking for its passphrase if it has one.
+func publicRecipient(ctx context.Context, dir string, cfg *config.Config) (string, error) {
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+	if runtime.GOOS != constants.WindowsGOOS {
+		client := agent.Client{Socket: agentSocketPath(dir, cfg)}
+		if r, err := client.Recipient(ctx, keyPath); err == nil {
+			if s, ok := r.(fmt.Stringer); ok {
+				return s.String(), nil
+			}
+		}
+	}
+
+	key, err := crypto.ReadKeyFile(keyPath, promptKeyPassphrase)
+	if err != nil {
+		return "", err
+	}
+	defer crypto.ClearMemory(key)
+
+	return key.Recipient()
+}
+
+// promptKeyPassphrase asks for the passphrase of a protected age.key.
+func promptKeyPassphrase() (string, error) {
+	if err := requireInteractive("the passphrase of "+constants.KeyFileName,
+		"run 'kairo agent start' in a terminal first; while it runs, kairo decrypts through it without asking"); err != nil {
+		return "", err
+	}
+	passphrase := tap.Password(promptContext(), tap.PasswordOptions{
+		Message: "Passphrase for " + constants.KeyFileName,
+	})
+	if passphrase == "" {
+		return "", kairoerrors.ErrUserCancelled
+	}
+
+	return passphrase, nil
+}
+
+// terminalWriter returns f if it is a terminal, and nil otherwise.
+func terminalWriter(f *os.File) io.Writer {
+	if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return f
+	}
+
+	return nil
+}
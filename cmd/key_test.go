@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+)
+
+func TestRunKeyShow(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+	if err := crypto.GenerateKey(context.Background(), keyPath); err != nil {
+		t.Fatal(err)
+	}
+	keyFile, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(keyFile)), "\n")
+
+	cmd, out := explainTestCmd(t, dir)
+	defer func() { keyShowPublic, keyShowCopy = false, false }()
+
+	if err := runKeyShow(cmd); err == nil || !strings.Contains(err.Error(), "private key") {
+		t.Errorf("runKeyShow() without --public error = %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("output = %q, want nothing without --public", out.String())
+	}
+
+	keyShowPublic = true
+	if err := runKeyShow(cmd); err != nil {
+		t.Fatalf("runKeyShow() error = %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != lines[1] {
+		t.Errorf("output = %q, want the recipient %q", got, lines[1])
+	}
+	if strings.Contains(out.String(), lines[0]) {
+		t.Error("output contains the private key")
+	}
+}
+
+func TestRunKeyShow_KMSBackend(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, "providers: {}\ncrypto:\n  backend: awskms\n  key_id: alias/kairo\n")
+
+	cmd, _ := explainTestCmd(t, dir)
+	defer func() { keyShowPublic = false }()
+	keyShowPublic = true
+
+	if err := runKeyShow(cmd); err == nil || !strings.Contains(err.Error(), "no age recipient") {
+		t.Errorf("runKeyShow() error = %v, want no age recipient", err)
+	}
+}
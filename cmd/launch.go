@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"cmp"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/envsnapshot"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/lockfile"
+	"github.com/spf13/cobra"
+)
+
+// launchTarget is what a launch starts: the provider, with the model of the
+// harness, and the harness with its arguments. A launch and its --explain
+// plan resolve it with the same function, so the plan describes the launch
+// that would run.
+type launchTarget struct {
+	ProviderName string
+	Provider     config.Provider
+	Harness      string
+	HarnessArgs  []string
+}
+
+// resolveSwitchLaunch resolves `kairo [provider] [-- args]`: the provider
+// named in args or the default one, the harness from --harness or the
+// config, --resume and --continue, and the model from --model or the
+// provider's entry for the harness.
+func resolveSwitchLaunch(cmd *cobra.Command, cliCtx *CLIContext, cfg *config.Config, args []string) (launchTarget, error) {
+	harnessArgs, providerName := resolveProviderAndArgs(cmd, cliCtx, cfg, args)
+	if providerName == "" {
+		return launchTarget{}, errReported
+	}
+	provider, err := lookupProvider(cfg, providerName)
+	if err != nil {
+		return launchTarget{}, err
+	}
+	harnessToUse := resolveHarness(harnessFlag, cfg.DefaultHarness)
+	harnessArgs, err = applyResumeFlag(harnessToUse, harnessArgs)
+	if err != nil {
+		return launchTarget{}, err
+	}
+	provider.Model = cmp.Or(modelFlag, provider.ModelFor(harnessToUse))
+
+	return launchTarget{ProviderName: providerName, Provider: provider, Harness: harnessToUse, HarnessArgs: harnessArgs}, nil
+}
+
+// resolveLockedLaunch resolves `kairo run --locked`: the provider pinned in
+// lock, started with the default harness, or an error when the
+// configuration drifted from the lock.
+func resolveLockedLaunch(cfg *config.Config, lock lockfile.Lock, harnessArgs []string) (launchTarget, error) {
+	provider, err := lockedProvider(cfg, lock)
+	if err != nil {
+		return launchTarget{}, err
+	}
+	_, harnessToUse := lockedHarness(cfg, provider)
+
+	return launchTarget{ProviderName: lock.Provider, Provider: provider, Harness: harnessToUse, HarnessArgs: harnessArgs}, nil
+}
+
+// resolveSnapshotLaunch resolves `kairo run --from-snapshot`: the provider
+// and harness recorded in snap. The names of redacted variables the local
+// configuration cannot fill are returned with it.
+func resolveSnapshotLaunch(cfg *config.Config, snap envsnapshot.Snapshot, harnessArgs []string) (launchTarget, []string) {
+	provider, missing := snapshotProvider(snap, cfg.Providers[snap.Provider])
+
+	return launchTarget{ProviderName: snap.Provider, Provider: provider, Harness: snap.Harness, HarnessArgs: harnessArgs}, missing
+}
+
+// startLaunch starts t, through Pi's own provider setup for Pi and the
+// wrapper for every other harness.
+func startLaunch(cmd *cobra.Command, cliCtx *CLIContext, cfg *config.Config, t launchTarget) {
+	if t.Harness == harness.Pi {
+		runPiProvider(cmd, cliCtx, cfg, t.Provider, t.ProviderName, t.Harness, t.HarnessArgs)
+	} else {
+		runStandardProvider(cmd, cliCtx, t.Provider, t.ProviderName, t.Harness, t.HarnessArgs)
+	}
+}
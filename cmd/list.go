@@ -3,8 +3,10 @@ package cmd
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/health"
 	"github.com/dkmnx/kairo/internal/providers"
 	"github.com/dkmnx/kairo/internal/ui"
 	"github.com/spf13/cobra"
@@ -31,29 +33,45 @@ var listCmd = &cobra.Command{
 		fmt.Println()
 
 		names := sortProviderNames(cfg.Providers, cfg.DefaultProvider)
+		cliCtx := CLIContextFromCmd(cmd)
+		state := stateDir(cliCtx, cliCtx.ConfigDir())
+		now := time.Now()
 
 		for _, name := range names {
 			p := cfg.Providers[name]
 			isDefault := (name == cfg.DefaultProvider)
 
-			if isDefault {
+			// A disabled provider is shown entirely in gray.
+			printLine := ui.PrintWhite
+			switch {
+			case p.Disabled:
+				printLine = ui.PrintGray
+				label := "(disabled)"
+				if isDefault {
+					label = "(default, disabled)"
+				}
+				printLine(fmt.Sprintf("  ❯ %s %s", name, label))
+			case isDefault:
 				fmt.Printf("%s  ❯ %s %s(default)%s\n", ui.White, name, ui.Gray, ui.Reset)
-			} else {
-				ui.PrintWhite(fmt.Sprintf("  ❯ %s", name))
+			default:
+				printLine(fmt.Sprintf("  ❯ %s", name))
 			}
 
 			if !providers.RequiresAPIKey(name) {
 				def, _ := providers.BuiltInProvider(name)
-				ui.PrintWhite(fmt.Sprintf("    %s", def.Name))
-				ui.PrintWhite("    Native Anthropic (no API key required)")
+				printLine(fmt.Sprintf("    %s", def.Name))
+				printLine("    Native Anthropic (no API key required)")
 			} else {
 				if p.BaseURL != "" {
-					ui.PrintWhite(fmt.Sprintf("    URL   : %s", p.BaseURL))
+					printLine(fmt.Sprintf("    URL   : %s", p.BaseURL))
 				}
 				if p.Model != "" {
-					ui.PrintWhite(fmt.Sprintf("    Model : %s", p.Model))
+					printLine(fmt.Sprintf("    Model : %s", p.Model))
 				}
 			}
+			if line := lastHealthLine(state, name, now); line != "" {
+				printLine("    Health: " + line)
+			}
 			fmt.Println()
 		}
 	},
@@ -63,6 +81,18 @@ func init() {
 	rootCmd.AddCommand(listCmd)
 }
 
+// lastHealthLine summarizes the most recent recorded health check for
+// provider, or returns "" if none was recorded.
+func lastHealthLine(dir, provider string, now time.Time) string {
+	results, err := health.LoadHistory(dir, provider)
+	if err != nil || len(results) == 0 {
+		return ""
+	}
+	last := results[len(results)-1]
+
+	return fmt.Sprintf("%s, checked %s", last.Status, ui.RelativeTime(last.Time, now))
+}
+
 func sortProviderNames(provs map[string]config.Provider, defaultProvider string) []string {
 	names := make([]string, 0, len(provs))
 	for name := range provs {
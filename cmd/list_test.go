@@ -3,8 +3,10 @@ package cmd
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/health"
 )
 
 func TestListCommandNoConfig(t *testing.T) {
@@ -131,3 +133,19 @@ func TestSortProviderNamesMultipleProvidersWithDefault(t *testing.T) {
 		t.Error("sortProviderNames() should have 4 non-default providers")
 	}
 }
+
+func TestLastHealthLine(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if got := lastHealthLine(dir, "zai", now); got != "" {
+		t.Errorf("lastHealthLine() without history = %q, want empty", got)
+	}
+
+	if err := health.AppendHistory(dir, "zai", health.Result{Time: now.Add(-3 * time.Hour), Status: health.StatusOK}); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastHealthLine(dir, "zai", now); got != "ok, checked 3h ago" {
+		t.Errorf("lastHealthLine() = %q", got)
+	}
+}
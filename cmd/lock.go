@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/lockfile"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/secrets"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lockFilePath string
+	runLocked    bool
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock [provider]",
+	Short: "Pin a provider's base URL and model in kairo.lock",
+	Long: `Write kairo.lock, recording the provider, its base URL, the model the
+default harness is started with, and the fingerprint of its stored API key.
+Without a provider the default provider is locked.
+
+Commit the file with a project and run 'kairo run --locked' in CI: it starts
+the locked provider and refuses to run when its base URL or model no longer
+match the lock, so a pipeline never silently moves to another model. The
+lock carries a checksum and is refused when edited by hand; run 'kairo lock'
+again to update it.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runLock(cmd, args, time.Now()); err != nil {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	lockCmd.Flags().StringVar(&lockFilePath, "lock-file", lockfile.FileName, "Lock file to write")
+	rootCmd.AddCommand(lockCmd)
+}
+
+func runLock(cmd *cobra.Command, args []string, now time.Time) error {
+	cfg, err := loadConfigOrExit(cmd)
+	if err != nil || cfg == nil {
+		return nil
+	}
+
+	providerName := cfg.DefaultProvider
+	if len(args) > 0 {
+		providerName = args[0]
+	}
+	if providerName == "" {
+		return kairoerrors.NewError(kairoerrors.ConfigError, "no provider given and no default provider set").
+			WithContext("hint", "run 'kairo lock <provider>'")
+	}
+	provider, ok := cfg.Providers[providerName]
+	if !ok {
+		return kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("provider '%s' not configured", providerName)).
+			WithContext("hint", "run 'kairo list' to see configured providers")
+	}
+
+	cliCtx := CLIContextFromCmd(cmd)
+	fingerprint, err := storedKeyFingerprint(cliCtx, providerName)
+	if err != nil {
+		return err
+	}
+	provider, _ = lockedHarness(cfg, provider)
+	lock := providerLock(providerName, provider, fingerprint)
+	lock.CreatedAt = now.UTC()
+
+	f, err := os.Create(lockFilePath)
+	if err != nil {
+		return kairoerrors.FileError("failed to create lock file", lockFilePath, err)
+	}
+	if err := lockfile.Write(f, lock); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return kairoerrors.FileError("failed to write lock file", lockFilePath, err)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Locked %s to %s at %s in %s", providerName, lock.Model, lock.BaseURL, lockFilePath))
+
+	return nil
+}
+
+// lockedHarness returns the harness 'kairo run --locked' starts, the default
+// harness, and provider with the model it is started with.
+func lockedHarness(cfg *config.Config, provider config.Provider) (config.Provider, string) {
+	harnessToUse := resolveHarness("", cfg.DefaultHarness)
+	provider.Model = provider.ModelFor(harnessToUse)
+
+	return provider, harnessToUse
+}
+
+// providerLock returns the lock of provider as configured, with the API
+// key fingerprint given.
+func providerLock(providerName string, provider config.Provider, fingerprint string) lockfile.Lock {
+	return lockfile.Lock{
+		Provider:       providerName,
+		BaseURL:        provider.BaseURL,
+		Model:          provider.Model,
+		KeyFingerprint: fingerprint,
+	}
+}
+
+// storedKeyFingerprint returns the fingerprint of providerName's stored API
+// key, or "" when it needs none or none is stored. A command-backed entry is
+// fingerprinted as stored; the command is not run.
+func storedKeyFingerprint(cliCtx *CLIContext, providerName string) (string, error) {
+	if !providers.RequiresAPIKey(providerName) {
+		return "", nil
+	}
+	if _, err := os.Stat(filepath.Join(cliCtx.ConfigDir(), constants.SecretsFileName)); err != nil {
+		return "", nil
+	}
+	secretsResult, err := LoadSecrets(cliCtx, cliCtx.ConfigDir())
+	if err != nil {
+		return "", err
+	}
+	value, ok := lookupAPIKeyWithFallback(secretsResult.Secrets, providerName)
+	if !ok {
+		return "", nil
+	}
+
+	return secrets.Fingerprint(value), nil
+}
+
+// readLock reads and verifies the lock file at path.
+func readLock(path string) (lockfile.Lock, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return lockfile.Lock{}, kairoerrors.FileError("failed to open lock file", path, err).
+			WithContext("hint", "run 'kairo lock' to pin the current default provider")
+	}
+	defer f.Close()
+
+	return lockfile.Read(f)
+}
+
+// checkLock returns the configured provider locked by lock, as lockedProvider
+// does. A changed API key is only warned about.
+func checkLock(cliCtx *CLIContext, cfg *config.Config, lock lockfile.Lock) (config.Provider, error) {
+	provider, err := lockedProvider(cfg, lock)
+	if err != nil {
+		return config.Provider{}, err
+	}
+
+	if fingerprint, err := storedKeyFingerprint(cliCtx, lock.Provider); err == nil && lock.KeyChanged(fingerprint) {
+		ui.PrintWarn(fmt.Sprintf("the API key of '%s' changed since it was locked (%s, now %s)",
+			lock.Provider, lock.KeyFingerprint, fingerprint))
+	}
+
+	return provider, nil
+}
+
+// lockedProvider returns the configured provider locked by lock, with the
+// model of the harness 'kairo run --locked' starts, or an error naming every
+// field that drifted from the lock.
+func lockedProvider(cfg *config.Config, lock lockfile.Lock) (config.Provider, error) {
+	provider, ok := cfg.Providers[lock.Provider]
+	if !ok {
+		return config.Provider{}, kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("locked provider '%s' is not configured", lock.Provider)).
+			WithContext("hint", "run 'kairo setup --provider "+lock.Provider+"' or 'kairo lock' again")
+	}
+	provider, _ = lockedHarness(cfg, provider)
+	if err := lockDriftError(lock, provider); err != nil {
+		return config.Provider{}, err
+	}
+
+	return provider, nil
+}
+
+// lockDriftError returns an error naming every field of provider that
+// differs from lock, or nil when it still matches.
+func lockDriftError(lock lockfile.Lock, provider config.Provider) error {
+	drift := lock.Compare(providerLock(lock.Provider, provider, ""))
+	if len(drift) == 0 {
+		return nil
+	}
+	fields := make([]string, len(drift))
+	for i, d := range drift {
+		fields[i] = d.String()
+	}
+
+	return kairoerrors.NewError(kairoerrors.ConfigError,
+		fmt.Sprintf("the configuration of '%s' no longer matches %s: %s",
+			lock.Provider, lockFilePath, strings.Join(fields, "; "))).
+		WithContext("hint", "restore the configuration, or run 'kairo lock' to accept the change")
+}
+
+// runLockedProvider starts the provider pinned in the lock file with the
+// default harness, after checking the configuration still matches it.
+func runLockedProvider(cmd *cobra.Command, harnessArgs []string) error {
+	lock, err := readLock(lockFilePath)
+	if err != nil {
+		return err
+	}
+	pol, id, err := loadPolicy()
+	if err != nil {
+		return err
+	}
+	if err := checkProviderPolicy(pol, id, lock.Provider); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfigOrExit(cmd)
+	if err != nil || cfg == nil {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	if _, err := checkLock(cliCtx, cfg, lock); err != nil {
+		return err
+	}
+	t, err := resolveLockedLaunch(cfg, lock, harnessArgs)
+	if err != nil {
+		return err
+	}
+	startLaunch(cmd, cliCtx, cfg, t)
+
+	return nil
+}
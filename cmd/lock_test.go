@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/lockfile"
+)
+
+func TestRunLock(t *testing.T) {
+	defer func() { lockFilePath = lockfile.FileName }()
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, `default_provider: zai
+providers:
+  zai:
+    name: Z.AI
+    base_url: https://api.z.ai/api/anthropic
+    model: glm-4.7
+  minimax:
+    name: MiniMax
+    base_url: https://api.minimax.io/anthropic
+    model: MiniMax-M2
+`)
+	cmd, _ := explainTestCmd(t, dir)
+	cliCtx := CLIContextFromCmd(cmd)
+	if err := storeProviderSecret(cliCtx, dir, "zai", "zai-test-key-0123456789abcdef0123456789", "set_secret"); err != nil {
+		t.Fatal(err)
+	}
+	lockFilePath = filepath.Join(t.TempDir(), lockfile.FileName)
+
+	if err := runLock(cmd, nil, time.Now()); err != nil {
+		t.Fatalf("runLock() error = %v", err)
+	}
+	lock, err := readLock(lockFilePath)
+	if err != nil {
+		t.Fatalf("readLock() error = %v", err)
+	}
+	if lock.Provider != "zai" || lock.Model != "glm-4.7" || lock.BaseURL != "https://api.z.ai/api/anthropic" {
+		t.Errorf("lock = %+v, want the default provider", lock)
+	}
+	if want, _ := storedKeyFingerprint(cliCtx, "zai"); want == "" || lock.KeyFingerprint != want {
+		t.Errorf("KeyFingerprint = %q, want %q", lock.KeyFingerprint, want)
+	}
+
+	if err := runLock(cmd, []string{"minimax"}, time.Now()); err != nil {
+		t.Fatalf("runLock(minimax) error = %v", err)
+	}
+	if lock, _ := readLock(lockFilePath); lock.Provider != "minimax" || lock.KeyFingerprint != "" {
+		t.Errorf("lock = %+v, want minimax without a key", lock)
+	}
+
+	if err := runLock(cmd, []string{"missing"}, time.Now()); err == nil {
+		t.Error("runLock() of an unconfigured provider error = nil")
+	}
+}
+
+func TestCheckLock(t *testing.T) {
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(t.TempDir())
+	lock := lockfile.Lock{Provider: "zai", BaseURL: "https://api.z.ai/api/anthropic", Model: "glm-4.7"}
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"zai": {Name: "Z.AI", BaseURL: "https://api.z.ai/api/anthropic/", Model: "glm-4.7"},
+	}}
+
+	provider, err := checkLock(cliCtx, cfg, lock)
+	if err != nil || provider.Model != "glm-4.7" {
+		t.Fatalf("checkLock() = %+v, %v; want the configured provider", provider, err)
+	}
+
+	cfg.Providers["zai"] = config.Provider{BaseURL: "https://proxy.example.com", Model: "glm-5"}
+	_, err = checkLock(cliCtx, cfg, lock)
+	if err == nil {
+		t.Fatal("checkLock() of a drifted provider error = nil")
+	}
+	for _, want := range []string{"model: locked glm-4.7, configured glm-5", "base_url: locked https://api.z.ai"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("checkLock() error = %v, want it to mention %q", err, want)
+		}
+	}
+
+	delete(cfg.Providers, "zai")
+	if _, err := checkLock(cliCtx, cfg, lock); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("checkLock() of a missing provider error = %v", err)
+	}
+}
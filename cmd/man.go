@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var manDir string
+
+var manCmd = &cobra.Command{
+	Use:   "man",
+	Short: "Write man pages for every command and help topic",
+	Long: `Write a section 1 man page for every kairo command and a section 7 page for
+every help topic (see 'kairo help') into --dir. The pages are generated from
+the command metadata compiled into this binary, so no network access is
+needed. For example:
+
+  kairo man --dir ~/.local/share/man/man1
+  man kairo-secrets-set`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		n, err := writeManPages(cmd.Root(), manDir, manDate())
+		if err != nil {
+			printError(err)
+
+			return
+		}
+		ui.PrintSuccess(fmt.Sprintf("Wrote %d man pages to %s", n, manDir))
+	},
+}
+
+func init() {
+	manCmd.Flags().StringVarP(&manDir, "dir", "d", ".", "Directory to write the man pages to")
+	rootCmd.AddCommand(manCmd)
+}
+
+// manDate returns the build date for man page headers, falling back to today
+// for development builds.
+func manDate() time.Time {
+	if t, err := time.Parse(time.RFC3339, version.Date); err == nil {
+		return t
+	}
+	if t, err := time.Parse(time.DateOnly, version.Date); err == nil {
+		return t
+	}
+
+	return time.Now()
+}
+
+// writeManPages renders a page for root, each visible subcommand, and each
+// help topic into dir and returns the number of pages written.
+func writeManPages(root *cobra.Command, dir string, date time.Time) (int, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, kairoerrors.WrapError(kairoerrors.FileSystemError, "failed to create man page directory", err).
+			WithContext("path", dir)
+	}
+
+	var pages int
+	for _, page := range manPages(root, date) {
+		var buf bytes.Buffer
+		page.render(&buf)
+		path := filepath.Join(dir, page.name)
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			return pages, kairoerrors.WrapError(kairoerrors.FileSystemError, "failed to write man page", err).
+				WithContext("path", path)
+		}
+		pages++
+	}
+
+	return pages, nil
+}
+
+// manPage is one man page file and the function that renders it.
+type manPage struct {
+	name   string
+	render func(io.Writer)
+}
+
+// manPages lists the pages 'kairo man' writes: one per available command
+// under root, then one per help topic.
+func manPages(root *cobra.Command, date time.Time) []manPage {
+	var pages []manPage
+
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		if c != root && (!c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand()) {
+			return
+		}
+		pages = append(pages, manPage{
+			name:   manPageName(c) + ".1",
+			render: func(w io.Writer) { renderCommandManPage(w, c, date) },
+		})
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+
+	for _, t := range helpTopics {
+		pages = append(pages, manPage{
+			name:   "kairo-" + t.Name + ".7",
+			render: func(w io.Writer) { renderTopicManPage(w, t, date) },
+		})
+	}
+
+	return pages
+}
+
+// manPageName returns the page name for c, e.g. "kairo-secrets-set".
+func manPageName(c *cobra.Command) string {
+	return strings.ReplaceAll(c.CommandPath(), " ", "-")
+}
+
+func renderCommandManPage(w io.Writer, c *cobra.Command, date time.Time) {
+	name := manPageName(c)
+	writeManHeader(w, name, 1, date)
+
+	fmt.Fprintf(w, ".SH NAME\n%s \\- %s\n", roffEscape(name), roffEscape(c.Short))
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B %s\n", roffEscape(c.CommandPath()))
+	if _, args, ok := strings.Cut(c.Use, " "); ok {
+		fmt.Fprintf(w, "%s\n", roffEscape(args))
+	}
+	if c.HasAvailableFlags() {
+		fmt.Fprintln(w, "[flags]")
+	}
+
+	desc := c.Long
+	if desc == "" {
+		desc = c.Short
+	}
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	writeRoffText(w, desc)
+
+	writeManFlags(w, "OPTIONS", c.NonInheritedFlags())
+	writeManFlags(w, "GLOBAL OPTIONS", c.InheritedFlags())
+
+	if c.Example != "" {
+		fmt.Fprintln(w, ".SH EXAMPLES")
+		writeRoffText(w, c.Example)
+	}
+
+	var seeAlso []string
+	if c.HasParent() {
+		seeAlso = append(seeAlso, manPageName(c.Parent())+"(1)")
+	}
+	for _, sub := range c.Commands() {
+		if sub.IsAvailableCommand() && !sub.IsAdditionalHelpTopicCommand() {
+			seeAlso = append(seeAlso, manPageName(sub)+"(1)")
+		}
+	}
+	if !c.HasParent() {
+		for _, t := range helpTopics {
+			seeAlso = append(seeAlso, "kairo-"+t.Name+"(7)")
+		}
+	}
+	writeManSeeAlso(w, seeAlso)
+}
+
+func renderTopicManPage(w io.Writer, t helpTopic, date time.Time) {
+	name := "kairo-" + t.Name
+	writeManHeader(w, name, 7, date)
+
+	fmt.Fprintf(w, ".SH NAME\n%s \\- %s\n", roffEscape(name), roffEscape(t.Short))
+	for _, s := range t.Sections {
+		fmt.Fprintf(w, ".SH %s\n", roffEscape(strings.ToUpper(s.Title)))
+		writeRoffText(w, s.Body)
+	}
+
+	seeAlso := make([]string, 0, len(t.SeeAlso))
+	for _, e := range t.SeeAlso {
+		if _, ok := lookupHelpTopic(e); ok {
+			seeAlso = append(seeAlso, "kairo-"+e+"(7)")
+		} else {
+			seeAlso = append(seeAlso, "kairo-"+strings.ReplaceAll(e, " ", "-")+"(1)")
+		}
+	}
+	writeManSeeAlso(w, seeAlso)
+}
+
+func writeManHeader(w io.Writer, name string, section int, date time.Time) {
+	fmt.Fprintf(w, ".TH \"%s\" \"%d\" \"%s\" \"kairo %s\" \"Kairo Manual\"\n",
+		roffEscape(strings.ToUpper(name)), section, date.Format(time.DateOnly), roffEscape(version.Version))
+	fmt.Fprintln(w, ".nh\n.ad l")
+}
+
+func writeManFlags(w io.Writer, title string, flags *pflag.FlagSet) {
+	if !flags.HasAvailableFlags() {
+		return
+	}
+	fmt.Fprintf(w, ".SH %s\n", title)
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden {
+			return
+		}
+		fmt.Fprintln(w, ".TP")
+		if f.Shorthand != "" {
+			fmt.Fprintf(w, "\\fB\\-%s\\fR, ", f.Shorthand)
+		}
+		fmt.Fprintf(w, "\\fB\\-\\-%s\\fR", roffEscape(f.Name))
+		if varname, _ := pflag.UnquoteUsage(f); varname != "" {
+			fmt.Fprintf(w, " \\fI%s\\fR", roffEscape(varname))
+		}
+		fmt.Fprintln(w)
+		_, usage := pflag.UnquoteUsage(f)
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" && f.DefValue != "0s" && f.DefValue != "[]" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(w, roffEscape(usage))
+	})
+}
+
+func writeManSeeAlso(w io.Writer, refs []string) {
+	if len(refs) == 0 {
+		return
+	}
+	for i, r := range refs {
+		refs[i] = "\\fB" + roffEscape(r) + "\\fR"
+	}
+	fmt.Fprintf(w, ".SH SEE ALSO\n%s\n", strings.Join(refs, ", "))
+}
+
+// writeRoffText writes help text as roff paragraphs. Lines indented by at
+// least two spaces are kept verbatim in a no-fill block.
+func writeRoffText(w io.Writer, text string) {
+	verbatim := false
+	for i, p := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if i > 0 {
+			fmt.Fprintln(w, ".PP")
+		}
+		for _, line := range strings.Split(p, "\n") {
+			indented := strings.HasPrefix(line, "  ")
+			switch {
+			case indented && !verbatim:
+				fmt.Fprintln(w, ".RS 4\n.nf")
+				verbatim = true
+			case !indented && verbatim:
+				fmt.Fprintln(w, ".fi\n.RE")
+				verbatim = false
+			}
+			if verbatim {
+				line = strings.TrimPrefix(line, "  ")
+			}
+			fmt.Fprintln(w, roffEscape(line))
+		}
+		if verbatim {
+			fmt.Fprintln(w, ".fi\n.RE")
+			verbatim = false
+		}
+	}
+}
+
+// roffEscape escapes backslashes and hyphens and protects lines that would
+// otherwise start with a roff control character.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+
+	return s
+}
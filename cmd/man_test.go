@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteManPages(t *testing.T) {
+	dir := t.TempDir()
+	date := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	n, err := writeManPages(rootCmd, dir, date)
+	if err != nil {
+		t.Fatalf("writeManPages() error = %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if n != len(entries) || n < len(helpTopics)+2 {
+		t.Fatalf("writeManPages() = %d pages, %d files", n, len(entries))
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "kairo-secrets-set.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`.TH "KAIRO\-SECRETS\-SET" "1" "2026-01-02"`,
+		".SH NAME\nkairo\\-secrets\\-set \\- ",
+		`\fB\-\-via\-browser\fR`,
+		".SH GLOBAL OPTIONS",
+		`\fBkairo\-secrets(1)\fR`,
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("kairo-secrets-set.1 missing %q:\n%s", want, page)
+		}
+	}
+
+	topic, err := os.ReadFile(filepath.Join(dir, "kairo-security-model.7"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(topic), ".SH AT RUN TIME") {
+		t.Errorf("kairo-security-model.7 missing section:\n%s", topic)
+	}
+}
+
+func TestWriteRoffText(t *testing.T) {
+	var b strings.Builder
+	writeRoffText(&b, "First line\n.starts with a dot\n\nRun:\n\n  kairo man --dir x\n\nDone")
+
+	want := "First line\n\\&.starts with a dot\n.PP\nRun:\n.PP\n.RS 4\n.nf\nkairo man \\-\\-dir x\n.fi\n.RE\n.PP\nDone\n"
+	if b.String() != want {
+		t.Errorf("writeRoffText() =\n%q\nwant\n%q", b.String(), want)
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/execution"
+	"github.com/dkmnx/kairo/internal/mockprovider"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mockListen    string
+	mockLatency   time.Duration
+	mockErrorRate float64
+	mockReply     string
+)
+
+var mockProviderCmd = &cobra.Command{
+	Use:   "mock-provider",
+	Short: "Run a local fake Anthropic-compatible provider",
+	Long: `Serve a minimal Anthropic-compatible Messages API that returns canned
+responses, for exercising switching and failure handling without spending
+tokens.
+
+Requests without an x-api-key or Authorization header are rejected. With
+--error-rate, that fraction of requests fails with a 529 overloaded error.
+
+Point a harness at it directly, for example:
+
+  ANTHROPIC_BASE_URL=http://127.0.0.1:8899 ANTHROPIC_AUTH_TOKEN=test claude`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := validateMockProviderFlags(); err != nil {
+			printError(err)
+
+			return
+		}
+
+		ctx, cancel, stopSig := execution.StartSession(commandContext(cmd))
+		defer cancel()
+		defer stopSig()
+
+		if err := serveMockProvider(ctx, cmd); err != nil {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	mockProviderCmd.Flags().StringVar(&mockListen, "listen", "127.0.0.1:8899", "Address to listen on")
+	mockProviderCmd.Flags().DurationVar(&mockLatency, "latency", 0, "Delay added to every response (e.g. 200ms)")
+	mockProviderCmd.Flags().Float64Var(&mockErrorRate, "error-rate", 0,
+		"Fraction of requests (0-1) answered with a 529 overloaded error")
+	mockProviderCmd.Flags().StringVar(&mockReply, "reply", mockprovider.DefaultReply, "Assistant text returned by /v1/messages")
+	rootCmd.AddCommand(mockProviderCmd)
+}
+
+func validateMockProviderFlags() error {
+	if mockErrorRate < 0 || mockErrorRate > 1 {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("--error-rate must be between 0 and 1 (got %g)", mockErrorRate))
+	}
+	if mockLatency < 0 {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			"--latency cannot be negative")
+	}
+
+	return nil
+}
+
+// serveMockProvider listens on --listen and serves the mock API until ctx is
+// canceled.
+func serveMockProvider(ctx context.Context, cmd *cobra.Command) error {
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", mockListen)
+	if err != nil {
+		return kairoerrors.WrapError(kairoerrors.NetworkError,
+			"failed to listen", err).WithContext("address", mockListen)
+	}
+
+	server := &http.Server{
+		Handler: mockprovider.NewHandler(mockprovider.Options{
+			Latency:   mockLatency,
+			ErrorRate: mockErrorRate,
+			Reply:     mockReply,
+			Log:       os.Stdout,
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	ui.PrintSuccess(fmt.Sprintf("Mock provider listening on http://%s", listener.Addr()))
+	ui.PrintInfo("Press Ctrl+C to stop")
+
+	if err := server.Serve(listener); err != nil && !stderrors.Is(err, http.ErrServerClosed) {
+		return kairoerrors.WrapError(kairoerrors.NetworkError, "mock provider stopped", err)
+	}
+	cmd.Println("Mock provider stopped")
+
+	return nil
+}
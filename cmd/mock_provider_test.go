@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestValidateMockProviderFlags(t *testing.T) {
+	defer func() { mockErrorRate, mockLatency = 0, 0 }()
+
+	tests := []struct {
+		name    string
+		rate    float64
+		latency time.Duration
+		wantErr bool
+	}{
+		{"defaults", 0, 0, false},
+		{"valid", 0.1, 200 * time.Millisecond, false},
+		{"rate above one", 1.5, 0, true},
+		{"negative rate", -0.1, 0, true},
+		{"negative latency", 0, -time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockErrorRate, mockLatency = tt.rate, tt.latency
+			if err := validateMockProviderFlags(); (err != nil) != tt.wantErr {
+				t.Errorf("validateMockProviderFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServeMockProvider(t *testing.T) {
+	l, err := (&net.ListenConfig{}).Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	originalListen := mockListen
+	mockListen = addr
+	defer func() { mockListen = originalListen }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveMockProvider(ctx, &cobra.Command{}) }()
+
+	var resp *http.Response
+	for range 50 {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost,
+			"http://"+addr+"/v1/messages", strings.NewReader(`{"model":"m"}`))
+		req.Header.Set("x-api-key", "test")
+		resp, err = http.DefaultClient.Do(req)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("mock provider never became reachable: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serveMockProvider() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveMockProvider() did not stop after cancel")
+	}
+}
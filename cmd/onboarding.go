@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/claudesettings"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/policy"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/yarlson/tap"
+)
+
+// onboardingHarnesses are the harnesses onboarding looks for, in the order
+// it prefers them.
+var onboardingHarnesses = []string{harness.Claude, harness.Qwen, harness.Pi, harness.Crush}
+
+// shouldOnboard reports whether a launch without any configuration should
+// start onboarding rather than point at 'kairo setup': only when someone is
+// at the terminal to answer, prompts are on, and the policy lets them run
+// setup.
+func shouldOnboard() bool {
+	if ui.Quiet() || nonInteractive() || terminalWriter(os.Stdin) == nil || terminalWriter(os.Stdout) == nil {
+		return false
+	}
+	pol, id, err := loadPolicy()
+
+	return err == nil && pol.AllowsCommand(id, "setup")
+}
+
+// runOnboarding guides the first run of kairo through what 'kairo setup'
+// and 'kairo import' do one piece at a time: it looks for installed
+// harnesses, creates the encryption key, offers to import providers and keys
+// found in Claude Code settings and the environment, suggests a provider to
+// configure otherwise, and finally shows where kairo keeps its files.
+func runOnboarding(cmd *cobra.Command, cliCtx *CLIContext, dir string) {
+	fmt.Println()
+	tap.Intro("Welcome to kairo", tap.MessageOptions{
+		Hint: "Nothing is configured yet; a few steps set up your first provider",
+	})
+
+	defaultHarness, ok := onboardHarness(installedHarnesses(cliCtx.Deps()))
+	if !ok {
+		tap.Cancel("Onboarding canceled")
+
+		return
+	}
+
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		printError(err)
+
+		return
+	}
+	cfg, err := LoadConfig(cliCtx, dir)
+	if err != nil {
+		printError(kairoerrors.WrapError(kairoerrors.ConfigError, "error loading config", err))
+
+		return
+	}
+	secretsResult, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		handleSecretsError(err)
+
+		return
+	}
+	tap.Message("API keys are stored encrypted in "+constants.SecretsFileName, tap.MessageOptions{
+		Hint: "Encryption key: " + secretsResult.KeyPath,
+	})
+
+	pol, id, err := loadPolicy()
+	if err != nil {
+		printError(err)
+
+		return
+	}
+	cfg.DefaultHarness = defaultHarness
+
+	name, err := importOnboardingProviders(cliCtx, dir, cfg, secretsResult, pol, id)
+	if err == nil && name == "" {
+		name, err = setupOnboardingProvider(cliCtx, dir, cfg, secretsResult, pol, id)
+	}
+	switch {
+	case errors.Is(err, errSetupInterrupted):
+		tap.Cancel("Onboarding interrupted")
+		if _, statErr := os.Stat(setupProgressPath(dir)); statErr == nil {
+			ui.PrintInfo("Your answers so far are saved; run 'kairo setup --resume' to continue")
+		}
+
+		return
+	case err != nil:
+		tap.Cancel(err.Error())
+
+		return
+	case name == "":
+		tap.Cancel("Onboarding canceled")
+		ui.PrintInfo("Run 'kairo setup' to configure a provider later")
+
+		return
+	}
+
+	fmt.Println()
+	ui.PrintWhite("Where kairo keeps its files:")
+	writeOnboardingFiles(cmd.OutOrStdout(), dir, cfg, secretsResult)
+	fmt.Println()
+	ui.PrintInfo(fmt.Sprintf("Run 'kairo' to start %s with %s, or 'kairo --help' for more",
+		harness.Resolve("", cfg.DefaultHarness), name))
+}
+
+// installedHarnesses returns the supported harnesses found in PATH.
+func installedHarnesses(deps *Deps) []string {
+	var installed []string
+	for _, h := range onboardingHarnesses {
+		if path, err := deps.Process.LookPath(h); err == nil && path != "" {
+			installed = append(installed, h)
+		}
+	}
+
+	return installed
+}
+
+// defaultHarnessFor returns the harness to make the default given those
+// installed: none when claude, already the default, is installed or nothing
+// is, and the only one otherwise. ask reports that several are installed
+// and the user should choose.
+func defaultHarnessFor(installed []string) (h string, ask bool) {
+	switch {
+	case len(installed) == 0 || slices.Contains(installed, harness.Claude):
+		return "", false
+	case len(installed) == 1:
+		return installed[0], false
+	default:
+		return "", true
+	}
+}
+
+// onboardHarness reports the harnesses found and returns the one to make
+// the default, or "" for claude. It returns false if the user cancels the
+// choice.
+func onboardHarness(installed []string) (string, bool) {
+	if len(installed) == 0 {
+		tap.Message("No supported harness found in PATH", tap.MessageOptions{
+			Hint: harness.InstallHint(harness.Claude, runtime.GOOS) + "; kairo also supports qwen, pi, and crush",
+		})
+
+		return "", true
+	}
+	h, ask := defaultHarnessFor(installed)
+	found := "Found harnesses: " + strings.Join(installed, ", ")
+	if !ask {
+		if h == "" {
+			tap.Message(found)
+		} else {
+			tap.Message(found, tap.MessageOptions{Hint: h + " becomes the default harness"})
+		}
+
+		return h, true
+	}
+	tap.Message(found)
+	h = tap.Select(promptContext(), tap.SelectOptions[string]{
+		Message: "Select the harness kairo starts by default",
+		Options: buildProviderListOptions(installed),
+	})
+
+	return h, h != ""
+}
+
+// providerEnvKey returns the variable holding a built-in provider's own API
+// key, such as ZAI_API_KEY, when it is set. The ANTHROPIC_ credentials are
+// left to the Claude Code settings import, which knows their base URL.
+func providerEnvKey(name string) (string, string, bool) {
+	names := []string{harness.APIKeyEnvVar(name)}
+	if envVar, ok := providers.APIKeyEnvVarFor(name); ok {
+		names = append(names, envVar)
+	}
+	for _, envVar := range slices.Compact(names) {
+		if value := strings.TrimSpace(os.Getenv(envVar)); value != "" {
+			return envVar, value, true
+		}
+	}
+
+	return "", "", false
+}
+
+// suggestProvider returns the provider onboarding suggests among options,
+// and why: the first whose API key is set in the environment, or the first
+// in display order.
+func suggestProvider(options []string) (string, string) {
+	for _, name := range options {
+		if !providers.IsBuiltInProvider(name) || !providers.RequiresAPIKey(name) {
+			continue
+		}
+		if envVar, _, ok := providerEnvKey(name); ok {
+			return name, envVar + " is set in your environment"
+		}
+	}
+	if len(options) == 0 {
+		return "", ""
+	}
+
+	return options[0], "other providers can be added later with 'kairo setup'"
+}
+
+// importOnboardingProviders offers to import the provider setups found in
+// Claude Code settings and the environment, returning the first imported.
+func importOnboardingProviders(cliCtx *CLIContext, dir string, cfg *config.Config, secretsResult SecretsResult,
+	pol *policy.Policy, id policy.Identity,
+) (string, error) {
+	settingsPath, err := claudesettings.Path()
+	if err != nil {
+		return "", nil
+	}
+	settings, err := claudesettings.Load(settingsPath)
+	if err != nil {
+		ui.PrintWarn(kairoerrors.Describe(err))
+
+		return "", nil
+	}
+
+	var first string
+	for _, d := range claudesettings.Detect(settings, os.Environ()) {
+		name, provider, err := importedProvider(d, "")
+		if err != nil || !pol.AllowsProvider(id, name) {
+			continue
+		}
+		if _, exists := cfg.Providers[name]; exists {
+			continue
+		}
+
+		printDetection(name, provider, d)
+		if !tap.Confirm(promptContext(), tap.ConfirmOptions{
+			Message:      fmt.Sprintf("Import as provider '%s'?", name),
+			InitialValue: true,
+		}) {
+			continue
+		}
+		if err := saveImportedProvider(cliCtx, dir, cfg, secretsResult, name, provider, d.AuthToken); err != nil {
+			return "", err
+		}
+		ui.PrintSuccess(fmt.Sprintf("Imported provider '%s'", name))
+		if first == "" {
+			first = name
+		}
+	}
+
+	return first, nil
+}
+
+// setupOnboardingProvider suggests a provider, lets the user pick one the
+// policy allows, and configures it, offering a key found in the environment
+// in place of the key prompt.
+func setupOnboardingProvider(cliCtx *CLIContext, dir string, cfg *config.Config, secretsResult SecretsResult,
+	pol *policy.Policy, id policy.Identity,
+) (string, error) {
+	options := slices.DeleteFunc(providers.ProviderList(), func(name string) bool {
+		return !pol.AllowsProvider(id, name)
+	})
+	suggested, reason := suggestProvider(options)
+	if suggested == "" {
+		return "", kairoerrors.NewError(kairoerrors.ValidationError, "no provider is allowed on this machine").
+			WithContext("policy", policy.Path)
+	}
+
+	tap.Message("Suggested provider: "+suggested, tap.MessageOptions{Hint: reason})
+	name := tap.Select(promptContext(), tap.SelectOptions[string]{
+		Message:      "Select provider to configure",
+		Options:      buildProviderListOptions(options),
+		InitialValue: &suggested,
+	})
+	if name == "" {
+		return "", nil
+	}
+
+	var apiKey, adopted string
+	if envVar, value, ok := providerEnvKey(name); ok {
+		if tap.Confirm(promptContext(), tap.ConfirmOptions{
+			Message:      fmt.Sprintf("Store %s from the environment, encrypted, as the API key?", envVar),
+			InitialValue: true,
+		}) {
+			apiKey, adopted = value, envVar
+		}
+	}
+
+	name, err := configureProvider(ProviderSetup{
+		CLIContext:   cliCtx,
+		ConfigDir:    dir,
+		Cfg:          cfg,
+		ProviderName: name,
+		Secrets:      secretsResult.Secrets,
+		SecretsPath:  secretsResult.SecretsPath,
+		KeyPath:      secretsResult.KeyPath,
+		APIKey:       apiKey,
+		// Onboarding always runs at a terminal.
+		OfferTestPrompt: true,
+	})
+	if err == nil && adopted != "" {
+		ui.PrintInfo(envKeyRemovalHint(adopted))
+	}
+
+	return name, err
+}
+
+// writeOnboardingFiles lists where kairo keeps its configuration, secrets,
+// key, and state.
+func writeOnboardingFiles(w io.Writer, dir string, cfg *config.Config, secretsResult SecretsResult) {
+	ui.PrintValue(w, "  Config ", filepath.Join(dir, config.BaseFileName))
+	ui.PrintValue(w, "  Secrets", secretsResult.SecretsPath+" (encrypted API keys)")
+	ui.PrintValue(w, "  Key    ", secretsResult.KeyPath+" (back it up: the secrets cannot be read without it)")
+	if stateDir, err := config.StateDir(dir, cfg); err == nil {
+		ui.PrintValue(w, "  State  ", stateDir+" (logs, health checks, and usage)")
+	}
+}
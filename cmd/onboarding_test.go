@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/harness"
+)
+
+func TestInstalledHarnesses(t *testing.T) {
+	deps := testDeps(func(mp *mockProcess, _ *mockWrapper, _ *mockUpdate) {
+		mp.LookPathFn = func(name string) (string, error) {
+			if name == harness.Pi || name == harness.Crush {
+				return "/usr/bin/" + name, nil
+			}
+
+			return "", errors.New("not found")
+		}
+	})
+
+	got := installedHarnesses(deps)
+	if want := []string{harness.Pi, harness.Crush}; !slices.Equal(got, want) {
+		t.Errorf("installedHarnesses() = %v, want %v", got, want)
+	}
+}
+
+func TestDefaultHarnessFor(t *testing.T) {
+	tests := []struct {
+		installed []string
+		want      string
+		wantAsk   bool
+	}{
+		{nil, "", false},
+		{[]string{harness.Claude, harness.Pi}, "", false},
+		{[]string{harness.Qwen}, harness.Qwen, false},
+		{[]string{harness.Pi, harness.Crush}, "", true},
+	}
+	for _, tt := range tests {
+		got, ask := defaultHarnessFor(tt.installed)
+		if got != tt.want || ask != tt.wantAsk {
+			t.Errorf("defaultHarnessFor(%v) = %q, %v, want %q, %v", tt.installed, got, ask, tt.want, tt.wantAsk)
+		}
+	}
+}
+
+func TestSuggestProvider(t *testing.T) {
+	options := []string{"zai", "minimax", "deepseek"}
+	for _, name := range options {
+		t.Setenv(harness.APIKeyEnvVar(name), "")
+	}
+
+	if got, _ := suggestProvider(options); got != "zai" {
+		t.Errorf("suggestProvider() = %q, want the first provider without keys in the environment", got)
+	}
+
+	t.Setenv("MINIMAX_API_KEY", "sk-test")
+	got, reason := suggestProvider(options)
+	if got != "minimax" || !strings.Contains(reason, "MINIMAX_API_KEY") {
+		t.Errorf("suggestProvider() = %q, %q, want minimax because MINIMAX_API_KEY is set", got, reason)
+	}
+
+	if got, _ := suggestProvider(nil); got != "" {
+		t.Errorf("suggestProvider(nil) = %q, want none", got)
+	}
+}
+
+func TestProviderEnvKey_IgnoresAnthropicCredentials(t *testing.T) {
+	t.Setenv("ZAI_API_KEY", "")
+	t.Setenv("ANTHROPIC_AUTH_TOKEN", "sk-ant-test")
+
+	if envVar, _, ok := providerEnvKey("zai"); ok {
+		t.Errorf("providerEnvKey(zai) = %s, want only the provider's own variable", envVar)
+	}
+}
+
+func TestWriteOnboardingFiles(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+	writeOnboardingFiles(&out, dir, &config.Config{StateDir: filepath.Join(dir, "state")}, SecretsResult{
+		SecretsPath: filepath.Join(dir, "secrets.age"),
+		KeyPath:     filepath.Join(dir, "age.key"),
+	})
+
+	for _, want := range []string{
+		filepath.Join(dir, config.BaseFileName),
+		filepath.Join(dir, "secrets.age"),
+		filepath.Join(dir, "age.key") + " (back it up",
+		filepath.Join(dir, "state"),
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not mention %s:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestShouldOnboard_NotATerminal(t *testing.T) {
+	if shouldOnboard() {
+		t.Error("shouldOnboard() = true without a terminal, want the 'kairo setup' hint instead")
+	}
+}
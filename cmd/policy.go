@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+// policyExempt lists the commands a policy cannot deny, so that a
+// restricted user can still find out what kairo is and how to use it.
+// Hidden commands whose names start with "__", such as cobra's completion
+// requests, are exempt too.
+var policyExempt = []string{"help", "version"}
+
+// loadPolicy reads the policy file an administrator may have deployed,
+// returning nil if there is none, and who it is applied to.
+func loadPolicy() (*policy.Policy, policy.Identity, error) {
+	pol, err := policy.Load(policy.Path)
+	if err != nil {
+		return nil, policy.Identity{}, kairoerrors.WrapError(kairoerrors.ConfigError,
+			"cannot apply the kairo policy", err).
+			WithContext("hint", "ask the administrator of this machine to fix "+policy.Path)
+	}
+	if pol == nil {
+		return nil, policy.Identity{}, nil
+	}
+	id, err := policy.CurrentIdentity()
+
+	return pol, id, err
+}
+
+// enforcePolicy checks cmd, and the providers it names, against the policy.
+// It runs before every command.
+func enforcePolicy(cmd *cobra.Command, cliCtx *CLIContext, args []string) error {
+	pol, id, err := loadPolicy()
+	if err != nil || pol == nil {
+		return err
+	}
+
+	command := policyCommand(cmd)
+	exempt := command == "" || strings.HasPrefix(command, "__") || slices.Contains(policyExempt, command)
+	if !exempt && !pol.AllowsCommand(id, command) {
+		return policyDenied(fmt.Sprintf("'kairo %s' is not allowed on this machine", command))
+	}
+	for _, name := range policyProviders(cmd, cliCtx, args) {
+		if err := checkProviderPolicy(pol, id, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkProviderPolicy returns an error if id may not use the provider name.
+func checkProviderPolicy(pol *policy.Policy, id policy.Identity, name string) error {
+	if name == "" || pol.AllowsProvider(id, name) {
+		return nil
+	}
+
+	return policyDenied(fmt.Sprintf("provider '%s' is not allowed on this machine", name))
+}
+
+// allowedProviders returns the providers of names the policy lets the
+// current user use, in the order given. Commands that act on every
+// configured provider filter their list with it, so that the key of a
+// denied provider is never resolved.
+func allowedProviders(names []string) ([]string, error) {
+	pol, id, err := loadPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(slices.Clone(names), func(name string) bool {
+		return !pol.AllowsProvider(id, name)
+	}), nil
+}
+
+func policyDenied(msg string) error {
+	return kairoerrors.NewError(kairoerrors.ValidationError, msg).
+		WithContext("policy", policy.Path).
+		WithContext("hint", "the administrator of this machine restricts kairo; ask them for access")
+}
+
+// policyCommand returns cmd's path without "kairo", such as "secrets set",
+// or "" for kairo itself.
+func policyCommand(cmd *cobra.Command) string {
+	return strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()), " ")
+}
+
+// policyProviders returns the providers an invocation of cmd would use:
+// the provider a launch resolves to, and the providers other commands take
+// as arguments or in --providers.
+func policyProviders(cmd *cobra.Command, cliCtx *CLIContext, args []string) []string {
+	switch cmd {
+	case rootCmd:
+		return []string{launchProvider(cliCtx, args)}
+	case spawnCmd:
+		return spawnProviders
+	case compareCmd:
+		return compareProviders
+	case setupCmd:
+		return []string{setupProvider}
+	case rotateCmd:
+		return []string{rotateProvider}
+	case secretsRevealCmd:
+		if len(args) == 1 {
+			return []string{revealProvider(policyConfig(cliCtx), args[0])}
+		}
+	case defaultCmd, deleteCmd, doctorCmd, secretsSetCmd, secretsValidateCmd, statusCmd, usageCmd,
+		snapshotEnvCmd, providersShowCmd, providersExportCmd, providersBundleCmd, providersDisableCmd, providersEnableCmd:
+		return args
+	}
+
+	return nil
+}
+
+// policyConfig returns the configuration providers are resolved against,
+// or nil if there is none.
+func policyConfig(cliCtx *CLIContext) *config.Config {
+	if cliCtx == nil || cliCtx.ConfigDir() == "" {
+		return nil
+	}
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), cliCtx.ConfigDir())
+	if err != nil {
+		return nil
+	}
+
+	return cfg
+}
+
+// launchProvider returns the provider a launch with args resolves to, as
+// resolveProviderAndArgs does, or "" if it resolves to none.
+func launchProvider(cliCtx *CLIContext, args []string) string {
+	cfg := policyConfig(cliCtx)
+	if cfg == nil {
+		return ""
+	}
+	if len(args) == 0 || cliCtx.DefaultProviderExplicit() {
+		return cfg.DefaultProvider
+	}
+	kairoArgs, _ := splitArgs(args)
+	if len(kairoArgs) == 0 || strings.HasPrefix(kairoArgs[0], "-") {
+		return cfg.DefaultProvider
+	}
+	if harnessFlag != "" && !isKnownProvider(kairoArgs[0], cfg) && cfg.DefaultProvider != "" {
+		return cfg.DefaultProvider
+	}
+
+	return kairoArgs[0]
+}
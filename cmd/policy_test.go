@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/policy"
+)
+
+// setTestPolicy points policy.Path at a policy file with content for the
+// duration of the test.
+func setTestPolicy(t *testing.T, content string) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), policy.FileName)
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	orig := policy.Path
+	policy.Path = file
+	t.Cleanup(func() { policy.Path = orig })
+}
+
+func policyTestContext(t *testing.T) *CLIContext {
+	t.Helper()
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, `default_provider: minimax
+providers:
+  minimax:
+    name: MiniMax
+    base_url: https://api.minimax.io/anthropic
+    model: MiniMax-M2
+  zai:
+    name: Z.AI
+    base_url: https://api.z.ai/api/anthropic
+    model: glm-4.7
+`)
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(dir)
+	cliCtx.SetRootCtx(context.Background())
+
+	return cliCtx
+}
+
+func TestEnforcePolicy(t *testing.T) {
+	u, err := user.Current()
+	if err != nil {
+		t.Skip("current user unknown:", err)
+	}
+	setTestPolicy(t, `commands:
+  deny: [update]
+rules:
+  - users: [`+u.Username+`]
+    providers:
+      allow: [minimax]
+    commands:
+      deny: [secrets, providers export]
+`)
+	cliCtx := policyTestContext(t)
+
+	tests := []struct {
+		name    string
+		run     func() error
+		wantErr string
+	}{
+		{"allowed command", func() error { return enforcePolicy(listCmd, cliCtx, nil) }, ""},
+		{"version is exempt", func() error { return enforcePolicy(versionCmd, cliCtx, nil) }, ""},
+		{"denied for everyone", func() error { return enforcePolicy(updateCmd, cliCtx, nil) }, "'kairo update'"},
+		{"denied subcommand", func() error { return enforcePolicy(secretsSetCmd, cliCtx, []string{"minimax"}) }, "'kairo secrets set'"},
+		{"denied provider argument", func() error { return enforcePolicy(defaultCmd, cliCtx, []string{"zai"}) }, "provider 'zai'"},
+		{"allowed provider argument", func() error { return enforcePolicy(defaultCmd, cliCtx, []string{"minimax"}) }, ""},
+		{"launch of default provider", func() error { return enforcePolicy(rootCmd, cliCtx, nil) }, ""},
+		{"launch of named provider", func() error { return enforcePolicy(rootCmd, cliCtx, []string{"zai", "--", "-p", "hi"}) }, "provider 'zai'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("enforcePolicy() = %v, want allowed", err)
+				}
+
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("enforcePolicy() = %v, want an error about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnforcePolicy_SecretsReveal(t *testing.T) {
+	u, err := user.Current()
+	if err != nil {
+		t.Skip("current user unknown:", err)
+	}
+	setTestPolicy(t, `rules:
+  - users: [`+u.Username+`]
+    providers:
+      allow: [minimax]
+`)
+	cliCtx := policyTestContext(t)
+
+	for secret, wantDenied := range map[string]bool{
+		"zai":             true,
+		"ZAI_API_KEY":     true,
+		"KIMI_API_KEY":    true,
+		"minimax":         false,
+		"MINIMAX_API_KEY": false,
+		"GITHUB_TOKEN":    false,
+	} {
+		err := enforcePolicy(secretsRevealCmd, cliCtx, []string{secret})
+		if denied := err != nil; denied != wantDenied {
+			t.Errorf("enforcePolicy(secrets reveal %s) = %v, want denied %v", secret, err, wantDenied)
+		}
+	}
+}
+
+func TestEnforcePolicy_NoPolicy(t *testing.T) {
+	orig := policy.Path
+	policy.Path = filepath.Join(t.TempDir(), policy.FileName)
+	t.Cleanup(func() { policy.Path = orig })
+
+	if err := enforcePolicy(updateCmd, policyTestContext(t), nil); err != nil {
+		t.Fatalf("enforcePolicy() = %v, want everything allowed without a policy", err)
+	}
+}
+
+func TestEnforcePolicy_BrokenPolicyFailsClosed(t *testing.T) {
+	setTestPolicy(t, "commands: [")
+
+	if err := enforcePolicy(listCmd, policyTestContext(t), nil); err == nil {
+		t.Fatal("enforcePolicy() = nil, want an error for an unreadable policy")
+	}
+}
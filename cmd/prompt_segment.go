@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/fsutil"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/spf13/cobra"
+)
+
+const defaultPromptFormat = "kairo:{provider}/{model}"
+
+var promptFormat string
+
+var promptSegmentCmd = &cobra.Command{
+	Use:   "prompt-segment",
+	Short: "Print the default provider for shell prompts",
+	Long: `Print a compact description of the default provider and model, such as
+"kairo:zai/glm-4.7", for embedding in PS1 or a starship custom module.
+
+The values are read from a small cache file that is refreshed whenever
+config.yaml changes, so nothing is decrypted and the command returns
+quickly. Nothing is printed when no default provider is configured or the
+config cannot be read, so a broken setup never breaks the prompt.
+
+--format accepts the placeholders {provider}, {model}, and {harness}. When
+the provider has no model, "/{model}" is dropped. For example:
+
+  PS1='$(kairo prompt-segment) \w \$ '
+
+  # starship.toml
+  [custom.kairo]
+  command = "kairo prompt-segment"
+  when = true`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		cliCtx := CLIContextFromCmd(cmd)
+		if cliCtx == nil {
+			return
+		}
+		state, ok := loadPromptState(cliCtx.RootCtx(), cliCtx.ConfigDir())
+		if !ok {
+			return
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), renderPromptSegment(promptFormat, state))
+	},
+}
+
+func init() {
+	promptSegmentCmd.Flags().StringVar(&promptFormat, "format", defaultPromptFormat,
+		"Output format with {provider}, {model}, and {harness} placeholders")
+	rootCmd.AddCommand(promptSegmentCmd)
+}
+
+// promptState is the cached prompt information for one config directory.
+// ConfigModTime, ConfigSize, and ConfigFiles identify the config.yaml and
+// override files it was built from.
+type promptState struct {
+	ConfigModTime int64  `json:"config_mod_time"`
+	ConfigSize    int64  `json:"config_size"`
+	ConfigFiles   int    `json:"config_files,omitempty"`
+	Provider      string `json:"provider"`
+	Model         string `json:"model,omitempty"`
+	Harness       string `json:"harness,omitempty"`
+}
+
+// promptCachePath returns the cache file for configDir. The user cache
+// directory is used so that the lookup needs no config parsing; the name is
+// derived from configDir so separate config directories do not collide.
+func promptCachePath(configDir string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(filepath.Clean(configDir)))
+
+	return filepath.Join(cacheDir, "kairo", "prompt-"+hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// loadPromptState returns the prompt state for configDir, reading the cache
+// when it matches the current config files and rebuilding it otherwise.
+func loadPromptState(ctx context.Context, configDir string) (promptState, bool) {
+	key, ok := promptCacheKey(configDir)
+	if !ok {
+		return promptState{}, false
+	}
+
+	cachePath, cacheErr := promptCachePath(configDir)
+	if cacheErr == nil {
+		if data, err := os.ReadFile(cachePath); err == nil {
+			var cached promptState
+			if json.Unmarshal(data, &cached) == nil &&
+				cached.ConfigModTime == key.ConfigModTime && cached.ConfigSize == key.ConfigSize &&
+				cached.ConfigFiles == key.ConfigFiles {
+				return cached, cached.Provider != ""
+			}
+		}
+	}
+
+	cfg, err := config.LoadConfig(ctx, configDir)
+	if err != nil {
+		return promptState{}, false
+	}
+	state := promptStateFromConfig(cfg)
+	state.ConfigModTime = key.ConfigModTime
+	state.ConfigSize = key.ConfigSize
+	state.ConfigFiles = key.ConfigFiles
+
+	if cacheErr == nil {
+		_ = writePromptState(cachePath, state)
+	}
+
+	return state, state.Provider != ""
+}
+
+// promptCacheKey summarizes config.yaml and the override files merged over
+// it: the latest modification time, the total size, and the override count.
+func promptCacheKey(configDir string) (promptState, bool) {
+	info, err := os.Stat(filepath.Join(configDir, config.BaseFileName))
+	if err != nil {
+		return promptState{}, false
+	}
+	key := promptState{ConfigModTime: info.ModTime().UnixNano(), ConfigSize: info.Size()}
+
+	files, err := config.OverrideFiles(configDir)
+	if err != nil {
+		return promptState{}, false
+	}
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return promptState{}, false
+		}
+		key.ConfigModTime = max(key.ConfigModTime, info.ModTime().UnixNano())
+		key.ConfigSize += info.Size()
+		key.ConfigFiles++
+	}
+
+	return key, true
+}
+
+func promptStateFromConfig(cfg *config.Config) promptState {
+	state := promptState{
+		Provider: cfg.DefaultProvider,
+		Harness:  harness.Resolve("", cfg.DefaultHarness),
+	}
+	if p, ok := cfg.Providers[cfg.DefaultProvider]; ok {
+		state.Model = p.Model
+	}
+
+	return state
+}
+
+func writePromptState(path string, state promptState) error {
+	if err := os.MkdirAll(filepath.Dir(path), constants.DirPermSecure); err != nil {
+		return err
+	}
+
+	return fsutil.WriteAtomic(path, func(f *os.File) error {
+		return json.NewEncoder(f).Encode(state)
+	})
+}
+
+// renderPromptSegment fills the placeholders of format from state.
+func renderPromptSegment(format string, state promptState) string {
+	if state.Model == "" {
+		format = strings.ReplaceAll(format, "/{model}", "")
+	}
+
+	return strings.NewReplacer(
+		"{provider}", state.Provider,
+		"{model}", state.Model,
+		"{harness}", state.Harness,
+	).Replace(format)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+)
+
+func TestLoadPromptState(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("LocalAppData", t.TempDir())
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	if _, ok := loadPromptState(ctx, dir); ok {
+		t.Fatal("loadPromptState() ok without a config file")
+	}
+
+	cfg := &config.Config{
+		DefaultProvider: "zai",
+		Providers:       map[string]config.Provider{"zai": {Name: "Z.AI", Model: "glm-4.7"}},
+	}
+	if err := config.SaveConfig(ctx, dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	state, ok := loadPromptState(ctx, dir)
+	if !ok || state.Provider != "zai" || state.Model != "glm-4.7" || state.Harness != "claude" {
+		t.Fatalf("loadPromptState() = %+v, %v", state, ok)
+	}
+	cachePath, err := promptCachePath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cachePath); err != nil {
+		t.Fatalf("cache file not written: %v", err)
+	}
+
+	cfg.Providers["minimax"] = config.Provider{Name: "MiniMax", Model: "MiniMax-M2"}
+	cfg.DefaultProvider = "minimax"
+	if err := config.SaveConfig(ctx, dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := loadPromptState(ctx, dir); state.Provider != "minimax" {
+		t.Errorf("loadPromptState() after config change = %+v, want minimax", state)
+	}
+}
+
+func TestRenderPromptSegment(t *testing.T) {
+	tests := []struct {
+		format string
+		state  promptState
+		want   string
+	}{
+		{defaultPromptFormat, promptState{Provider: "zai", Model: "glm-4.7"}, "kairo:zai/glm-4.7"},
+		{defaultPromptFormat, promptState{Provider: "anthropic"}, "kairo:anthropic"},
+		{"{harness}@{provider}", promptState{Provider: "zai", Harness: "qwen"}, "qwen@zai"},
+	}
+	for _, tt := range tests {
+		if got := renderPromptSegment(tt.format, tt.state); got != tt.want {
+			t.Errorf("renderPromptSegment(%q, %+v) = %q, want %q", tt.format, tt.state, got, tt.want)
+		}
+	}
+}
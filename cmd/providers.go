@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
 	"github.com/dkmnx/kairo/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -73,9 +74,9 @@ Use KAIRO_PROVIDER_CATALOG_URL to override the catalog URL.`,
 
 		cmd.Println("Fetching and verifying provider catalog...")
 
-		n, err := deps.Catalog.RefreshFromRemote(cmd.Context())
+		n, err := deps.Catalog.RefreshFromRemote(commandContext(cmd))
 		if err != nil {
-			ui.PrintError(fmt.Sprintf("Failed to refresh provider catalog: %v", err))
+			printError(kairoerrors.WrapError(kairoerrors.NetworkError, "failed to refresh provider catalog", err))
 
 			return
 		}
@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/harnessver"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/update"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	providersBundleNoSecrets bool
+	providersImportSignature string
+)
+
+// providerBundle is a set of providers, and the policy settings a team
+// requires of each, that a team signs with minisign and hands out. `kairo
+// providers import` accepts it only with a signature from a trusted signer.
+type providerBundle struct {
+	Providers []bundleProvider `yaml:"providers"`
+}
+
+// bundleProvider is one provider of a bundle: the fields of a snippet and
+// its policy.
+type bundleProvider struct {
+	providerSnippet `yaml:",inline"`
+	Policy          *bundlePolicy `yaml:"policy,omitempty"`
+}
+
+// bundlePolicy holds the provider settings a bundle enforces. Importing the
+// bundle sets them as given, clearing those it leaves out.
+type bundlePolicy struct {
+	MinHarnessVersion map[string]string `yaml:"min_harness_version,omitempty"`
+	WrapperPing       bool              `yaml:"wrapper_ping,omitempty"`
+	RunEnvAllow       []string          `yaml:"run_env_allow,omitempty"`
+}
+
+var providersBundleCmd = &cobra.Command{
+	Use:   "bundle <provider>...",
+	Short: "Print providers and their policy settings as a bundle to sign",
+	Long: `Print configured providers as a provider bundle: each one as 'kairo
+providers export' prints it, with the policy settings min_harness_version,
+wrapper_ping, and run_env_allow. Sign the bundle with minisign and hand out
+both files; 'kairo providers import' accepts the bundle only when the
+signature verifies against a signer added with 'kairo providers trust', so
+a base URL changed on the way is refused.
+
+API keys are never included. Environment variables whose names look like
+credentials stop the bundle unless --no-secrets leaves them out.`,
+	Example: `  kairo providers bundle acme-gateway acme-batch --no-secrets > acme.yaml
+  minisign -Sm acme.yaml
+  kairo providers import acme.yaml   # on a teammate's machine, reads acme.yaml.minisig`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runProvidersBundle(cmd, args); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	providersBundleCmd.Flags().BoolVar(&providersBundleNoSecrets, "no-secrets", false,
+		"Leave out environment variables that look like credentials")
+	providersImportCmd.Flags().StringVar(&providersImportSignature, "signature", "",
+		"minisign signature of a provider bundle (default: <file>.minisig)")
+	providersCmd.AddCommand(providersBundleCmd)
+}
+
+func runProvidersBundle(cmd *cobra.Command, names []string) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil {
+		if stderrors.Is(err, kairoerrors.ErrConfigNotFound) {
+			printNoProvidersMessage()
+
+			return errReported
+		}
+
+		return err
+	}
+
+	var (
+		bundle providerBundle
+		left   []string
+	)
+	for _, name := range names {
+		snippet, secret, err := providerSnippetFor(cfg, name)
+		if err != nil {
+			return err
+		}
+		if len(secret) > 0 && !providersBundleNoSecrets {
+			return kairoerrors.NewError(kairoerrors.ValidationError,
+				fmt.Sprintf("provider '%s' sets %s, which look like credentials", name, strings.Join(secret, ", "))).
+				WithContext("hint", "pass --no-secrets to leave them out of the bundle")
+		}
+		left = appendUnique(left, secret...)
+		bundle.Providers = append(bundle.Providers, bundleProvider{
+			providerSnippet: snippet,
+			Policy:          providerPolicy(cfg.Providers[name]),
+		})
+	}
+
+	return writeProviderBundle(cmd.OutOrStdout(), bundle, left)
+}
+
+// providerPolicy returns the bundle policy settings of p, or nil when it
+// sets none.
+func providerPolicy(p config.Provider) *bundlePolicy {
+	if len(p.MinHarnessVersion) == 0 && !p.WrapperPing && len(p.RunEnvAllow) == 0 {
+		return nil
+	}
+
+	return &bundlePolicy{MinHarnessVersion: p.MinHarnessVersion, WrapperPing: p.WrapperPing, RunEnvAllow: p.RunEnvAllow}
+}
+
+// writeProviderBundle writes bundle as YAML under a comment explaining how
+// to sign and import it and naming the variables left out.
+func writeProviderBundle(out io.Writer, bundle providerBundle, left []string) error {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "# kairo provider bundle; sign it with: minisign -Sm <file>")
+	fmt.Fprintln(&buf, "# then add it with: kairo providers import <file>")
+	fmt.Fprintln(&buf, "# API keys are not included; each user stores theirs with: kairo secrets set <provider>")
+	if len(left) > 0 {
+		fmt.Fprintf(&buf, "# Left out as credentials; set your own in env_vars: %s\n", strings.Join(left, ", "))
+	}
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(bundle); err != nil {
+		return kairoerrors.WrapError(kairoerrors.RuntimeError, "failed to encode provider bundle", err)
+	}
+	if err := enc.Close(); err != nil {
+		return kairoerrors.WrapError(kairoerrors.RuntimeError, "failed to encode provider bundle", err)
+	}
+	_, err := out.Write(buf.Bytes())
+
+	return err
+}
+
+// isProviderBundle reports whether data holds a provider bundle rather than
+// a single snippet.
+func isProviderBundle(data []byte) bool {
+	var probe struct {
+		Providers yaml.Node `yaml:"providers"`
+	}
+
+	return yaml.Unmarshal(data, &probe) == nil && probe.Providers.Kind != 0
+}
+
+// importProviderBundle verifies the bundle in data, read from file, against
+// the trusted signers of dir and imports each of its providers.
+func importProviderBundle(cliCtx *CLIContext, dir, file string, data []byte, strategy importStrategy) error {
+	signer, err := verifyProviderBundle(dir, file, data)
+	if err != nil {
+		return err
+	}
+	bundle, err := parseProviderBundle(data)
+	if err != nil {
+		return err
+	}
+	ui.PrintSuccess(fmt.Sprintf("Provider bundle signed by trusted signer %s", signer.ID()))
+
+	cfg, err := LoadConfig(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(bundle.Providers))
+	for _, p := range bundle.Providers {
+		policy := p.Policy
+		if policy == nil {
+			policy = &bundlePolicy{}
+		}
+		if err := importProviderSnippet(cliCtx, dir, cfg, p.providerSnippet, policy, strategy); err != nil {
+			return err
+		}
+		names = append(names, p.Provider)
+	}
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:   audit.EventConfig,
+		Action:  "import_bundle",
+		Details: map[string]string{"signer": signer.ID(), "providers": strings.Join(names, ",")},
+	})
+
+	return nil
+}
+
+// verifyProviderBundle checks the minisign signature of the bundle in data
+// against the trusted signers of dir and returns the signer. The signature
+// is read from --signature, or from file with .minisig appended.
+func verifyProviderBundle(dir, file string, data []byte) (update.MinisignPublicKey, error) {
+	sigPath := providersImportSignature
+	if sigPath == "" {
+		if file == "-" {
+			return update.MinisignPublicKey{}, kairoerrors.NewError(kairoerrors.ValidationError,
+				"a provider bundle read from stdin needs --signature")
+		}
+		sigPath = file + ".minisig"
+	}
+	sig, err := os.ReadFile(sigPath)
+	if err != nil {
+		return update.MinisignPublicKey{}, kairoerrors.FileError("failed to read provider bundle signature", sigPath, err).
+			WithContext("hint", "a bundle is only imported with its signature; minisign -Sm <file> writes <file>.minisig")
+	}
+
+	signersPath := filepath.Join(dir, constants.TrustedSignersFileName)
+	signers, err := readTrustedSigners(signersPath)
+	if err != nil {
+		return update.MinisignPublicKey{}, err
+	}
+	if len(signers) == 0 {
+		return update.MinisignPublicKey{}, kairoerrors.NewError(kairoerrors.ValidationError,
+			"no trusted signers to verify the provider bundle with").
+			WithContext("hint", "add your team's bundle signing key with 'kairo providers trust <public-key>'")
+	}
+	signer, err := update.VerifyMinisignWith(signers, data, sig)
+	if err != nil {
+		return update.MinisignPublicKey{}, kairoerrors.WrapError(kairoerrors.VerificationError,
+			"refusing the provider bundle", err).
+			WithContext("path", file).
+			WithContext("hint", "the bundle was changed after signing, or signed by an untrusted key; "+
+				"get it from its signer again")
+	}
+
+	return signer, nil
+}
+
+// parseProviderBundle decodes and validates a bundle before anything is
+// written to config.yaml.
+func parseProviderBundle(data []byte) (providerBundle, error) {
+	var b providerBundle
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&b); err != nil {
+		return providerBundle{}, kairoerrors.WrapError(kairoerrors.ValidationError, "invalid provider bundle", err)
+	}
+	if len(b.Providers) == 0 {
+		return providerBundle{}, kairoerrors.NewError(kairoerrors.ValidationError, "provider bundle lists no providers")
+	}
+
+	var seen []string
+	for _, p := range b.Providers {
+		if err := validateProviderSnippet(p.providerSnippet); err != nil {
+			return providerBundle{}, err
+		}
+		if slices.Contains(seen, p.Provider) {
+			return providerBundle{}, kairoerrors.NewError(kairoerrors.ValidationError,
+				fmt.Sprintf("provider bundle lists '%s' twice", p.Provider))
+		}
+		seen = append(seen, p.Provider)
+		if err := validateBundlePolicy(p.Provider, p.Policy); err != nil {
+			return providerBundle{}, err
+		}
+	}
+
+	return b, nil
+}
+
+// validateBundlePolicy checks the policy settings of provider.
+func validateBundlePolicy(provider string, policy *bundlePolicy) error {
+	if policy == nil {
+		return nil
+	}
+	for h, v := range policy.MinHarnessVersion {
+		if !harness.IsValid(h) {
+			return kairoerrors.NewError(kairoerrors.ValidationError,
+				fmt.Sprintf("provider bundle: '%s' requires a version of unknown harness %q", provider, h))
+		}
+		if v == "" || harnessver.ParseVersion(v) != v {
+			return kairoerrors.NewError(kairoerrors.ValidationError,
+				fmt.Sprintf("provider bundle: '%s' requires %s %q, which is not a version", provider, h, v))
+		}
+	}
+	for _, name := range policy.RunEnvAllow {
+		if name == "" || strings.ContainsAny(name, "= ") {
+			return kairoerrors.NewError(kairoerrors.ValidationError,
+				fmt.Sprintf("provider bundle: '%s' allows %q, which is not a variable name", provider, name))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"golang.org/x/crypto/blake2b"
+)
+
+// bundleSigner returns a minisign public key line and a function signing a
+// message with it as 'minisign -Sm' does.
+func bundleSigner(t *testing.T, keyID byte) (string, func([]byte) []byte) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := []byte{keyID, 2, 3, 4, 5, 6, 7, 8}
+	pubKey := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), id...), pub...))
+
+	return pubKey, func(message []byte) []byte {
+		sum := blake2b.Sum512(message)
+		sig := ed25519.Sign(priv, sum[:])
+		trusted := "timestamp:1700000000\tfile:acme.yaml"
+		global := ed25519.Sign(priv, append(append([]byte{}, sig...), trusted...))
+
+		return []byte("untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte("ED"), id...), sig...)) + "\n" +
+			"trusted comment: " + trusted + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n")
+	}
+}
+
+func TestProvidersBundleImport(t *testing.T) {
+	defer func() {
+		providersBundleNoSecrets, providersImportStrategy, providersImportSignature = false, "", ""
+	}()
+	src := t.TempDir()
+	writeExplainConfig(t, src, `providers:
+  gateway:
+    name: Team Gateway
+    base_url: https://llm.example.com/anthropic
+    model: gw-large
+    wrapper_ping: true
+    min_harness_version:
+      claude: 2.0.0
+    run_env_allow:
+      - GATEWAY_REGION
+    env_vars:
+      - GATEWAY_TOKEN=tok-0123456789abcdef
+`)
+	cmd, out := explainTestCmd(t, src)
+	if err := runProvidersBundle(cmd, []string{"gateway"}); err == nil || !strings.Contains(err.Error(), "GATEWAY_TOKEN") {
+		t.Fatalf("runProvidersBundle() without --no-secrets error = %v, want GATEWAY_TOKEN refused", err)
+	}
+	providersBundleNoSecrets = true
+	if err := runProvidersBundle(cmd, []string{"gateway"}); err != nil {
+		t.Fatalf("runProvidersBundle() error = %v", err)
+	}
+	bundle := out.Bytes()
+	for _, want := range []string{"providers:", "provider: gateway", "wrapper_ping: true", "claude: 2.0.0",
+		"GATEWAY_REGION", "minisign -Sm"} {
+		if !strings.Contains(string(bundle), want) {
+			t.Errorf("bundle missing %q:\n%s", want, bundle)
+		}
+	}
+	if strings.Contains(string(bundle), "tok-0123456789abcdef") {
+		t.Fatalf("bundle leaks the token:\n%s", bundle)
+	}
+
+	pubKey, sign := bundleSigner(t, 1)
+	files := t.TempDir()
+	file := filepath.Join(files, "acme.yaml")
+	if err := os.WriteFile(file, bundle, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dst := t.TempDir()
+	importCmd, _ := explainTestCmd(t, dst)
+
+	if err := runProvidersImport(importCmd, file); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("runProvidersImport() without a signature error = %v", err)
+	}
+	if err := os.WriteFile(file+".minisig", sign(bundle), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runProvidersImport(importCmd, file); err == nil || !strings.Contains(err.Error(), "no trusted signers") {
+		t.Errorf("runProvidersImport() without trusted signers error = %v", err)
+	}
+
+	otherKey, _ := bundleSigner(t, 9)
+	if err := runProvidersTrustEdit(importCmd, otherKey, true); err != nil {
+		t.Fatalf("runProvidersTrustEdit() error = %v", err)
+	}
+	if err := runProvidersImport(importCmd, file); err == nil || !strings.Contains(err.Error(), "not trusted") {
+		t.Errorf("runProvidersImport() signed by an untrusted key error = %v", err)
+	}
+	if err := runProvidersTrustEdit(importCmd, pubKey, true); err != nil {
+		t.Fatalf("runProvidersTrustEdit() error = %v", err)
+	}
+
+	tampered := strings.Replace(string(bundle), "llm.example.com", "llm.attacker.example", 1)
+	if err := os.WriteFile(file, []byte(tampered), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runProvidersImport(importCmd, file); err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Errorf("runProvidersImport() of a tampered bundle error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, config.BaseFileName)); err == nil {
+		t.Error("a refused bundle wrote config.yaml")
+	}
+
+	if err := os.WriteFile(file, bundle, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runProvidersImport(importCmd, file); err != nil {
+		t.Fatalf("runProvidersImport() of a signed bundle error = %v", err)
+	}
+	cfg, err := config.LoadConfig(context.Background(), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cfg.Providers["gateway"]
+	if got.BaseURL != "https://llm.example.com/anthropic" || !got.WrapperPing ||
+		got.MinHarnessVersion["claude"] != "2.0.0" || len(got.RunEnvAllow) != 1 {
+		t.Errorf("imported provider = %+v", got)
+	}
+
+	relaxed := strings.Replace(string(bundle), "wrapper_ping: true", "wrapper_ping: false", 1)
+	if err := os.WriteFile(file, []byte(relaxed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file+".minisig", sign([]byte(relaxed)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	providersImportStrategy = "take-incoming"
+	if err := runProvidersImport(importCmd, file); err != nil {
+		t.Fatalf("runProvidersImport() of a changed bundle error = %v", err)
+	}
+	if cfg, err = config.LoadConfig(context.Background(), dst); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Providers["gateway"].WrapperPing {
+		t.Error("take-incoming kept wrapper_ping the bundle no longer sets")
+	}
+}
+
+func TestTrustedSigners(t *testing.T) {
+	dir := t.TempDir()
+	cmd, out := explainTestCmd(t, dir)
+	pubKey, _ := bundleSigner(t, 1)
+	keyFile := filepath.Join(dir, "acme.pub")
+	if err := os.WriteFile(keyFile, []byte("untrusted comment: minisign public key\n"+pubKey+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runProvidersTrustEdit(cmd, keyFile, true); err != nil {
+		t.Fatalf("runProvidersTrustEdit() of a .pub file error = %v", err)
+	}
+	if err := runProvidersTrustEdit(cmd, pubKey, true); err != nil {
+		t.Errorf("runProvidersTrustEdit() of a trusted key error = %v", err)
+	}
+	path := filepath.Join(dir, constants.TrustedSignersFileName)
+	signers, err := readTrustedSigners(path)
+	if err != nil || len(signers) != 1 || signers[0].String() != pubKey {
+		t.Fatalf("readTrustedSigners() = %v, %v; want the key once", signers, err)
+	}
+
+	if err := runProvidersTrustList(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if want := "0807060504030201  " + pubKey; !strings.Contains(out.String(), want) {
+		t.Errorf("trust list = %q, want %q", out.String(), want)
+	}
+
+	if err := runProvidersTrustEdit(cmd, "0807060504030201", false); err != nil {
+		t.Fatalf("runProvidersTrustEdit() by key ID error = %v", err)
+	}
+	if signers, err = readTrustedSigners(path); err != nil || len(signers) != 0 {
+		t.Errorf("readTrustedSigners() after untrust = %v, %v", signers, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "#") {
+		t.Errorf("untrust dropped the comment:\n%s", data)
+	}
+	if err := runProvidersTrustEdit(cmd, pubKey, false); err == nil {
+		t.Error("runProvidersTrustEdit() of an untrusted key: want an error")
+	}
+	if err := runProvidersTrustEdit(cmd, "not-a-key", true); err == nil {
+		t.Error("runProvidersTrustEdit() of a bad key: want an error")
+	}
+
+	if err := os.WriteFile(path, []byte("garbage\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readTrustedSigners(path); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("readTrustedSigners() of a bad file error = %v", err)
+	}
+}
+
+func TestParseProviderBundleRejects(t *testing.T) {
+	tests := map[string]string{
+		"no providers":    "providers: []\n",
+		"unknown field":   "providers:\n  - provider: x\n    name: X\n    base_uri: https://x.example.com\n",
+		"duplicate":       "providers:\n  - provider: x\n    name: X\n  - provider: x\n    name: Y\n",
+		"unknown harness": "providers:\n  - provider: x\n    name: X\n    policy:\n      min_harness_version: {vim: 1.0.0}\n",
+		"bad version":     "providers:\n  - provider: x\n    name: X\n    policy:\n      min_harness_version: {claude: new}\n",
+		"bad env name":    "providers:\n  - provider: x\n    name: X\n    policy:\n      run_env_allow: [A=1]\n",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseProviderBundle([]byte(data)); err == nil {
+				t.Errorf("parseProviderBundle(%q) expected error", data)
+			}
+		})
+	}
+}
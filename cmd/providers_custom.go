@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/validate"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// customProviderTemplate is the annotated file printed by
+// `kairo providers template`. Its fields mirror customProviderFile.
+const customProviderTemplate = `# Kairo custom provider definition.
+# Register it with: kairo providers add -f <file>
+
+# Provider key used on the command line, as in 'kairo my-llm'.
+key: my-llm
+
+# Display name shown in setup and list output.
+name: My LLM
+
+# Anthropic-compatible endpoint (HTTPS only).
+base_url: https://api.example.com/anthropic
+
+# Default model; it can be changed per provider during setup.
+model: custom-model
+
+# Whether switching to this provider needs an API key.
+requires_api_key: true
+
+# Environment variable the API key is passed in. Leave empty for <KEY>_API_KEY.
+api_key_env_var: MY_LLM_API_KEY
+
+# Checks applied to the API key during setup.
+min_key_length: 20
+key_prefix: ""
+key_pattern: ""
+
+# Strength heuristics: warn when a stored key is shorter than this (0 = off)
+# or below this many bits of entropy per character (0 = default 3.0).
+typical_key_length: 0
+min_key_entropy: 0
+
+# Header kairo's health checks send the key in: x-api-key, bearer, or both.
+# With x-api-key, Claude gets the key as ANTHROPIC_API_KEY instead of
+# ANTHROPIC_AUTH_TOKEN.
+auth_style: both
+
+# Extra KEY=value environment variables passed to the harness.
+env_vars: []
+`
+
+var (
+	providersAddFile  string
+	providersAddForce bool
+)
+
+// customProviderFile is the on-disk form read by `kairo providers add -f`.
+type customProviderFile struct {
+	Key                                string `yaml:"key"`
+	providers.CustomProviderDefinition `yaml:",inline"`
+}
+
+var providersTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Print an annotated custom provider template",
+	Long: `Print an annotated custom provider definition to stdout. Edit it and
+register it with 'kairo providers add -f <file>':
+
+  kairo providers template > my-llm.yaml`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, _ = io.WriteString(cmd.OutOrStdout(), customProviderTemplate)
+	},
+}
+
+var providersAddCmd = &cobra.Command{
+	Use:   "add -f <file>",
+	Short: "Register a custom provider from a file",
+	Long: `Validate a custom provider definition file and add it to custom_providers
+in config.yaml. Use '-f -' to read the file from stdin.
+
+An existing custom provider with the same key is only replaced with --force.
+After adding it, run 'kairo setup --provider <key>' to store its API key.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runProvidersAdd(cmd); err != nil {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	providersAddCmd.Flags().StringVarP(&providersAddFile, "file", "f", "", "Provider definition file ('-' for stdin)")
+	providersAddCmd.Flags().BoolVar(&providersAddForce, "force", false, "Replace an existing custom provider with the same key")
+	_ = providersAddCmd.MarkFlagRequired("file")
+	providersCmd.AddCommand(providersTemplateCmd)
+	providersCmd.AddCommand(providersAddCmd)
+}
+
+func runProvidersAdd(cmd *cobra.Command) error {
+	var (
+		data []byte
+		err  error
+	)
+	if providersAddFile == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(providersAddFile)
+	}
+	if err != nil {
+		return kairoerrors.FileError("failed to read provider file", providersAddFile, err)
+	}
+
+	key, def, err := parseCustomProviderFile(data)
+	if err != nil {
+		return err
+	}
+
+	dir := requireConfigDirWritable(cmd)
+	if dir == "" {
+		return kairoerrors.NewError(kairoerrors.ConfigError, "config directory not available")
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	cfg, err := LoadConfig(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+
+	if err := addCustomProvider(cliCtx, dir, cfg, key, def, providersAddForce); err != nil {
+		return err
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Custom provider '%s' added", key))
+	ui.PrintInfo(fmt.Sprintf("Run 'kairo setup --provider %s' to configure it", key))
+
+	return nil
+}
+
+// parseCustomProviderFile decodes and validates a provider definition file.
+func parseCustomProviderFile(data []byte) (string, providers.CustomProviderDefinition, error) {
+	var f customProviderFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&f); err != nil {
+		return "", providers.CustomProviderDefinition{}, kairoerrors.WrapError(kairoerrors.ValidationError,
+			"invalid provider file", err)
+	}
+
+	if err := validateCustomProviderDefinition(f.Key, f.CustomProviderDefinition); err != nil {
+		return "", providers.CustomProviderDefinition{}, err
+	}
+
+	return f.Key, f.CustomProviderDefinition, nil
+}
+
+// validateCustomProviderDefinition checks a custom provider before it is
+// written to config.yaml, so that a bad file never breaks later commands.
+func validateCustomProviderDefinition(key string, def providers.CustomProviderDefinition) error {
+	if key == "" {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "provider file: key is required")
+	}
+	if len(key) > validate.MaxProviderNameLength || !providerNamePattern.MatchString(key) {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("provider file: invalid key %q", key)).
+			WithContext("hint", "keys start with a letter and contain only letters, digits, '_' and '-'")
+	}
+	if strings.TrimSpace(def.Name) == "" {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "provider file: name is required")
+	}
+	if def.BaseURL != "" {
+		if err := validate.ValidateURL(def.BaseURL, key); err != nil {
+			return err
+		}
+	}
+	if def.Model != "" {
+		if err := validate.ValidateProviderModel(key, def.Model); err != nil {
+			return err
+		}
+	}
+	if def.MinKeyLength < 0 {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "provider file: min_key_length cannot be negative")
+	}
+	if def.TypicalKeyLength < 0 {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "provider file: typical_key_length cannot be negative")
+	}
+	if def.MinKeyEntropy < 0 {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "provider file: min_key_entropy cannot be negative")
+	}
+	if _, err := health.ParseAuthStyle(def.AuthStyle); err != nil {
+		return kairoerrors.WrapError(kairoerrors.ValidationError, "provider file: invalid auth_style", err)
+	}
+	if def.KeyPattern != "" {
+		if _, err := regexp.Compile(def.KeyPattern); err != nil {
+			return kairoerrors.WrapError(kairoerrors.ValidationError, "provider file: invalid key_pattern", err)
+		}
+	}
+	for _, kv := range def.EnvVars {
+		if name, _, ok := strings.Cut(kv, "="); !ok || name == "" {
+			return kairoerrors.NewError(kairoerrors.ValidationError,
+				fmt.Sprintf("provider file: env_vars entry %q is not KEY=value", kv))
+		}
+	}
+
+	return nil
+}
+
+// addCustomProvider stores def under key in cfg.CustomProviders and saves the
+// config. An existing entry is only replaced when force is set.
+func addCustomProvider(
+	cliCtx *CLIContext, dir string, cfg *config.Config, key string, def providers.CustomProviderDefinition, force bool,
+) error {
+	if _, exists := cfg.CustomProviders[key]; exists && !force {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("custom provider '%s' already exists", key)).
+			WithContext("hint", "use --force to replace it")
+	}
+
+	if cfg.CustomProviders == nil {
+		cfg.CustomProviders = make(map[string]providers.CustomProviderDefinition)
+	}
+	cfg.CustomProviders[key] = def
+
+	if err := config.SaveConfig(cliCtx.RootCtx(), dir, cfg); err != nil {
+		return kairoerrors.WrapError(kairoerrors.ConfigError, "saving config", err)
+	}
+	cliCtx.InvalidateCache(dir)
+
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:    audit.EventConfig,
+		Action:   "add_custom_provider",
+		Provider: key,
+		Details:  map[string]string{"base_url": def.BaseURL, "model": def.Model},
+	})
+
+	return nil
+}
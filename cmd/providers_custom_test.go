@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/providers"
+)
+
+func TestCustomProviderTemplateParses(t *testing.T) {
+	key, def, err := parseCustomProviderFile([]byte(customProviderTemplate))
+	if err != nil {
+		t.Fatalf("parseCustomProviderFile(template) error = %v", err)
+	}
+	if key != "my-llm" || def.Name != "My LLM" || !def.RequiresAPIKey {
+		t.Errorf("parsed template = %q, %+v", key, def)
+	}
+}
+
+func TestParseCustomProviderFileRejects(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":   "key: x\nname: X\nbase_uri: https://x.example.com\n",
+		"missing key":     "name: X\n",
+		"bad key":         "key: 1x\nname: X\n",
+		"missing name":    "key: x\n",
+		"http base url":   "key: x\nname: X\nbase_url: http://x.example.com\n",
+		"bad key pattern": "key: x\nname: X\nkey_pattern: '['\n",
+		"bad env var":     "key: x\nname: X\nenv_vars: [NOEQUALS]\n",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := parseCustomProviderFile([]byte(data)); err == nil {
+				t.Errorf("parseCustomProviderFile(%q) expected error", data)
+			}
+		})
+	}
+}
+
+func TestAddCustomProvider(t *testing.T) {
+	dir := t.TempDir()
+	cliCtx := NewCLIContext()
+	cfg := &config.Config{Providers: map[string]config.Provider{}}
+	def := providers.CustomProviderDefinition{Name: "My LLM", BaseURL: "https://api.example.com/anthropic"}
+
+	if err := addCustomProvider(cliCtx, dir, cfg, "my-llm", def, false); err != nil {
+		t.Fatalf("addCustomProvider() error = %v", err)
+	}
+
+	loaded, err := config.LoadConfig(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.CustomProviders["my-llm"]; got.BaseURL != def.BaseURL {
+		t.Errorf("saved custom provider = %+v", got)
+	}
+
+	def.Name = "Renamed"
+	err = addCustomProvider(cliCtx, dir, loaded, "my-llm", def, false)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("addCustomProvider() duplicate error = %v, want already exists", err)
+	}
+	if err := addCustomProvider(cliCtx, dir, loaded, "my-llm", def, true); err != nil {
+		t.Errorf("addCustomProvider(force) error = %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fuzzy"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var providersDisableCmd = &cobra.Command{
+	Use:   "disable <provider>",
+	Short: "Stop a provider from being used without deleting it",
+	Long: `Mark a configured provider as disabled, for example while it is down or
+its account is suspended. Its config and stored API key are kept, but kairo
+refuses to start a harness with it, leaves it out of shell completions and of
+'kairo status' when no provider is named, and shows it greyed out in
+'kairo list'. 'kairo providers enable' undoes it.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProviders(false),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := setProviderDisabled(cmd, args[0], true); err != nil {
+			printError(err)
+		}
+	},
+}
+
+var providersEnableCmd = &cobra.Command{
+	Use:               "enable <provider>",
+	Short:             "Allow a disabled provider to be used again",
+	Long:              "Clear the disabled mark 'kairo providers disable' set on a configured provider.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProviders(true),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := setProviderDisabled(cmd, args[0], false); err != nil {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	providersCmd.AddCommand(providersDisableCmd)
+	providersCmd.AddCommand(providersEnableCmd)
+}
+
+// setProviderDisabled marks the configured provider name as disabled or
+// enabled, saving and auditing the change if it is one.
+func setProviderDisabled(cmd *cobra.Command, name string, disabled bool) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return nil
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil {
+		return kairoerrors.WrapError(kairoerrors.ConfigError, "error loading config", err)
+	}
+	provider, ok := cfg.Providers[name]
+	if !ok {
+		return kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("provider '%s' not configured", name)).
+			WithContext("hint", "run 'kairo list' to see configured providers")
+	}
+
+	state, action := "enabled", "enable_provider"
+	if disabled {
+		state, action = "disabled", "disable_provider"
+	}
+	if provider.Disabled == disabled {
+		ui.PrintInfo(fmt.Sprintf("Provider '%s' is already %s", name, state))
+
+		return nil
+	}
+
+	previous := provider
+	provider.Disabled = disabled
+	cfg.Providers[name] = provider
+	if err := config.SaveConfig(cliCtx.RootCtx(), dir, cfg); err != nil {
+		return kairoerrors.WrapError(kairoerrors.ConfigError, "error saving config", err)
+	}
+	cliCtx.InvalidateCache(dir)
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:    audit.EventConfig,
+		Action:   action,
+		Provider: name,
+		Patch:    providerPatch(name, &previous, &provider),
+	})
+
+	ui.PrintSuccess(fmt.Sprintf("Provider '%s' %s", name, state))
+	if disabled && name == cfg.DefaultProvider {
+		ui.PrintWarn("It is the default provider; 'kairo' without a provider will refuse to start until you enable it or run 'kairo default <provider>'")
+	}
+
+	return nil
+}
+
+// disabledProviderError is returned when a harness would be started with a
+// disabled provider.
+func disabledProviderError(name string) error {
+	return kairoerrors.NewError(kairoerrors.ProviderError,
+		fmt.Sprintf("provider '%s' is disabled", name)).
+		WithContext("provider", name).
+		WithContext("hint", fmt.Sprintf("run 'kairo providers enable %s' to use it again", name))
+}
+
+// completeProviders returns a completion function offering the configured
+// providers that are disabled, or those that are not. When none starts with
+// the word being completed, the names closest to it are offered instead, for
+// shells that show completions not matching the word.
+func completeProviders(disabled bool) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var all, names []string
+		for name, p := range completionConfig(cmd).Providers {
+			if p.Disabled != disabled {
+				continue
+			}
+			all = append(all, name)
+			if strings.HasPrefix(name, toComplete) {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 && toComplete != "" {
+			return fuzzy.Closest(toComplete, all), cobra.ShellCompDirectiveNoFileComp
+		}
+		sort.Strings(names)
+
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+)
+
+func writeDisableTestConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	content := `default_provider: zai
+providers:
+  zai:
+    name: Z.AI
+    base_url: https://api.z.ai/api/anthropic
+    model: glm-5.1
+  minimax:
+    name: MiniMax
+    base_url: https://api.minimax.io/anthropic
+    model: MiniMax-M2
+    disabled: true
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestProvidersDisableEnable(t *testing.T) {
+	originalConfigDir := testCLI.ConfigDir()
+	defer func() { testCLI.SetConfigDir(originalConfigDir) }()
+	dir := writeDisableTestConfig(t)
+	testCLI.SetConfigDir(dir)
+
+	disabled := func(name string) bool {
+		t.Helper()
+		cfg, err := config.LoadConfig(context.Background(), dir)
+		if err != nil {
+			t.Fatalf("LoadConfig() error = %v", err)
+		}
+
+		return cfg.Providers[name].Disabled
+	}
+
+	rootCmd.SetArgs([]string{"--config", dir, "providers", "disable", "zai"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !disabled("zai") {
+		t.Error("providers disable zai did not disable it")
+	}
+
+	rootCmd.SetArgs([]string{"--config", dir, "providers", "enable", "minimax"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if disabled("minimax") {
+		t.Error("providers enable minimax did not enable it")
+	}
+
+	cfg, err := config.LoadConfig(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Providers["zai"].BaseURL != "https://api.z.ai/api/anthropic" || cfg.DefaultProvider != "zai" {
+		t.Errorf("disabling changed the rest of the config: %+v", cfg)
+	}
+}
+
+func TestSetProviderDisabled_NotConfigured(t *testing.T) {
+	originalConfigDir := testCLI.ConfigDir()
+	defer func() { testCLI.SetConfigDir(originalConfigDir) }()
+	dir := writeDisableTestConfig(t)
+	testCLI.SetConfigDir(dir)
+
+	cmd := testCmd()
+	cmd.SetContext(WithCLIContext(context.Background(), testCLI))
+	if err := setProviderDisabled(cmd, "deepseek", true); err == nil {
+		t.Error("setProviderDisabled() for an unconfigured provider succeeded, want an error")
+	}
+}
+
+func TestCompleteProviders(t *testing.T) {
+	dir := writeDisableTestConfig(t)
+	cmd := testCmd()
+	cmd.Flags().String("config", "", "")
+	if err := cmd.Flags().Set("config", dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := completeProviders(false)(cmd, nil, ""); !slices.Equal(got, []string{"zai"}) {
+		t.Errorf("enabled providers = %q, want [zai]", got)
+	}
+	if got, _ := completeProviders(true)(cmd, nil, ""); !slices.Equal(got, []string{"minimax"}) {
+		t.Errorf("disabled providers = %q, want [minimax]", got)
+	}
+	if got, _ := completeProviders(false)(cmd, []string{"zai"}, ""); len(got) != 0 {
+		t.Errorf("completion after the provider = %q, want none", got)
+	}
+	if got, _ := completeProviders(false)(cmd, nil, "zia"); !slices.Equal(got, []string{"zai"}) {
+		t.Errorf("completion of a misspelled provider = %q, want [zai]", got)
+	}
+	if got, _ := completeProviders(false)(cmd, nil, "minimx"); len(got) != 0 {
+		t.Errorf("completion offered a disabled provider = %q, want none", got)
+	}
+}
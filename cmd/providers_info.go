@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"strings"
+
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/spf13/cobra"
+)
+
+var providersInfoCmd = &cobra.Command{
+	Use:   "info <provider>",
+	Short: "Show quick-start notes for a provider in the catalog",
+	Long: `Print where to get a provider's API key, its default base URL and model,
+the format kairo expects of its key, and notes on its endpoints and rate
+limits. The notes are part of the provider catalog, so they are available
+offline and need no configured provider.`,
+	Example: `  kairo providers info zai`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runProvidersInfo(cmd, args[0]); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	providersCmd.AddCommand(providersInfoCmd)
+}
+
+func runProvidersInfo(cmd *cobra.Command, name string) error {
+	def, ok := CLIContextFromCmd(cmd).Deps().Catalog.BuiltInProvider(name)
+	if !ok {
+		return kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("provider '%s' is not in the catalog", name)).
+			WithContext("hint", "run 'kairo providers list' to see the providers in the catalog")
+	}
+	writeProviderInfo(cmd.OutOrStdout(), name, def)
+
+	return nil
+}
+
+// writeProviderInfo prints def's quick-start details, skipping those the
+// catalog leaves empty.
+func writeProviderInfo(out io.Writer, name string, def providers.ProviderDefinition) {
+	title := name
+	if def.Name != "" && def.Name != name {
+		title += " (" + def.Name + ")"
+	}
+	fmt.Fprintln(out, title)
+	fmt.Fprintln(out)
+
+	if def.Info.KeyURL != "" {
+		fmt.Fprintf(out, "  Get your key at: %s\n", def.Info.KeyURL)
+	}
+	if def.APIKeyEnvVar != "" {
+		fmt.Fprintf(out, "  API key:         $%s\n", def.APIKeyEnvVar)
+	}
+	if format := describeKeyFormat(def.KeyFormat); format != "" {
+		fmt.Fprintf(out, "  Key format:      %s\n", format)
+	}
+	baseURL, model := def.BaseURL, def.Model
+	if baseURL == "" {
+		baseURL = "(provider-managed)"
+	}
+	if model == "" {
+		model = "(provider-managed)"
+	}
+	fmt.Fprintf(out, "  Base URL:        %s\n", baseURL)
+	fmt.Fprintf(out, "  Default model:   %s\n", model)
+
+	if len(def.Info.Notes) > 0 {
+		fmt.Fprintln(out, "\nNotes:")
+		for _, note := range def.Info.Notes {
+			fmt.Fprintf(out, "  - %s\n", note)
+		}
+	}
+}
+
+// describeKeyFormat renders the key format rules setup checks a key against.
+func describeKeyFormat(kf providers.KeyFormat) string {
+	var parts []string
+	if kf.Prefix != "" {
+		parts = append(parts, fmt.Sprintf("starts with %q", kf.Prefix))
+	}
+	if kf.MinLength > 0 {
+		parts = append(parts, fmt.Sprintf("at least %d characters", kf.MinLength))
+	}
+
+	return strings.Join(parts, ", ")
+}
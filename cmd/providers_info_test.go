@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRunProvidersInfo(t *testing.T) {
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(NewDeps())
+	var out bytes.Buffer
+	cmd := &cobra.Command{Use: "kairo"}
+	cmd.SetOut(&out)
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	if err := runProvidersInfo(cmd, "anthropic"); err != nil {
+		t.Fatalf("runProvidersInfo() error = %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"anthropic (Anthropic)",
+		"Get your key at: https://console.anthropic.com/settings/keys",
+		"API key:         $ANTHROPIC_API_KEY",
+		`Key format:      starts with "sk-ant-", at least 32 characters`,
+		"Base URL:        (provider-managed)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+
+	out.Reset()
+	if err := runProvidersInfo(cmd, "zai"); err != nil {
+		t.Fatalf("runProvidersInfo(zai) error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Notes:\n  - ") {
+		t.Errorf("zai output lacks its notes:\n%s", out.String())
+	}
+
+	if err := runProvidersInfo(cmd, "missing"); err == nil || !strings.Contains(err.Error(), "not in the catalog") {
+		t.Errorf("runProvidersInfo() of an unknown provider error = %v", err)
+	}
+}
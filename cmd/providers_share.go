@@ -0,0 +1,421 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/envcheck"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/validate"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	providersExportNoSecrets bool
+	providersImportForce     bool
+	providersImportStrategy  string
+)
+
+// providerSnippet is the shareable form of a configured provider, written by
+// `kairo providers export` and read by `kairo providers import`. It never
+// holds the API key.
+type providerSnippet struct {
+	Provider  string   `yaml:"provider"`
+	Name      string   `yaml:"name"`
+	BaseURL   string   `yaml:"base_url"`
+	Model     string   `yaml:"model"`
+	EnvVars   []string `yaml:"env_vars,omitempty"`
+	AuthStyle string   `yaml:"auth_style,omitempty"`
+	// Definition is the custom provider definition the provider needs, for
+	// one registered with `kairo providers add`.
+	Definition *providers.CustomProviderDefinition `yaml:"definition,omitempty"`
+}
+
+// requiresAPIKey reports whether the snippet's provider needs an API key,
+// going by its definition when it carries one.
+func (s providerSnippet) requiresAPIKey() bool {
+	if s.Definition != nil {
+		return s.Definition.RequiresAPIKey
+	}
+
+	return providers.RequiresAPIKey(s.Provider)
+}
+
+var providersExportCmd = &cobra.Command{
+	Use:   "export <provider>",
+	Short: "Print a provider as a shareable YAML snippet",
+	Long: `Print a configured provider's name, base URL, model, and environment
+variables as a YAML snippet that a teammate can pass to 'kairo providers
+import'. A custom provider's definition is included with it.
+
+The API key is never exported. Environment variables whose names look like
+credentials, such as GATEWAY_TOKEN, stop the export unless --no-secrets leaves
+them out; the snippet then names them so the recipient can set their own.`,
+	Example: `  kairo providers export my-gateway --no-secrets > my-gateway.yaml
+  kairo providers export zai --no-secrets | pbcopy`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runProvidersExport(cmd, args[0]); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+var providersImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Add a provider from a snippet made by 'providers export'",
+	Long: `Add the provider described by a snippet from 'kairo providers export' to
+config.yaml, along with its custom provider definition if it has one. Use '-'
+to read the snippet from stdin, as when pasting it from chat.
+
+The snippet is checked like a provider entered in setup: its base URL must be
+HTTPS on a public host, and its model and environment variables must be
+well-formed. The API key is not part of the snippet; store yours with
+'kairo secrets set <provider>' afterwards.
+
+When the provider is already configured with different settings, kairo lists
+them and asks whether to keep the local provider, take the incoming one, or
+merge them field by field. --strategy makes the same choice without asking:
+keep-local, take-incoming, or merge, which takes settings only the snippet
+has and keeps the local value of the others. --force is take-incoming.
+
+A provider bundle from 'kairo providers bundle' is imported only when its
+minisign signature, read from <file>.minisig or --signature, was made by a
+signer added with 'kairo providers trust'. Each of its providers is then
+imported as above, with the policy settings the bundle gives it.`,
+	Example: `  kairo providers import my-gateway.yaml
+  pbpaste | kairo providers import -`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runProvidersImport(cmd, args[0]); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	providersExportCmd.Flags().BoolVar(&providersExportNoSecrets, "no-secrets", false,
+		"Leave out environment variables that look like credentials")
+	providersImportCmd.Flags().BoolVar(&providersImportForce, "force", false,
+		"Replace a provider or custom definition that already exists (--strategy take-incoming)")
+	providersImportCmd.Flags().StringVar(&providersImportStrategy, "strategy", "", importStrategyUsage)
+	providersCmd.AddCommand(providersExportCmd)
+	providersCmd.AddCommand(providersImportCmd)
+}
+
+func runProvidersExport(cmd *cobra.Command, name string) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil {
+		if stderrors.Is(err, kairoerrors.ErrConfigNotFound) {
+			printNoProvidersMessage()
+
+			return errReported
+		}
+
+		return err
+	}
+	snippet, left, err := providerSnippetFor(cfg, name)
+	if err != nil {
+		return err
+	}
+	if len(left) > 0 && !providersExportNoSecrets {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("provider '%s' sets %s, which look like credentials", name, strings.Join(left, ", "))).
+			WithContext("hint", "pass --no-secrets to leave them out of the snippet")
+	}
+
+	return writeProviderSnippet(cmd.OutOrStdout(), snippet, left)
+}
+
+// providerSnippetFor returns the snippet of the configured provider name,
+// without the environment variables that look like credentials, and the
+// names of those variables.
+func providerSnippetFor(cfg *config.Config, name string) (providerSnippet, []string, error) {
+	provider, ok := cfg.Providers[name]
+	if !ok {
+		return providerSnippet{}, nil, kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("provider '%s' not configured", name)).
+			WithContext("hint", "run 'kairo list' to see configured providers")
+	}
+
+	snippet := providerSnippet{
+		Provider:  name,
+		Name:      provider.Name,
+		BaseURL:   provider.BaseURL,
+		Model:     provider.Model,
+		AuthStyle: provider.AuthStyle,
+	}
+	var left []string
+	snippet.EnvVars, left = splitSecretEnvVars(provider.EnvVars)
+	if def, ok := cfg.CustomProviders[name]; ok {
+		var defLeft []string
+		def.EnvVars, defLeft = splitSecretEnvVars(def.EnvVars)
+		snippet.Definition = &def
+		left = appendUnique(left, defLeft...)
+	}
+
+	return snippet, left, nil
+}
+
+// splitSecretEnvVars separates KEY=value entries whose names suggest a
+// credential, returning the other entries and the names of those left out.
+func splitSecretEnvVars(vars []string) ([]string, []string) {
+	var kept, secret []string
+	for _, kv := range vars {
+		name, _, _ := strings.Cut(kv, "=")
+		if envcheck.IsSensitive(name) {
+			secret = appendUnique(secret, name)
+
+			continue
+		}
+		kept = append(kept, kv)
+	}
+
+	return kept, secret
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		if !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// writeProviderSnippet writes snippet as YAML under a comment explaining how
+// to import it and naming the variables left out.
+func writeProviderSnippet(out io.Writer, snippet providerSnippet, left []string) error {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "# kairo provider snippet; add it with: kairo providers import <file>")
+	if snippet.requiresAPIKey() {
+		fmt.Fprintf(&buf, "# The API key is not included; store yours with: kairo secrets set %s\n", snippet.Provider)
+	}
+	if len(left) > 0 {
+		fmt.Fprintf(&buf, "# Left out as credentials; set your own in env_vars: %s\n", strings.Join(left, ", "))
+	}
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(snippet); err != nil {
+		return kairoerrors.WrapError(kairoerrors.RuntimeError, "failed to encode provider snippet", err)
+	}
+	if err := enc.Close(); err != nil {
+		return kairoerrors.WrapError(kairoerrors.RuntimeError, "failed to encode provider snippet", err)
+	}
+	_, err := out.Write(buf.Bytes())
+
+	return err
+}
+
+func runProvidersImport(cmd *cobra.Command, file string) error {
+	var (
+		data []byte
+		err  error
+	)
+	if file == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return kairoerrors.FileError("failed to read provider snippet", file, err)
+	}
+
+	bundle := isProviderBundle(data)
+	var snippet providerSnippet
+	if !bundle {
+		if snippet, err = parseProviderSnippet(data); err != nil {
+			return err
+		}
+	}
+	strategy, err := parseImportStrategy(providersImportStrategy)
+	if err != nil {
+		return err
+	}
+	if providersImportForce {
+		strategy = importTakeIncoming
+	}
+
+	dir := requireConfigDirWritable(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		return err
+	}
+	if bundle {
+		return importProviderBundle(cliCtx, dir, file, data, strategy)
+	}
+	cfg, err := LoadConfig(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+
+	return importProviderSnippet(cliCtx, dir, cfg, snippet, nil, strategy)
+}
+
+// importProviderSnippet adds the provider of snippet to cfg, with the
+// settings of policy when it comes from a bundle, resolving a conflict with
+// the configured provider by strategy.
+func importProviderSnippet(
+	cliCtx *CLIContext, dir string, cfg *config.Config,
+	snippet providerSnippet, policy *bundlePolicy, strategy importStrategy,
+) error {
+	local, exists := cfg.Providers[snippet.Provider]
+	incoming := local
+	incoming.Name = snippet.Name
+	incoming.BaseURL = snippet.BaseURL
+	incoming.Model = snippet.Model
+	incoming.EnvVars = snippet.EnvVars
+	incoming.AuthStyle = snippet.AuthStyle
+	if policy != nil {
+		incoming.MinHarnessVersion = policy.MinHarnessVersion
+		incoming.WrapperPing = policy.WrapperPing
+		incoming.RunEnvAllow = policy.RunEnvAllow
+	}
+
+	var conflicts []fieldConflict
+	if exists {
+		conflicts = providerConflicts(local, incoming)
+		if policy != nil {
+			conflicts = append(conflicts, policyConflicts(local, incoming)...)
+		}
+	}
+	localDef, defExists := cfg.CustomProviders[snippet.Provider]
+	if snippet.Definition != nil && defExists && !reflect.DeepEqual(localDef, *snippet.Definition) {
+		conflicts = append(conflicts, definitionConflict(localDef, *snippet.Definition))
+	}
+	if exists && len(conflicts) == 0 {
+		ui.PrintInfo(fmt.Sprintf("Provider '%s' is already configured with these settings", snippet.Provider))
+
+		return nil
+	}
+	provider := incoming
+	addDefinition := snippet.Definition != nil
+	if len(conflicts) > 0 {
+		taken, err := resolveImportConflicts(cliCtx.RootCtx(), snippet.Provider, conflicts, strategy)
+		if err != nil {
+			return err
+		}
+		if len(taken) == 0 {
+			ui.PrintInfo(fmt.Sprintf("Kept the configured provider '%s'", snippet.Provider))
+
+			return nil
+		}
+		if exists {
+			provider = applyTaken(local, incoming, taken)
+		}
+		addDefinition = addDefinition && (!defExists || taken["definition"])
+	}
+
+	if addDefinition {
+		if err := addCustomProvider(cliCtx, dir, cfg, snippet.Provider, *snippet.Definition, true); err != nil {
+			return err
+		}
+	}
+	if err := AddAndSaveProvider(AddProviderParams{
+		CLIContext:   cliCtx,
+		ConfigDir:    dir,
+		Cfg:          cfg,
+		ProviderName: snippet.Provider,
+		Provider:     provider,
+		SetAsDefault: true,
+	}); err != nil {
+		return err
+	}
+	ui.PrintSuccess(fmt.Sprintf("Imported provider '%s'", snippet.Provider))
+	if snippet.requiresAPIKey() {
+		ui.PrintInfo(fmt.Sprintf("Run 'kairo secrets set %s' to store your API key", snippet.Provider))
+	}
+
+	return nil
+}
+
+// definitionConflict describes a custom definition in a snippet that
+// differs from the one registered under the same name.
+func definitionConflict(local, incoming providers.CustomProviderDefinition) fieldConflict {
+	summary := func(def providers.CustomProviderDefinition) string {
+		return fmt.Sprintf("%s at %s", cmp.Or(def.Model, "(no model)"), cmp.Or(def.BaseURL, "(no base URL)"))
+	}
+	c := fieldConflict{Field: "definition", Local: summary(local), Incoming: summary(incoming)}
+	if c.Local == c.Incoming {
+		c.Incoming += " (other settings differ)"
+	}
+
+	return c
+}
+
+// parseProviderSnippet decodes and validates a snippet before anything is
+// written to config.yaml.
+func parseProviderSnippet(data []byte) (providerSnippet, error) {
+	var s providerSnippet
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&s); err != nil {
+		return providerSnippet{}, kairoerrors.WrapError(kairoerrors.ValidationError,
+			"invalid provider snippet", err).
+			WithContext("hint", "paste the whole output of 'kairo providers export'")
+	}
+	if err := validateProviderSnippet(s); err != nil {
+		return providerSnippet{}, err
+	}
+
+	return s, nil
+}
+
+// validateProviderSnippet checks the settings of s as setup checks those
+// entered by hand.
+func validateProviderSnippet(s providerSnippet) error {
+	if s.Provider == "" || len(s.Provider) > validate.MaxProviderNameLength ||
+		!providerNamePattern.MatchString(s.Provider) {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("provider snippet: invalid provider %q", s.Provider))
+	}
+	if s.Definition != nil {
+		if err := validateCustomProviderDefinition(s.Provider, *s.Definition); err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(s.Name) == "" {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "provider snippet: name is required")
+	}
+	if s.BaseURL != "" {
+		if err := validate.ValidateURL(s.BaseURL, s.Provider); err != nil {
+			return err
+		}
+	}
+	if s.Model != "" {
+		if err := validate.ValidateProviderModel(s.Provider, s.Model); err != nil {
+			return err
+		}
+	}
+	// validateCustomProviderDefinition covers auth_style and env_vars.
+	check := providers.CustomProviderDefinition{Name: s.Name, AuthStyle: s.AuthStyle, EnvVars: s.EnvVars}
+	if err := validateCustomProviderDefinition(s.Provider, check); err != nil {
+		return err
+	}
+	if _, secret := splitSecretEnvVars(s.EnvVars); len(secret) > 0 {
+		ui.PrintWarn(fmt.Sprintf("The snippet sets %s in config.yaml in plain text", strings.Join(secret, ", ")))
+	}
+
+	return nil
+}
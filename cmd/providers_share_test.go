@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+)
+
+func TestProvidersExportImport(t *testing.T) {
+	defer func() {
+		providersExportNoSecrets, providersImportForce, providersImportStrategy = false, false, ""
+	}()
+	src := t.TempDir()
+	writeExplainConfig(t, src, `providers:
+  gateway:
+    name: Team Gateway
+    base_url: https://llm.example.com/anthropic
+    model: gw-large
+    env_vars:
+      - API_TIMEOUT_MS=600000
+      - GATEWAY_TOKEN=tok-0123456789abcdef
+custom_providers:
+  gateway:
+    name: Team Gateway
+    base_url: https://llm.example.com/anthropic
+    model: gw-large
+    requires_api_key: true
+    api_key_env_var: GATEWAY_API_KEY
+`)
+	cmd, out := explainTestCmd(t, src)
+
+	if err := runProvidersExport(cmd, "gateway"); err == nil || !strings.Contains(err.Error(), "GATEWAY_TOKEN") {
+		t.Fatalf("runProvidersExport() without --no-secrets error = %v, want GATEWAY_TOKEN refused", err)
+	}
+	providersExportNoSecrets = true
+	if err := runProvidersExport(cmd, "gateway"); err != nil {
+		t.Fatalf("runProvidersExport() error = %v", err)
+	}
+	snippet := out.String()
+	if strings.Contains(snippet, "tok-0123456789abcdef") {
+		t.Fatalf("snippet leaks the token:\n%s", snippet)
+	}
+	for _, want := range []string{"provider: gateway", "API_TIMEOUT_MS=600000", "Left out as credentials",
+		"kairo secrets set gateway", "definition:"} {
+		if !strings.Contains(snippet, want) {
+			t.Errorf("snippet missing %q:\n%s", want, snippet)
+		}
+	}
+
+	dst := t.TempDir()
+	importCmd, _ := explainTestCmd(t, dst)
+	importCmd.SetIn(strings.NewReader(snippet))
+	if err := runProvidersImport(importCmd, "-"); err != nil {
+		t.Fatalf("runProvidersImport() error = %v", err)
+	}
+	cfg, err := config.LoadConfig(context.Background(), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cfg.Providers["gateway"]
+	if got.BaseURL != "https://llm.example.com/anthropic" || got.Model != "gw-large" ||
+		len(got.EnvVars) != 1 || cfg.DefaultProvider != "gateway" {
+		t.Errorf("imported provider = %+v, default %q", got, cfg.DefaultProvider)
+	}
+	if def := cfg.CustomProviders["gateway"]; def.APIKeyEnvVar != "GATEWAY_API_KEY" {
+		t.Errorf("imported definition = %+v", def)
+	}
+
+	importCmd.SetIn(strings.NewReader(snippet))
+	if err := runProvidersImport(importCmd, "-"); err != nil {
+		t.Errorf("runProvidersImport() of an unchanged provider error = %v", err)
+	}
+
+	changed := strings.Replace(snippet, "model: gw-large", "model: gw-small", 1)
+	importChanged := func(strategy string, force bool) (string, error) {
+		t.Helper()
+		providersImportStrategy, providersImportForce = strategy, force
+		importCmd.SetIn(strings.NewReader(changed))
+		err := runProvidersImport(importCmd, "-")
+		cfg, loadErr := config.LoadConfig(context.Background(), dst)
+		if loadErr != nil {
+			t.Fatal(loadErr)
+		}
+
+		return cfg.Providers["gateway"].Model, err
+	}
+	if _, err := importChanged("", false); err == nil {
+		t.Error("runProvidersImport() over a changed provider: want an error without a terminal or --strategy")
+	}
+	for _, strategy := range []string{"keep-local", "merge"} {
+		if model, err := importChanged(strategy, false); err != nil || model != "gw-large" {
+			t.Errorf("runProvidersImport() with --strategy %s = model %q, %v; want gw-large kept", strategy, model, err)
+		}
+	}
+	if model, err := importChanged("take-incoming", false); err != nil || model != "gw-small" {
+		t.Errorf("runProvidersImport() with --strategy take-incoming = model %q, %v; want gw-small", model, err)
+	}
+	changed = strings.Replace(changed, "model: gw-small", "model: gw-medium", 1)
+	if model, err := importChanged("", true); err != nil || model != "gw-medium" {
+		t.Errorf("runProvidersImport() with --force = model %q, %v; want gw-medium", model, err)
+	}
+}
+
+func TestParseProviderSnippetRejects(t *testing.T) {
+	tests := map[string]string{
+		"unknown field": "provider: x\nname: X\nbase_uri: https://x.example.com\n",
+		"bad provider":  "provider: 1x\nname: X\n",
+		"missing name":  "provider: x\nbase_url: https://x.example.com\n",
+		"http base url": "provider: x\nname: X\nbase_url: http://x.example.com\n",
+		"bad env var":   "provider: x\nname: X\nenv_vars: [NOEQUALS]\n",
+		"bad auth":      "provider: x\nname: X\nauth_style: cookie\n",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseProviderSnippet([]byte(data)); err == nil {
+				t.Errorf("parseProviderSnippet(%q) expected error", data)
+			}
+		})
+	}
+}
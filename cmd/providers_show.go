@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/secrets"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var providersShowCmd = &cobra.Command{
+	Use:   "show <provider>",
+	Short: "Show everything kairo knows about a configured provider",
+	Long: `Print one provider's resolved configuration with the file that set each
+field, the environment variables a switch sets for the default harness, the
+fingerprint of its stored API key, when it was last used, rotated, and
+health-checked, whether its model is still in the provider catalog, and the
+command that switches to it.
+
+The secrets file is decrypted to fingerprint the key; a key stored with
+'kairo secrets set --command' is not fetched.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runProvidersShow(cmd, args[0], time.Now()); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	providersCmd.AddCommand(providersShowCmd)
+}
+
+func runProvidersShow(cmd *cobra.Command, name string, now time.Time) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+
+	cfg, origins, err := config.LoadConfigOrigins(cliCtx.RootCtx(), dir)
+	if err != nil {
+		if stderrors.Is(err, kairoerrors.ErrConfigNotFound) {
+			printNoProvidersMessage()
+		} else {
+			handleConfigError(cmd, err)
+		}
+
+		return errReported
+	}
+	provider, ok := cfg.Providers[name]
+	if !ok {
+		return kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("provider '%s' not configured", name)).
+			WithContext("hint", "run 'kairo list' to see configured providers")
+	}
+
+	out := cmd.OutOrStdout()
+	title := name
+	if provider.Name != "" && provider.Name != name {
+		title += " (" + provider.Name + ")"
+	}
+	if name == cfg.DefaultProvider {
+		title += " [default]"
+	}
+	if provider.Disabled {
+		title += " [disabled]"
+	}
+	fmt.Fprintln(out, title)
+
+	fields, err := origins.Fields(cfg)
+	if err != nil {
+		return err
+	}
+	prefix := "providers." + name + "."
+	fmt.Fprintln(out, "\nConfiguration:")
+	for _, f := range fields {
+		if path, ok := strings.CutPrefix(f.Path, prefix); ok {
+			fmt.Fprintf(out, "  %s: %s  # %s\n", path, f.Value, f.Origin)
+		}
+	}
+
+	fmt.Fprintln(out, "\nAPI key:")
+	hasKey := showProviderKey(out, cliCtx, dir, name)
+
+	harnessToUse := resolveHarness("", cfg.DefaultHarness)
+	provider.Model = provider.ModelFor(harnessToUse)
+	fmt.Fprintf(out, "\nEnvironment set for %s:\n", harnessToUse)
+	execCfg := ExecutionConfig{HarnessToUse: harnessToUse, Provider: provider, ProviderName: name}
+	if hasKey {
+		execCfg.APIKey = "<API key>"
+	}
+	env := injectedEnv(execCfg)
+	if hasKey && harnessToUse == harness.Pi {
+		env[piKeyEnvVar(name, provider)] = execCfg.APIKey
+	}
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		fmt.Fprintf(out, "  %s=%s\n", k, env[k])
+	}
+
+	fmt.Fprintln(out, "\nActivity:")
+	state := stateDir(cliCtx, dir)
+	showProviderActivity(out, cliCtx, dir, name, now)
+	health := lastHealthLine(state, name, now)
+	if health == "" {
+		health = "never checked; run 'kairo status " + name + "'"
+	}
+	fmt.Fprintln(out, "  Health      : "+health)
+
+	fmt.Fprintln(out, "\nCatalog:")
+	fmt.Fprintln(out, "  "+providerCatalogStatus(cliCtx, name, provider))
+
+	if provider.Disabled {
+		fmt.Fprintln(out, "\nDisabled; enable with:")
+		fmt.Fprintln(out, "  kairo providers enable "+name)
+
+		return nil
+	}
+	fmt.Fprintln(out, "\nSwitch with:")
+	fmt.Fprintln(out, "  kairo "+name)
+	if name == cfg.DefaultProvider {
+		fmt.Fprintln(out, "  kairo --")
+	}
+
+	return nil
+}
+
+// showProviderKey prints how name's API key is stored and reports whether
+// there is one. It only decrypts the secrets file; a command-backed key is
+// not fetched.
+func showProviderKey(out io.Writer, cliCtx *CLIContext, dir, name string) bool {
+	if !providers.RequiresAPIKey(name) {
+		fmt.Fprintln(out, "  not required")
+
+		return false
+	}
+
+	secretsResult, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		fmt.Fprintf(out, "  %s✗%s %s\n", ui.Red, ui.Reset, kairoerrors.Describe(err))
+
+		return false
+	}
+	value, found := lookupAPIKeyWithFallback(secretsResult.Secrets, name)
+	if !found {
+		fmt.Fprintf(out, "  not stored; run 'kairo secrets set %s'\n", name)
+
+		return false
+	}
+
+	envVar := harness.APIKeyEnvVar(name)
+	if _, ok := secretsResult.Secrets[envVar]; !ok {
+		envVar = harness.APIKeyEnvVar(customProviderName)
+	}
+	if _, isCommand := secrets.Command(value); isCommand {
+		fmt.Fprintf(out, "  %s, fetched by a command at switch time\n", envVar)
+	} else {
+		fmt.Fprintf(out, "  %s, fingerprint %s\n", envVar, secrets.Fingerprint(value))
+	}
+
+	return true
+}
+
+// showProviderActivity prints the last switch to and key change of name
+// recorded in the audit log.
+func showProviderActivity(out io.Writer, cliCtx *CLIContext, dir, name string, now time.Time) {
+	entries, err := loadAuditEntries(cliCtx, dir)
+	if err != nil {
+		fmt.Fprintf(out, "  Audit log   : %s\n", kairoerrors.Describe(err))
+
+		return
+	}
+
+	var lastSwitch, lastKey *audit.Entry
+	for i := range entries {
+		e := &entries[i]
+		if e.Provider != name {
+			continue
+		}
+		switch e.Event {
+		case audit.EventSwitch:
+			lastSwitch = e
+		case audit.EventRotate:
+			lastKey = e
+		}
+	}
+
+	when := func(e *audit.Entry) string {
+		return ui.FormatTime(e.Timestamp, utcFlag) + " (" + ui.RelativeTime(e.Timestamp, now) + ")"
+	}
+	switch {
+	case lastSwitch == nil:
+		fmt.Fprintln(out, "  Last used   : no switch in the audit log")
+	case lastSwitch.Details["harness"] != "":
+		fmt.Fprintf(out, "  Last used   : %s with %s\n", when(lastSwitch), lastSwitch.Details["harness"])
+	default:
+		fmt.Fprintf(out, "  Last used   : %s\n", when(lastSwitch))
+	}
+	if lastKey != nil {
+		fmt.Fprintf(out, "  Key changed : %s (%s)\n", when(lastKey), lastKey.Action)
+	}
+}
+
+// providerCatalogStatus describes where name's definition comes from and
+// whether its configured model is still one the catalog names, which is
+// how a retired model shows up.
+func providerCatalogStatus(cliCtx *CLIContext, name string, provider config.Provider) string {
+	catalog := cliCtx.Deps().Catalog
+	def, ok := catalog.BuiltInProvider(name)
+	if !ok {
+		return "not in the provider catalog; configured by hand"
+	}
+
+	status := "from the " + catalog.ProviderSource(name) + " catalog"
+	if provider.Model != "" && !slices.Contains(def.Models(), provider.Model) {
+		return fmt.Sprintf("%s; model %s is not in it (catalog default: %s) and may be deprecated",
+			status, provider.Model, def.Model)
+	}
+
+	return status + "; model is current"
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+func TestRunProvidersShow(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, "default_provider: zai\nproviders:\n  zai:\n    name: Z.AI\n"+
+		"    base_url: https://api.z.ai/api/anthropic\n    model: glm-retired\n")
+
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(dir)
+	cliCtx.SetDeps(NewDeps())
+	var out bytes.Buffer
+	cmd := &cobra.Command{Use: "kairo"}
+	cmd.SetOut(&out)
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		t.Fatal(err)
+	}
+	const key = "sk-zai-test-key-abcdefghijklmnopqrst"
+	err := SaveSecrets(cliCtx, filepath.Join(dir, constants.SecretsFileName), filepath.Join(dir, constants.KeyFileName),
+		map[string]string{"ZAI_API_KEY": key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	logger := audit.NewLogger(stateDir(cliCtx, dir), audit.Policy{})
+	if err := logger.Log(audit.Entry{Timestamp: now.Add(-2 * time.Hour), Event: audit.EventSwitch, Provider: "zai",
+		Details: map[string]string{"harness": "claude"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runProvidersShow(cmd, "zai", now); err != nil {
+		t.Fatalf("runProvidersShow() error = %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"zai (Z.AI) [default]",
+		"base_url: https://api.z.ai/api/anthropic  # config.yaml",
+		"ZAI_API_KEY, fingerprint " + secrets.Fingerprint(key),
+		"ANTHROPIC_AUTH_TOKEN=<API key>",
+		"ANTHROPIC_MODEL=glm-retired",
+		"with claude",
+		"never checked",
+		"model glm-retired is not in it",
+		"kairo zai",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, key) {
+		t.Error("output contains the API key")
+	}
+
+	if err := runProvidersShow(cmd, "kimi", now); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("runProvidersShow() for an unconfigured provider error = %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bufio"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/constants"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsutil"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/update"
+	"github.com/spf13/cobra"
+)
+
+var providersTrustCmd = &cobra.Command{
+	Use:   "trust [public-key]",
+	Short: "Trust a signer of provider bundles, or list the trusted signers",
+	Long: `Add a minisign public key to the trusted signers in the config directory.
+'kairo providers import' only accepts a provider bundle whose signature was
+made with one of them, so a base URL changed after signing is refused.
+
+The key is the base64 line of the signer's minisign .pub file, or the path of
+the file. Without an argument, the trusted signers are listed by key ID.`,
+	Example: `  kairo providers trust RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3
+  kairo providers trust ./acme-platform.pub`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		if len(args) == 0 {
+			err = runProvidersTrustList(cmd)
+		} else {
+			err = runProvidersTrustEdit(cmd, args[0], true)
+		}
+		if err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+var providersUntrustCmd = &cobra.Command{
+	Use:   "untrust <key-id|public-key>",
+	Short: "Stop trusting a signer of provider bundles",
+	Long: `Remove a signer from the trusted signers, by the key ID 'kairo providers
+trust' lists or by its public key. Providers already imported from its
+bundles stay configured.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := runProvidersTrustEdit(cmd, args[0], false)
+		if err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	providersCmd.AddCommand(providersTrustCmd)
+	providersCmd.AddCommand(providersUntrustCmd)
+}
+
+func runProvidersTrustList(cmd *cobra.Command) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	signers, err := readTrustedSigners(filepath.Join(dir, constants.TrustedSignersFileName))
+	if err != nil {
+		return err
+	}
+	if len(signers) == 0 {
+		ui.PrintInfo("No trusted signers; add one with 'kairo providers trust <public-key>'")
+
+		return nil
+	}
+
+	out := cmd.OutOrStdout()
+	for _, pk := range signers {
+		fmt.Fprintf(out, "%s  %s\n", pk.ID(), pk)
+	}
+
+	return nil
+}
+
+// runProvidersTrustEdit adds the signer named by arg to the trusted signers,
+// or removes it.
+func runProvidersTrustEdit(cmd *cobra.Command, arg string, add bool) error {
+	dir := requireConfigDirWritable(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	path := filepath.Join(dir, constants.TrustedSignersFileName)
+	signers, err := readTrustedSigners(path)
+	if err != nil {
+		return err
+	}
+
+	var pk update.MinisignPublicKey
+	if !add {
+		i := slices.IndexFunc(signers, func(s update.MinisignPublicKey) bool {
+			return strings.EqualFold(s.ID(), strings.TrimSpace(arg))
+		})
+		if i >= 0 {
+			pk = signers[i]
+		}
+	}
+	if pk.Key == nil {
+		if pk, err = parseSignerKey(arg); err != nil {
+			return err
+		}
+	}
+
+	trusted := slices.ContainsFunc(signers, func(s update.MinisignPublicKey) bool { return s.ID() == pk.ID() })
+	switch {
+	case add && trusted:
+		ui.PrintInfo(fmt.Sprintf("Signer %s is already trusted", pk.ID()))
+
+		return nil
+	case !add && !trusted:
+		return kairoerrors.NewError(kairoerrors.ValidationError, fmt.Sprintf("signer %s is not trusted", pk.ID())).
+			WithContext("path", path).
+			WithContext("hint", "run 'kairo providers trust' to list the trusted signers")
+	}
+
+	if err := editTrustedSigners(path, pk, add); err != nil {
+		return err
+	}
+
+	action, message := "trust_signer", "Trusted signer "+pk.ID()
+	if !add {
+		action, message = "untrust_signer", "Stopped trusting signer "+pk.ID()
+	}
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:   audit.EventConfig,
+		Action:  action,
+		Details: map[string]string{"signer": pk.ID()},
+	})
+	ui.PrintSuccess(message)
+
+	return nil
+}
+
+// parseSignerKey parses a minisign public key given as its base64 line or
+// as the path of a .pub file.
+func parseSignerKey(arg string) (update.MinisignPublicKey, error) {
+	if data, err := os.ReadFile(arg); err == nil {
+		arg = string(data)
+	}
+	pk, err := update.ParseMinisignPublicKey(arg)
+	if err != nil {
+		return update.MinisignPublicKey{}, kairoerrors.WrapError(kairoerrors.ValidationError,
+			"not a minisign public key", err).
+			WithContext("hint", "pass the RW... line of the signer's minisign .pub file, or the file itself")
+	}
+
+	return pk, nil
+}
+
+// readTrustedSigners returns the minisign public keys listed in the trusted
+// signers file at path, or none if it does not exist.
+func readTrustedSigners(path string) ([]update.MinisignPublicKey, error) {
+	data, err := os.ReadFile(path)
+	if stderrors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, kairoerrors.FileError("failed to read trusted signers file", path, err)
+	}
+
+	var signers []update.MinisignPublicKey
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pk, err := update.ParseMinisignPublicKey(line)
+		if err != nil {
+			return nil, kairoerrors.WrapError(kairoerrors.ConfigError,
+				fmt.Sprintf("line %d of the trusted signers file is not a minisign public key", n), err).
+				WithContext("path", path).
+				WithContext("hint", "each line must hold one key, as added by 'kairo providers trust'")
+		}
+		signers = append(signers, pk)
+	}
+
+	return signers, nil
+}
+
+// editTrustedSigners appends pk to the trusted signers file at path, or
+// drops the lines holding it, leaving comments and other lines as they are.
+func editTrustedSigners(path string, pk update.MinisignPublicKey, add bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !stderrors.Is(err, fs.ErrNotExist) {
+		return kairoerrors.FileError("failed to read trusted signers file", path, err)
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	if add {
+		if len(lines) == 0 {
+			lines = append(lines, "# Minisign keys whose provider bundles kairo accepts; see 'kairo providers trust'.")
+		}
+		lines = append(lines, pk.String())
+	} else {
+		lines = slices.DeleteFunc(lines, func(line string) bool {
+			listed, err := update.ParseMinisignPublicKey(strings.TrimSpace(line))
+
+			return err == nil && listed.ID() == pk.ID()
+		})
+	}
+
+	if err := fsutil.WriteAtomic(path, func(f *os.File) error {
+		_, err := f.WriteString(strings.Join(lines, "\n") + "\n")
+
+		return err
+	}); err != nil {
+		return kairoerrors.FileError("failed to write trusted signers file", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsutil"
+	"github.com/dkmnx/kairo/internal/secrets"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var recipientsCmd = &cobra.Command{
+	Use:   "recipients",
+	Short: "Manage who the secrets file is encrypted to",
+	Long: `secrets.age is encrypted to the key in age.key and to every age recipient
+listed in the recipients file beside it, one age1... per line as printed by
+'kairo key show --public' on the other machine. Listing a teammate's key or
+a second machine's lets them decrypt the file with their own age.key. An
+organization's escrow recipient, set with 'kairo escrow set', is added too.
+
+Each write of secrets.age encrypts to the current list. After adding or
+removing a recipient, 'kairo recipients rekey' re-encrypts the existing file
+to it at once, without generating a new key the way 'kairo rotate' does.
+Recipients only apply to the age crypto backend.`,
+}
+
+var recipientsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print the recipients the secrets file is encrypted to",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runRecipientsList(cmd); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+var recipientsAddCmd = &cobra.Command{
+	Use:   "add <recipient>",
+	Short: "Also encrypt the secrets file to an age recipient",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRecipientsEdit(cmd, args[0], true); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+var recipientsRemoveCmd = &cobra.Command{
+	Use:   "remove <recipient>",
+	Short: "Stop encrypting the secrets file to an age recipient",
+	Long: `Remove an age recipient from the recipients file. The current secrets.age
+stays readable with its key until 'kairo recipients rekey' re-encrypts it,
+and copies taken before then stay readable for good, so rotate the API keys
+a lost machine held as well.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRecipientsEdit(cmd, args[0], false); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+var recipientsRekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "Re-encrypt the secrets file to the current recipients",
+	Long: `Re-encrypt secrets.age to the key in age.key and the recipients listed in
+the recipients file, keeping age.key as it is. The file it replaces is kept
+as a backup for 'kairo secrets recover'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runRecipientsRekey(cmd); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	recipientsCmd.AddCommand(recipientsListCmd)
+	recipientsCmd.AddCommand(recipientsAddCmd)
+	recipientsCmd.AddCommand(recipientsRemoveCmd)
+	recipientsCmd.AddCommand(recipientsRekeyCmd)
+	rootCmd.AddCommand(recipientsCmd)
+}
+
+// recipientsConfig loads the config of dir, refusing a KMS backend, which
+// has no recipients.
+func recipientsConfig(cliCtx *CLIContext, dir string) (*config.Config, error) {
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil && !stderrors.Is(err, kairoerrors.ErrConfigNotFound) {
+		return nil, err
+	}
+	if cfg != nil && cfg.Crypto != nil && cfg.Crypto.Backend != "" && cfg.Crypto.Backend != crypto.BackendAge {
+		return nil, kairoerrors.NewError(kairoerrors.ConfigError,
+			fmt.Sprintf("crypto.backend is %s, which has no age recipients", cfg.Crypto.Backend)).
+			WithContext("hint", "anyone with access to the KMS key can decrypt secrets.age")
+	}
+
+	return cfg, nil
+}
+
+func runRecipientsList(cmd *cobra.Command) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	cfg, err := recipientsConfig(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+
+	own, err := publicRecipient(cliCtx.RootCtx(), dir, cfg)
+	if err != nil {
+		return err
+	}
+	listed, err := crypto.ReadRecipients(filepath.Join(dir, constants.RecipientsFileName))
+	if err != nil {
+		return err
+	}
+
+	escrow, err := crypto.ReadEscrow(filepath.Join(dir, constants.EscrowFileName))
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "%s  (%s)\n", own, constants.KeyFileName)
+	for _, r := range listed {
+		if r != own && r != escrow {
+			fmt.Fprintln(out, r)
+		}
+	}
+	if escrow != "" {
+		fmt.Fprintf(out, "%s  (%s)\n", escrow, constants.EscrowFileName)
+	}
+
+	return nil
+}
+
+// runRecipientsEdit adds recipient to the recipients file, or removes it.
+func runRecipientsEdit(cmd *cobra.Command, recipient string, add bool) error {
+	recipient = strings.TrimSpace(recipient)
+	if _, err := age.ParseX25519Recipient(recipient); err != nil {
+		return kairoerrors.WrapError(kairoerrors.ValidationError, "not an age recipient", err).
+			WithContext("hint", "pass the age1... line 'kairo key show --public' prints on the other machine")
+	}
+
+	dir := requireConfigDirWritable(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	if _, err := recipientsConfig(cliCtx, dir); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, constants.RecipientsFileName)
+	listed, err := crypto.ReadRecipients(path)
+	if err != nil {
+		return err
+	}
+	switch {
+	case add && slices.Contains(listed, recipient):
+		ui.PrintInfo("Already a recipient")
+
+		return nil
+	case !add && !slices.Contains(listed, recipient):
+		return kairoerrors.NewError(kairoerrors.ValidationError, "not a listed recipient").
+			WithContext("path", path).
+			WithContext("hint", "run 'kairo recipients list' to see the recipients")
+	}
+
+	if err := editRecipientsFile(path, recipient, add); err != nil {
+		return err
+	}
+
+	action, message := "add_recipient", "Added the recipient"
+	if !add {
+		action, message = "remove_recipient", "Removed the recipient"
+	}
+	recordAudit(cliCtx, dir, audit.Entry{Event: audit.EventConfig, Action: action})
+	ui.PrintSuccess(message)
+	if _, err := os.Stat(filepath.Join(dir, constants.SecretsFileName)); err == nil {
+		ui.PrintInfo("Run 'kairo recipients rekey' to re-encrypt secrets.age to the new list now")
+	}
+
+	return nil
+}
+
+// editRecipientsFile appends recipient to the recipients file at path, or
+// drops the lines holding it, leaving comments and other lines as they are.
+func editRecipientsFile(path, recipient string, add bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !stderrors.Is(err, fs.ErrNotExist) {
+		return kairoerrors.FileError("failed to read recipients file", path, err)
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	if add {
+		if len(lines) == 0 {
+			lines = append(lines, "# Further age recipients secrets.age is encrypted to; see 'kairo recipients'.")
+		}
+		lines = append(lines, recipient)
+	} else {
+		lines = slices.DeleteFunc(lines, func(line string) bool {
+			return strings.TrimSpace(line) == recipient
+		})
+	}
+
+	if err := fsutil.WriteAtomic(path, func(f *os.File) error {
+		_, err := f.WriteString(strings.Join(lines, "\n") + "\n")
+
+		return err
+	}); err != nil {
+		return kairoerrors.FileError("failed to write recipients file", path, err)
+	}
+
+	return nil
+}
+
+func runRecipientsRekey(cmd *cobra.Command) error {
+	dir := requireConfigDirWritable(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	if _, err := recipientsConfig(cliCtx, dir); err != nil {
+		return err
+	}
+
+	secretsPath := filepath.Join(dir, constants.SecretsFileName)
+	if _, err := os.Stat(secretsPath); stderrors.Is(err, fs.ErrNotExist) {
+		ui.PrintInfo("No secrets.age yet; it is encrypted to the current recipients when first written")
+
+		return nil
+	}
+	if err := rekeySecrets(cliCtx, dir); err != nil {
+		return err
+	}
+
+	ui.PrintSuccess("Re-encrypted secrets.age to the current recipients")
+
+	return nil
+}
+
+// rekeySecrets re-encrypts the secrets file in dir to the current
+// recipients without changing the key.
+func rekeySecrets(cliCtx *CLIContext, dir string) error {
+	secretsPath := filepath.Join(dir, constants.SecretsFileName)
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+	svc, err := cryptoFor(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+	// A broken recipients file is reported before anything is touched.
+	if _, err := crypto.ReadRecipients(crypto.RecipientsPath(keyPath)); err != nil {
+		return err
+	}
+
+	// The sealed payload is carried over as it is, so entries this version
+	// of kairo cannot parse are not lost.
+	payload, err := readSecretsFile(cliCtx, secretsPath, keyPath)
+	if err != nil {
+		return err
+	}
+	defer crypto.ClearMemory(payload)
+	if err := backupSecretsFile(cliCtx, secretsPath, keyPath); err != nil {
+		return err
+	}
+	cliCtx.Secrets().Invalidate(secretsPath)
+	if err := svc.EncryptSecrets(cliCtx.RootCtx(), secretsPath, keyPath, secrets.Seal(string(payload))); err != nil {
+		return kairoerrors.WrapError(kairoerrors.CryptoError, "re-encrypting secrets", err)
+	}
+
+	recordAudit(cliCtx, dir, audit.Entry{Event: audit.EventRotate, Action: "rekey_secrets"})
+
+	return nil
+}
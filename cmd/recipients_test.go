@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+)
+
+func TestRecipientsAddRekeyRemove(t *testing.T) {
+	originalConfigDir := testCLI.ConfigDir()
+	defer func() { testCLI.SetConfigDir(originalConfigDir) }()
+
+	dir := t.TempDir()
+	cfg := &config.Config{Providers: map[string]config.Provider{"zai": {Name: "Z.AI"}}}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+	testCLI.SetConfigDir(dir)
+	key := "zai-test-key-0123456789abcdef0123456789"
+	if err := storeProviderSecret(NewCLIContext(), dir, "zai", key, "set_secret"); err != nil {
+		t.Fatal(err)
+	}
+	keyBefore, err := os.ReadFile(filepath.Join(dir, constants.KeyFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teammate, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	teammateKeyPath := filepath.Join(t.TempDir(), constants.KeyFileName)
+	keyFile := teammate.String() + "\n" + teammate.Recipient().String() + "\n"
+	if err := os.WriteFile(teammateKeyPath, []byte(keyFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	secretsPath := filepath.Join(dir, constants.SecretsFileName)
+	teammateCanRead := func() bool {
+		_, err := crypto.DecryptSecrets(context.Background(), secretsPath, teammateKeyPath)
+
+		return err == nil
+	}
+
+	rootCmd.SetArgs([]string{"--config", dir, "recipients", "add", teammate.Recipient().String()})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	listed, err := crypto.ReadRecipients(filepath.Join(dir, constants.RecipientsFileName))
+	if err != nil || len(listed) != 1 || listed[0] != teammate.Recipient().String() {
+		t.Fatalf("recipients file lists %v, %v; want the teammate", listed, err)
+	}
+	if teammateCanRead() {
+		t.Fatal("secrets.age was re-encrypted before rekey")
+	}
+
+	rootCmd.SetArgs([]string{"--config", dir, "recipients", "rekey"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !teammateCanRead() {
+		t.Error("teammate cannot decrypt secrets.age after rekey")
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, constants.KeyFileName)); !bytes.Equal(got, keyBefore) {
+		t.Error("rekey replaced age.key")
+	}
+	result, err := LoadSecrets(NewCLIContext(), dir)
+	if err != nil || result.Secrets["ZAI_API_KEY"] != key {
+		t.Errorf("LoadSecrets() after rekey = %v, %v", result.Secrets, err)
+	}
+
+	rootCmd.SetArgs([]string{"--config", dir, "recipients", "remove", teammate.Recipient().String()})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	rootCmd.SetArgs([]string{"--config", dir, "recipients", "rekey"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if teammateCanRead() {
+		t.Error("removed recipient can still decrypt secrets.age after rekey")
+	}
+}
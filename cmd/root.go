@@ -3,20 +3,37 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
 
 	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/events"
 	"github.com/dkmnx/kairo/internal/harness"
 	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/ui"
 	"github.com/dkmnx/kairo/internal/version"
+	"github.com/dkmnx/kairo/internal/warnlimit"
 	"github.com/spf13/cobra"
 )
 
 var (
 	harnessFlag         string
+	modelFlag           string
 	skipPermissionsFlag bool
+	resumeFlag          string
+	continueFlag        bool
 	verboseFlag         bool
+	utcFlag             bool
+	timeoutFlag         time.Duration
+	quietFlag           bool
+	strictFlag          bool
 )
 
 // verbose reports whether verbose output should be emitted. It reads from the
@@ -44,10 +61,24 @@ Version: %s (commit: %s, date: %s)`, version.Version, version.Commit, version.Da
 	},
 }
 
-// Execute runs the root command.
+// Execute runs the root command. The first Ctrl-C or SIGTERM cancels the
+// root context so that running operations can clean up; a second one
+// terminates kairo as usual.
 func Execute() error {
 	cliCtx := NewCLIContext()
+	defer cliCtx.Close()
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-sigCtx.Done()
+		stop()
+	}()
+	cliCtx.SetRootCtx(sigCtx)
+
+	prevPromptCtx := promptRootCtx
 	promptRootCtx = cliCtx.RootCtx()
+	defer func() { promptRootCtx = prevPromptCtx }()
 
 	args := os.Args[1:]
 	cliCtx.SetDefaultProviderExplicit(hasLeadingArgsSeparator(args))
@@ -66,7 +97,21 @@ func Execute() error {
 		rootCmd.SetArgs(nil)
 	}()
 
-	return rootCmd.Execute()
+	installExplain(rootCmd)
+	promptRefused.Store(false)
+	if err := rootCmd.Execute(); err != nil {
+		return err
+	}
+	if promptRefused.Load() {
+		return errPromptRefused
+	}
+	if errors.Is(context.Cause(cliCtx.RootCtx()), errCommandTimeout) {
+		return kairoerrors.NewError(kairoerrors.RuntimeError,
+			fmt.Sprintf("timed out after %s", timeoutFlag)).
+			WithContext("hint", "raise --timeout, or use --timeout 0 to disable it")
+	}
+
+	return nil
 }
 
 // SetArgs overrides os.Args for the next Execute call. Production code never
@@ -80,9 +125,41 @@ func SetArgs(args ...string) {
 func init() {
 	rootCmd.PersistentFlags().String("config", "", "Config directory (default is platform-specific)")
 	rootCmd.PersistentFlags().BoolVarP(&verboseFlag, "verbose", "v", false, "Verbose output")
+	rootCmd.PersistentFlags().BoolVar(&utcFlag, "utc", false, "Show timestamps in UTC instead of local time")
+	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false,
+		"Print only command output on stdout; suppress status messages (warnings and errors still go to stderr)")
+	rootCmd.PersistentFlags().DurationVar(&timeoutFlag, "timeout", 0,
+		"Cancel kairo's own work (not the harness session) after this long, e.g. 30s (0 disables)")
+	rootCmd.PersistentFlags().BoolVar(&strictFlag, "strict", false,
+		"Reject unknown config fields and mistyped values, reporting line and column (as with 'validation: strict')")
+	rootCmd.PersistentFlags().BoolVar(&yesFlag, "yes", false,
+		"Answer yes to every confirmation instead of asking")
+	rootCmd.PersistentFlags().BoolVar(&nonInteractiveFlag, "non-interactive", false,
+		"Never prompt: fail with a message naming the flag to use instead (as does "+nonInteractiveEnv+"=1)")
+	rootCmd.PersistentFlags().BoolVar(&explainFlag, "explain", false,
+		"Print a JSON plan of the files, environment, processes, and secrets the command would touch, instead of running it")
 	rootCmd.Flags().StringVar(&harnessFlag, "harness", "", "CLI harness to use (claude, qwen, pi, or crush)")
+	rootCmd.Flags().StringVar(&modelFlag, "model", "", "Model to use for this run instead of the provider's configured model")
+	_ = rootCmd.RegisterFlagCompletionFunc("model", completeModels)
+	rootCmd.ValidArgsFunction = completeProviders(false)
 	rootCmd.Flags().BoolVarP(&skipPermissionsFlag, "yolo", "y", false,
 		"Skip permission prompts (--dangerously-skip-permissions for Claude, --yolo for Qwen)")
+	rootCmd.Flags().StringVar(&resumeFlag, "resume", "",
+		"Resume this harness session (--resume for Claude and Qwen, --session for Pi)")
+	rootCmd.Flags().BoolVar(&continueFlag, "continue", false,
+		"Continue the harness's most recent session in the current directory")
+	rootCmd.MarkFlagsMutuallyExclusive("resume", "continue")
+	rootCmd.Flags().BoolVar(&adoptEnvFlag, "adopt-env", false,
+		"Store a provider key found in the environment, encrypted, when none is stored, without asking")
+	rootCmd.Flags().BoolVar(&captureUsageFlag, "capture-usage", false,
+		"Record the session's token usage from Claude Code's telemetry (as with 'usage.capture: true')")
+	rootCmd.Flags().BoolVar(&explainEnvFlag, "explain-env", false,
+		"Print the effective harness environment (secrets masked) and exit")
+	rootCmd.Flags().DurationVar(&waitHealthyFlag, "wait-healthy", 0,
+		"Probe the provider before starting the harness, retrying for up to this long (default 1m when given without a value)")
+	rootCmd.Flags().Lookup("wait-healthy").NoOptDefVal = waitHealthyDefault.String()
+	rootCmd.Flags().StringVar(&eventsFDFlag, "events-fd", "",
+		"Write the switch's lifecycle events as JSON lines to this file descriptor, or to a file or named pipe path")
 
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
 		cliCtx := CLIContextFromCmd(cmd)
@@ -95,6 +172,29 @@ func init() {
 			cliCtx.SetConfigDir(configFlag)
 		}
 		cliCtx.SetVerbose(verboseFlag)
+		ui.SetQuiet(quietFlag)
+
+		if strictFlag {
+			cliCtx.SetRootCtx(config.WithStrictValidation(cliCtx.RootCtx()))
+			cmd.SetContext(WithCLIContext(cliCtx.RootCtx(), cliCtx))
+		}
+
+		if timeoutFlag > 0 {
+			cliCtx.ApplyTimeout(timeoutFlag)
+			promptRootCtx = cliCtx.RootCtx()
+			cmd.SetContext(WithCLIContext(cliCtx.RootCtx(), cliCtx))
+		}
+
+		// --explain only reports what a command would do, so it leaves an
+		// interrupted operation for the next real run.
+		if !explainFlag {
+			recoverInterruptedOperation(cliCtx, cliCtx.ConfigDir())
+		}
+
+		if err := enforcePolicy(cmd, cliCtx, args); err != nil {
+			printError(err)
+			cliCtx.Deps().Process.ExitProcess(1)
+		}
 	}
 }
 
@@ -108,6 +208,7 @@ func runPiProvider(
 ) {
 	envResult, err := BuildProviderEnv(cliCtx, cliCtx.ConfigDir(), provider, providerName)
 	if err != nil {
+		emitSwitchError(cliCtx.Events(), events.Decrypt, err)
 		handleSecretsError(err)
 
 		return
@@ -116,26 +217,54 @@ func runPiProvider(
 	providerEnv := envResult.ProviderEnv
 	secrets := envResult.Secrets
 
+	names, err := piKeyProviders(cfg)
+	if err != nil {
+		emitSwitchError(cliCtx.Events(), events.Decrypt, err)
+		printError(err)
+
+		return
+	}
+	resolver := newSecretResolver(cliCtx)
 	hasAnyKey := false
-	for pName, p := range cfg.Providers {
-		piEnvVar, ok := providers.APIKeyEnvVarFor(pName)
-		if !ok {
-			if p.EnvKey == "" {
-				piEnvVar = harness.APIKeyEnvVar(pName)
-			} else {
-				piEnvVar = p.EnvKey
+	var keyProviders []string
+	for _, pName := range names {
+		p := cfg.Providers[pName]
+		piEnvVar := piKeyEnvVar(pName, p)
+		val, found, err := resolver.resolveAPIKey(secrets, pName)
+		if err != nil {
+			// Only the selected provider's key is needed to start; the
+			// others are a convenience for switching models inside Pi.
+			if pName == providerName {
+				emitSwitchError(cliCtx.Events(), events.Decrypt, err)
+				printError(err)
+
+				return
 			}
+			printLimitedWarn(cliCtx, warnlimit.PiProviderKey, pName, kairoerrors.Describe(err))
+
+			continue
+		}
+		if !found && pName == providerName {
+			val, found = adoptEnvKey(cliCtx, pName, p)
 		}
-		val, found := lookupAPIKeyWithFallback(secrets, pName)
 		if found {
 			providerEnv = append(providerEnv, fmt.Sprintf("%s=%s", piEnvVar, val))
 			hasAnyKey = true
+			keyProviders = append(keyProviders, pName)
 		}
 	}
+	cliCtx.Events().Emit(events.Event{Event: events.Decrypt})
 
 	execCfg := buildExecutionConfig(cmd, cliCtx, providerEnv, provider, providerName, harnessToUse, harnessArgs, "")
+	if !runPreflight(execCfg) {
+		return
+	}
+	recordSwitch(&execCfg)
 
 	if hasAnyKey {
+		for _, pName := range keyProviders {
+			notifySecretAccess(cliCtx, cliCtx.ConfigDir(), pName, accessPurposeSwitch)
+		}
 		executeWithAuth(execCfg)
 	} else {
 		executeWithoutAuth(execCfg)
@@ -151,25 +280,66 @@ func runStandardProvider(
 ) {
 	envResult, err := BuildProviderEnv(cliCtx, cliCtx.ConfigDir(), provider, providerName)
 	if err != nil {
+		emitSwitchError(cliCtx.Events(), events.Decrypt, err)
 		handleSecretsError(err)
 
 		return
 	}
 
-	apiKey, hasKey := lookupAPIKeyWithFallback(envResult.Secrets, providerName)
+	apiKey, hasKey, err := newSecretResolver(cliCtx).resolveAPIKey(envResult.Secrets, providerName)
+	if err != nil {
+		emitSwitchError(cliCtx.Events(), events.Decrypt, err)
+		printError(err)
+
+		return
+	}
+	if !hasKey {
+		apiKey, hasKey = adoptEnvKey(cliCtx, providerName, provider)
+	}
+	cliCtx.Events().Emit(events.Event{Event: events.Decrypt})
 
 	execCfg := buildExecutionConfig(
 		cmd, cliCtx, envResult.ProviderEnv, provider,
 		providerName, harnessToUse, harnessArgs, apiKey,
 	)
+	if !runPreflight(execCfg) {
+		return
+	}
+	recordSwitch(&execCfg)
 
 	if hasKey {
+		notifySecretAccess(cliCtx, cliCtx.ConfigDir(), providerName, accessPurposeSwitch)
 		executeWithAuth(execCfg)
 	} else {
 		executeWithoutAuth(execCfg)
 	}
 }
 
+// piKeyProviders returns the providers whose keys runPiProvider hands to Pi,
+// sorted: every provider that is enabled and that the policy allows.
+func piKeyProviders(cfg *config.Config) ([]string, error) {
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(cfg.Providers)) {
+		if !cfg.Providers[name].Disabled {
+			names = append(names, name)
+		}
+	}
+
+	return allowedProviders(names)
+}
+
+// piKeyEnvVar returns the variable runPiProvider exports providerName's key as.
+func piKeyEnvVar(providerName string, provider config.Provider) string {
+	if name, ok := providers.APIKeyEnvVarFor(providerName); ok {
+		return name
+	}
+	if provider.EnvKey != "" {
+		return provider.EnvKey
+	}
+
+	return harness.APIKeyEnvVar(providerName)
+}
+
 func lookupAPIKeyWithFallback(secrets map[string]string, providerName string) (string, bool) {
 	if val, ok := secrets[harness.APIKeyEnvVar(providerName)]; ok {
 		return val, true
@@ -204,5 +374,6 @@ func buildExecutionConfig(
 		APIKey:        apiKey,
 		Yolo:          skipPermissionsFlag,
 		Deps:          cliCtx.Deps(),
+		TempDir:       configuredTempDir(cliCtx),
 	}
 }
@@ -12,6 +12,7 @@ import (
 
 	"github.com/dkmnx/kairo/internal/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func TestRootCmd(t *testing.T) {
@@ -311,3 +312,26 @@ func TestIsKnownProvider(t *testing.T) {
 		t.Error("Expected unknown provider to not be known")
 	}
 }
+
+// TestNoFlagShadowsPersistentFlag checks that no subcommand defines a local
+// flag with the name or shorthand of a persistent flag of the root command,
+// which would silently replace the global flag for that subcommand.
+func TestNoFlagShadowsPersistentFlag(t *testing.T) {
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		c.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+			if p := rootCmd.PersistentFlags().Lookup(f.Name); p != nil {
+				t.Errorf("%q defines --%s, shadowing the global flag", c.CommandPath(), f.Name)
+			}
+			if f.Shorthand != "" && rootCmd.PersistentFlags().ShorthandLookup(f.Shorthand) != nil {
+				t.Errorf("%q defines -%s, shadowing the global flag", c.CommandPath(), f.Shorthand)
+			}
+		})
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	for _, sub := range rootCmd.Commands() {
+		walk(sub)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/dkmnx/kairo/internal/config"
@@ -122,3 +123,59 @@ func TestRunPiProviderWithAuth(t *testing.T) {
 		t.Error("Expected executeWithAuth to be called for Pi harness with API key")
 	}
 }
+
+func TestRunPiProvider_SkipsProvidersDeniedByPolicy(t *testing.T) {
+	setTestPolicy(t, "providers:\n  allow: [minimax]\n")
+	tmpDir := t.TempDir()
+
+	cfg := &config.Config{
+		DefaultProvider: "minimax",
+		Providers: map[string]config.Provider{
+			"minimax": {Name: "MiniMax", BaseURL: "https://api.minimax.io/anthropic", Model: "MiniMax-M2"},
+			"zai":     {Name: "Z.AI", BaseURL: "https://api.z.ai", Model: "glm-5"},
+		},
+	}
+	createConfigFile(t, tmpDir, cfg)
+
+	keyPath := filepath.Join(tmpDir, "age.key")
+	secretsPath := filepath.Join(tmpDir, "secrets.age")
+	if err := crypto.GenerateKey(context.Background(), keyPath); err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	if err := crypto.EncryptSecrets(context.Background(), secretsPath, keyPath,
+		"MINIMAX_API_KEY=sk-minimax-test\nZAI_API_KEY=sk-zai-test\n"); err != nil {
+		t.Fatalf("EncryptSecrets: %v", err)
+	}
+
+	output := &bytes.Buffer{}
+	rootCmd.SetOut(output)
+	rootCmd.SetErr(output)
+
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(tmpDir)
+
+	var started *exec.Cmd
+	cliCtx.SetDeps(testDeps(func(mp *mockProcess, _ *mockWrapper, _ *mockUpdate) {
+		mp.LookPathFn = func(string) (string, error) { return "/usr/bin/pi", nil }
+		mp.ExecCommandContextFn = func(ctx context.Context, _ string, _ ...string) *exec.Cmd {
+			started = exec.CommandContext(ctx, "true")
+
+			return started
+		}
+	}))
+	skipPermissionsFlag = false
+	harnessFlag = ""
+
+	runPiProvider(rootCmd, cliCtx, cfg, cfg.Providers["minimax"], "minimax", "pi", nil)
+
+	if started == nil {
+		t.Fatal("Pi was not started")
+	}
+	env := strings.Join(started.Env, "\n")
+	if strings.Contains(env, "sk-zai-test") {
+		t.Error("Pi's environment holds the key of zai, which the policy denies")
+	}
+	if !strings.Contains(env, "sk-minimax-test") {
+		t.Errorf("Pi's environment lacks the key of minimax, which the policy allows:\n%s", env)
+	}
+}
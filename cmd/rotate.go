@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/secrets"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/yarlson/tap"
+)
+
+// revokeHookTimeout bounds a provider's revoke_hook, which usually calls a
+// provider API over the network.
+const revokeHookTimeout = 30 * time.Second
+
+var (
+	rotateProvider    string
+	rotateNewKeyStdin bool
+)
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Rotate the encryption key or a provider's API key",
+	Long: `Without flags, generate a new encryption key and re-encrypt the secrets file
+with it. With a KMS crypto.backend, the secrets are re-encrypted under a new
+data key wrapped by the KMS key's current version.
+
+With --provider, replace that provider's API key instead. The new key is read
+from a prompt, or from stdin with --new-key-stdin. Only fingerprints of the old
+and new keys are written to the audit log.
+
+A provider can revoke the old key after a successful swap with a revoke_hook
+in config.yaml. The hook runs through the shell with the old key on stdin and
+KAIRO_PROVIDER set; a failing hook is reported but does not undo the swap:
+
+  providers:
+    zai:
+      revoke_hook: ./scripts/revoke-zai-key.sh`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		if rotateProvider != "" {
+			err = runRotateProviderKey(cmd, rotateProvider)
+		} else {
+			err = runRotateMasterKey(cmd)
+		}
+		if err != nil && !errors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	rotateCmd.Flags().StringVar(&rotateProvider, "provider", "", "Rotate this provider's API key instead of the encryption key")
+	rotateCmd.Flags().BoolVar(&rotateNewKeyStdin, "new-key-stdin", false, "Read the new API key from stdin (requires --provider)")
+	rootCmd.AddCommand(rotateCmd)
+}
+
+func runRotateMasterKey(cmd *cobra.Command) error {
+	if rotateNewKeyStdin {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "--new-key-stdin requires --provider")
+	}
+
+	dir := requireConfigDirWritable(cmd)
+	if dir == "" {
+		return errReported
+	}
+
+	ui.PrintInfo("This generates a new encryption key and re-encrypts your secrets with it.")
+	ok, err := confirmed("rotating the encryption key", func() (bool, error) { return ui.Confirm("Continue") })
+	if errors.Is(err, errPromptsOff) {
+		return err
+	}
+	if err != nil || !ok {
+		return kairoerrors.ErrUserCancelled
+	}
+
+	cliCtx := CLIContextFromCmd(cmd)
+	if err := rotateMasterKey(cliCtx, dir); err != nil {
+		return err
+	}
+
+	ui.PrintSuccess("Encryption key rotated")
+
+	return nil
+}
+
+// rotateMasterKey re-encrypts the secrets in dir under new key material: a
+// new age key, or a new data key wrapped by the KMS when crypto.backend
+// names one.
+func rotateMasterKey(cliCtx *CLIContext, dir string) error {
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		return err
+	}
+
+	svc, err := cryptoFor(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+
+	secretsPath := filepath.Join(dir, constants.SecretsFileName)
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+	if _, err := os.Stat(secretsPath); errors.Is(err, fs.ErrNotExist) {
+		if err := SaveSecrets(cliCtx, secretsPath, keyPath, map[string]string{}); err != nil {
+			return err
+		}
+	}
+	// Re-encrypting a corrupted payload would only carry the damage over to
+	// the new key, where the backups could no longer repair it.
+	payload, err := readSecretsFile(cliCtx, secretsPath, keyPath)
+	if err != nil {
+		return err
+	}
+	count := len(secrets.Parse(string(payload)))
+	crypto.ClearMemory(payload)
+
+	ctx := cliCtx.RootCtx()
+	if out := terminalWriter(os.Stderr); out != nil && !ui.Quiet() {
+		ctx = crypto.WithProgress(ctx, rotateProgress(out, count))
+	}
+	if err := svc.RotateKeyring(ctx, secretsPath, keyPath); err != nil {
+		return kairoerrors.WrapError(kairoerrors.CryptoError, "rotating encryption key", err)
+	}
+	// The backups are encrypted with the old key and cannot be read after.
+	if err := removeSecretsBackups(secretsPath); err != nil {
+		return err
+	}
+
+	recordAudit(cliCtx, dir, audit.Entry{Event: audit.EventRotate, Action: "rotate_master_key"})
+
+	return nil
+}
+
+// rotateProgress returns a ProgressFunc that shows how much of a secrets
+// file holding count secrets has been re-encrypted, rewriting one line of
+// out in place.
+func rotateProgress(out io.Writer, count int) crypto.ProgressFunc {
+	last := -1
+
+	return func(done, total int64) {
+		percent := 100
+		if total > 0 {
+			percent = int(done * 100 / total)
+		}
+		if percent == last {
+			return
+		}
+		last = percent
+		fmt.Fprintf(out, "\rRe-encrypting %d secrets... %3d%%", count, percent)
+		if percent == 100 {
+			fmt.Fprintln(out)
+		}
+	}
+}
+
+func runRotateProviderKey(cmd *cobra.Command, providerName string) error {
+	dir := requireConfigDirWritable(cmd)
+	if dir == "" {
+		return errReported
+	}
+
+	cfg, err := loadConfigOrExit(cmd)
+	if err != nil || cfg == nil {
+		return errReported
+	}
+
+	provider, ok := cfg.Providers[providerName]
+	if !ok {
+		return kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("provider '%s' not configured", providerName)).
+			WithContext("hint", "run 'kairo setup' to configure it")
+	}
+	if !providers.RequiresAPIKey(providerName) {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("provider '%s' does not use an API key", providerName))
+	}
+
+	var newKey string
+	if rotateNewKeyStdin {
+		newKey, err = readKeyFromReader(cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+	} else {
+		if err := requireInteractive("the new API key", "pipe it into 'kairo rotate --provider "+providerName+" --new-key-stdin'"); err != nil {
+			return err
+		}
+		label := ProviderDefinition(providerName).Name
+		newKey = tap.Password(promptContext(), tap.PasswordOptions{Message: fmt.Sprintf("New API Key for %s", label)})
+		if newKey == "" {
+			return kairoerrors.ErrUserCancelled
+		}
+	}
+
+	cliCtx := CLIContextFromCmd(cmd)
+	oldKey, err := swapProviderKey(cliCtx, dir, providerName, newKey)
+	if err != nil {
+		return err
+	}
+	ui.PrintSuccess(fmt.Sprintf("API key for '%s' rotated", providerName))
+
+	if provider.RevokeHook == "" || oldKey == "" {
+		return nil
+	}
+	if _, ok := secrets.Command(oldKey); ok {
+		ui.PrintInfo("The old key came from a secret command; revoke it where that command reads it.")
+
+		return nil
+	}
+
+	notifySecretAccess(cliCtx, dir, providerName, accessPurposeRevoke)
+	if err := runRevokeHook(cliCtx.RootCtx(), cliCtx.Deps(), provider.RevokeHook, providerName, oldKey, cmd.ErrOrStderr()); err != nil {
+		ui.PrintWarn(fmt.Sprintf("revoke_hook for '%s' failed: %v", providerName, err))
+		ui.PrintWarn("The new key is in place; revoke the old key manually.")
+		recordAudit(cliCtx, dir, audit.Entry{
+			Event:    audit.EventRotate,
+			Action:   "revoke_failed",
+			Provider: providerName,
+			Details:  map[string]string{"old_fingerprint": secrets.Fingerprint(oldKey)},
+		})
+
+		return nil
+	}
+
+	ui.PrintSuccess("Old key revoked")
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:    audit.EventRotate,
+		Action:   "revoke_key",
+		Provider: providerName,
+		Details:  map[string]string{"old_fingerprint": secrets.Fingerprint(oldKey)},
+	})
+
+	return nil
+}
+
+// readKeyFromReader reads a single key from the first line of r. The line
+// is returned as read, line ending included, for normalizeAPIKey to clean
+// and report on when the key is stored.
+func readKeyFromReader(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", kairoerrors.WrapError(kairoerrors.RuntimeError, "failed to read key from stdin", err)
+	}
+
+	if strings.TrimSpace(line) == "" {
+		return "", kairoerrors.NewError(kairoerrors.ValidationError, "no key provided on stdin")
+	}
+
+	return line, nil
+}
+
+// swapProviderKey validates newKey and replaces providerName's key in the
+// encrypted secrets file in a single write. It returns the previous key, or
+// "" if none was stored.
+func swapProviderKey(cliCtx *CLIContext, dir, providerName, newKey string) (string, error) {
+	newKey, err := normalizeAPIKey(providerName, newKey)
+	if err != nil {
+		return "", err
+	}
+	if err := ProviderDefinition(providerName).ValidateAPIKey(newKey); err != nil {
+		return "", err
+	}
+	warnKeyStrength(providerName, newKey)
+
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		return "", err
+	}
+
+	secretsResult, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		return "", err
+	}
+
+	envVar := harness.APIKeyEnvVar(providerName)
+	oldKey := secretsResult.Secrets[envVar]
+	if oldKey == newKey {
+		return "", kairoerrors.NewError(kairoerrors.ValidationError,
+			"new key is the same as the current key")
+	}
+
+	secretsResult.Secrets[envVar] = newKey
+	if err := SaveSecrets(cliCtx, secretsResult.SecretsPath, secretsResult.KeyPath, secretsResult.Secrets); err != nil {
+		return "", err
+	}
+
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:    audit.EventRotate,
+		Action:   "rotate_key",
+		Provider: providerName,
+		Details:  secretChangeDetails(oldKey, newKey),
+	})
+
+	return oldKey, nil
+}
+
+// runRevokeHook runs hook through the platform shell with oldKey on stdin.
+func runRevokeHook(ctx context.Context, deps *Deps, hook, providerName, oldKey string, out io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, revokeHookTimeout)
+	defer cancel()
+
+	c := hookCommand(ctx, deps, hook)
+	if c == nil {
+		return kairoerrors.NewError(kairoerrors.RuntimeError, "failed to start revoke hook")
+	}
+	c.Stdin = strings.NewReader(oldKey + "\n")
+	c.Stdout = out
+	c.Stderr = out
+	c.Env = append(os.Environ(), "KAIRO_PROVIDER="+providerName)
+
+	if err := c.Run(); err != nil {
+		return kairoerrors.WrapError(kairoerrors.RuntimeError, "revoke hook failed", err).
+			WithContext("provider", providerName)
+	}
+
+	return nil
+}
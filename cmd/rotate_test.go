@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/secrets"
+)
+
+func TestRotateMasterKey(t *testing.T) {
+	dir := t.TempDir()
+	cliCtx := NewCLIContext()
+	key := "zai-test-key-0123456789abcdef0123456789"
+	if err := storeProviderSecret(cliCtx, dir, "zai", key, "set_secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+	oldIdentity, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rotateMasterKey(cliCtx, dir); err != nil {
+		t.Fatalf("rotateMasterKey() error = %v", err)
+	}
+
+	newIdentity, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(oldIdentity, newIdentity) {
+		t.Error("key file unchanged after rotation")
+	}
+	if _, err := os.Stat(keyPath + ".new"); !os.IsNotExist(err) {
+		t.Errorf("temporary key file left behind: %v", err)
+	}
+
+	result, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		t.Fatalf("LoadSecrets() after rotation error = %v", err)
+	}
+	if got := result.Secrets["ZAI_API_KEY"]; got != key {
+		t.Errorf("ZAI_API_KEY = %q, want %q", got, key)
+	}
+}
+
+func TestRotateProgress(t *testing.T) {
+	var out bytes.Buffer
+	progress := rotateProgress(&out, 500)
+	for _, done := range []int64{0, 400, 401, 1000} {
+		progress(done, 1000)
+	}
+
+	want := "\rRe-encrypting 500 secrets...   0%\rRe-encrypting 500 secrets...  40%\rRe-encrypting 500 secrets... 100%\n"
+	if out.String() != want {
+		t.Errorf("rotateProgress() output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestSwapProviderKey(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Providers: map[string]config.Provider{"zai": {Name: "Z.AI"}},
+		Audit:     &config.AuditConfig{Enabled: true},
+	}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	cliCtx := NewCLIContext()
+	oldKey := "zai-old-key-0123456789abcdef0123456789"
+	newKey := "zai-new-key-0123456789abcdef0123456789"
+	if err := storeProviderSecret(cliCtx, dir, "zai", oldKey, "set_secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := swapProviderKey(cliCtx, dir, "zai", newKey)
+	if err != nil {
+		t.Fatalf("swapProviderKey() error = %v", err)
+	}
+	if got != oldKey {
+		t.Errorf("swapProviderKey() old key = %q, want %q", got, oldKey)
+	}
+
+	result, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Secrets["ZAI_API_KEY"] != newKey {
+		t.Errorf("ZAI_API_KEY = %q, want new key", result.Secrets["ZAI_API_KEY"])
+	}
+
+	if _, err := swapProviderKey(cliCtx, dir, "zai", newKey); err == nil {
+		t.Error("swapProviderKey() expected error when the key is unchanged")
+	}
+
+	entries, err := audit.LoadEntries(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := entries[len(entries)-1]
+	if last.Action != "rotate_key" || last.Details["old_fingerprint"] != secrets.Fingerprint(oldKey) {
+		t.Errorf("last audit entry = %+v, want rotate_key with old fingerprint", last)
+	}
+	for _, e := range entries {
+		for _, v := range e.Details {
+			if strings.Contains(v, "0123456789abcdef") {
+				t.Errorf("audit entry leaks key material: %+v", e)
+			}
+		}
+	}
+}
+
+func TestReadKeyFromReader(t *testing.T) {
+	got, err := readKeyFromReader(strings.NewReader("  sk-key \r\nignored\n"))
+	if err != nil || got != "  sk-key \r\n" {
+		t.Errorf("readKeyFromReader() = %q, %v; want the first line as read", got, err)
+	}
+
+	if _, err := readKeyFromReader(strings.NewReader("\n")); err == nil {
+		t.Error("readKeyFromReader() expected error for empty input")
+	}
+}
+
+func TestRunRevokeHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("revoke hook test uses a POSIX shell")
+	}
+
+	out := filepath.Join(t.TempDir(), "revoked")
+	hook := `printf '%s:' "$KAIRO_PROVIDER" > ` + out + ` && cat >> ` + out
+	deps := NewDeps()
+
+	if err := runRevokeHook(context.Background(), deps, hook, "zai", "sk-old", new(bytes.Buffer)); err != nil {
+		t.Fatalf("runRevokeHook() error = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "zai:sk-old\n" {
+		t.Errorf("hook saw %q, want provider name and old key on stdin", data)
+	}
+
+	if err := runRevokeHook(context.Background(), deps, "exit 3", "zai", "sk-old", new(bytes.Buffer)); err == nil {
+		t.Error("runRevokeHook() expected error for failing hook")
+	}
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/envutil"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/execution"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// runProvider is the provider 'kairo run -- <command>' injects; the default
+// provider when empty.
+var runProvider string
+
+// runCommandWithProvider runs args as a command with the environment a switch
+// to the provider with Claude Code would inject: the base URL, model and
+// provider variables, and the API key handed over by the wrapper. kairo
+// prints nothing on stdout, so the command's output can be piped.
+func runCommandWithProvider(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigOrExit(cmd)
+	if err != nil || cfg == nil {
+		return errReported
+	}
+	providerName, provider, err := runCommandProvider(cfg, args)
+	if err != nil {
+		return err
+	}
+	pol, id, err := loadPolicy()
+	if err != nil {
+		return err
+	}
+	if err := checkProviderPolicy(pol, id, providerName); err != nil {
+		return err
+	}
+
+	cliCtx := CLIContextFromCmd(cmd)
+	if _, err := cliCtx.Deps().Process.LookPath(args[0]); err != nil {
+		return harnessNotFoundError(args[0], err)
+	}
+	envResult, err := BuildProviderEnv(cliCtx, cliCtx.ConfigDir(), provider, providerName)
+	if err != nil {
+		return err
+	}
+	apiKey, hasKey, err := newSecretResolver(cliCtx).resolveAPIKey(envResult.Secrets, providerName)
+	if err != nil {
+		return err
+	}
+	if !hasKey {
+		apiKey, hasKey = adoptEnvKey(cliCtx, providerName, provider)
+	}
+	if !hasKey && providers.RequiresAPIKey(providerName) {
+		ui.PrintWarn(fmt.Sprintf("no API key stored for '%s'; running without one", providerName))
+	}
+
+	execCfg := buildExecutionConfig(
+		cmd, cliCtx, envResult.ProviderEnv, provider,
+		providerName, harness.Claude, args[1:], apiKey,
+	)
+	execCfg.HarnessBinary = args[0]
+	if len(provider.RunEnvAllow) > 0 {
+		execCfg.ProviderEnv = allowlistedRunEnv(provider)
+	}
+	if !checkBaseURL(execCfg) {
+		return errReported
+	}
+	recordCommandRun(execCfg)
+
+	if hasKey {
+		notifySecretAccess(cliCtx, cliCtx.ConfigDir(), providerName, accessPurposeRun)
+
+		return execCommandWithAuth(execCfg)
+	}
+
+	return execCommandWithoutAuth(execCfg)
+}
+
+// runEnvAlwaysAllowed are passed on even when the provider sets
+// run_env_allow: the command is found through PATH, and PowerShell, which
+// runs the wrapper script on Windows, does not start without SystemRoot.
+var runEnvAlwaysAllowed = []string{"PATH", "SYSTEMROOT"}
+
+// allowlistedRunEnv returns the environment of a command run for a provider
+// that sets run_env_allow: the allowlisted variables of kairo's own
+// environment, then the variables kairo injects.
+func allowlistedRunEnv(provider config.Provider) []string {
+	ambient := envutil.Filter(os.Environ(), slices.Concat(provider.RunEnvAllow, runEnvAlwaysAllowed),
+		runtime.GOOS == constants.WindowsGOOS)
+
+	return mergeEnvVars(ambient, BuildBuiltInEnvVars(provider), provider.EnvVars)
+}
+
+// runCommandProvider returns the provider given with --provider, or the
+// default provider, with its Claude model, after checking args names a
+// command to run.
+func runCommandProvider(cfg *config.Config, args []string) (string, config.Provider, error) {
+	if len(args) == 0 {
+		return "", config.Provider{}, kairoerrors.NewError(kairoerrors.ValidationError, "no command to run").
+			WithContext("hint", "run 'kairo run -- <command> [args]', or pass --from-snapshot or --locked")
+	}
+	providerName := runProvider
+	if providerName == "" {
+		providerName = cfg.DefaultProvider
+	}
+	if providerName == "" {
+		return "", config.Provider{}, kairoerrors.NewError(kairoerrors.ConfigError,
+			"no provider given and no default provider set").
+			WithContext("hint", "run 'kairo run --provider <provider> -- <command>'")
+	}
+	provider, ok := cfg.Providers[providerName]
+	if !ok {
+		return "", config.Provider{}, kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("provider '%s' not configured", providerName)).
+			WithContext("hint", "run 'kairo list' to see configured providers")
+	}
+	if provider.Disabled {
+		return "", config.Provider{}, disabledProviderError(providerName)
+	}
+	provider.Model = provider.ModelFor(harness.Claude)
+
+	return providerName, provider, nil
+}
+
+// recordCommandRun audits a command started by 'kairo run' as a switch, with
+// the command's name in place of a harness.
+func recordCommandRun(cfg ExecutionConfig) {
+	cliCtx := CLIContextFromCmd(cfg.Cmd)
+	if cliCtx == nil {
+		return
+	}
+	recordAudit(cliCtx, cliCtx.ConfigDir(), audit.Entry{
+		Event:    audit.EventSwitch,
+		Action:   "run_command",
+		Provider: cfg.ProviderName,
+		Details: map[string]string{
+			"command": filepath.Base(cfg.HarnessBinary),
+			"model":   cfg.Provider.Model,
+		},
+	})
+}
+
+// execCommandWithAuth runs the command through the wrapper, which reads the
+// API key from a temporary token file and sets it in the command's
+// environment, so the key
+// never appears in kairo's own environment or on a command line.
+func execCommandWithAuth(cfg ExecutionConfig) error {
+	cleanStaleAuthDirs(cfg)
+
+	authDir, err := cfg.Deps.Wrapper.CreateTempAuthDir(cfg.TempDir)
+	if err != nil {
+		return authDirError("Error creating auth directory", cfg.tempDir(), err)
+	}
+	var cleanupOnce sync.Once
+	cleanup := func() {
+		cleanupOnce.Do(func() {
+			if err := os.RemoveAll(authDir); err != nil {
+				cfg.Cmd.Printf("Error cleaning up auth directory: %v\n", err)
+			}
+		})
+	}
+	defer cleanup()
+
+	tokenPath, err := cfg.Deps.Wrapper.WriteTempTokenFile(authDir, cfg.APIKey)
+	if err != nil {
+		return authDirError("Error creating secure token file", authDir, err)
+	}
+
+	err = runHarnessWithWrapper(harnessSessionContext(cfg.Cmd), cfg.Deps, HarnessRun{
+		AuthDir:       authDir,
+		TokenPath:     tokenPath,
+		HarnessBinary: cfg.HarnessBinary,
+		CliArgs:       cfg.HarnessArgs,
+		ProviderEnv:   cfg.ProviderEnv,
+		Provider:      cfg.Provider,
+		ProviderName:  cfg.ProviderName,
+		EnvVarName:    authEnvVarName(cfg),
+		Harness:       cfg.HarnessToUse,
+	})
+	if err != nil {
+		// reportHarnessError exits, which skips the deferred cleanup.
+		cleanup()
+		reportHarnessError(cfg, cfg.HarnessBinary, err)
+	}
+
+	return nil
+}
+
+// execCommandWithoutAuth runs the command directly for a provider that needs
+// no API key.
+func execCommandWithoutAuth(cfg ExecutionConfig) error {
+	path, err := cfg.Deps.Process.LookPath(cfg.HarnessBinary)
+	if err != nil {
+		return harnessNotFoundError(cfg.HarnessBinary, err)
+	}
+
+	execCmd := cfg.Deps.Process.ExecCommandContext(harnessSessionContext(cfg.Cmd), path, cfg.HarnessArgs...)
+	execCmd.Env = cfg.ProviderEnv
+	execCmd.Stdin = os.Stdin
+	execCmd.Stdout = os.Stdout
+	execCmd.Stderr = os.Stderr
+	if err := execution.Run(execCmd); err != nil {
+		reportHarnessError(cfg, cfg.HarnessBinary, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/claudesettings"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/plan"
+)
+
+func TestRunCommandProvider(t *testing.T) {
+	defer func() { runProvider = "" }()
+	cfg := &config.Config{
+		DefaultProvider: "zai",
+		Providers: map[string]config.Provider{
+			"zai":     {Name: "Z.AI", Model: "glm-4.7"},
+			"minimax": {Name: "MiniMax", Model: "MiniMax-M2", Models: map[string]string{"claude": "MiniMax-M2.1"}},
+		},
+	}
+
+	name, provider, err := runCommandProvider(cfg, []string{"env"})
+	if err != nil || name != "zai" || provider.Model != "glm-4.7" {
+		t.Errorf("runCommandProvider() = %q, %+v, %v; want the default provider", name, provider, err)
+	}
+
+	runProvider = "minimax"
+	if name, provider, err := runCommandProvider(cfg, []string{"env"}); err != nil || name != "minimax" ||
+		provider.Model != "MiniMax-M2.1" {
+		t.Errorf("runCommandProvider() with --provider = %q, %q, %v; want minimax with its Claude model",
+			name, provider.Model, err)
+	}
+
+	runProvider = "missing"
+	if _, _, err := runCommandProvider(cfg, []string{"env"}); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("runCommandProvider() of an unconfigured provider error = %v", err)
+	}
+
+	runProvider = ""
+	if _, _, err := runCommandProvider(cfg, nil); err == nil || !strings.Contains(err.Error(), "no command") {
+		t.Errorf("runCommandProvider() without a command error = %v", err)
+	}
+}
+
+func TestLockedProvider(t *testing.T) {
+	cfg := &config.Config{
+		DefaultHarness: "qwen",
+		Providers: map[string]config.Provider{
+			"zai": {
+				Name: "Z.AI", BaseURL: "https://api.z.ai/api/anthropic", Model: "glm-4.7",
+				Models: map[string]string{"qwen": "glm-4.7-openai"},
+			},
+		},
+	}
+
+	provider, harnessToUse := lockedHarness(cfg, cfg.Providers["zai"])
+	if harnessToUse != "qwen" || provider.Model != "glm-4.7-openai" {
+		t.Errorf("lockedHarness() = %q, %q; want qwen with its Qwen model", harnessToUse, provider.Model)
+	}
+	lock := providerLock("zai", provider, "")
+	if lock.Model != "glm-4.7-openai" {
+		t.Errorf("providerLock() model = %q, want the model run --locked starts", lock.Model)
+	}
+
+	got, err := lockedProvider(cfg, lock)
+	if err != nil || got.Model != "glm-4.7-openai" {
+		t.Errorf("lockedProvider() = %q, %v; want the Qwen model", got.Model, err)
+	}
+
+	lock.Model = "glm-4.7"
+	if _, err := lockedProvider(cfg, lock); err == nil || !strings.Contains(err.Error(), "no longer matches") {
+		t.Errorf("lockedProvider() of a lock pinning the base model error = %v, want drift", err)
+	}
+}
+
+func TestPlanRunCommand(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, `default_provider: zai
+providers:
+  zai:
+    name: Z.AI
+    base_url: https://api.z.ai/api/anthropic
+    model: glm-4.7
+    env_vars:
+      - API_TIMEOUT_MS=3000000
+`)
+	for _, name := range []string{constants.SecretsFileName, constants.KeyFileName} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("not a real key"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd, _ := explainTestCmd(t, dir)
+	p := plan.New("kairo run", []string{"python", "my_script.py"})
+	if err := planRunFromSnapshot(cmd, []string{"python", "my_script.py"}, p); err != nil {
+		t.Fatalf("planRunFromSnapshot() error = %v", err)
+	}
+
+	for _, want := range []string{"ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_BASE_URL", "ANTHROPIC_MODEL", "API_TIMEOUT_MS"} {
+		if !slices.Contains(p.EnvSet, want) {
+			t.Errorf("EnvSet = %v, want %s", p.EnvSet, want)
+		}
+	}
+	if !slices.Contains(p.Secrets, "ZAI_API_KEY") {
+		t.Errorf("Secrets = %v, want ZAI_API_KEY", p.Secrets)
+	}
+	last := p.Processes[len(p.Processes)-1]
+	if last.Path != "/usr/bin/python" || !slices.Equal(last.Args, []string{"my_script.py"}) {
+		t.Errorf("last process = %+v, want the command with its args", last)
+	}
+	if len(p.FilesRemoved) != 1 || !strings.Contains(p.FilesRemoved[0], "kairo-auth-") {
+		t.Errorf("FilesRemoved = %v, want the auth directory", p.FilesRemoved)
+	}
+}
+
+func TestAllowlistedRunEnv(t *testing.T) {
+	t.Setenv("KAIRO_TEST_ALLOWED", "yes")
+	t.Setenv("KAIRO_TEST_SECRET", "hunter2")
+	t.Setenv("LC_KAIRO_TEST", "C")
+	provider := config.Provider{
+		BaseURL:     "https://api.z.ai/api/anthropic",
+		Model:       "glm-4.7",
+		EnvVars:     []string{"API_TIMEOUT_MS=3000000"},
+		RunEnvAllow: []string{"KAIRO_TEST_ALLOWED", "LC_*"},
+	}
+
+	env := claudesettings.EnvMap(allowlistedRunEnv(provider))
+	for _, name := range []string{"KAIRO_TEST_ALLOWED", "LC_KAIRO_TEST", "PATH", "ANTHROPIC_BASE_URL", "ANTHROPIC_MODEL", "API_TIMEOUT_MS"} {
+		if _, ok := env[name]; !ok {
+			t.Errorf("allowlistedRunEnv() lacks %s: %v", name, env)
+		}
+	}
+	if _, ok := env["KAIRO_TEST_SECRET"]; ok {
+		t.Error("allowlistedRunEnv() passed a variable run_env_allow does not list")
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/secrets"
+)
+
+// secretCommandTimeout bounds a command-backed secret, which may wait for a
+// password manager to be unlocked.
+const secretCommandTimeout = 30 * time.Second
+
+// secretResolver turns stored secret values into credentials, running the
+// command of "command:" entries. Resolved values live only in memory for the
+// resolver's lifetime, so a command shared by several entries runs once per
+// switch and its output is never written to secrets.age.
+type secretResolver struct {
+	ctx   context.Context
+	deps  *Deps
+	cache map[string]string
+}
+
+func newSecretResolver(cliCtx *CLIContext) *secretResolver {
+	return &secretResolver{
+		ctx:   cliCtx.RootCtx(),
+		deps:  cliCtx.Deps(),
+		cache: make(map[string]string),
+	}
+}
+
+// resolve returns the credential value stands for, running its command when
+// it is a command-backed entry. provider names the entry in errors.
+func (r *secretResolver) resolve(provider, value string) (string, error) {
+	command, ok := secrets.Command(value)
+	if !ok {
+		return value, nil
+	}
+	if cached, ok := r.cache[command]; ok {
+		return cached, nil
+	}
+
+	resolved, err := runSecretCommand(r.ctx, r.deps, command)
+	if err != nil {
+		return "", kairoerrors.WrapError(kairoerrors.RuntimeError,
+			fmt.Sprintf("secret command for '%s' failed", provider), err).
+			WithContext("provider", provider).
+			WithContext("hint", "run the command by hand to check it, or replace it with 'kairo secrets set "+provider+"'")
+	}
+	r.cache[command] = resolved
+
+	return resolved, nil
+}
+
+// resolveAPIKey looks up providerName's API key, falling back to the custom
+// provider's, and resolves it. found reports whether an entry was stored.
+func (r *secretResolver) resolveAPIKey(secretsMap map[string]string, providerName string) (string, bool, error) {
+	value, found := lookupAPIKeyWithFallback(secretsMap, providerName)
+	if !found {
+		return "", false, nil
+	}
+	key, err := r.resolve(providerName, value)
+
+	return key, true, err
+}
+
+// runSecretCommand runs command through the platform shell and returns the
+// single line it prints. The terminal stays attached to stdin and stderr so
+// that password managers can prompt.
+func runSecretCommand(ctx context.Context, deps *Deps, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, secretCommandTimeout)
+	defer cancel()
+
+	c := hookCommand(ctx, deps, command)
+	if c == nil {
+		return "", kairoerrors.NewError(kairoerrors.RuntimeError, "failed to start secret command")
+	}
+	var stdout bytes.Buffer
+	c.Stdin = os.Stdin
+	c.Stdout = &stdout
+	c.Stderr = os.Stderr
+
+	if err := c.Run(); err != nil {
+		if stderrors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", kairoerrors.NewError(kairoerrors.RuntimeError,
+				fmt.Sprintf("timed out after %s", secretCommandTimeout))
+		}
+
+		return "", kairoerrors.WrapError(kairoerrors.RuntimeError, "command exited unsuccessfully", err)
+	}
+
+	value := strings.TrimSpace(stdout.String())
+	switch {
+	case value == "":
+		return "", kairoerrors.NewError(kairoerrors.ValidationError, "command printed nothing")
+	case strings.ContainsAny(value, "\r\n"):
+		return "", kairoerrors.NewError(kairoerrors.ValidationError,
+			"command printed more than one line; print only the secret "+
+				"(for example with --query SecretString --output text)")
+	}
+
+	return value, nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+)
+
+func TestSecretResolver(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("secret command test uses a POSIX shell")
+	}
+
+	runs := filepath.Join(t.TempDir(), "runs")
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(NewDeps())
+	r := newSecretResolver(cliCtx)
+
+	stored := map[string]string{
+		"ZAI_API_KEY":     "command:echo run >> " + runs + " && echo '  sk-from-command  '",
+		"MINIMAX_API_KEY": "command:echo run >> " + runs + " && echo '  sk-from-command  '",
+		"KIMI_API_KEY":    "sk-stored",
+	}
+	for _, name := range []string{"zai", "minimax"} {
+		key, found, err := r.resolveAPIKey(stored, name)
+		if err != nil || !found || key != "sk-from-command" {
+			t.Fatalf("resolveAPIKey(%s) = %q, %v, %v", name, key, found, err)
+		}
+	}
+	if data, _ := os.ReadFile(runs); strings.Count(string(data), "run") != 1 {
+		t.Errorf("command ran %d times, want once per switch", strings.Count(string(data), "run"))
+	}
+
+	if key, found, err := r.resolveAPIKey(stored, "kimi"); err != nil || !found || key != "sk-stored" {
+		t.Errorf("resolveAPIKey(kimi) = %q, %v, %v, want the stored key", key, found, err)
+	}
+	if _, found, err := r.resolveAPIKey(stored, "deepseek"); found || err != nil {
+		t.Errorf("resolveAPIKey(deepseek) found = %v, err = %v, want not found", found, err)
+	}
+}
+
+func TestSecretResolver_CommandErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("secret command test uses a POSIX shell")
+	}
+
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(NewDeps())
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		typ     kairoerrors.ErrorType
+	}{
+		{"fails", "exit 3", "exit status 3", kairoerrors.RuntimeError},
+		{"empty", "true", "printed nothing", kairoerrors.ValidationError},
+		{"multiline", "printf 'a\\nb\\n'", "more than one line", kairoerrors.ValidationError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newSecretResolver(cliCtx).resolve("zai", "command:"+tt.command)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "zai") {
+				t.Errorf("resolve() error = %v, want %q for zai", err, tt.want)
+			}
+			_, err = runSecretCommand(context.Background(), cliCtx.Deps(), tt.command)
+			var ke *kairoerrors.KairoError
+			if !stderrors.As(err, &ke) || ke.Type != tt.typ {
+				t.Errorf("runSecretCommand() error = %#v, want a %s error", err, tt.typ)
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/keyentry"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/secrets"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/yarlson/tap"
+)
+
+var (
+	secretsViaBrowser             bool
+	secretsBrowserListen          string
+	secretsBrowserTimeout         time.Duration
+	secretsValidateFailOnWarnings bool
+	secretsCommand                string
+	secretsSetForce               bool
+	secretsSetAPIKeyStdin         bool
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage provider API keys",
+	Long:  "Set the encrypted API keys used when switching to a provider.",
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set <provider>",
+	Short: "Set the API key for a configured provider",
+	Long: `Prompt for a provider's API key and store it in the encrypted secrets file.
+With --api-key-stdin, the key is read from piped stdin instead, for scripts:
+
+  op read op://vault/zai/key | kairo secrets set zai --api-key-stdin
+
+With --via-browser, kairo serves a one-time HTTPS page on localhost with a
+self-signed certificate instead of prompting. On a remote host, forward the
+port first (for example 'ssh -L 8443:127.0.0.1:8443 host') and open the
+printed URL in your local browser. The page accepts a single submission and
+stops after --browser-timeout.
+
+With --command, kairo stores a command instead of the key, such as
+'op read op://vault/zai/key' or 'aws secretsmanager get-secret-value
+--secret-id zai --query SecretString --output text'. The command runs through
+the shell each time you switch to the provider, with a 30 second timeout, and
+must print only the key. Its output is kept in memory for that switch and
+never written to secrets.age.
+
+kairo refuses to overwrite a secrets.age that cannot be decrypted or fails
+its checksum; run 'kairo secrets recover' first. With --force, the unreadable
+file is renamed to secrets.age.corrupt-<time> and a new one is started.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSecretsSet(cmd, args[0]); err != nil && !errors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+var secretsValidateCmd = &cobra.Command{
+	Use:   "validate [provider...]",
+	Short: "Check stored API keys for format problems and weak values",
+	Long: `Check the stored API key of each configured provider (or only the named
+ones) against the provider's key format rules and strength heuristics:
+entropy, repeated characters, placeholder text, stray whitespace or quotes,
+and a length below the provider's typical key length (a sign of a truncated
+paste).
+
+Exits with status 1 if a key is missing or fails the format rules, or, with
+--fail-on-warnings, if any key draws a warning.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		failed, err := runSecretsValidate(cmd, args)
+		if err != nil {
+			if !errors.Is(err, kairoerrors.ErrUserCancelled) {
+				printError(err)
+			}
+
+			return
+		}
+		if failed {
+			if cliCtx := CLIContextFromCmd(cmd); cliCtx != nil && cliCtx.Deps() != nil {
+				cliCtx.Deps().Process.ExitProcess(1)
+			}
+		}
+	},
+}
+
+func init() {
+	secretsValidateCmd.Flags().BoolVar(&secretsValidateFailOnWarnings, "fail-on-warnings", false,
+		"Also exit with status 1 when a key only draws strength warnings")
+	secretsCmd.AddCommand(secretsValidateCmd)
+	secretsSetCmd.Flags().BoolVar(&secretsViaBrowser, "via-browser", false,
+		"Enter the key on a one-time local HTTPS page instead of the terminal")
+	secretsSetCmd.Flags().StringVar(&secretsBrowserListen, "listen", "127.0.0.1:8443",
+		"Address for the --via-browser page")
+	secretsSetCmd.Flags().DurationVar(&secretsBrowserTimeout, "browser-timeout", 5*time.Minute,
+		"How long the --via-browser page waits for a key")
+	secretsSetCmd.Flags().StringVar(&secretsCommand, "command", "",
+		"Store a command that prints the key at switch time instead of the key")
+	secretsSetCmd.Flags().BoolVar(&secretsSetForce, "force", false,
+		"Set aside a secrets file that cannot be decrypted instead of refusing")
+	secretsSetCmd.Flags().BoolVar(&secretsSetAPIKeyStdin, "api-key-stdin", false,
+		"Read the key from piped stdin instead of prompting")
+	secretsSetCmd.MarkFlagsMutuallyExclusive("command", "via-browser", "api-key-stdin")
+	secretsCmd.AddCommand(secretsSetCmd)
+	rootCmd.AddCommand(secretsCmd)
+}
+
+func runSecretsSet(cmd *cobra.Command, providerName string) error {
+	dir := requireConfigDirWritable(cmd)
+	if dir == "" {
+		return errReported
+	}
+
+	cfg, err := loadConfigOrExit(cmd)
+	if err != nil || cfg == nil {
+		return errReported
+	}
+
+	if _, ok := cfg.Providers[providerName]; !ok {
+		return kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("provider '%s' not configured", providerName)).
+			WithContext("hint", "run 'kairo setup' to configure it")
+	}
+	if !providers.RequiresAPIKey(providerName) {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("provider '%s' does not use an API key", providerName))
+	}
+
+	cliCtx := CLIContextFromCmd(cmd)
+	label := ProviderDefinition(providerName).Name
+
+	var key string
+	switch {
+	case secretsCommand != "":
+		key = secrets.CommandPrefix + secretsCommand
+	case secretsViaBrowser:
+		key, err = readKeyViaBrowser(cliCtx.RootCtx(), label)
+		if err != nil {
+			return err
+		}
+	case secretsSetAPIKeyStdin:
+		if key, err = readAPIKeyFromStdin(cmd.InOrStdin()); err != nil {
+			return err
+		}
+	default:
+		if err := requireInteractive("the API key", "pipe it into 'kairo secrets set "+providerName+" --api-key-stdin'"); err != nil {
+			return err
+		}
+		key = tap.Password(promptContext(), tap.PasswordOptions{Message: fmt.Sprintf("API Key for %s", label)})
+		if key == "" {
+			return kairoerrors.ErrUserCancelled
+		}
+	}
+
+	if secretsSetForce {
+		if err := setAsideUnreadableSecrets(cliCtx, dir); err != nil {
+			return err
+		}
+	}
+	if err := storeProviderSecret(cliCtx, dir, providerName, key, "set_secret"); err != nil {
+		return err
+	}
+
+	if secretsCommand != "" {
+		ui.PrintSuccess(fmt.Sprintf("Secret command for '%s' saved", providerName))
+
+		return nil
+	}
+	ui.PrintSuccess(fmt.Sprintf("API key for '%s' saved", providerName))
+
+	return nil
+}
+
+// readKeyViaBrowser serves a one-time key entry page and waits for the key.
+func readKeyViaBrowser(ctx context.Context, label string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, secretsBrowserTimeout)
+	defer cancel()
+
+	session, err := keyentry.Start(ctx, secretsBrowserListen, label)
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	ui.PrintInfo("Open this URL in a browser and paste the key:")
+	ui.PrintWhite("  " + session.URL)
+	if _, port, err := net.SplitHostPort(secretsBrowserListen); err == nil && port != "0" {
+		ui.PrintInfo(fmt.Sprintf("Over SSH, forward the port first: ssh -L %s:127.0.0.1:%s <host>", port, port))
+	}
+	ui.PrintInfo("Certificate fingerprint (SHA-256): " + session.Fingerprint)
+	ui.PrintInfo(fmt.Sprintf("Waiting up to %s...", secretsBrowserTimeout))
+
+	return session.Wait(ctx)
+}
+
+// storeProviderSecret validates key for providerName and writes it to the
+// encrypted secrets file in dir. key may instead be a command-backed entry.
+// The change is audited as action, with the fingerprints of both values.
+func storeProviderSecret(cliCtx *CLIContext, dir, providerName, key, action string) error {
+	// A command-backed entry holds no key to check until it runs.
+	if _, isCommand := secrets.Command(key); !isCommand {
+		var err error
+		if key, err = normalizeAPIKey(providerName, key); err != nil {
+			return err
+		}
+		if err := ProviderDefinition(providerName).ValidateAPIKey(key); err != nil {
+			return err
+		}
+		warnKeyStrength(providerName, key)
+	}
+
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		return err
+	}
+
+	secretsResult, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+
+	envVar := harness.APIKeyEnvVar(providerName)
+	oldKey := secretsResult.Secrets[envVar]
+	secretsResult.Secrets[envVar] = key
+	if err := SaveSecrets(cliCtx, secretsResult.SecretsPath, secretsResult.KeyPath, secretsResult.Secrets); err != nil {
+		return err
+	}
+
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:    audit.EventRotate,
+		Action:   action,
+		Provider: providerName,
+		Details:  secretChangeDetails(oldKey, key),
+	})
+
+	return nil
+}
+
+// normalizeAPIKey cleans key as it was entered for providerName, printing
+// what it removed, such as a trailing newline from a pipe or a byte order
+// mark from a file saved on Windows. Command-backed entries are left alone.
+func normalizeAPIKey(providerName, key string) (string, error) {
+	if _, isCommand := secrets.Command(key); isCommand {
+		return key, nil
+	}
+	clean, notes, err := secrets.NormalizeKey(key)
+	if err != nil {
+		return "", kairoerrors.WrapError(kairoerrors.ValidationError,
+			fmt.Sprintf("%s: API key cannot be stored", ProviderDefinition(providerName).Name), err).
+			WithContext("hint", "copy the key again from the provider's dashboard, or pipe it from a UTF-8 file")
+	}
+	for _, note := range notes {
+		ui.PrintInfo(fmt.Sprintf("%s: %s from the API key", providerName, note))
+	}
+
+	return clean, nil
+}
+
+// secretChangeDetails describes a secret replaced by newValue for the audit
+// log by the fingerprints of the old and new values, never the values.
+func secretChangeDetails(oldValue, newValue string) map[string]string {
+	details := map[string]string{"new_fingerprint": secrets.Fingerprint(newValue)}
+	if oldValue != "" {
+		details["old_fingerprint"] = secrets.Fingerprint(oldValue)
+	}
+
+	return details
+}
+
+// warnKeyStrength prints a warning for each strength heuristic key fails for
+// providerName. The key is still stored; the warnings only flag likely
+// mistakes such as a truncated paste.
+func warnKeyStrength(providerName, key string) {
+	for _, w := range ProviderDefinition(providerName).KeyStrengthWarnings(key) {
+		ui.PrintWarn(fmt.Sprintf("%s: %s", providerName, w))
+	}
+}
+
+// runSecretsValidate checks the stored keys of the named providers, or of
+// every configured provider that needs one and that the policy allows, and
+// prints one line per provider. It reports whether any provider failed.
+func runSecretsValidate(cmd *cobra.Command, names []string) (bool, error) {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return false, errReported
+	}
+
+	cfg, err := loadConfigOrExit(cmd)
+	if err != nil || cfg == nil {
+		return false, errReported
+	}
+
+	if len(names) == 0 {
+		for _, name := range sortProviderNames(cfg.Providers, cfg.DefaultProvider) {
+			if providers.RequiresAPIKey(name) {
+				names = append(names, name)
+			}
+		}
+		if names, err = allowedProviders(names); err != nil {
+			return false, err
+		}
+	}
+	for _, name := range names {
+		if _, ok := cfg.Providers[name]; !ok {
+			return false, kairoerrors.NewError(kairoerrors.ProviderError,
+				fmt.Sprintf("provider '%s' not configured", name))
+		}
+	}
+
+	secretsResult, err := LoadSecrets(CLIContextFromCmd(cmd), dir)
+	if err != nil {
+		return false, err
+	}
+
+	out := cmd.OutOrStdout()
+	failed := false
+	resolver := newSecretResolver(CLIContextFromCmd(cmd))
+	for _, name := range names {
+		if !providers.RequiresAPIKey(name) {
+			fmt.Fprintf(out, "%s✓%s %-12s no API key required\n", ui.Green, ui.Reset, name)
+
+			continue
+		}
+
+		key, ok, err := resolver.resolveAPIKey(secretsResult.Secrets, name)
+		if !ok {
+			fmt.Fprintf(out, "%s✗%s %-12s not set; run 'kairo secrets set %s'\n", ui.Red, ui.Reset, name, name)
+			failed = true
+
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(out, "%s✗%s %-12s %s\n", ui.Red, ui.Reset, name, kairoerrors.Describe(err))
+			failed = true
+
+			continue
+		}
+		notifySecretAccess(CLIContextFromCmd(cmd), dir, name, accessPurposeValidate)
+
+		def := ProviderDefinition(name)
+		if err := def.ValidateAPIKey(key); err != nil {
+			fmt.Fprintf(out, "%s✗%s %-12s %s\n", ui.Red, ui.Reset, name, err)
+			failed = true
+
+			continue
+		}
+
+		warnings := def.KeyStrengthWarnings(key)
+		if len(warnings) == 0 {
+			fmt.Fprintf(out, "%s✓%s %-12s ok\n", ui.Green, ui.Reset, name)
+
+			continue
+		}
+		for _, w := range warnings {
+			fmt.Fprintf(out, "%s!%s %-12s %s\n", ui.Yellow, ui.Reset, name, w)
+		}
+		if secretsValidateFailOnWarnings {
+			failed = true
+		}
+	}
+
+	return failed, nil
+}
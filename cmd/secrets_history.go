@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var secretsHistoryCmd = &cobra.Command{
+	Use:   "history <secret>",
+	Short: "Show when a provider's API key changed",
+	Long: `List the recorded changes of a stored API key, oldest first: when it
+was set or replaced, by which command, and the fingerprints of the old and
+new values. Values are never shown. The secret is named by its variable,
+such as ZAI_API_KEY, or by its provider.
+
+The history is read from the audit log, so it only covers changes made while
+auditing was on with 'rotate' events. The host and user of each change are
+recorded at 'audit.level: verbose'. Changes logged before fingerprints were
+recorded are listed without them.`,
+	Example: `  kairo secrets history ZAI_API_KEY
+  kairo secrets history zai`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSecretsHistory(cmd, args[0], time.Now()); err != nil &&
+			!stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsHistoryCmd)
+}
+
+func runSecretsHistory(cmd *cobra.Command, secret string, now time.Time) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	cfg, err := LoadConfig(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+	entries, err := loadAuditEntries(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+
+	providerName, ok := secretHistoryProvider(cfg, entries, secret)
+	if !ok {
+		return kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("no provider stores its API key as '%s'", secret)).
+			WithContext("hint", "name the key's variable, such as ZAI_API_KEY, or its provider")
+	}
+	envVar := harness.APIKeyEnvVar(providerName)
+
+	var changes []secretChange
+	for _, e := range entries {
+		if c, ok := secretHistoryChange(e, providerName); ok {
+			changes = append(changes, c)
+		}
+	}
+	if len(changes) == 0 {
+		if _, enabled, _ := auditPolicy(cfg); !enabled {
+			ui.PrintInfo(fmt.Sprintf("No changes of %s recorded. Set 'audit.enabled: true' in config.yaml to record them.",
+				envVar))
+
+			return nil
+		}
+		ui.PrintInfo(fmt.Sprintf("No changes of %s recorded.", envVar))
+
+		return nil
+	}
+
+	printSecretHistory(cmd.OutOrStdout(), envVar, changes, now)
+
+	return nil
+}
+
+// secretHistoryProvider returns the provider whose API key is stored as
+// secret, which may also name the provider itself. A deleted provider is
+// found through its entries in the audit log.
+func secretHistoryProvider(cfg *config.Config, entries []audit.Entry, secret string) (string, bool) {
+	if _, ok := cfg.Providers[secret]; ok {
+		return secret, true
+	}
+	for name := range cfg.Providers {
+		if harness.APIKeyEnvVar(name) == secret {
+			return name, true
+		}
+	}
+	for _, e := range entries {
+		if e.Provider != "" && (e.Provider == secret || harness.APIKeyEnvVar(e.Provider) == secret) {
+			return e.Provider, true
+		}
+	}
+
+	return "", false
+}
+
+// secretChange is one change of a stored key, as listed by secrets history.
+type secretChange struct {
+	audit.Entry
+	Command string
+	Change  string
+}
+
+// secretHistoryChange describes e when it changed the API key of
+// providerName, directly or together with every other secret.
+func secretHistoryChange(e audit.Entry, providerName string) (secretChange, bool) {
+	c := secretChange{Entry: e}
+	if e.Provider == "" {
+		switch e.Action {
+		case "rotate_master_key":
+			c.Command, c.Change = "kairo rotate", "re-encrypted under a new key; value unchanged"
+		case "reset_secrets":
+			c.Command, c.Change = "kairo setup --reset-secrets", "deleted with every other secret"
+		case "recover_secrets":
+			c.Command, c.Change = "kairo secrets recover", "restored from "+e.Details["backup"]
+		default:
+			return c, false
+		}
+
+		return c, true
+	}
+	if e.Provider != providerName {
+		return c, false
+	}
+
+	switch e.Action {
+	case "setup_secret":
+		c.Command = "kairo setup"
+	case "import_secret":
+		c.Command = "kairo import"
+	case "set_secret":
+		c.Command = "kairo secrets set"
+	case "adopt_env_key":
+		c.Command = "kairo " + providerName + " --adopt-env"
+	case "rotate_key":
+		c.Command = "kairo rotate --provider"
+	case "revoke_key":
+		c.Command, c.Change = "kairo rotate --provider", "old key "+e.Details["old_fingerprint"]+" revoked"
+
+		return c, true
+	case "revoke_failed":
+		c.Command, c.Change = "kairo rotate --provider", "revoking old key "+e.Details["old_fingerprint"]+" failed"
+
+		return c, true
+	case "delete_provider":
+		c.Command, c.Change = "kairo delete", "removed with the provider"
+
+		return c, true
+	case "migrate_secret":
+		c.Command, c.Change = "kairo "+providerName, "renamed from "+e.Details["from"]+"; value unchanged"
+
+		return c, true
+	default:
+		return c, false
+	}
+
+	oldFP, newFP := e.Details["old_fingerprint"], e.Details["new_fingerprint"]
+	switch {
+	case newFP == "":
+		c.Change = "changed (fingerprint not recorded)"
+	case oldFP == "":
+		c.Change = "set to " + newFP
+	default:
+		c.Change = oldFP + " → " + newFP
+	}
+
+	return c, true
+}
+
+// printSecretHistory prints the changes of envVar as a table, with ages
+// relative to now.
+func printSecretHistory(out io.Writer, envVar string, changes []secretChange, now time.Time) {
+	fmt.Fprintf(out, "History of %s (fingerprints only):\n\n", envVar)
+	fmt.Fprintf(out, "%-23s  %-9s  %-27s  %-14s  %s\n", "TIME", "AGE", "COMMAND", "BY", "CHANGE")
+	for _, c := range changes {
+		by := "-"
+		switch {
+		case c.User != "" && c.Hostname != "":
+			by = c.User + "@" + c.Hostname
+		case c.User != "" || c.Hostname != "":
+			by = c.User + c.Hostname
+		}
+		fmt.Fprintf(out, "%-23s  %-9s  %-27s  %-14s  %s\n",
+			ui.FormatTime(c.Timestamp, utcFlag), ui.RelativeTime(c.Timestamp, now), c.Command, by, c.Change)
+	}
+}
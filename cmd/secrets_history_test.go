@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/secrets"
+)
+
+func TestRunSecretsHistory(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, `providers:
+  zai:
+    name: Z.AI
+  minimax:
+    name: MiniMax
+audit:
+  enabled: true
+  level: verbose
+`)
+	cmd, out := explainTestCmd(t, dir)
+	cliCtx := CLIContextFromCmd(cmd)
+
+	oldKey := "zai-old-key-0123456789abcdef0123456789"
+	newKey := "zai-new-key-0123456789abcdef0123456789"
+	for _, key := range []string{oldKey, newKey} {
+		if err := storeProviderSecret(cliCtx, dir, "zai", key, "set_secret"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := storeProviderSecret(cliCtx, dir, "minimax", "minimax-key-0123456789abcdef0123456789", "set_secret"); err != nil {
+		t.Fatal(err)
+	}
+	recordAudit(cliCtx, dir, audit.Entry{Event: audit.EventRotate, Action: "rotate_master_key"})
+
+	if err := runSecretsHistory(cmd, "ZAI_API_KEY", time.Now()); err != nil {
+		t.Fatalf("runSecretsHistory() error = %v", err)
+	}
+	got := out.String()
+	oldFP, newFP := secrets.Fingerprint(oldKey), secrets.Fingerprint(newKey)
+	for _, want := range []string{
+		"History of ZAI_API_KEY",
+		"kairo secrets set",
+		"set to " + oldFP,
+		oldFP + " → " + newFP,
+		"kairo rotate",
+		"value unchanged",
+		"@",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	for _, leak := range []string{oldKey, newKey, "minimax-key"} {
+		if strings.Contains(got, leak) {
+			t.Errorf("output contains %q:\n%s", leak, got)
+		}
+	}
+	if n := strings.Count(got, "kairo secrets set"); n != 2 {
+		t.Errorf("listed %d changes by kairo secrets set, want 2:\n%s", n, got)
+	}
+
+	out.Reset()
+	if err := runSecretsHistory(cmd, "zai", time.Now()); err != nil || !strings.Contains(out.String(), newFP) {
+		t.Errorf("history by provider name = %q, %v", out.String(), err)
+	}
+	if err := runSecretsHistory(cmd, "NOPE_API_KEY", time.Now()); err == nil {
+		t.Error("runSecretsHistory() for an unknown secret: want an error")
+	}
+}
+
+func TestSecretsHistoryFindsDeletedProvider(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, "providers: {}\n")
+	cmd, out := explainTestCmd(t, dir)
+	logger := audit.NewLogger(stateDir(CLIContextFromCmd(cmd), dir), audit.Policy{})
+	now := time.Now()
+	for _, e := range []audit.Entry{
+		{Timestamp: now.Add(-48 * time.Hour), Event: audit.EventRotate, Action: "rotate_key", Provider: "deepseek"},
+		{Timestamp: now.Add(-time.Hour), Event: audit.EventConfig, Action: "delete_provider", Provider: "deepseek"},
+	} {
+		if err := logger.Log(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := runSecretsHistory(cmd, "DEEPSEEK_API_KEY", now); err != nil {
+		t.Fatalf("runSecretsHistory() error = %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{"fingerprint not recorded", "kairo delete", "removed with the provider"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/ui"
+)
+
+// legacyCustomPrefix is the prefix older kairo versions put before the API
+// key variable of a custom provider, as in CUSTOM_MYGATEWAY_API_KEY. Keys
+// are now stored as <PROVIDER>_API_KEY for every provider.
+const legacyCustomPrefix = "CUSTOM_"
+
+// legacySecret is a legacy entry of the secrets file holding the API key of
+// Provider under From, to be renamed to To.
+type legacySecret struct {
+	Provider string
+	From     string
+	To       string
+}
+
+// legacySecrets returns the legacy entries of stored that hold the API key
+// of a custom provider in cfg, sorted by provider. An entry is left alone
+// when the provider already has a key under the current name, so a key set
+// since is never overwritten.
+func legacySecrets(cfg *config.Config, stored map[string]string) []legacySecret {
+	if cfg == nil {
+		return nil
+	}
+	var found []legacySecret
+	for _, name := range slices.Sorted(maps.Keys(cfg.Providers)) {
+		if providers.IsBuiltInProvider(name) {
+			continue
+		}
+		envVar := harness.APIKeyEnvVar(name)
+		if _, ok := stored[legacyCustomPrefix+envVar]; !ok {
+			continue
+		}
+		if _, ok := stored[envVar]; ok {
+			continue
+		}
+		found = append(found, legacySecret{Provider: name, From: legacyCustomPrefix + envVar, To: envVar})
+	}
+
+	return found
+}
+
+// migrateLegacySecrets renames the legacy entries of result to the current
+// scheme, saves the secrets file, and records each rename in the audit log.
+// result is renamed in memory even when saving fails, with a warning, so
+// that the key is found for this run. With --explain-env only the copy in
+// memory is renamed: nothing is launched, so nothing is saved or audited.
+func migrateLegacySecrets(cliCtx *CLIContext, dir string, cfg *config.Config, result *SecretsResult) {
+	legacy := legacySecrets(cfg, result.Secrets)
+	if len(legacy) == 0 {
+		return
+	}
+	for _, l := range legacy {
+		result.Secrets[l.To] = result.Secrets[l.From]
+		delete(result.Secrets, l.From)
+	}
+	if explainEnvFlag {
+		return
+	}
+
+	if err := SaveSecrets(cliCtx, result.SecretsPath, result.KeyPath, result.Secrets); err != nil {
+		ui.PrintWarn("Could not rename legacy secrets: " + kairoerrors.Describe(err))
+
+		return
+	}
+	for _, l := range legacy {
+		recordAudit(cliCtx, dir, audit.Entry{
+			Event:    audit.EventRotate,
+			Action:   "migrate_secret",
+			Provider: l.Provider,
+			Details:  map[string]string{"from": l.From, "to": l.To},
+		})
+		ui.PrintInfo(fmt.Sprintf("Renamed legacy secret %s to %s", l.From, l.To))
+	}
+}
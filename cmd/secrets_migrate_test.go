@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/secrets"
+)
+
+func TestLegacySecrets(t *testing.T) {
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"zai":       {},
+		"mygateway": {},
+		"other":     {},
+		"custom":    {},
+	}}
+	stored := map[string]string{
+		"CUSTOM_ZAI_API_KEY":       "sk-zai",
+		"CUSTOM_MYGATEWAY_API_KEY": "sk-gw",
+		"CUSTOM_OTHER_API_KEY":     "sk-old",
+		"OTHER_API_KEY":            "sk-new",
+		"CUSTOM_API_KEY":           "sk-custom",
+	}
+
+	got := legacySecrets(cfg, stored)
+	want := []legacySecret{{Provider: "mygateway", From: "CUSTOM_MYGATEWAY_API_KEY", To: "MYGATEWAY_API_KEY"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("legacySecrets() = %+v, want %+v", got, want)
+	}
+	if got := legacySecrets(nil, stored); got != nil {
+		t.Errorf("legacySecrets(nil) = %+v, want nil", got)
+	}
+}
+
+func TestBuildProviderEnv_MigratesLegacySecrets(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, `providers:
+  mygateway:
+    name: My Gateway
+    base_url: https://gateway.example.com
+    model: gw-large
+audit:
+  enabled: true
+  level: verbose
+`)
+	cmd, out := explainTestCmd(t, dir)
+	cliCtx := CLIContextFromCmd(cmd)
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		t.Fatal(err)
+	}
+	secretsPath := filepath.Join(dir, constants.SecretsFileName)
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+	if err := SaveSecrets(cliCtx, secretsPath, keyPath, map[string]string{"CUSTOM_MYGATEWAY_API_KEY": "sk-gw"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := BuildProviderEnv(cliCtx, dir, cfg.Providers["mygateway"], "mygateway")
+	if err != nil {
+		t.Fatalf("BuildProviderEnv() error = %v", err)
+	}
+	if got := result.Secrets["MYGATEWAY_API_KEY"]; got != "sk-gw" {
+		t.Errorf("MYGATEWAY_API_KEY = %q, want sk-gw", got)
+	}
+
+	payload, err := readSecretsFile(cliCtx, secretsPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored := secrets.Parse(string(payload)); stored["MYGATEWAY_API_KEY"] != "sk-gw" || stored["CUSTOM_MYGATEWAY_API_KEY"] != "" {
+		t.Errorf("secrets file after migration = %v", stored)
+	}
+
+	entries, err := loadAuditEntries(cliCtx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Event != audit.EventRotate || entries[0].Action != "migrate_secret" || entries[0].Provider != "mygateway" ||
+		entries[0].Details["from"] != "CUSTOM_MYGATEWAY_API_KEY" {
+		t.Errorf("audit entries = %+v", entries)
+	}
+
+	out.Reset()
+	if err := runSecretsHistory(cmd, "MYGATEWAY_API_KEY", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "renamed from CUSTOM_MYGATEWAY_API_KEY") {
+		t.Errorf("secrets history missing the rename:\n%s", out.String())
+	}
+}
+
+func TestBuildProviderEnv_ExplainEnvLeavesLegacySecrets(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, `providers:
+  mygateway:
+    name: My Gateway
+    base_url: https://gateway.example.com
+    model: gw-large
+audit:
+  enabled: true
+  level: verbose
+`)
+	cmd, _ := explainTestCmd(t, dir)
+	cliCtx := CLIContextFromCmd(cmd)
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		t.Fatal(err)
+	}
+	secretsPath := filepath.Join(dir, constants.SecretsFileName)
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+	if err := SaveSecrets(cliCtx, secretsPath, keyPath, map[string]string{"CUSTOM_MYGATEWAY_API_KEY": "sk-gw"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { explainEnvFlag = false })
+	explainEnvFlag = true
+
+	result, err := BuildProviderEnv(cliCtx, dir, cfg.Providers["mygateway"], "mygateway")
+	if err != nil {
+		t.Fatalf("BuildProviderEnv() error = %v", err)
+	}
+	if got := result.Secrets["MYGATEWAY_API_KEY"]; got != "sk-gw" {
+		t.Errorf("MYGATEWAY_API_KEY = %q, want sk-gw renamed in memory", got)
+	}
+
+	payload, err := readSecretsFile(cliCtx, secretsPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored := secrets.Parse(string(payload)); stored["CUSTOM_MYGATEWAY_API_KEY"] != "sk-gw" {
+		t.Errorf("secrets file after --explain-env = %v, want it unchanged", stored)
+	}
+	entries, err := loadAuditEntries(cliCtx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("audit entries = %+v, want none for --explain-env", entries)
+	}
+}
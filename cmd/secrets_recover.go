@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsperm"
+	"github.com/dkmnx/kairo/internal/fsutil"
+	"github.com/dkmnx/kairo/internal/journal"
+	"github.com/dkmnx/kairo/internal/secrets"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// secretsBackupCount is how many earlier versions of the secrets file are
+// kept, as secrets.age.1 (newest) to secrets.age.3.
+const secretsBackupCount = 3
+
+var secretsRecoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Restore secrets.age from the newest backup that decrypts",
+	Long: fmt.Sprintf(`Each time kairo writes secrets.age it first keeps the previous version, up to
+%d of them, as secrets.age.1 (newest) to secrets.age.%d. If secrets.age cannot be
+decrypted or fails its checksum, recover tries these backups in order and
+restores the first one that decrypts and verifies. The unreadable file is
+kept as secrets.age.corrupt-<time>.
+
+Backups are encrypted with the same key, so they are dropped when the key is
+rotated or reset.`, secretsBackupCount, secretsBackupCount),
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runSecretsRecover(cmd); err != nil && !errors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsRecoverCmd)
+}
+
+func runSecretsRecover(cmd *cobra.Command) error {
+	dir := requireConfigDirWritable(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	secretsPath := filepath.Join(dir, constants.SecretsFileName)
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+
+	_, statErr := os.Stat(secretsPath)
+	if statErr == nil {
+		if payload, err := readSecretsFile(cliCtx, secretsPath, keyPath); err == nil {
+			crypto.ClearMemory(payload)
+			ui.PrintSuccess(constants.SecretsFileName + " decrypts and passes its checksum; nothing to recover")
+
+			return nil
+		}
+	}
+
+	for n := 1; n <= secretsBackupCount; n++ {
+		backup := secretsBackupPath(secretsPath, n)
+		if _, err := os.Stat(backup); err != nil {
+			continue
+		}
+		payload, err := readSecretsFile(cliCtx, backup, keyPath)
+		if err != nil {
+			ui.PrintWarn(fmt.Sprintf("Skipping %s: %s", filepath.Base(backup), kairoerrors.Describe(err)))
+
+			continue
+		}
+		count := len(secrets.Parse(string(payload)))
+		crypto.ClearMemory(payload)
+
+		aside, err := restoreSecretsBackup(cliCtx, backup, secretsPath, statErr == nil)
+		if err != nil {
+			return err
+		}
+		if aside != "" {
+			ui.PrintInfo("Kept the unreadable file as " + filepath.Base(aside))
+		}
+		recordAudit(cliCtx, dir, audit.Entry{
+			Event:   audit.EventRotate,
+			Action:  "recover_secrets",
+			Details: map[string]string{"backup": filepath.Base(backup)},
+		})
+		ui.PrintSuccess(fmt.Sprintf("Restored %s from %s (%d keys)", constants.SecretsFileName, filepath.Base(backup), count))
+
+		return nil
+	}
+
+	return kairoerrors.NewError(kairoerrors.CryptoError, "no backup of "+constants.SecretsFileName+" could be decrypted").
+		WithContext("hint", "restore age.key and secrets.age from your own backup, or run 'kairo setup --reset-secrets'")
+}
+
+func secretsBackupPath(secretsPath string, n int) string {
+	return fmt.Sprintf("%s.%d", secretsPath, n)
+}
+
+// backupSecretsFile keeps the secrets file about to be replaced as the
+// newest backup. A file that cannot be decrypted is never replaced, since
+// doing so would lose whatever it still holds.
+func backupSecretsFile(cliCtx *CLIContext, secretsPath, keyPath string) error {
+	if _, err := os.Stat(secretsPath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	payload, err := readSecretsFile(cliCtx, secretsPath, keyPath)
+	if err != nil {
+		return kairoerrors.WrapError(kairoerrors.CryptoError,
+			"refusing to overwrite a secrets file that cannot be decrypted", err).
+			WithContext("path", secretsPath).
+			WithContext("hint", "run 'kairo secrets recover' first, or 'kairo secrets set --force' to set it aside")
+	}
+	crypto.ClearMemory(payload)
+
+	for n := secretsBackupCount; n > 1; n-- {
+		err := os.Rename(secretsBackupPath(secretsPath, n-1), secretsBackupPath(secretsPath, n))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return kairoerrors.FileError("failed to rotate secrets backups", secretsPath, err)
+		}
+	}
+
+	return copySecretsFile(secretsPath, secretsBackupPath(secretsPath, 1))
+}
+
+// removeSecretsBackups drops the backups of secretsPath, which can no longer
+// be decrypted once the key changes.
+func removeSecretsBackups(secretsPath string) error {
+	for n := 1; n <= secretsBackupCount; n++ {
+		if err := os.Remove(secretsBackupPath(secretsPath, n)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return kairoerrors.FileError("failed to remove secrets backup", secretsBackupPath(secretsPath, n), err)
+		}
+	}
+
+	return nil
+}
+
+// setAsideUnreadableSecrets moves the secrets file in dir out of the way if
+// it cannot be decrypted, so that 'secrets set --force' can start a new one.
+func setAsideUnreadableSecrets(cliCtx *CLIContext, dir string) error {
+	secretsPath := filepath.Join(dir, constants.SecretsFileName)
+	if _, err := os.Stat(secretsPath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	payload, err := readSecretsFile(cliCtx, secretsPath, filepath.Join(dir, constants.KeyFileName))
+	if err == nil {
+		crypto.ClearMemory(payload)
+
+		return nil
+	}
+
+	aside, asideErr := setAsideSecretsFile(secretsPath)
+	if asideErr != nil {
+		return asideErr
+	}
+	ui.PrintWarn(fmt.Sprintf("%s could not be read (%s); kept it as %s and started a new one",
+		constants.SecretsFileName, kairoerrors.Describe(err), filepath.Base(aside)))
+
+	return nil
+}
+
+// restoreSecretsBackup replaces secretsPath with a copy of backup, first
+// setting the current file aside when there is one, and returns where it was
+// set aside. The copy is staged and swapped in through a journal, so an
+// interruption leaves either the old file or the restored one.
+func restoreSecretsBackup(cliCtx *CLIContext, backup, secretsPath string, setAside bool) (string, error) {
+	staged := secretsPath + ".new"
+	steps := []journal.Step{journal.Replace(staged, secretsPath)}
+	aside := ""
+	if setAside {
+		aside = asideSecretsPath(secretsPath)
+		steps = append([]journal.Step{journal.Move(secretsPath, aside)}, steps...)
+	}
+
+	j, err := journal.Begin(cliCtx.RootCtx(), filepath.Dir(secretsPath), "secrets recover", steps)
+	if err != nil {
+		return "", err
+	}
+	if err := copySecretsFile(backup, staged); err != nil {
+		j.Abort()
+
+		return "", err
+	}
+	if err := j.Commit(); err != nil {
+		return "", err
+	}
+
+	return aside, nil
+}
+
+// setAsideSecretsFile renames an unreadable secrets file out of the way and
+// returns its new path.
+func setAsideSecretsFile(secretsPath string) (string, error) {
+	aside := asideSecretsPath(secretsPath)
+	if err := os.Rename(secretsPath, aside); err != nil {
+		return "", kairoerrors.FileError("failed to set the unreadable secrets file aside", secretsPath, err)
+	}
+
+	return aside, nil
+}
+
+// asideSecretsPath returns the name an unreadable secrets file is kept
+// under when it is set aside.
+func asideSecretsPath(secretsPath string) string {
+	return secretsPath + ".corrupt-" + time.Now().UTC().Format("20060102T150405Z")
+}
+
+func copySecretsFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return kairoerrors.FileError("failed to open secrets file", src, err)
+	}
+	defer in.Close()
+
+	if err := fsutil.WriteAtomic(dst, func(f *os.File) error {
+		_, err := io.Copy(f, in)
+
+		return err
+	}); err != nil {
+		return kairoerrors.FileError("failed to copy secrets file", dst, err)
+	}
+
+	return fsperm.Restrict(dst)
+}
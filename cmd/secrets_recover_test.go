@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/secrets"
+)
+
+// writeCorruptedSecrets encrypts a payload whose checksum does not match.
+func writeCorruptedSecrets(t *testing.T, cliCtx *CLIContext, dir string) {
+	t.Helper()
+	sealed := secrets.Seal("ZAI_API_KEY=sk-good\n")
+	tampered := strings.Replace(sealed, "sk-good", "sk-evil", 1)
+	err := cliCtx.Crypto().EncryptSecrets(cliCtx.RootCtx(), filepath.Join(dir, constants.SecretsFileName),
+		filepath.Join(dir, constants.KeyFileName), tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveSecrets_Backups(t *testing.T) {
+	dir := t.TempDir()
+	cmd, _ := explainTestCmd(t, dir)
+	cliCtx := CLIContextFromCmd(cmd)
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		t.Fatal(err)
+	}
+	secretsPath := filepath.Join(dir, constants.SecretsFileName)
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+
+	for _, key := range []string{"sk-1", "sk-2", "sk-3", "sk-4", "sk-5"} {
+		if err := SaveSecrets(cliCtx, secretsPath, keyPath, map[string]string{"ZAI_API_KEY": key}); err != nil {
+			t.Fatalf("SaveSecrets(%s) error = %v", key, err)
+		}
+	}
+
+	for n, want := range map[int]string{1: "sk-4", 2: "sk-3", 3: "sk-2"} {
+		payload, err := readSecretsFile(cliCtx, secretsBackupPath(secretsPath, n), keyPath)
+		if err != nil {
+			t.Fatalf("backup %d: %v", n, err)
+		}
+		if got := secrets.Parse(string(payload))["ZAI_API_KEY"]; got != want {
+			t.Errorf("backup %d holds %q, want %q", n, got, want)
+		}
+	}
+	if _, err := os.Stat(secretsBackupPath(secretsPath, 4)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("a fourth backup exists: %v", err)
+	}
+}
+
+func TestSaveSecrets_RefusesCorruptedFile(t *testing.T) {
+	dir := t.TempDir()
+	cmd, _ := explainTestCmd(t, dir)
+	cliCtx := CLIContextFromCmd(cmd)
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		t.Fatal(err)
+	}
+	writeCorruptedSecrets(t, cliCtx, dir)
+
+	if _, err := LoadSecrets(cliCtx, dir); err == nil || !strings.Contains(err.Error(), "corrupted") {
+		t.Fatalf("LoadSecrets() error = %v, want corrupted", err)
+	}
+
+	secretsPath := filepath.Join(dir, constants.SecretsFileName)
+	before, err := os.ReadFile(secretsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = SaveSecrets(cliCtx, secretsPath, filepath.Join(dir, constants.KeyFileName), map[string]string{"K": "V"})
+	if err == nil || !strings.Contains(err.Error(), "refusing to overwrite") {
+		t.Fatalf("SaveSecrets() error = %v, want a refusal", err)
+	}
+	after, _ := os.ReadFile(secretsPath)
+	if string(before) != string(after) {
+		t.Error("SaveSecrets() replaced the corrupted file")
+	}
+
+	if err := setAsideUnreadableSecrets(cliCtx, dir); err != nil {
+		t.Fatalf("setAsideUnreadableSecrets() error = %v", err)
+	}
+	if _, err := os.Stat(secretsPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("corrupted file was not set aside: %v", err)
+	}
+	if aside, _ := filepath.Glob(secretsPath + ".corrupt-*"); len(aside) != 1 {
+		t.Errorf("set-aside files = %v, want one", aside)
+	}
+}
+
+func TestRunSecretsRecover(t *testing.T) {
+	dir := t.TempDir()
+	cmd, _ := explainTestCmd(t, dir)
+	cliCtx := CLIContextFromCmd(cmd)
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		t.Fatal(err)
+	}
+	secretsPath := filepath.Join(dir, constants.SecretsFileName)
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+
+	if err := runSecretsRecover(cmd); err == nil || !strings.Contains(err.Error(), "no backup") {
+		t.Errorf("runSecretsRecover() with nothing to restore error = %v", err)
+	}
+
+	for _, key := range []string{"sk-old", "sk-new"} {
+		if err := SaveSecrets(cliCtx, secretsPath, keyPath, map[string]string{"ZAI_API_KEY": key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := runSecretsRecover(cmd); err != nil {
+		t.Fatalf("runSecretsRecover() on a good file error = %v", err)
+	}
+
+	writeCorruptedSecrets(t, cliCtx, dir)
+	if err := runSecretsRecover(cmd); err != nil {
+		t.Fatalf("runSecretsRecover() error = %v", err)
+	}
+	result, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		t.Fatalf("LoadSecrets() after recover error = %v", err)
+	}
+	if got := result.Secrets["ZAI_API_KEY"]; got != "sk-old" {
+		t.Errorf("recovered key = %q, want the newest backup's sk-old", got)
+	}
+	if aside, _ := filepath.Glob(secretsPath + ".corrupt-*"); len(aside) != 1 {
+		t.Errorf("set-aside files = %v, want one", aside)
+	}
+}
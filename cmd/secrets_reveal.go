@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/secrets"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// revealMaxDuration bounds --for, so that a revealed key is not left on an
+// unattended screen.
+const revealMaxDuration = 5 * time.Minute
+
+// Terminal sequences used by revealSecret. The alternate screen keeps the
+// value out of the scrollback of terminals that support it; clearing it
+// before switching back covers those that only emulate it.
+const (
+	enterAltScreen = "\033[?1049h\033[H"
+	clearScreen    = "\033[2J\033[3J\033[H"
+	leaveAltScreen = "\033[?1049l"
+)
+
+var revealFor time.Duration
+
+var secretsRevealCmd = &cobra.Command{
+	Use:   "reveal <secret>",
+	Short: "Show a stored API key for a few seconds, then clear it",
+	Long: `Print a stored API key for debugging, wait, then clear it from the
+terminal. The secret is named by its variable, such as ZAI_API_KEY, or by its
+provider. A key stored with 'kairo secrets set --command' is shown as its
+command returns it.
+
+The key is shown on the terminal's alternate screen, which is cleared and
+left after --for (10s by default, at most 5m), on Enter, or on Ctrl-C, so it
+does not stay in the scrollback of terminals that support one. Output that is
+not a terminal is refused, since it would keep the key. Each reveal is
+recorded as a 'reveal' audit event, with the key's fingerprint only, and
+reported to the hooks.secret_access command.`,
+	Example: `  kairo secrets reveal ZAI_API_KEY
+  kairo secrets reveal zai --for 30s`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSecretsReveal(cmd, args[0]); err != nil &&
+			!stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	secretsRevealCmd.Flags().DurationVar(&revealFor, "for", 10*time.Second, "How long the key stays on screen")
+	secretsCmd.AddCommand(secretsRevealCmd)
+}
+
+func runSecretsReveal(cmd *cobra.Command, secret string) error {
+	if revealFor < time.Second || revealFor > revealMaxDuration {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("--for must be between 1s and %s", revealMaxDuration))
+	}
+	out := terminalWriter(os.Stdout)
+	if out == nil {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			"kairo secrets reveal needs a terminal, since redirected output would keep the key").
+			WithContext("hint", "run it in a terminal without redirecting stdout")
+	}
+
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	cfg, err := LoadConfig(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+	secretsResult, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+	name, providerName, ok := revealedSecret(cfg, secretsResult.Secrets, secret)
+	if !ok {
+		return kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("no secret named '%s' is stored", secret)).
+			WithContext("hint", "name the key's variable, such as ZAI_API_KEY, or its provider")
+	}
+	value, err := newSecretResolver(cliCtx).resolve(cmp.Or(providerName, name), secretsResult.Secrets[name])
+	if err != nil {
+		return err
+	}
+
+	if providerName != "" {
+		notifySecretAccess(cliCtx, dir, providerName, accessPurposeReveal)
+	}
+	recordReveal(cliCtx, dir, providerName, name, value)
+
+	var hide <-chan struct{}
+	if terminalWriter(os.Stdin) != nil {
+		hide = enterPressed(os.Stdin)
+	}
+	revealSecret(cliCtx.RootCtx(), out, name, value, revealFor, hide)
+	ui.PrintInfo(fmt.Sprintf("%s (%s) was shown and cleared from the screen", name, secrets.Fingerprint(value)))
+
+	return nil
+}
+
+// recordReveal records in the audit log that the secret stored as name was
+// revealed. The name is kept under "entry" rather than a secret-named
+// detail, which the audit masker would hide.
+func recordReveal(cliCtx *CLIContext, dir, providerName, name, value string) {
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:    audit.EventReveal,
+		Action:   "reveal_secret",
+		Provider: providerName,
+		Details: map[string]string{
+			"entry":       name,
+			"fingerprint": secrets.Fingerprint(value),
+			"duration":    revealFor.String(),
+		},
+	})
+}
+
+// revealedSecret returns the stored entry secret names, which may be the
+// variable itself or a provider, and the provider it belongs to, if any. A
+// provider without a key of its own reveals the shared custom key, as a
+// switch to it would use.
+func revealedSecret(cfg *config.Config, stored map[string]string, secret string) (string, string, bool) {
+	if _, ok := cfg.Providers[secret]; ok {
+		name := harness.APIKeyEnvVar(secret)
+		_, found := lookupAPIKeyWithFallback(stored, secret)
+		if _, own := stored[name]; !own && found {
+			name = harness.APIKeyEnvVar(customProviderName)
+		}
+
+		return name, secret, found
+	}
+	if _, ok := stored[secret]; !ok {
+		return "", "", false
+	}
+
+	return secret, revealProvider(cfg, secret), true
+}
+
+// revealProvider returns the provider whose API key secret names, by the
+// provider's name or its <PROVIDER>_API_KEY variable, or "" for a secret
+// of no provider. It needs no secrets, so the policy can be checked before
+// anything is decrypted.
+func revealProvider(cfg *config.Config, secret string) string {
+	names := providers.ProviderList()
+	if cfg != nil {
+		names = append(names, slices.Collect(maps.Keys(cfg.Providers))...)
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+	if slices.Contains(names, secret) {
+		return secret
+	}
+	for _, name := range names {
+		if harness.APIKeyEnvVar(name) == secret {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// enterPressed returns a channel closed when a line is read from r.
+func enterPressed(r io.Reader) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		_, _ = bufio.NewReader(r).ReadString('\n')
+		close(done)
+	}()
+
+	return done
+}
+
+// revealSecret shows value on the alternate screen of out until d passes,
+// hide is closed, or ctx is cancelled, then clears it and switches back.
+func revealSecret(ctx context.Context, out io.Writer, name, value string, d time.Duration, hide <-chan struct{}) {
+	fmt.Fprint(out, enterAltScreen)
+	fmt.Fprintf(out, "%s=%s\n\nHidden in %s; press Enter to hide it now.\n", name, value, d)
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-hide:
+	case <-ctx.Done():
+	}
+
+	fmt.Fprint(out, clearScreen+leaveAltScreen)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/config"
+)
+
+func TestRevealedSecret(t *testing.T) {
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"zai": {Name: "Z.AI"}, "minimax": {Name: "MiniMax"}, "mygateway": {Name: "My Gateway"},
+	}}
+	stored := map[string]string{"ZAI_API_KEY": "sk-zai", "GITHUB_TOKEN": "ghp", "CUSTOM_API_KEY": "sk-custom", "KIMI_API_KEY": "sk-kimi"}
+
+	tests := []struct {
+		secret       string
+		wantName     string
+		wantProvider string
+		wantOK       bool
+	}{
+		{secret: "zai", wantName: "ZAI_API_KEY", wantProvider: "zai", wantOK: true},
+		{secret: "ZAI_API_KEY", wantName: "ZAI_API_KEY", wantProvider: "zai", wantOK: true},
+		{secret: "GITHUB_TOKEN", wantName: "GITHUB_TOKEN", wantOK: true},
+		{secret: "minimax", wantName: "CUSTOM_API_KEY", wantProvider: "minimax", wantOK: true},
+		{secret: "mygateway", wantName: "CUSTOM_API_KEY", wantProvider: "mygateway", wantOK: true},
+		{secret: "KIMI_API_KEY", wantName: "KIMI_API_KEY", wantProvider: "kimi", wantOK: true},
+		{secret: "MISSING_KEY"},
+	}
+	for _, tt := range tests {
+		name, providerName, ok := revealedSecret(cfg, stored, tt.secret)
+		if name != tt.wantName || providerName != tt.wantProvider || ok != tt.wantOK {
+			t.Errorf("revealedSecret(%q) = %q, %q, %v; want %q, %q, %v",
+				tt.secret, name, providerName, ok, tt.wantName, tt.wantProvider, tt.wantOK)
+		}
+	}
+	delete(stored, "CUSTOM_API_KEY")
+	if name, providerName, ok := revealedSecret(cfg, stored, "minimax"); ok {
+		t.Errorf("revealedSecret(minimax) without a key = %q, %q, true; want not found", name, providerName)
+	}
+}
+
+func TestRevealSecret(t *testing.T) {
+	var out bytes.Buffer
+	hide := make(chan struct{})
+	close(hide)
+
+	start := time.Now()
+	revealSecret(context.Background(), &out, "ZAI_API_KEY", "sk-zai-secret", time.Minute, hide)
+	if time.Since(start) > 5*time.Second {
+		t.Error("revealSecret() did not return when hidden early")
+	}
+
+	got := out.String()
+	if !strings.HasPrefix(got, enterAltScreen) || !strings.HasSuffix(got, clearScreen+leaveAltScreen) {
+		t.Errorf("revealSecret() output = %q, want it on a cleared alternate screen", got)
+	}
+	if !strings.Contains(got, "ZAI_API_KEY=sk-zai-secret") {
+		t.Errorf("revealSecret() output = %q, want the key", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	out.Reset()
+	revealSecret(ctx, &out, "ZAI_API_KEY", "sk-zai-secret", time.Minute, nil)
+	if !strings.HasSuffix(out.String(), leaveAltScreen) {
+		t.Error("revealSecret() did not clear the screen when interrupted")
+	}
+}
+
+func TestRecordReveal(t *testing.T) {
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, `providers:
+  zai:
+    name: Z.AI
+    base_url: https://api.z.ai/api/anthropic
+audit:
+  enabled: true
+  level: verbose
+`)
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(dir)
+	cliCtx.SetRootCtx(context.Background())
+
+	recordReveal(cliCtx, dir, "zai", "ZAI_API_KEY", "sk-zai-test-key-abcdefghijklmnopqrst")
+
+	entries, err := loadAuditEntries(cliCtx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != "reveal_secret" {
+		t.Fatalf("audit entries = %+v, want the reveal", entries)
+	}
+	if got := entries[0].Details["entry"]; got != "ZAI_API_KEY" {
+		t.Errorf("revealed entry in the audit log = %q, want ZAI_API_KEY", got)
+	}
+	for name, value := range entries[0].Details {
+		if strings.Contains(value, "sk-zai-test") {
+			t.Errorf("audit detail %s holds the key", name)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func TestStoreProviderSecret(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{Providers: map[string]config.Provider{"zai": {Name: "Z.AI"}}}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	cliCtx := NewCLIContext()
+	key := "zai-test-key-0123456789abcdef0123456789"
+	if err := storeProviderSecret(cliCtx, dir, "zai", key, "set_secret"); err != nil {
+		t.Fatalf("storeProviderSecret() error = %v", err)
+	}
+
+	result, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		t.Fatalf("LoadSecrets() error = %v", err)
+	}
+	if got := result.Secrets["ZAI_API_KEY"]; got != key {
+		t.Errorf("ZAI_API_KEY = %q, want %q", got, key)
+	}
+}
+
+func TestStoreProviderSecretNormalizesKey(t *testing.T) {
+	dir := t.TempDir()
+	cliCtx := NewCLIContext()
+	key := "zai-test-key-0123456789abcdef0123456789"
+
+	if err := storeProviderSecret(cliCtx, dir, "zai", "\uFEFF"+key+" \r\n", "set_secret"); err != nil {
+		t.Fatalf("storeProviderSecret() error = %v", err)
+	}
+	result, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		t.Fatalf("LoadSecrets() error = %v", err)
+	}
+	if got := result.Secrets["ZAI_API_KEY"]; got != key {
+		t.Errorf("ZAI_API_KEY = %q, want %q", got, key)
+	}
+
+	err = storeProviderSecret(cliCtx, dir, "zai", "zai-test-key-0123456789\u200Babcdef0123456789", "set_secret")
+	if err == nil || !strings.Contains(err.Error(), "U+200B") {
+		t.Errorf("storeProviderSecret() error = %v, want the invisible character named", err)
+	}
+}
+
+func TestStoreProviderSecretRejectsInvalidKey(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := storeProviderSecret(NewCLIContext(), dir, "zai", "   ", "set_secret"); err == nil {
+		t.Fatal("storeProviderSecret() expected error for blank key")
+	}
+
+	result, err := LoadSecrets(NewCLIContext(), dir)
+	if err != nil {
+		t.Fatalf("LoadSecrets() error = %v", err)
+	}
+	if len(result.Secrets) != 0 {
+		t.Errorf("secrets written despite invalid key: %v", result.Secrets)
+	}
+}
+
+func TestRunSecretsValidate(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"zai":     {Name: "Z.AI"},
+		"minimax": {Name: "MiniMax"},
+	}}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(dir)
+	if err := storeProviderSecret(cliCtx, dir, "zai", "Zq8vN3pLr7Tx2KmW9cYd4HbF6gJs1QaE", "set_secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	failed, err := runSecretsValidate(cmd, []string{"zai"})
+	if err != nil || failed {
+		t.Fatalf("runSecretsValidate(zai) = %v, %v; want ok\n%s", failed, err, out.String())
+	}
+
+	out.Reset()
+	failed, err = runSecretsValidate(cmd, nil)
+	if err != nil {
+		t.Fatalf("runSecretsValidate() error = %v", err)
+	}
+	if !failed || !strings.Contains(out.String(), "kairo secrets set minimax") {
+		t.Errorf("runSecretsValidate() = %v, output:\n%s\nwant missing minimax key reported", failed, out.String())
+	}
+
+	if _, err := runSecretsValidate(cmd, []string{"openai"}); err == nil {
+		t.Error("runSecretsValidate(openai) expected error for unconfigured provider")
+	}
+}
+
+func TestRunSecretsValidate_SkipsProvidersDeniedByPolicy(t *testing.T) {
+	setTestPolicy(t, "providers:\n  allow: [zai]\n")
+	dir := t.TempDir()
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"zai":     {Name: "Z.AI"},
+		"minimax": {Name: "MiniMax"},
+	}}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(dir)
+	if err := storeProviderSecret(cliCtx, dir, "zai", "Zq8vN3pLr7Tx2KmW9cYd4HbF6gJs1QaE", "set_secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	failed, err := runSecretsValidate(cmd, nil)
+	if err != nil || failed {
+		t.Fatalf("runSecretsValidate() = %v, %v; want ok\n%s", failed, err, out.String())
+	}
+	if strings.Contains(out.String(), "minimax") {
+		t.Errorf("runSecretsValidate() checked minimax, which the policy denies:\n%s", out.String())
+	}
+}
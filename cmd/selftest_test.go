@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/constants"
+)
+
+func TestRunSelfTest(t *testing.T) {
+	if runtime.GOOS == constants.WindowsGOOS {
+		t.Skip("the wrapper runs through PowerShell on Windows")
+	}
+	tempDir := filepath.Join(t.TempDir(), "tmp")
+
+	results := runSelfTest(context.Background(), NewDeps(), tempDir)
+	want := []string{"platform", "key", "encryption", "key rotation", "wrapper", "wrapper signals"}
+	if len(results) != len(want) {
+		t.Fatalf("runSelfTest() = %+v, want checks %v", results, want)
+	}
+	for i, r := range results {
+		if r.Name != want[i] || r.Status != doctorOK {
+			t.Errorf("runSelfTest()[%d] = %s %v: %s, want %s to pass", i, r.Name, r.Status, r.Detail, want[i])
+		}
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("scratch directory left behind: %v, %v", entries, err)
+	}
+}
+
+func TestCheckSelfTestCrypto_StopsWithoutKey(t *testing.T) {
+	// A key path inside a missing directory cannot be written.
+	results := checkSelfTestCrypto(context.Background(), filepath.Join(t.TempDir(), "missing"))
+	if len(results) != 1 || results[0].Name != "key" || results[0].Status != doctorFail {
+		t.Errorf("checkSelfTestCrypto() = %+v, want only a failed key check", results)
+	}
+}
@@ -1,17 +1,33 @@
 package cmd
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
 	kairoerrors "github.com/dkmnx/kairo/internal/errors"
 	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/providers"
 	"github.com/dkmnx/kairo/internal/ui"
 	"github.com/dkmnx/kairo/internal/validate"
 	"github.com/spf13/cobra"
 	"github.com/yarlson/tap"
 )
 
-var setupResetSecrets bool
+var (
+	setupResetSecrets  bool
+	setupForce         bool
+	setupProvider      string
+	setupAPIKeyStdin   bool
+	setupResume        bool
+	setupAllowInsecure bool
+
+	setupEscrowRecipient string
+)
 
 func configureProvider(params ProviderSetup) (string, error) {
 	validatedName, err := ResolveProviderName(params.ProviderName)
@@ -31,30 +47,86 @@ func configureProvider(params ProviderSetup) (string, error) {
 		Exists:       exists,
 	}
 
-	displayProviderHeader(promptCfg)
+	// A typed or pasted key is cleaned before it is checked and kept in the
+	// setup progress; a key that cannot be cleaned reaches checkKey as
+	// entered, and checkKey reports why.
+	promptKey := func() string {
+		key := promptForAPIKey(promptCfg)
+		if clean, err := normalizeAPIKey(validatedName, key); err == nil {
+			return clean
+		}
 
-	var envKey string
-	if definition.APIKeyEnvVar == "" {
-		envKey = promptForEnvKey(promptCfg)
+		return key
 	}
+	checkKey := func(key string) error {
+		if _, err := normalizeAPIKey(validatedName, key); err != nil {
+			return err
+		}
+		if err := definition.ValidateAPIKey(key); err != nil {
+			return err
+		}
+		warnKeyStrength(validatedName, key)
 
-	apiKey := promptForAPIKey(promptCfg)
-	if err := definition.ValidateAPIKey(apiKey); err != nil {
-		return "", err
+		return nil
 	}
-
-	baseURL := promptForBaseURL(promptCfg)
-	if err := validate.ValidateURL(baseURL, definition.Name); err != nil {
-		return "", err
+	allowInsecure := params.AllowInsecure || provider.AllowInsecure
+	checkURL := func(baseURL string) error {
+		return validate.ValidateBaseURL(baseURL, definition.Name, allowInsecure)
+	}
+	checkModel := func(model string) error {
+		return validateConfiguredModel(modelValidationConfig{
+			Model:        model,
+			ProviderName: validatedName,
+			DisplayName:  definition.Name,
+		})
 	}
 
-	model := promptForModel(promptCfg)
-	if err := validateConfiguredModel(modelValidationConfig{
-		Model:        model,
-		ProviderName: validatedName,
-		DisplayName:  definition.Name,
-	}); err != nil {
-		return "", err
+	var envKey, apiKey, baseURL, model string
+	if params.APIKey != "" {
+		if apiKey, err = normalizeAPIKey(validatedName, params.APIKey); err != nil {
+			return "", err
+		}
+		envKey = provider.EnvKey
+		baseURL = cmp.Or(provider.BaseURL, definition.BaseURL)
+		model = cmp.Or(provider.Model, definition.Model)
+		if err := checkKey(apiKey); err != nil {
+			return "", err
+		}
+		if err := checkURL(baseURL); err != nil {
+			return "", err
+		}
+		if err := checkModel(model); err != nil {
+			return "", err
+		}
+	} else {
+		progress := params.Progress
+		if progress == nil || progress.Provider != validatedName {
+			progress = &setupProgress{Provider: validatedName}
+		}
+		wizard := setupWizard{params: params, progress: progress}
+
+		if progress.empty() {
+			displayProviderHeader(promptCfg)
+		} else {
+			tap.Message(fmt.Sprintf("Resuming setup of %s", definition.Name), tap.MessageOptions{
+				Hint: "Steps answered before the interruption are kept",
+			})
+		}
+
+		if definition.APIKeyEnvVar == "" {
+			if envKey, err = wizard.answer(&progress.EnvKey, func() string { return promptForEnvKey(promptCfg) }, nil); err != nil {
+				return "", err
+			}
+		}
+		if apiKey, err = wizard.answer(&progress.APIKey, promptKey, checkKey); err != nil {
+			return "", err
+		}
+		if baseURL, err = wizard.answer(&progress.BaseURL, func() string { return promptForBaseURL(promptCfg) }, checkURL); err != nil {
+			return "", err
+		}
+		if model, err = wizard.answer(&progress.Model, func() string { return promptForModel(promptCfg) }, checkModel); err != nil {
+			return "", err
+		}
 	}
 
 	provider = BuildProviderConfig(ProviderBuildConfig{
@@ -65,6 +137,7 @@ func configureProvider(params ProviderSetup) (string, error) {
 		Exists:     exists,
 		Existing:   &provider,
 	})
+	provider.AllowInsecure = allowInsecure
 
 	setAsDefault := params.Cfg.DefaultProvider == ""
 	if err := AddAndSaveProvider(AddProviderParams{
@@ -78,10 +151,32 @@ func configureProvider(params ProviderSetup) (string, error) {
 		return "", err
 	}
 
-	params.Secrets[harness.APIKeyEnvVar(validatedName)] = apiKey
+	envVar := harness.APIKeyEnvVar(validatedName)
+	oldKey := params.Secrets[envVar]
+	params.Secrets[envVar] = apiKey
 	if err := SaveSecrets(params.CLIContext, params.SecretsPath, params.KeyPath, params.Secrets); err != nil {
 		return "", err
 	}
+	if apiKey != oldKey {
+		recordAudit(params.CLIContext, params.ConfigDir, audit.Entry{
+			Event:    audit.EventRotate,
+			Action:   "setup_secret",
+			Provider: validatedName,
+			Details:  secretChangeDetails(oldKey, apiKey),
+		})
+	}
+
+	// The provider is saved, so the progress of this setup is no longer
+	// needed. Only the wizard writes progress.
+	if params.APIKey == "" {
+		if err := clearSetupProgress(params.ConfigDir); err != nil {
+			ui.PrintWarn(kairoerrors.Describe(err))
+		}
+	}
+
+	if params.OfferTestPrompt {
+		offerSetupProbe(params.CLIContext.RootCtx(), validatedName, provider, apiKey)
+	}
 
 	tap.Outro(fmt.Sprintf("%s configured successfully", provider.Name), tap.MessageOptions{
 		Hint: fmt.Sprintf("Run 'kairo %s' to use this provider", validatedName),
@@ -90,14 +185,53 @@ func configureProvider(params ProviderSetup) (string, error) {
 	return validatedName, nil
 }
 
+// setupWizard asks the setup prompts for one provider, recording each answer
+// in progress as it is given.
+type setupWizard struct {
+	params   ProviderSetup
+	progress *setupProgress
+}
+
+// answer returns the answer recorded in field by an interrupted setup, or
+// prompts for it. A new answer must pass check, if set, before it is
+// recorded and the progress saved. An empty answer means the prompt was
+// cancelled.
+func (w setupWizard) answer(field *string, prompt func() string, check func(string) error) (string, error) {
+	if *field != "" {
+		return *field, nil
+	}
+
+	value := prompt()
+	if value == "" {
+		return "", errSetupInterrupted
+	}
+	if check != nil {
+		if err := check(value); err != nil {
+			return "", err
+		}
+	}
+
+	*field = value
+	if err := saveSetupProgress(w.params.CLIContext, w.params.ConfigDir, w.params.KeyPath, w.progress); err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
 func runResetSecrets(cliCtx *CLIContext, configDir string, secretsResult SecretsResult) error {
 	ui.PrintWarn("This will delete your current encryption key and encrypted secrets.")
 	ui.PrintInfo("You will need to re-enter all API keys.")
 	ui.PrintInfo("")
 
-	confirmed, err := ui.Confirm("Continue")
-	if err != nil || !confirmed {
-		return kairoerrors.ErrUserCancelled
+	if !setupForce {
+		ok, err := confirmed("replacing the encryption key", func() (bool, error) { return ui.Confirm("Continue") })
+		if errors.Is(err, errPromptsOff) {
+			return err
+		}
+		if err != nil || !ok {
+			return kairoerrors.ErrUserCancelled
+		}
 	}
 
 	if err := ResetSecretsFiles(
@@ -105,6 +239,7 @@ func runResetSecrets(cliCtx *CLIContext, configDir string, secretsResult Secrets
 	); err != nil {
 		return err
 	}
+	recordAudit(cliCtx, configDir, audit.Entry{Event: audit.EventRotate, Action: "reset_secrets"})
 
 	ui.PrintSuccess("Encryption key regenerated successfully")
 
@@ -115,25 +250,38 @@ var setupCmd = &cobra.Command{
 	Use:   "setup",
 	Short: "Interactive setup and edit wizard",
 	Long: "Run the interactive wizard to configure new providers or edit existing ones. " +
-		"Select a provider to edit or choose 'new provider' to add a new provider.",
+		"Select a provider to edit or choose 'new provider' to add a new provider.\n\n" +
+		"Each answer is saved, encrypted, as soon as it is given. If the wizard is interrupted " +
+		"(for example with Ctrl-C) before the provider is saved, 'kairo setup --resume' " +
+		"continues from the first unanswered step.",
 	Run: func(cmd *cobra.Command, args []string) {
 		cliCtx := CLIContextFromCmd(cmd)
 		configDir := cliCtx.ConfigDir()
 		if configDir == "" {
-			ui.PrintError("Could not determine config directory. Set KAIRO_CONFIG_DIR or provide --config flag.")
+			printError(kairoerrors.NewError(kairoerrors.ConfigError, "could not determine config directory").
+				WithContext("hint", "set KAIRO_CONFIG_DIR or pass --config <dir>"))
 
 			return
 		}
 
+		if !setupAPIKeyStdin {
+			if err := requireInteractive("the provider's settings",
+				"pipe the key into 'kairo setup --provider <name> --api-key-stdin'"); err != nil {
+				printError(err)
+
+				return
+			}
+		}
+
 		if err := EnsureConfigDir(cliCtx, configDir); err != nil {
-			ui.PrintError(err.Error())
+			printError(err)
 
 			return
 		}
 
 		cfg, err := LoadConfig(cliCtx, configDir)
 		if err != nil {
-			ui.PrintError(fmt.Sprintf("Error loading config: %v", err))
+			printError(kairoerrors.WrapError(kairoerrors.ConfigError, "error loading config", err))
 
 			return
 		}
@@ -142,15 +290,13 @@ var setupCmd = &cobra.Command{
 		if err != nil {
 			if setupResetSecrets {
 				if err := runResetSecrets(cliCtx, configDir, secretsResult); err != nil {
-					ui.PrintError(fmt.Sprintf("Failed to reset secrets: %v", err))
-					ui.PrintInfo("Use --verbose for more details.")
+					printError(kairoerrors.WrapError(kairoerrors.CryptoError, "failed to reset secrets", err))
 
 					return
 				}
 				secretsResult.Secrets = make(map[string]string)
 			} else {
-				ui.PrintError(fmt.Sprintf("Failed to decrypt secrets file: %v", err))
-				printSecretsRecoveryHelp()
+				handleSecretsError(err)
 
 				return
 			}
@@ -158,7 +304,61 @@ var setupCmd = &cobra.Command{
 
 		ui.PrintWarnings(secretsResult.Warnings)
 
-		providerName := promptForProvider(cfg)
+		if setupEscrowRecipient != "" {
+			if err := setEscrowRecipient(cliCtx, configDir, setupEscrowRecipient); err != nil {
+				printError(err)
+
+				return
+			}
+		}
+
+		progress, err := loadSetupProgress(cliCtx, configDir, secretsResult.KeyPath)
+		if err != nil {
+			if setupResume {
+				printError(err)
+
+				return
+			}
+			ui.PrintWarn(kairoerrors.Describe(err))
+		}
+		if setupResume && progress == nil {
+			printError(kairoerrors.NewError(kairoerrors.ValidationError, "no interrupted setup to resume").
+				WithContext("hint", "run 'kairo setup' to start one"))
+
+			return
+		}
+		if !setupResume && progress != nil {
+			ui.PrintWarn(fmt.Sprintf("An interrupted setup of '%s' was found; run 'kairo setup --resume' to continue it. "+
+				"Answering the prompts below replaces it.", progress.Provider))
+			progress = nil
+		}
+
+		var apiKey string
+		if setupAPIKeyStdin {
+			if setupProvider == "" || setupProvider == customProviderName {
+				printError(kairoerrors.NewError(kairoerrors.ValidationError,
+					"--api-key-stdin requires --provider with a provider name").
+					WithContext("hint", "pipe the key into 'kairo setup --provider <name> --api-key-stdin'"))
+
+				return
+			}
+			if apiKey, err = readAPIKeyFromStdin(cmd.InOrStdin()); err != nil {
+				printError(err)
+
+				return
+			}
+		}
+
+		providerName := setupProvider
+		if progress != nil {
+			providerName = progress.Provider
+		} else if providerName == "" {
+			providerName = promptForProvider(cfg)
+		} else if providerName, err = resolveSetupProviderFlag(cfg, providerName); err != nil {
+			printError(err)
+
+			return
+		}
 		if providerName == "" {
 			tap.Cancel("Setup canceled")
 
@@ -166,14 +366,26 @@ var setupCmd = &cobra.Command{
 		}
 
 		if _, err := configureProvider(ProviderSetup{
-			CLIContext:   cliCtx,
-			ConfigDir:    configDir,
-			Cfg:          cfg,
-			ProviderName: providerName,
-			Secrets:      secretsResult.Secrets,
-			SecretsPath:  secretsResult.SecretsPath,
-			KeyPath:      secretsResult.KeyPath,
+			CLIContext:      cliCtx,
+			ConfigDir:       configDir,
+			Cfg:             cfg,
+			ProviderName:    providerName,
+			Secrets:         secretsResult.Secrets,
+			SecretsPath:     secretsResult.SecretsPath,
+			KeyPath:         secretsResult.KeyPath,
+			APIKey:          apiKey,
+			Progress:        progress,
+			AllowInsecure:   setupAllowInsecure,
+			OfferTestPrompt: !setupAPIKeyStdin,
 		}); err != nil {
+			if errors.Is(err, errSetupInterrupted) {
+				tap.Cancel("Setup interrupted")
+				if _, statErr := os.Stat(setupProgressPath(configDir)); statErr == nil {
+					ui.PrintInfo("Your answers so far are saved; run 'kairo setup --resume' to continue")
+				}
+
+				return
+			}
 			tap.Cancel(err.Error())
 
 			return
@@ -184,5 +396,48 @@ var setupCmd = &cobra.Command{
 func init() {
 	setupCmd.Flags().BoolVar(&setupResetSecrets, "reset-secrets", false,
 		"Reset encrypted secrets by regenerating encryption key (requires re-entering API keys)")
+	setupCmd.Flags().BoolVar(&setupForce, "force", false,
+		"With --reset-secrets, replace the existing encryption key without asking")
+	setupCmd.Flags().StringVar(&setupProvider, "provider", "",
+		"Configure this provider instead of choosing from a list")
+	setupCmd.Flags().BoolVar(&setupAPIKeyStdin, "api-key-stdin", false,
+		"Read the API key from piped stdin and keep current or default values for other fields (requires --provider)")
+	setupCmd.Flags().BoolVar(&setupResume, "resume", false,
+		"Continue an interrupted setup from the first unanswered step")
+	setupCmd.Flags().BoolVar(&setupAllowInsecure, "allow-insecure", false,
+		"Accept a plain HTTP or private base URL for an intentional local gateway (sets allow_insecure)")
+	setupCmd.Flags().StringVar(&setupEscrowRecipient, "escrow-recipient", "",
+		"Also encrypt secrets.age to this organization escrow age recipient (see 'kairo escrow')")
+	setupCmd.MarkFlagsMutuallyExclusive("resume", "provider")
+	setupCmd.MarkFlagsMutuallyExclusive("resume", "api-key-stdin")
 	rootCmd.AddCommand(setupCmd)
 }
+
+// resolveSetupProviderFlag checks the provider named by --provider. Configured
+// and built-in providers are used as-is; any other name must be a valid
+// custom provider name.
+func resolveSetupProviderFlag(cfg *config.Config, name string) (string, error) {
+	if _, ok := cfg.Providers[name]; ok {
+		return name, nil
+	}
+	if name == customProviderName || providers.IsBuiltInProvider(name) {
+		return name, nil
+	}
+
+	return ValidateCustomProviderName(name)
+}
+
+// readAPIKeyFromStdin reads a key piped into r. It refuses to read from a
+// terminal, where the key would be echoed; the interactive prompt is used
+// for that instead.
+func readAPIKeyFromStdin(r io.Reader) (string, error) {
+	if f, ok := r.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return "", kairoerrors.NewError(kairoerrors.ValidationError,
+				"--api-key-stdin needs the key piped in, but stdin is a terminal").
+				WithContext("hint", "run without --api-key-stdin to be prompted")
+		}
+	}
+
+	return readKeyFromReader(r)
+}
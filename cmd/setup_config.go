@@ -1,16 +1,23 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
+	"github.com/dkmnx/kairo/internal/agent"
+	"github.com/dkmnx/kairo/internal/audit"
 	"github.com/dkmnx/kairo/internal/config"
 	"github.com/dkmnx/kairo/internal/constants"
 	"github.com/dkmnx/kairo/internal/crypto"
 	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/journal"
 	"github.com/dkmnx/kairo/internal/secrets"
 )
 
@@ -20,7 +27,11 @@ func EnsureConfigDir(cliCtx *CLIContext, configDir string) error {
 		return kairoerrors.WrapError(kairoerrors.FileSystemError,
 			"creating config directory", err)
 	}
-	if err := cliCtx.Crypto().EnsureKeyExists(cliCtx.RootCtx(), configDir); err != nil {
+	svc, err := cryptoFor(cliCtx, configDir)
+	if err != nil {
+		return err
+	}
+	if err := svc.EnsureKeyExists(cliCtx.RootCtx(), configDir); err != nil {
 		return kairoerrors.WrapError(kairoerrors.CryptoError,
 			"creating encryption key", err)
 	}
@@ -28,6 +39,65 @@ func EnsureConfigDir(cliCtx *CLIContext, configDir string) error {
 	return nil
 }
 
+// cryptoFor returns the crypto service selected by crypto.backend in the
+// config in configDir. Without a config or a crypto section it is the
+// session's age service.
+func cryptoFor(cliCtx *CLIContext, configDir string) (crypto.Service, error) {
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), configDir)
+	if err != nil {
+		if errors.Is(err, kairoerrors.ErrConfigNotFound) || errors.Is(err, fs.ErrNotExist) {
+			return ageCrypto(cliCtx, configDir, nil), nil
+		}
+
+		return nil, err
+	}
+	if cfg.Crypto == nil {
+		return ageCrypto(cliCtx, configDir, cfg), nil
+	}
+
+	return crypto.NewService(crypto.BackendConfig{
+		Backend: cfg.Crypto.Backend,
+		KeyID:   cfg.Crypto.KeyID,
+		Region:  cfg.Crypto.Region,
+	}, ageCrypto(cliCtx, configDir, cfg), kmsCommandRunner(cliCtx.Deps()))
+}
+
+// ageCrypto is the session's age service, which goes through a running
+// kairo agent when it holds the key for configDir.
+func ageCrypto(cliCtx *CLIContext, configDir string, cfg *config.Config) crypto.Service {
+	if runtime.GOOS == constants.WindowsGOOS {
+		return cliCtx.Crypto()
+	}
+
+	return agent.Service{
+		Client:   agent.Client{Socket: agentSocketPath(configDir, cfg)},
+		Fallback: cliCtx.Crypto(),
+	}
+}
+
+// kmsCommandRunner runs the cloud provider CLIs used by the KMS backends.
+// Their stderr is kept for the error, since it usually names the missing
+// credential or permission.
+func kmsCommandRunner(deps *Deps) crypto.CommandRunner {
+	return func(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+		c := deps.Process.ExecCommandContext(ctx, name, args...)
+		if c == nil {
+			return nil, kairoerrors.NewError(kairoerrors.RuntimeError, "failed to start "+name)
+		}
+		var stderr bytes.Buffer
+		c.Stdin = bytes.NewReader(stdin)
+		c.Stderr = &stderr
+
+		out, err := c.Output()
+		if err != nil {
+			return nil, kairoerrors.WrapError(kairoerrors.RuntimeError, name+" failed", err).
+				WithContext("stderr", strings.TrimSpace(stderr.String()))
+		}
+
+		return out, nil
+	}
+}
+
 // LoadConfig loads the configuration, returning a default if not found.
 func LoadConfig(cliCtx *CLIContext, configDir string) (*config.Config, error) {
 	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), configDir)
@@ -55,20 +125,85 @@ type AddProviderParams struct {
 
 // AddAndSaveProvider adds a provider to the config and persists it.
 func AddAndSaveProvider(params AddProviderParams) error {
-	params.Cfg.Providers[params.ProviderName] = params.Provider
-	if params.SetAsDefault && params.Cfg.DefaultProvider == "" {
-		params.Cfg.DefaultProvider = params.ProviderName
-	}
+	previous := addProvider(params)
 	if err := config.SaveConfig(params.CLIContext.RootCtx(), params.ConfigDir, params.Cfg); err != nil {
 		return kairoerrors.WrapError(kairoerrors.ConfigError,
 			"saving config", err)
 	}
+	providerSaved(params, previous)
 
-	params.CLIContext.InvalidateCache(params.ConfigDir)
+	return nil
+}
+
+// AddAndSaveProviderWithSecrets adds a provider to the config and persists
+// it together with secretsResult.Secrets. Both files are staged and then
+// swapped in through a journal, so an interruption cannot leave the provider
+// saved without its key, or the key without its provider.
+func AddAndSaveProviderWithSecrets(params AddProviderParams, operation string, secretsResult SecretsResult) error {
+	previous := addProvider(params)
+
+	ctx := params.CLIContext.RootCtx()
+	configPath := filepath.Join(params.ConfigDir, config.BaseFileName)
+	stagedConfig := configPath + ".new"
+	stagedSecrets := secretsResult.SecretsPath + ".new"
+	j, err := journal.Begin(ctx, params.ConfigDir, operation, []journal.Step{
+		journal.Replace(stagedConfig, configPath),
+		journal.Replace(stagedSecrets, secretsResult.SecretsPath),
+	})
+	if err != nil {
+		return err
+	}
+	if err := config.WriteConfigFile(ctx, stagedConfig, params.Cfg); err != nil {
+		j.Abort()
+
+		return kairoerrors.WrapError(kairoerrors.ConfigError, "saving config", err)
+	}
+	if err := writeSecrets(params.CLIContext, stagedSecrets, secretsResult.SecretsPath,
+		secretsResult.KeyPath, secretsResult.Secrets); err != nil {
+		j.Abort()
+
+		return err
+	}
+	if err := j.Commit(); err != nil {
+		return err
+	}
+	providerSaved(params, previous)
 
 	return nil
 }
 
+// addProvider puts the provider into the config and returns the entry it
+// replaced, or nil when it is new.
+func addProvider(params AddProviderParams) *config.Provider {
+	var previous *config.Provider
+	if p, ok := params.Cfg.Providers[params.ProviderName]; ok {
+		previous = &p
+	}
+	params.Cfg.Providers[params.ProviderName] = params.Provider
+	if params.SetAsDefault && params.Cfg.DefaultProvider == "" {
+		params.Cfg.DefaultProvider = params.ProviderName
+	}
+
+	return previous
+}
+
+// providerSaved drops the cached config and audits the saved provider,
+// with the patch from its previous entry.
+func providerSaved(params AddProviderParams, previous *config.Provider) {
+	params.CLIContext.InvalidateCache(params.ConfigDir)
+
+	recordAudit(params.CLIContext, params.ConfigDir, audit.Entry{
+		Event:    audit.EventConfig,
+		Action:   "save_provider",
+		Provider: params.ProviderName,
+		Details: map[string]string{
+			"base_url": params.Provider.BaseURL,
+			"model":    params.Provider.Model,
+		},
+		Patch: providerPatch(params.ProviderName, previous, &params.Provider),
+	})
+}
+
 // SecretsResult holds the result of loading or initializing secrets.
 type SecretsResult struct {
 	Secrets      map[string]string
@@ -80,7 +215,6 @@ type SecretsResult struct {
 
 // LoadSecrets loads and decrypts secrets from the config directory.
 func LoadSecrets(cliCtx *CLIContext, configDir string) (SecretsResult, error) {
-	ctx := cliCtx.RootCtx()
 	result := SecretsResult{
 		Secrets: make(map[string]string),
 	}
@@ -92,13 +226,13 @@ func LoadSecrets(cliCtx *CLIContext, configDir string) (SecretsResult, error) {
 		return result, nil
 	}
 
-	existingSecrets, err := cliCtx.Crypto().DecryptSecretsBytes(ctx, result.SecretsPath, result.KeyPath)
+	payload, err := readSecretsFile(cliCtx, result.SecretsPath, result.KeyPath)
 	if err != nil {
 		return SecretsResult{}, err
 	}
-	defer crypto.ClearMemory(existingSecrets)
+	defer crypto.ClearMemory(payload)
 
-	secretsResult := secrets.ParseWithStats(string(existingSecrets))
+	secretsResult := secrets.ParseWithStats(string(payload))
 	result.Secrets = secretsResult.Secrets
 	result.SkippedCount = secretsResult.SkippedCount
 	result.Warnings = secretsResult.Warnings
@@ -118,7 +252,20 @@ func ResetSecretsFiles(ctx context.Context, cliCtx *CLIContext, configDir, secre
 			"failed to remove old secrets file", err)
 	}
 
-	if err := cliCtx.Crypto().EnsureKeyExists(ctx, configDir); err != nil {
+	// Setup progress and secrets backups are encrypted with the old key and
+	// cannot be read after.
+	if err := clearSetupProgress(configDir); err != nil {
+		return err
+	}
+	if err := removeSecretsBackups(secretsPath); err != nil {
+		return err
+	}
+
+	svc, err := cryptoFor(cliCtx, configDir)
+	if err != nil {
+		return err
+	}
+	if err := svc.EnsureKeyExists(ctx, configDir); err != nil {
 		return kairoerrors.WrapError(kairoerrors.CryptoError,
 			"failed to generate new encryption key", err)
 	}
@@ -126,10 +273,69 @@ func ResetSecretsFiles(ctx context.Context, cliCtx *CLIContext, configDir, secre
 	return nil
 }
 
-// SaveSecrets encrypts and writes the secrets map to the secrets file.
+// readSecretsFile decrypts the secrets file and verifies its checksum,
+// returning the formatted secrets. The caller should ClearMemory the result.
+func readSecretsFile(cliCtx *CLIContext, secretsPath, keyPath string) ([]byte, error) {
+	// Encryption and armoring add overhead to the sealed payload, so the
+	// file itself may be somewhat larger than MaxFileSize.
+	if info, err := os.Stat(secretsPath); err == nil && info.Size() > 2*secrets.MaxFileSize {
+		return nil, secretsCorruptedError(secretsPath,
+			fmt.Errorf("file is %d bytes, over the %d byte limit", info.Size(), 2*secrets.MaxFileSize))
+	}
+
+	// Commands often read the secrets more than once, and decrypting may be
+	// slow or ask for a passphrase, so each file is decrypted once a run.
+	plaintext, err := cliCtx.Secrets().Load(secretsPath, keyPath, func() ([]byte, error) {
+		svc, err := cryptoFor(cliCtx, filepath.Dir(secretsPath))
+		if err != nil {
+			return nil, err
+		}
+
+		return svc.DecryptSecretsBytes(cliCtx.RootCtx(), secretsPath, keyPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+	payload, err := secrets.Open(plaintext)
+	if err != nil {
+		crypto.ClearMemory(plaintext)
+
+		return nil, secretsCorruptedError(secretsPath, err)
+	}
+
+	return payload, nil
+}
+
+func secretsCorruptedError(secretsPath string, err error) error {
+	return kairoerrors.WrapError(kairoerrors.CryptoError, "secrets file is corrupted", err).
+		WithContext("path", secretsPath).
+		WithContext("hint", "run 'kairo secrets recover' to restore the last good backup")
+}
+
+// SaveSecrets encrypts and writes the secrets map to the secrets file. It
+// refuses to replace a secrets file that cannot be decrypted, and keeps the
+// file it replaces as a backup for 'kairo secrets recover'.
 func SaveSecrets(cliCtx *CLIContext, secretsPath, keyPath string, secretsMap map[string]string) error {
-	secretsContent := secrets.Format(secretsMap)
-	if err := cliCtx.Crypto().EncryptSecrets(cliCtx.RootCtx(), secretsPath, keyPath, secretsContent); err != nil {
+	return writeSecrets(cliCtx, secretsPath, secretsPath, keyPath, secretsMap)
+}
+
+// writeSecrets is SaveSecrets writing to dst, which is secretsPath or a file
+// staged to replace it.
+func writeSecrets(cliCtx *CLIContext, dst, secretsPath, keyPath string, secretsMap map[string]string) error {
+	svc, err := cryptoFor(cliCtx, filepath.Dir(secretsPath))
+	if err != nil {
+		return err
+	}
+	secretsContent := secrets.Seal(secrets.Format(secretsMap))
+	if len(secretsContent) > secrets.MaxFileSize {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("secrets would take %d bytes, over the %d byte limit", len(secretsContent), secrets.MaxFileSize))
+	}
+	if err := backupSecretsFile(cliCtx, secretsPath, keyPath); err != nil {
+		return err
+	}
+	cliCtx.Secrets().Invalidate(dst)
+	if err := svc.EncryptSecrets(cliCtx.RootCtx(), dst, keyPath, secretsContent); err != nil {
 		return kairoerrors.WrapError(kairoerrors.CryptoError,
 			"saving secrets", err)
 	}
@@ -146,4 +352,17 @@ type ProviderSetup struct {
 	Secrets      map[string]string
 	SecretsPath  string
 	KeyPath      string
+	// APIKey, when set, is used instead of prompting. The base URL, model,
+	// and env key then keep their current or default values so that setup
+	// can run without a terminal.
+	APIKey string
+	// Progress, when set for the same provider, holds the answers of an
+	// interrupted setup; the wizard only prompts for the remaining steps.
+	Progress *setupProgress
+	// AllowInsecure accepts a plain HTTP or private base URL and saves the
+	// provider with allow_insecure set.
+	AllowInsecure bool
+	// OfferTestPrompt offers, once the provider is saved, to send it a test
+	// prompt and show the reply.
+	OfferTestPrompt bool
 }
@@ -3,10 +3,15 @@ package cmd
 import (
 	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/dkmnx/kairo/internal/config"
 	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/secrets"
 )
 
 func TestResetSecretsFiles(t *testing.T) {
@@ -118,11 +123,11 @@ func TestSaveSecrets(t *testing.T) {
 		secretsPath := filepath.Join(tmpDir, constants.SecretsFileName)
 		keyPath := filepath.Join(tmpDir, constants.KeyFileName)
 
-		secrets := map[string]string{
+		secretsMap := map[string]string{
 			"ZAI_API_KEY": "sk-test-123",
 		}
 
-		err := SaveSecrets(cliCtx, secretsPath, keyPath, secrets)
+		err := SaveSecrets(cliCtx, secretsPath, keyPath, secretsMap)
 		if err != nil {
 			t.Fatalf("SaveSecrets() error = %v", err)
 		}
@@ -131,12 +136,19 @@ func TestSaveSecrets(t *testing.T) {
 			t.Errorf("secrets file should exist: %v", err)
 		}
 
-		decrypted, err := cliCtx.Crypto().DecryptSecrets(context.Background(), secretsPath, keyPath)
+		plaintext, err := cliCtx.Crypto().DecryptSecretsBytes(context.Background(), secretsPath, keyPath)
 		if err != nil {
-			t.Fatalf("DecryptSecrets() error = %v", err)
+			t.Fatalf("DecryptSecretsBytes() error = %v", err)
+		}
+		if !strings.HasPrefix(string(plaintext), "# kairo-secrets v1 sha256=") {
+			t.Errorf("plaintext = %q, want the version and checksum header", plaintext)
+		}
+		payload, err := secrets.Open(plaintext)
+		if err != nil {
+			t.Fatalf("secrets.Open() error = %v", err)
 		}
 
-		if decrypted != "ZAI_API_KEY=sk-test-123\n" {
+		if decrypted := string(payload); decrypted != "ZAI_API_KEY=sk-test-123\n" {
 			t.Errorf("decrypted content = %q, want %q", decrypted, "ZAI_API_KEY=sk-test-123\n")
 		}
 	})
@@ -149,3 +161,101 @@ func TestSaveSecrets(t *testing.T) {
 		}
 	})
 }
+
+func TestSecrets_KMSBackend(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses cat as a stand-in for gcloud")
+	}
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Providers: map[string]config.Provider{},
+		Crypto: &config.CryptoConfig{
+			Backend: "gcpkms",
+			KeyID:   "projects/p/locations/l/keyRings/r/cryptoKeys/k",
+		},
+	}
+	if err := config.SaveConfig(context.Background(), dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []string
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(testDeps(func(mp *mockProcess, _ *mockWrapper, _ *mockUpdate) {
+		mp.ExecCommandContextFn = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+			calls = append(calls, name+" "+strings.Join(args[:2], " "))
+
+			return exec.CommandContext(ctx, "cat")
+		}
+	}))
+
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		t.Fatalf("EnsureConfigDir() error = %v", err)
+	}
+	secretsPath := filepath.Join(dir, constants.SecretsFileName)
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+	if err := SaveSecrets(cliCtx, secretsPath, keyPath, map[string]string{"ZAI_API_KEY": "sk-kms"}); err != nil {
+		t.Fatalf("SaveSecrets() error = %v", err)
+	}
+	if _, err := os.Stat(keyPath); !os.IsNotExist(err) {
+		t.Errorf("age key created with a KMS backend: %v", err)
+	}
+
+	result, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		t.Fatalf("LoadSecrets() error = %v", err)
+	}
+	if result.Secrets["ZAI_API_KEY"] != "sk-kms" {
+		t.Errorf("ZAI_API_KEY = %q, want sk-kms", result.Secrets["ZAI_API_KEY"])
+	}
+	if len(calls) != 2 || calls[0] != "gcloud kms encrypt" || calls[1] != "gcloud kms decrypt" {
+		t.Errorf("KMS calls = %v, want one encrypt and one decrypt", calls)
+	}
+
+	if err := rotateMasterKey(cliCtx, dir); err != nil {
+		t.Fatalf("rotateMasterKey() error = %v", err)
+	}
+	if result, err := LoadSecrets(cliCtx, dir); err != nil || result.Secrets["ZAI_API_KEY"] != "sk-kms" {
+		t.Errorf("LoadSecrets() after rotation = %v, %v", result.Secrets, err)
+	}
+}
+
+func TestAddAndSaveProviderWithSecrets(t *testing.T) {
+	dir := t.TempDir()
+	cliCtx := NewCLIContext()
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		t.Fatal(err)
+	}
+	secretsResult, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretsResult.Secrets["ZAI_API_KEY"] = "sk-imported"
+	cfg := &config.Config{Providers: map[string]config.Provider{}}
+
+	err = AddAndSaveProviderWithSecrets(AddProviderParams{
+		CLIContext:   cliCtx,
+		ConfigDir:    dir,
+		Cfg:          cfg,
+		ProviderName: "zai",
+		Provider:     config.Provider{Name: "Z.AI", Model: "glm-4.7"},
+		SetAsDefault: true,
+	}, "import", secretsResult)
+	if err != nil {
+		t.Fatalf("AddAndSaveProviderWithSecrets() error = %v", err)
+	}
+
+	saved, err := LoadConfig(cliCtx, dir)
+	if err != nil || saved.DefaultProvider != "zai" || saved.Providers["zai"].Model != "glm-4.7" {
+		t.Errorf("LoadConfig() = %+v, %v; want zai saved as the default", saved, err)
+	}
+	stored, err := LoadSecrets(cliCtx, dir)
+	if err != nil || stored.Secrets["ZAI_API_KEY"] != "sk-imported" {
+		t.Errorf("LoadSecrets() = %v, %v; want the imported key", stored.Secrets, err)
+	}
+	for _, name := range []string{constants.JournalFileName, config.BaseFileName + ".new", constants.SecretsFileName + ".new"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s left behind: %v", name, err)
+		}
+	}
+}
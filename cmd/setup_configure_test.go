@@ -137,3 +137,105 @@ func TestConfigureProvider_InvalidAPIKey(t *testing.T) {
 		t.Error("provider should not be added when API key validation fails")
 	}
 }
+
+func TestConfigureProvider_APIKeySkipsPrompts(t *testing.T) {
+	setupTapTest(t)
+	configDir := t.TempDir()
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(configDir)
+	cliCtx.SetDeps(&Deps{Crypto: &mockCrypto{}})
+
+	cfg := &config.Config{Providers: map[string]config.Provider{}}
+	secrets := map[string]string{}
+	key := "sk-zai-stdin-key-abcdefghijklmnopqrst"
+
+	name, err := configureProvider(ProviderSetup{
+		CLIContext:   cliCtx,
+		ConfigDir:    configDir,
+		Cfg:          cfg,
+		ProviderName: "zai",
+		Secrets:      secrets,
+		APIKey:       key + "\r\n",
+	})
+	if err != nil {
+		t.Fatalf("configureProvider() error = %v", err)
+	}
+	if name != "zai" {
+		t.Errorf("configureProvider() = %q, want zai", name)
+	}
+
+	def := ProviderDefinition("zai")
+	if prov := cfg.Providers["zai"]; prov.BaseURL != def.BaseURL || prov.Model != def.Model {
+		t.Errorf("provider = %+v, want built-in defaults", prov)
+	}
+	if secrets["ZAI_API_KEY"] != key {
+		t.Errorf("ZAI_API_KEY = %q, want stdin key", secrets["ZAI_API_KEY"])
+	}
+
+	if _, err := configureProvider(ProviderSetup{
+		CLIContext:   cliCtx,
+		ConfigDir:    configDir,
+		Cfg:          cfg,
+		ProviderName: "zai",
+		Secrets:      secrets,
+		APIKey:       "short",
+	}); err == nil {
+		t.Error("configureProvider() expected validation error for short key")
+	}
+}
+
+func TestResolveSetupProviderFlag(t *testing.T) {
+	cfg := &config.Config{Providers: map[string]config.Provider{"my-llm": {}}}
+
+	for _, name := range []string{"zai", "my-llm", "new-llm"} {
+		if got, err := resolveSetupProviderFlag(cfg, name); err != nil || got != name {
+			t.Errorf("resolveSetupProviderFlag(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := resolveSetupProviderFlag(cfg, "9bad name"); err == nil {
+		t.Error("resolveSetupProviderFlag() expected error for invalid name")
+	}
+}
+
+func TestReadAPIKeyFromStdin(t *testing.T) {
+	got, err := readAPIKeyFromStdin(strings.NewReader("sk-piped-key\n"))
+	if err != nil || got != "sk-piped-key\n" {
+		t.Errorf("readAPIKeyFromStdin() = %q, %v", got, err)
+	}
+
+	if _, err := readAPIKeyFromStdin(strings.NewReader("")); err == nil {
+		t.Error("readAPIKeyFromStdin() expected error for empty stdin")
+	}
+}
+
+func TestConfigureProvider_AllowInsecure(t *testing.T) {
+	setupTapTest(t)
+	configDir := t.TempDir()
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(configDir)
+	cliCtx.SetDeps(&Deps{Crypto: &mockCrypto{}})
+
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"zai": {Name: "Z.AI", BaseURL: "http://127.0.0.1:8899", Model: "glm-4.7"},
+	}}
+	setup := ProviderSetup{
+		CLIContext:   cliCtx,
+		ConfigDir:    configDir,
+		Cfg:          cfg,
+		ProviderName: "zai",
+		Secrets:      map[string]string{},
+		APIKey:       "sk-zai-stdin-key-abcdefghijklmnopqrst",
+	}
+
+	if _, err := configureProvider(setup); err == nil || !strings.Contains(err.Error(), "HTTPS") {
+		t.Fatalf("configureProvider() with a plain HTTP base URL error = %v", err)
+	}
+
+	setup.AllowInsecure = true
+	if _, err := configureProvider(setup); err != nil {
+		t.Fatalf("configureProvider() with AllowInsecure error = %v", err)
+	}
+	if prov := cfg.Providers["zai"]; !prov.AllowInsecure || prov.BaseURL != "http://127.0.0.1:8899" {
+		t.Errorf("provider = %+v, want allow_insecure saved with the local base URL", prov)
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/compare"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/yarlson/tap"
+)
+
+const (
+	// setupProbePrompt is sent by the optional test at the end of setup.
+	// It asks for a reply short enough to cost a handful of tokens.
+	setupProbePrompt = "Reply with one word: ready"
+	// setupProbeMaxTokens bounds the reply to the test prompt.
+	setupProbeMaxTokens = 16
+	// setupProbeTimeout bounds the test prompt, including a cold start of
+	// the provider's model.
+	setupProbeTimeout = 60 * time.Second
+	// setupProbeReplyWidth is how much of the reply is shown.
+	setupProbeReplyWidth = 80
+)
+
+// offerSetupProbe asks whether to send a test prompt to the provider just
+// configured, and sends it if so, so that a wrong key, base URL, or model
+// name shows up before the user leaves the wizard.
+func offerSetupProbe(ctx context.Context, name string, provider config.Provider, apiKey string) {
+	if !tap.Confirm(promptContext(), tap.ConfirmOptions{
+		Message:      "Send a test prompt to check the key, base URL, and model?",
+		InitialValue: true,
+	}) {
+		return
+	}
+
+	ui.PrintInfo(fmt.Sprintf("Sending a test prompt to %s (%s)...", name, provider.Model))
+	res := sendSetupProbe(ctx, &http.Client{Timeout: setupProbeTimeout}, name, provider, apiKey)
+	if res.Err != nil {
+		ui.PrintWarn(fmt.Sprintf("Test prompt failed: %v", res.Err))
+		ui.PrintInfo(setupProbeHint(name, res))
+
+		return
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("%s replied in %s: %s", provider.Model,
+		res.Latency.Round(time.Millisecond), setupProbeReply(res.Text)))
+}
+
+// sendSetupProbe sends the test prompt to the provider.
+func sendSetupProbe(ctx context.Context, client *http.Client, name string, provider config.Provider,
+	apiKey string,
+) compare.Result {
+	ctx, cancel := context.WithTimeout(ctx, setupProbeTimeout)
+	defer cancel()
+
+	return compare.Send(ctx, client, compare.Target{
+		Provider: name,
+		BaseURL:  provider.BaseURL,
+		APIKey:   apiKey,
+		Style:    providerAuthStyle(name, provider),
+		Model:    provider.Model,
+	}, setupProbePrompt, setupProbeMaxTokens)
+}
+
+// setupProbeHint names the setting a failed test prompt most likely points
+// at. The provider stays saved either way.
+func setupProbeHint(name string, res compare.Result) string {
+	var cause string
+	switch res.Status {
+	case 0:
+		cause = "The provider could not be reached; check the base URL"
+	case http.StatusUnauthorized, http.StatusForbidden:
+		cause = "The provider rejected the API key; check that it was copied whole"
+	case http.StatusBadRequest, http.StatusNotFound:
+		cause = "Check the model name and the base URL"
+	case http.StatusTooManyRequests, 529:
+		cause = "The provider is busy or rate-limiting; the settings may be fine, so try again later"
+	default:
+		cause = "The provider returned an error"
+	}
+
+	return fmt.Sprintf("%s. The provider is saved; run 'kairo setup --provider %s' to change it.", cause, name)
+}
+
+// setupProbeReply returns the reply on one line, shortened to
+// setupProbeReplyWidth.
+func setupProbeReply(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return "(an empty reply)"
+	}
+	if r := []rune(text); len(r) > setupProbeReplyWidth {
+		return string(r[:setupProbeReplyWidth-1]) + "…"
+	}
+
+	return text
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/compare"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/mockprovider"
+)
+
+func TestSendSetupProbe(t *testing.T) {
+	srv := httptest.NewServer(mockprovider.NewHandler(mockprovider.Options{Reply: "ready"}))
+	defer srv.Close()
+
+	provider := config.Provider{Name: "Z.AI", BaseURL: srv.URL, Model: "glm-4.7"}
+	res := sendSetupProbe(context.Background(), srv.Client(), "zai", provider, "sk-zai-test-key-abcdefghijklmnopqrst")
+	if res.Err != nil {
+		t.Fatalf("sendSetupProbe() error = %v", res.Err)
+	}
+	if res.Text != "ready" || res.Status != http.StatusOK {
+		t.Errorf("sendSetupProbe() = %q, status %d", res.Text, res.Status)
+	}
+
+	res = sendSetupProbe(context.Background(), srv.Client(), "zai", provider, "")
+	if res.Err == nil || res.Status != http.StatusUnauthorized {
+		t.Fatalf("sendSetupProbe() without a key = %v, status %d", res.Err, res.Status)
+	}
+	if hint := setupProbeHint("zai", res); !strings.Contains(hint, "rejected the API key") {
+		t.Errorf("setupProbeHint() = %q", hint)
+	}
+}
+
+func TestSetupProbeHint(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{0, "could not be reached"},
+		{http.StatusForbidden, "rejected the API key"},
+		{http.StatusNotFound, "model name"},
+		{529, "try again later"},
+		{http.StatusInternalServerError, "returned an error"},
+	}
+	for _, tt := range tests {
+		got := setupProbeHint("zai", compare.Result{Status: tt.status})
+		if !strings.Contains(got, tt.want) || !strings.Contains(got, "kairo setup --provider zai") {
+			t.Errorf("setupProbeHint(%d) = %q, want it to mention %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestSetupProbeReply(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"  ready\n", "ready"},
+		{"one\ntwo", "one two"},
+		{"", "(an empty reply)"},
+		{strings.Repeat("a", 100), strings.Repeat("a", 79) + "…"},
+	}
+	for _, tt := range tests {
+		if got := setupProbeReply(tt.in); got != tt.want {
+			t.Errorf("setupProbeReply(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/secrets"
+)
+
+// errSetupInterrupted is returned by configureProvider when a prompt is
+// cancelled. The answers given so far are kept for 'kairo setup --resume'.
+var errSetupInterrupted = kairoerrors.NewError(kairoerrors.ValidationError, "setup interrupted")
+
+// Keys of the setup progress file, one per wizard step.
+const (
+	progressProvider = "provider"
+	progressEnvKey   = "env_key"
+	progressAPIKey   = "api_key"
+	progressBaseURL  = "base_url"
+	progressModel    = "model"
+)
+
+// setupProgress holds the wizard answers for one provider that have not been
+// saved to the config and secrets files yet. It is written after every step
+// and removed once the provider is saved, so an interrupted setup loses at
+// most the prompt that was open.
+type setupProgress struct {
+	Provider string
+	EnvKey   string
+	APIKey   string
+	BaseURL  string
+	Model    string
+}
+
+// empty reports whether no step has been answered.
+func (p *setupProgress) empty() bool {
+	return p.EnvKey == "" && p.APIKey == "" && p.BaseURL == "" && p.Model == ""
+}
+
+func setupProgressPath(configDir string) string {
+	return filepath.Join(configDir, constants.SetupProgressFileName)
+}
+
+// loadSetupProgress decrypts the progress of an interrupted setup in
+// configDir. It returns nil when there is none.
+func loadSetupProgress(cliCtx *CLIContext, configDir, keyPath string) (*setupProgress, error) {
+	path := setupProgressPath(configDir)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	svc, err := cryptoFor(cliCtx, configDir)
+	if err != nil {
+		return nil, err
+	}
+	content, err := svc.DecryptSecretsBytes(cliCtx.RootCtx(), path, keyPath)
+	if err != nil {
+		return nil, kairoerrors.WrapError(kairoerrors.CryptoError,
+			"failed to read interrupted setup progress", err).
+			WithContext("hint", "run 'kairo setup' to start over")
+	}
+	defer crypto.ClearMemory(content)
+
+	values := secrets.Parse(string(content))
+	if values[progressProvider] == "" {
+		return nil, nil
+	}
+
+	return &setupProgress{
+		Provider: values[progressProvider],
+		EnvKey:   values[progressEnvKey],
+		APIKey:   values[progressAPIKey],
+		BaseURL:  values[progressBaseURL],
+		Model:    values[progressModel],
+	}, nil
+}
+
+// saveSetupProgress encrypts progress to configDir with the secrets key.
+func saveSetupProgress(cliCtx *CLIContext, configDir, keyPath string, progress *setupProgress) error {
+	svc, err := cryptoFor(cliCtx, configDir)
+	if err != nil {
+		return err
+	}
+	content := secrets.Format(map[string]string{
+		progressProvider: progress.Provider,
+		progressEnvKey:   progress.EnvKey,
+		progressAPIKey:   progress.APIKey,
+		progressBaseURL:  progress.BaseURL,
+		progressModel:    progress.Model,
+	})
+	if err := svc.EncryptSecrets(cliCtx.RootCtx(), setupProgressPath(configDir), keyPath, content); err != nil {
+		return kairoerrors.WrapError(kairoerrors.CryptoError, "saving setup progress", err)
+	}
+
+	return nil
+}
+
+// clearSetupProgress removes the progress file once setup has finished.
+func clearSetupProgress(configDir string) error {
+	if err := os.Remove(setupProgressPath(configDir)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return kairoerrors.WrapError(kairoerrors.FileSystemError,
+			"failed to remove setup progress", err)
+	}
+
+	return nil
+}
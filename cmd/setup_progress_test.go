@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/yarlson/tap"
+)
+
+func emitCtrlC(in *tap.MockReadable) {
+	in.EmitKeypress("\x03", tap.Key{Name: "c", Ctrl: true})
+}
+
+func TestConfigureProvider_InterruptAndResume(t *testing.T) {
+	in, _ := setupTapTest(t)
+
+	dir := t.TempDir()
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(dir)
+	cliCtx.SetDeps(NewDeps())
+	if err := EnsureConfigDir(cliCtx, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Providers: map[string]config.Provider{}}
+	params := ProviderSetup{
+		CLIContext:   cliCtx,
+		ConfigDir:    dir,
+		Cfg:          cfg,
+		ProviderName: "zai",
+		Secrets:      map[string]string{},
+		SecretsPath:  filepath.Join(dir, constants.SecretsFileName),
+		KeyPath:      filepath.Join(dir, constants.KeyFileName),
+	}
+	const key = "sk-zai-test-key-abcdefghijklmnopqrst"
+
+	errCh := make(chan error)
+	go func() {
+		_, err := configureProvider(params)
+		errCh <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	emitText(in, key)
+	emitReturn(in)
+	time.Sleep(50 * time.Millisecond)
+	emitCtrlC(in)
+
+	if err := <-errCh; !errors.Is(err, errSetupInterrupted) {
+		t.Fatalf("configureProvider() error = %v, want errSetupInterrupted", err)
+	}
+	if _, ok := cfg.Providers["zai"]; ok {
+		t.Fatal("interrupted setup saved the provider")
+	}
+
+	progress, err := loadSetupProgress(cliCtx, dir, params.KeyPath)
+	if err != nil {
+		t.Fatalf("loadSetupProgress() error = %v", err)
+	}
+	if progress == nil || progress.Provider != "zai" || progress.APIKey != key || progress.BaseURL != "" {
+		t.Fatalf("progress = %+v, want the zai API key only", progress)
+	}
+
+	params.Progress = progress
+	resultCh := make(chan string)
+	go func() {
+		name, err := configureProvider(params)
+		if err != nil {
+			name = "error:" + err.Error()
+		}
+		resultCh <- name
+	}()
+	// Only the base URL and model are asked again.
+	time.Sleep(50 * time.Millisecond)
+	emitReturn(in)
+	time.Sleep(50 * time.Millisecond)
+	emitReturn(in)
+
+	if result := <-resultCh; result != "zai" {
+		t.Fatalf("resumed configureProvider() = %q, want zai", result)
+	}
+	if params.Secrets["ZAI_API_KEY"] != key {
+		t.Errorf("resumed setup stored key %q, want the one entered before the interruption", params.Secrets["ZAI_API_KEY"])
+	}
+	if _, err := os.Stat(setupProgressPath(dir)); !os.IsNotExist(err) {
+		t.Errorf("progress file still exists after setup finished: %v", err)
+	}
+}
+
+func TestLoadSetupProgress_None(t *testing.T) {
+	dir := t.TempDir()
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(NewDeps())
+
+	progress, err := loadSetupProgress(cliCtx, dir, filepath.Join(dir, constants.KeyFileName))
+	if err != nil || progress != nil {
+		t.Errorf("loadSetupProgress() = %+v, %v, want nil, nil", progress, err)
+	}
+}
@@ -95,10 +95,20 @@ func displayProviderHeader(cfg providerPromptConfig) {
 	}
 }
 
+// showKeyURL points at the page where the provider's API keys are created,
+// when the catalog knows it.
+func showKeyURL(def providers.ProviderDefinition) {
+	if def.Info.KeyURL != "" {
+		tap.Message("Get your key at " + def.Info.KeyURL)
+	}
+}
+
 func promptForAPIKey(cfg providerPromptConfig) string {
 	ctx := promptContext()
 
 	if !cfg.IsEdit || !cfg.Exists {
+		showKeyURL(cfg.Definition)
+
 		return tap.Password(ctx, tap.PasswordOptions{Message: "API Key"})
 	}
 
@@ -109,10 +119,14 @@ func promptForAPIKey(cfg providerPromptConfig) string {
 	}
 
 	if existingKey == "" {
+		showKeyURL(cfg.Definition)
+
 		return tap.Password(ctx, tap.PasswordOptions{Message: "API Key"})
 	}
 
 	if tap.Confirm(ctx, tap.ConfirmOptions{Message: "Modify API key?"}) {
+		showKeyURL(cfg.Definition)
+
 		return tap.Password(ctx, tap.PasswordOptions{Message: "New API Key"})
 	}
 
@@ -134,17 +148,13 @@ func promptForField(cfg promptFieldConfig) string {
 		return promptForFieldEdit(ctx, cfg)
 	}
 
-	result := strings.TrimSpace(tap.Text(ctx, tap.TextOptions{
+	// An empty submission returns DefaultValue, so an empty result means the
+	// prompt was cancelled.
+	return strings.TrimSpace(tap.Text(ctx, tap.TextOptions{
 		Message:      cfg.Label,
 		DefaultValue: cfg.DefaultValue,
 		Placeholder:  cfg.DefaultValue,
 	}))
-
-	if result == "" {
-		return cfg.DefaultValue
-	}
-
-	return result
 }
 
 func promptForFieldEdit(ctx context.Context, cfg promptFieldConfig) string {
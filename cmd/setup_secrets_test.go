@@ -165,6 +165,53 @@ func TestLoadSecrets(t *testing.T) {
 	}
 }
 
+func TestLoadSecrets_DecryptsOncePerSession(t *testing.T) {
+	tmpDir := t.TempDir()
+	secretsPath := filepath.Join(tmpDir, "secrets.age")
+	keyPath := filepath.Join(tmpDir, "age.key")
+	if err := crypto.GenerateKey(context.Background(), keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := crypto.EncryptSecrets(context.Background(), secretsPath, keyPath, "ZAI_API_KEY=test-key\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	decrypts := 0
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(&Deps{Crypto: &mockCrypto{
+		DecryptSecretsBytesFn: func(ctx context.Context, secretsPath, keyPath string) ([]byte, error) {
+			decrypts++
+
+			return crypto.DecryptSecretsBytes(ctx, secretsPath, keyPath)
+		},
+		EncryptSecretsFn: crypto.EncryptSecrets,
+	}})
+
+	for range 3 {
+		if _, err := LoadSecrets(cliCtx, tmpDir); err != nil {
+			t.Fatalf("LoadSecrets() error = %v", err)
+		}
+	}
+	if decrypts != 1 {
+		t.Errorf("secrets decrypted %d times by three loads, want 1", decrypts)
+	}
+
+	// Saving checks the old file is readable from the memo, then drops it.
+	if err := SaveSecrets(cliCtx, secretsPath, keyPath, map[string]string{"ZAI_API_KEY": "new-key"}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := LoadSecrets(cliCtx, tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Secrets["ZAI_API_KEY"] != "new-key" {
+		t.Errorf("ZAI_API_KEY after save = %q, want %q", result.Secrets["ZAI_API_KEY"], "new-key")
+	}
+	if decrypts != 2 {
+		t.Errorf("secrets decrypted %d times after a save, want 2", decrypts)
+	}
+}
+
 func TestLoadSecretsNoSecretsFile(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -62,9 +62,9 @@ func TestPrintBanner(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			oldStdout := os.Stdout
+			oldStderr := os.Stderr
 			r, w, _ := os.Pipe()
-			os.Stdout = w
+			os.Stderr = w
 
 			done := make(chan struct{})
 			go func() {
@@ -80,7 +80,7 @@ func TestPrintBanner(t *testing.T) {
 
 			<-done
 
-			os.Stdout = oldStdout
+			os.Stderr = oldStderr
 			var buf bytes.Buffer
 			if _, err := io.Copy(&buf, r); err != nil {
 				t.Logf("Warning: io.Copy failed: %v", err)
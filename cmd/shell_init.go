@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/spf13/cobra"
+)
+
+const defaultShellFunction = "kc"
+
+// fishPromptHook wraps the current fish_prompt so the segment is printed first.
+const fishPromptHook = `functions -c fish_prompt __kairo_orig_fish_prompt 2>/dev/null
+function fish_prompt
+    printf '%s ' (command kairo prompt-segment 2>/dev/null)
+    __kairo_orig_fish_prompt
+end
+`
+
+var (
+	shellInitFunction     string
+	shellInitPrompt       bool
+	shellInitNoCompletion bool
+)
+
+// shellFunctionName restricts --name to names every supported shell accepts
+// unquoted in a function definition.
+var shellFunctionName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+var shellInitCmd = &cobra.Command{
+	Use:   "shell-init bash|zsh|fish",
+	Short: "Print shell functions and completions for your rc file",
+	Long: `Print a short shell function for switching providers, completions for kairo
+and the function, and optionally a prompt hook. Evaluating the output does
+not run kairo, so it adds no noticeable time to shell startup. Add one line
+to your rc file:
+
+  # ~/.bashrc
+  eval "$(kairo shell-init bash)"
+
+  # ~/.zshrc
+  eval "$(kairo shell-init zsh)"
+
+  # ~/.config/fish/config.fish
+  kairo shell-init fish | source
+
+Afterwards 'kc zai' starts the harness with zai, 'kc' uses the default
+provider, and 'kc zai -- -p "hi"' passes arguments to the harness. Use
+--name to pick another function name and --prompt to show the default
+provider (see 'kairo prompt-segment') at the start of the prompt. In zsh,
+completions are only registered when compinit has already run.`,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := shellInitOptions{
+			Function:   shellInitFunction,
+			Prompt:     shellInitPrompt,
+			Completion: !shellInitNoCompletion,
+		}
+		if err := writeShellInit(cmd.OutOrStdout(), cmd.Root(), args[0], opts); err != nil {
+			printError(err)
+			CLIContextFromCmd(cmd).Deps().Process.ExitProcess(1)
+		}
+	},
+}
+
+func init() {
+	shellInitCmd.Flags().StringVar(&shellInitFunction, "name", defaultShellFunction, "Name of the switch function")
+	shellInitCmd.Flags().BoolVar(&shellInitPrompt, "prompt", false, "Prefix the prompt with 'kairo prompt-segment'")
+	shellInitCmd.Flags().BoolVar(&shellInitNoCompletion, "no-completion", false, "Leave out the completion script")
+	rootCmd.AddCommand(shellInitCmd)
+}
+
+// shellInitOptions selects the parts of the shell-init script.
+type shellInitOptions struct {
+	Function   string
+	Prompt     bool
+	Completion bool
+}
+
+// writeShellInit writes the shell-init script for shell to w.
+func writeShellInit(w io.Writer, root *cobra.Command, shell string, opts shellInitOptions) error {
+	if !shellFunctionName.MatchString(opts.Function) {
+		return kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("invalid function name %q", opts.Function))
+	}
+
+	var completion bytes.Buffer
+	if opts.Completion {
+		var err error
+		switch shell {
+		case "bash":
+			err = root.GenBashCompletion(&completion)
+		case "zsh":
+			err = root.GenZshCompletion(&completion)
+		case "fish":
+			err = root.GenFishCompletion(&completion, true)
+		}
+		if err != nil {
+			return kairoerrors.WrapError(kairoerrors.RuntimeError, "failed to generate completion script", err)
+		}
+	}
+
+	fn := opts.Function
+	fmt.Fprintf(w, "# kairo shell integration for %s, generated by 'kairo shell-init %s'\n\n", shell, shell)
+
+	switch shell {
+	case "bash", "zsh":
+		fmt.Fprintf(w, "%s() { command kairo \"$@\"; }\n", fn)
+	case "fish":
+		fmt.Fprintf(w, "function %s --wraps kairo --description 'Switch kairo provider'\n    command kairo $argv\nend\n", fn)
+	}
+
+	if opts.Completion {
+		fmt.Fprintln(w)
+		switch shell {
+		case "bash":
+			_, _ = completion.WriteTo(w)
+			fmt.Fprintf(w, "\ncomplete -o default -F __start_kairo %s\n", fn)
+		case "zsh":
+			// compdef only exists once compinit has run.
+			fmt.Fprintln(w, "if (( $+functions[compdef] )); then")
+			_, _ = completion.WriteTo(w)
+			fmt.Fprintf(w, "\ncompdef _kairo %s\nfi\n", fn)
+		case "fish":
+			_, _ = completion.WriteTo(w)
+		}
+	}
+
+	if opts.Prompt {
+		fmt.Fprintln(w)
+		switch shell {
+		case "bash":
+			fmt.Fprintln(w, `PS1='$(command kairo prompt-segment 2>/dev/null) '"$PS1"`)
+		case "zsh":
+			fmt.Fprintln(w, `setopt prompt_subst`)
+			fmt.Fprintln(w, `PROMPT='$(command kairo prompt-segment 2>/dev/null) '"$PROMPT"`)
+		case "fish":
+			_, _ = io.WriteString(w, fishPromptHook)
+		}
+	}
+
+	return nil
+}
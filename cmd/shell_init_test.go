@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteShellInit(t *testing.T) {
+	tests := []struct {
+		shell string
+		opts  shellInitOptions
+		want  []string
+	}{
+		{"bash", shellInitOptions{Function: "kc", Completion: true},
+			[]string{`kc() { command kairo "$@"; }`, "__start_kairo()", "complete -o default -F __start_kairo kc"}},
+		{"zsh", shellInitOptions{Function: "ks", Completion: true, Prompt: true},
+			[]string{`ks() { command kairo "$@"; }`, "if (( $+functions[compdef] )); then", "compdef _kairo ks", "kairo prompt-segment"}},
+		{"fish", shellInitOptions{Function: "kc"},
+			[]string{"function kc --wraps kairo", "command kairo $argv"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeShellInit(&buf, rootCmd, tt.shell, tt.opts); err != nil {
+				t.Fatalf("writeShellInit() error = %v", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("output missing %q", s)
+				}
+			}
+			if !tt.opts.Completion && strings.Contains(buf.String(), "__kairo_") {
+				t.Error("completion script included despite Completion=false")
+			}
+		})
+	}
+
+	if err := writeShellInit(new(bytes.Buffer), rootCmd, "bash", shellInitOptions{Function: "kc; rm -rf ~"}); err == nil {
+		t.Error("writeShellInit() expected error for an invalid function name")
+	}
+}
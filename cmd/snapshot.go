@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/claudesettings"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/envsnapshot"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/lockfile"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// snapshotKeyPlaceholder stands in for the API key when computing the
+// injected environment, so the credential variable is listed as redacted
+// without the real key ever being decrypted.
+const snapshotKeyPlaceholder = "redacted"
+
+var (
+	snapshotHarness string
+	snapshotOutput  string
+	runFromSnapshot string
+)
+
+var snapshotEnvCmd = &cobra.Command{
+	Use:   "snapshot-env [provider]",
+	Short: "Save the sanitized environment kairo would inject",
+	Long: `Write the provider, model, base URL, injected environment variables and
+harness version kairo would use for a switch to a JSON file, so the same setup
+can be reproduced later with 'kairo run --from-snapshot <file>'.
+
+Values of variables that look like credentials are never written; only their
+names are listed under "redacted". Without a provider the default provider is
+used. Without --output the snapshot is printed to stdout.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSnapshotEnv(cmd, args); err != nil {
+			printError(err)
+		}
+	},
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run [--provider <provider>] -- <command> [args]",
+	Short: "Run a command, or a recorded harness setup, with provider credentials",
+	Long: `Run any command with the environment a switch to Claude Code would inject:
+the provider's base URL, model and variables, and its API key, which is
+handed over by the same short-lived wrapper a harness gets. Without
+--provider the default provider is used. For example:
+
+  kairo run --provider zai -- env
+  kairo run -- python my_script.py
+
+The command's exit status is kairo's, and kairo prints nothing on stdout.
+When the provider sets run_env_allow in config.yaml, the command receives
+only the variables it lists, PATH, and those kairo injects.
+
+With --from-snapshot, reproduce a switch recorded by 'kairo snapshot-env'. The provider, model,
+base URL, harness and environment variables come from the snapshot; the API
+key and any redacted variables come from your own configuration and secrets.
+
+A warning is printed when the installed harness version differs from the
+recorded one.
+
+With --locked, start the provider pinned in kairo.lock by 'kairo lock' with
+the default harness instead. kairo exits with status 1 without starting the
+harness when the provider's base URL or model differ from the lock, and
+warns when its API key changed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch {
+		case runLocked:
+			err = runLockedProvider(cmd, args)
+		case runFromSnapshot != "":
+			if err := runFromSnapshotFile(cmd, args); err != nil {
+				printError(err)
+			}
+
+			return
+		default:
+			err = runCommandWithProvider(cmd, args)
+		}
+		if err != nil {
+			if !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+				printError(err)
+			}
+			CLIContextFromCmd(cmd).Deps().Process.ExitProcess(1)
+		}
+	},
+}
+
+func init() {
+	snapshotEnvCmd.Flags().StringVar(&snapshotHarness, "harness", "", "CLI harness to record (claude, qwen, pi, or crush)")
+	snapshotEnvCmd.Flags().StringVarP(&snapshotOutput, "output", "o", "", "Write the snapshot to this file instead of stdout")
+	runCmd.Flags().StringVar(&runFromSnapshot, "from-snapshot", "", "Snapshot file written by 'kairo snapshot-env'")
+	runCmd.Flags().BoolVar(&runLocked, "locked", false,
+		"Run the provider pinned in the lock file, refusing if its configuration changed")
+	runCmd.Flags().StringVar(&lockFilePath, "lock-file", lockfile.FileName, "Lock file to check with --locked")
+	runCmd.Flags().StringVar(&runProvider, "provider", "", "Provider whose credentials the command gets (default: the default provider)")
+	runCmd.MarkFlagsMutuallyExclusive("from-snapshot", "locked", "provider")
+	rootCmd.AddCommand(snapshotEnvCmd)
+	rootCmd.AddCommand(runCmd)
+}
+
+func runSnapshotEnv(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigOrExit(cmd)
+	if err != nil || cfg == nil {
+		return nil
+	}
+
+	providerName := cfg.DefaultProvider
+	if len(args) > 0 {
+		providerName = args[0]
+	}
+	if providerName == "" {
+		return kairoerrors.NewError(kairoerrors.ConfigError, "no provider given and no default provider set").
+			WithContext("hint", "run 'kairo snapshot-env <provider>'")
+	}
+	provider, ok := cfg.Providers[providerName]
+	if !ok {
+		return kairoerrors.NewError(kairoerrors.ProviderError,
+			fmt.Sprintf("provider '%s' not configured", providerName)).
+			WithContext("hint", "run 'kairo list' to see configured providers")
+	}
+
+	cliCtx := CLIContextFromCmd(cmd)
+	snap := buildSnapshot(cmd, cliCtx, provider, providerName,
+		resolveHarness(snapshotHarness, cfg.DefaultHarness))
+
+	if snapshotOutput == "" {
+		return envsnapshot.Write(cmd.OutOrStdout(), snap)
+	}
+
+	f, err := os.Create(snapshotOutput)
+	if err != nil {
+		return kairoerrors.FileError("failed to create snapshot file", snapshotOutput, err)
+	}
+	if err := envsnapshot.Write(f, snap); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return kairoerrors.FileError("failed to write snapshot file", snapshotOutput, err)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Environment snapshot written to %s", snapshotOutput))
+
+	return nil
+}
+
+// buildSnapshot records the environment a switch to providerName with
+// harnessToUse would inject, with credential values removed.
+func buildSnapshot(
+	cmd *cobra.Command, cliCtx *CLIContext, provider config.Provider, providerName, harnessToUse string,
+) envsnapshot.Snapshot {
+	deps := cliCtx.Deps()
+	provider.Model = provider.ModelFor(harnessToUse)
+	execCfg := ExecutionConfig{
+		Cmd:           cmd,
+		HarnessToUse:  harnessToUse,
+		HarnessBinary: harnessToUse,
+		Provider:      provider,
+		ProviderName:  providerName,
+		Deps:          deps,
+	}
+	if providers.RequiresAPIKey(providerName) {
+		execCfg.APIKey = snapshotKeyPlaceholder
+	}
+
+	env, redacted := envsnapshot.Sanitize(injectedEnv(execCfg))
+
+	snap := envsnapshot.Snapshot{
+		Version:      envsnapshot.FormatVersion,
+		CreatedAt:    time.Now().UTC(),
+		Provider:     providerName,
+		ProviderName: provider.Name,
+		Harness:      harnessToUse,
+		BaseURL:      provider.BaseURL,
+		Model:        provider.Model,
+		Env:          env,
+		Redacted:     redacted,
+	}
+	if path, err := deps.Process.LookPath(harnessToUse); err == nil && path != "" {
+		snap.HarnessVersion = harnessBinaryVersion(cliCtx.RootCtx(), deps, path)
+	}
+
+	return snap
+}
+
+func runFromSnapshotFile(cmd *cobra.Command, harnessArgs []string) error {
+	f, err := os.Open(runFromSnapshot)
+	if err != nil {
+		return kairoerrors.FileError("failed to open snapshot file", runFromSnapshot, err)
+	}
+	snap, err := envsnapshot.Read(f)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	pol, id, err := loadPolicy()
+	if err != nil {
+		return err
+	}
+	if err := checkProviderPolicy(pol, id, snap.Provider); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfigOrEmpty(cmd)
+	if err != nil {
+		return nil
+	}
+
+	cliCtx := CLIContextFromCmd(cmd)
+	t, missing := resolveSnapshotLaunch(cfg, snap, harnessArgs)
+	for _, name := range missing {
+		ui.PrintWarn(fmt.Sprintf("%s was redacted from the snapshot and is not set in your config", name))
+	}
+	warnSnapshotHarnessVersion(cliCtx, snap)
+	startLaunch(cmd, cliCtx, cfg, t)
+
+	return nil
+}
+
+// snapshotProvider rebuilds the provider recorded in snap. Redacted variables
+// are filled from the local provider configuration where it sets them; the
+// names of those it cannot fill are returned. The API key variable is not
+// among them, since it always comes from the local secrets.
+func snapshotProvider(snap envsnapshot.Snapshot, local config.Provider) (config.Provider, []string) {
+	provider := config.Provider{
+		Name:    snap.ProviderName,
+		BaseURL: snap.BaseURL,
+		Model:   snap.Model,
+		EnvVars: snap.EnvVars(),
+	}
+
+	localEnv := claudesettings.EnvMap(local.EnvVars)
+	authVar := authEnvVarName(ExecutionConfig{HarnessToUse: snap.Harness, ProviderName: snap.Provider, Provider: provider})
+
+	var missing []string
+	for _, name := range snap.Redacted {
+		if v, ok := localEnv[name]; ok {
+			provider.EnvVars = append(provider.EnvVars, name+"="+v)
+
+			continue
+		}
+		if name == authVar || name == harness.APIKeyEnvVar(snap.Provider) {
+			continue
+		}
+		missing = append(missing, name)
+	}
+	slices.Sort(provider.EnvVars)
+
+	return provider, missing
+}
+
+// warnSnapshotHarnessVersion warns when the installed harness differs from
+// the version recorded in snap.
+func warnSnapshotHarnessVersion(cliCtx *CLIContext, snap envsnapshot.Snapshot) {
+	if snap.HarnessVersion == "" {
+		return
+	}
+	deps := cliCtx.Deps()
+	path, err := deps.Process.LookPath(snap.Harness)
+	if err != nil || path == "" {
+		return
+	}
+	if cur := harnessBinaryVersion(cliCtx.RootCtx(), deps, path); cur != "" && cur != snap.HarnessVersion {
+		ui.PrintWarn(fmt.Sprintf("snapshot was taken with %s %s; %s is installed",
+			snap.Harness, snap.HarnessVersion, cur))
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/envsnapshot"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/spf13/cobra"
+)
+
+func TestBuildSnapshot(t *testing.T) {
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(testDeps(func(mp *mockProcess, _ *mockWrapper, _ *mockUpdate) {
+		mp.LookPathFn = func(string) (string, error) { return "", errors.New("not found") }
+	}))
+
+	provider := config.Provider{
+		Name:    "Z.AI",
+		BaseURL: "https://api.z.ai/api/anthropic",
+		Model:   "glm-5.1",
+		EnvVars: []string{"API_TIMEOUT_MS=3000000", "ZAI_SESSION_TOKEN=abc"},
+	}
+	snap := buildSnapshot(&cobra.Command{}, cliCtx, provider, "zai", harness.Claude)
+
+	if snap.Provider != "zai" || snap.Model != "glm-5.1" || snap.Harness != harness.Claude {
+		t.Errorf("snapshot = %+v", snap)
+	}
+	if snap.Env["API_TIMEOUT_MS"] != "3000000" || snap.Env["ANTHROPIC_BASE_URL"] != provider.BaseURL {
+		t.Errorf("Env = %v, want provider and built-in variables", snap.Env)
+	}
+	if !slices.Equal(snap.Redacted, []string{"ANTHROPIC_AUTH_TOKEN", "ZAI_SESSION_TOKEN"}) {
+		t.Errorf("Redacted = %v", snap.Redacted)
+	}
+	for k, v := range snap.Env {
+		if v == snapshotKeyPlaceholder || v == "abc" {
+			t.Errorf("Env[%s] carries a redacted value", k)
+		}
+	}
+
+	provider.Models = map[string]string{harness.Qwen: "glm-5.1-openai"}
+	snap = buildSnapshot(&cobra.Command{}, cliCtx, provider, "zai", harness.Qwen)
+	if snap.Model != "glm-5.1-openai" || snap.Env["ANTHROPIC_MODEL"] != "glm-5.1-openai" {
+		t.Errorf("qwen snapshot model = %q, env %v; want the qwen model", snap.Model, snap.Env)
+	}
+}
+
+func TestSnapshotProvider(t *testing.T) {
+	snap := envsnapshot.Snapshot{
+		Provider:     "zai",
+		ProviderName: "Z.AI",
+		Harness:      harness.Claude,
+		BaseURL:      "https://api.z.ai/api/anthropic",
+		Model:        "glm-5.1",
+		Env:          map[string]string{"API_TIMEOUT_MS": "3000000"},
+		Redacted:     []string{"ANTHROPIC_AUTH_TOKEN", "ZAI_SESSION_TOKEN", "OTHER_SECRET"},
+	}
+	local := config.Provider{EnvVars: []string{"ZAI_SESSION_TOKEN=mine"}}
+
+	provider, missing := snapshotProvider(snap, local)
+
+	if provider.BaseURL != snap.BaseURL || provider.Model != snap.Model {
+		t.Errorf("provider = %+v", provider)
+	}
+	want := []string{"API_TIMEOUT_MS=3000000", "ZAI_SESSION_TOKEN=mine"}
+	if !slices.Equal(provider.EnvVars, want) {
+		t.Errorf("EnvVars = %v, want %v", provider.EnvVars, want)
+	}
+	if !slices.Equal(missing, []string{"OTHER_SECRET"}) {
+		t.Errorf("missing = %v, want only OTHER_SECRET", missing)
+	}
+}
@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// spawnSessionDefault names the tmux session kairo spawn creates when it is
+// not run inside tmux.
+const spawnSessionDefault = "kairo-spawn"
+
+var (
+	spawnProviders []string
+	spawnLayout    int
+	spawnSession   string
+	spawnHarness   string
+	spawnYolo      bool
+)
+
+var spawnCmd = &cobra.Command{
+	Use:   "spawn --providers <a,b,...> [-- <prompt-file>]",
+	Short: "Start one harness session per provider side by side in tmux",
+	Long: `Start a harness session for each provider in its own tmux pane, to compare
+their answers to the same prompt side by side. Each pane runs 'kairo <provider>',
+so it switches exactly as a normal launch does: its key is decrypted, checked,
+and injected for that pane only.
+
+Given a prompt file, each harness starts with the file's contents as its
+first prompt. --layout sets how many panes share a tmux window; the panes of
+a window are tiled, and further providers open further windows.
+
+Outside tmux, spawn creates the session named by --session and attaches to
+it. Inside tmux, it opens the windows in the current session. Panes stay
+open after their harness exits so that its last output can be read. Without
+tmux, spawn prints the command for each provider to run in separate
+terminals.`,
+	Example: `  kairo spawn --providers zai,minimax,deepseek -- prompt.txt
+  kairo spawn --layout 2 --providers zai,minimax,deepseek,kimi --harness qwen`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSpawn(cmd, args); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	spawnCmd.Flags().StringSliceVar(&spawnProviders, "providers", nil, "Providers to start, one pane each (required)")
+	spawnCmd.Flags().IntVar(&spawnLayout, "layout", 0, "Panes per tmux window (default all in one window)")
+	spawnCmd.Flags().StringVar(&spawnSession, "session", spawnSessionDefault, "tmux session to create when not inside tmux")
+	spawnCmd.Flags().StringVar(&spawnHarness, "harness", "", "CLI harness for every pane (default is the configured harness)")
+	spawnCmd.Flags().BoolVarP(&spawnYolo, "yolo", "y", false, "Skip permission prompts in every pane")
+	rootCmd.AddCommand(spawnCmd)
+}
+
+// spawnPane is one provider's session and the shell command that starts it.
+type spawnPane struct {
+	provider string
+	command  string
+}
+
+func runSpawn(cmd *cobra.Command, args []string) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil {
+		if stderrors.Is(err, kairoerrors.ErrConfigNotFound) {
+			printNoProvidersMessage()
+
+			return errReported
+		}
+
+		return err
+	}
+
+	promptPath, err := spawnPromptPath(args)
+	if err != nil {
+		return err
+	}
+	panes, err := spawnPanes(cfg, dir, promptPath, runtime.GOOS == constants.WindowsGOOS)
+	if err != nil {
+		return err
+	}
+
+	tmux, err := cliCtx.Deps().Process.LookPath("tmux")
+	if err != nil || runtime.GOOS == constants.WindowsGOOS {
+		ui.PrintWarn("tmux is not available; run each command below in its own terminal")
+		out := cmd.OutOrStdout()
+		for _, pane := range panes {
+			fmt.Fprintln(out, pane.command)
+		}
+
+		return nil
+	}
+
+	return spawnTmux(cmd, cliCtx, tmux, panes)
+}
+
+// spawnPromptPath validates the optional prompt file argument and returns
+// its absolute path, since the panes may not start in the same directory.
+func spawnPromptPath(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", nil
+	}
+
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return "", kairoerrors.FileError("failed to resolve prompt file", args[0], err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", kairoerrors.FileError("failed to read prompt file", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("prompt file %s is not a regular file", path))
+	}
+
+	return path, nil
+}
+
+// spawnPanes checks the spawn flags against cfg and builds the command for
+// each provider's pane. Every provider is checked before any pane starts so
+// that a typo does not leave half a layout behind.
+func spawnPanes(cfg *config.Config, dir, promptPath string, windows bool) ([]spawnPane, error) {
+	if len(spawnProviders) == 0 {
+		return nil, kairoerrors.NewError(kairoerrors.ValidationError, "--providers is required").
+			WithContext("hint", "name the providers to compare, e.g. --providers zai,minimax")
+	}
+	if spawnLayout < 0 {
+		return nil, kairoerrors.NewError(kairoerrors.ValidationError, "--layout must not be negative")
+	}
+	if spawnHarness != "" && !isValidHarness(spawnHarness) {
+		return nil, kairoerrors.NewError(kairoerrors.ValidationError,
+			fmt.Sprintf("unknown harness '%s'", spawnHarness)).
+			WithContext("hint", "use claude, qwen, pi, or crush")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "kairo"
+	}
+
+	panes := make([]spawnPane, 0, len(spawnProviders))
+	for _, name := range spawnProviders {
+		provider, ok := cfg.Providers[name]
+		if !ok {
+			return nil, kairoerrors.NewError(kairoerrors.ProviderError,
+				fmt.Sprintf("provider '%s' not configured", name)).
+				WithContext("hint", "run 'kairo list' to see configured providers")
+		}
+		if provider.Disabled {
+			return nil, disabledProviderError(name)
+		}
+		panes = append(panes, spawnPane{provider: name, command: spawnPaneCommand(exe, dir, name, promptPath, windows)})
+	}
+
+	return panes, nil
+}
+
+// spawnPaneCommand returns the shell command that switches to provider in a
+// pane. The prompt file is read by the pane's shell rather than passed
+// inline, which keeps long prompts clear of tmux's command length limit.
+func spawnPaneCommand(exe, dir, provider, promptPath string, windows bool) string {
+	quote := spawnQuotePOSIX
+	if windows {
+		quote = spawnQuotePowerShell
+	}
+
+	parts := []string{quote(exe), "--config", quote(dir)}
+	if windows {
+		parts = append([]string{"&"}, parts...)
+	}
+	if spawnHarness != "" {
+		parts = append(parts, "--harness", quote(spawnHarness))
+	}
+	if spawnYolo {
+		parts = append(parts, "--yolo")
+	}
+	parts = append(parts, quote(provider))
+	if promptPath != "" {
+		if windows {
+			parts = append(parts, "--", "(Get-Content -Raw "+quote(promptPath)+")")
+		} else {
+			parts = append(parts, "--", `"$(cat `+quote(promptPath)+`)"`)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// spawnQuotePOSIX wraps s in single quotes for /bin/sh.
+func spawnQuotePOSIX(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// spawnQuotePowerShell wraps s in a PowerShell literal string.
+func spawnQuotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// spawnWindows groups panes into tmux windows of at most --layout panes.
+func spawnWindows(panes []spawnPane) [][]spawnPane {
+	size := spawnLayout
+	if size == 0 || size > len(panes) {
+		size = len(panes)
+	}
+
+	var windows [][]spawnPane
+	for start := 0; start < len(panes); start += size {
+		windows = append(windows, panes[start:min(start+size, len(panes))])
+	}
+
+	return windows
+}
+
+// spawnTmux lays the panes out in tmux, titling each pane with its provider,
+// and attaches to the new session unless kairo already runs inside tmux.
+func spawnTmux(cmd *cobra.Command, cliCtx *CLIContext, tmux string, panes []spawnPane) error {
+	ctx := cliCtx.RootCtx()
+	run := func(args ...string) (string, error) {
+		c := cliCtx.Deps().Process.ExecCommandContext(ctx, tmux, args...)
+		if c == nil {
+			return "", kairoerrors.NewError(kairoerrors.RuntimeError, "failed to run tmux")
+		}
+		var stderr bytes.Buffer
+		c.Stderr = &stderr
+		out, err := c.Output()
+		if err != nil {
+			return "", kairoerrors.WrapError(kairoerrors.RuntimeError,
+				fmt.Sprintf("tmux %s failed: %s", args[0], strings.TrimSpace(stderr.String())), err)
+		}
+
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	inside := os.Getenv("TMUX") != ""
+	if !inside {
+		if _, err := run("has-session", "-t", "="+spawnSession); err == nil {
+			return kairoerrors.NewError(kairoerrors.ValidationError,
+				fmt.Sprintf("tmux session '%s' already exists", spawnSession)).
+				WithContext("hint", "pass --session <name>, or attach with 'tmux attach -t "+spawnSession+"'")
+		}
+	}
+
+	for i, window := range spawnWindows(panes) {
+		names := make([]string, len(window))
+		for j, pane := range window {
+			names[j] = pane.provider
+		}
+		newWindow := []string{"new-window", "-P", "-F", "#{pane_id}"}
+		switch {
+		case i == 0 && !inside:
+			newWindow = []string{"new-session", "-d", "-P", "-F", "#{pane_id}", "-s", spawnSession}
+		case !inside:
+			newWindow = append(newWindow, "-t", spawnSession+":")
+		}
+		first, err := run(append(newWindow, "-n", strings.Join(names, ","), window[0].command)...)
+		if err != nil {
+			return err
+		}
+		if err := spawnTitlePane(run, first, window[0].provider); err != nil {
+			return err
+		}
+		for _, opt := range [][]string{{"remain-on-exit", "on"}, {"pane-border-status", "top"}} {
+			if _, err := run("set-window-option", "-t", first, opt[0], opt[1]); err != nil {
+				return err
+			}
+		}
+
+		for _, pane := range window[1:] {
+			id, err := run("split-window", "-t", first, "-P", "-F", "#{pane_id}", pane.command)
+			if err != nil {
+				return err
+			}
+			if err := spawnTitlePane(run, id, pane.provider); err != nil {
+				return err
+			}
+			// Retiling after each split keeps room for the next one.
+			if _, err := run("select-layout", "-t", first, "tiled"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if inside {
+		ui.PrintSuccess(fmt.Sprintf("Started %d sessions: %s", len(panes), strings.Join(spawnProviders, ", ")))
+
+		return nil
+	}
+
+	attach := cliCtx.Deps().Process.ExecCommandContext(ctx, tmux, "attach-session", "-t", "="+spawnSession)
+	if attach == nil {
+		return kairoerrors.NewError(kairoerrors.RuntimeError, "failed to run tmux")
+	}
+	attach.Stdin = os.Stdin
+	attach.Stdout = cmd.OutOrStdout()
+	attach.Stderr = os.Stderr
+	if err := attach.Run(); err != nil {
+		return kairoerrors.WrapError(kairoerrors.RuntimeError, "failed to attach to tmux session "+spawnSession, err).
+			WithContext("hint", "attach with 'tmux attach -t "+spawnSession+"'")
+	}
+
+	return nil
+}
+
+// spawnTitlePane titles pane with its provider, shown in the pane border.
+func spawnTitlePane(run func(...string) (string, error), pane, provider string) error {
+	_, err := run("select-pane", "-t", pane, "-T", provider)
+
+	return err
+}
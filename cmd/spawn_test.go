@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestSpawnPaneCommand(t *testing.T) {
+	defer func() { spawnHarness, spawnYolo = "", false }()
+	spawnHarness, spawnYolo = "qwen", true
+
+	got := spawnPaneCommand("/usr/bin/kairo", "/home/me/.config/kairo", "zai", "/tmp/it's.txt", false)
+	want := `'/usr/bin/kairo' --config '/home/me/.config/kairo' --harness 'qwen' --yolo 'zai' -- "$(cat '/tmp/it'\''s.txt')"`
+	if got != want {
+		t.Errorf("spawnPaneCommand() = %s, want %s", got, want)
+	}
+
+	got = spawnPaneCommand(`C:\kairo.exe`, `C:\cfg`, "zai", `C:\prompt.txt`, true)
+	if !strings.HasPrefix(got, `& 'C:\kairo.exe'`) || !strings.HasSuffix(got, `-- (Get-Content -Raw 'C:\prompt.txt')`) {
+		t.Errorf("spawnPaneCommand() for PowerShell = %s", got)
+	}
+}
+
+func TestSpawnWindows(t *testing.T) {
+	defer func() { spawnLayout = 0 }()
+	panes := make([]spawnPane, 5)
+
+	for _, tt := range []struct {
+		layout int
+		want   []int
+	}{{0, []int{5}}, {2, []int{2, 2, 1}}, {9, []int{5}}} {
+		spawnLayout = tt.layout
+		var got []int
+		for _, w := range spawnWindows(panes) {
+			got = append(got, len(w))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("layout %d: window sizes %v, want %v", tt.layout, got, tt.want)
+		}
+	}
+}
+
+func TestRunSpawnTmux(t *testing.T) {
+	t.Setenv("TMUX", "")
+	defer func() { spawnProviders, spawnLayout, spawnSession = nil, 0, spawnSessionDefault }()
+	spawnProviders, spawnLayout = []string{"zai", "minimax", "deepseek"}, 2
+
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, "providers:\n  zai:\n    name: Z.AI\n  minimax:\n    name: MiniMax\n  deepseek:\n    name: DeepSeek\n")
+	prompt := filepath.Join(dir, "prompt.txt")
+	if err := os.WriteFile(prompt, []byte("Explain this repo"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls [][]string
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(dir)
+	cliCtx.SetDeps(testDeps(func(mp *mockProcess, _ *mockWrapper, _ *mockUpdate) {
+		mp.LookPathFn = func(name string) (string, error) { return "/usr/bin/" + name, nil }
+		mp.ExecCommandContextFn = func(ctx context.Context, _ string, arg ...string) *exec.Cmd {
+			calls = append(calls, arg)
+			if arg[0] == "has-session" {
+				return exec.CommandContext(ctx, "false")
+			}
+
+			return exec.CommandContext(ctx, "echo", "%1")
+		}
+	}))
+	var out bytes.Buffer
+	cmd := &cobra.Command{Use: "kairo"}
+	cmd.SetOut(&out)
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	if err := runSpawn(cmd, []string{prompt}); err != nil {
+		t.Fatalf("runSpawn() error = %v", err)
+	}
+
+	var windows, splits, titles []string
+	for _, c := range calls {
+		switch c[0] {
+		case "new-session", "new-window":
+			windows = append(windows, strings.Join(c, " "))
+		case "split-window":
+			splits = append(splits, c[len(c)-1])
+		case "select-pane":
+			titles = append(titles, c[len(c)-1])
+		}
+	}
+	if len(windows) != 2 || !strings.HasPrefix(windows[0], "new-session -d") ||
+		!strings.Contains(windows[0], "-n zai,minimax") || !strings.Contains(windows[1], "-t kairo-spawn:") {
+		t.Errorf("windows = %q, want a new session for zai,minimax and a window for deepseek", windows)
+	}
+	if len(splits) != 1 || !strings.Contains(splits[0], "'minimax' -- \"$(cat '"+prompt+"')\"") {
+		t.Errorf("splits = %q, want one pane for minimax with the prompt", splits)
+	}
+	if strings.Join(titles, ",") != "zai,minimax,deepseek" {
+		t.Errorf("pane titles = %v", titles)
+	}
+	if last := calls[len(calls)-1]; strings.Join(last, " ") != "attach-session -t =kairo-spawn" {
+		t.Errorf("last tmux call = %v, want attach-session", last)
+	}
+}
+
+func TestRunSpawnValidation(t *testing.T) {
+	defer func() { spawnProviders = nil }()
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, "providers:\n  zai:\n    name: Z.AI\n")
+	cmd, out := explainTestCmd(t, dir)
+
+	spawnProviders = []string{"zai", "kimi"}
+	if err := runSpawn(cmd, nil); err == nil || !strings.Contains(err.Error(), "'kimi' not configured") {
+		t.Errorf("runSpawn() with an unconfigured provider error = %v", err)
+	}
+	spawnProviders = nil
+	if err := runSpawn(cmd, nil); err == nil || !strings.Contains(err.Error(), "--providers is required") {
+		t.Errorf("runSpawn() without providers error = %v", err)
+	}
+	spawnProviders = []string{"zai"}
+	if err := runSpawn(cmd, []string{filepath.Join(dir, "missing.txt")}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("runSpawn() with a missing prompt file error = %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("runSpawn() printed %q before failing", out.String())
+	}
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusHistory     string
+	statusLimit       int
+	statusTestWebhook bool
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status [provider...]",
+	Short: "Check provider health",
+	Long: `Probe each configured provider (or only the named ones) and record the
+result in a per-provider history under the state directory.
+
+Under each provider, the request ID and rate limits it reported in the
+response headers are shown when it sends them, such as the requests left and
+when the limit resets. Quote the request ID in support tickets.
+
+With --history, print the recorded checks for one provider instead of
+running new ones.
+
+When hooks.health_webhook is set, a provider that went down or came back up
+since its last recorded check is posted to it as a JSON event that Slack
+incoming webhooks accept. Run kairo status from cron or a systemd timer for
+scheduled alerting. --test-webhook posts a sample event instead of running
+checks.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if statusLimit < 1 {
+			printError(kairoerrors.NewError(kairoerrors.ValidationError, "--limit must be at least 1"))
+
+			return
+		}
+
+		dir := requireConfigDir(cmd)
+		if dir == "" {
+			return
+		}
+
+		if statusHistory != "" {
+			state := stateDir(CLIContextFromCmd(cmd), dir)
+			if err := printHealthHistory(cmd.OutOrStdout(), state, statusHistory, statusLimit, time.Now()); err != nil {
+				printError(err)
+			}
+
+			return
+		}
+
+		cfg, err := loadConfigOrExit(cmd)
+		if err != nil || cfg == nil {
+			return
+		}
+		if statusTestWebhook {
+			if err := sendTestWebhook(cmd, cfg); err != nil {
+				printError(err)
+			}
+
+			return
+		}
+		if len(cfg.Providers) == 0 {
+			printNoProvidersMessage()
+
+			return
+		}
+
+		if err := runStatusChecks(cmd, dir, cfg, args); err != nil {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	statusCmd.Flags().StringVar(&statusHistory, "history", "", "Show recorded health checks for a provider")
+	statusCmd.Flags().IntVar(&statusLimit, "limit", 20, "Number of recorded checks shown with --history")
+	statusCmd.Flags().BoolVar(&statusTestWebhook, "test-webhook", false, "Post a sample event to hooks.health_webhook and exit")
+	rootCmd.AddCommand(statusCmd)
+}
+
+// runStatusChecks probes the named providers, or all configured providers
+// that are not disabled and that the policy allows when names is empty,
+// prints one line per provider, and appends each result to its history.
+func runStatusChecks(cmd *cobra.Command, dir string, cfg *config.Config, names []string) error {
+	cliCtx := CLIContextFromCmd(cmd)
+	if cliCtx == nil || cliCtx.Deps() == nil || cliCtx.Deps().Health == nil {
+		return kairoerrors.NewError(kairoerrors.RuntimeError, "health checks are not available")
+	}
+
+	if len(names) == 0 {
+		for _, name := range sortProviderNames(cfg.Providers, cfg.DefaultProvider) {
+			if !cfg.Providers[name].Disabled {
+				names = append(names, name)
+			}
+		}
+		allowed, err := allowedProviders(names)
+		if err != nil {
+			return err
+		}
+		names = allowed
+	}
+	for _, name := range names {
+		if _, ok := cfg.Providers[name]; !ok {
+			return kairoerrors.NewError(kairoerrors.ProviderError,
+				fmt.Sprintf("provider '%s' not configured", name))
+		}
+	}
+
+	secretsResult, err := LoadSecrets(cliCtx, dir)
+	if err != nil {
+		return err
+	}
+
+	state := stateDir(cliCtx, dir)
+	out := cmd.OutOrStdout()
+	resolver := newSecretResolver(cliCtx)
+	for _, name := range names {
+		apiKey := ""
+		if providers.RequiresAPIKey(name) {
+			apiKey, _, err = resolver.resolveAPIKey(secretsResult.Secrets, name)
+			if err != nil {
+				fmt.Fprintf(out, "%s✗%s %-12s %s\n", ui.Red, ui.Reset, name, kairoerrors.Describe(err))
+
+				continue
+			}
+		}
+		if apiKey != "" {
+			notifySecretAccess(cliCtx, dir, name, accessPurposeHealth)
+		}
+
+		style := providerAuthStyle(name, cfg.Providers[name])
+		res := cliCtx.Deps().Health.Check(cliCtx.RootCtx(), cfg.Providers[name].BaseURL, apiKey, style)
+		printStatusLine(out, name, res)
+
+		// A provider's first check has nothing to compare with.
+		if history, err := health.LoadHistory(state, name); err == nil && len(history) > 0 {
+			notifyHealthChange(cliCtx.RootCtx(), cfg, name, history[len(history)-1], res)
+		}
+		if err := health.AppendHistory(state, name, res); err != nil {
+			ui.PrintWarn(fmt.Sprintf("Could not record health history for %s: %v", name, err))
+		}
+	}
+
+	return nil
+}
+
+// sendTestWebhook posts a sample event to the configured health webhook, so
+// that its URL and the receiving channel can be checked.
+func sendTestWebhook(cmd *cobra.Command, cfg *config.Config) error {
+	webhook, ok := healthWebhook(cfg)
+	if !ok {
+		return kairoerrors.NewError(kairoerrors.ConfigError, "no health webhook is configured").
+			WithContext("hint", "set hooks.health_webhook in config.yaml")
+	}
+	host, _ := os.Hostname()
+	event := health.Event{
+		Text:     "kairo: test event from kairo status --test-webhook",
+		Event:    health.EventTest,
+		Provider: cfg.DefaultProvider,
+		Host:     host,
+		Status:   health.StatusOK,
+		Time:     time.Now().UTC().Format(time.RFC3339),
+	}
+	if host != "" {
+		event.Text += " on " + host
+	}
+	if err := webhook.Send(commandContext(cmd), event); err != nil {
+		return err
+	}
+	ui.PrintSuccess("Sent a test event to the health webhook")
+
+	return nil
+}
+
+// printStatusLine prints the result of a check, followed by the request ID
+// and rate limits the provider reported, for quota questions and support
+// tickets.
+func printStatusLine(out io.Writer, name string, res health.Result) {
+	if res.OK() {
+		fmt.Fprintf(out, "%s✓%s %-12s %s\n", ui.Green, ui.Reset, name, formatLatency(res.Latency))
+	} else {
+		fmt.Fprintf(out, "%s✗%s %-12s %s (%s)\n", ui.Red, ui.Reset, name, res.Status, res.Error)
+	}
+	if summary := res.Quota.Summary(res.Time.Add(res.Latency)); summary != "" {
+		fmt.Fprintf(out, "  %-12s %s%s%s\n", "", ui.Gray, summary, ui.Reset)
+	}
+}
+
+// printHealthHistory renders the last limit recorded checks for provider as
+// a sparkline, a summary line, and a table. Ages are relative to now.
+func printHealthHistory(out io.Writer, dir, provider string, limit int, now time.Time) error {
+	results, err := health.LoadHistory(dir, provider)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Fprintf(out, "No health history for %s. Run 'kairo status %s' to record a check.\n", provider, provider)
+
+		return nil
+	}
+	if len(results) > limit {
+		results = results[len(results)-limit:]
+	}
+
+	summary := health.Summarize(results)
+	fmt.Fprintf(out, "%s  %s\n", provider, health.Sparkline(results))
+	fmt.Fprintf(out, "%d checks, %d failed, avg latency %s\n\n",
+		summary.Checks, summary.Failures, formatLatency(summary.AvgLatency))
+
+	fmt.Fprintf(out, "%-23s  %-9s  %-10s  %10s  %s\n", "TIME", "AGE", "STATUS", "LATENCY", "DETAIL")
+	for _, r := range results {
+		fmt.Fprintf(out, "%-23s  %-9s  %-10s  %10s  %s\n",
+			ui.FormatTime(r.Time, utcFlag), ui.RelativeTime(r.Time, now), r.Status, formatLatency(r.Latency), r.Error)
+	}
+
+	return nil
+}
+
+func formatLatency(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+
+	return d.Round(time.Millisecond).String()
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/spf13/cobra"
+)
+
+func TestRunStatusChecksRecordsHistory(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"zai":    {Name: "Z.AI", BaseURL: "https://api.z.ai/api/anthropic"},
+		"custom": {Name: "custom", BaseURL: "https://down.example.com"},
+	}}
+
+	var probed []string
+	d := testDeps()
+	d.Health = &mockHealth{CheckFn: func(_ context.Context, baseURL, _ string, _ health.AuthStyle) health.Result {
+		probed = append(probed, baseURL)
+		if strings.Contains(baseURL, "down") {
+			return health.Result{Time: time.Now(), Status: health.StatusError, Error: "HTTP 503"}
+		}
+
+		return health.Result{Time: time.Now(), Latency: 120 * time.Millisecond, Status: health.StatusOK}
+	}}
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(d)
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	if err := runStatusChecks(cmd, dir, cfg, nil); err != nil {
+		t.Fatalf("runStatusChecks() error = %v", err)
+	}
+	if len(probed) != 2 {
+		t.Fatalf("probed %d providers, want 2", len(probed))
+	}
+	if out := buf.String(); !strings.Contains(out, "zai") || !strings.Contains(out, "HTTP 503") {
+		t.Errorf("output missing provider lines:\n%s", out)
+	}
+
+	for _, name := range []string{"zai", "custom"} {
+		results, err := health.LoadHistory(dir, name)
+		if err != nil || len(results) != 1 {
+			t.Errorf("history for %s = %v, %v; want one result", name, results, err)
+		}
+	}
+}
+
+func TestRunStatusChecksSkipsProvidersDeniedByPolicy(t *testing.T) {
+	setTestPolicy(t, "providers:\n  deny: [zai]\n")
+	dir := t.TempDir()
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"zai":     {Name: "Z.AI", BaseURL: "https://api.z.ai/api/anthropic"},
+		"minimax": {Name: "MiniMax", BaseURL: "https://api.minimax.io/anthropic"},
+	}}
+
+	var probed []string
+	d := testDeps()
+	d.Health = &mockHealth{CheckFn: func(_ context.Context, baseURL, _ string, _ health.AuthStyle) health.Result {
+		probed = append(probed, baseURL)
+
+		return health.Result{Time: time.Now(), Status: health.StatusOK}
+	}}
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(d)
+
+	cmd := &cobra.Command{}
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	if err := runStatusChecks(cmd, dir, cfg, nil); err != nil {
+		t.Fatalf("runStatusChecks() error = %v", err)
+	}
+	if len(probed) != 1 || probed[0] != "https://api.minimax.io/anthropic" {
+		t.Errorf("probed %v, want only minimax", probed)
+	}
+}
+
+func TestRunStatusChecksPostsHealthChanges(t *testing.T) {
+	var events []health.Event
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var e health.Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Error(err)
+		}
+		events = append(events, e)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Providers: map[string]config.Provider{"zai": {Name: "Z.AI", BaseURL: "https://api.z.ai/api/anthropic"}},
+		Hooks:     &config.HooksConfig{HealthWebhook: srv.URL},
+	}
+	up := true
+	d := testDeps()
+	d.Health = &mockHealth{CheckFn: func(context.Context, string, string, health.AuthStyle) health.Result {
+		if !up {
+			return health.Result{Time: time.Now(), Status: health.StatusError, Error: "HTTP 503"}
+		}
+
+		return health.Result{Time: time.Now(), Status: health.StatusOK}
+	}}
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(d)
+	cmd := &cobra.Command{}
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	for _, up = range []bool{true, true, false, false, true} {
+		if err := runStatusChecks(cmd, dir, cfg, nil); err != nil {
+			t.Fatalf("runStatusChecks() error = %v", err)
+		}
+	}
+
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Event+":"+e.Provider)
+	}
+	want := []string{"provider_down:zai", "provider_up:zai"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("webhook events = %v, want %v", got, want)
+	}
+}
+
+func TestSendTestWebhook(t *testing.T) {
+	var got health.Event
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(WithCLIContext(context.Background(), NewCLIContext()))
+	if err := sendTestWebhook(cmd, &config.Config{}); err == nil {
+		t.Error("sendTestWebhook() without a webhook succeeded, want an error")
+	}
+
+	cfg := &config.Config{DefaultProvider: "zai", Hooks: &config.HooksConfig{HealthWebhook: srv.URL}}
+	if err := sendTestWebhook(cmd, cfg); err != nil {
+		t.Fatalf("sendTestWebhook() error = %v", err)
+	}
+	if got.Event != health.EventTest || got.Provider != "zai" || !strings.HasPrefix(got.Text, "kairo: test event") {
+		t.Errorf("test event = %+v", got)
+	}
+}
+
+func TestRunStatusChecksUnknownProvider(t *testing.T) {
+	d := testDeps()
+	d.Health = &mockHealth{CheckFn: func(context.Context, string, string, health.AuthStyle) health.Result {
+		t.Fatal("Check called for unknown provider")
+
+		return health.Result{}
+	}}
+	cliCtx := NewCLIContext()
+	cliCtx.SetDeps(d)
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	cfg := &config.Config{Providers: map[string]config.Provider{"zai": {}}}
+	if err := runStatusChecks(cmd, t.TempDir(), cfg, []string{"nope"}); err == nil {
+		t.Error("runStatusChecks() expected error for unknown provider")
+	}
+}
+
+func TestPrintStatusLine(t *testing.T) {
+	buf := new(bytes.Buffer)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	printStatusLine(buf, "zai", health.Result{Time: now, Latency: 120 * time.Millisecond, Status: health.StatusOK})
+	if strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("output without headers = %q, want one line", buf.String())
+	}
+
+	buf.Reset()
+	printStatusLine(buf, "zai", health.Result{
+		Time: now, Status: health.StatusError, StatusCode: http.StatusTooManyRequests, Error: "HTTP 429",
+		Quota: health.Quota{RequestID: "req_1", RequestsRemaining: "0", RequestsLimit: "50", RequestsReset: "2026-03-01T12:00:30Z"},
+	})
+	if !strings.Contains(buf.String(), "request req_1, 0 of 50 requests left, resets in 30s") {
+		t.Errorf("quota line missing:\n%s", buf.String())
+	}
+}
+
+func TestPrintHealthHistory(t *testing.T) {
+	dir := t.TempDir()
+
+	buf := new(bytes.Buffer)
+	if err := printHealthHistory(buf, dir, "zai", 20, time.Now()); err != nil {
+		t.Fatalf("printHealthHistory() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "No health history") {
+		t.Errorf("empty history output = %q", buf.String())
+	}
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		r := health.Result{Time: base.Add(time.Duration(i) * time.Minute), Latency: 100 * time.Millisecond, Status: health.StatusOK}
+		if i == 4 {
+			r = health.Result{Time: r.Time, Status: health.StatusAuthError, Error: "HTTP 401"}
+		}
+		if err := health.AppendHistory(dir, "zai", r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf.Reset()
+	if err := printHealthHistory(buf, dir, "zai", 3, base.Add(2*time.Hour)); err != nil {
+		t.Fatalf("printHealthHistory() error = %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "3 checks, 1 failed") {
+		t.Errorf("summary missing or wrong:\n%s", out)
+	}
+	if !strings.Contains(out, "1h ago") {
+		t.Errorf("relative age missing:\n%s", out)
+	}
+	if !strings.Contains(out, "▁▁✗") {
+		t.Errorf("sparkline missing:\n%s", out)
+	}
+	if got := strings.Count(out, "auth_error") + strings.Count(out, " ok "); got != 3 {
+		t.Errorf("table rows = %d, want 3:\n%s", got, out)
+	}
+}
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/providers"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// unusedProviderAge is how long a provider may go without a switch
+	// before the summary suggests removing it.
+	unusedProviderAge = 30 * 24 * time.Hour
+	// staleKeyAge is how old an API key may get before the summary suggests
+	// rotating it.
+	staleKeyAge = 90 * 24 * time.Hour
+)
+
+// keyChangeActions are the audit actions that store a new API key for a
+// provider.
+var keyChangeActions = []string{"setup_secret", "import_secret", "set_secret", "adopt_env_key", "rotate_key"}
+
+var summarySince string
+
+var summaryCmd = &cobra.Command{
+	Use:   "summary",
+	Short: "Summarize the past week of kairo use",
+	Long: `Summarize how kairo was used over the past week, or the period given with
+--since: the sessions per provider and the most used one, failed health
+checks, warnings and failed key revocations, and suggested cleanups such as
+providers that are no longer used and API keys that were not rotated in a
+long time.
+
+The summary is computed from the audit log, the recorded usage, and the
+health check history in the state directory. Nothing is sent anywhere.
+Sessions are counted from the audit log when auditing is on, and from the
+recorded usage otherwise.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runSummary(cmd, time.Now()); err != nil {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	summaryCmd.Flags().StringVar(&summarySince, "since", "7d", "Period to summarize, such as 30d")
+	rootCmd.AddCommand(summaryCmd)
+}
+
+// providerActivity is one provider's use within the summarized period.
+type providerActivity struct {
+	Provider       string
+	Sessions       int
+	Tokens         int64
+	HealthChecks   int
+	HealthFailures int
+}
+
+// activitySummary is what kairo summary reports.
+type activitySummary struct {
+	Since          time.Time
+	FromAudit      bool
+	Providers      []providerActivity
+	Warnings       int
+	RevokeFailures int
+	Cleanups       []string
+}
+
+// Sessions returns the number of sessions across providers.
+func (s activitySummary) Sessions() int {
+	n := 0
+	for _, p := range s.Providers {
+		n += p.Sessions
+	}
+
+	return n
+}
+
+// MostUsed returns the provider with the most sessions, if any had one.
+func (s activitySummary) MostUsed() (providerActivity, bool) {
+	var best providerActivity
+	for _, p := range s.Providers {
+		if p.Sessions > best.Sessions {
+			best = p
+		}
+	}
+
+	return best, best.Sessions > 0
+}
+
+func runSummary(cmd *cobra.Command, now time.Time) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return nil
+	}
+	period, err := audit.ParseRetention(summarySince)
+	if err != nil {
+		return err
+	}
+
+	cliCtx := CLIContextFromCmd(cmd)
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil {
+		if stderrors.Is(err, kairoerrors.ErrConfigNotFound) {
+			printNoProvidersMessage()
+
+			return nil
+		}
+
+		return err
+	}
+
+	state := stateDir(cliCtx, dir)
+	_, auditEnabled, _ := auditPolicy(cfg)
+	var entries []audit.Entry
+	if auditEnabled {
+		if entries, err = loadAuditEntries(cliCtx, dir); err != nil {
+			return err
+		}
+	}
+	records, err := usage.Load(state)
+	if err != nil {
+		return err
+	}
+	history := map[string][]health.Result{}
+	for name := range cfg.Providers {
+		if history[name], err = health.LoadHistory(state, name); err != nil {
+			return err
+		}
+	}
+
+	s := summarizeActivity(cfg, auditEnabled, entries, records, history, now.Add(-period), now)
+	writeSummary(cmd.OutOrStdout(), s, summarySince)
+
+	return nil
+}
+
+// summarizeActivity computes the summary of everything recorded after since.
+// Sessions are the switches in entries when auditing is on, and the usage
+// records otherwise; cleanups look further back than since.
+func summarizeActivity(cfg *config.Config, fromAudit bool, entries []audit.Entry, records []usage.Record,
+	history map[string][]health.Result, since, now time.Time,
+) activitySummary {
+	s := activitySummary{Since: since, FromAudit: fromAudit}
+	byProvider := map[string]*providerActivity{}
+	activity := func(name string) *providerActivity {
+		if byProvider[name] == nil {
+			byProvider[name] = &providerActivity{Provider: name}
+		}
+
+		return byProvider[name]
+	}
+
+	lastUsed := map[string]time.Time{}
+	lastKeyChange := map[string]time.Time{}
+	var oldest time.Time
+	for _, e := range entries {
+		if oldest.IsZero() || e.Timestamp.Before(oldest) {
+			oldest = e.Timestamp
+		}
+		switch {
+		case e.Event == audit.EventSwitch:
+			lastUsed[e.Provider] = e.Timestamp
+		case e.Event == audit.EventRotate && slices.Contains(keyChangeActions, e.Action):
+			lastKeyChange[e.Provider] = e.Timestamp
+		}
+		if !e.Timestamp.After(since) {
+			continue
+		}
+		switch {
+		case e.Event == audit.EventSwitch:
+			activity(e.Provider).Sessions++
+		case e.Event == audit.EventWarning:
+			s.Warnings++
+		case e.Event == audit.EventRotate && e.Action == "revoke_failed":
+			s.RevokeFailures++
+		}
+	}
+	for _, r := range records {
+		if r.End.After(lastUsed[r.Provider]) {
+			lastUsed[r.Provider] = r.End
+		}
+		if !r.End.After(since) {
+			continue
+		}
+		a := activity(r.Provider)
+		a.Tokens += r.Total()
+		if !fromAudit {
+			a.Sessions++
+		}
+	}
+	for name, results := range history {
+		for _, r := range results {
+			if !r.Time.After(since) {
+				continue
+			}
+			a := activity(name)
+			a.HealthChecks++
+			if !r.OK() {
+				a.HealthFailures++
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(byProvider)) {
+		s.Providers = append(s.Providers, *byProvider[name])
+	}
+	slices.SortStableFunc(s.Providers, func(a, b providerActivity) int { return b.Sessions - a.Sessions })
+
+	// A provider only counts as unused once the audit log reaches back far
+	// enough to have seen it used.
+	coversUnused := fromAudit && !oldest.IsZero() && now.Sub(oldest) >= unusedProviderAge
+	for _, name := range slices.Sorted(maps.Keys(cfg.Providers)) {
+		if coversUnused && name != cfg.DefaultProvider && now.Sub(lastUsed[name]) >= unusedProviderAge {
+			s.Cleanups = append(s.Cleanups, fmt.Sprintf(
+				"%s has not been used in %d days; remove it with 'kairo delete %s'",
+				name, int(unusedProviderAge.Hours()/24), name))
+		}
+		changed, ok := lastKeyChange[name]
+		if ok && providers.RequiresAPIKey(name) && now.Sub(changed) >= staleKeyAge {
+			s.Cleanups = append(s.Cleanups, fmt.Sprintf(
+				"the API key of %s was last changed %s; rotate it with 'kairo rotate --provider %s'",
+				name, ui.RelativeTime(changed, now), name))
+		}
+	}
+
+	return s
+}
+
+// writeSummary prints s, which covers the period named since.
+func writeSummary(out io.Writer, s activitySummary, since string) {
+	fmt.Fprintf(out, "kairo summary for the last %s, since %s (computed locally)\n\n",
+		since, ui.FormatTime(s.Since, utcFlag))
+
+	sessions := s.Sessions()
+	if len(s.Providers) == 0 {
+		fmt.Fprintln(out, "No sessions recorded.")
+	} else {
+		fmt.Fprintf(out, "%-14s  %8s  %8s  %s\n", "PROVIDER", "SESSIONS", "TOKENS", "HEALTH FAILURES")
+		for _, p := range s.Providers {
+			failures := "-"
+			if p.HealthChecks > 0 {
+				failures = fmt.Sprintf("%d of %d", p.HealthFailures, p.HealthChecks)
+			}
+			fmt.Fprintf(out, "%-14s  %8d  %8s  %s\n", p.Provider, p.Sessions, formatTokens(p.Tokens), failures)
+		}
+		fmt.Fprintln(out)
+		if p, ok := s.MostUsed(); ok {
+			fmt.Fprintf(out, "%d sessions in total; most used: %s (%d)\n", sessions, p.Provider, p.Sessions)
+		}
+	}
+	if !s.FromAudit {
+		fmt.Fprintln(out, "Auditing is off: sessions are counted from recorded usage only. "+
+			"Set 'audit.enabled: true' in config.yaml to count every switch.")
+	}
+
+	checks, failures := 0, 0
+	for _, p := range s.Providers {
+		checks += p.HealthChecks
+		failures += p.HealthFailures
+	}
+	fmt.Fprintln(out, "\nFailures:")
+	fmt.Fprintf(out, "  Health checks   : %d of %d failed\n", failures, checks)
+	if s.FromAudit {
+		fmt.Fprintf(out, "  Warnings        : %d\n", s.Warnings)
+		fmt.Fprintf(out, "  Key revocations : %d failed\n", s.RevokeFailures)
+	}
+
+	fmt.Fprintln(out, "\nSuggested cleanups:")
+	if len(s.Cleanups) == 0 {
+		fmt.Fprintln(out, "  none")
+	}
+	for _, c := range s.Cleanups {
+		fmt.Fprintln(out, "  - "+c)
+	}
+}
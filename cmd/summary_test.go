@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/usage"
+)
+
+func TestSummarizeActivity(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	since := now.Add(-7 * day)
+	cfg := &config.Config{
+		DefaultProvider: "zai",
+		Providers: map[string]config.Provider{
+			"zai":      {Name: "Z.AI"},
+			"minimax":  {Name: "MiniMax"},
+			"deepseek": {Name: "DeepSeek"},
+		},
+	}
+	entries := []audit.Entry{
+		{Timestamp: now.Add(-200 * day), Event: audit.EventRotate, Action: "setup_secret", Provider: "zai"},
+		{Timestamp: now.Add(-60 * day), Event: audit.EventSwitch, Provider: "minimax"},
+		{Timestamp: now.Add(-10 * day), Event: audit.EventRotate, Action: "set_secret", Provider: "deepseek"},
+		{Timestamp: now.Add(-3 * day), Event: audit.EventSwitch, Provider: "zai"},
+		{Timestamp: now.Add(-2 * day), Event: audit.EventSwitch, Provider: "zai"},
+		{Timestamp: now.Add(-2 * day), Event: audit.EventSwitch, Provider: "deepseek"},
+		{Timestamp: now.Add(-day), Event: audit.EventWarning, Action: "refuse_base_url", Provider: "deepseek"},
+		{Timestamp: now.Add(-day), Event: audit.EventRotate, Action: "revoke_failed", Provider: "deepseek"},
+	}
+	records := []usage.Record{
+		{End: now.Add(-2 * day), Provider: "zai", Tokens: usage.Tokens{Input: 12_000, Output: 800}},
+		{End: now.Add(-20 * day), Provider: "zai", Tokens: usage.Tokens{Input: 5_000_000}},
+	}
+	history := map[string][]health.Result{
+		"zai":     {{Time: now.Add(-day), Status: health.StatusOK}, {Time: now.Add(-day), Status: health.StatusError}},
+		"minimax": {{Time: now.Add(-30 * day), Status: health.StatusError}},
+	}
+
+	s := summarizeActivity(cfg, true, entries, records, history, since, now)
+
+	if got := s.Sessions(); got != 3 {
+		t.Errorf("Sessions() = %d, want 3", got)
+	}
+	if p, ok := s.MostUsed(); !ok || p.Provider != "zai" || p.Sessions != 2 {
+		t.Errorf("MostUsed() = %+v, %v, want zai with 2 sessions", p, ok)
+	}
+	if p := s.Providers[0]; p.Tokens != 12_800 || p.HealthChecks != 2 || p.HealthFailures != 1 {
+		t.Errorf("zai activity = %+v", p)
+	}
+	if s.Warnings != 1 || s.RevokeFailures != 1 {
+		t.Errorf("Warnings, RevokeFailures = %d, %d, want 1, 1", s.Warnings, s.RevokeFailures)
+	}
+	want := []string{
+		"minimax has not been used in 30 days; remove it with 'kairo delete minimax'",
+		"the API key of zai was last changed 200d ago; rotate it with 'kairo rotate --provider zai'",
+	}
+	if strings.Join(s.Cleanups, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Cleanups = %q, want %q", s.Cleanups, want)
+	}
+}
+
+func TestSummarizeActivity_WithoutAudit(t *testing.T) {
+	now := time.Now()
+	cfg := &config.Config{Providers: map[string]config.Provider{"zai": {}, "minimax": {}}}
+	records := []usage.Record{
+		{End: now.Add(-time.Hour), Provider: "zai", Tokens: usage.Tokens{Input: 100}},
+		{End: now.Add(-2 * time.Hour), Provider: "zai", Tokens: usage.Tokens{Input: 100}},
+	}
+
+	s := summarizeActivity(cfg, false, nil, records, nil, now.Add(-7*24*time.Hour), now)
+
+	if got := s.Sessions(); got != 2 {
+		t.Errorf("Sessions() = %d, want 2 from usage records", got)
+	}
+	if len(s.Cleanups) != 0 {
+		t.Errorf("Cleanups = %q, want none without an audit log to tell unused providers", s.Cleanups)
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	s := activitySummary{
+		Since:     time.Now().Add(-7 * 24 * time.Hour),
+		FromAudit: true,
+		Providers: []providerActivity{
+			{Provider: "zai", Sessions: 4, Tokens: 1_200_000, HealthChecks: 3, HealthFailures: 1},
+			{Provider: "minimax", Sessions: 1},
+		},
+		Warnings: 2,
+		Cleanups: []string{"minimax has not been used in 30 days"},
+	}
+	var out bytes.Buffer
+	writeSummary(&out, s, "7d")
+
+	got := out.String()
+	for _, want := range []string{
+		"computed locally",
+		"5 sessions in total; most used: zai (4)",
+		"1.2M",
+		"1 of 3",
+		"Health checks   : 1 of 3 failed",
+		"Warnings        : 2",
+		"  - minimax has not been used in 30 days",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("writeSummary() missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Auditing is off") {
+		t.Errorf("writeSummary() mentions auditing being off:\n%s", got)
+	}
+}
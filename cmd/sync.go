@@ -0,0 +1,452 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/configsync"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsperm"
+	"github.com/dkmnx/kairo/internal/journal"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncDir   string
+	syncForce bool
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Copy the configuration between machines through a git repository",
+	Long: `Export the configuration to a directory laid out for a private git
+repository, and import it on another machine.
+
+The directory holds config.yaml, written the same way for the same settings
+so that only real changes show up in diffs, and MANIFEST.sha256 with the hash
+of each file. The encrypted secrets.age is included too when other machines
+can decrypt it: with a KMS crypto backend, or with the age backend once
+'kairo recipients add' encrypts it to their keys as well. Encrypted to this
+machine's age.key alone it is left out, and API keys are stored on each
+machine with 'kairo secrets set'. Override files are not synced, so config.override.yaml
+is the place for settings that differ between machines.
+
+kairo remembers the hashes of the last export or import in .kairo.sync in
+the config directory. A file that changed on both sides since then is a
+conflict, which stops the sync unless --force lets the side synced from win.`,
+}
+
+var syncExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the configuration to a sync directory",
+	Long: `Write config.yaml, and secrets.age when it is encrypted with a KMS
+backend or to further age recipients, to the sync directory with a
+MANIFEST.sha256 listing their hashes. A file that changed in the directory
+since the last sync, and not here, is left as it is, to be applied with
+'kairo sync import'; one that changed on both sides stops the export unless
+--force overwrites it.`,
+	Example: `  kairo sync export --dir ~/src/dotfiles/kairo-sync
+  cd ~/src/dotfiles && git add kairo-sync && git commit -m "kairo: add minimax"`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runSyncExport(cmd); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+var syncImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Apply a sync directory to the configuration",
+	Long: `Check each file of the sync directory against MANIFEST.sha256, then
+replace the local config.yaml, and secrets.age when the directory holds one,
+with it. The files are swapped in together through the operation journal. A
+file that changed here since the last sync, and not in the directory, is
+kept; one that changed on both sides stops the import unless --force takes
+the directory's version.`,
+	Example: `  cd ~/src/dotfiles && git pull
+  kairo sync import --dir ~/src/dotfiles/kairo-sync`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := runSyncImport(cmd); err != nil && !stderrors.Is(err, kairoerrors.ErrUserCancelled) {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{syncExportCmd, syncImportCmd} {
+		c.Flags().StringVar(&syncDir, "dir", "kairo-sync", "Sync directory, usually inside a git repository")
+		c.Flags().BoolVar(&syncForce, "force", false, "Resolve conflicts in favor of the side being synced from")
+		syncCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(syncCmd)
+}
+
+func runSyncExport(cmd *cobra.Command) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err != nil {
+		if stderrors.Is(err, kairoerrors.ErrConfigNotFound) {
+			printNoProvidersMessage()
+
+			return errReported
+		}
+
+		return err
+	}
+	withSecrets, err := syncsSecrets(cliCtx, dir, cfg)
+	if err != nil {
+		return err
+	}
+	local, err := readLocalSyncFiles(dir, withSecrets)
+	if err != nil {
+		return err
+	}
+	remote, err := readSyncDir()
+	if err != nil {
+		return err
+	}
+	base, err := configsync.ReadManifest(syncStatePath(dir))
+	if err != nil {
+		return err
+	}
+
+	changes, err := syncChanges(local, remote, base, "rerun with --force to overwrite "+syncDir+
+		" with this configuration, run 'kairo sync import --force' to take its version, or merge by hand")
+	if err != nil {
+		return err
+	}
+	if err := fsperm.MkdirAll(syncDir); err != nil {
+		return kairoerrors.FileError("failed to create sync directory", syncDir, err)
+	}
+	for _, name := range applied(changes) {
+		path := filepath.Join(syncDir, name)
+		data, ok := local[name]
+		if ok {
+			err = fsperm.WriteFile(path, data)
+			remote[name] = data
+		} else {
+			err = os.Remove(path)
+			delete(remote, name)
+		}
+		if err != nil && !stderrors.Is(err, fs.ErrNotExist) {
+			return kairoerrors.FileError("failed to write sync directory", path, err)
+		}
+	}
+	if err := configsync.WriteManifest(filepath.Join(syncDir, configsync.ManifestName), manifestOf(remote)); err != nil {
+		return err
+	}
+	if err := configsync.WriteManifest(syncStatePath(dir), syncedState(local, base, changes)); err != nil {
+		return err
+	}
+
+	if !withSecrets {
+		ui.PrintInfo(constants.SecretsFileName + " is only encrypted to this machine's " + constants.KeyFileName +
+			" and is not exported; add the other machine with 'kairo recipients add', or store API keys there with 'kairo secrets set'")
+	}
+	reportSync(changes, "Exported", "%s changed in "+syncDir+" since the last sync and was left as it is; "+
+		"run 'kairo sync import' to apply it here")
+
+	return nil
+}
+
+func runSyncImport(cmd *cobra.Command) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return errReported
+	}
+	cliCtx := CLIContextFromCmd(cmd)
+	remote, err := configsync.Read(syncDir)
+	if err != nil {
+		return err
+	}
+	if err := checkSyncedFiles(remote); err != nil {
+		return err
+	}
+	_, withSecrets := remote[constants.SecretsFileName]
+	local, err := readLocalSyncFiles(dir, withSecrets)
+	if err != nil {
+		return err
+	}
+	// Only the files the directory holds are imported; one it lacks is
+	// never removed here.
+	maps.DeleteFunc(local, func(name string, _ []byte) bool {
+		_, ok := remote[name]
+
+		return !ok
+	})
+	base, err := configsync.ReadManifest(syncStatePath(dir))
+	if err != nil {
+		return err
+	}
+
+	changes, err := syncChanges(remote, local, base, "rerun with --force to take the version in "+syncDir+
+		", run 'kairo sync export --force' to keep this one, or merge by hand")
+	if err != nil {
+		return err
+	}
+	if names := applied(changes); len(names) > 0 {
+		if err := applySyncedFiles(cliCtx, dir, remote, names); err != nil {
+			return err
+		}
+	}
+	if err := configsync.WriteManifest(syncStatePath(dir), syncedState(remote, base, changes)); err != nil {
+		return err
+	}
+	reportSync(changes, "Imported", "%s changed here since the last sync and was kept; "+
+		"run 'kairo sync export' to send it")
+
+	return nil
+}
+
+// syncsSecrets reports whether the secrets file travels with the config: a
+// KMS backend decrypts it on any machine with access to the key, and an
+// age-encrypted one is shared once it is encrypted to recipients besides
+// this machine's age.key, such as those in recipients.txt. One encrypted to
+// age.key alone is only readable here.
+func syncsSecrets(cliCtx *CLIContext, dir string, cfg *config.Config) (bool, error) {
+	if cfg.Crypto != nil && cfg.Crypto.Backend != "" && cfg.Crypto.Backend != crypto.BackendAge {
+		return true, nil
+	}
+	keyPath := filepath.Join(dir, constants.KeyFileName)
+	if _, err := os.Stat(keyPath); stderrors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	own, err := publicRecipient(cliCtx.RootCtx(), dir, cfg)
+	if err != nil {
+		return false, err
+	}
+	r, err := age.ParseX25519Recipient(own)
+	if err != nil {
+		return false, kairoerrors.WrapError(kairoerrors.CryptoError, "invalid age key", err)
+	}
+	recipients, err := crypto.RecipientsFor(keyPath, r)
+	if err != nil {
+		return false, err
+	}
+
+	return len(recipients) > 1, nil
+}
+
+// readLocalSyncFiles returns the files of the config directory a sync
+// compares: config.yaml in its canonical form, and secrets.age as stored
+// when withSecrets is set. Files that do not exist are left out.
+func readLocalSyncFiles(dir string, withSecrets bool) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	configPath := filepath.Join(dir, config.BaseFileName)
+	data, err := os.ReadFile(configPath)
+	switch {
+	case err == nil:
+		if files[config.BaseFileName], err = config.CanonicalConfig(data, configPath); err != nil {
+			return nil, err
+		}
+	case !stderrors.Is(err, fs.ErrNotExist):
+		return nil, kairoerrors.FileError("failed to read configuration file", configPath, err)
+	}
+
+	if withSecrets {
+		secretsPath := filepath.Join(dir, constants.SecretsFileName)
+		data, err := os.ReadFile(secretsPath)
+		switch {
+		case err == nil:
+			files[constants.SecretsFileName] = data
+		case !stderrors.Is(err, fs.ErrNotExist):
+			return nil, kairoerrors.FileError("failed to read secrets file", secretsPath, err)
+		}
+	}
+
+	return files, nil
+}
+
+// checkSyncedFiles refuses a sync directory holding files kairo does not
+// sync, or a config.yaml this version of kairo cannot read.
+func checkSyncedFiles(files map[string][]byte) error {
+	for name := range files {
+		if name != config.BaseFileName && name != constants.SecretsFileName {
+			return kairoerrors.NewError(kairoerrors.ConfigError,
+				fmt.Sprintf("sync directory lists %s, which kairo does not sync", name)).
+				WithContext("path", filepath.Join(syncDir, configsync.ManifestName))
+		}
+	}
+	if data, ok := files[config.BaseFileName]; ok {
+		if _, err := config.CanonicalConfig(data, filepath.Join(syncDir, config.BaseFileName)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncChanges compares every file of source and dest against base. Files
+// that changed on both sides are a conflict, returned as an error with hint
+// unless --force lets source win.
+func syncChanges(source, dest map[string][]byte, base configsync.Manifest, hint string) (map[string]configsync.Change, error) {
+	changes := make(map[string]configsync.Change)
+	var conflicts []string
+	for _, name := range slices.Sorted(maps.Keys(mergeKeys(source, dest))) {
+		change := configsync.Compare(hashOf(source, name), hashOf(dest, name), base[name])
+		if change == configsync.Conflict {
+			conflicts = append(conflicts, name)
+		}
+		changes[name] = change
+	}
+	if len(conflicts) > 0 && !syncForce {
+		return nil, kairoerrors.NewError(kairoerrors.ConfigError,
+			fmt.Sprintf("%s changed both here and in %s since the last sync", strings.Join(conflicts, " and "), syncDir)).
+			WithContext("hint", hint)
+	}
+
+	return changes, nil
+}
+
+// applySyncedFiles stages the named files of remote next to the ones they
+// replace and swaps them in through the operation journal.
+func applySyncedFiles(cliCtx *CLIContext, dir string, remote map[string][]byte, names []string) error {
+	steps := make([]journal.Step, 0, len(names))
+	for _, name := range names {
+		target := filepath.Join(dir, name)
+		steps = append(steps, journal.Replace(target+".new", target))
+	}
+	j, err := journal.Begin(cliCtx.RootCtx(), dir, "sync import", steps)
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		if err := fsperm.WriteFile(step.From, remote[filepath.Base(step.To)]); err != nil {
+			j.Abort()
+
+			return kairoerrors.FileError("failed to stage synced file", step.From, err)
+		}
+	}
+	if err := j.Commit(); err != nil {
+		return err
+	}
+	cliCtx.InvalidateCache(dir)
+
+	recordAudit(cliCtx, dir, audit.Entry{
+		Event:   audit.EventConfig,
+		Action:  "sync_import",
+		Details: map[string]string{"dir": syncDir, "files": strings.Join(names, ",")},
+	})
+
+	return nil
+}
+
+// syncedState is the state after a sync from source: files applied, or
+// already equal, are now common to both sides, and kept files still share
+// only their base.
+func syncedState(source map[string][]byte, base configsync.Manifest, changes map[string]configsync.Change) configsync.Manifest {
+	state := maps.Clone(base)
+	for name, change := range changes {
+		if change == configsync.Keep {
+			continue
+		}
+		if data, ok := source[name]; ok {
+			state[name] = configsync.Hash(data)
+		} else {
+			delete(state, name)
+		}
+	}
+
+	return state
+}
+
+// applied returns the files a sync writes, in name order: those that only
+// changed on the source side, and conflicts overridden with --force.
+func applied(changes map[string]configsync.Change) []string {
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(changes)) {
+		if changes[name] == configsync.Apply || changes[name] == configsync.Conflict {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// reportSync prints what a sync did. kept formats the warning for a file
+// left alone because only the destination changed it.
+func reportSync(changes map[string]configsync.Change, verb, kept string) {
+	names := applied(changes)
+	if len(names) == 0 {
+		ui.PrintInfo(syncDir + " and the configuration are in sync")
+	} else {
+		ui.PrintSuccess(fmt.Sprintf("%s %s (%s)", verb, strings.Join(names, ", "), syncDir))
+	}
+	for _, name := range slices.Sorted(maps.Keys(changes)) {
+		if changes[name] == configsync.Keep {
+			ui.PrintWarn(fmt.Sprintf(kept, name))
+		}
+	}
+}
+
+// readSyncDir returns the files of the sync directory, which may not have
+// been exported to yet. With --force, a directory that fails its hash check
+// is overwritten as if it were empty.
+func readSyncDir() (map[string][]byte, error) {
+	if _, err := os.Stat(filepath.Join(syncDir, configsync.ManifestName)); err != nil {
+		return map[string][]byte{}, nil
+	}
+	files, err := configsync.Read(syncDir)
+	if err != nil {
+		if syncForce {
+			return map[string][]byte{}, nil
+		}
+
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func syncStatePath(dir string) string {
+	return filepath.Join(dir, constants.SyncStateFileName)
+}
+
+func manifestOf(files map[string][]byte) configsync.Manifest {
+	m := make(configsync.Manifest, len(files))
+	for name, data := range files {
+		m[name] = configsync.Hash(data)
+	}
+
+	return m
+}
+
+// hashOf returns the hash of files[name], or "" when there is no such file.
+func hashOf(files map[string][]byte, name string) string {
+	data, ok := files[name]
+	if !ok {
+		return ""
+	}
+
+	return configsync.Hash(data)
+}
+
+func mergeKeys(a, b map[string][]byte) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for name := range a {
+		keys[name] = struct{}{}
+	}
+	for name := range b {
+		keys[name] = struct{}{}
+	}
+
+	return keys
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/configsync"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+)
+
+func TestSyncExportImport(t *testing.T) {
+	defer func() { syncDir, syncForce = "kairo-sync", false }()
+	syncDir = filepath.Join(t.TempDir(), "kairo-sync")
+	laptop, desktop := t.TempDir(), t.TempDir()
+	writeExplainConfig(t, laptop, `# laptop
+providers:
+  zai:
+    model: glm-4.7
+    name: Z.AI
+    base_url: https://api.z.ai/api/anthropic
+default_provider: zai
+`)
+	laptopCmd, _ := explainTestCmd(t, laptop)
+	desktopCmd, _ := explainTestCmd(t, desktop)
+
+	if err := runSyncExport(laptopCmd); err != nil {
+		t.Fatalf("runSyncExport() error = %v", err)
+	}
+	exported, err := configsync.Read(syncDir)
+	if err != nil {
+		t.Fatalf("configsync.Read() error = %v", err)
+	}
+	if _, ok := exported[constants.SecretsFileName]; ok || strings.Contains(string(exported["config.yaml"]), "# laptop") {
+		t.Errorf("exported %v, want only config.yaml in canonical form", exported)
+	}
+
+	if err := runSyncImport(desktopCmd); err != nil {
+		t.Fatalf("runSyncImport() error = %v", err)
+	}
+	imported, err := os.ReadFile(filepath.Join(desktop, "config.yaml"))
+	if err != nil || string(imported) != string(exported["config.yaml"]) {
+		t.Fatalf("imported config.yaml = %q, %v; want the exported one", imported, err)
+	}
+	if err := runSyncExport(desktopCmd); err != nil {
+		t.Fatalf("runSyncExport() of an unchanged config error = %v", err)
+	}
+
+	// A change on each side since the last sync is a conflict both ways.
+	writeExplainConfig(t, laptop, strings.Replace(string(imported), "glm-4.7", "glm-5.1", 1))
+	writeExplainConfig(t, desktop, strings.Replace(string(imported), "glm-4.7", "glm-4.6", 1))
+	if err := runSyncExport(laptopCmd); err != nil {
+		t.Fatalf("runSyncExport() error = %v", err)
+	}
+	if err := runSyncImport(desktopCmd); err == nil || !strings.Contains(err.Error(), "changed both here and in") {
+		t.Errorf("runSyncImport() of a conflicting change error = %v", err)
+	}
+	if err := runSyncExport(desktopCmd); err == nil {
+		t.Error("runSyncExport() of a conflicting change error = nil")
+	}
+
+	syncForce = true
+	if err := runSyncImport(desktopCmd); err != nil {
+		t.Fatalf("runSyncImport() with --force error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(desktop, "config.yaml")); !strings.Contains(string(data), "glm-5.1") {
+		t.Errorf("config.yaml after a forced import = %q, want the synced model", data)
+	}
+}
+
+func TestSyncImport_KeepsLocalChange(t *testing.T) {
+	defer func() { syncDir, syncForce = "kairo-sync", false }()
+	syncDir = filepath.Join(t.TempDir(), "kairo-sync")
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, "default_provider: zai\nproviders:\n  zai:\n    name: Z.AI\n    base_url: https://api.z.ai/api/anthropic\n    model: glm-4.7\n")
+	cmd, _ := explainTestCmd(t, dir)
+	if err := runSyncExport(cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := "default_provider: zai\nproviders:\n  zai:\n    name: Z.AI\n    base_url: https://api.z.ai/api/anthropic\n    model: glm-5.1\n"
+	writeExplainConfig(t, dir, edited)
+	if err := runSyncImport(cmd); err != nil {
+		t.Fatalf("runSyncImport() error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "config.yaml")); string(data) != edited {
+		t.Errorf("config.yaml = %q, want the local change kept", data)
+	}
+}
+
+func TestSyncExport_KMSSecrets(t *testing.T) {
+	defer func() { syncDir = "kairo-sync" }()
+	syncDir = filepath.Join(t.TempDir(), "kairo-sync")
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, "providers: {}\ncrypto:\n  backend: awskms\n  key_id: alias/kairo\n")
+	if err := os.WriteFile(filepath.Join(dir, constants.SecretsFileName), []byte("envelope"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmd, _ := explainTestCmd(t, dir)
+
+	if err := runSyncExport(cmd); err != nil {
+		t.Fatalf("runSyncExport() error = %v", err)
+	}
+	files, err := configsync.Read(syncDir)
+	if err != nil || string(files[constants.SecretsFileName]) != "envelope" {
+		t.Errorf("exported %v, %v; want the KMS-encrypted secrets file as stored", files, err)
+	}
+}
+
+func TestSyncExport_MultiRecipientSecrets(t *testing.T) {
+	defer func() { syncDir = "kairo-sync" }()
+	syncDir = filepath.Join(t.TempDir(), "kairo-sync")
+	laptop, desktop := t.TempDir(), t.TempDir()
+	cfg := "providers:\n  zai:\n    name: Z.AI\n    base_url: https://api.z.ai/api/anthropic\n"
+	writeExplainConfig(t, laptop, cfg)
+	writeExplainConfig(t, desktop, cfg)
+	const key = "Zq8vN3pLr7Tx2KmW9cYd4HbF6gJs1QaE"
+	if err := storeProviderSecret(NewCLIContext(), laptop, "zai", key, "set_secret"); err != nil {
+		t.Fatal(err)
+	}
+	laptopCmd, _ := explainTestCmd(t, laptop)
+
+	// Encrypted to the laptop's age.key alone, the secrets stay behind.
+	if err := runSyncExport(laptopCmd); err != nil {
+		t.Fatalf("runSyncExport() error = %v", err)
+	}
+	if exported, _ := configsync.Read(syncDir); exported[constants.SecretsFileName] != nil {
+		t.Fatal("exported secrets.age encrypted to this machine's key alone")
+	}
+
+	if err := crypto.GenerateKey(context.Background(), filepath.Join(desktop, constants.KeyFileName)); err != nil {
+		t.Fatal(err)
+	}
+	desktopKey, err := publicRecipient(context.Background(), desktop, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(laptop, constants.RecipientsFileName), []byte(desktopKey+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := storeProviderSecret(NewCLIContext(), laptop, "zai", key, "set_secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runSyncExport(laptopCmd); err != nil {
+		t.Fatalf("runSyncExport() error = %v", err)
+	}
+	if exported, _ := configsync.Read(syncDir); exported[constants.SecretsFileName] == nil {
+		t.Fatal("did not export secrets.age encrypted to the desktop as well")
+	}
+	desktopCmd, _ := explainTestCmd(t, desktop)
+	if err := runSyncImport(desktopCmd); err != nil {
+		t.Fatalf("runSyncImport() error = %v", err)
+	}
+	result, err := LoadSecrets(CLIContextFromCmd(desktopCmd), desktop)
+	if err != nil || result.Secrets["ZAI_API_KEY"] != key {
+		t.Errorf("desktop secrets after import = %v, %v; want the laptop's key", result.Secrets, err)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"os/exec"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/dkmnx/kairo/internal/crypto"
+	"github.com/dkmnx/kairo/internal/health"
 	"github.com/dkmnx/kairo/internal/providers"
 	"github.com/dkmnx/kairo/internal/update"
 	"github.com/dkmnx/kairo/internal/wrapper"
@@ -38,17 +40,31 @@ func (m *mockProcess) ExitProcess(code int) { m.ExitProcessFn(code) }
 
 // mockWrapper is a test double for WrapperService.
 type mockWrapper struct {
-	CreateTempAuthDirFn     func() (string, error)
-	WriteTempTokenFileFn    func(authDir, token string) (string, error)
-	GenerateWrapperScriptFn func(cfg wrapper.ScriptConfig) (string, bool, error)
+	CreateTempAuthDirFn  func(parent string) (string, error)
+	WriteTempTokenFileFn func(authDir, token string) (string, error)
+	WriteSettingsFileFn  func(authDir, name, content string) (string, error)
+	PrepareLaunchFn      func(cfg wrapper.ScriptConfig) (wrapper.Launch, error)
+	CleanStaleAuthDirsFn func(tmpDir string, maxAge time.Duration, dryRun bool) ([]string, error)
 }
 
-func (m *mockWrapper) CreateTempAuthDir() (string, error) { return m.CreateTempAuthDirFn() }
+func (m *mockWrapper) CreateTempAuthDir(parent string) (string, error) {
+	return m.CreateTempAuthDirFn(parent)
+}
 func (m *mockWrapper) WriteTempTokenFile(authDir, token string) (string, error) {
 	return m.WriteTempTokenFileFn(authDir, token)
 }
-func (m *mockWrapper) GenerateWrapperScript(cfg wrapper.ScriptConfig) (string, bool, error) {
-	return m.GenerateWrapperScriptFn(cfg)
+func (m *mockWrapper) WriteSettingsFile(authDir, name, content string) (string, error) {
+	return m.WriteSettingsFileFn(authDir, name, content)
+}
+func (m *mockWrapper) PrepareLaunch(cfg wrapper.ScriptConfig) (wrapper.Launch, error) {
+	return m.PrepareLaunchFn(cfg)
+}
+func (m *mockWrapper) CleanStaleAuthDirs(tmpDir string, maxAge time.Duration, dryRun bool) ([]string, error) {
+	if m.CleanStaleAuthDirsFn == nil {
+		return nil, nil
+	}
+
+	return m.CleanStaleAuthDirsFn(tmpDir, maxAge, dryRun)
 }
 
 // mockUpdate is a test double for UpdateService.
@@ -99,6 +115,15 @@ func (m *mockCatalog) RefreshFromRemote(ctx context.Context) (int, error) {
 	return m.RefreshFromRemoteFn(ctx)
 }
 
+// mockHealth is a test double for HealthService.
+type mockHealth struct {
+	CheckFn func(ctx context.Context, baseURL, apiKey string, style health.AuthStyle) health.Result
+}
+
+func (m *mockHealth) Check(ctx context.Context, baseURL, apiKey string, style health.AuthStyle) health.Result {
+	return m.CheckFn(ctx, baseURL, apiKey, style)
+}
+
 // mockCrypto is a test double for crypto.Service with configurable function fields.
 type mockCrypto struct {
 	GenerateKeyFn         func(ctx context.Context, keyPath string) error
@@ -106,6 +131,7 @@ type mockCrypto struct {
 	DecryptSecretsFn      func(ctx context.Context, secretsPath, keyPath string) (string, error)
 	DecryptSecretsBytesFn func(ctx context.Context, secretsPath, keyPath string) ([]byte, error)
 	EnsureKeyExistsFn     func(ctx context.Context, configDir string) error
+	RotateKeyringFn       func(ctx context.Context, secretsPath, keyPath string) error
 }
 
 func (m *mockCrypto) GenerateKey(ctx context.Context, keyPath string) error {
@@ -148,6 +174,14 @@ func (m *mockCrypto) EnsureKeyExists(ctx context.Context, configDir string) erro
 	return nil
 }
 
+func (m *mockCrypto) RotateKeyring(ctx context.Context, secretsPath, keyPath string) error {
+	if m.RotateKeyringFn != nil {
+		return m.RotateKeyringFn(ctx, secretsPath, keyPath)
+	}
+
+	return nil
+}
+
 // feedStdin replaces os.Stdin with a pipe pre-filled with input and registered
 // for cleanup. The test reads from os.Stdin (e.g. via fmt.Scanln).
 func feedStdin(t *testing.T, input string) {
@@ -175,9 +209,10 @@ func testDeps(overrides ...func(mp *mockProcess, mw *mockWrapper, mu *mockUpdate
 		ExitProcessFn:        func(int) {},
 	}
 	mw := &mockWrapper{
-		CreateTempAuthDirFn:     func() (string, error) { return "", nil },
-		WriteTempTokenFileFn:    func(string, string) (string, error) { return "", nil },
-		GenerateWrapperScriptFn: func(wrapper.ScriptConfig) (string, bool, error) { return "", false, nil },
+		CreateTempAuthDirFn:  func(string) (string, error) { return "", nil },
+		WriteTempTokenFileFn: func(string, string) (string, error) { return "", nil },
+		WriteSettingsFileFn:  func(string, string, string) (string, error) { return "", nil },
+		PrepareLaunchFn:      func(wrapper.ScriptConfig) (wrapper.Launch, error) { return wrapper.Launch{}, nil },
 	}
 	mu := &mockUpdate{
 		FetchLatestReleaseFn:        func(context.Context) (*update.Release, error) { return nil, nil },
@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
 
-	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/constants"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
 	"github.com/dkmnx/kairo/internal/update"
 	"github.com/dkmnx/kairo/internal/version"
 	"github.com/spf13/cobra"
@@ -26,7 +28,8 @@ Security considerations:
 - The install script is downloaded from the specific release tag to ensure
   the script matches the version being installed.
 - SHA256 checksums are verified before execution to ensure script integrity.
-- You will be prompted for confirmation before installation.
+- You will be prompted for confirmation before installation, unless --yes
+  is given.
 - The script is executed with your current user permissions.
 
 For manual verification, you can download and inspect the install script and checksums from:
@@ -43,9 +46,9 @@ https://github.com/dkmnx/kairo/blob/<tag>/scripts/checksums.txt`,
 			return
 		}
 
-		latest, err := deps.Update.FetchLatestRelease(cmd.Context())
+		latest, err := deps.Update.FetchLatestRelease(commandContext(cmd))
 		if err != nil {
-			ui.PrintError(fmt.Sprintf("Error checking for updates: %v", err))
+			printError(kairoerrors.WrapError(kairoerrors.NetworkError, "error checking for updates", err))
 
 			return
 		}
@@ -60,13 +63,21 @@ https://github.com/dkmnx/kairo/blob/<tag>/scripts/checksums.txt`,
 
 		installScriptURL := update.InstallScriptURL(runtime.GOOS, latest.TagName)
 
-		confirmed, err := deps.Update.ConfirmUpdate("Do you want to proceed with installation?")
+		ok, err := confirmed("installing "+latest.TagName, func() (bool, error) {
+			return deps.Update.ConfirmUpdate("Do you want to proceed with installation?")
+		})
+		if errors.Is(err, errPromptsOff) {
+			printError(err)
+
+			return
+		}
 		if err != nil {
-			ui.PrintError(fmt.Sprintf("Error reading input: %v", err))
+			printError(kairoerrors.WrapError(kairoerrors.RuntimeError, "error reading input", err).
+				WithContext("hint", "run 'kairo update' from an interactive terminal"))
 
 			return
 		}
-		if !confirmed {
+		if !ok {
 			cmd.Println("Installation canceled.")
 
 			return
@@ -74,9 +85,9 @@ https://github.com/dkmnx/kairo/blob/<tag>/scripts/checksums.txt`,
 
 		cmd.Printf("\nDownloading install script from: %s\n", installScriptURL)
 
-		tempFile, err := deps.Update.DownloadToTempFile(cmd.Context(), installScriptURL)
+		tempFile, err := deps.Update.DownloadToTempFile(commandContext(cmd), installScriptURL)
 		if err != nil {
-			ui.PrintError(fmt.Sprintf("Error downloading install script: %v", err))
+			printError(kairoerrors.WrapError(kairoerrors.NetworkError, "error downloading install script", err))
 
 			return
 		}
@@ -87,26 +98,28 @@ https://github.com/dkmnx/kairo/blob/<tag>/scripts/checksums.txt`,
 
 		cmd.Printf("Downloading checksums from: %s\n", checksumsURL)
 
-		checksums, err := deps.Update.DownloadAndParseChecksums(cmd.Context(), checksumsURL)
+		checksums, err := deps.Update.DownloadAndParseChecksums(commandContext(cmd), checksumsURL)
 		if err != nil {
-			ui.PrintError(fmt.Sprintf("Error downloading checksums: %v", err))
+			printError(kairoerrors.WrapError(kairoerrors.NetworkError, "error downloading checksums", err))
 
 			return
 		}
 
 		expectedHash, ok := checksums[scriptName]
 		if !ok {
-			ui.PrintError(fmt.Sprintf("Checksum for %s not found in checksums file", scriptName))
+			printError(kairoerrors.NewError(kairoerrors.VerificationError,
+				fmt.Sprintf("checksum for %s not found in checksums file", scriptName)).
+				WithContext("hint", "try again later; the release may still be uploading"))
 
 			return
 		}
 
 		cmd.Printf("Verifying script integrity...\n")
 
-		if err := deps.Update.VerifyCosignBundle(cmd.Context(), latest.TagName); err != nil {
+		if err := deps.Update.VerifyCosignBundle(commandContext(cmd), latest.TagName); err != nil {
 			if os.Getenv("KAIRO_REQUIRE_COSIGN") == "1" {
-				ui.PrintError(fmt.Sprintf("Cosign verification required but failed: %v", err))
-				cmd.Println("Set KAIRO_REQUIRE_COSIGN=0 to allow update without cosign.")
+				printError(kairoerrors.WrapError(kairoerrors.VerificationError, "cosign verification required but failed", err).
+					WithContext("hint", "install cosign, or set KAIRO_REQUIRE_COSIGN=0 to update without it"))
 				os.Remove(tempFile)
 
 				return
@@ -115,8 +128,8 @@ https://github.com/dkmnx/kairo/blob/<tag>/scripts/checksums.txt`,
 		}
 
 		if err := deps.Update.VerifyChecksum(tempFile, expectedHash); err != nil {
-			ui.PrintError(fmt.Sprintf("Security verification failed: %v", err))
-			cmd.Println("Downloaded script has been removed. Please try again later or report this issue.")
+			printError(kairoerrors.WrapError(kairoerrors.VerificationError, "security verification failed", err).
+				WithContext("hint", "the downloaded script was removed; try again later or report this issue"))
 
 			return
 		}
@@ -124,7 +137,8 @@ https://github.com/dkmnx/kairo/blob/<tag>/scripts/checksums.txt`,
 		cmd.Printf("Running install script...\n\n")
 
 		if err := deps.Update.RunInstallScript(tempFile); err != nil {
-			ui.PrintError(fmt.Sprintf("Error during installation: %v", err))
+			printError(kairoerrors.WrapError(kairoerrors.RuntimeError, "error during installation", err).
+				WithContext("hint", "install manually: "+constants.GitHubBlobURL("main", "docs/guides/user-guide.md")+"#manual-installation"))
 
 			return
 		}
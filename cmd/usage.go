@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harness"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/usage"
+	"github.com/dkmnx/kairo/internal/warnlimit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	captureUsageFlag bool
+	usageSince       string
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage [provider]",
+	Short: "Show token usage recorded per provider",
+	Long: `Show the tokens recorded for harness sessions, totalled per provider, or
+the sessions of one provider.
+
+Usage is recorded when capture is on, with 'usage.capture: true' in
+config.yaml or --capture-usage for one run. kairo then receives Claude Code's
+OpenTelemetry metrics on a loopback port during the session and records the
+input, output, and cache tokens it reports. Other harnesses do not export
+token metrics and are not recorded.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runUsage(cmd, args, time.Now()); err != nil {
+			printError(err)
+		}
+	},
+}
+
+func init() {
+	usageCmd.Flags().StringVar(&usageSince, "since", "", "Only count sessions newer than this period, such as 30d")
+	rootCmd.AddCommand(usageCmd)
+}
+
+func runUsage(cmd *cobra.Command, args []string, now time.Time) error {
+	dir := requireConfigDir(cmd)
+	if dir == "" {
+		return nil
+	}
+
+	records, err := usage.Load(stateDir(CLIContextFromCmd(cmd), dir))
+	if err != nil {
+		return err
+	}
+	if usageSince != "" {
+		period, err := audit.ParseRetention(usageSince)
+		if err != nil {
+			return err
+		}
+		cutoff := now.Add(-period)
+		kept := records[:0]
+		for _, r := range records {
+			if r.End.After(cutoff) {
+				kept = append(kept, r)
+			}
+		}
+		records = kept
+	}
+
+	out := cmd.OutOrStdout()
+	if len(args) == 1 {
+		return printProviderUsage(out, args[0], records, now)
+	}
+	if len(records) == 0 {
+		ui.PrintInfo("No usage recorded. Set 'usage.capture: true' in config.yaml to record Claude Code sessions.")
+
+		return nil
+	}
+
+	fmt.Fprintf(out, "%-14s  %8s  %8s  %8s  %10s  %11s  %s\n",
+		"PROVIDER", "SESSIONS", "INPUT", "OUTPUT", "CACHE READ", "CACHE WRITE", "LAST USED")
+	var total usage.Tokens
+	for _, s := range usage.Summarize(records) {
+		fmt.Fprintf(out, "%-14s  %8d  %8s  %8s  %10s  %11s  %s\n", s.Provider, s.Sessions,
+			formatTokens(s.Input), formatTokens(s.Output), formatTokens(s.CacheRead), formatTokens(s.CacheCreation),
+			ui.RelativeTime(s.Last, now))
+		total = total.Add(s.Tokens)
+	}
+	fmt.Fprintln(out, "\n"+usageTotalLine(len(records), total))
+
+	return nil
+}
+
+// printProviderUsage lists the recorded sessions of provider, newest last.
+func printProviderUsage(out io.Writer, provider string, records []usage.Record, now time.Time) error {
+	var total usage.Tokens
+	n := 0
+	for _, r := range records {
+		if r.Provider != provider {
+			continue
+		}
+		if n == 0 {
+			fmt.Fprintf(out, "%-23s  %-9s  %8s  %-8s  %8s  %8s  %10s  %11s\n",
+				"START", "AGE", "DURATION", "HARNESS", "INPUT", "OUTPUT", "CACHE READ", "CACHE WRITE")
+		}
+		fmt.Fprintf(out, "%-23s  %-9s  %8s  %-8s  %8s  %8s  %10s  %11s\n",
+			ui.FormatTime(r.Start, utcFlag), ui.RelativeTime(r.Start, now), r.End.Sub(r.Start).Round(time.Second),
+			r.Harness, formatTokens(r.Input), formatTokens(r.Output), formatTokens(r.CacheRead),
+			formatTokens(r.CacheCreation))
+		total = total.Add(r.Tokens)
+		n++
+	}
+	if n == 0 {
+		fmt.Fprintf(out, "No usage recorded for %s.\n", provider)
+
+		return nil
+	}
+	fmt.Fprintln(out, "\n"+usageTotalLine(n, total))
+
+	return nil
+}
+
+// usageTotalLine summarizes n sessions that used total tokens.
+func usageTotalLine(n int, total usage.Tokens) string {
+	sessions := "sessions"
+	if n == 1 {
+		sessions = "session"
+	}
+
+	return fmt.Sprintf("%d %s, %s tokens", n, sessions, formatTokens(total.Total()))
+}
+
+// formatTokens abbreviates a token count, such as 1.2M or 340k.
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 10_000:
+		return fmt.Sprintf("%dk", n/1000)
+	default:
+		return fmt.Sprint(n)
+	}
+}
+
+// usageCaptureEnabled reports whether the session's token usage should be
+// captured, by --capture-usage or usage.capture in config.yaml.
+func usageCaptureEnabled(cliCtx *CLIContext) bool {
+	if captureUsageFlag {
+		return true
+	}
+	if cliCtx == nil {
+		return false
+	}
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), cliCtx.ConfigDir())
+
+	return err == nil && cfg.Usage != nil && cfg.Usage.Capture
+}
+
+// startUsageCapture starts an OTLP collector for the session when capture is
+// on and the harness is Claude Code, and returns the variables that point
+// the harness at it. finish stops the collector and records the tokens; it
+// must run after the harness exits, before any exit of kairo itself.
+func startUsageCapture(cfg ExecutionConfig) ([]string, func()) {
+	cliCtx := CLIContextFromCmd(cfg.Cmd)
+	if !usageCaptureEnabled(cliCtx) {
+		return nil, func() {}
+	}
+	if cfg.HarnessToUse != harness.Claude {
+		if captureUsageFlag {
+			ui.PrintWarn(fmt.Sprintf("%s does not export token metrics; usage is not captured", cfg.HarnessToUse))
+		}
+
+		return nil, func() {}
+	}
+	// A metrics exporter the user configured would be redirected to kairo,
+	// so leave it alone.
+	configured := func(name string) bool {
+		return os.Getenv(name) != "" || slices.ContainsFunc(cfg.ProviderEnv, func(kv string) bool {
+			return strings.HasPrefix(kv, name+"=")
+		})
+	}
+	if configured("OTEL_METRICS_EXPORTER") || configured("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT") {
+		printLimitedWarn(cliCtx, warnlimit.UsageExporter, "",
+			"OpenTelemetry metrics are already configured; usage is not captured")
+
+		return nil, func() {}
+	}
+
+	srv, err := usage.Listen()
+	if err != nil {
+		ui.PrintWarn("Could not capture usage: " + kairoerrors.Describe(err))
+
+		return nil, func() {}
+	}
+
+	start := time.Now()
+	finish := func() {
+		tokens := srv.Stop()
+		if tokens.Total() == 0 {
+			return
+		}
+		record := usage.Record{
+			Start:    start,
+			End:      time.Now(),
+			Provider: cfg.ProviderName,
+			Harness:  cfg.HarnessToUse,
+			Model:    cfg.Provider.Model,
+			Tokens:   tokens,
+		}
+		if err := usage.Append(stateDir(cliCtx, cliCtx.ConfigDir()), record); err != nil {
+			ui.PrintWarn("Could not record usage: " + kairoerrors.Describe(err))
+
+			return
+		}
+		ui.PrintInfo(fmt.Sprintf("Session used %s input and %s output tokens on %s",
+			formatTokens(tokens.Input+tokens.CacheRead+tokens.CacheCreation), formatTokens(tokens.Output),
+			cfg.ProviderName))
+	}
+
+	return usage.ClaudeTelemetryEnv(srv.Endpoint()), finish
+}
+
+// usageCaptureNote describes the capture for --explain, or is empty when
+// it is off for this launch.
+func usageCaptureNote(cliCtx *CLIContext, harnessToUse string) string {
+	if !usageCaptureEnabled(cliCtx) || harnessToUse != harness.Claude {
+		return ""
+	}
+
+	return "usage capture listens on a loopback port for Claude Code's OpenTelemetry metrics and appends the " +
+		"session's tokens to " + usage.FileName + " in the state directory"
+}
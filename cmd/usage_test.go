@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/usage"
+)
+
+func TestStartUsageCapture(t *testing.T) {
+	t.Setenv("OTEL_METRICS_EXPORTER", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "")
+	dir := t.TempDir()
+	writeExplainConfig(t, dir, "providers:\n  zai:\n    name: Z.AI\n    model: glm-4.7\nusage:\n  capture: true\n")
+	cmd, _ := explainTestCmd(t, dir)
+	cfg := ExecutionConfig{Cmd: cmd, HarnessToUse: "claude", ProviderName: "zai"}
+
+	env, finish := startUsageCapture(cfg)
+	endpoint := ""
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT="); ok {
+			endpoint = v
+		}
+	}
+	if endpoint == "" {
+		t.Fatalf("startUsageCapture() env = %v, want an OTLP endpoint", env)
+	}
+	body := `{"resourceMetrics":[{"scopeMetrics":[{"metrics":[{"name":"claude_code.token.usage","sum":{"dataPoints":[` +
+		`{"attributes":[{"key":"type","value":{"stringValue":"input"}}],"asInt":"1200"},` +
+		`{"attributes":[{"key":"type","value":{"stringValue":"output"}}],"asInt":"300"}]}}]}]}]}`
+	resp, err := http.Post(endpoint, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	finish()
+
+	records, err := usage.Load(stateDir(CLIContextFromCmd(cmd), dir))
+	if err != nil || len(records) != 1 {
+		t.Fatalf("usage.Load() = %v, %v", records, err)
+	}
+	if r := records[0]; r.Provider != "zai" || r.Input != 1200 || r.Output != 300 || r.Harness != "claude" {
+		t.Errorf("recorded %+v", r)
+	}
+
+	if env, _ := startUsageCapture(ExecutionConfig{Cmd: cmd, HarnessToUse: "qwen", ProviderName: "zai"}); env != nil {
+		t.Errorf("startUsageCapture() for qwen env = %v, want none", env)
+	}
+	cfg.ProviderEnv = []string{"OTEL_METRICS_EXPORTER=otlp"}
+	if env, _ := startUsageCapture(cfg); env != nil {
+		t.Errorf("startUsageCapture() with an exporter configured env = %v, want none", env)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	defer func() { usageSince = "" }()
+	dir := t.TempDir()
+	cmd, out := explainTestCmd(t, dir)
+	state := stateDir(CLIContextFromCmd(cmd), dir)
+	now := time.Now()
+	for _, r := range []usage.Record{
+		{Start: now.Add(-40 * 24 * time.Hour), End: now.Add(-40 * 24 * time.Hour), Provider: "zai", Harness: "claude",
+			Tokens: usage.Tokens{Input: 5_000_000}},
+		{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour), Provider: "zai", Harness: "claude",
+			Tokens: usage.Tokens{Input: 12_000, Output: 800}},
+		{Start: now.Add(-3 * time.Hour), End: now.Add(-2 * time.Hour), Provider: "minimax", Harness: "claude",
+			Tokens: usage.Tokens{Input: 2_000}},
+	} {
+		if err := usage.Append(state, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := runUsage(cmd, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "5.0M") || !strings.Contains(got, "3 sessions") {
+		t.Errorf("runUsage() output:\n%s", got)
+	}
+
+	out.Reset()
+	usageSince = "30d"
+	if err := runUsage(cmd, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); strings.Contains(got, "5.0M") || !strings.Contains(got, "12k") || !strings.Contains(got, "2 sessions") {
+		t.Errorf("runUsage() --since 30d output:\n%s", got)
+	}
+
+	var perProvider bytes.Buffer
+	cmd.SetOut(&perProvider)
+	if err := runUsage(cmd, []string{"minimax"}, now); err != nil {
+		t.Fatal(err)
+	}
+	if got := perProvider.String(); !strings.Contains(got, "1 session, 2000 tokens") {
+		t.Errorf("runUsage() for minimax output:\n%s", got)
+	}
+}
@@ -2,13 +2,20 @@ package cmd
 
 import (
 	stderrors "errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/dkmnx/kairo/internal/audit"
 	"github.com/dkmnx/kairo/internal/config"
 	"github.com/dkmnx/kairo/internal/constants"
 	"github.com/dkmnx/kairo/internal/envutil"
 	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/harnessver"
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/journal"
 	"github.com/dkmnx/kairo/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -16,14 +23,15 @@ import (
 func requireConfigDir(cmd *cobra.Command) string {
 	cliCtx := CLIContextFromCmd(cmd)
 	if cliCtx == nil {
-		ui.PrintError("CLI context not available")
+		printError(kairoerrors.NewError(kairoerrors.RuntimeError, "CLI context not available"))
 
 		return ""
 	}
 
 	dir := cliCtx.ConfigDir()
 	if dir == "" {
-		ui.PrintError("Config directory not found")
+		printError(kairoerrors.NewError(kairoerrors.ConfigError, "config directory not found").
+			WithContext("hint", "set KAIRO_CONFIG_DIR or pass --config <dir>"))
 	}
 
 	return dir
@@ -35,7 +43,7 @@ func requireConfigDirWritable(cmd *cobra.Command) string {
 		return ""
 	}
 	if err := os.MkdirAll(dir, constants.DirPermSecure); err != nil {
-		ui.PrintError("Error creating config directory: " + err.Error())
+		printError(kairoerrors.FileError("error creating config directory", dir, err))
 
 		return ""
 	}
@@ -43,15 +51,101 @@ func requireConfigDirWritable(cmd *cobra.Command) string {
 	return dir
 }
 
+// configuredTempDir returns the directory auth directories are created in
+// (see config.TempDir), falling back to the system temp directory with a
+// warning when temp_dir cannot be resolved.
+func configuredTempDir(cliCtx *CLIContext) string {
+	var cfg *config.Config
+	if cliCtx != nil && cliCtx.ConfigDir() != "" {
+		cfg, _ = cliCtx.ConfigCache().Get(cliCtx.RootCtx(), cliCtx.ConfigDir())
+	}
+
+	dir, err := config.TempDir(cfg)
+	if err != nil {
+		ui.PrintWarn(fmt.Sprintf("Could not resolve temp_dir, using %s: %v", os.TempDir(), err))
+
+		return os.TempDir()
+	}
+
+	return dir
+}
+
+// stateFiles are the entries kept in the state directory. Earlier versions
+// wrote them to the config directory; stateDir moves them on first use.
+var stateFiles = []string{audit.LogFileName, health.HistoryDirName, harnessver.StateFileName}
+
+// stateDir returns the directory for mutable state belonging to the config in
+// configDir (see config.StateDir), creating it and moving state files left in
+// configDir by earlier versions. It falls back to configDir with a warning
+// when the state directory cannot be prepared.
+func stateDir(cliCtx *CLIContext, configDir string) string {
+	var cfg *config.Config
+	if cliCtx != nil {
+		cfg, _ = cliCtx.ConfigCache().Get(cliCtx.RootCtx(), configDir)
+	}
+
+	dir, err := config.StateDir(configDir, cfg)
+	if err != nil {
+		ui.PrintWarn(fmt.Sprintf("Could not resolve state directory, using %s: %v", configDir, err))
+
+		return configDir
+	}
+	if filepath.Clean(dir) == filepath.Clean(configDir) {
+		return configDir
+	}
+	if err := os.MkdirAll(dir, constants.DirPermSecure); err != nil {
+		ui.PrintWarn(fmt.Sprintf("Could not create state directory %s, using %s: %v", dir, configDir, err))
+
+		return configDir
+	}
+
+	moved, err := config.MigrateState(configDir, dir, stateFiles)
+	for _, name := range moved {
+		ui.PrintInfo(fmt.Sprintf("Moved %s to %s", name, dir))
+	}
+	if err != nil {
+		ui.PrintWarn(fmt.Sprintf("Could not move state to %s: %v", dir, err))
+	}
+
+	return dir
+}
+
+// recoverInterruptedOperation settles an operation a killed kairo left half
+// done in configDir, such as a rotation between swapping in the new secrets
+// file and the new key, before any command reads the files it touched.
+func recoverInterruptedOperation(cliCtx *CLIContext, configDir string) {
+	if configDir == "" {
+		return
+	}
+	rec, err := journal.Recover(cliCtx.RootCtx(), configDir)
+	if err != nil {
+		ui.PrintWarn("Could not settle an interrupted operation: " + kairoerrors.Describe(err))
+
+		return
+	}
+	if rec == nil {
+		return
+	}
+
+	cliCtx.InvalidateCache(configDir)
+	started := ui.RelativeTime(rec.Started, time.Now())
+	switch rec.Outcome {
+	case journal.RolledForward:
+		ui.PrintWarn(fmt.Sprintf("Finished the '%s' that was interrupted %s", rec.Operation, started))
+	case journal.RolledBack:
+		ui.PrintWarn(fmt.Sprintf("Undid the '%s' that was interrupted %s, leaving its files as they were", rec.Operation, started))
+	}
+}
+
 func loadConfigOrExit(cmd *cobra.Command) (*config.Config, error) {
 	dir := requireConfigDir(cmd)
 	if dir == "" {
-		return nil, stderrors.New("config directory not found")
+		return nil, errReported
 	}
 
 	cliCtx := CLIContextFromCmd(cmd)
 	if cliCtx == nil {
-		return nil, stderrors.New("CLI context not available")
+		return nil, kairoerrors.NewError(kairoerrors.RuntimeError, "CLI context not available")
 	}
 
 	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
@@ -92,12 +186,6 @@ func printNoProvidersMessage() {
 	ui.PrintInfo("Run 'kairo setup' to get started")
 }
 
-func printSecretsRecoveryHelp() {
-	ui.PrintInfo("Restore 'age.key' and 'secrets.age' from backup,")
-	ui.PrintInfo("or remove both files and run 'kairo setup --reset-secrets' to re-enter API keys.")
-	ui.PrintInfo("Use --verbose for more details.")
-}
-
 func runningWithRaceDetector() bool {
 	return strings.Contains(os.Getenv("GOFLAGS"), "-race")
 }
@@ -1,13 +1,20 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/dkmnx/kairo/internal/config"
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/journal"
 	"github.com/spf13/cobra"
 )
 
@@ -127,8 +134,41 @@ func TestRunningWithRaceDetector(t *testing.T) {
 	}
 }
 
-func TestPrintSecretsRecoveryHelp(t *testing.T) {
-	printSecretsRecoveryHelp()
+func TestPrintCmdError(t *testing.T) {
+	cmd := &cobra.Command{}
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+
+	printCmdError(cmd, harnessNotFoundError("claude", nil))
+
+	out := buf.String()
+	if !strings.Contains(out, "'claude' command not found in PATH") {
+		t.Errorf("output missing error message:\n%s", out)
+	}
+	if strings.Count(out, "→") != 1 || !strings.Contains(out, "npm install -g @anthropic-ai/claude-code") {
+		t.Errorf("output should have exactly one install suggestion:\n%s", out)
+	}
+	if strings.Contains(out, "hint=") {
+		t.Errorf("hint repeated in the error message:\n%s", out)
+	}
+
+	buf.Reset()
+	printCmdError(cmd, fmt.Errorf("loading: %w", errReported))
+	if buf.Len() != 0 {
+		t.Errorf("printCmdError(errReported) printed %q, want nothing", buf.String())
+	}
+}
+
+func TestLoadConfigOrExit_NoConfigDir(t *testing.T) {
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDirResolver(func() (string, error) { return "", os.ErrNotExist })
+	cmd := &cobra.Command{}
+	cmd.SetContext(WithCLIContext(context.Background(), cliCtx))
+
+	_, err := loadConfigOrExit(cmd)
+	if !errors.Is(err, errReported) || errors.Is(err, kairoerrors.ErrUserCancelled) {
+		t.Errorf("loadConfigOrExit() error = %v, want errReported and not a user cancel", err)
+	}
 }
 
 func TestRequireConfigDirWritable(t *testing.T) {
@@ -288,3 +328,55 @@ func TestRequireConfigDirWritable_MkdirFails(t *testing.T) {
 		t.Errorf("requireConfigDirWritable() = %q, want empty string when mkdir fails", result)
 	}
 }
+
+func TestStateDirMovesExistingState(t *testing.T) {
+	configDir := t.TempDir()
+	state := filepath.Join(t.TempDir(), "state")
+	if err := config.SaveConfig(context.Background(), configDir, &config.Config{StateDir: state}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "audit.log"), []byte("{}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cliCtx := NewCLIContext()
+	if got := stateDir(cliCtx, configDir); got != state {
+		t.Fatalf("stateDir() = %q, want %q", got, state)
+	}
+	if _, err := os.Stat(filepath.Join(state, "audit.log")); err != nil {
+		t.Errorf("audit.log not moved to state dir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "audit.log")); !os.IsNotExist(err) {
+		t.Errorf("audit.log still in config dir: %v", err)
+	}
+
+	t.Setenv("KAIRO_STATE_DIR", "")
+	other := t.TempDir()
+	if got := stateDir(cliCtx, other); got != other {
+		t.Errorf("stateDir() for a custom config dir = %q, want %q", got, other)
+	}
+}
+
+func TestRecoverInterruptedOperation(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "age.key")
+	for path, content := range map[string]string{keyPath: "old key", keyPath + ".new": "new key"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entry := `{"operation": "rotate", "started": "2026-01-02T03:04:05Z", "prepared": true,
+		"steps": [{"from": ` + strconv.Quote(keyPath+".new") + `, "to": ` + strconv.Quote(keyPath) + `, "staged": true}]}`
+	if err := os.WriteFile(journal.Path(dir), []byte(entry), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	recoverInterruptedOperation(NewCLIContext(), dir)
+
+	if data, _ := os.ReadFile(keyPath); string(data) != "new key" {
+		t.Errorf("age.key = %q, want the rotation finished", data)
+	}
+	if _, err := os.Stat(journal.Path(dir)); !os.IsNotExist(err) {
+		t.Errorf("journal kept after recovery: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	kairoerrors "github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/update"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyChecksums string
+	verifySignature string
+	verifyPublicKey string
+	verifyName      string
+)
+
+var verifyReleaseCmd = &cobra.Command{
+	Use:   "verify-release <file> --checksums <file>",
+	Short: "Verify a downloaded release artifact offline",
+	Long: `Check a downloaded kairo binary or archive against a release checksums file,
+and the checksums file against its minisign signature, without sha256sum,
+minisign or network access:
+
+  kairo verify-release kairo_windows_amd64.zip \
+    --checksums checksums.txt \
+    --signature checksums.txt.minisig \
+    --public-key RWS...
+
+--public-key takes the base64 key or a .pub file. The file is looked up in the
+checksums file by its base name; use --name when it was saved under another
+name. Without --signature only the checksum is verified.
+
+Exits with status 1 if verification fails.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runVerifyRelease(args[0]); err != nil {
+			printError(err)
+			if cliCtx := CLIContextFromCmd(cmd); cliCtx != nil && cliCtx.Deps() != nil {
+				cliCtx.Deps().Process.ExitProcess(1)
+			}
+		}
+	},
+}
+
+func init() {
+	verifyReleaseCmd.Flags().StringVar(&verifyChecksums, "checksums", "", "sha256sum-style checksums file from the release")
+	verifyReleaseCmd.Flags().StringVar(&verifySignature, "signature", "", "minisign signature of the checksums file")
+	verifyReleaseCmd.Flags().StringVar(&verifyPublicKey, "public-key", "", "minisign public key (base64 or .pub file)")
+	verifyReleaseCmd.Flags().StringVar(&verifyName, "name", "", "Name of the file in the checksums file (default: its base name)")
+	_ = verifyReleaseCmd.MarkFlagRequired("checksums")
+	rootCmd.AddCommand(verifyReleaseCmd)
+}
+
+func runVerifyRelease(file string) error {
+	checksumsData, err := os.ReadFile(verifyChecksums)
+	if err != nil {
+		return kairoerrors.FileError("failed to read checksums file", verifyChecksums, err)
+	}
+
+	if verifySignature != "" {
+		if err := verifyChecksumsSignature(checksumsData, verifySignature, verifyPublicKey); err != nil {
+			return err
+		}
+		ui.PrintSuccess("Checksums file signature verified")
+	}
+
+	name := verifyName
+	if name == "" {
+		name = filepath.Base(file)
+	}
+	hash, err := update.VerifyReleaseFile(file, name, checksumsData)
+	if err != nil {
+		return err
+	}
+	ui.PrintSuccess(fmt.Sprintf("%s matches checksum %s", name, hash))
+
+	if verifySignature == "" {
+		ui.PrintWarn("No --signature given: the checksums file itself was not verified")
+	}
+
+	return nil
+}
+
+// verifyChecksumsSignature checks the minisign signature at sigPath over the
+// checksums file. publicKey is the base64 key or the path of a .pub file.
+func verifyChecksumsSignature(checksumsData []byte, sigPath, publicKey string) error {
+	if publicKey == "" {
+		return kairoerrors.NewError(kairoerrors.ValidationError, "--signature requires --public-key")
+	}
+	if data, err := os.ReadFile(publicKey); err == nil {
+		publicKey = string(data)
+	}
+	pk, err := update.ParseMinisignPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	sig, err := os.ReadFile(sigPath)
+	if err != nil {
+		return kairoerrors.FileError("failed to read signature file", sigPath, err)
+	}
+
+	return update.VerifyMinisign(pk, checksumsData, sig)
+}
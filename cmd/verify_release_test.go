@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunVerifyRelease(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "kairo_linux_amd64.tar.gz")
+	if err := os.WriteFile(file, []byte("archive"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("archive"))
+	checksums := filepath.Join(dir, "checksums.txt")
+	if err := os.WriteFile(checksums, []byte(hex.EncodeToString(sum[:])+"  kairo_linux_amd64.tar.gz\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { verifyChecksums, verifySignature, verifyPublicKey, verifyName = "", "", "", "" })
+	verifyChecksums = checksums
+
+	if err := runVerifyRelease(file); err != nil {
+		t.Errorf("runVerifyRelease() error = %v", err)
+	}
+
+	verifyName = "kairo_darwin_arm64.tar.gz"
+	if err := runVerifyRelease(file); err == nil {
+		t.Error("runVerifyRelease() expected error for a name missing from the checksums file")
+	}
+
+	verifyName = ""
+	verifySignature = filepath.Join(dir, "checksums.txt.minisig")
+	if err := runVerifyRelease(file); err == nil {
+		t.Error("runVerifyRelease() expected error for --signature without --public-key")
+	}
+}
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/dkmnx/kairo/internal/ui"
 	"github.com/dkmnx/kairo/internal/update"
 	"github.com/dkmnx/kairo/internal/version"
 	"github.com/spf13/cobra"
@@ -13,15 +15,19 @@ var versionCmd = &cobra.Command{
 	Short: "Show version information",
 	Long:  "Display the version number of Kairo",
 	Run: func(cmd *cobra.Command, args []string) {
-		cmd.Printf("Kairo version: %s\n", version.Version)
+		out := cmd.OutOrStdout()
+		ui.PrintValue(out, "Kairo version", version.Version)
+		if ui.Quiet() {
+			return
+		}
 		if version.Commit != "unknown" && version.Commit != "" {
-			cmd.Printf("Commit: %s\n", version.Commit)
+			fmt.Fprintf(out, "Commit: %s\n", version.Commit)
 		}
 		if version.Date != "" && version.Date != "unknown" {
 			if t, err := time.Parse(time.RFC3339, version.Date); err == nil {
-				cmd.Printf("Date: %s\n", t.Format("2006-01-02"))
+				fmt.Fprintf(out, "Date: %s\n", t.Format("2006-01-02"))
 			} else {
-				cmd.Printf("Date: %s\n", version.Date)
+				fmt.Fprintf(out, "Date: %s\n", version.Date)
 			}
 		}
 
@@ -34,7 +40,7 @@ var versionCmd = &cobra.Command{
 func checkForUpdates(cmd *cobra.Command) {
 	deps := CLIContextFromCmd(cmd).Deps()
 
-	latest, err := deps.Update.FetchLatestRelease(cmd.Context())
+	latest, err := deps.Update.FetchLatestRelease(commandContext(cmd))
 	if err != nil {
 		return
 	}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/audit"
+	"github.com/dkmnx/kairo/internal/ui"
+	"github.com/dkmnx/kairo/internal/warnlimit"
+)
+
+// warningLimiter returns the limiter for the warnings of the config in use,
+// or nil, which prints every warning, under --verbose or when there is no
+// config directory.
+func warningLimiter(cliCtx *CLIContext) *warnlimit.Limiter {
+	if cliCtx == nil || cliCtx.Verbose() || verboseFlag {
+		return nil
+	}
+	dir := cliCtx.ConfigDir()
+	if dir == "" {
+		return nil
+	}
+
+	interval := warnlimit.DefaultInterval
+	cfg, err := cliCtx.ConfigCache().Get(cliCtx.RootCtx(), dir)
+	if err == nil && cfg.Warnings != nil && cfg.Warnings.Interval != "" {
+		interval, err = warningInterval(cfg.Warnings.Interval)
+		if err != nil {
+			ui.PrintWarn(fmt.Sprintf("warnings.interval: %v; using %s", err, warnlimit.DefaultInterval))
+			interval = warnlimit.DefaultInterval
+		}
+	}
+
+	return warnlimit.New(stateDir(cliCtx, dir), interval)
+}
+
+// warningInterval parses warnings.interval: a period such as 24h or 7d, or
+// 0 to print every warning.
+func warningInterval(s string) (time.Duration, error) {
+	if strings.TrimSpace(s) == "0" {
+		return 0, nil
+	}
+
+	return audit.ParseRetention(s)
+}
+
+// printLimitedWarn prints msg as a warning unless the warning of class about
+// subject was printed within warnings.interval.
+func printLimitedWarn(cliCtx *CLIContext, class, subject, msg string) {
+	if warningLimiter(cliCtx).Allow(class, subject) {
+		ui.PrintWarn(msg)
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/warnlimit"
+)
+
+func TestWarningInterval(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"24h", 24 * time.Hour, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"0", 0, false},
+		{"soon", 0, true},
+		{"-1h", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := warningInterval(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("warningInterval(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestWarningLimiter(t *testing.T) {
+	t.Setenv("KAIRO_STATE_DIR", "")
+	configDir := t.TempDir()
+	state := filepath.Join(t.TempDir(), "state")
+	cfg := &config.Config{StateDir: state, Warnings: &config.WarningsConfig{Interval: "1h"}}
+	if err := config.SaveConfig(context.Background(), configDir, cfg); err != nil {
+		t.Fatal(err)
+	}
+	cliCtx := NewCLIContext()
+	cliCtx.SetConfigDir(configDir)
+
+	l := warningLimiter(cliCtx)
+	if !l.Allow(warnlimit.UsageExporter, "") {
+		t.Fatal("first warning was quieted")
+	}
+	if warningLimiter(cliCtx).Allow(warnlimit.UsageExporter, "") {
+		t.Error("repeated warning was shown within warnings.interval")
+	}
+	if _, err := os.Stat(filepath.Join(state, warnlimit.StateFileName)); err != nil {
+		t.Errorf("warning state not kept in the state directory: %v", err)
+	}
+
+	cliCtx.SetVerbose(true)
+	if !warningLimiter(cliCtx).Allow(warnlimit.UsageExporter, "") {
+		t.Error("--verbose quieted a repeated warning")
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"os"
+
+	"github.com/dkmnx/kairo/internal/wrapper"
+	"github.com/spf13/cobra"
+)
+
+var wrapperExecCmd = &cobra.Command{
+	Use:    wrapper.ExecCommand + " --token-file <file> --env <name> -- <cli> [args]",
+	Short:  "Hand the API key to a harness and exec it",
+	Hidden: true,
+	Long: `Read the API key from --token-file, delete the file, and replace this
+process with the harness, the key set in the variable named by --env. kairo
+runs this in place of a wrapper script on Unix; it is not meant to be run by
+hand.`,
+	DisableFlagParsing: true,
+	// The launch must not touch the config directory or apply policy again;
+	// the kairo that started it already did both.
+	PersistentPreRun: func(*cobra.Command, []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := wrapper.ParseExecArgs(args)
+		if err == nil {
+			err = wrapper.Exec(cmd.Context(), cfg, os.Environ())
+		}
+		if !stderrors.Is(err, wrapper.ErrProbeFailed) {
+			printError(err)
+		}
+		CLIContextFromCmd(cmd).Deps().Process.ExitProcess(1)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(wrapperExecCmd)
+}
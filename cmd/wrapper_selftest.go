@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/fsperm"
+	"github.com/dkmnx/kairo/internal/fsutil"
+	"github.com/dkmnx/kairo/internal/secrets"
+	"github.com/dkmnx/kairo/internal/wrapper"
+	"github.com/spf13/cobra"
+)
+
+// wrapperStubName is the hidden command a wrapper self-test execs in place
+// of a harness.
+const wrapperStubName = "__wrapper-test"
+
+const (
+	// wrapperCheckTimeout bounds each wrapper self-test run.
+	wrapperCheckTimeout = 10 * time.Second
+	// wrapperCheckExit is the status the stub is asked to exit with.
+	wrapperCheckExit = 3
+)
+
+var (
+	wrapperStubReport     string
+	wrapperStubEnv        []string
+	wrapperStubTokenFile  string
+	wrapperStubExit       int
+	wrapperStubWaitSignal bool
+)
+
+// wrapperStubResult is what the stub writes to --report. Variable values
+// are recorded by fingerprint only.
+type wrapperStubResult struct {
+	Args []string `json:"args"`
+	// Env maps each variable named by --env to the fingerprint of its value,
+	// or "" when it is unset.
+	Env              map[string]string `json:"env"`
+	TokenFileRemoved bool              `json:"token_file_removed"`
+	// Ready is set once the stub waits for a signal; Signal names the one it
+	// then received.
+	Ready  bool   `json:"ready,omitempty"`
+	Signal string `json:"signal,omitempty"`
+}
+
+var wrapperStubCmd = &cobra.Command{
+	Use:    wrapperStubName + " --report <file> [-- args]",
+	Short:  "Stand in for a harness to test the wrapper",
+	Hidden: true,
+	Long: `Record the arguments, environment, and token file state the wrapper
+handed over, then exit with --exit, or wait for a signal with
+--wait-signal and exit with 128 plus its number. Used by 'kairo doctor --deep'
+and the test suite; it is not meant to be run by hand.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		code := runWrapperStub(args)
+		CLIContextFromCmd(cmd).Deps().Process.ExitProcess(code)
+	},
+}
+
+func init() {
+	wrapperStubCmd.Flags().StringVar(&wrapperStubReport, "report", "", "File to write the report to")
+	wrapperStubCmd.Flags().StringArrayVar(&wrapperStubEnv, "env", nil, "Variable to record by fingerprint")
+	wrapperStubCmd.Flags().StringVar(&wrapperStubTokenFile, "token-file", "", "Token file the wrapper should have deleted")
+	wrapperStubCmd.Flags().IntVar(&wrapperStubExit, "exit", 0, "Exit status")
+	wrapperStubCmd.Flags().BoolVar(&wrapperStubWaitSignal, "wait-signal", false,
+		"Wait for SIGINT or SIGTERM instead of exiting")
+	rootCmd.AddCommand(wrapperStubCmd)
+}
+
+// runWrapperStub writes the report and returns the exit status.
+func runWrapperStub(args []string) int {
+	result := wrapperStubResult{Args: args, Env: make(map[string]string)}
+	if result.Args == nil {
+		result.Args = []string{}
+	}
+	for _, name := range wrapperStubEnv {
+		if v, ok := os.LookupEnv(name); ok {
+			result.Env[name] = secrets.Fingerprint(v)
+		} else {
+			result.Env[name] = ""
+		}
+	}
+	if wrapperStubTokenFile != "" {
+		_, err := os.Stat(wrapperStubTokenFile)
+		result.TokenFileRemoved = stderrors.Is(err, os.ErrNotExist)
+	}
+
+	if !wrapperStubWaitSignal {
+		if writeWrapperStubReport(result) != nil {
+			return 1
+		}
+
+		return wrapperStubExit
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	result.Ready = true
+	if writeWrapperStubReport(result) != nil {
+		return 1
+	}
+	sig := <-ch
+	result.Signal = sig.String()
+	if writeWrapperStubReport(result) != nil {
+		return 1
+	}
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+
+	return 1
+}
+
+func writeWrapperStubReport(result wrapperStubResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	return fsutil.WriteAtomic(wrapperStubReport, func(f *os.File) error {
+		_, err := f.Write(data)
+
+		return err
+	})
+}
+
+// checkDoctorWrapper runs the wrapper self-test against the running kairo
+// binary for 'kairo doctor --deep'.
+func checkDoctorWrapper(cliCtx *CLIContext) []doctorResult {
+	exe, err := os.Executable()
+	if err != nil {
+		return []doctorResult{{Name: "wrapper", Status: doctorFail,
+			Detail: fmt.Sprintf("cannot find the kairo binary: %v", err)}}
+	}
+
+	return checkWrapper(cliCtx.RootCtx(), cliCtx.Deps(), exe, configuredTempDir(cliCtx))
+}
+
+// checkWrapper runs the real wrapper around exe, which must run the stub
+// command, in an auth directory created in tempDir, and checks that it sets
+// the token in the auth variable, deletes the token file, passes arguments
+// unchanged, and hands over the exit status and, outside Windows, signals.
+func checkWrapper(ctx context.Context, deps *Deps, exe, tempDir string) []doctorResult {
+	result := checkWrapperRun(ctx, deps, exe, tempDir, false)
+	result.Name = "wrapper"
+	results := []doctorResult{result}
+	if runtime.GOOS != constants.WindowsGOOS {
+		result = checkWrapperRun(ctx, deps, exe, tempDir, true)
+		result.Name = "wrapper signals"
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// checkWrapperRun runs one self-test. With sendSignal set, the stub waits and
+// is sent SIGTERM through the wrapper's process.
+func checkWrapperRun(ctx context.Context, deps *Deps, exe, tempDir string, sendSignal bool) doctorResult {
+	fail := func(format string, a ...any) doctorResult {
+		return doctorResult{Status: doctorFail, Detail: fmt.Sprintf(format, a...)}
+	}
+
+	authDir, err := wrapper.CreateTempAuthDir(tempDir)
+	if err != nil {
+		return fail("%s", doctorErrorDetail(authDirError("cannot create auth directory", tempDir, err)))
+	}
+	defer os.RemoveAll(authDir)
+
+	token := "kairo-wrapper-test-" + filepath.Base(authDir)
+	tokenPath, err := wrapper.WriteTempTokenFile(authDir, token)
+	if err != nil {
+		return fail("cannot write token file: %v", err)
+	}
+	report := filepath.Join(authDir, "report.json")
+	// Arguments a shell would expand or split if the wrapper quoted them
+	// wrongly.
+	passed := []string{"two words", "$HOME", "it's", "*"}
+	stubArgs := []string{wrapperStubName, "--report", report, "--env", constants.EnvAuthToken,
+		"--token-file", tokenPath}
+	if sendSignal {
+		stubArgs = append(stubArgs, "--wait-signal")
+	} else {
+		stubArgs = append(stubArgs, "--exit", fmt.Sprint(wrapperCheckExit))
+	}
+	stubArgs = append(append(stubArgs, "--"), passed...)
+
+	launch, err := wrapper.PrepareLaunch(wrapper.ScriptConfig{
+		AuthDir:    authDir,
+		TokenPath:  tokenPath,
+		CliPath:    exe,
+		CliArgs:    stubArgs,
+		EnvVarName: constants.EnvAuthToken,
+	}, exe)
+	if err != nil {
+		return fail("cannot prepare the wrapper: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wrapperCheckTimeout)
+	defer cancel()
+	c := deps.Process.ExecCommandContext(ctx, launch.Name, launch.Args...)
+	if err := c.Start(); err != nil {
+		if fsperm.Blocked(authDir, err) {
+			return fail("%s", doctorErrorDetail(
+				blockedError("cannot run the wrapper", authDir, tempDirBlockedHint, err)))
+		}
+
+		return fail("cannot run the wrapper: %v", err)
+	}
+
+	want := wrapperCheckExit
+	if sendSignal {
+		if _, err := waitWrapperStubReady(ctx, report); err != nil {
+			_ = c.Process.Kill()
+			_ = c.Wait()
+
+			return fail("stub did not start: %v", err)
+		}
+		if err := c.Process.Signal(syscall.SIGTERM); err != nil {
+			return fail("cannot signal the wrapper: %v", err)
+		}
+		want = 128 + int(syscall.SIGTERM)
+	}
+	err = c.Wait()
+	var exitErr *exec.ExitError
+	code := 0
+	switch {
+	case ctx.Err() != nil:
+		return fail("wrapper did not finish within %s", wrapperCheckTimeout)
+	case stderrors.As(err, &exitErr):
+		code = exitErr.ExitCode()
+	case err != nil:
+		return fail("wrapper failed: %v", err)
+	}
+
+	result, err := readWrapperStubReport(report)
+	switch {
+	case err != nil:
+		return fail("harness stub left no report: %v", err)
+	case result.Env[constants.EnvAuthToken] != secrets.Fingerprint(token):
+		return fail("%s did not reach the harness", constants.EnvAuthToken)
+	case !result.TokenFileRemoved:
+		return fail("token file was not deleted before the harness started")
+	case !slices.Equal(result.Args, passed):
+		return fail("arguments changed on the way: got %q, want %q", result.Args, passed)
+	case sendSignal && result.Signal == "":
+		return fail("SIGTERM did not reach the harness")
+	case code != want:
+		return fail("exit status %d, want %d", code, want)
+	case sendSignal:
+		return doctorResult{Status: doctorOK, Detail: "SIGTERM reaches the harness and its status comes back"}
+	default:
+		return doctorResult{Status: doctorOK, Detail: fmt.Sprintf(
+			"token exported and its file deleted, arguments intact, exit status %d returned", code)}
+	}
+}
+
+// waitWrapperStubReady polls for the report of a stub waiting for a signal.
+func waitWrapperStubReady(ctx context.Context, path string) (wrapperStubResult, error) {
+	for {
+		if result, err := readWrapperStubReport(path); err == nil && result.Ready {
+			return result, nil
+		}
+		select {
+		case <-ctx.Done():
+			return wrapperStubResult{}, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func readWrapperStubReport(path string) (wrapperStubResult, error) {
+	var result wrapperStubResult
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+
+	return result, err
+}
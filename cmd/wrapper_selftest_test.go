@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/secrets"
+	"github.com/dkmnx/kairo/internal/wrapper"
+)
+
+// TestMain lets the test binary stand in for kairo in a wrapper self-test,
+// which runs it as the wrapper and then as the hidden stub command.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && (os.Args[1] == wrapperStubName || os.Args[1] == wrapper.ExecCommand) {
+		if err := Execute(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestCheckWrapper(t *testing.T) {
+	if runtime.GOOS == constants.WindowsGOOS {
+		t.Skip("the wrapper runs through PowerShell on Windows")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := checkWrapper(context.Background(), NewDeps(), exe, os.TempDir())
+	if len(results) != 2 {
+		t.Fatalf("checkWrapper() = %d results, want env and signal checks", len(results))
+	}
+	for _, r := range results {
+		if r.Status != doctorOK {
+			t.Errorf("checkWrapper() %s = %v: %s", r.Name, r.Status, r.Detail)
+		}
+	}
+}
+
+func TestCheckWrapperBrokenHarness(t *testing.T) {
+	if runtime.GOOS == constants.WindowsGOOS {
+		t.Skip("the wrapper runs through PowerShell on Windows")
+	}
+	// A harness that ignores its arguments and leaves no report.
+	exe := filepath.Join(t.TempDir(), "harness")
+	if err := os.WriteFile(exe, []byte("#!/bin/sh\nexit 3\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	result := checkWrapperRun(context.Background(), NewDeps(), exe, os.TempDir(), false)
+	if result.Status != doctorFail {
+		t.Errorf("checkWrapperRun() = %+v, want a failure", result)
+	}
+}
+
+func TestRunWrapperStub(t *testing.T) {
+	defer func() {
+		wrapperStubReport, wrapperStubEnv, wrapperStubTokenFile = "", nil, ""
+		wrapperStubExit, wrapperStubWaitSignal = 0, false
+	}()
+	dir := t.TempDir()
+	wrapperStubReport = filepath.Join(dir, "report.json")
+	wrapperStubEnv = []string{"KAIRO_STUB_SET", "KAIRO_STUB_UNSET"}
+	wrapperStubTokenFile = filepath.Join(dir, "token")
+	wrapperStubExit = 7
+	t.Setenv("KAIRO_STUB_SET", "value")
+
+	if code := runWrapperStub([]string{"a b"}); code != 7 {
+		t.Errorf("runWrapperStub() = %d, want 7", code)
+	}
+	result, err := readWrapperStubReport(wrapperStubReport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Env["KAIRO_STUB_SET"] != secrets.Fingerprint("value") || result.Env["KAIRO_STUB_UNSET"] != "" {
+		t.Errorf("report env = %v", result.Env)
+	}
+	if !result.TokenFileRemoved || !slices.Equal(result.Args, []string{"a b"}) {
+		t.Errorf("report = %+v", result)
+	}
+}
@@ -0,0 +1,395 @@
+// Package agent keeps an unlocked age identity in memory for a limited time
+// and answers decryption requests over a unix socket, so commands run from a
+// long-lived shell neither re-read the key file nor ask for its passphrase
+// again. It works like ssh-agent: the identity never leaves the agent, and
+// only the user owning the socket can reach it.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"filippo.io/age"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+const (
+	// SocketEnv overrides the agent socket path.
+	SocketEnv = "KAIRO_AGENT_SOCK"
+	// SocketFileName is the agent socket in the state directory.
+	SocketFileName = "agent.sock"
+	// DefaultTTL is how long the agent holds the key unless told otherwise.
+	DefaultTTL = time.Hour
+
+	// maxMessageBytes bounds a request or response, which carries at most
+	// one secrets file.
+	maxMessageBytes = 4 << 20
+	// connTimeout bounds a single request.
+	connTimeout = 10 * time.Second
+
+	// watchInterval is how often the agent compares its clocks.
+	watchInterval = 5 * time.Second
+	// sleepGap is how far the wall clock must run ahead of the monotonic
+	// clock, which stops while the system sleeps, between two checks for
+	// the agent to conclude the system slept. It is well above the steps
+	// of a clock synchronization.
+	sleepGap = 30 * time.Second
+	// unlockWindow is the period MaxUnlocksPerHour counts decryptions over.
+	unlockWindow = time.Hour
+)
+
+// Policy limits how long and how often the agent's key may be used.
+type Policy struct {
+	// TTL is how long the agent holds the key, counted on the wall clock
+	// so that time the system spends asleep counts too.
+	TTL time.Duration
+	// LockOnSleep wipes the key and stops the agent when the system wakes
+	// from sleep.
+	LockOnSleep bool
+	// MaxUnlocksPerHour bounds the decryptions the agent performs with the
+	// key in any hour; further requests are refused until older ones fall
+	// out of the hour. Zero is unlimited.
+	MaxUnlocksPerHour int
+}
+
+// Operations understood by the agent.
+const (
+	opDecrypt   = "decrypt"
+	opRecipient = "recipient"
+	opStatus    = "status"
+	opStop      = "stop"
+)
+
+type request struct {
+	Op      string `json:"op"`
+	KeyPath string `json:"key_path,omitempty"`
+	Data    []byte `json:"data,omitempty"`
+}
+
+type response struct {
+	Error     string  `json:"error,omitempty"`
+	Data      []byte  `json:"data,omitempty"`
+	Recipient string  `json:"recipient,omitempty"`
+	Status    *Status `json:"status,omitempty"`
+}
+
+// Status describes a running agent.
+type Status struct {
+	PID     int       `json:"pid"`
+	KeyPath string    `json:"key_path"`
+	Expires time.Time `json:"expires"`
+	// MemoryLocked is false when the key could not be locked into memory
+	// and may be written to swap.
+	MemoryLocked bool `json:"memory_locked"`
+	LockOnSleep  bool `json:"lock_on_sleep"`
+	// MaxUnlocksPerHour is the policy's limit, zero when there is none;
+	// UnlocksLastHour counts the decryptions of the past hour.
+	MaxUnlocksPerHour int `json:"max_unlocks_per_hour,omitempty"`
+	UnlocksLastHour   int `json:"unlocks_last_hour"`
+}
+
+// Agent holds one unlocked identity until its TTL elapses or it is stopped.
+type Agent struct {
+	keyPath   string
+	recipient string
+	expires   time.Time
+	policy    Policy
+
+	mu      sync.Mutex
+	secret  []byte // identity line, locked into memory where supported
+	locked  bool
+	unlocks []time.Time // decryptions within the last unlockWindow
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// New returns an agent holding the identity in key, the unlocked contents of
+// the key file at keyPath, under policy. The caller keeps ownership of key
+// and should clear it; the agent keeps its own copy.
+func New(key crypto.KeyMaterial, keyPath string, policy Policy) (*Agent, error) {
+	if policy.TTL <= 0 {
+		return nil, errors.NewError(errors.ValidationError, "agent TTL must be positive")
+	}
+	if policy.MaxUnlocksPerHour < 0 {
+		return nil, errors.NewError(errors.ValidationError, "agent unlock limit must not be negative")
+	}
+
+	identity, err := key.Identity()
+	if err != nil {
+		return nil, errors.WrapError(errors.CryptoError, "failed to load key into agent", err).
+			WithContext("path", keyPath)
+	}
+	line := identity.String()
+
+	a := &Agent{
+		keyPath:   filepath.Clean(keyPath),
+		recipient: identity.Recipient().String(),
+		expires:   time.Now().Add(policy.TTL),
+		policy:    policy,
+		secret:    make([]byte, len(line)),
+		stop:      make(chan struct{}),
+	}
+	a.locked = lockMemory(a.secret)
+	copy(a.secret, line)
+
+	return a, nil
+}
+
+// MemoryLocked reports whether the key is locked into memory.
+func (a *Agent) MemoryLocked() bool {
+	return a.locked
+}
+
+// Expires returns when the agent drops the key and stops serving.
+func (a *Agent) Expires() time.Time {
+	return a.expires
+}
+
+// Status returns the agent's status as 'kairo agent status' shows it.
+func (a *Agent) Status() Status {
+	return Status{
+		PID:               os.Getpid(),
+		KeyPath:           a.keyPath,
+		Expires:           a.expires,
+		MemoryLocked:      a.locked,
+		LockOnSleep:       a.policy.LockOnSleep,
+		MaxUnlocksPerHour: a.policy.MaxUnlocksPerHour,
+		UnlocksLastHour:   a.unlocksSince(time.Now()),
+	}
+}
+
+// Stop makes Serve return.
+func (a *Agent) Stop() {
+	a.stopOnce.Do(func() { close(a.stop) })
+}
+
+// Serve answers requests on l until ctx is done, the TTL elapses, the
+// system wakes from sleep under LockOnSleep, or Stop is called, then closes
+// l and wipes the key.
+func (a *Agent) Serve(ctx context.Context, l net.Listener) error {
+	ctx, cancel := context.WithDeadline(ctx, a.expires)
+	defer cancel()
+	defer a.wipe()
+
+	go a.watch(ctx)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-a.stop:
+		}
+		_ = l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-a.stop:
+				return nil
+			default:
+			}
+
+			return errors.WrapError(errors.NetworkError, "agent stopped accepting connections", err)
+		}
+		go a.handle(ctx, conn)
+	}
+}
+
+// watch stops the agent once the TTL has elapsed on the wall clock, which
+// the deadline of Serve, kept on the monotonic clock, misses for time spent
+// asleep, and on waking from sleep under LockOnSleep.
+func (a *Agent) watch(ctx context.Context) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.stop:
+			return
+		case now := <-ticker.C:
+			if a.mustLock(now.Round(0), now.Round(0).Sub(last.Round(0)), now.Sub(last)) {
+				a.Stop()
+
+				return
+			}
+			last = now
+		}
+	}
+}
+
+// mustLock reports whether the key must be dropped at wall-clock time now,
+// wallElapsed and monoElapsed after the previous check on the wall and
+// monotonic clocks.
+func (a *Agent) mustLock(now time.Time, wallElapsed, monoElapsed time.Duration) bool {
+	if !now.Before(a.expires.Round(0)) {
+		return true
+	}
+
+	return a.policy.LockOnSleep && wallElapsed-monoElapsed > sleepGap
+}
+
+func (a *Agent) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(connTimeout))
+
+	var req request
+	if err := json.NewDecoder(io.LimitReader(conn, maxMessageBytes)).Decode(&req); err != nil {
+		return
+	}
+
+	resp := a.respond(ctx, req)
+	_ = json.NewEncoder(conn).Encode(resp)
+	crypto.ClearMemory(resp.Data)
+	if req.Op == opStop {
+		a.Stop()
+	}
+}
+
+func (a *Agent) respond(ctx context.Context, req request) response {
+	switch req.Op {
+	case opStatus:
+		status := a.Status()
+
+		return response{Status: &status}
+	case opStop:
+		return response{}
+	case opRecipient, opDecrypt:
+	default:
+		return response{Error: "unknown agent operation " + req.Op}
+	}
+
+	if filepath.Clean(req.KeyPath) != a.keyPath {
+		return response{Error: "agent holds the key " + a.keyPath + ", not " + req.KeyPath}
+	}
+	if req.Op == opRecipient {
+		return response{Recipient: a.recipient}
+	}
+
+	if !a.takeUnlock(time.Now()) {
+		return response{Error: fmt.Sprintf("agent refused: its key was used %d times in the last hour, "+
+			"the limit of agent.max_unlocks_per_hour", a.policy.MaxUnlocksPerHour)}
+	}
+	identity, err := a.identity()
+	if err != nil {
+		return response{Error: err.Error()}
+	}
+	plaintext, err := crypto.DecryptWithIdentity(ctx, req.Data, identity)
+	if err != nil {
+		return response{Error: err.Error()}
+	}
+
+	return response{Data: plaintext}
+}
+
+// takeUnlock counts a decryption at now, reporting false without counting
+// it when the policy's hourly limit is reached.
+func (a *Agent) takeUnlock(now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.pruneUnlocks(now)
+	if a.policy.MaxUnlocksPerHour > 0 && len(a.unlocks) >= a.policy.MaxUnlocksPerHour {
+		return false
+	}
+	a.unlocks = append(a.unlocks, now)
+
+	return true
+}
+
+// unlocksSince returns the number of decryptions in the hour before now.
+func (a *Agent) unlocksSince(now time.Time) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.pruneUnlocks(now)
+
+	return len(a.unlocks)
+}
+
+// pruneUnlocks drops decryptions older than unlockWindow. The caller holds
+// a.mu.
+func (a *Agent) pruneUnlocks(now time.Time) {
+	i := 0
+	for i < len(a.unlocks) && now.Sub(a.unlocks[i]) >= unlockWindow {
+		i++
+	}
+	a.unlocks = a.unlocks[i:]
+}
+
+// identity parses the held identity. The parsed copy lives only for the
+// request that needs it.
+func (a *Agent) identity() (age.Identity, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.secret == nil {
+		return nil, errors.NewError(errors.CryptoError, "agent key has expired")
+	}
+
+	return age.ParseX25519Identity(string(a.secret))
+}
+
+func (a *Agent) wipe() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.secret == nil {
+		return
+	}
+	crypto.ClearMemory(a.secret)
+	if a.locked {
+		unlockMemory(a.secret)
+	}
+	a.secret = nil
+}
+
+// Listen creates the agent socket at path, readable only by the current
+// user. A socket left behind by an agent that is no longer running is
+// replaced; a live one is an error.
+func Listen(ctx context.Context, path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), constants.DirPermSecure); err != nil {
+		return nil, errors.FileError("failed to create agent socket directory", filepath.Dir(path), err)
+	}
+
+	if _, err := os.Lstat(path); err == nil {
+		if _, err := (Client{Socket: path}).Status(ctx); err == nil {
+			return nil, errors.NewError(errors.RuntimeError, "a kairo agent is already running").
+				WithContext("socket", path).
+				WithContext("hint", "stop it with 'kairo agent stop'")
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, errors.FileError("failed to remove stale agent socket", path, err)
+		}
+	} else if !stderrors.Is(err, os.ErrNotExist) {
+		return nil, errors.FileError("failed to check agent socket", path, err)
+	}
+
+	l, err := (&net.ListenConfig{}).Listen(ctx, "unix", path)
+	if err != nil {
+		return nil, errors.WrapError(errors.NetworkError, "failed to listen on agent socket", err).
+			WithContext("socket", path)
+	}
+	if err := os.Chmod(path, constants.FilePermSecure); err != nil {
+		_ = l.Close()
+
+		return nil, errors.FileError("failed to restrict agent socket permissions", path, err)
+	}
+
+	return l, nil
+}
@@ -0,0 +1,283 @@
+package agent
+
+import (
+	"context"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/crypto"
+)
+
+const testSecrets = "ZAI_API_KEY=sk-test\n"
+
+// startAgent generates a key and secrets file in a temp dir and serves an
+// agent for them. It returns the dir, the client, and a channel with the
+// result of Serve.
+func startAgent(t *testing.T, ttl time.Duration) (string, Client, <-chan error) {
+	t.Helper()
+
+	return startAgentWith(t, Policy{TTL: ttl})
+}
+
+// startAgentWith is startAgent with a full policy.
+func startAgentWith(t *testing.T, policy Policy) (string, Client, <-chan error) {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "age.key")
+	if err := crypto.GenerateKey(ctx, keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := crypto.EncryptSecrets(ctx, filepath.Join(dir, "secrets.age"), keyPath, testSecrets); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := crypto.ReadKeyFile(keyPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(key, keyPath, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	socket := filepath.Join(dir, SocketFileName)
+	l, err := Listen(ctx, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- a.Serve(ctx, l) }()
+	t.Cleanup(a.Stop)
+
+	return dir, Client{Socket: socket}, done
+}
+
+func TestAgentDecryptAndEncrypt(t *testing.T) {
+	ctx := context.Background()
+	dir, client, _ := startAgent(t, time.Minute)
+	keyPath := filepath.Join(dir, "age.key")
+	secretsPath := filepath.Join(dir, "secrets.age")
+
+	ciphertext, err := os.ReadFile(secretsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := client.Decrypt(ctx, keyPath, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != testSecrets {
+		t.Errorf("Decrypt() = %q, want %q", plaintext, testSecrets)
+	}
+
+	// Without the key file, only the agent can encrypt and decrypt.
+	if err := os.Remove(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	svc := Service{Client: client, Fallback: crypto.DefaultService{}}
+	if err := svc.EncryptSecrets(ctx, secretsPath, keyPath, "NEW=1\n"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := svc.DecryptSecrets(ctx, secretsPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "NEW=1\n" {
+		t.Errorf("DecryptSecrets() = %q", got)
+	}
+}
+
+func TestAgentRejectsOtherKeyPath(t *testing.T) {
+	_, client, _ := startAgent(t, time.Minute)
+
+	_, err := client.Decrypt(context.Background(), "/elsewhere/age.key", []byte("x"))
+	if err == nil || !strings.Contains(err.Error(), "agent holds the key") {
+		t.Errorf("Decrypt() error = %v, want key path mismatch", err)
+	}
+}
+
+func TestAgentStatusAndStop(t *testing.T) {
+	ctx := context.Background()
+	dir, client, done := startAgent(t, time.Minute)
+
+	status, err := client.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.KeyPath != filepath.Join(dir, "age.key") || status.PID != os.Getpid() {
+		t.Errorf("Status() = %+v", status)
+	}
+	if until := time.Until(status.Expires); until <= 0 || until > time.Minute {
+		t.Errorf("Expires in %v, want within the TTL", until)
+	}
+
+	if _, err := Listen(ctx, client.Socket); err == nil {
+		t.Error("Listen() on a live agent socket should fail")
+	}
+
+	if err := client.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve() = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() did not return after Stop")
+	}
+	if _, err := client.Status(ctx); !stderrors.Is(err, ErrNotRunning) {
+		t.Errorf("Status() after Stop error = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestAgentExpires(t *testing.T) {
+	_, client, done := startAgent(t, 50*time.Millisecond)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() did not return after the TTL")
+	}
+	if _, err := client.Status(context.Background()); !stderrors.Is(err, ErrNotRunning) {
+		t.Errorf("Status() after TTL error = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestAgentUnlockLimit(t *testing.T) {
+	ctx := context.Background()
+	dir, client, _ := startAgentWith(t, Policy{TTL: time.Minute, MaxUnlocksPerHour: 2})
+	keyPath := filepath.Join(dir, "age.key")
+	ciphertext, err := os.ReadFile(filepath.Join(dir, "secrets.age"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 2 {
+		if _, err := client.Decrypt(ctx, keyPath, ciphertext); err != nil {
+			t.Fatalf("Decrypt() %d = %v", i+1, err)
+		}
+	}
+	if _, err := client.Decrypt(ctx, keyPath, ciphertext); err == nil ||
+		!strings.Contains(err.Error(), "max_unlocks_per_hour") {
+		t.Errorf("Decrypt() over the limit error = %v, want the limit named", err)
+	}
+	// The recipient is public and does not count.
+	if _, err := client.Recipient(ctx, keyPath); err != nil {
+		t.Errorf("Recipient() over the limit = %v", err)
+	}
+
+	status, err := client.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.UnlocksLastHour != 2 || status.MaxUnlocksPerHour != 2 {
+		t.Errorf("Status() = %+v, want 2 of 2 unlocks", status)
+	}
+}
+
+func TestAgentUnlocksLeaveTheWindow(t *testing.T) {
+	a := &Agent{policy: Policy{TTL: time.Hour, MaxUnlocksPerHour: 1}}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if !a.takeUnlock(now) {
+		t.Fatal("first unlock refused")
+	}
+	if a.takeUnlock(now.Add(59 * time.Minute)) {
+		t.Error("second unlock within the hour allowed")
+	}
+	if !a.takeUnlock(now.Add(time.Hour)) {
+		t.Error("unlock refused after the first left the hour")
+	}
+}
+
+func TestAgentMustLock(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		policy      Policy
+		now         time.Time
+		wallElapsed time.Duration
+		monoElapsed time.Duration
+		want        bool
+	}{
+		{"running", Policy{}, now, watchInterval, watchInterval, false},
+		{"expired on the wall clock", Policy{}, now.Add(time.Hour), watchInterval, watchInterval, true},
+		{"woke without lock_on_sleep", Policy{}, now, 2 * time.Hour, watchInterval, false},
+		{"woke with lock_on_sleep", Policy{LockOnSleep: true}, now, 2 * time.Hour, watchInterval, true},
+		{"clock step with lock_on_sleep", Policy{LockOnSleep: true}, now, watchInterval + time.Second, watchInterval, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Agent{expires: now.Add(time.Hour), policy: tt.policy}
+			if got := a.mustLock(tt.now, tt.wallElapsed, tt.monoElapsed); got != tt.want {
+				t.Errorf("mustLock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRejectsBadPolicy(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "age.key")
+	if err := crypto.GenerateKey(context.Background(), keyPath); err != nil {
+		t.Fatal(err)
+	}
+	key, err := crypto.ReadKeyFile(keyPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, policy := range []Policy{{}, {TTL: time.Minute, MaxUnlocksPerHour: -1}} {
+		if _, err := New(key, keyPath, policy); err == nil {
+			t.Errorf("New(%+v) succeeded, want an error", policy)
+		}
+	}
+}
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), SocketFileName)
+	if err := os.WriteFile(socket, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Listen(context.Background(), socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %v, want a 0600 socket", info.Mode())
+	}
+}
+
+func TestServiceFallsBackWithoutAgent(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "age.key")
+	secretsPath := filepath.Join(dir, "secrets.age")
+	if err := crypto.GenerateKey(ctx, keyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := Service{Client: Client{Socket: filepath.Join(dir, SocketFileName)}, Fallback: crypto.DefaultService{}}
+	if err := svc.EncryptSecrets(ctx, secretsPath, keyPath, testSecrets); err != nil {
+		t.Fatal(err)
+	}
+	got, err := svc.DecryptSecrets(ctx, secretsPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != testSecrets {
+		t.Errorf("DecryptSecrets() = %q, want %q", got, testSecrets)
+	}
+}
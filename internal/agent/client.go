@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net"
+	"time"
+
+	"filippo.io/age"
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// ErrNotRunning is returned when no agent is listening on the socket.
+var ErrNotRunning = stderrors.New("kairo agent is not running")
+
+// dialTimeout bounds connecting to the socket, so a wedged agent does not
+// hold up commands that fall back to the key file.
+const dialTimeout = time.Second
+
+// Client talks to the agent listening on Socket.
+type Client struct {
+	Socket string
+}
+
+// Decrypt asks the agent to decrypt ciphertext with the key it holds for
+// keyPath.
+func (c Client) Decrypt(ctx context.Context, keyPath string, ciphertext []byte) ([]byte, error) {
+	resp, err := c.call(ctx, request{Op: opDecrypt, KeyPath: keyPath, Data: ciphertext})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// Recipient returns the recipient of the key the agent holds for keyPath.
+func (c Client) Recipient(ctx context.Context, keyPath string) (age.Recipient, error) {
+	resp, err := c.call(ctx, request{Op: opRecipient, KeyPath: keyPath})
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := age.ParseX25519Recipient(resp.Recipient)
+	if err != nil {
+		return nil, errors.WrapError(errors.CryptoError, "agent returned an invalid recipient", err)
+	}
+
+	return recipient, nil
+}
+
+// Status describes the running agent.
+func (c Client) Status(ctx context.Context) (Status, error) {
+	resp, err := c.call(ctx, request{Op: opStatus})
+	if err != nil {
+		return Status{}, err
+	}
+	if resp.Status == nil {
+		return Status{}, errors.NewError(errors.RuntimeError, "agent returned no status")
+	}
+
+	return *resp.Status, nil
+}
+
+// Stop asks the agent to wipe its key and exit.
+func (c Client) Stop(ctx context.Context) error {
+	_, err := c.call(ctx, request{Op: opStop})
+
+	return err
+}
+
+func (c Client) call(ctx context.Context, req request) (response, error) {
+	if c.Socket == "" {
+		return response{}, ErrNotRunning
+	}
+
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "unix", c.Socket)
+	if err != nil {
+		return response{}, errors.WrapError(errors.RuntimeError, "cannot reach kairo agent", ErrNotRunning).
+			WithContext("socket", c.Socket)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(connTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return response{}, errors.WrapError(errors.NetworkError, "failed to send agent request", err)
+	}
+
+	var resp response
+	if err := json.NewDecoder(io.LimitReader(conn, maxMessageBytes)).Decode(&resp); err != nil {
+		return response{}, errors.WrapError(errors.NetworkError, "failed to read agent response", err)
+	}
+	if resp.Error != "" {
+		return response{}, errors.NewError(errors.CryptoError, resp.Error)
+	}
+
+	return resp, nil
+}
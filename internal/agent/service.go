@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"os"
+
+	"github.com/dkmnx/kairo/internal/crypto"
+)
+
+// Service is a crypto.Service that decrypts and encrypts the secrets file
+// with the key held by the agent, and uses Fallback when no agent holds the
+// key for the file's key path. Key generation and rotation always use
+// Fallback.
+type Service struct {
+	Client   Client
+	Fallback crypto.Service
+}
+
+func (s Service) GenerateKey(ctx context.Context, keyPath string) error {
+	return s.Fallback.GenerateKey(ctx, keyPath)
+}
+
+func (s Service) EncryptSecrets(ctx context.Context, secretsPath, keyPath, secrets string) error {
+	recipient, err := s.Client.Recipient(ctx, keyPath)
+	if err != nil {
+		return s.Fallback.EncryptSecrets(ctx, secretsPath, keyPath, secrets)
+	}
+
+	recipients, err := crypto.RecipientsFor(keyPath, recipient)
+	if err != nil {
+		return err
+	}
+
+	return crypto.EncryptSecretsTo(ctx, secretsPath, secrets, recipients...)
+}
+
+func (s Service) DecryptSecrets(ctx context.Context, secretsPath, keyPath string) (string, error) {
+	plaintext, err := s.DecryptSecretsBytes(ctx, secretsPath, keyPath)
+	if err != nil {
+		return "", err
+	}
+	defer crypto.ClearMemory(plaintext)
+
+	return string(plaintext), nil
+}
+
+func (s Service) DecryptSecretsBytes(ctx context.Context, secretsPath, keyPath string) ([]byte, error) {
+	if ciphertext, err := os.ReadFile(secretsPath); err == nil {
+		if plaintext, err := s.Client.Decrypt(ctx, keyPath, ciphertext); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	return s.Fallback.DecryptSecretsBytes(ctx, secretsPath, keyPath)
+}
+
+func (s Service) EnsureKeyExists(ctx context.Context, configDir string) error {
+	return s.Fallback.EnsureKeyExists(ctx, configDir)
+}
+
+func (s Service) RotateKeyring(ctx context.Context, secretsPath, keyPath string) error {
+	return s.Fallback.RotateKeyring(ctx, secretsPath, keyPath)
+}
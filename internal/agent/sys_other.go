@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd && !openbsd && !netbsd && !dragonfly
+
+package agent
+
+import "os/exec"
+
+func lockMemory([]byte) bool {
+	return false
+}
+
+func unlockMemory([]byte) {}
+
+// Detach is a no-op where new sessions are not supported.
+func Detach(*exec.Cmd) {}
@@ -0,0 +1,26 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd || dragonfly
+
+package agent
+
+import (
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockMemory keeps b out of swap, reporting whether it succeeded. It fails
+// without privileges when RLIMIT_MEMLOCK is exhausted.
+func lockMemory(b []byte) bool {
+	return unix.Mlock(b) == nil
+}
+
+func unlockMemory(b []byte) {
+	_ = unix.Munlock(b)
+}
+
+// Detach starts c in a new session, so the agent outlives the terminal that
+// started it.
+func Detach(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,221 @@
+package audit
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// HintKind names the heuristic that produced a Hint.
+type HintKind string
+
+// Hint kinds reported by Analyze.
+const (
+	HintNewHost      HintKind = "new host"
+	HintNewUser      HintKind = "new user"
+	HintFailureBurst HintKind = "failures"
+	HintUnusualHour  HintKind = "odd hour"
+	HintEscrow       HintKind = "escrow"
+)
+
+// Thresholds of the Analyze heuristics.
+const (
+	// identityBaseline is how many entries must name a host or user before
+	// one seen for the first time is flagged; the first ones set the
+	// baseline.
+	identityBaseline = 10
+	// burstSize failures within burstWindow make a burst.
+	burstSize   = 3
+	burstWindow = 10 * time.Minute
+	// hourBaseline is how many entries the log must hold before hours are
+	// judged, and an hour is unusual when the hours around it hold less than
+	// unusualHourShare of them.
+	hourBaseline     = 50
+	unusualHourShare = 0.05
+)
+
+// Hint is an informational finding of Analyze. It is not proof of misuse:
+// a new laptop or a late night also produce hints.
+type Hint struct {
+	Kind    HintKind
+	Time    time.Time
+	Message string
+}
+
+// Analyze looks for activity in entries, oldest first, that stands out from
+// the rest of the log: the first use from a host or user name after a
+// baseline of others, bursts of warnings and failed revocations, entries at
+// hours of the day, in loc, the log is rarely active, and every decryption
+// with the escrow key. Hosts and users are
+// only recorded at LevelVerbose. Hints are returned in time order.
+func Analyze(entries []Entry, loc *time.Location) []Hint {
+	entries = slices.DeleteFunc(slices.Clone(entries), func(e Entry) bool { return e.Event == EventPrune })
+
+	var hints []Hint
+	hints = append(hints, newIdentities(entries, HintNewHost, func(e Entry) string { return e.Hostname })...)
+	hints = append(hints, newIdentities(entries, HintNewUser, func(e Entry) string { return e.User })...)
+	hints = append(hints, failureBursts(entries)...)
+	hints = append(hints, unusualHours(entries, loc)...)
+	hints = append(hints, escrowOpens(entries)...)
+	slices.SortStableFunc(hints, func(a, b Hint) int { return a.Time.Compare(b.Time) })
+
+	return hints
+}
+
+// newIdentities flags the first entry naming each value of field, such as
+// the host name, once identityBaseline entries have named others.
+func newIdentities(entries []Entry, kind HintKind, field func(Entry) string) []Hint {
+	var hints []Hint
+	seen := make(map[string]bool)
+	named := 0
+	for _, e := range entries {
+		value := field(e)
+		if value == "" {
+			continue
+		}
+		if !seen[value] && named >= identityBaseline {
+			hints = append(hints, Hint{
+				Kind: kind,
+				Time: e.Timestamp,
+				Message: fmt.Sprintf("first %s from %s %q, after %d entries from %s",
+					describe(e), strings.TrimPrefix(string(kind), "new "), value, named, quoted(seen)),
+			})
+		}
+		seen[value] = true
+		named++
+	}
+
+	return hints
+}
+
+// escrowOpens flags every decryption with the escrow key, which is only
+// meant for recovering the keys of someone who has left.
+func escrowOpens(entries []Entry) []Hint {
+	var hints []Hint
+	for _, e := range entries {
+		if e.Event != EventEscrow {
+			continue
+		}
+		msg := "secrets decrypted with the escrow key"
+		if e.User != "" {
+			msg += " by " + e.User
+		}
+		if reason := e.Details["reason"]; reason != "" {
+			msg += fmt.Sprintf(", reason %q", reason)
+		}
+		hints = append(hints, Hint{Kind: HintEscrow, Time: e.Timestamp, Message: msg})
+	}
+
+	return hints
+}
+
+// failureBursts flags runs of at least burstSize failures, each within
+// burstWindow of the first of the run.
+func failureBursts(entries []Entry) []Hint {
+	var failures []Entry
+	for _, e := range entries {
+		if e.Event == EventWarning || e.Action == "revoke_failed" {
+			failures = append(failures, e)
+		}
+	}
+
+	var hints []Hint
+	for i := 0; i < len(failures); {
+		j := i + 1
+		for j < len(failures) && failures[j].Timestamp.Sub(failures[i].Timestamp) <= burstWindow {
+			j++
+		}
+		if j-i >= burstSize {
+			counts := make(map[string]int)
+			for _, e := range failures[i:j] {
+				counts[cmpOr(e.Action, string(e.Event))]++
+			}
+			parts := make([]string, 0, len(counts))
+			for _, action := range slices.Sorted(maps.Keys(counts)) {
+				parts = append(parts, fmt.Sprintf("%s x%d", action, counts[action]))
+			}
+			hints = append(hints, Hint{
+				Kind: HintFailureBurst,
+				Time: failures[i].Timestamp,
+				Message: fmt.Sprintf("%d failures in %s: %s", j-i,
+					failures[j-1].Timestamp.Sub(failures[i].Timestamp).Round(time.Second), strings.Join(parts, ", ")),
+			})
+			i = j
+
+			continue
+		}
+		i++
+	}
+
+	return hints
+}
+
+// unusualHours flags entries at hours of the day whose neighborhood, the
+// hour before to the hour after, holds under unusualHourShare of the log.
+// Entries in the same unusual hour of the same day make one hint.
+func unusualHours(entries []Entry, loc *time.Location) []Hint {
+	if len(entries) < hourBaseline {
+		return nil
+	}
+	var perHour [24]int
+	for _, e := range entries {
+		perHour[e.Timestamp.In(loc).Hour()]++
+	}
+	share := func(h int) float64 {
+		n := perHour[(h+23)%24] + perHour[h] + perHour[(h+1)%24]
+
+		return float64(n) / float64(len(entries))
+	}
+
+	var hints []Hint
+	var lastSlot time.Time
+	for _, e := range entries {
+		t := e.Timestamp.In(loc)
+		if share(t.Hour()) >= unusualHourShare {
+			continue
+		}
+		slot := t.Truncate(time.Hour)
+		if slot.Equal(lastSlot) {
+			continue
+		}
+		lastSlot = slot
+		hints = append(hints, Hint{
+			Kind: HintUnusualHour,
+			Time: e.Timestamp,
+			Message: fmt.Sprintf("%s at %02d:%02d; %.1f%% of the log falls between %02d:00 and %02d:59",
+				describe(e), t.Hour(), t.Minute(), 100*share(t.Hour()), (t.Hour()+23)%24, (t.Hour()+1)%24),
+		})
+	}
+
+	return hints
+}
+
+// describe names the activity of e, such as "switch for zai".
+func describe(e Entry) string {
+	what := cmpOr(e.Action, string(e.Event))
+	if e.Provider != "" {
+		return what + " for " + e.Provider
+	}
+
+	return what
+}
+
+// quoted lists the keys of seen, sorted and quoted.
+func quoted(seen map[string]bool) string {
+	names := slices.Sorted(maps.Keys(seen))
+	for i, name := range names {
+		names[i] = fmt.Sprintf("%q", name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func cmpOr(a, b string) string {
+	if a != "" {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// workdays returns n switches from host "desk" by user "me", one a day at
+// 10:00 UTC starting from start.
+func workdays(start time.Time, n int) []Entry {
+	entries := make([]Entry, 0, n)
+	for i := range n {
+		entries = append(entries, Entry{
+			Timestamp: start.AddDate(0, 0, i),
+			Event:     EventSwitch,
+			Provider:  "zai",
+			Hostname:  "desk",
+			User:      "me",
+		})
+	}
+
+	return entries
+}
+
+func TestAnalyze(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("quiet log", func(t *testing.T) {
+		if hints := Analyze(workdays(start, 60), time.UTC); len(hints) != 0 {
+			t.Errorf("Analyze() = %v, want no hints", hints)
+		}
+	})
+
+	t.Run("new host and user", func(t *testing.T) {
+		entries := workdays(start, 20)
+		stranger := Entry{
+			Timestamp: start.AddDate(0, 0, 20),
+			Event:     EventSwitch,
+			Provider:  "kimi",
+			Hostname:  "unknown-box",
+			User:      "root",
+		}
+		entries = append(entries, stranger, stranger)
+
+		hints := Analyze(entries, time.UTC)
+		if len(hints) != 2 {
+			t.Fatalf("Analyze() = %v, want one new host and one new user", hints)
+		}
+		if hints[0].Kind != HintNewHost || !strings.Contains(hints[0].Message, `host "unknown-box"`) ||
+			!strings.Contains(hints[0].Message, "switch for kimi") {
+			t.Errorf("hint = %+v", hints[0])
+		}
+		if hints[1].Kind != HintNewUser || !strings.Contains(hints[1].Message, `"me"`) {
+			t.Errorf("hint = %+v", hints[1])
+		}
+	})
+
+	t.Run("no baseline for new hosts", func(t *testing.T) {
+		entries := workdays(start, 3)
+		entries[2].Hostname = "laptop"
+		if hints := Analyze(entries, time.UTC); len(hints) != 0 {
+			t.Errorf("Analyze() = %v, want no hints before a baseline", hints)
+		}
+	})
+
+	t.Run("failure burst", func(t *testing.T) {
+		at := start.Add(time.Hour)
+		entries := []Entry{
+			{Timestamp: at, Event: EventWarning, Action: "refuse_base_url"},
+			{Timestamp: at.Add(2 * time.Minute), Event: EventWarning, Action: "refuse_base_url"},
+			{Timestamp: at.Add(4 * time.Minute), Event: EventRotate, Action: "revoke_failed"},
+			{Timestamp: at.Add(5 * time.Minute), Event: EventPrune, Action: "checkpoint"},
+			// Too late to join the burst, and alone.
+			{Timestamp: at.Add(time.Hour), Event: EventWarning, Action: "private_base_url"},
+		}
+
+		hints := Analyze(entries, time.UTC)
+		if len(hints) != 1 || hints[0].Kind != HintFailureBurst {
+			t.Fatalf("Analyze() = %v, want one failure burst", hints)
+		}
+		want := "3 failures in 4m0s: refuse_base_url x2, revoke_failed x1"
+		if hints[0].Message != want || !hints[0].Time.Equal(at) {
+			t.Errorf("hint = %+v, want %q at %v", hints[0], want, at)
+		}
+	})
+
+	t.Run("unusual hour", func(t *testing.T) {
+		entries := workdays(start, 60)
+		night := start.AddDate(0, 0, 60).Add(-7 * time.Hour)
+		entries = append(entries,
+			Entry{Timestamp: night, Event: EventReveal, Provider: "zai", Hostname: "desk", User: "me"},
+			Entry{Timestamp: night.Add(10 * time.Minute), Event: EventReveal, Provider: "zai", Hostname: "desk", User: "me"},
+		)
+
+		hints := Analyze(entries, time.UTC)
+		if len(hints) != 1 || hints[0].Kind != HintUnusualHour {
+			t.Fatalf("Analyze() = %v, want one unusual hour", hints)
+		}
+		if !strings.Contains(hints[0].Message, "reveal for zai at 03:00") {
+			t.Errorf("hint = %+v", hints[0])
+		}
+
+		// Hours are judged in the location given.
+		hints = Analyze(entries, time.FixedZone("UTC+2", 2*60*60))
+		if len(hints) != 1 || !strings.Contains(hints[0].Message, "at 05:00") {
+			t.Errorf("Analyze() in UTC+2 = %v", hints)
+		}
+	})
+
+	t.Run("escrow open", func(t *testing.T) {
+		entries := append(workdays(start, 3), Entry{
+			Timestamp: start.AddDate(0, 0, 3),
+			Event:     EventEscrow,
+			User:      "admin",
+			Details:   map[string]string{"reason": "offboarding"},
+		})
+		hints := Analyze(entries, time.UTC)
+		if len(hints) != 1 || hints[0].Kind != HintEscrow ||
+			hints[0].Message != `secrets decrypted with the escrow key by admin, reason "offboarding"` {
+			t.Errorf("Analyze() = %v, want one escrow hint", hints)
+		}
+	})
+}
@@ -0,0 +1,242 @@
+// Package audit records provider switches, key rotations, configuration
+// changes, and security warnings to an append-only JSON lines log in the state directory.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"filippo.io/age"
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsperm"
+	"github.com/dkmnx/kairo/internal/fsutil"
+	"github.com/dkmnx/kairo/internal/osuser"
+)
+
+// LogFileName is the audit log file inside the config directory.
+const LogFileName = "audit.log"
+
+// Event is the category of an audited action.
+type Event string
+
+// Audited event types.
+const (
+	EventSwitch Event = "switch"
+	EventRotate Event = "rotate"
+	EventConfig Event = "config"
+	// EventWarning records a suspicious condition kairo let pass or refused,
+	// such as a base URL that resolves to a private address.
+	EventWarning Event = "warning"
+	// EventReveal records a stored secret shown by 'kairo secrets reveal'.
+	EventReveal Event = "reveal"
+	// EventEscrow records secrets decrypted with the organization's escrow
+	// key by 'kairo escrow open'.
+	EventEscrow Event = "escrow"
+)
+
+// Events lists every event type in display order.
+var Events = []Event{EventSwitch, EventRotate, EventConfig, EventWarning, EventReveal, EventEscrow}
+
+// Level controls how much of each entry is written.
+type Level string
+
+// Detail levels. LevelMinimal drops Details and Patch, LevelNormal writes entries as
+// given, and LevelVerbose also records the host and user name.
+const (
+	LevelMinimal Level = "minimal"
+	LevelNormal  Level = "normal"
+	LevelVerbose Level = "verbose"
+)
+
+// Entry is a single audit log record. Patch holds the JSON patch of a
+// configuration change, from the config before it to the config after it.
+type Entry struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Event     Event             `json:"event"`
+	Action    string            `json:"action,omitempty"`
+	Provider  string            `json:"provider,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	Patch     []PatchOp         `json:"patch,omitempty"`
+	Hostname  string            `json:"hostname,omitempty"`
+	User      string            `json:"user,omitempty"`
+}
+
+// Policy selects which events are written, at what level, and how their
+// details are masked. When Recipient is set, each entry is encrypted to it.
+type Policy struct {
+	Events    []Event
+	Level     Level
+	Mask      Masker
+	Recipient age.Recipient
+}
+
+// ParsePolicy validates configured event names and level. An empty events
+// list audits every event; an empty level means LevelNormal.
+func ParsePolicy(events []string, level string) (Policy, error) {
+	p := Policy{Level: LevelNormal}
+
+	switch Level(level) {
+	case "":
+	case LevelMinimal, LevelNormal, LevelVerbose:
+		p.Level = Level(level)
+	default:
+		return Policy{}, errors.NewError(errors.ConfigError,
+			fmt.Sprintf("invalid audit level %q (use minimal, normal, or verbose)", level))
+	}
+
+	for _, name := range events {
+		e := Event(name)
+		if !slices.Contains(Events, e) {
+			return Policy{}, errors.NewError(errors.ConfigError,
+				fmt.Sprintf("invalid audit event %q (use switch, rotate, config, warning, reveal, or escrow)", name))
+		}
+		p.Events = append(p.Events, e)
+	}
+
+	return p, nil
+}
+
+// Allows reports whether entries of event e are written under p.
+func (p Policy) Allows(e Event) bool {
+	return len(p.Events) == 0 || slices.Contains(p.Events, e)
+}
+
+// apply trims or enriches e according to the policy level, and masks its
+// details.
+func (p Policy) apply(e Entry) Entry {
+	switch p.Level {
+	case LevelMinimal:
+		e.Details = nil
+		e.Patch = nil
+	case LevelVerbose:
+		e.Hostname, _ = os.Hostname()
+		e.User, _ = osuser.Name()
+	}
+	e.Details = p.Mask.Details(e.Details)
+	e.Patch = p.Mask.maskPatch(e.Patch)
+
+	return e
+}
+
+// Logger appends entries to the audit log of one config directory.
+type Logger struct {
+	path   string
+	policy Policy
+}
+
+// NewLogger returns a logger writing to LogFileName under dir.
+func NewLogger(dir string, policy Policy) *Logger {
+	return &Logger{path: filepath.Join(dir, LogFileName), policy: policy}
+}
+
+// Log writes e if its event is allowed by the policy. A zero Timestamp is
+// set to the current time.
+func (l *Logger) Log(e Entry) error {
+	if !l.policy.Allows(e.Event) {
+		return nil
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+
+	line, err := json.Marshal(l.policy.apply(e))
+	if err != nil {
+		return errors.WrapError(errors.RuntimeError, "failed to encode audit entry", err)
+	}
+	if l.policy.Recipient != nil {
+		if line, err = seal(line, l.policy.Recipient); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+	unlock, err := fsutil.Lock(ctx, l.path+".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := fsperm.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY)
+	if err != nil {
+		return errors.FileError("failed to open audit log", l.path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return errors.FileError("failed to write audit log", l.path, err)
+	}
+
+	return f.Sync()
+}
+
+// LoadEntries reads every entry from the audit log under dir, oldest first.
+// A missing log yields no entries and no error; malformed and encrypted
+// lines are skipped.
+func LoadEntries(dir string) ([]Entry, error) {
+	entries, _, err := Read(dir, nil)
+
+	return entries, err
+}
+
+// Read is LoadEntries that also decrypts encrypted lines with identity. It
+// returns the number of encrypted lines it could not decrypt, because
+// identity is nil or not the key they were written to.
+func Read(dir string, identity age.Identity) ([]Entry, int, error) {
+	path := filepath.Join(dir, LogFileName)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return nil, 0, nil
+		}
+
+		return nil, 0, errors.FileError("failed to read audit log", path, err)
+	}
+
+	var entries []Entry
+	sealed := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		e, ok, opened := parseLine(scanner.Bytes(), identity)
+		if !opened {
+			sealed++
+
+			continue
+		}
+		if ok {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, sealed, nil
+}
+
+// parseLine decodes one line of the log, decrypting it with identity when it
+// is encrypted. ok is false for a malformed line, and opened is false for an
+// encrypted line identity cannot decrypt.
+func parseLine(line []byte, identity age.Identity) (e Entry, ok, opened bool) {
+	if isSealed(line) {
+		plaintext, ok := unseal(line, identity)
+		if !ok {
+			return Entry{}, false, false
+		}
+		line = plaintext
+	}
+
+	if err := json.Unmarshal(line, &e); err != nil {
+		return Entry{}, false, true
+	}
+
+	return e, true, true
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		events    []string
+		level     string
+		wantLevel Level
+		wantErr   bool
+	}{
+		{"defaults", nil, "", LevelNormal, false},
+		{"filtered minimal", []string{"switch", "rotate"}, "minimal", LevelMinimal, false},
+		{"verbose", nil, "verbose", LevelVerbose, false},
+		{"unknown event", []string{"login"}, "", "", true},
+		{"unknown level", nil, "debug", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePolicy(tt.events, tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && p.Level != tt.wantLevel {
+				t.Errorf("Level = %q, want %q", p.Level, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestPolicyAllows(t *testing.T) {
+	all := Policy{}
+	for _, e := range Events {
+		if !all.Allows(e) {
+			t.Errorf("empty policy should allow %q", e)
+		}
+	}
+
+	only := Policy{Events: []Event{EventRotate}}
+	if only.Allows(EventSwitch) || !only.Allows(EventRotate) {
+		t.Error("filtered policy allows the wrong events")
+	}
+}
+
+func TestLoggerFiltersAndLevels(t *testing.T) {
+	dir := t.TempDir()
+	details := map[string]string{"harness": "claude"}
+
+	if err := NewLogger(dir, Policy{Events: []Event{EventSwitch}, Level: LevelNormal}).
+		Log(Entry{Event: EventConfig, Action: "set_default"}); err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	if err := NewLogger(dir, Policy{Level: LevelNormal}).
+		Log(Entry{Event: EventSwitch, Provider: "zai", Details: details}); err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	if err := NewLogger(dir, Policy{Level: LevelMinimal}).
+		Log(Entry{Event: EventSwitch, Provider: "zai", Details: details}); err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	if err := NewLogger(dir, Policy{Level: LevelVerbose}).
+		Log(Entry{Event: EventRotate, Action: "reset_secrets"}); err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+
+	entries, err := LoadEntries(dir)
+	if err != nil {
+		t.Fatalf("LoadEntries() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("len(entries) = %d, want 3 (filtered config event must be dropped)", len(entries))
+	}
+	if entries[0].Details["harness"] != "claude" {
+		t.Errorf("normal level dropped details: %+v", entries[0])
+	}
+	if entries[1].Details != nil {
+		t.Errorf("minimal level kept details: %+v", entries[1])
+	}
+	if entries[2].Hostname == "" && entries[2].User == "" {
+		t.Errorf("verbose level recorded no host or user: %+v", entries[2])
+	}
+	if entries[0].Timestamp.IsZero() {
+		t.Error("Timestamp not set")
+	}
+
+	info, err := os.Stat(filepath.Join(dir, LogFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		t.Errorf("audit log permissions = %o, want owner-only", perm)
+	}
+}
+
+func TestLoadEntriesMissing(t *testing.T) {
+	entries, err := LoadEntries(t.TempDir())
+	if err != nil || entries != nil {
+		t.Errorf("LoadEntries() = %v, %v; want nil, nil", entries, err)
+	}
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+
+	"filippo.io/age"
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// KeyFileName is the age key that encrypted audit entries are written to,
+// kept in the config directory apart from the log. It is separate from the
+// secrets key so that rotating or locking that key leaves the log readable.
+const KeyFileName = "audit.key"
+
+// sealedPrefix starts an encrypted line: the age ciphertext of the entry's
+// JSON, base64-encoded so that each entry stays on one line.
+var sealedPrefix = []byte("age:")
+
+// isSealed reports whether line holds an encrypted entry.
+func isSealed(line []byte) bool {
+	return bytes.HasPrefix(line, sealedPrefix)
+}
+
+// seal encrypts the JSON line of an entry to recipient.
+func seal(line []byte, recipient age.Recipient) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipient)
+	if err != nil {
+		return nil, errors.WrapError(errors.CryptoError, "failed to encrypt audit entry", err)
+	}
+	if _, err := w.Write(line); err != nil {
+		return nil, errors.WrapError(errors.CryptoError, "failed to encrypt audit entry", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.WrapError(errors.CryptoError, "failed to encrypt audit entry", err)
+	}
+
+	out := make([]byte, len(sealedPrefix)+base64.StdEncoding.EncodedLen(buf.Len()))
+	copy(out, sealedPrefix)
+	base64.StdEncoding.Encode(out[len(sealedPrefix):], buf.Bytes())
+
+	return out, nil
+}
+
+// unseal returns the JSON of an encrypted line, or false when identity is
+// nil or cannot decrypt it.
+func unseal(line []byte, identity age.Identity) ([]byte, bool) {
+	if identity == nil {
+		return nil, false
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(line[len(sealedPrefix):])))
+	if err != nil {
+		return nil, false
+	}
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), identity)
+	if err != nil {
+		return nil, false
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, false
+	}
+
+	return plaintext, true
+}
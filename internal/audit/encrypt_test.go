@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+)
+
+func TestEncryptedLog(t *testing.T) {
+	dir := t.TempDir()
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A log that was plaintext before encryption was turned on.
+	now := time.Now().UTC()
+	if err := NewLogger(dir, Policy{}).Log(Entry{Timestamp: now.Add(-48 * time.Hour), Event: EventConfig,
+		Action: "set_default"}); err != nil {
+		t.Fatal(err)
+	}
+	sealed := NewLogger(dir, Policy{Level: LevelVerbose, Recipient: identity.Recipient()})
+	for _, e := range []Entry{
+		{Timestamp: now.Add(-24 * time.Hour), Event: EventSwitch, Provider: "zai"},
+		{Timestamp: now, Event: EventRotate, Action: "rotate_key", Provider: "zai"},
+	} {
+		if err := sealed.Log(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, LogFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("zai")) || bytes.Count(data, sealedPrefix) != 2 {
+		t.Fatalf("log does not hide encrypted entries:\n%s", data)
+	}
+
+	entries, locked, err := Read(dir, identity)
+	if err != nil || locked != 0 || len(entries) != 3 {
+		t.Fatalf("Read() = %d entries, %d locked, %v; want 3, 0", len(entries), locked, err)
+	}
+	if e := entries[2]; e.Action != "rotate_key" || e.Provider != "zai" || e.Hostname == "" {
+		t.Errorf("decrypted entry = %+v", e)
+	}
+
+	other, _ := age.GenerateX25519Identity()
+	if entries, locked, _ := Read(dir, other); len(entries) != 1 || locked != 2 {
+		t.Errorf("Read() with another key = %d entries, %d locked; want 1, 2", len(entries), locked)
+	}
+	if entries, _ := LoadEntries(dir); len(entries) != 1 {
+		t.Errorf("LoadEntries() = %d entries, want the plaintext one", len(entries))
+	}
+
+	// Without the key, pruning stops at the first encrypted line.
+	if result, err := Prune(dir, now.Add(-time.Hour), now, nil); err != nil || result.Removed != 1 {
+		t.Fatalf("Prune() without key = %+v, %v; want the plaintext entry removed", result, err)
+	}
+	if result, err := Prune(dir, now.Add(-time.Hour), now, identity); err != nil || result.Removed != 1 {
+		t.Fatalf("Prune() with key = %+v, %v; want the old encrypted entry removed", result, err)
+	}
+	entries, locked, _ = Read(dir, identity)
+	if len(entries) != 2 || locked != 0 || entries[0].Event != EventPrune || entries[1].Action != "rotate_key" {
+		t.Errorf("after prune = %+v, %d locked", entries, locked)
+	}
+}
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// MaskMode selects the built-in rules that mask entry details before they
+// are written.
+type MaskMode string
+
+// Masking modes. MaskStrict, the default, masks key-like strings, email
+// addresses, credentials in URLs, and the values of details whose names
+// suggest a secret. MaskBasic keeps only the rules for well-known key
+// formats, URL credentials, and secret-named details. MaskOff applies no
+// built-in rule; configured keys and patterns are still masked.
+const (
+	MaskStrict MaskMode = "strict"
+	MaskBasic  MaskMode = "basic"
+	MaskOff    MaskMode = "off"
+)
+
+// masked replaces a masked value or match.
+const masked = "****"
+
+var (
+	// urlCredentials matches the user info of a URL, such as user:pass@.
+	urlCredentials = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/@\s]+@`)
+	// knownKeys matches API key and token formats with a recognizable
+	// prefix: sk-/sk_ keys, GitHub and Slack tokens, AWS access key IDs,
+	// Google API keys, JWTs, and bearer tokens.
+	knownKeys = regexp.MustCompile(`\b(?:sk[-_][A-Za-z0-9_-]{8,}|gh[pousr]_[A-Za-z0-9]{20,}|xox[abpors]-[A-Za-z0-9-]{10,}|` +
+		`AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_-]{30,}|eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+)|` +
+		`(?i:bearer\s+)[A-Za-z0-9._~+/=-]{8,}`)
+	// emails matches the local part of an email address.
+	emails = regexp.MustCompile(`[A-Za-z0-9._%+-]+(@[A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+	// longTokens matches unbroken runs long enough to be a key. Model and
+	// host names break up into shorter words at hyphens, dots, and slashes.
+	longTokens = regexp.MustCompile(`[A-Za-z0-9+=]{24,}`)
+	hexOnly    = regexp.MustCompile(`^[0-9a-f]+$`)
+	hasDigit   = regexp.MustCompile(`[0-9]`)
+	hasLetter  = regexp.MustCompile(`[A-Za-z]`)
+)
+
+// secretNameMarkers are substrings of detail names whose values are secret.
+// Names ending in "fingerprint" are exempt, since a fingerprint is a hash.
+var secretNameMarkers = []string{"key", "token", "secret", "password", "passwd", "credential", "auth"}
+
+// Masker masks sensitive values in entry details. The zero Masker applies
+// MaskStrict.
+type Masker struct {
+	mode     MaskMode
+	keys     []string
+	patterns []*regexp.Regexp
+}
+
+// NewMasker validates a configured masking policy. keys lists detail names
+// whose values are always masked, and patterns regular expressions whose
+// matches are masked in every value. An empty mode means MaskStrict.
+func NewMasker(mode string, keys, patterns []string) (Masker, error) {
+	m := Masker{mode: MaskStrict}
+	switch MaskMode(mode) {
+	case "":
+	case MaskStrict, MaskBasic, MaskOff:
+		m.mode = MaskMode(mode)
+	default:
+		return Masker{}, errors.NewError(errors.ConfigError,
+			fmt.Sprintf("invalid audit mask %q (use strict, basic, or off)", mode))
+	}
+
+	for _, k := range keys {
+		m.keys = append(m.keys, strings.ToLower(k))
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return Masker{}, errors.WrapError(errors.ConfigError,
+				fmt.Sprintf("invalid audit mask pattern %q", p), err)
+		}
+		m.patterns = append(m.patterns, re)
+	}
+
+	return m, nil
+}
+
+// Details returns a copy of details with sensitive values masked.
+func (m Masker) Details(details map[string]string) map[string]string {
+	if details == nil {
+		return nil
+	}
+
+	out := make(map[string]string, len(details))
+	for k, v := range details {
+		out[k] = m.value(k, v)
+	}
+
+	return out
+}
+
+func (m Masker) value(name, v string) string {
+	mode := m.mode
+	if mode == "" {
+		mode = MaskStrict
+	}
+	lower := strings.ToLower(name)
+	if slices.Contains(m.keys, lower) || (mode != MaskOff && secretName(lower)) {
+		return masked
+	}
+
+	if mode != MaskOff {
+		v = urlCredentials.ReplaceAllString(v, "${1}"+masked+"@")
+		v = knownKeys.ReplaceAllString(v, masked)
+	}
+	if mode == MaskStrict {
+		v = emails.ReplaceAllString(v, masked+"${1}")
+		v = longTokens.ReplaceAllStringFunc(v, func(s string) string {
+			// Pure hex runs are hashes and fingerprints, and runs without
+			// both letters and digits are words.
+			if hexOnly.MatchString(s) || !hasDigit.MatchString(s) || !hasLetter.MatchString(s) {
+				return s
+			}
+
+			return masked
+		})
+	}
+	for _, re := range m.patterns {
+		v = re.ReplaceAllString(v, masked)
+	}
+
+	return v
+}
+
+func secretName(name string) bool {
+	if strings.HasSuffix(name, "fingerprint") {
+		return false
+	}
+	for _, marker := range secretNameMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,187 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Patch operations. OpTest carries the value a path held before an OpReplace
+// or OpRemove, so a replayed patch fails on a config that has since changed.
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpTest    = "test"
+)
+
+// PatchOp is one operation of an RFC 6902 JSON patch.
+type PatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value,omitempty"`
+}
+
+// Diff returns the JSON patch that turns before into after, with paths below
+// root, a JSON pointer such as "/providers/zai". Values are compared by
+// their config.yaml form, so field names match the config file; a nil
+// before or after stands for an absent entry. Objects are compared key by
+// key in sorted order, lists and scalars as a whole, so the patch of two
+// values is always the same.
+func Diff(root string, before, after any) ([]PatchOp, error) {
+	b, err := yamlValue(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := yamlValue(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []PatchOp
+	diffValue(&ops, root, b, a)
+
+	return ops, nil
+}
+
+// PointerToken escapes s for use as one segment of a JSON pointer.
+func PointerToken(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+// yamlValue returns v as the generic value config.yaml would decode to.
+func yamlValue(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, errors.WrapError(errors.RuntimeError, "failed to encode config for the audit patch", err)
+	}
+	var out any
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return nil, errors.WrapError(errors.RuntimeError, "failed to decode config for the audit patch", err)
+	}
+
+	return out, nil
+}
+
+func diffValue(ops *[]PatchOp, path string, before, after any) {
+	switch {
+	case before == nil && after == nil:
+	case before == nil:
+		*ops = append(*ops, PatchOp{Op: OpAdd, Path: path, Value: after})
+	case after == nil:
+		*ops = append(*ops, PatchOp{Op: OpTest, Path: path, Value: before}, PatchOp{Op: OpRemove, Path: path})
+	default:
+		bm, bok := before.(map[string]any)
+		am, aok := after.(map[string]any)
+		if bok && aok {
+			union := maps.Clone(bm)
+			maps.Copy(union, am)
+			for _, k := range slices.Sorted(maps.Keys(union)) {
+				diffValue(ops, path+"/"+PointerToken(k), bm[k], am[k])
+			}
+
+			return
+		}
+		if !reflect.DeepEqual(before, after) {
+			*ops = append(*ops, PatchOp{Op: OpTest, Path: path, Value: before},
+				PatchOp{Op: OpReplace, Path: path, Value: after})
+		}
+	}
+}
+
+// maskPatch returns a copy of ops with sensitive string values masked, each
+// judged by the name of the field it belongs to.
+func (m Masker) maskPatch(ops []PatchOp) []PatchOp {
+	if ops == nil {
+		return nil
+	}
+
+	out := make([]PatchOp, len(ops))
+	for i, op := range ops {
+		name := op.Path[strings.LastIndex(op.Path, "/")+1:]
+		op.Value = m.maskValue(name, op.Value)
+		out[i] = op
+	}
+
+	return out
+}
+
+func (m Masker) maskValue(name string, v any) any {
+	switch v := v.(type) {
+	case string:
+		return m.value(name, v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = m.maskValue(k, e)
+		}
+
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = m.maskValue(name, e)
+		}
+
+		return out
+	default:
+		return v
+	}
+}
+
+// FormatPatch returns ops as lines for display: "+ path: value" for an
+// added path, "- path: old" for a removed one, and "~ path: old -> new" for a
+// replaced one. A test that precedes the operation on its path supplies the
+// old value.
+func FormatPatch(ops []PatchOp) []string {
+	var lines []string
+	for i := 0; i < len(ops); i++ {
+		op := ops[i]
+		if op.Op == OpTest && i+1 < len(ops) && ops[i+1].Path == op.Path {
+			next := ops[i+1]
+			switch next.Op {
+			case OpRemove:
+				lines = append(lines, fmt.Sprintf("- %s: %s", op.Path, formatPatchValue(op.Value)))
+				i++
+
+				continue
+			case OpReplace:
+				lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", op.Path,
+					formatPatchValue(op.Value), formatPatchValue(next.Value)))
+				i++
+
+				continue
+			}
+		}
+
+		switch op.Op {
+		case OpAdd:
+			lines = append(lines, fmt.Sprintf("+ %s: %s", op.Path, formatPatchValue(op.Value)))
+		case OpRemove:
+			lines = append(lines, "- "+op.Path)
+		default:
+			lines = append(lines, fmt.Sprintf("%s %s: %s", op.Op, op.Path, formatPatchValue(op.Value)))
+		}
+	}
+
+	return lines
+}
+
+// formatPatchValue returns v as compact JSON.
+func formatPatchValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
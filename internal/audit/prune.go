@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsutil"
+)
+
+// EventPrune marks the checkpoint entry written at the top of a pruned log.
+// It is not listed in Events because it cannot be filtered out.
+const EventPrune Event = "prune"
+
+// lockTimeout bounds how long Log and Prune wait for each other.
+const lockTimeout = 5 * time.Second
+
+// PruneResult describes one prune of the audit log.
+type PruneResult struct {
+	// Removed is the number of entries dropped, not counting earlier
+	// checkpoints.
+	Removed int
+	// Kept is the number of lines left after the checkpoint.
+	Kept int
+	// Checkpoint is the hex SHA-256 of the removed lines, or "" when
+	// nothing was removed.
+	Checkpoint string
+}
+
+// ParseRetention parses a retention period such as "90d", "2w", or "36h".
+func ParseRetention(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	var d time.Duration
+	var err error
+	switch {
+	case strings.HasSuffix(s, "d"), strings.HasSuffix(s, "w"):
+		unit := 24 * time.Hour
+		if strings.HasSuffix(s, "w") {
+			unit *= 7
+		}
+		var n int
+		n, err = strconv.Atoi(s[:len(s)-1])
+		d = time.Duration(n) * unit
+	default:
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return 0, errors.NewError(errors.ValidationError,
+			fmt.Sprintf("invalid retention %q (use a positive period such as 90d, 2w, or 36h)", s))
+	}
+
+	return d, nil
+}
+
+// Prune rewrites the audit log under dir without the entries written before
+// the cutoff. The removed lines are replaced by a single EventPrune
+// checkpoint holding their SHA-256, so the kept log can still be tied to an
+// archived copy of the original. Because a previous checkpoint at the top of
+// the log is removed along with the entries it precedes, each checkpoint
+// hash also covers the one before it.
+//
+// Only the oldest lines are removed: pruning stops at the first entry
+// written at or after before, or at an encrypted line identity cannot
+// decrypt, since its age is unknown. Nothing is rewritten when no entries
+// are old enough. The checkpoint holds no host, user, or activity and is
+// written unencrypted.
+func Prune(dir string, before, now time.Time, identity age.Identity) (PruneResult, error) {
+	path := filepath.Join(dir, LogFileName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+	unlock, err := fsutil.Lock(ctx, path+".lock")
+	if err != nil {
+		return PruneResult{}, err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return PruneResult{}, nil
+		}
+
+		return PruneResult{}, errors.FileError("failed to read audit log", path, err)
+	}
+
+	cut, removed := 0, 0
+scan:
+	for cut < len(data) {
+		end := bytes.IndexByte(data[cut:], '\n')
+		if end < 0 {
+			end = len(data) - cut
+		} else {
+			end++
+		}
+
+		e, ok, opened := parseLine(bytes.TrimRight(data[cut:cut+end], "\n"), identity)
+		switch {
+		case !opened:
+			break scan
+		case !ok:
+			// Malformed lines among old entries are dropped too.
+		case e.Event == EventPrune:
+		case e.Timestamp.Before(before):
+			removed++
+		default:
+			break scan
+		}
+		cut += end
+	}
+	if removed == 0 {
+		return PruneResult{}, nil
+	}
+
+	sum := sha256.Sum256(data[:cut])
+	result := PruneResult{
+		Removed:    removed,
+		Kept:       bytes.Count(data[cut:], []byte{'\n'}),
+		Checkpoint: hex.EncodeToString(sum[:]),
+	}
+
+	checkpoint, err := json.Marshal(Entry{
+		Timestamp: now.UTC(),
+		Event:     EventPrune,
+		Action:    "checkpoint",
+		Details: map[string]string{
+			"before":  before.UTC().Format(time.RFC3339),
+			"removed": strconv.Itoa(removed),
+			"sha256":  result.Checkpoint,
+		},
+	})
+	if err != nil {
+		return PruneResult{}, errors.WrapError(errors.RuntimeError, "failed to encode audit checkpoint", err)
+	}
+
+	err = fsutil.WriteAtomic(path, func(f *os.File) error {
+		if _, err := f.Write(append(checkpoint, '\n')); err != nil {
+			return err
+		}
+		_, err := f.Write(data[cut:])
+
+		return err
+	})
+	if err != nil {
+		return PruneResult{}, errors.FileError("failed to rewrite audit log", path, err)
+	}
+
+	return result, nil
+}
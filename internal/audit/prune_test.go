@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90d", 90 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"36h", 36 * time.Hour, false},
+		{"0d", 0, true},
+		{"-1h", 0, true},
+		{"soon", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRetention(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRetention(%q) = %v, %v; want %v, wantErr %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, LogFileName)
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	logger := NewLogger(dir, Policy{})
+	for _, days := range []int{40, 20, 5, 1} {
+		if err := logger.Log(Entry{Timestamp: now.AddDate(0, 0, -days), Event: EventSwitch, Provider: "zai"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(before), "\n")
+
+	result, err := Prune(dir, now.AddDate(0, 0, -30), now, nil)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	sum := sha256.Sum256([]byte(lines[0]))
+	if result.Removed != 1 || result.Kept != 3 || result.Checkpoint != hex.EncodeToString(sum[:]) {
+		t.Fatalf("Prune() = %+v", result)
+	}
+
+	entries, err := LoadEntries(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 || entries[0].Event != EventPrune || entries[0].Details["sha256"] != result.Checkpoint {
+		t.Fatalf("entries after prune = %+v", entries)
+	}
+
+	if again, err := Prune(dir, now.AddDate(0, 0, -30), now, nil); err != nil || again.Removed != 0 {
+		t.Errorf("second Prune() = %+v, %v; want nothing removed", again, err)
+	}
+
+	pruned, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = Prune(dir, now.AddDate(0, 0, -10), now.Add(time.Hour), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chained := strings.SplitAfter(string(pruned), "\n")
+	sum = sha256.Sum256([]byte(chained[0] + chained[1]))
+	if result.Removed != 1 || result.Checkpoint != hex.EncodeToString(sum[:]) {
+		t.Errorf("chained Prune() = %+v, want hash over the old checkpoint and entry", result)
+	}
+	if entries, _ := LoadEntries(dir); len(entries) != 3 {
+		t.Errorf("len(entries) = %d, want checkpoint plus 2 entries", len(entries))
+	}
+}
+
+func TestPruneMissingLog(t *testing.T) {
+	if result, err := Prune(t.TempDir(), time.Now(), time.Now(), nil); err != nil || result.Removed != 0 {
+		t.Errorf("Prune() = %+v, %v; want no-op", result, err)
+	}
+}
@@ -0,0 +1,208 @@
+// Package claudesettings reads Claude Code's settings.json and the process
+// environment to detect Anthropic-compatible provider setups that were
+// configured outside kairo.
+package claudesettings
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// EnvAPIKey is the environment variable Claude Code reads for native
+// Anthropic API keys (as opposed to the bearer token in EnvAuthToken).
+const EnvAPIKey = "ANTHROPIC_API_KEY"
+
+// Settings is the subset of Claude Code's settings.json that kairo reads.
+type Settings struct {
+	Env          map[string]string `json:"env"`
+	APIKeyHelper string            `json:"apiKeyHelper,omitempty"`
+}
+
+// Source identifies where a detected provider setup was found.
+type Source string
+
+// Sources of detected provider setups.
+const (
+	SourceSettings    Source = "settings.json"
+	SourceEnvironment Source = "environment"
+)
+
+// Detection is a provider setup found in settings.json or the environment.
+type Detection struct {
+	Source    Source
+	BaseURL   string
+	AuthToken string
+	Model     string
+}
+
+// Dir returns Claude Code's configuration directory. CLAUDE_CONFIG_DIR
+// overrides the default ~/.claude location.
+func Dir() (string, error) {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.WrapError(errors.ConfigError,
+			"cannot determine home directory", err)
+	}
+
+	return filepath.Join(home, ".claude"), nil
+}
+
+// Path returns the location of Claude Code's user settings.json.
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "settings.json"), nil
+}
+
+// managedSettingsPath is the policy file administrators deploy, whose
+// settings override every other file. A variable so tests can move it.
+var managedSettingsPath = func() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "/Library/Application Support/ClaudeCode/managed-settings.json"
+	case "windows":
+		return filepath.Join(os.Getenv("ProgramFiles"), "ClaudeCode", "managed-settings.json")
+	default:
+		return "/etc/claude-code/managed-settings.json"
+	}
+}()
+
+// Paths returns the settings files Claude Code reads for a session started
+// in projectDir, lowest precedence first: the user settings.json, the
+// project's .claude/settings.json and .claude/settings.local.json, and the
+// managed settings. A file that does not exist is still listed.
+func Paths(projectDir string) ([]string, error) {
+	user, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{user}
+	if projectDir != "" {
+		for _, name := range []string{"settings.json", "settings.local.json"} {
+			// In the home directory the project settings are the user's.
+			if p := filepath.Join(projectDir, ".claude", name); !slices.Contains(paths, p) {
+				paths = append(paths, p)
+			}
+		}
+	}
+
+	return append(paths, managedSettingsPath), nil
+}
+
+// LoadEnv merges the env blocks of the settings files in paths, later files
+// winning, and returns the merged variables with the file each was taken
+// from. Missing files are skipped. A file that cannot be read or parsed is
+// skipped as well and reported in the returned error, so the variables of
+// the other files are still returned.
+func LoadEnv(paths []string) (map[string]string, map[string]string, error) {
+	env := make(map[string]string)
+	from := make(map[string]string)
+	var errs []error
+	for _, path := range paths {
+		s, err := Load(path)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+		for k, v := range s.Env {
+			env[k] = v
+			from[k] = path
+		}
+	}
+
+	return env, from, stderrors.Join(errs...)
+}
+
+// Load reads and parses the settings file at path. A missing file is not an
+// error: Load returns empty Settings so callers can fall back to the
+// environment alone.
+func Load(path string) (*Settings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return &Settings{Env: map[string]string{}}, nil
+		}
+
+		return nil, errors.FileError("failed to read Claude Code settings", path, err)
+	}
+
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, errors.WrapError(errors.ConfigError,
+			"failed to parse Claude Code settings (invalid JSON)", err).
+			WithContext("path", path)
+	}
+	if s.Env == nil {
+		s.Env = map[string]string{}
+	}
+
+	return &s, nil
+}
+
+// Detect returns the provider setups defined in settings and environ, in that
+// order. A setup is reported when it defines a base URL or a credential.
+// The environment entry is omitted when it duplicates the settings entry.
+func Detect(s *Settings, environ []string) []Detection {
+	var out []Detection
+
+	if s != nil {
+		if d, ok := detectFrom(SourceSettings, s.Env); ok {
+			out = append(out, d)
+		}
+	}
+
+	if d, ok := detectFrom(SourceEnvironment, EnvMap(environ)); ok {
+		if len(out) == 0 || out[0].BaseURL != d.BaseURL || out[0].AuthToken != d.AuthToken {
+			out = append(out, d)
+		}
+	}
+
+	return out
+}
+
+func detectFrom(source Source, env map[string]string) (Detection, bool) {
+	d := Detection{
+		Source:    source,
+		BaseURL:   strings.TrimSpace(env[constants.EnvBaseURL]),
+		AuthToken: strings.TrimSpace(env[constants.EnvAuthToken]),
+		Model:     strings.TrimSpace(env[constants.EnvModel]),
+	}
+	if d.AuthToken == "" {
+		d.AuthToken = strings.TrimSpace(env[EnvAPIKey])
+	}
+
+	return d, d.BaseURL != "" || d.AuthToken != ""
+}
+
+// EnvMap converts a KEY=value slice (as returned by os.Environ) into a map.
+// Entries without '=' are skipped; later duplicates win.
+func EnvMap(environ []string) map[string]string {
+	m := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			continue
+		}
+		m[k] = v
+	}
+
+	return m
+}
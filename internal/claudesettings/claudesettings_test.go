@@ -0,0 +1,191 @@
+package claudesettings
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPathHonorsClaudeConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", dir)
+
+	got, err := Path()
+	if err != nil {
+		t.Fatalf("Path() error = %v", err)
+	}
+	if want := filepath.Join(dir, "settings.json"); got != want {
+		t.Errorf("Path() = %q, want %q", got, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("missing file returns empty settings", func(t *testing.T) {
+		s, err := Load(filepath.Join(t.TempDir(), "settings.json"))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if len(s.Env) != 0 {
+			t.Errorf("Load() Env = %v, want empty", s.Env)
+		}
+	})
+
+	t.Run("parses env block", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		content := `{"env": {"ANTHROPIC_BASE_URL": "https://api.z.ai/api/anthropic"}, "apiKeyHelper": "pass show z"}`
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if s.Env["ANTHROPIC_BASE_URL"] != "https://api.z.ai/api/anthropic" {
+			t.Errorf("Env[ANTHROPIC_BASE_URL] = %q", s.Env["ANTHROPIC_BASE_URL"])
+		}
+		if s.APIKeyHelper != "pass show z" {
+			t.Errorf("APIKeyHelper = %q, want %q", s.APIKeyHelper, "pass show z")
+		}
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Load(path); err == nil {
+			t.Error("Load() error = nil, want parse error")
+		}
+	})
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings *Settings
+		environ  []string
+		want     []Detection
+	}{
+		{
+			name:     "nothing configured",
+			settings: &Settings{Env: map[string]string{}},
+			environ:  []string{"PATH=/usr/bin"},
+			want:     nil,
+		},
+		{
+			name: "settings with auth token",
+			settings: &Settings{Env: map[string]string{
+				"ANTHROPIC_BASE_URL":   "https://api.z.ai/api/anthropic",
+				"ANTHROPIC_AUTH_TOKEN": "tok",
+				"ANTHROPIC_MODEL":      "glm-5.1",
+			}},
+			want: []Detection{{
+				Source: SourceSettings, BaseURL: "https://api.z.ai/api/anthropic", AuthToken: "tok", Model: "glm-5.1",
+			}},
+		},
+		{
+			name:     "environment falls back to API key",
+			settings: nil,
+			environ:  []string{"ANTHROPIC_API_KEY=sk-ant-x"},
+			want:     []Detection{{Source: SourceEnvironment, AuthToken: "sk-ant-x"}},
+		},
+		{
+			name:     "duplicate environment entry omitted",
+			settings: &Settings{Env: map[string]string{"ANTHROPIC_BASE_URL": "https://a.example.com"}},
+			environ:  []string{"ANTHROPIC_BASE_URL=https://a.example.com"},
+			want:     []Detection{{Source: SourceSettings, BaseURL: "https://a.example.com"}},
+		},
+		{
+			name:     "distinct settings and environment",
+			settings: &Settings{Env: map[string]string{"ANTHROPIC_BASE_URL": "https://a.example.com"}},
+			environ:  []string{"ANTHROPIC_BASE_URL=https://b.example.com"},
+			want: []Detection{
+				{Source: SourceSettings, BaseURL: "https://a.example.com"},
+				{Source: SourceEnvironment, BaseURL: "https://b.example.com"},
+			},
+		},
+		{
+			name:     "blank values ignored",
+			settings: &Settings{Env: map[string]string{"ANTHROPIC_BASE_URL": "  "}},
+			environ:  []string{"ANTHROPIC_AUTH_TOKEN="},
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.settings, tt.environ)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvMap(t *testing.T) {
+	got := EnvMap([]string{"A=1", "malformed", "=empty", "B=x=y", "A=2"})
+	want := map[string]string{"A": "2", "B": "x=y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvMap() = %v, want %v", got, want)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	claudeDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", claudeDir)
+	project := t.TempDir()
+
+	got, err := Paths(project)
+	if err != nil {
+		t.Fatalf("Paths() error = %v", err)
+	}
+	want := []string{
+		filepath.Join(claudeDir, "settings.json"),
+		filepath.Join(project, ".claude", "settings.json"),
+		filepath.Join(project, ".claude", "settings.local.json"),
+		managedSettingsPath,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Paths() = %v, want %v", got, want)
+	}
+
+	// Started in the home directory, the project settings.json is the
+	// user's and is not listed twice.
+	home := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", filepath.Join(home, ".claude"))
+	got, err = Paths(home)
+	if err != nil {
+		t.Fatalf("Paths() error = %v", err)
+	}
+	if len(got) != 3 {
+		t.Errorf("Paths() from the home directory = %v, want 3 files", got)
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+	user := write("user.json", `{"env": {"ANTHROPIC_BASE_URL": "https://user", "ANTHROPIC_MODEL": "m"}}`)
+	local := write("local.json", `{"env": {"ANTHROPIC_BASE_URL": "https://local"}}`)
+	broken := write("broken.json", `{`)
+
+	env, from, err := LoadEnv([]string{user, filepath.Join(dir, "missing.json"), broken, local})
+	if err == nil {
+		t.Error("LoadEnv() error = nil, want the parse error of the broken file")
+	}
+	wantEnv := map[string]string{"ANTHROPIC_BASE_URL": "https://local", "ANTHROPIC_MODEL": "m"}
+	wantFrom := map[string]string{"ANTHROPIC_BASE_URL": local, "ANTHROPIC_MODEL": user}
+	if !reflect.DeepEqual(env, wantEnv) || !reflect.DeepEqual(from, wantFrom) {
+		t.Errorf("LoadEnv() = %v, %v, want %v, %v", env, from, wantEnv, wantFrom)
+	}
+}
@@ -0,0 +1,127 @@
+// Package clipboard copies text to the system clipboard without cgo, so that
+// kairo keeps cross-compiling for every platform. It pipes the text to the
+// platform's clipboard command when one is installed, and otherwise sends
+// the OSC 52 escape sequence, which terminals that support it turn into a
+// clipboard write, including over SSH.
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// ErrUnavailable is returned by Copy when there is no clipboard command and
+// no terminal to send OSC 52 to.
+var ErrUnavailable = errors.New("no clipboard available")
+
+// MethodOSC52 is the method Copy reports when it used the terminal escape
+// sequence. Terminals do not acknowledge it, so the copy may not have
+// happened.
+const MethodOSC52 = "OSC 52"
+
+// Clipboard copies text with the first method available on GOOS.
+type Clipboard struct {
+	GOOS     string
+	Getenv   func(string) string
+	LookPath func(string) (string, error)
+	// Run runs name with args, writing stdin to it.
+	Run func(ctx context.Context, stdin []byte, name string, args ...string) error
+	// Terminal receives the OSC 52 sequence. It is nil when output is not a
+	// terminal, since the sequence would corrupt a pipe or file.
+	Terminal io.Writer
+}
+
+// New returns the Clipboard for this platform. terminal is where to send
+// OSC 52, or nil to never use it.
+func New(terminal io.Writer) Clipboard {
+	return Clipboard{
+		GOOS:     runtime.GOOS,
+		Getenv:   os.Getenv,
+		LookPath: exec.LookPath,
+		Run: func(ctx context.Context, stdin []byte, name string, args ...string) error {
+			c := exec.CommandContext(ctx, name, args...)
+			c.Stdin = bytes.NewReader(stdin)
+
+			return c.Run()
+		},
+		Terminal: terminal,
+	}
+}
+
+// command is a clipboard command line.
+type command struct {
+	name string
+	args []string
+}
+
+// commands returns the clipboard commands to try, in order. On Linux and the
+// BSDs they depend on the display server the session runs under.
+func (c Clipboard) commands() []command {
+	switch c.GOOS {
+	case "darwin":
+		return []command{{name: "pbcopy"}}
+	case "windows":
+		return []command{{name: "clip.exe"}}
+	}
+
+	var cmds []command
+	if c.Getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append(cmds, command{name: "wl-copy"})
+	}
+	if c.Getenv("DISPLAY") != "" {
+		cmds = append(cmds,
+			command{name: "xclip", args: []string{"-selection", "clipboard"}},
+			command{name: "xsel", args: []string{"--clipboard", "--input"}})
+	}
+
+	return append(cmds, command{name: "termux-clipboard-set"})
+}
+
+// Copy copies text to the clipboard and returns the method it used: the
+// name of the clipboard command, or MethodOSC52.
+func (c Clipboard) Copy(ctx context.Context, text string) (string, error) {
+	var lastErr error
+	for _, cmd := range c.commands() {
+		path, err := c.LookPath(cmd.name)
+		if err != nil {
+			continue
+		}
+		if err := c.Run(ctx, []byte(text), path, cmd.args...); err != nil {
+			lastErr = err
+
+			continue
+		}
+
+		return cmd.name, nil
+	}
+
+	if c.Terminal != nil {
+		if _, err := io.WriteString(c.Terminal, c.osc52(text)); err != nil {
+			return "", err
+		}
+
+		return MethodOSC52, nil
+	}
+	if lastErr != nil {
+		return "", errors.Join(ErrUnavailable, lastErr)
+	}
+
+	return "", ErrUnavailable
+}
+
+// osc52 returns the escape sequence that sets the clipboard to text, wrapped
+// for tmux when running inside it so that tmux passes it through.
+func (c Clipboard) osc52(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if c.Getenv("TMUX") != "" {
+		return "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	}
+
+	return seq
+}
@@ -0,0 +1,111 @@
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeClipboard returns a Clipboard for goos whose installed commands are
+// those in installed, recording what each one is given.
+func fakeClipboard(goos string, env map[string]string, installed ...string) (*Clipboard, map[string]string) {
+	got := map[string]string{}
+	c := &Clipboard{
+		GOOS:   goos,
+		Getenv: func(k string) string { return env[k] },
+		LookPath: func(name string) (string, error) {
+			for _, n := range installed {
+				if n == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+
+			return "", exec.ErrNotFound
+		},
+		Run: func(_ context.Context, stdin []byte, name string, args ...string) error {
+			got[strings.Join(append([]string{name}, args...), " ")] = string(stdin)
+
+			return nil
+		},
+	}
+
+	return c, got
+}
+
+func TestCopy_Commands(t *testing.T) {
+	tests := []struct {
+		name      string
+		goos      string
+		env       map[string]string
+		installed []string
+		want      string
+		wantRun   string
+	}{
+		{"macOS", "darwin", nil, []string{"pbcopy"}, "pbcopy", "/usr/bin/pbcopy"},
+		{"Windows", "windows", nil, []string{"clip.exe"}, "clip.exe", "/usr/bin/clip.exe"},
+		{"Wayland", "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"},
+			[]string{"wl-copy", "xclip"}, "wl-copy", "/usr/bin/wl-copy"},
+		{"X11", "linux", map[string]string{"DISPLAY": ":0"}, []string{"xsel"},
+			"xsel", "/usr/bin/xsel --clipboard --input"},
+		{"no display", "linux", nil, []string{"xclip", "termux-clipboard-set"},
+			"termux-clipboard-set", "/usr/bin/termux-clipboard-set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, got := fakeClipboard(tt.goos, tt.env, tt.installed...)
+			method, err := c.Copy(context.Background(), "age1xyz")
+			if err != nil || method != tt.want {
+				t.Fatalf("Copy() = %q, %v, want %q", method, err, tt.want)
+			}
+			if got[tt.wantRun] != "age1xyz" {
+				t.Errorf("ran %v, want %q with the text on stdin", got, tt.wantRun)
+			}
+		})
+	}
+}
+
+func TestCopy_OSC52Fallback(t *testing.T) {
+	c, _ := fakeClipboard("linux", nil)
+	var term bytes.Buffer
+	c.Terminal = &term
+
+	method, err := c.Copy(context.Background(), "age1xyz")
+	if err != nil || method != MethodOSC52 {
+		t.Fatalf("Copy() = %q, %v, want OSC 52", method, err)
+	}
+	if term.String() != "\x1b]52;c;YWdlMXh5eg==\a" {
+		t.Errorf("terminal got %q", term.String())
+	}
+
+	term.Reset()
+	c.Getenv = func(k string) string {
+		if k == "TMUX" {
+			return "/tmp/tmux-1000/default,1,0"
+		}
+
+		return ""
+	}
+	if _, err := c.Copy(context.Background(), "age1xyz"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(term.String(), "\x1bPtmux;\x1b\x1b]52;c;") || !strings.HasSuffix(term.String(), "\x1b\\") {
+		t.Errorf("terminal got %q, want the sequence wrapped for tmux", term.String())
+	}
+}
+
+func TestCopy_Unavailable(t *testing.T) {
+	c, _ := fakeClipboard("darwin", nil)
+	if _, err := c.Copy(context.Background(), "age1xyz"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Copy() error = %v, want ErrUnavailable", err)
+	}
+
+	c, _ = fakeClipboard("darwin", nil, "pbcopy")
+	c.Run = func(context.Context, []byte, string, ...string) error { return errors.New("pbcopy: no pasteboard") }
+	_, err := c.Copy(context.Background(), "age1xyz")
+	if !errors.Is(err, ErrUnavailable) || !strings.Contains(err.Error(), "no pasteboard") {
+		t.Errorf("Copy() error = %v, want ErrUnavailable with the command's error", err)
+	}
+}
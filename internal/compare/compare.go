@@ -0,0 +1,184 @@
+// Package compare sends one prompt to several Anthropic-compatible providers
+// concurrently through the Messages API and collects each reply with its
+// latency and token usage, for side-by-side evaluation.
+package compare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/health"
+)
+
+// maxResponseSize bounds the response body read from a provider.
+const maxResponseSize = 4 * 1024 * 1024
+
+// Target is a provider to send the prompt to.
+type Target struct {
+	Provider string
+	// BaseURL is the provider's Anthropic-compatible endpoint;
+	// health.AnthropicBaseURL is used when it is empty.
+	BaseURL string
+	APIKey  string
+	Style   health.AuthStyle
+	Model   string
+}
+
+// Result is one provider's reply. Err is set when the request failed, and
+// the other fields other than Provider, Model, Latency, Status, and Quota are
+// then empty.
+type Result struct {
+	Provider     string
+	Model        string
+	Text         string
+	StopReason   string
+	Latency      time.Duration
+	InputTokens  int
+	OutputTokens int
+	// Status is the HTTP status of the reply, or 0 when none arrived.
+	Status int
+	// Quota holds the request ID and rate-limit headers of the reply, which
+	// are kept when the request failed too.
+	Quota health.Quota
+	Err   error
+}
+
+// MessagesEndpoint returns the Messages API endpoint for baseURL, or for
+// health.AnthropicBaseURL when baseURL is empty.
+func MessagesEndpoint(baseURL string) string {
+	if baseURL == "" {
+		baseURL = health.AnthropicBaseURL
+	}
+
+	return strings.TrimRight(baseURL, "/") + "/v1/messages"
+}
+
+// Run sends prompt to every target at once and returns the results in the
+// order of targets.
+func Run(ctx context.Context, client *http.Client, targets []Target, prompt string, maxTokens int) []Result {
+	results := make([]Result, len(targets))
+
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Go(func() {
+			results[i] = Send(ctx, client, t, prompt, maxTokens)
+		})
+	}
+	wg.Wait()
+
+	return results
+}
+
+type messagesRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	Messages  []message `json:"messages"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type messagesResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Send asks t's model to answer prompt in a single, non-streaming request
+// and returns the text blocks of the reply joined together.
+func Send(ctx context.Context, client *http.Client, t Target, prompt string, maxTokens int) Result {
+	res := Result{Provider: t.Provider, Model: t.Model}
+	if t.Model == "" {
+		res.Err = fmt.Errorf("no model configured")
+
+		return res
+	}
+
+	body, err := json.Marshal(messagesRequest{
+		Model:     t.Model,
+		MaxTokens: maxTokens,
+		Messages:  []message{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		res.Err = err
+
+		return res
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, MessagesEndpoint(t.BaseURL), bytes.NewReader(body))
+	if err != nil {
+		res.Err = err
+
+		return res
+	}
+	health.SetAuthHeaders(req.Header, t.Style, t.APIKey)
+	req.Header.Set("anthropic-version", health.AnthropicVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		res.Latency = time.Since(start)
+		res.Err = err
+
+		return res
+	}
+	defer resp.Body.Close()
+	res.Status = resp.StatusCode
+	res.Quota = health.ParseQuota(resp.Header)
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	res.Latency = time.Since(start)
+	if err != nil {
+		res.Err = err
+
+		return res
+	}
+
+	var parsed messagesResponse
+	decodeErr := json.Unmarshal(data, &parsed)
+	if resp.StatusCode != http.StatusOK {
+		if decodeErr == nil && parsed.Error != nil && parsed.Error.Message != "" {
+			res.Err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, parsed.Error.Message)
+		} else {
+			res.Err = fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+
+		return res
+	}
+	if decodeErr != nil {
+		res.Err = fmt.Errorf("invalid response: %w", decodeErr)
+
+		return res
+	}
+
+	var text []string
+	for _, block := range parsed.Content {
+		if block.Type == "text" {
+			text = append(text, block.Text)
+		}
+	}
+	res.Text = strings.Join(text, "\n")
+	res.StopReason = parsed.StopReason
+	res.InputTokens = parsed.Usage.InputTokens
+	res.OutputTokens = parsed.Usage.OutputTokens
+
+	return res
+}
@@ -0,0 +1,66 @@
+package compare
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/health"
+	"github.com/dkmnx/kairo/internal/mockprovider"
+)
+
+func TestRun(t *testing.T) {
+	var gotPrompt string
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req messagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err == nil && len(req.Messages) == 1 {
+			gotPrompt = req.Messages[0].Content
+		}
+		if r.Header.Get("Authorization") != "Bearer key-a" || r.Header.Get("x-api-key") != "" {
+			mockprovider.WriteError(w, http.StatusUnauthorized, "authentication_error", "bad key")
+
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mockprovider.Message(req.Model, "four words of reply"))
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(mockprovider.NewHandler(mockprovider.Options{ErrorRate: 1}))
+	defer failing.Close()
+
+	results := Run(context.Background(), http.DefaultClient, []Target{
+		{Provider: "a", BaseURL: ok.URL + "/", APIKey: "key-a", Style: health.AuthStyleBearer, Model: "model-a"},
+		{Provider: "b", BaseURL: failing.URL, APIKey: "key-b", Model: "model-b"},
+		{Provider: "c", BaseURL: ok.URL, APIKey: "key-c"},
+	}, "What is 2+2?", 64)
+
+	a := results[0]
+	if a.Err != nil || a.Provider != "a" || a.Text != "four words of reply" || a.StopReason != "end_turn" ||
+		a.InputTokens != 1 || a.OutputTokens != 4 || a.Latency <= 0 || a.Status != http.StatusOK {
+		t.Errorf("results[0] = %+v", a)
+	}
+	if gotPrompt != "What is 2+2?" {
+		t.Errorf("prompt sent = %q", gotPrompt)
+	}
+	if b := results[1]; b.Err == nil || !strings.Contains(b.Err.Error(), "HTTP 529: Overloaded") || b.Status != 529 {
+		t.Errorf("results[1].Err = %v, want the provider's error message", b.Err)
+	}
+	if q := results[1].Quota; q.RequestID != "req_mock_000001" || q.RetryAfter != "1" {
+		t.Errorf("results[1].Quota = %+v, want the mock provider's headers", q)
+	}
+	if c := results[2]; c.Err == nil || !strings.Contains(c.Err.Error(), "no model") {
+		t.Errorf("results[2].Err = %v, want a missing model error", c.Err)
+	}
+}
+
+func TestMessagesEndpoint(t *testing.T) {
+	if got := MessagesEndpoint(""); got != health.AnthropicBaseURL+"/v1/messages" {
+		t.Errorf("MessagesEndpoint(\"\") = %s", got)
+	}
+	if got := MessagesEndpoint("https://api.z.ai/api/anthropic/"); got != "https://api.z.ai/api/anthropic/v1/messages" {
+		t.Errorf("MessagesEndpoint() = %s", got)
+	}
+}
@@ -39,12 +39,27 @@ func deepCopyConfig(cfg *Config) *Config {
 	}
 	provs := make(map[string]Provider, len(cfg.Providers))
 	for k, v := range cfg.Providers {
+		var qwenCfg *QwenConfig
+		if v.Qwen != nil {
+			q := *v.Qwen
+			qwenCfg = &q
+		}
 		provs[k] = Provider{
-			Name:    v.Name,
-			BaseURL: v.BaseURL,
-			Model:   v.Model,
-			EnvKey:  v.EnvKey,
-			EnvVars: append([]string{}, v.EnvVars...),
+			Name:              v.Name,
+			BaseURL:           v.BaseURL,
+			Model:             v.Model,
+			EnvKey:            v.EnvKey,
+			EnvVars:           append([]string{}, v.EnvVars...),
+			Models:            maps.Clone(v.Models),
+			MinHarnessVersion: maps.Clone(v.MinHarnessVersion),
+			RevokeHook:        v.RevokeHook,
+			SettingsFiles:     append([]SettingsFile(nil), v.SettingsFiles...),
+			AuthStyle:         v.AuthStyle,
+			WrapperPing:       v.WrapperPing,
+			AllowInsecure:     v.AllowInsecure,
+			Disabled:          v.Disabled,
+			RunEnvAllow:       append([]string(nil), v.RunEnvAllow...),
+			Qwen:              qwenCfg,
 		}
 	}
 	defaultModels := make(map[string]string, len(cfg.DefaultModels))
@@ -55,12 +70,66 @@ func deepCopyConfig(cfg *Config) *Config {
 		customProvs[k] = cfg.CustomProviders[k]
 	}
 
+	var auditCfg *AuditConfig
+	if cfg.Audit != nil {
+		auditCfg = &AuditConfig{
+			Enabled:      cfg.Audit.Enabled,
+			Events:       append([]string(nil), cfg.Audit.Events...),
+			Level:        cfg.Audit.Level,
+			Retention:    cfg.Audit.Retention,
+			Mask:         cfg.Audit.Mask,
+			MaskKeys:     append([]string(nil), cfg.Audit.MaskKeys...),
+			MaskPatterns: append([]string(nil), cfg.Audit.MaskPatterns...),
+			Encrypt:      cfg.Audit.Encrypt,
+		}
+	}
+
+	var cryptoCfg *CryptoConfig
+	if cfg.Crypto != nil {
+		c := *cfg.Crypto
+		cryptoCfg = &c
+	}
+
+	var agentCfg *AgentConfig
+	if cfg.Agent != nil {
+		a := *cfg.Agent
+		agentCfg = &a
+	}
+
+	var hooksCfg *HooksConfig
+	if cfg.Hooks != nil {
+		h := *cfg.Hooks
+		hooksCfg = &h
+	}
+
+	var usageCfg *UsageConfig
+	if cfg.Usage != nil {
+		u := *cfg.Usage
+		usageCfg = &u
+	}
+
+	var warningsCfg *WarningsConfig
+	if cfg.Warnings != nil {
+		w := *cfg.Warnings
+		warningsCfg = &w
+	}
+
 	return &Config{
 		DefaultProvider: cfg.DefaultProvider,
 		Providers:       provs,
 		DefaultModels:   defaultModels,
 		DefaultHarness:  cfg.DefaultHarness,
 		CustomProviders: customProvs,
+		Audit:           auditCfg,
+		Crypto:          cryptoCfg,
+		Agent:           agentCfg,
+		StateDir:        cfg.StateDir,
+		TempDir:         cfg.TempDir,
+		Hooks:           hooksCfg,
+		Usage:           usageCfg,
+		Warnings:        warningsCfg,
+		Validation:      cfg.Validation,
+		overlay:         cfg.overlay,
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -185,3 +186,66 @@ providers:
 		t.Errorf("Concurrent write error: %v", err)
 	}
 }
+
+func TestConfigCache_CopiesAudit(t *testing.T) {
+	cache := NewConfigCache(5 * time.Minute)
+	tmpDir := t.TempDir()
+
+	configContent := `providers: {}
+audit:
+  enabled: true
+  events: [switch]
+  level: minimal
+crypto:
+  backend: gcpkms
+  key_id: projects/p/locations/l/keyRings/r/cryptoKeys/k
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte(configContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cache.Get(context.Background(), tmpDir); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	cfg, err := cache.Get(context.Background(), tmpDir)
+	if err != nil {
+		t.Fatalf("Get() cached error = %v", err)
+	}
+	if cfg.Audit == nil || !cfg.Audit.Enabled || cfg.Audit.Level != "minimal" || len(cfg.Audit.Events) != 1 {
+		t.Errorf("cached Audit = %+v, want copy of configured audit settings", cfg.Audit)
+	}
+	if cfg.Crypto == nil || cfg.Crypto.Backend != "gcpkms" {
+		t.Errorf("cached Crypto = %+v, want copy of configured crypto settings", cfg.Crypto)
+	}
+}
+
+func TestDeepCopyConfig_CopiesEveryProviderField(t *testing.T) {
+	p := Provider{
+		Name:              "Z.AI",
+		BaseURL:           "https://api.z.ai/api/anthropic",
+		Model:             "glm-5.1",
+		EnvVars:           []string{"A=1"},
+		EnvKey:            "ZAI_KEY",
+		Models:            map[string]string{"qwen": "glm-5.1-openai"},
+		MinHarnessVersion: map[string]string{"claude": "2.0.0"},
+		RevokeHook:        "revoke",
+		SettingsFiles:     []SettingsFile{{Name: "settings.json"}},
+		AuthStyle:         "bearer",
+		WrapperPing:       true,
+		AllowInsecure:     true,
+		Disabled:          true,
+		RunEnvAllow:       []string{"PATH"},
+		Qwen:              &QwenConfig{AuthType: "openai"},
+	}
+	v := reflect.ValueOf(p)
+	for i := range v.NumField() {
+		if v.Field(i).IsZero() {
+			t.Fatalf("test provider leaves %s unset; set it so the copy is checked", v.Type().Field(i).Name)
+		}
+	}
+
+	got := deepCopyConfig(&Config{Providers: map[string]Provider{"zai": p}}).Providers["zai"]
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("deepCopyConfig() provider = %+v, want %+v", got, p)
+	}
+}
@@ -1,9 +1,12 @@
 package config
 
 import (
+	stderrors "errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/dkmnx/kairo/internal/constants"
 	"github.com/dkmnx/kairo/internal/errors"
@@ -21,6 +24,10 @@ func ConfigDir() (string, error) {
 		return dir, nil
 	}
 
+	return platformConfigDir()
+}
+
+func platformConfigDir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", errors.WrapError(errors.ConfigError,
@@ -33,3 +40,106 @@ func ConfigDir() (string, error) {
 
 	return filepath.Join(home, ".config", "kairo"), nil
 }
+
+// DefaultStateDir returns the platform-specific directory for kairo's
+// mutable state: $XDG_STATE_HOME/kairo (~/.local/state/kairo when unset) on
+// Unix and %LOCALAPPDATA%\kairo on Windows.
+func DefaultStateDir() (string, error) {
+	if runtime.GOOS == constants.WindowsGOOS {
+		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+			return filepath.Join(dir, "kairo"), nil
+		}
+	} else if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "kairo"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.WrapError(errors.ConfigError,
+			"cannot determine home directory", err)
+	}
+	if runtime.GOOS == constants.WindowsGOOS {
+		return filepath.Join(home, "AppData", "Local", "kairo"), nil
+	}
+
+	return filepath.Join(home, ".local", "state", "kairo"), nil
+}
+
+// StateDir returns the directory holding mutable state (audit log, health
+// history, harness version records) for the config in configDir. In order:
+// state_dir in cfg, KAIRO_STATE_DIR, then DefaultStateDir when configDir is
+// the platform default. A config directory chosen with --config or
+// KAIRO_CONFIG_DIR keeps its state alongside the config so it stays
+// self-contained.
+func StateDir(configDir string, cfg *Config) (string, error) {
+	if cfg != nil && cfg.StateDir != "" {
+		return expandHome(cfg.StateDir)
+	}
+	if dir := os.Getenv("KAIRO_STATE_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	if def, err := platformConfigDir(); err == nil && filepath.Clean(configDir) == filepath.Clean(def) {
+		return DefaultStateDir()
+	}
+
+	return configDir, nil
+}
+
+// TempDir returns the directory the temporary auth directory is created in:
+// temp_dir in cfg when set, otherwise the system temp directory. Endpoint
+// security software often blocks running scripts from the system one.
+func TempDir(cfg *Config) (string, error) {
+	if cfg != nil && cfg.TempDir != "" {
+		return expandHome(cfg.TempDir)
+	}
+
+	return os.TempDir(), nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.WrapError(errors.ConfigError,
+			"cannot determine home directory", err)
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+// MigrateState moves the named files or directories from configDir to
+// stateDir, skipping names that are missing from configDir or already
+// present in stateDir. It returns the names it moved.
+func MigrateState(configDir, stateDir string, names []string) ([]string, error) {
+	if filepath.Clean(configDir) == filepath.Clean(stateDir) {
+		return nil, nil
+	}
+
+	var moved []string
+	for _, name := range names {
+		src := filepath.Join(configDir, name)
+		dst := filepath.Join(stateDir, name)
+		if _, err := os.Lstat(src); err != nil {
+			continue
+		}
+		if _, err := os.Lstat(dst); err == nil {
+			continue
+		} else if !stderrors.Is(err, fs.ErrNotExist) {
+			return moved, errors.FileError("failed to check state file", dst, err)
+		}
+
+		if err := os.MkdirAll(stateDir, constants.DirPermSecure); err != nil {
+			return moved, errors.FileError("failed to create state directory", stateDir, err)
+		}
+		if err := os.Rename(src, dst); err != nil {
+			return moved, errors.FileError("failed to move state file", src, err).
+				WithContext("destination", dst)
+		}
+		moved = append(moved, name)
+	}
+
+	return moved, nil
+}
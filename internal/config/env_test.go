@@ -64,3 +64,109 @@ func TestConfigDirConcurrentAccess(t *testing.T) {
 		}
 	})
 }
+
+func TestStateDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("XDG_STATE_HOME is not used on Windows")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("KAIRO_STATE_DIR", "")
+	t.Setenv("XDG_STATE_HOME", "")
+	defaultConfig := filepath.Join(home, ".config", "kairo")
+
+	tests := []struct {
+		name      string
+		configDir string
+		cfg       *Config
+		xdg       string
+		env       string
+		want      string
+	}{
+		{"default config uses ~/.local/state", defaultConfig, nil, "", "", filepath.Join(home, ".local", "state", "kairo")},
+		{"XDG_STATE_HOME", defaultConfig, nil, "/xdg/state", "", "/xdg/state/kairo"},
+		{"relative XDG_STATE_HOME ignored", defaultConfig, nil, "rel", "", filepath.Join(home, ".local", "state", "kairo")},
+		{"custom config dir keeps state", "/custom/kairo", nil, "/xdg/state", "", "/custom/kairo"},
+		{"KAIRO_STATE_DIR", "/custom/kairo", nil, "", "/env/state", "/env/state"},
+		{"state_dir wins", defaultConfig, &Config{StateDir: "~/state"}, "/xdg/state", "/env/state", filepath.Join(home, "state")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_STATE_HOME", tt.xdg)
+			t.Setenv("KAIRO_STATE_DIR", tt.env)
+
+			got, err := StateDir(tt.configDir, tt.cfg)
+			if err != nil {
+				t.Fatalf("StateDir() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("StateDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTempDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	tests := []struct {
+		name string
+		cfg  *Config
+		want string
+	}{
+		{"no config", nil, os.TempDir()},
+		{"unset", &Config{}, os.TempDir()},
+		{"temp_dir", &Config{TempDir: "/srv/kairo-tmp"}, "/srv/kairo-tmp"},
+		{"temp_dir under home", &Config{TempDir: "~/.kairo-tmp"}, filepath.Join(home, ".kairo-tmp")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TempDir(tt.cfg)
+			if err != nil {
+				t.Fatalf("TempDir() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("TempDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateState(t *testing.T) {
+	configDir := t.TempDir()
+	stateDir := filepath.Join(t.TempDir(), "state")
+
+	if err := os.WriteFile(filepath.Join(configDir, "audit.log"), []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(configDir, "health"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(stateDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(stateDir, "health"), []byte("newer"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := MigrateState(configDir, stateDir, []string{"audit.log", "health", "missing.json"})
+	if err != nil {
+		t.Fatalf("MigrateState() error = %v", err)
+	}
+	if len(moved) != 1 || moved[0] != "audit.log" {
+		t.Errorf("MigrateState() moved %v, want [audit.log]", moved)
+	}
+	if data, err := os.ReadFile(filepath.Join(stateDir, "audit.log")); err != nil || string(data) != "old" {
+		t.Errorf("audit.log in state dir = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "health")); err != nil {
+		t.Errorf("health/ should stay when the state dir already has one: %v", err)
+	}
+
+	if moved, err := MigrateState(configDir, configDir, []string{"health"}); err != nil || moved != nil {
+		t.Errorf("MigrateState(same dir) = %v, %v", moved, err)
+	}
+}
@@ -2,9 +2,9 @@
 package config
 
 import (
-	"bytes"
 	"context"
 	stderrors "errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -17,25 +17,174 @@ import (
 
 // Config represents the top-level kairo configuration file.
 type Config struct {
-	DefaultProvider string                                        `yaml:"default_provider"`
-	Providers       map[string]Provider                           `yaml:"providers"`
-	DefaultModels   map[string]string                             `yaml:"default_models"`
-	DefaultHarness  string                                        `yaml:"default_harness,omitempty"`
-	CustomProviders map[string]providers.CustomProviderDefinition `yaml:"custom_providers"`
+	DefaultProvider string                                        `yaml:"default_provider" doc:"Provider used when kairo runs without a provider name"`
+	Providers       map[string]Provider                           `yaml:"providers" doc:"Configured providers by name" key:"provider"`
+	DefaultModels   map[string]string                             `yaml:"default_models" doc:"Default model of each built-in provider, maintained by kairo for migrations" key:"provider"`
+	DefaultHarness  string                                        `yaml:"default_harness,omitempty" doc:"Harness started when --harness is not given" default:"claude" enum:"claude,qwen,pi,crush"`
+	CustomProviders map[string]providers.CustomProviderDefinition `yaml:"custom_providers" doc:"Providers that are not built in; an entry named after a built-in provider replaces it" key:"provider"`
+	Audit           *AuditConfig                                  `yaml:"audit,omitempty" doc:"Audit log settings"`
+	Crypto          *CryptoConfig                                 `yaml:"crypto,omitempty" doc:"How secrets.age is encrypted"`
+	Agent           *AgentConfig                                  `yaml:"agent,omitempty" doc:"How long and how often kairo agent lets the unlocked key be used, and its metrics endpoint"`
+	StateDir        string                                        `yaml:"state_dir,omitempty" doc:"Directory for the audit log, health history, and other state; ~/ is expanded"`
+	TempDir         string                                        `yaml:"temp_dir,omitempty" doc:"Directory for the temporary auth directory and wrapper script instead of the system temp directory; ~/ is expanded"`
+	Hooks           *HooksConfig                                  `yaml:"hooks,omitempty" doc:"Shell commands run on events"`
+	Usage           *UsageConfig                                  `yaml:"usage,omitempty" doc:"Token usage capture"`
+	Warnings        *WarningsConfig                               `yaml:"warnings,omitempty" doc:"How often repeated warnings are printed"`
+	// Validation set to "strict" makes every load reject unknown fields and
+	// mistyped values with their line and column, instead of assuming a
+	// newer kairo wrote them.
+	Validation string `yaml:"validation,omitempty" doc:"strict rejects unknown fields and mistyped values on every load" enum:"strict"`
+
+	// overlay is set when override files were merged in at load time.
+	overlay *overlay
+}
+
+// HooksConfig holds what kairo notifies on events. SecretAccess is a shell
+// command run whenever a provider's API key is decrypted for use;
+// HealthWebhook receives provider up and down events from health checks.
+type HooksConfig struct {
+	SecretAccess  string `yaml:"secret_access,omitempty" doc:"Shell command run whenever a provider's API key is decrypted for use"`
+	HealthWebhook string `yaml:"health_webhook,omitempty" doc:"URL that kairo status posts a JSON event to when a provider goes down or comes back up"`
+}
+
+// UsageConfig controls token usage capture. With Capture set, kairo
+// receives the harness's OpenTelemetry metrics on a loopback port during
+// each Claude Code session and records the tokens it used.
+type UsageConfig struct {
+	Capture bool `yaml:"capture,omitempty" doc:"Record the tokens each Claude Code session uses" default:"false"`
+}
+
+// WarningsConfig controls warnings that would otherwise repeat on every
+// command. Each is printed at most once per Interval, such as "24h" or
+// "7d"; "0" prints every one.
+type WarningsConfig struct {
+	Interval string `yaml:"interval,omitempty" doc:"How long a repeated warning stays quiet after it is printed, such as 24h or 7d; 0 prints every one" default:"24h"`
+}
+
+// CryptoConfig selects how the secrets file is encrypted. An empty Backend
+// uses the local age key; awskms and gcpkms envelope-encrypt the file with
+// the KMS key named by KeyID.
+type CryptoConfig struct {
+	Backend string `yaml:"backend" doc:"Encryption backend" default:"age" enum:"age,awskms,gcpkms"`
+	KeyID   string `yaml:"key_id,omitempty" doc:"KMS key that encrypts the secrets file's data key"`
+	Region  string `yaml:"region,omitempty" doc:"AWS region of the KMS key"`
+}
+
+// AgentConfig is the cache policy of `kairo agent`, which holds the
+// unlocked key of a passphrase-protected age.key. UnlockTTL, such as "15m",
+// is how long the agent keeps the key unless --ttl asks for less, and the
+// most --ttl may ask for. LockOnSleep wipes the key when the system wakes
+// from sleep. MaxUnlocksPerHour bounds the decryptions the agent performs
+// with the key in any hour. MetricsListen, such as "127.0.0.1:9464", is the
+// loopback address the agent serves Prometheus metrics on.
+type AgentConfig struct {
+	UnlockTTL         string `yaml:"unlock_ttl,omitempty" doc:"How long kairo agent keeps the unlocked key, and the longest --ttl it accepts, such as 15m" default:"1h"`
+	LockOnSleep       bool   `yaml:"lock_on_sleep,omitempty" doc:"Wipe the key and stop kairo agent when the system wakes from sleep" default:"false"`
+	MaxUnlocksPerHour int    `yaml:"max_unlocks_per_hour,omitempty" doc:"Most decryptions kairo agent performs with the key in any hour; 0 is unlimited" default:"0"`
+	MetricsListen     string `yaml:"metrics_listen,omitempty" doc:"Loopback address where kairo agent serves Prometheus metrics, such as 127.0.0.1:9464"`
+}
+
+// AuditConfig controls the audit log. Logging is off unless Enabled is set.
+// An empty Events list audits every event type. A non-empty Retention, such
+// as "90d", prunes older entries each time an entry is written. Mask selects
+// the built-in masking of entry details (strict by default); MaskKeys and
+// MaskPatterns add detail names and regular expressions to mask. Encrypt
+// writes each entry age-encrypted to the audit key.
+type AuditConfig struct {
+	Enabled      bool     `yaml:"enabled" doc:"Write the audit log" default:"false"`
+	Events       []string `yaml:"events,omitempty" doc:"Event types to audit; empty audits every type" enum:"switch,rotate,config,warning,reveal,escrow"`
+	Level        string   `yaml:"level,omitempty" doc:"How much detail entries hold" default:"normal" enum:"minimal,normal,verbose"`
+	Retention    string   `yaml:"retention,omitempty" doc:"How long entries are kept, such as 90d; older entries are pruned on each write"`
+	Mask         string   `yaml:"mask,omitempty" doc:"Built-in masking of entry details" default:"strict" enum:"strict,basic,off"`
+	MaskKeys     []string `yaml:"mask_keys,omitempty" doc:"Further detail names whose values are masked"`
+	MaskPatterns []string `yaml:"mask_patterns,omitempty" doc:"Regular expressions whose matches in details are masked"`
+	Encrypt      bool     `yaml:"encrypt,omitempty" doc:"Encrypt each entry to audit.key" default:"false"`
 }
 
 // Provider represents a single provider's configuration entry.
 type Provider struct {
-	Name    string   `yaml:"name"`
-	BaseURL string   `yaml:"base_url"`
-	Model   string   `yaml:"model"`
-	EnvVars []string `yaml:"env_vars"`
-	EnvKey  string   `yaml:"env_key,omitempty"`
+	Name    string   `yaml:"name" doc:"Display name"`
+	BaseURL string   `yaml:"base_url" doc:"Anthropic-compatible API base URL"`
+	Model   string   `yaml:"model" doc:"Model the harness uses"`
+	EnvVars []string `yaml:"env_vars" doc:"Extra KEY=value environment variables for the harness"`
+	EnvKey  string   `yaml:"env_key,omitempty" doc:"Variable the API key is passed in, instead of the one derived from the provider name"`
+	// Models maps a harness name to the model it is started with, for
+	// providers whose model names differ between harnesses. A harness
+	// missing from it uses Model.
+	Models map[string]string `yaml:"models,omitempty" doc:"Model for each harness, used instead of model when that harness is started" key:"harness"`
+	// MinHarnessVersion maps a harness name to the oldest version known to
+	// work with this provider. It is checked by `kairo doctor`.
+	MinHarnessVersion map[string]string `yaml:"min_harness_version,omitempty" doc:"Oldest version of each harness that works with the provider, checked by kairo doctor" key:"harness"`
+	// RevokeHook is a shell command run by `kairo rotate --provider` after a
+	// key swap, with the old key on stdin, to revoke it at the provider.
+	RevokeHook string `yaml:"revoke_hook,omitempty" doc:"Shell command kairo rotate --provider runs with the old key on stdin to revoke it"`
+	// SettingsFiles are rendered into the temporary auth directory for
+	// harnesses that read credentials from a file instead of the environment.
+	SettingsFiles []SettingsFile `yaml:"settings_files,omitempty" doc:"Credentials files rendered into the temporary auth directory for each run"`
+	// AuthStyle selects the header kairo's own requests and the Claude
+	// credential variable use for the API key: x-api-key, bearer, or both.
+	AuthStyle string `yaml:"auth_style,omitempty" doc:"Header that carries the API key" default:"both" enum:"x-api-key,bearer,both"`
+	// WrapperPing makes the wrapper probe the provider before it
+	// starts the harness and stop with a short message when the provider is
+	// unreachable or rejects the API key.
+	WrapperPing bool `yaml:"wrapper_ping,omitempty" doc:"Probe the provider before the harness starts and stop if it is unreachable or rejects the key" default:"false"`
+	// AllowInsecure accepts a plain HTTP base URL and one on localhost or a
+	// private network, for a gateway that is meant to be local, and skips
+	// the launch-time check that the host does not resolve to such an
+	// address.
+	AllowInsecure bool `yaml:"allow_insecure,omitempty" doc:"Accept a plain HTTP or private base URL for a gateway that is meant to be local" default:"false"`
+	// Disabled keeps the provider's config and API key but refuses to
+	// launch it, for a provider that is temporarily broken. It is set by
+	// `kairo providers disable` and cleared by `kairo providers enable`.
+	Disabled bool `yaml:"disabled,omitempty" doc:"Keep the provider and its API key but refuse to start harnesses with it" default:"false"`
+	// RunEnvAllow lists the variables of kairo's environment that a command
+	// started by `kairo run` receives. When set, every other variable is
+	// withheld, so an untrusted script cannot read unrelated secrets. A
+	// trailing * matches a prefix.
+	RunEnvAllow []string `yaml:"run_env_allow,omitempty" doc:"Variables of kairo's environment passed to commands started by kairo run; when set, all others are withheld"`
+	// Qwen adjusts how Qwen Code connects to this provider.
+	Qwen *QwenConfig `yaml:"qwen,omitempty" doc:"How Qwen Code connects to the provider"`
+}
+
+// ModelFor returns the model the provider is started with under harness h:
+// its entry in Models, or Model.
+func (p Provider) ModelFor(h string) string {
+	if model := p.Models[h]; model != "" {
+		return model
+	}
+
+	return p.Model
+}
+
+// QwenConfig holds the Qwen Code settings of a provider.
+type QwenConfig struct {
+	// AuthType is "anthropic" (the default) or "openai". With "openai" the
+	// key, base URL, and model are passed as OPENAI_* variables.
+	AuthType string `yaml:"auth_type,omitempty" doc:"Credential variables Qwen Code receives" default:"anthropic" enum:"anthropic,openai"`
+	// BaseURL replaces the provider's base_url for Qwen Code, such as the
+	// provider's OpenAI-compatible endpoint.
+	BaseURL string `yaml:"base_url,omitempty" doc:"Base URL Qwen Code uses instead of the provider's, such as an OpenAI-compatible endpoint"`
+	// WriteSettings renders a settings.json selecting the auth type and
+	// model into the temporary auth directory for each run.
+	WriteSettings bool `yaml:"write_settings,omitempty" doc:"Write a settings.json selecting the auth type and model for each run" default:"false"`
+}
+
+// SettingsFile is a templated credentials file written for a harness run.
+type SettingsFile struct {
+	// Name is the file name inside the temporary auth directory.
+	Name string `yaml:"name" doc:"File name inside the temporary auth directory"`
+	// Env is the environment variable set to the rendered file's path.
+	Env string `yaml:"env" doc:"Variable set to the rendered file's path"`
+	// Template is a Go text/template with .Provider, .APIKey, .BaseURL, and
+	// .Model, plus a json function for quoting string values.
+	Template string `yaml:"template" doc:"Go text/template with .Provider, .APIKey, .BaseURL, .Model, and a json function"`
+	// Harness limits the file to one harness; empty means every harness.
+	Harness string `yaml:"harness,omitempty" doc:"Harness the file is written for; empty means every harness" enum:"claude,qwen,pi,crush"`
 }
 
 func migrateConfigFile(ctx context.Context, configDir string) (bool, error) {
 	oldConfigPath := filepath.Join(configDir, "config")
-	newConfigPath := filepath.Join(configDir, "config.yaml")
+	newConfigPath := filepath.Join(configDir, BaseFileName)
 
 	if err := errors.CheckContext(ctx); err != nil {
 		return false, err
@@ -86,17 +235,26 @@ func migrateConfigFile(ctx context.Context, configDir string) (bool, error) {
 	return true, nil
 }
 
-// LoadConfig reads and parses the configuration file from configDir.
+// LoadConfig reads and parses the configuration file from configDir, merging
+// any override files (see OverrideFiles) over it.
 func LoadConfig(ctx context.Context, configDir string) (*Config, error) {
-	configPath := filepath.Join(configDir, "config.yaml")
+	cfg, _, err := LoadConfigOrigins(ctx, configDir)
+
+	return cfg, err
+}
+
+// LoadConfigOrigins is LoadConfig that also reports which file set each
+// field: config.yaml, a conf.d file, or config.override.yaml.
+func LoadConfigOrigins(ctx context.Context, configDir string) (*Config, Origins, error) {
+	configPath := filepath.Join(configDir, BaseFileName)
 
 	if err := errors.CheckContext(ctx); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	_, migrateErr := migrateConfigFile(ctx, configDir)
 	if migrateErr != nil {
-		return nil, errors.WrapError(errors.ConfigError,
+		return nil, nil, errors.WrapError(errors.ConfigError,
 			"failed to migrate configuration file", migrateErr).
 			WithContext("old_path", filepath.Join(configDir, "config")).
 			WithContext("new_path", configPath).
@@ -104,35 +262,62 @@ func LoadConfig(ctx context.Context, configDir string) (*Config, error) {
 	}
 
 	if err := errors.CheckContext(ctx); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if stderrors.Is(err, fs.ErrNotExist) {
-			return nil, errors.ErrConfigNotFound
+			return nil, nil, errors.ErrConfigNotFound
 		}
 
-		return nil, errors.WrapError(errors.FileSystemError,
+		return nil, nil, errors.WrapError(errors.FileSystemError,
 			"failed to read configuration file", err).
 			WithContext("path", configPath)
 	}
 
-	var cfg Config
-	decoder := yaml.NewDecoder(bytes.NewReader(data))
-	decoder.KnownFields(true)
-	if err := decoder.Decode(&cfg); err != nil {
-		if isUnknownFieldError(err) {
-			return nil, errors.WrapError(errors.ConfigError,
-				"configuration file contains field(s) not recognized by this version of kairo", err).
-				WithContext("path", configPath).
-				WithContext("hint", "your installed kairo binary is outdated, please upgrade")
+	base, err := parseMapping(data, configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files, err := OverrideFiles(configDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	layers, err := readOverrides(configDir, files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkLayers(ctx, configPath, base, layers); err != nil {
+		return nil, nil, err
+	}
+
+	cfg, err := decodeConfig(data, configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	origins := make(Origins)
+	recordOrigins(base, "", BaseFileName, origins)
+	delete(origins, "")
+
+	if len(layers) > 0 {
+		for _, layer := range layers {
+			if _, err := decodeConfig(layer.data, layer.path); err != nil {
+				return nil, nil, err
+			}
 		}
 
-		return nil, errors.WrapError(errors.ConfigError,
-			"failed to parse configuration file (invalid YAML)", err).
-			WithContext("path", configPath).
-			WithContext("hint", "check YAML syntax and indentation")
+		merged := applyOverrides(base, layers, origins)
+		var mergedCfg Config
+		if err := merged.Decode(&mergedCfg); err != nil {
+			return nil, nil, errors.WrapError(errors.ConfigError,
+				"failed to merge override files", err).
+				WithContext("path", configDir)
+		}
+		cfg = &mergedCfg
+		cfg.overlay = &overlay{base: base, merged: merged}
 	}
 
 	if cfg.Providers == nil {
@@ -150,7 +335,43 @@ func LoadConfig(ctx context.Context, configDir string) (*Config, error) {
 
 	cfg.validate()
 
-	return &cfg, nil
+	return cfg, origins, nil
+}
+
+// checkLayers runs the strict schema check over config.yaml and each override
+// file when strict validation is on, either through the context or through
+// `validation: strict` in the merged files.
+func checkLayers(ctx context.Context, configPath string, base *yaml.Node, layers []overrideLayer) error {
+	roots := []*yaml.Node{base}
+	paths := []string{configPath}
+	names := []string{BaseFileName}
+	for _, layer := range layers {
+		roots = append(roots, layer.root)
+		paths = append(paths, layer.path)
+		names = append(names, layer.source)
+	}
+
+	mode := validationMode(roots...)
+	if mode != "" && mode != ValidationStrict {
+		return errors.NewError(errors.ConfigError,
+			fmt.Sprintf("invalid validation mode %q", mode)).
+			WithContext("path", configPath).
+			WithContext("hint", "set 'validation: strict' or remove the field")
+	}
+	if mode != ValidationStrict && !strictValidation(ctx) {
+		return nil
+	}
+
+	for i, root := range roots {
+		if err := checkSchema(root, names[i]); err != nil {
+			return errors.WrapError(errors.ConfigError,
+				"configuration file does not match the schema", err).
+				WithContext("path", paths[i]).
+				WithContext("hint", "fix the fields listed; see docs/reference/configuration.md for the schema")
+		}
+	}
+
+	return nil
 }
 
 // reconcileDefaultModels prunes stale entries for providers no longer in the
@@ -197,12 +418,17 @@ func (c *Config) validate() {
 
 // SaveConfig writes the configuration to configDir atomically.
 func SaveConfig(ctx context.Context, configDir string, cfg *Config) error {
+	return WriteConfigFile(ctx, filepath.Join(configDir, BaseFileName), cfg)
+}
+
+// WriteConfigFile writes the configuration to configPath atomically, for
+// callers that stage config.yaml under another name before swapping it in.
+func WriteConfigFile(ctx context.Context, configPath string, cfg *Config) error {
 	if err := errors.CheckContext(ctx); err != nil {
 		return err
 	}
 
-	configPath := filepath.Join(configDir, "config.yaml")
-	data, err := yaml.Marshal(cfg)
+	data, err := marshalConfig(cfg)
 	if err != nil {
 		return errors.WrapError(errors.ConfigError,
 			"failed to marshal configuration to YAML", err).
@@ -229,3 +455,36 @@ func SaveConfig(ctx context.Context, configDir string, cfg *Config) error {
 
 	return nil
 }
+
+// CanonicalConfig decodes data, the contents of a config.yaml at path, and
+// encodes it again the way SaveConfig writes it: fields in declaration
+// order, maps sorted by key, and no comments. Files holding the same
+// settings encode to the same bytes.
+func CanonicalConfig(data []byte, path string) ([]byte, error) {
+	cfg, err := decodeConfig(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(cfg)
+}
+
+// marshalConfig encodes cfg for config.yaml. When cfg was loaded with
+// override files, fields still holding their merged value are written with
+// their config.yaml value instead, so overrides stay out of the base file.
+func marshalConfig(cfg *Config) ([]byte, error) {
+	if cfg.overlay == nil {
+		return yaml.Marshal(cfg)
+	}
+
+	var out yaml.Node
+	if err := out.Encode(cfg); err != nil {
+		return nil, err
+	}
+	node := restoreBase(&out, cfg.overlay.merged, cfg.overlay.base)
+	if node == nil {
+		node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	return yaml.Marshal(node)
+}
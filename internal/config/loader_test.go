@@ -331,3 +331,17 @@ func TestReconcileDefaultModels_NilProvidersNoPanic(t *testing.T) {
 		t.Errorf("reconcileDefaultModels should prune all entries when Providers is nil, got %d", len(cfg.DefaultModels))
 	}
 }
+
+func TestProviderModelFor(t *testing.T) {
+	p := Provider{Model: "glm-4.7", Models: map[string]string{"qwen": "glm-4.7-openai", "pi": ""}}
+
+	tests := map[string]string{"qwen": "glm-4.7-openai", "claude": "glm-4.7", "pi": "glm-4.7"}
+	for h, want := range tests {
+		if got := p.ModelFor(h); got != want {
+			t.Errorf("ModelFor(%q) = %q, want %q", h, got, want)
+		}
+	}
+	if got := (Provider{Model: "glm-4.7"}).ModelFor("qwen"); got != "glm-4.7" {
+		t.Errorf("ModelFor() without models = %q, want the model", got)
+	}
+}
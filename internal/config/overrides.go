@@ -0,0 +1,343 @@
+package config
+
+import (
+	"bytes"
+	stderrors "errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// BaseFileName is the main configuration file in the config directory.
+	BaseFileName = "config.yaml"
+	// OverrideFileName is merged over config.yaml and every conf.d file.
+	OverrideFileName = "config.override.yaml"
+	// OverrideDirName holds *.yaml files merged over config.yaml in lexical
+	// order, before OverrideFileName.
+	OverrideDirName = "conf.d"
+	// DefaultOrigin is the origin of fields that no file sets.
+	DefaultOrigin = "default"
+)
+
+// Origins maps a dotted field path, such as "providers.zai.base_url", to the
+// file that set it, relative to the config directory.
+type Origins map[string]string
+
+// overlay keeps the parsed base file and the merged result of a load with
+// override files, so SaveConfig can write back only what changed.
+type overlay struct {
+	base   *yaml.Node
+	merged *yaml.Node
+}
+
+// OverrideFiles returns the override files present in configDir in merge
+// order: conf.d/*.yaml sorted by name, then config.override.yaml.
+func OverrideFiles(configDir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(configDir, OverrideDirName, "*.yaml"))
+	if err != nil {
+		return nil, errors.WrapError(errors.FileSystemError,
+			"failed to list override files", err)
+	}
+	slices.Sort(matches)
+
+	overridePath := filepath.Join(configDir, OverrideFileName)
+	if _, err := os.Stat(overridePath); err == nil {
+		matches = append(matches, overridePath)
+	} else if !stderrors.Is(err, fs.ErrNotExist) {
+		return nil, errors.WrapError(errors.FileSystemError,
+			"failed to check override file", err).
+			WithContext("path", overridePath)
+	}
+
+	return matches, nil
+}
+
+// parseMapping parses data as a YAML document whose top level is a mapping.
+// An empty document yields an empty mapping.
+func parseMapping(data []byte, path string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.WrapError(errors.ConfigError,
+			"failed to parse configuration file (invalid YAML)", err).
+			WithContext("path", path).
+			WithContext("hint", "check YAML syntax and indentation")
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.NewError(errors.ConfigError,
+			"configuration file must be a YAML mapping").
+			WithContext("path", path)
+	}
+
+	return root, nil
+}
+
+// decodeConfig strictly decodes data, rejecting fields this version of kairo
+// does not know.
+func decodeConfig(data []byte, path string) (*Config, error) {
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil {
+		if isUnknownFieldError(err) {
+			return nil, errors.WrapError(errors.ConfigError,
+				"configuration file contains field(s) not recognized by this version of kairo", err).
+				WithContext("path", path).
+				WithContext("hint", "your installed kairo binary is outdated, please upgrade")
+		}
+
+		return nil, errors.WrapError(errors.ConfigError,
+			"failed to parse configuration file (invalid YAML)", err).
+			WithContext("path", path).
+			WithContext("hint", "check YAML syntax and indentation")
+	}
+
+	return &cfg, nil
+}
+
+// overrideLayer is one parsed override file.
+type overrideLayer struct {
+	path   string
+	source string
+	data   []byte
+	root   *yaml.Node
+}
+
+// readOverrides reads and parses the override files, skipping empty ones.
+func readOverrides(configDir string, files []string) ([]overrideLayer, error) {
+	var layers []overrideLayer
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.WrapError(errors.FileSystemError,
+				"failed to read override file", err).
+				WithContext("path", path)
+		}
+		root, err := parseMapping(data, path)
+		if err != nil {
+			return nil, err
+		}
+		if len(root.Content) == 0 {
+			continue
+		}
+
+		source, err := filepath.Rel(configDir, path)
+		if err != nil {
+			source = path
+		}
+		layers = append(layers, overrideLayer{path: path, source: filepath.ToSlash(source), data: data, root: root})
+	}
+
+	return layers, nil
+}
+
+// applyOverrides merges each override layer over base, recording the file
+// that set each field in origins. Mappings merge key by key; any other value,
+// including a list, replaces the value below it.
+func applyOverrides(base *yaml.Node, layers []overrideLayer, origins Origins) *yaml.Node {
+	merged := cloneNode(base)
+	for _, layer := range layers {
+		mergeNode(merged, layer.root, "", layer.source, origins)
+	}
+
+	return merged
+}
+
+// mergeNode merges the mapping src into the mapping dst.
+func mergeNode(dst, src *yaml.Node, prefix, source string, origins Origins) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		path := joinPath(prefix, key.Value)
+
+		existing := mappingValue(dst, key.Value)
+		if existing != nil && existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			mergeNode(existing, value, path, source, origins)
+
+			continue
+		}
+
+		for p := range origins {
+			if p == path || strings.HasPrefix(p, path+".") {
+				delete(origins, p)
+			}
+		}
+		recordOrigins(value, path, source, origins)
+
+		if existing != nil {
+			*existing = *cloneNode(value)
+
+			continue
+		}
+		dst.Content = append(dst.Content, cloneNode(key), cloneNode(value))
+	}
+}
+
+// recordOrigins sets source as the origin of every leaf under node.
+func recordOrigins(node *yaml.Node, prefix, source string, origins Origins) {
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		origins[prefix] = source
+
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		recordOrigins(node.Content[i+1], joinPath(prefix, node.Content[i].Value), source, origins)
+	}
+}
+
+// restoreBase returns the node to write for a field whose saved value is out,
+// whose merged value at load time was merged, and whose config.yaml value was
+// base. Fields left as loaded keep their config.yaml value, so values from
+// override files are not copied into config.yaml. A nil result drops the
+// field.
+func restoreBase(out, merged, base *yaml.Node) *yaml.Node {
+	if merged == nil {
+		return out
+	}
+	if nodesEqual(out, merged) {
+		return base
+	}
+	if out.Kind != yaml.MappingNode || merged.Kind != yaml.MappingNode {
+		return out
+	}
+
+	result := &yaml.Node{Kind: yaml.MappingNode, Tag: out.Tag, Style: out.Style}
+	for i := 0; i+1 < len(out.Content); i += 2 {
+		key := out.Content[i]
+		var baseValue *yaml.Node
+		if base != nil && base.Kind == yaml.MappingNode {
+			baseValue = mappingValue(base, key.Value)
+		}
+		value := restoreBase(out.Content[i+1], mappingValue(merged, key.Value), baseValue)
+		if value != nil {
+			result.Content = append(result.Content, key, value)
+		}
+	}
+
+	return result
+}
+
+// nodesEqual reports whether a and b hold the same YAML value, ignoring style
+// and mapping key order.
+func nodesEqual(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || len(a.Content) != len(b.Content) {
+		return false
+	}
+
+	switch a.Kind {
+	case yaml.ScalarNode:
+		return a.ShortTag() == b.ShortTag() && a.Value == b.Value
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(a.Content); i += 2 {
+			other := mappingValue(b, a.Content[i].Value)
+			if other == nil || !nodesEqual(a.Content[i+1], other) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		for i := range a.Content {
+			if !nodesEqual(a.Content[i], b.Content[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// mappingValue returns the value for key in the mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func cloneNode(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		c.Content[i] = cloneNode(child)
+	}
+
+	return &c
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+// Field is one leaf value of a configuration and the file that set it.
+type Field struct {
+	Path   string
+	Value  string
+	Origin string
+}
+
+// Fields lists the leaf values of cfg in schema order, each with the file
+// that set it or DefaultOrigin. Lists and empty mappings are single fields
+// rendered in YAML flow style.
+func (o Origins) Fields(cfg *Config) ([]Field, error) {
+	var root yaml.Node
+	if err := root.Encode(cfg); err != nil {
+		return nil, errors.WrapError(errors.ConfigError,
+			"failed to encode configuration", err)
+	}
+
+	var fields []Field
+	var walk func(node *yaml.Node, path string) error
+	walk = func(node *yaml.Node, path string) error {
+		if node.Kind == yaml.MappingNode && len(node.Content) > 0 {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if err := walk(node.Content[i+1], joinPath(path, node.Content[i].Value)); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		value := node.Value
+		if node.Kind != yaml.ScalarNode {
+			flow := cloneNode(node)
+			flow.Style = yaml.FlowStyle
+			data, err := yaml.Marshal(flow)
+			if err != nil {
+				return err
+			}
+			value = strings.TrimSpace(string(data))
+		}
+
+		origin, ok := o[path]
+		if !ok {
+			origin = DefaultOrigin
+		}
+		fields = append(fields, Field{Path: path, Value: value, Origin: origin})
+
+		return nil
+	}
+	if err := walk(&root, ""); err != nil {
+		return nil, errors.WrapError(errors.ConfigError,
+			"failed to encode configuration", err)
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const overrideBaseConfig = `default_provider: zai
+providers:
+  zai:
+    name: Z.AI
+    base_url: https://api.z.ai/api/anthropic
+    model: glm-5.1
+    env_vars:
+      - A=1
+`
+
+func TestLoadConfigOrigins_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, BaseFileName), overrideBaseConfig)
+	writeConfigFile(t, filepath.Join(dir, OverrideDirName, "20-corp.yaml"), `providers:
+  zai:
+    base_url: https://proxy.corp.example/zai
+    model: glm-4.7
+`)
+	writeConfigFile(t, filepath.Join(dir, OverrideDirName, "10-env.yaml"), `providers:
+  zai:
+    env_vars:
+      - B=2
+`)
+	writeConfigFile(t, filepath.Join(dir, OverrideFileName), `providers:
+  zai:
+    model: glm-5.1-air
+`)
+
+	cfg, origins, err := LoadConfigOrigins(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zai := cfg.Providers["zai"]
+	if zai.BaseURL != "https://proxy.corp.example/zai" || zai.Model != "glm-5.1-air" || zai.Name != "Z.AI" {
+		t.Errorf("merged provider = %+v", zai)
+	}
+	if len(zai.EnvVars) != 1 || zai.EnvVars[0] != "B=2" {
+		t.Errorf("EnvVars = %v, want lists replaced by the override", zai.EnvVars)
+	}
+
+	want := map[string]string{
+		"default_provider":       BaseFileName,
+		"providers.zai.name":     BaseFileName,
+		"providers.zai.base_url": "conf.d/20-corp.yaml",
+		"providers.zai.env_vars": "conf.d/10-env.yaml",
+		"providers.zai.model":    OverrideFileName,
+	}
+	for path, origin := range want {
+		if origins[path] != origin {
+			t.Errorf("origins[%q] = %q, want %q", path, origins[path], origin)
+		}
+	}
+}
+
+func TestLoadConfig_OverrideUnknownField(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, BaseFileName), overrideBaseConfig)
+	writeConfigFile(t, filepath.Join(dir, OverrideFileName), "not_a_field: true\n")
+
+	_, err := LoadConfig(context.Background(), dir)
+	if err == nil || !strings.Contains(err.Error(), "not recognized") {
+		t.Fatalf("LoadConfig() error = %v, want unknown field error", err)
+	}
+}
+
+func TestSaveConfig_KeepsOverridesOutOfBase(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, BaseFileName), overrideBaseConfig)
+	writeConfigFile(t, filepath.Join(dir, OverrideFileName), `default_harness: qwen
+providers:
+  zai:
+    base_url: https://proxy.corp.example/zai
+`)
+
+	cfg, err := LoadConfig(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zai := cfg.Providers["zai"]
+	zai.Model = "glm-4.7"
+	cfg.Providers["zai"] = zai
+	if err := SaveConfig(ctx, dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, BaseFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := string(data)
+	if strings.Contains(saved, "proxy.corp.example") || strings.Contains(saved, "qwen") {
+		t.Errorf("override values written to config.yaml:\n%s", saved)
+	}
+	if !strings.Contains(saved, "https://api.z.ai/api/anthropic") || !strings.Contains(saved, "glm-4.7") {
+		t.Errorf("config.yaml lost base or changed values:\n%s", saved)
+	}
+
+	reloaded, err := LoadConfig(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Providers["zai"]; got.BaseURL != "https://proxy.corp.example/zai" || got.Model != "glm-4.7" {
+		t.Errorf("reloaded provider = %+v", got)
+	}
+}
+
+func TestOriginsFields(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, BaseFileName), overrideBaseConfig)
+	writeConfigFile(t, filepath.Join(dir, OverrideFileName), "default_harness: qwen\n")
+
+	cfg, origins, err := LoadConfigOrigins(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := origins.Fields(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]Field, len(fields))
+	for _, f := range fields {
+		got[f.Path] = f
+	}
+	if f := got["default_harness"]; f.Value != "qwen" || f.Origin != OverrideFileName {
+		t.Errorf("default_harness field = %+v", f)
+	}
+	if f := got["providers.zai.env_vars"]; f.Value != "[A=1]" || f.Origin != BaseFileName {
+		t.Errorf("env_vars field = %+v", f)
+	}
+	if f := got["default_models.zai"]; f.Origin != DefaultOrigin {
+		t.Errorf("derived default_models field = %+v, want origin %q", f, DefaultOrigin)
+	}
+}
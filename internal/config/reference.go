@@ -0,0 +1,238 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"iter"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SchemaURL is where the JSON Schema for config.yaml is published, for
+// editors to validate and complete the file against.
+const SchemaURL = "https://raw.githubusercontent.com/dkmnx/kairo/main/docs/reference/config.schema.json"
+
+// The configuration struct tags that document a field, next to its yaml
+// tag: doc describes it, default gives the value used when it is unset,
+// enum lists the values it accepts, separated by commas, and key names the
+// keys of a mapping, such as "provider".
+const (
+	tagDoc     = "doc"
+	tagDefault = "default"
+	tagEnum    = "enum"
+	tagKey     = "key"
+)
+
+// SchemaField documents one configuration field.
+type SchemaField struct {
+	// Path is the field's place in config.yaml, such as
+	// "providers.<provider>.base_url" or "providers.<provider>.settings_files[].name".
+	Path        string
+	Type        string
+	Default     string
+	Enum        []string
+	Description string
+}
+
+// SchemaFields lists every configuration field, depth first in the order
+// the configuration types declare them.
+func SchemaFields() []SchemaField {
+	var fields []SchemaField
+	collectFields(reflect.TypeFor[Config](), "", &fields)
+
+	return fields
+}
+
+func collectFields(t reflect.Type, prefix string, fields *[]SchemaField) {
+	for f := range documentedFields(t) {
+		path := joinPath(prefix, f.name)
+		*fields = append(*fields, SchemaField{
+			Path:        path,
+			Type:        typeName(f.field.Type),
+			Default:     f.field.Tag.Get(tagDefault),
+			Enum:        f.enum(),
+			Description: f.field.Tag.Get(tagDoc),
+		})
+
+		elem, suffix := f.field.Type, ""
+		for {
+			elem = derefType(elem)
+			if elem.Kind() == reflect.Map {
+				suffix += ".<" + f.key() + ">"
+			} else if elem.Kind() == reflect.Slice {
+				suffix += "[]"
+			} else {
+				break
+			}
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Struct {
+			collectFields(elem, path+suffix, fields)
+		}
+	}
+}
+
+// JSONSchema returns the configuration schema as a JSON Schema (draft
+// 2020-12) document.
+func JSONSchema() ([]byte, error) {
+	schema := structSchema(reflect.TypeFor[Config]())
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaURL
+	schema["title"] = "kairo " + BaseFileName
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	for f := range documentedFields(t) {
+		s := typeSchema(f.field.Type, f.enum())
+		if f.field.Type.Kind() == reflect.Pointer {
+			// An optional section may be left empty, which YAML reads as null.
+			s["type"] = []string{s["type"].(string), "null"}
+		}
+		if doc := f.field.Tag.Get(tagDoc); doc != "" {
+			s["description"] = doc
+		}
+		if def, ok := f.field.Tag.Lookup(tagDefault); ok {
+			s["default"] = defaultValue(f.field.Type, def)
+		}
+		properties[f.name] = s
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema returns the schema of a value of type t. enum applies to the
+// strings t holds, directly or as list items.
+func typeSchema(t reflect.Type, enum []string) map[string]any {
+	t = derefType(t)
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), nil)}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), enum)}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		s := map[string]any{"type": "string"}
+		if len(enum) > 0 {
+			s["enum"] = enum
+		}
+
+		return s
+	}
+}
+
+// defaultValue converts a default tag to the JSON value of type t.
+func defaultValue(t reflect.Type, def string) any {
+	switch derefType(t).Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(def); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(def, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(def, 64); err == nil {
+			return n
+		}
+	}
+
+	return def
+}
+
+// typeName describes t for the Markdown reference.
+func typeName(t reflect.Type) string {
+	t = derefType(t)
+	switch t.Kind() {
+	case reflect.Struct:
+		return "mapping"
+	case reflect.Map:
+		return "map of " + typeName(t.Elem())
+	case reflect.Slice:
+		return "list of " + typeName(t.Elem())
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// documentedField is an exported struct field with its YAML name.
+type documentedField struct {
+	name  string
+	field reflect.StructField
+}
+
+func (f documentedField) enum() []string {
+	enum := f.field.Tag.Get(tagEnum)
+	if enum == "" {
+		return nil
+	}
+
+	return strings.Split(enum, ",")
+}
+
+func (f documentedField) key() string {
+	if key := f.field.Tag.Get(tagKey); key != "" {
+		return key
+	}
+
+	return "name"
+}
+
+// documentedFields yields t's fields as config.yaml spells them, in
+// declaration order, skipping those YAML ignores.
+func documentedFields(t reflect.Type) iter.Seq[documentedField] {
+	return func(yield func(documentedField) bool) {
+		for f := range t.Fields() {
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			if !yield(documentedField{name: name, field: f}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSchemaFields_Documented(t *testing.T) {
+	fields := SchemaFields()
+	paths := make([]string, 0, len(fields))
+	for _, f := range fields {
+		paths = append(paths, f.Path)
+		if f.Description == "" {
+			t.Errorf("%s has no doc tag", f.Path)
+		}
+	}
+
+	for _, want := range []string{
+		"default_harness",
+		"providers.<provider>.base_url",
+		"providers.<provider>.settings_files[].template",
+		"providers.<provider>.qwen.auth_type",
+		"custom_providers.<provider>.min_key_entropy",
+		"audit.mask_patterns",
+		"usage.capture",
+	} {
+		if !slices.Contains(paths, want) {
+			t.Errorf("SchemaFields() has no %s", want)
+		}
+	}
+}
+
+func TestSchemaFields_Types(t *testing.T) {
+	byPath := map[string]SchemaField{}
+	for _, f := range SchemaFields() {
+		byPath[f.Path] = f
+	}
+
+	tests := map[string]string{
+		"providers":                                  "map of mapping",
+		"providers.<provider>.env_vars":              "list of string",
+		"providers.<provider>.min_harness_version":   "map of string",
+		"providers.<provider>.wrapper_ping":          "boolean",
+		"custom_providers.<provider>.min_key_length": "integer",
+		"audit": "mapping",
+	}
+	for path, want := range tests {
+		if got := byPath[path].Type; got != want {
+			t.Errorf("%s type = %q, want %q", path, got, want)
+		}
+	}
+	if f := byPath["default_harness"]; f.Default != "claude" || !slices.Contains(f.Enum, "crush") {
+		t.Errorf("default_harness = %+v, want default claude and the harnesses as values", f)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		ID                   string `json:"$id"`
+		AdditionalProperties bool   `json:"additionalProperties"`
+		Properties           map[string]struct {
+			Type                 any             `json:"type"`
+			Enum                 []string        `json:"enum"`
+			Default              any             `json:"default"`
+			AdditionalProperties json.RawMessage `json:"additionalProperties"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not JSON: %v", err)
+	}
+
+	if schema.ID != SchemaURL || schema.AdditionalProperties {
+		t.Errorf("$id = %q, additionalProperties = %v, want %s and unknown fields rejected",
+			schema.ID, schema.AdditionalProperties, SchemaURL)
+	}
+	if h := schema.Properties["default_harness"]; h.Default != "claude" || len(h.Enum) != 4 {
+		t.Errorf("default_harness = %+v", h)
+	}
+	if a := schema.Properties["audit"]; !slices.Equal(toStrings(a.Type), []string{"object", "null"}) {
+		t.Errorf("audit type = %v, want an object or null", a.Type)
+	}
+	if p := schema.Properties["providers"]; !bytes.Contains(p.AdditionalProperties, []byte(`"base_url"`)) {
+		t.Errorf("providers does not describe its entries: %s", p.AdditionalProperties)
+	}
+}
+
+// TestJSONSchema_Published keeps the schema editors fetch from SchemaURL in
+// step with the configuration types.
+func TestJSONSchema_Published(t *testing.T) {
+	want, err := JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "..", "docs", "reference", "config.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("docs/reference/config.schema.json is out of date; run 'just schema'")
+	}
+}
+
+func toStrings(v any) []string {
+	list, _ := v.([]any)
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		s, _ := item.(string)
+		out = append(out, s)
+	}
+
+	return out
+}
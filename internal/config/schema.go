@@ -0,0 +1,220 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationStrict is the `validation` value that turns on strict schema
+// checks for every load.
+const ValidationStrict = "strict"
+
+type strictValidationKey struct{}
+
+// WithStrictValidation returns a context under which LoadConfig checks the
+// configuration strictly, as if `validation: strict` were set.
+func WithStrictValidation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, strictValidationKey{}, true)
+}
+
+func strictValidation(ctx context.Context) bool {
+	strict, _ := ctx.Value(strictValidationKey{}).(bool)
+
+	return strict
+}
+
+// SchemaIssue is one field that does not match the configuration schema.
+type SchemaIssue struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+// SchemaError lists every schema issue found in one configuration file.
+type SchemaError struct {
+	File   string
+	Issues []SchemaIssue
+}
+
+func (e *SchemaError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		msgs[i] = fmt.Sprintf("%s:%d:%d: %s", e.File, issue.Line, issue.Column, issue.Message)
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// checkSchema compares the mapping root against the Config type and returns
+// a *SchemaError listing unknown fields, with the closest known name, and
+// values whose YAML kind cannot decode into the field's type.
+func checkSchema(root *yaml.Node, file string) error {
+	var issues []SchemaIssue
+	checkNode(root, reflect.TypeFor[Config](), "", &issues)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	return &SchemaError{File: file, Issues: issues}
+}
+
+func checkNode(node *yaml.Node, t reflect.Type, path string, issues *[]SchemaIssue) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	mismatch := func(want string) {
+		*issues = append(*issues, SchemaIssue{
+			Line:   node.Line,
+			Column: node.Column,
+			Message: fmt.Sprintf("%s: expected %s, got %s",
+				displayPath(path), want, describeNode(node)),
+		})
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			mismatch("a mapping")
+
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			field, ok := fields[key.Value]
+			if !ok {
+				msg := fmt.Sprintf("unknown field %q in %s", key.Value, displayPath(path))
+				if suggestion := closestField(key.Value, fields); suggestion != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+				}
+				*issues = append(*issues, SchemaIssue{Line: key.Line, Column: key.Column, Message: msg})
+
+				continue
+			}
+			checkNode(node.Content[i+1], field, joinPath(path, key.Value), issues)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			mismatch("a mapping")
+
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			checkNode(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), issues)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			mismatch("a list")
+
+			return
+		}
+		for i, item := range node.Content {
+			checkNode(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), issues)
+		}
+	case reflect.String:
+		if node.Kind != yaml.ScalarNode {
+			mismatch("a string")
+		}
+	case reflect.Bool:
+		if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!bool" {
+			mismatch("true or false")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!int" {
+			mismatch("an integer")
+		}
+	case reflect.Float32, reflect.Float64:
+		if node.Kind != yaml.ScalarNode || (node.ShortTag() != "!!float" && node.ShortTag() != "!!int") {
+			mismatch("a number")
+		}
+	}
+}
+
+// yamlFields maps the YAML names of t's exported fields to their types.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for f := range documentedFields(t) {
+		fields[f.name] = f.field.Type
+	}
+
+	return fields
+}
+
+// closestField returns the known field name within two edits of name, or ""
+// when there is none.
+func closestField(name string, fields map[string]reflect.Type) string {
+	best, bestDist := "", 3
+	for candidate := range fields {
+		d := editDistance(strings.ToLower(name), candidate)
+		if d < bestDist || (d == bestDist && candidate < best) {
+			best, bestDist = candidate, d
+		}
+	}
+
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	default:
+		return fmt.Sprintf("%q", node.Value)
+	}
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "top level"
+	}
+
+	return path
+}
+
+// validationMode returns the `validation` value of the last file in layers
+// that sets it.
+func validationMode(layers ...*yaml.Node) string {
+	mode := ""
+	for _, layer := range layers {
+		if v := mappingValue(layer, "validation"); v != nil && v.Kind == yaml.ScalarNode {
+			mode = v.Value
+		}
+	}
+
+	return mode
+}
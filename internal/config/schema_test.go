@@ -0,0 +1,101 @@
+package config
+
+import (
+	"context"
+	stderrors "errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig_StrictValidation(t *testing.T) {
+	const typoConfig = `validation: strict
+providers:
+  zai:
+    name: Z.AI
+    basurl: https://api.z.ai/api/anthropic
+    env_vars: ANTHROPIC_MODEL=glm-5.1
+audit:
+  enabled: yes please
+`
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, BaseFileName), typoConfig)
+
+	_, err := LoadConfig(context.Background(), dir)
+	var schemaErr *SchemaError
+	if !stderrors.As(err, &schemaErr) {
+		t.Fatalf("LoadConfig() error = %v, want *SchemaError", err)
+	}
+
+	want := []string{
+		`config.yaml:5:5: unknown field "basurl" in providers.zai (did you mean "base_url"?)`,
+		`config.yaml:6:15: providers.zai.env_vars: expected a list, got "ANTHROPIC_MODEL=glm-5.1"`,
+		`config.yaml:8:12: audit.enabled: expected true or false, got "yes please"`,
+	}
+	if len(schemaErr.Issues) != len(want) {
+		t.Fatalf("issues = %v, want %d", schemaErr, len(want))
+	}
+	for _, w := range want {
+		if !strings.Contains(err.Error(), w) {
+			t.Errorf("error %q does not contain %q", err, w)
+		}
+	}
+}
+
+func TestLoadConfig_StrictContext(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, BaseFileName), "default_provder: zai\n")
+
+	_, err := LoadConfig(context.Background(), dir)
+	var schemaErr *SchemaError
+	if err == nil || stderrors.As(err, &schemaErr) {
+		t.Fatalf("LoadConfig() without strict mode error = %v, want the unknown field error", err)
+	}
+
+	_, err = LoadConfig(WithStrictValidation(context.Background()), dir)
+	if !stderrors.As(err, &schemaErr) || !strings.Contains(err.Error(), `did you mean "default_provider"?`) {
+		t.Fatalf("LoadConfig() in strict mode error = %v, want suggestion", err)
+	}
+}
+
+func TestLoadConfig_StrictFromOverride(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, BaseFileName), overrideBaseConfig)
+	writeConfigFile(t, filepath.Join(dir, OverrideFileName), "validation: strict\ndefault_harness: [qwen]\n")
+
+	_, err := LoadConfig(context.Background(), dir)
+	if err == nil || !strings.Contains(err.Error(), "config.override.yaml:2:18: default_harness: expected a string") {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+}
+
+func TestLoadConfig_InvalidValidationMode(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, BaseFileName), "validation: paranoid\n")
+
+	if _, err := LoadConfig(context.Background(), dir); err == nil || !strings.Contains(err.Error(), "invalid validation mode") {
+		t.Fatalf("LoadConfig() error = %v, want invalid validation mode", err)
+	}
+}
+
+func TestLoadConfig_StrictValidConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, BaseFileName), overrideBaseConfig+`audit:
+  enabled: true
+  events: [switch]
+custom_providers:
+  mine:
+    name: Mine
+    base_url: https://example.com
+    min_key_length: 20
+    min_key_entropy: 3
+`)
+
+	cfg, err := LoadConfig(WithStrictValidation(context.Background()), dir)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.CustomProviders["mine"].MinKeyEntropy != 3 {
+		t.Errorf("MinKeyEntropy = %v", cfg.CustomProviders["mine"].MinKeyEntropy)
+	}
+}
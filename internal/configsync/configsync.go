@@ -0,0 +1,165 @@
+// Package configsync lays out kairo's configuration in a directory meant to
+// be committed to a private git repository, and decides which side of a sync
+// changed. Files are written byte-for-byte the same for the same settings,
+// and MANIFEST.sha256 lists their hashes, so that a file damaged in a merge
+// or only partly pulled is refused rather than applied.
+package configsync
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsutil"
+)
+
+// ManifestName is the manifest in a sync directory. It uses the format of
+// sha256sum, so `sha256sum -c MANIFEST.sha256` checks the directory too.
+const ManifestName = "MANIFEST.sha256"
+
+// Manifest maps a file name to the hex SHA-256 of its contents.
+type Manifest map[string]string
+
+// Hash returns the hex SHA-256 of data, as a Manifest holds it.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Encode renders m in sha256sum format, sorted by file name.
+func (m Manifest) Encode() []byte {
+	var b bytes.Buffer
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		fmt.Fprintf(&b, "%s  %s\n", m[name], name)
+	}
+
+	return b.Bytes()
+}
+
+// ParseManifest parses a manifest written by Encode.
+func ParseManifest(data []byte) (Manifest, error) {
+	m := make(Manifest)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		sum, name, ok := strings.Cut(text, "  ")
+		if !ok || len(sum) != sha256.Size*2 || name == "" {
+			return nil, fmt.Errorf("line %d: expected '<sha256>  <file>'", line)
+		}
+		m[name] = sum
+	}
+
+	return m, scanner.Err()
+}
+
+// ReadManifest reads the manifest at path. A missing file yields an empty
+// manifest.
+func ReadManifest(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if stderrors.Is(err, fs.ErrNotExist) {
+		return Manifest{}, nil
+	}
+	if err != nil {
+		return nil, errors.FileError("failed to read sync manifest", path, err)
+	}
+	m, err := ParseManifest(data)
+	if err != nil {
+		return nil, errors.WrapError(errors.ConfigError, "sync manifest is malformed", err).
+			WithContext("path", path)
+	}
+
+	return m, nil
+}
+
+// WriteManifest writes m to path atomically.
+func WriteManifest(path string, m Manifest) error {
+	return fsutil.WriteAtomic(path, func(f *os.File) error {
+		_, err := f.Write(m.Encode())
+
+		return err
+	})
+}
+
+// Read returns the files dir's manifest lists, after checking each against
+// its hash. A listed file that is missing or whose contents differ from the
+// manifest fails the whole read.
+func Read(dir string) (map[string][]byte, error) {
+	manifestPath := filepath.Join(dir, ManifestName)
+	if _, err := os.Stat(manifestPath); err != nil {
+		return nil, errors.FileError("no kairo sync export found", manifestPath, err).
+			WithContext("hint", "run 'kairo sync export --dir "+dir+"' on the machine to copy from")
+	}
+	m, err := ReadManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(m))
+	for name, sum := range m {
+		if name != filepath.Base(name) {
+			return nil, errors.NewError(errors.ConfigError,
+				fmt.Sprintf("sync manifest lists %q outside the sync directory", name)).
+				WithContext("path", manifestPath)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, errors.FileError("file listed in the sync manifest is missing", filepath.Join(dir, name), err)
+		}
+		if Hash(data) != sum {
+			return nil, errors.NewError(errors.ConfigError,
+				fmt.Sprintf("%s does not match its hash in %s", name, ManifestName)).
+				WithContext("path", filepath.Join(dir, name)).
+				WithContext("hint", "the file was edited or only partly pulled; pull again, or export it again on the machine it came from")
+		}
+		files[name] = data
+	}
+
+	return files, nil
+}
+
+// Change is what a sync does with one file.
+type Change int
+
+const (
+	// Unchanged means both sides hold the same contents.
+	Unchanged Change = iota
+	// Apply means only the source changed since the last sync, so it
+	// replaces the destination.
+	Apply
+	// Keep means only the destination changed since the last sync, so it
+	// stays as it is until it is synced the other way.
+	Keep
+	// Conflict means both sides changed since the last sync.
+	Conflict
+)
+
+// Compare decides what syncing a file does from the hashes of its source
+// and destination contents and of the contents both last held, base. An
+// empty hash is a missing file; with no base, any differing destination is
+// a conflict.
+func Compare(source, dest, base string) Change {
+	switch {
+	case source == dest:
+		return Unchanged
+	case dest == base:
+		return Apply
+	case source == base:
+		return Keep
+	default:
+		return Conflict
+	}
+}
@@ -0,0 +1,82 @@
+package configsync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	m := Manifest{"secrets.age": Hash([]byte("b")), "config.yaml": Hash([]byte("a"))}
+
+	encoded := string(m.Encode())
+	if !strings.HasPrefix(encoded, m["config.yaml"]+"  config.yaml\n") {
+		t.Errorf("Encode() = %q, want entries sorted by name in sha256sum format", encoded)
+	}
+	got, err := ParseManifest([]byte(encoded))
+	if err != nil || len(got) != 2 || got["secrets.age"] != m["secrets.age"] {
+		t.Errorf("ParseManifest() = %v, %v; want %v", got, err, m)
+	}
+	if _, err := ParseManifest([]byte("not a manifest\n")); err == nil {
+		t.Error("ParseManifest() of a malformed line error = nil")
+	}
+
+	missing, err := ReadManifest(filepath.Join(t.TempDir(), ManifestName))
+	if err != nil || len(missing) != 0 {
+		t.Errorf("ReadManifest() of a missing file = %v, %v; want an empty manifest", missing, err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Read(dir); err == nil {
+		t.Error("Read() without a manifest error = nil")
+	}
+
+	data := []byte("default_provider: zai\n")
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteManifest(filepath.Join(dir, ManifestName), Manifest{"config.yaml": Hash(data)}); err != nil {
+		t.Fatal(err)
+	}
+	files, err := Read(dir)
+	if err != nil || string(files["config.yaml"]) != string(data) {
+		t.Fatalf("Read() = %v, %v; want config.yaml", files, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("<<<<<<< HEAD\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(dir); err == nil || !strings.Contains(err.Error(), "does not match its hash") {
+		t.Errorf("Read() of an edited file error = %v", err)
+	}
+
+	if err := WriteManifest(filepath.Join(dir, ManifestName), Manifest{"../config.yaml": Hash(data)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(dir); err == nil || !strings.Contains(err.Error(), "outside the sync directory") {
+		t.Errorf("Read() of a path outside the directory error = %v", err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name               string
+		source, dest, base string
+		want               Change
+	}{
+		{"same contents", "a", "a", "", Unchanged},
+		{"only source changed", "b", "a", "a", Apply},
+		{"destination missing", "a", "", "", Apply},
+		{"only destination changed", "a", "b", "a", Keep},
+		{"both changed", "b", "c", "a", Conflict},
+		{"never synced", "a", "b", "", Conflict},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.source, tt.dest, tt.base); got != tt.want {
+			t.Errorf("%s: Compare() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -14,6 +14,37 @@ const (
 	SecretsFileName = "secrets.age"
 )
 
+// SetupProgressFileName holds the answers of an interrupted setup wizard,
+// encrypted like the secrets file since they may include an API key.
+const SetupProgressFileName = "setup-progress.age"
+
+// RecipientsFileName lists further age recipients, such as a teammate's key
+// or another machine's, that the secrets file is encrypted to besides the
+// one in age.key.
+const RecipientsFileName = "recipients"
+
+// EscrowFileName holds an organization's escrow age recipient, which the
+// secrets file is always encrypted to as well, so an administrator can
+// recover the keys of someone who has left.
+const EscrowFileName = "escrow"
+
+// TrustedSignersFileName lists the minisign public keys of signers whose
+// provider bundles 'kairo providers import' accepts, one per line.
+const TrustedSignersFileName = "trusted_signers"
+
+// LockFileName is the lock file in the config directory that serializes
+// first-run initialization across concurrent kairo processes.
+const LockFileName = ".kairo.lock"
+
+// JournalFileName records an operation replacing several files in the
+// config directory while it runs, so that one cut short can be finished or
+// undone on the next start.
+const JournalFileName = ".kairo.journal"
+
+// SyncStateFileName records the hashes of the files 'kairo sync' last
+// exported or imported, so that the next sync can tell which side changed.
+const SyncStateFileName = ".kairo.sync"
+
 // File and directory permission modes used across the application.
 var (
 	// DirPermSecure is used for directories containing sensitive data (0700).
@@ -39,6 +70,10 @@ const (
 
 	// RequestTimeout is the default timeout for HTTP requests to external APIs.
 	RequestTimeout = 10 * time.Second
+
+	// LockTimeout is how long to wait for another kairo process to finish
+	// initializing the config directory.
+	LockTimeout = 10 * time.Second
 )
 
 // Environment variable names for Anthropic-compatible provider configuration.
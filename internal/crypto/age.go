@@ -7,23 +7,36 @@ import (
 	"context"
 	stderrors "errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"filippo.io/age"
 	"github.com/dkmnx/kairo/internal/constants"
 	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsperm"
 	"github.com/dkmnx/kairo/internal/fsutil"
+	"github.com/dkmnx/kairo/internal/journal"
 )
 
 // GenerateKey creates a new X25519 keypair and writes it to keyPath atomically.
+// It refuses to replace an existing key, since secrets encrypted to it would
+// become unreadable; callers that mean to replace it remove it first.
 func GenerateKey(ctx context.Context, keyPath string) error {
 	if err := errors.CheckContext(ctx); err != nil {
 		return err
 	}
 
+	if _, err := os.Stat(keyPath); err == nil {
+		return errors.WrapError(errors.CryptoError,
+			"refusing to overwrite existing encryption key", errors.ErrKeyExists).
+			WithContext("path", keyPath).
+			WithContext("hint", "use 'kairo setup --reset-secrets --force' to replace it")
+	}
+
 	key, err := age.GenerateX25519Identity()
 	if err != nil {
 		return errors.WrapError(errors.CryptoError,
@@ -41,7 +54,7 @@ func GenerateKey(ctx context.Context, keyPath string) error {
 			WithContext("path", keyPath)
 	}
 
-	return nil
+	return fsperm.Restrict(keyPath)
 }
 
 // EncryptSecrets encrypts the given secrets string and writes the ciphertext to secretsPath.
@@ -51,21 +64,39 @@ func EncryptSecrets(ctx context.Context, secretsPath, keyPath, secrets string) e
 	}
 
 	recipient, err := loadRecipient(keyPath)
+	if stderrors.Is(err, errors.ErrKeyLocked) {
+		return err
+	}
 	if err != nil {
 		return errors.WrapError(errors.CryptoError,
 			"failed to load encryption key", err).
 			WithContext("key_path", keyPath).
 			WithContext("secrets_path", secretsPath)
 	}
+	recipients, err := RecipientsFor(keyPath, recipient)
+	if err != nil {
+		return err
+	}
+
+	return EncryptSecretsTo(ctx, secretsPath, secrets, recipients...)
+}
+
+// EncryptSecretsTo encrypts secrets to recipients and writes the ciphertext
+// to secretsPath, for callers that hold the recipients rather than a key
+// file.
+func EncryptSecretsTo(ctx context.Context, secretsPath, secrets string, recipients ...age.Recipient) error {
+	if err := errors.CheckContext(ctx); err != nil {
+		return err
+	}
 
 	if err := fsutil.WriteAtomic(secretsPath, func(f *os.File) error {
-		encryptor, encErr := age.Encrypt(f, recipient)
+		encryptor, encErr := age.Encrypt(f, recipients...)
 		if encErr != nil {
 			return errors.WrapError(errors.CryptoError,
 				"failed to initialize encryption", encErr)
 		}
 
-		if _, writeErr := encryptor.Write([]byte(secrets)); writeErr != nil {
+		if _, writeErr := io.Copy(encryptor, contextReader{ctx, strings.NewReader(secrets)}); writeErr != nil {
 			return errors.WrapError(errors.CryptoError,
 				"failed to encrypt secrets", writeErr)
 		}
@@ -99,6 +130,21 @@ func DecryptSecrets(ctx context.Context, secretsPath, keyPath string) (string, e
 	return buf.String(), nil
 }
 
+// contextReader stops a long encryption or decryption stream once ctx is
+// canceled, so an interrupted write is discarded by fsutil.WriteAtomic.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return cr.r.Read(p)
+}
+
 // ClearMemory zeroes out the given byte slice to prevent sensitive data from
 // remaining in memory.
 func ClearMemory(b []byte) {
@@ -123,9 +169,31 @@ func decryptToBuffer(ctx context.Context, secretsPath, keyPath string, buf *byte
 		return err
 	}
 
-	identity, err := loadIdentity(keyPath)
+	identity, file, err := openSecrets(secretsPath, keyPath)
 	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := decryptWith(ctx, file, identity, buf); err != nil {
 		return errors.WrapError(errors.CryptoError,
+			"failed to decrypt secrets file", err).
+			WithContext("path", secretsPath).
+			WithContext("hint", "Ensure your encryption key matches the one used for encryption")
+	}
+
+	return nil
+}
+
+// openSecrets loads the identity in keyPath and opens the secrets file it
+// decrypts. The caller must close the returned file when done.
+func openSecrets(secretsPath, keyPath string) (age.Identity, *os.File, error) {
+	identity, err := loadIdentity(keyPath)
+	if stderrors.Is(err, errors.ErrKeyLocked) {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, errors.WrapError(errors.CryptoError,
 			"failed to load decryption key", err).
 			WithContext("key_path", keyPath).
 			WithContext("hint", "Ensure your encryption key file exists and is valid")
@@ -133,22 +201,36 @@ func decryptToBuffer(ctx context.Context, secretsPath, keyPath string, buf *byte
 
 	file, err := os.Open(secretsPath)
 	if err != nil {
-		return errors.WrapError(errors.FileSystemError,
+		return nil, nil, errors.WrapError(errors.FileSystemError,
 			"failed to open secrets file", err).
 			WithContext("path", secretsPath)
 	}
-	defer file.Close()
 
-	decryptor, err := age.Decrypt(file, identity)
-	if err != nil {
-		return errors.WrapError(errors.CryptoError,
-			"failed to decrypt secrets file", err).
-			WithContext("path", secretsPath).
-			WithContext("hint", "Ensure your encryption key matches the one used for encryption")
+	return identity, file, nil
+}
+
+// DecryptWithIdentity decrypts ciphertext with identity, for callers that
+// hold the identity rather than a key file.
+func DecryptWithIdentity(ctx context.Context, ciphertext []byte, identity age.Identity) ([]byte, error) {
+	if err := errors.CheckContext(ctx); err != nil {
+		return nil, err
 	}
 
-	_, err = buf.ReadFrom(decryptor)
+	var buf bytes.Buffer
+	if err := decryptWith(ctx, bytes.NewReader(ciphertext), identity, &buf); err != nil {
+		return nil, errors.WrapError(errors.CryptoError, "failed to decrypt secrets", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decryptWith(ctx context.Context, src io.Reader, identity age.Identity, buf *bytes.Buffer) error {
+	decryptor, err := age.Decrypt(src, identity)
 	if err != nil {
+		return err
+	}
+
+	if _, err := buf.ReadFrom(contextReader{ctx, decryptor}); err != nil {
 		return errors.WrapError(errors.CryptoError,
 			"failed to read decrypted content", err)
 	}
@@ -156,26 +238,18 @@ func decryptToBuffer(ctx context.Context, secretsPath, keyPath string, buf *byte
 	return nil
 }
 
-// checkKeyFilePermissions returns an error if the key file is group/world
-// accessible (skipped on Windows).
+// checkKeyFilePermissions returns an error if users other than the owner
+// can access the key file.
 func checkKeyFilePermissions(keyPath string) error {
-	if runtime.GOOS == "windows" {
-		return nil
-	}
-
-	info, err := os.Stat(keyPath)
-	if err != nil {
-		return err
-	}
-	if info.Mode().Perm()&0o077 != 0 {
-		return errors.NewError(errors.CryptoError,
-			"key file has overly permissive permissions").
+	err := fsperm.CheckOwnerOnly(keyPath)
+	if stderrors.Is(err, fsperm.ErrNotOwnerOnly) {
+		return errors.WrapError(errors.CryptoError,
+			"key file has overly permissive permissions", err).
 			WithContext("path", keyPath).
-			WithContext("mode", fmt.Sprintf("%04o", info.Mode().Perm())).
-			WithContext("hint", "fix with: chmod 600 <keyfile>")
+			WithContext("hint", fsperm.FixHint(keyPath))
 	}
 
-	return nil
+	return err
 }
 
 // readKeyFileScanner opens a key file and returns a scanner for reading its lines.
@@ -207,6 +281,9 @@ func loadRecipient(keyPath string) (age.Recipient, error) {
 			"key file is empty").
 			WithContext("path", keyPath)
 	}
+	if isPassphraseProtected(scanner.Bytes()) {
+		return nil, lockedKeyError(keyPath)
+	}
 	if !scanner.Scan() {
 		return nil, errors.NewError(errors.CryptoError,
 			"key file is missing recipient line").
@@ -237,6 +314,9 @@ func loadIdentity(keyPath string) (age.Identity, error) {
 			"key file is empty").
 			WithContext("path", keyPath)
 	}
+	if isPassphraseProtected(scanner.Bytes()) {
+		return nil, lockedKeyError(keyPath)
+	}
 
 	identity, err := age.ParseX25519Identity(scanner.Text())
 	if err != nil {
@@ -249,18 +329,154 @@ func loadIdentity(keyPath string) (age.Identity, error) {
 	return identity, nil
 }
 
-// EnsureKeyExists generates a new keypair if one does not already exist in configDir.
+// EnsureKeyExists generates a new keypair if one does not already exist in
+// configDir. Generation holds the config directory lock, so concurrent first
+// runs agree on a single key instead of the last writer winning.
 func EnsureKeyExists(ctx context.Context, configDir string) error {
 	keyPath := filepath.Join(configDir, constants.KeyFileName)
+	exists, err := keyExists(keyPath)
+	if err != nil || exists {
+		return err
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, constants.LockTimeout)
+	defer cancel()
+
+	unlock, err := fsutil.Lock(lockCtx, filepath.Join(configDir, constants.LockFileName))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Another process may have generated the key while we waited.
+	if exists, err := keyExists(keyPath); err != nil || exists {
+		return err
+	}
+
+	return GenerateKey(ctx, keyPath)
+}
+
+func keyExists(keyPath string) (bool, error) {
 	_, err := os.Stat(keyPath)
 	if err == nil {
-		return nil
+		return true, nil
 	}
 	if !stderrors.Is(err, fs.ErrNotExist) {
-		return errors.WrapError(errors.FileSystemError,
+		return false, errors.WrapError(errors.FileSystemError,
 			"failed to check key file status", err).
 			WithContext("path", keyPath)
 	}
 
-	return GenerateKey(ctx, keyPath)
+	return false, nil
+}
+
+// RotateKey re-encrypts the secrets file under a newly generated key. The
+// plaintext is streamed from the old file to the new one a chunk at a time,
+// so memory use does not grow with the number of secrets, and progress is
+// reported to the ProgressFunc set with WithProgress. The new key and
+// ciphertext are staged next to the current files and swapped in through a
+// journal, so an interruption leaves either the old pair or the new one.
+func RotateKey(ctx context.Context, secretsPath, keyPath string) error {
+	if err := errors.CheckContext(ctx); err != nil {
+		return err
+	}
+
+	identity, file, err := openSecrets(secretsPath, keyPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	newKeyPath := keyPath + ".new"
+	newSecretsPath := secretsPath + ".new"
+	// Leftovers from a rotation interrupted by an older kairo, which kept no
+	// journal, would make GenerateKey refuse.
+	_ = os.Remove(newKeyPath)
+	_ = os.Remove(newSecretsPath)
+
+	j, err := journal.Begin(ctx, filepath.Dir(secretsPath), "rotate", []journal.Step{
+		journal.Replace(newSecretsPath, secretsPath),
+		journal.Replace(newKeyPath, keyPath),
+	})
+	if err != nil {
+		return err
+	}
+	if err := GenerateKey(ctx, newKeyPath); err != nil {
+		j.Abort()
+
+		return err
+	}
+	recipient, err := loadRecipient(newKeyPath)
+	if err != nil {
+		j.Abort()
+
+		return err
+	}
+	recipients, err := RecipientsFor(keyPath, recipient)
+	if err != nil {
+		j.Abort()
+
+		return err
+	}
+	if err := reencrypt(ctx, file, identity, newSecretsPath, recipients); err != nil {
+		j.Abort()
+
+		return err
+	}
+	// Windows cannot rename over a file that is still open.
+	_ = file.Close()
+
+	// Last point at which an interrupt leaves the old pair untouched.
+	if err := errors.CheckContext(ctx); err != nil {
+		j.Abort()
+
+		return err
+	}
+
+	return j.Commit()
+}
+
+// reencrypt streams the plaintext of src, decrypted with identity, into a
+// new file at dstPath encrypted to recipients.
+func reencrypt(ctx context.Context, src *os.File, identity age.Identity, dstPath string, recipients []age.Recipient) error {
+	info, err := src.Stat()
+	if err != nil {
+		return errors.FileError("failed to read secrets file", src.Name(), err)
+	}
+	decryptor, err := age.Decrypt(&progressReader{r: src, total: info.Size(), fn: progressFrom(ctx)}, identity)
+	if err != nil {
+		return errors.WrapError(errors.CryptoError,
+			"failed to decrypt secrets file", err).
+			WithContext("path", src.Name()).
+			WithContext("hint", "Ensure your encryption key matches the one used for encryption")
+	}
+
+	buf := make([]byte, 32*1024)
+	defer ClearMemory(buf)
+
+	if err := fsutil.WriteAtomic(dstPath, func(f *os.File) error {
+		encryptor, encErr := age.Encrypt(f, recipients...)
+		if encErr != nil {
+			return errors.WrapError(errors.CryptoError,
+				"failed to initialize encryption", encErr)
+		}
+
+		if _, copyErr := io.CopyBuffer(encryptor, contextReader{ctx, decryptor}, buf); copyErr != nil {
+			return errors.WrapError(errors.CryptoError,
+				"failed to re-encrypt secrets", copyErr)
+		}
+
+		if closeErr := encryptor.Close(); closeErr != nil {
+			return errors.WrapError(errors.CryptoError,
+				"failed to finalize encryption", closeErr)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.WrapError(errors.FileSystemError,
+			"failed to write encrypted secrets file", err).
+			WithContext("path", dstPath)
+	}
+
+	return nil
 }
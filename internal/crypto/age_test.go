@@ -2,9 +2,13 @@ package crypto
 
 import (
 	"context"
+	stderrors "errors"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+
+	"github.com/dkmnx/kairo/internal/errors"
 )
 
 func TestGenerateKey_CancelledContext(t *testing.T) {
@@ -187,7 +191,7 @@ func TestDecryptSecrets_WrongKey(t *testing.T) {
 	}
 }
 
-func TestGenerateKey_OverwritesExistingKey(t *testing.T) {
+func TestGenerateKey_RefusesExistingKey(t *testing.T) {
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
 
@@ -200,8 +204,9 @@ func TestGenerateKey_OverwritesExistingKey(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := GenerateKey(context.Background(), keyPath); err != nil {
-		t.Fatal(err)
+	err = GenerateKey(context.Background(), keyPath)
+	if !stderrors.Is(err, errors.ErrKeyExists) {
+		t.Fatalf("GenerateKey() on existing key error = %v, want ErrKeyExists", err)
 	}
 
 	secondKey, err := os.ReadFile(keyPath)
@@ -209,7 +214,97 @@ func TestGenerateKey_OverwritesExistingKey(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if string(firstKey) == string(secondKey) {
-		t.Error("regenerating key should produce different key content")
+	if string(firstKey) != string(secondKey) {
+		t.Error("existing key was overwritten")
+	}
+}
+
+func TestEnsureKeyExists_Concurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- EnsureKeyExists(context.Background(), tmpDir)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("EnsureKeyExists() error = %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".kairo.lock")); !os.IsNotExist(err) {
+		t.Errorf("lock file not released: %v", err)
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "age.key")
+	secretsPath := filepath.Join(dir, "secrets.age")
+	if err := GenerateKey(ctx, keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := EncryptSecrets(ctx, secretsPath, keyPath, "A=1\n"); err != nil {
+		t.Fatal(err)
+	}
+	oldKey, _ := os.ReadFile(keyPath)
+
+	if err := RotateKey(ctx, secretsPath, keyPath); err != nil {
+		t.Fatalf("RotateKey() error = %v", err)
+	}
+
+	newKey, _ := os.ReadFile(keyPath)
+	if string(oldKey) == string(newKey) {
+		t.Error("key file unchanged after rotation")
+	}
+	if got, err := DecryptSecrets(ctx, secretsPath, keyPath); err != nil || got != "A=1\n" {
+		t.Errorf("DecryptSecrets() after rotation = %q, %v", got, err)
+	}
+}
+
+func TestRotateKey_Progress(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "age.key")
+	secretsPath := filepath.Join(dir, "secrets.age")
+	if err := GenerateKey(ctx, keyPath); err != nil {
+		t.Fatal(err)
+	}
+	content := benchSecrets(500)
+	if err := EncryptSecrets(ctx, secretsPath, keyPath, content); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(secretsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls int
+	var lastDone, lastTotal int64
+	ctx = WithProgress(ctx, func(done, total int64) {
+		if done < lastDone {
+			t.Errorf("progress went back from %d to %d", lastDone, done)
+		}
+		calls++
+		lastDone, lastTotal = done, total
+	})
+	if err := RotateKey(ctx, secretsPath, keyPath); err != nil {
+		t.Fatalf("RotateKey() error = %v", err)
+	}
+
+	if calls == 0 || lastDone != info.Size() || lastTotal != info.Size() {
+		t.Errorf("last progress = %d/%d after %d calls, want %d/%d", lastDone, lastTotal, calls, info.Size(), info.Size())
+	}
+	if got, err := DecryptSecrets(context.Background(), secretsPath, keyPath); err != nil || got != content {
+		t.Errorf("DecryptSecrets() after rotation lost secrets: %v", err)
 	}
 }
@@ -2,10 +2,23 @@ package crypto
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
+// benchSecrets returns a secrets file body holding n API keys of a typical
+// length.
+func benchSecrets(n int) string {
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, "PROVIDER_%03d_API_KEY=sk-%059d\n", i, i)
+	}
+
+	return b.String()
+}
+
 func BenchmarkEncryptSecrets(b *testing.B) {
 	tmpDir := b.TempDir()
 	keyPath := filepath.Join(tmpDir, "age.key")
@@ -77,3 +90,70 @@ func BenchmarkEncryptDecryptRoundtrip(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkEncryptSecrets_500 encrypts a store of 500 keys, the size the
+// rotation hot path is tuned for.
+func BenchmarkEncryptSecrets_500(b *testing.B) {
+	tmpDir := b.TempDir()
+	keyPath := filepath.Join(tmpDir, "age.key")
+	if err := GenerateKey(context.Background(), keyPath); err != nil {
+		b.Fatal(err)
+	}
+
+	secretsPath := filepath.Join(tmpDir, "secrets.age")
+	secrets := benchSecrets(500)
+	b.SetBytes(int64(len(secrets)))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := EncryptSecrets(context.Background(), secretsPath, keyPath, secrets); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecryptSecrets_500(b *testing.B) {
+	tmpDir := b.TempDir()
+	keyPath := filepath.Join(tmpDir, "age.key")
+	if err := GenerateKey(context.Background(), keyPath); err != nil {
+		b.Fatal(err)
+	}
+
+	secretsPath := filepath.Join(tmpDir, "secrets.age")
+	secrets := benchSecrets(500)
+	if err := EncryptSecrets(context.Background(), secretsPath, keyPath, secrets); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(secrets)))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecryptSecretsBytes(context.Background(), secretsPath, keyPath); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRotateKey_500 measures a full rotation of 500 keys: a new key,
+// the streamed re-encryption, and both renames.
+func BenchmarkRotateKey_500(b *testing.B) {
+	tmpDir := b.TempDir()
+	keyPath := filepath.Join(tmpDir, "age.key")
+	if err := GenerateKey(context.Background(), keyPath); err != nil {
+		b.Fatal(err)
+	}
+
+	secretsPath := filepath.Join(tmpDir, "secrets.age")
+	secrets := benchSecrets(500)
+	if err := EncryptSecrets(context.Background(), secretsPath, keyPath, secrets); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(secrets)))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := RotateKey(context.Background(), secretsPath, keyPath); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
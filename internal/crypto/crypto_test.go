@@ -641,6 +641,16 @@ func TestCheckKeyFilePermissions(t *testing.T) {
 	})
 }
 
+func TestGenerateKey_OwnerOnly(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "age.key")
+	if err := GenerateKey(context.Background(), keyPath); err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	if err := checkKeyFilePermissions(keyPath); err != nil {
+		t.Errorf("checkKeyFilePermissions() of a generated key error = %v", err)
+	}
+}
+
 func TestDecryptSecrets_OpenError(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("Skipping readonly test on Windows")
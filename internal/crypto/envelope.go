@@ -0,0 +1,192 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsutil"
+)
+
+// envelopeVersion is the envelope file format written by EnvelopeService.
+const envelopeVersion = 1
+
+// dataKeySize is the size of the per-file AES-256 data key.
+const dataKeySize = 32
+
+// ageHeader starts every file written by the age backend. EnvelopeService
+// still reads such files so that switching backends does not lose secrets.
+var ageHeader = []byte("age-encryption.org/")
+
+// KMS wraps and unwraps data keys with a key that never leaves the key
+// management service.
+type KMS interface {
+	// Name identifies the backend, as in the crypto.backend config value.
+	Name() string
+	// KeyID identifies the KMS key data keys are wrapped with.
+	KeyID() string
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// envelope is the on-disk form of a secrets file written by EnvelopeService.
+type envelope struct {
+	Version    int    `json:"kairo_envelope"`
+	Backend    string `json:"backend"`
+	KeyID      string `json:"key_id"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EnvelopeService encrypts the secrets file with a fresh AES-256-GCM data
+// key on every write and stores the data key wrapped by a KMS key, so no key
+// that can decrypt the secrets is kept on disk.
+type EnvelopeService struct {
+	KMS KMS
+}
+
+// GenerateKey is a no-op: the key is held by the KMS.
+func (EnvelopeService) GenerateKey(ctx context.Context, keyPath string) error {
+	return errors.CheckContext(ctx)
+}
+
+// EnsureKeyExists is a no-op: the key is held by the KMS.
+func (EnvelopeService) EnsureKeyExists(ctx context.Context, configDir string) error {
+	return errors.CheckContext(ctx)
+}
+
+func (s EnvelopeService) EncryptSecrets(ctx context.Context, secretsPath, keyPath, secrets string) error {
+	if err := errors.CheckContext(ctx); err != nil {
+		return err
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return errors.WrapError(errors.CryptoError, "failed to generate data key", err)
+	}
+	defer ClearMemory(dataKey)
+
+	env := envelope{Version: envelopeVersion, Backend: s.KMS.Name(), KeyID: s.KMS.KeyID()}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return err
+	}
+	env.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return errors.WrapError(errors.CryptoError, "failed to generate nonce", err)
+	}
+	env.Ciphertext = gcm.Seal(nil, env.Nonce, []byte(secrets), env.additionalData())
+
+	env.WrappedKey, err = s.KMS.Encrypt(ctx, dataKey)
+	if err != nil {
+		return errors.WrapError(errors.CryptoError, "failed to wrap data key with KMS", err).
+			WithContext("backend", env.Backend).
+			WithContext("key_id", env.KeyID)
+	}
+
+	if err := fsutil.WriteAtomic(secretsPath, func(f *os.File) error {
+		return json.NewEncoder(f).Encode(env)
+	}); err != nil {
+		return errors.WrapError(errors.FileSystemError,
+			"failed to write encrypted secrets file", err).
+			WithContext("path", secretsPath)
+	}
+
+	return nil
+}
+
+func (s EnvelopeService) DecryptSecrets(ctx context.Context, secretsPath, keyPath string) (string, error) {
+	plaintext, err := s.DecryptSecretsBytes(ctx, secretsPath, keyPath)
+	if err != nil {
+		return "", err
+	}
+	defer ClearMemory(plaintext)
+
+	return string(plaintext), nil
+}
+
+func (s EnvelopeService) DecryptSecretsBytes(ctx context.Context, secretsPath, keyPath string) ([]byte, error) {
+	if err := errors.CheckContext(ctx); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(secretsPath)
+	if err != nil {
+		return nil, errors.FileError("failed to open secrets file", secretsPath, err)
+	}
+	if bytes.HasPrefix(data, ageHeader) {
+		// Written before crypto.backend was set; the next save migrates it.
+		return DecryptSecretsBytes(ctx, secretsPath, keyPath)
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Version != envelopeVersion {
+		return nil, errors.NewError(errors.CryptoError, "secrets file is not a kairo KMS envelope").
+			WithContext("path", secretsPath)
+	}
+	if env.Backend != s.KMS.Name() {
+		return nil, errors.NewError(errors.CryptoError,
+			"secrets file was encrypted with the "+env.Backend+" backend").
+			WithContext("path", secretsPath).
+			WithContext("hint", "set crypto.backend to "+env.Backend+" in config.yaml")
+	}
+
+	dataKey, err := s.KMS.Decrypt(ctx, env.WrappedKey)
+	if err != nil {
+		return nil, errors.WrapError(errors.CryptoError, "failed to unwrap data key with KMS", err).
+			WithContext("backend", env.Backend).
+			WithContext("key_id", env.KeyID).
+			WithContext("hint", "check your cloud credentials and access to the key")
+	}
+	defer ClearMemory(dataKey)
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, env.Nonce, env.Ciphertext, env.additionalData())
+	if err != nil {
+		return nil, errors.WrapError(errors.CryptoError, "failed to decrypt secrets file", err).
+			WithContext("path", secretsPath)
+	}
+
+	return plaintext, nil
+}
+
+// RotateKeyring re-encrypts the secrets under a new data key wrapped with the
+// KMS key's current primary version. Rotating the KMS key itself is done in
+// the cloud provider.
+func (s EnvelopeService) RotateKeyring(ctx context.Context, secretsPath, keyPath string) error {
+	plaintext, err := s.DecryptSecretsBytes(ctx, secretsPath, keyPath)
+	if err != nil {
+		return err
+	}
+	defer ClearMemory(plaintext)
+
+	return s.EncryptSecrets(ctx, secretsPath, keyPath, string(plaintext))
+}
+
+// additionalData binds the ciphertext to the backend and key it was
+// written for.
+func (e envelope) additionalData() []byte {
+	return []byte(e.Backend + "\x00" + e.KeyID)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WrapError(errors.CryptoError, "invalid data key", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WrapError(errors.CryptoError, "failed to initialize encryption", err)
+	}
+
+	return gcm, nil
+}
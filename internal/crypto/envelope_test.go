@@ -0,0 +1,137 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeKMS wraps data keys by reversing them, and counts calls.
+type fakeKMS struct {
+	name     string
+	wraps    int
+	failWrap bool
+}
+
+func (k *fakeKMS) Name() string  { return k.name }
+func (k *fakeKMS) KeyID() string { return "test-key" }
+
+func (k *fakeKMS) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	if k.failWrap {
+		return nil, errors.New("access denied")
+	}
+	k.wraps++
+
+	return reversed(plaintext), nil
+}
+
+func (k *fakeKMS) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	return reversed(ciphertext), nil
+}
+
+func reversed(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+
+	return out
+}
+
+func TestEnvelopeService_Roundtrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "secrets.age")
+	svc := EnvelopeService{KMS: &fakeKMS{name: BackendAWSKMS}}
+	secrets := "ZAI_API_KEY=sk-test-0123456789\n"
+
+	if err := svc.EncryptSecrets(ctx, path, "", secrets); err != nil {
+		t.Fatalf("EncryptSecrets() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-test") {
+		t.Error("secrets file contains plaintext")
+	}
+
+	got, err := svc.DecryptSecrets(ctx, path, "")
+	if err != nil {
+		t.Fatalf("DecryptSecrets() error = %v", err)
+	}
+	if got != secrets {
+		t.Errorf("DecryptSecrets() = %q, want %q", got, secrets)
+	}
+
+	other := EnvelopeService{KMS: &fakeKMS{name: BackendGCPKMS}}
+	if _, err := other.DecryptSecretsBytes(ctx, path, ""); err == nil {
+		t.Error("DecryptSecretsBytes() expected error for a file from another backend")
+	}
+}
+
+func TestEnvelopeService_RotateKeyring(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "secrets.age")
+	kms := &fakeKMS{name: BackendGCPKMS}
+	svc := EnvelopeService{KMS: kms}
+
+	if err := svc.EncryptSecrets(ctx, path, "", "A=1\n"); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(path)
+
+	if err := svc.RotateKeyring(ctx, path, ""); err != nil {
+		t.Fatalf("RotateKeyring() error = %v", err)
+	}
+	after, _ := os.ReadFile(path)
+
+	if bytes.Equal(before, after) || kms.wraps != 2 {
+		t.Errorf("RotateKeyring() did not re-wrap a new data key (wraps = %d)", kms.wraps)
+	}
+	if got, err := svc.DecryptSecrets(ctx, path, ""); err != nil || got != "A=1\n" {
+		t.Errorf("DecryptSecrets() after rotation = %q, %v", got, err)
+	}
+}
+
+func TestEnvelopeService_ReadsAgeFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "age.key")
+	path := filepath.Join(dir, "secrets.age")
+	if err := GenerateKey(ctx, keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := EncryptSecrets(ctx, path, keyPath, "A=1\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := EnvelopeService{KMS: &fakeKMS{name: BackendAWSKMS}}
+	if got, err := svc.DecryptSecrets(ctx, path, keyPath); err != nil || got != "A=1\n" {
+		t.Errorf("DecryptSecrets() of age file = %q, %v", got, err)
+	}
+}
+
+func TestEnvelopeService_WrapFailureKeepsFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "secrets.age")
+	kms := &fakeKMS{name: BackendAWSKMS}
+	svc := EnvelopeService{KMS: kms}
+	if err := svc.EncryptSecrets(ctx, path, "", "A=1\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	kms.failWrap = true
+	if err := svc.EncryptSecrets(ctx, path, "", "A=2\n"); err == nil {
+		t.Fatal("EncryptSecrets() expected error when the KMS refuses")
+	}
+
+	kms.failWrap = false
+	if got, err := svc.DecryptSecrets(ctx, path, ""); err != nil || got != "A=1\n" {
+		t.Errorf("DecryptSecrets() = %q, %v; want the previous secrets", got, err)
+	}
+}
@@ -0,0 +1,119 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"runtime"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// Backend names accepted in the crypto.backend config value.
+const (
+	BackendAge    = "age"
+	BackendAWSKMS = "awskms"
+	BackendGCPKMS = "gcpkms"
+)
+
+// CommandRunner runs name with args, writing stdin to it, and returns its
+// standard output. KMS backends use it to call the cloud provider's CLI,
+// which brings its own credential handling.
+type CommandRunner func(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error)
+
+// BackendConfig selects and configures a secrets encryption backend.
+type BackendConfig struct {
+	Backend string
+	// KeyID is the KMS key: a key ID, ARN or alias for awskms, or a full
+	// projects/.../cryptoKeys/... resource name for gcpkms.
+	KeyID string
+	// Region overrides the AWS region for awskms.
+	Region string
+}
+
+// NewService returns the Service for cfg. An empty backend selects age, and
+// fallback is returned so that callers keep their configured age service.
+func NewService(cfg BackendConfig, fallback Service, run CommandRunner) (Service, error) {
+	switch cfg.Backend {
+	case "", BackendAge:
+		return fallback, nil
+	case BackendAWSKMS, BackendGCPKMS:
+	default:
+		return nil, errors.NewError(errors.ConfigError, "unknown crypto backend "+cfg.Backend).
+			WithContext("hint", "crypto.backend must be age, awskms, or gcpkms")
+	}
+
+	if cfg.KeyID == "" {
+		return nil, errors.NewError(errors.ConfigError, "crypto.key_id is required for the "+cfg.Backend+" backend")
+	}
+
+	if cfg.Backend == BackendAWSKMS {
+		if runtime.GOOS == "windows" {
+			return nil, errors.NewError(errors.ConfigError, "the awskms backend is not supported on Windows")
+		}
+
+		return EnvelopeService{KMS: awsKMS{keyID: cfg.KeyID, region: cfg.Region, run: run}}, nil
+	}
+
+	return EnvelopeService{KMS: gcpKMS{keyID: cfg.KeyID, run: run}}, nil
+}
+
+// awsKMS wraps data keys with the aws CLI. Key material is passed on stdin
+// so it never appears in a process listing.
+type awsKMS struct {
+	keyID  string
+	region string
+	run    CommandRunner
+}
+
+func (k awsKMS) Name() string  { return BackendAWSKMS }
+func (k awsKMS) KeyID() string { return k.keyID }
+
+func (k awsKMS) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	return k.call(ctx, plaintext, "encrypt", "--plaintext", "fileb:///dev/stdin", "--query", "CiphertextBlob")
+}
+
+func (k awsKMS) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	return k.call(ctx, ciphertext, "decrypt", "--ciphertext-blob", "fileb:///dev/stdin", "--query", "Plaintext")
+}
+
+// call runs an aws kms subcommand, which prints its blob result base64
+// encoded with --output text.
+func (k awsKMS) call(ctx context.Context, stdin []byte, op string, args ...string) ([]byte, error) {
+	argv := append([]string{"kms", op, "--key-id", k.keyID, "--output", "text"}, args...)
+	if k.region != "" {
+		argv = append(argv, "--region", k.region)
+	}
+
+	out, err := k.run(ctx, stdin, "aws", argv...)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, errors.WrapError(errors.CryptoError, "unexpected output from aws kms "+op, err)
+	}
+
+	return decoded, nil
+}
+
+// gcpKMS wraps data keys with the gcloud CLI, reading and writing raw bytes
+// on stdin and stdout.
+type gcpKMS struct {
+	keyID string
+	run   CommandRunner
+}
+
+func (k gcpKMS) Name() string  { return BackendGCPKMS }
+func (k gcpKMS) KeyID() string { return k.keyID }
+
+func (k gcpKMS) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	return k.run(ctx, plaintext, "gcloud", "kms", "encrypt", "--key", k.keyID,
+		"--plaintext-file", "-", "--ciphertext-file", "-")
+}
+
+func (k gcpKMS) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	return k.run(ctx, ciphertext, "gcloud", "kms", "decrypt", "--key", k.keyID,
+		"--ciphertext-file", "-", "--plaintext-file", "-")
+}
@@ -0,0 +1,80 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestNewService(t *testing.T) {
+	fallback := DefaultService{}
+
+	if svc, err := NewService(BackendConfig{}, fallback, nil); err != nil || svc != Service(fallback) {
+		t.Errorf("NewService(empty) = %v, %v; want fallback", svc, err)
+	}
+	if _, err := NewService(BackendConfig{Backend: "vault"}, fallback, nil); err == nil {
+		t.Error("NewService(vault) expected error")
+	}
+	if _, err := NewService(BackendConfig{Backend: BackendGCPKMS}, fallback, nil); err == nil {
+		t.Error("NewService(gcpkms without key_id) expected error")
+	}
+	svc, err := NewService(BackendConfig{Backend: BackendGCPKMS, KeyID: "projects/p/locations/l/keyRings/r/cryptoKeys/k"}, fallback, nil)
+	if err != nil {
+		t.Fatalf("NewService(gcpkms) error = %v", err)
+	}
+	if _, ok := svc.(EnvelopeService); !ok {
+		t.Errorf("NewService(gcpkms) = %T, want EnvelopeService", svc)
+	}
+}
+
+func TestAWSKMS_PassesKeyOnStdin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("awskms backend is not supported on Windows")
+	}
+
+	var gotArgs []string
+	var gotStdin []byte
+	run := func(_ context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+		gotArgs = append([]string{name}, args...)
+		gotStdin = stdin
+
+		return []byte(base64.StdEncoding.EncodeToString([]byte("wrapped")) + "\n"), nil
+	}
+	kms := awsKMS{keyID: "alias/kairo", region: "eu-west-1", run: run}
+
+	out, err := kms.Encrypt(context.Background(), []byte("data-key"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if string(out) != "wrapped" {
+		t.Errorf("Encrypt() = %q, want decoded CiphertextBlob", out)
+	}
+	if string(gotStdin) != "data-key" || slices.Contains(gotArgs, "data-key") {
+		t.Errorf("data key must go on stdin only: args %v", gotArgs)
+	}
+	for _, want := range []string{"aws", "kms", "encrypt", "alias/kairo", "eu-west-1"} {
+		if !slices.Contains(gotArgs, want) {
+			t.Errorf("args %v missing %q", gotArgs, want)
+		}
+	}
+}
+
+func TestGCPKMS_Args(t *testing.T) {
+	var gotArgs []string
+	run := func(_ context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+		gotArgs = append([]string{name}, args...)
+
+		return stdin, nil
+	}
+	kms := gcpKMS{keyID: "projects/p/locations/l/keyRings/r/cryptoKeys/k", run: run}
+
+	if _, err := kms.Decrypt(context.Background(), []byte("wrapped")); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"gcloud", "kms", "decrypt", "--key", kms.keyID, "--ciphertext-file", "-", "--plaintext-file", "-"}
+	if !slices.Equal(gotArgs, want) {
+		t.Errorf("args = %v, want %v", gotArgs, want)
+	}
+}
@@ -0,0 +1,112 @@
+package crypto
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// isPassphraseProtected reports whether the first line of a key file starts
+// an age-encrypted file, as written by `age --passphrase`, rather than an
+// identity.
+func isPassphraseProtected(firstLine []byte) bool {
+	line := bytes.TrimSpace(firstLine)
+
+	return bytes.HasPrefix(line, ageHeader) || string(line) == armor.Header
+}
+
+func lockedKeyError(keyPath string) error {
+	return errors.WrapError(errors.CryptoError,
+		"failed to load encryption key", errors.ErrKeyLocked).
+		WithContext("path", keyPath)
+}
+
+// KeyMaterial is an unlocked key file: the identity line and the recipient
+// line, as written by GenerateKey.
+type KeyMaterial []byte
+
+// Identity parses the identity line, the first one that is neither blank nor
+// a # comment, so identity files written by age-keygen are read too.
+func (k KeyMaterial) Identity() (*age.X25519Identity, error) {
+	var line []byte
+	for rest := []byte(k); len(rest) > 0 && len(line) == 0; {
+		line, rest, _ = bytes.Cut(rest, []byte("\n"))
+		if line = bytes.TrimSpace(line); bytes.HasPrefix(line, []byte("#")) {
+			line = nil
+		}
+	}
+	identity, err := age.ParseX25519Identity(string(line))
+	if err != nil {
+		return nil, errors.WrapError(errors.CryptoError,
+			"failed to parse identity from key file", err).
+			WithContext("hint", "key file may be corrupted or invalid format")
+	}
+
+	return identity, nil
+}
+
+// Recipient returns the public age recipient of the identity, which can be
+// shared so that others encrypt to this key.
+func (k KeyMaterial) Recipient() (string, error) {
+	identity, err := k.Identity()
+	if err != nil {
+		return "", err
+	}
+
+	return identity.Recipient().String(), nil
+}
+
+// ReadKeyFile returns the contents of the key file at keyPath. A key file
+// encrypted with `age --passphrase`, binary or armored, is decrypted with the
+// passphrase returned by passphrase, which is only called for such files.
+// The caller should ClearMemory the result when done.
+func ReadKeyFile(keyPath string, passphrase func() (string, error)) (KeyMaterial, error) {
+	if err := checkKeyFilePermissions(keyPath); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, errors.WrapError(errors.FileSystemError,
+			"failed to read key file", err).
+			WithContext("path", keyPath)
+	}
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if !isPassphraseProtected(firstLine) {
+		return data, nil
+	}
+
+	pass, err := passphrase()
+	if err != nil {
+		return nil, err
+	}
+	identity, err := age.NewScryptIdentity(pass)
+	if err != nil {
+		return nil, errors.WrapError(errors.CryptoError, "invalid passphrase", err)
+	}
+
+	var src io.Reader = bytes.NewReader(data)
+	if strings.TrimSpace(string(firstLine)) == armor.Header {
+		src = armor.NewReader(src)
+	}
+	decryptor, err := age.Decrypt(src, identity)
+	if err != nil {
+		return nil, errors.WrapError(errors.CryptoError,
+			"failed to unlock key file", err).
+			WithContext("path", keyPath).
+			WithContext("hint", "check the passphrase")
+	}
+	plaintext, err := io.ReadAll(decryptor)
+	if err != nil {
+		return nil, errors.WrapError(errors.CryptoError,
+			"failed to read unlocked key file", err).
+			WithContext("path", keyPath)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,142 @@
+package crypto
+
+import (
+	"context"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// protectKeyFile encrypts the key file at keyPath with passphrase, as
+// `age --passphrase --armor` would.
+func protectKeyFile(t *testing.T, keyPath, passphrase string) {
+	t.Helper()
+	plain, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient.SetWorkFactor(10)
+
+	f, err := os.OpenFile(keyPath, os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	a := armor.NewWriter(f)
+	w, err := age.Encrypt(a, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadKeyFile_Plain(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "age.key")
+	if err := GenerateKey(context.Background(), keyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ReadKeyFile(keyPath, func() (string, error) {
+		t.Error("passphrase requested for a plain key file")
+
+		return "", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := key.Identity(); err != nil {
+		t.Errorf("Identity() error = %v", err)
+	}
+
+	// The recipient is derived from the identity and matches the line
+	// GenerateKey writes after it.
+	recipient, err := key.Recipient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(string(key), "\n"); len(lines) < 2 || lines[1] != recipient {
+		t.Errorf("Recipient() = %q, want the key file's recipient line", recipient)
+	}
+	if strings.Contains(recipient, "AGE-SECRET-KEY") {
+		t.Errorf("Recipient() = %q contains the private key", recipient)
+	}
+}
+
+func TestKeyMaterial_IdentityAfterComments(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := KeyMaterial("# created: 2026-10-16T00:00:00Z\n# public key: " + identity.Recipient().String() +
+		"\n\n" + identity.String() + "\n")
+	got, err := key.Identity()
+	if err != nil || got.String() != identity.String() {
+		t.Errorf("Identity() of an age-keygen file = %v, %v", got, err)
+	}
+	if _, err := KeyMaterial("# only a comment\n").Identity(); err == nil {
+		t.Error("Identity() of a file without an identity succeeded")
+	}
+}
+
+func TestReadKeyFile_Passphrase(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "age.key")
+	secretsPath := filepath.Join(dir, "secrets.age")
+	if err := GenerateKey(ctx, keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := EncryptSecrets(ctx, secretsPath, keyPath, "ZAI_API_KEY=sk-test\n"); err != nil {
+		t.Fatal(err)
+	}
+	protectKeyFile(t, keyPath, "correct horse")
+
+	if _, err := DecryptSecrets(ctx, secretsPath, keyPath); !stderrors.Is(err, errors.ErrKeyLocked) {
+		t.Fatalf("DecryptSecrets() error = %v, want ErrKeyLocked", err)
+	}
+	if err := EncryptSecrets(ctx, secretsPath, keyPath, "x"); !stderrors.Is(err, errors.ErrKeyLocked) {
+		t.Fatalf("EncryptSecrets() error = %v, want ErrKeyLocked", err)
+	}
+
+	if _, err := ReadKeyFile(keyPath, func() (string, error) { return "wrong", nil }); err == nil {
+		t.Error("ReadKeyFile() with a wrong passphrase should fail")
+	}
+
+	key, err := ReadKeyFile(keyPath, func() (string, error) { return "correct horse", nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := key.Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := os.ReadFile(secretsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := DecryptWithIdentity(ctx, ciphertext, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != "ZAI_API_KEY=sk-test\n" {
+		t.Errorf("plaintext = %q", plaintext)
+	}
+}
@@ -0,0 +1,42 @@
+package crypto
+
+import (
+	"context"
+	"io"
+)
+
+// ProgressFunc is told how many bytes of the secrets file a long operation
+// has read so far, out of its total size.
+type ProgressFunc func(done, total int64)
+
+type progressKey struct{}
+
+// WithProgress returns a context under which RotateKey reports its progress
+// to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func progressFrom(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+
+	return fn
+}
+
+// progressReader reports the bytes read from r to fn.
+type progressReader struct {
+	r     io.Reader
+	done  int64
+	total int64
+	fn    ProgressFunc
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.done += int64(n)
+	if n > 0 && pr.fn != nil {
+		pr.fn(pr.done, pr.total)
+	}
+
+	return n, err
+}
@@ -0,0 +1,120 @@
+package crypto
+
+import (
+	"bufio"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// RecipientsPath returns the recipients file that belongs to the key file at
+// keyPath.
+func RecipientsPath(keyPath string) string {
+	return filepath.Join(filepath.Dir(keyPath), constants.RecipientsFileName)
+}
+
+// EscrowPath returns the escrow file that belongs to the key file at keyPath.
+func EscrowPath(keyPath string) string {
+	return filepath.Join(filepath.Dir(keyPath), constants.EscrowFileName)
+}
+
+// ReadEscrow returns the escrow recipient in the file at path, which holds
+// one age1... recipient in the format of ReadRecipients, or "" when there is
+// no such file.
+func ReadEscrow(path string) (string, error) {
+	recipients, err := ReadRecipients(path)
+	if err != nil {
+		return "", err
+	}
+	switch len(recipients) {
+	case 0:
+		if _, err := os.Stat(path); err == nil {
+			return "", errors.NewError(errors.CryptoError, "the escrow file holds no age recipient").
+				WithContext("path", path).
+				WithContext("hint", "set one with 'kairo escrow set <recipient>'")
+		}
+
+		return "", nil
+	case 1:
+		return recipients[0], nil
+	default:
+		return "", errors.NewError(errors.CryptoError, "the escrow file holds more than one age recipient").
+			WithContext("path", path).
+			WithContext("hint", "list further recipients in the recipients file instead")
+	}
+}
+
+// ReadRecipients returns the age recipients listed in the file at path, in
+// the format of age's -R files: one age1... recipient per line, with blank
+// lines and # comments ignored. A missing file lists none.
+func ReadRecipients(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if stderrors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.FileError("failed to read recipients file", path, err)
+	}
+
+	var recipients []string
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := age.ParseX25519Recipient(line); err != nil {
+			return nil, errors.WrapError(errors.CryptoError,
+				fmt.Sprintf("line %d of the recipients file is not an age recipient", n), err).
+				WithContext("path", path).
+				WithContext("hint", "each line must hold one age1... recipient, as printed by 'kairo key show --public'")
+		}
+		recipients = append(recipients, line)
+	}
+
+	return recipients, nil
+}
+
+// RecipientsFor returns own followed by the recipients listed in the
+// recipients file beside keyPath and the escrow recipient, which together
+// are everyone the secrets file is encrypted to. An escrow file that cannot
+// be read is an error, so the secrets are never written without it.
+func RecipientsFor(keyPath string, own age.Recipient) ([]age.Recipient, error) {
+	listed, err := ReadRecipients(RecipientsPath(keyPath))
+	if err != nil {
+		return nil, err
+	}
+	escrow, err := ReadEscrow(EscrowPath(keyPath))
+	if err != nil {
+		return nil, err
+	}
+	if escrow != "" && !slices.Contains(listed, escrow) {
+		listed = append(listed, escrow)
+	}
+
+	recipients := []age.Recipient{own}
+	ownString := ""
+	if x, ok := own.(*age.X25519Recipient); ok {
+		ownString = x.String()
+	}
+	for _, s := range listed {
+		if s == ownString {
+			continue
+		}
+		r, err := age.ParseX25519Recipient(s)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+
+	return recipients, nil
+}
@@ -0,0 +1,146 @@
+package crypto
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+// teammateKey generates a key in its own directory and returns its path and
+// recipient.
+func teammateKey(t *testing.T) (string, string) {
+	t.Helper()
+	keyPath := filepath.Join(t.TempDir(), "age.key")
+	if err := GenerateKey(context.Background(), keyPath); err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := loadRecipient(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return keyPath, recipient.(*age.X25519Recipient).String()
+}
+
+func TestReadRecipients(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "recipients")
+
+	got, err := ReadRecipients(path)
+	if err != nil || got != nil {
+		t.Fatalf("ReadRecipients(missing) = %v, %v; want nil, nil", got, err)
+	}
+
+	_, teammate := teammateKey(t)
+	content := "# laptop\n\n" + teammate + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ReadRecipients(path)
+	if err != nil {
+		t.Fatalf("ReadRecipients() error = %v", err)
+	}
+	if len(got) != 1 || got[0] != teammate {
+		t.Errorf("ReadRecipients() = %v, want [%s]", got, teammate)
+	}
+
+	if err := os.WriteFile(path, []byte(teammate+"\nage1notarecipient\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadRecipients(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadRecipients() error = %v, want one naming line 2", err)
+	}
+}
+
+func TestEncryptSecrets_ListedRecipients(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "age.key")
+	secretsPath := filepath.Join(dir, "secrets.age")
+	if err := GenerateKey(ctx, keyPath); err != nil {
+		t.Fatal(err)
+	}
+	teammateKeyPath, teammate := teammateKey(t)
+	if err := os.WriteFile(RecipientsPath(keyPath), []byte(teammate+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EncryptSecrets(ctx, secretsPath, keyPath, "KEY=value\n"); err != nil {
+		t.Fatalf("EncryptSecrets() error = %v", err)
+	}
+	for _, k := range []string{keyPath, teammateKeyPath} {
+		got, err := DecryptSecrets(ctx, secretsPath, k)
+		if err != nil || got != "KEY=value\n" {
+			t.Errorf("DecryptSecrets(%s) = %q, %v", k, got, err)
+		}
+	}
+
+	// Rotating replaces this machine's key but keeps the listed ones.
+	if err := RotateKey(ctx, secretsPath, keyPath); err != nil {
+		t.Fatalf("RotateKey() error = %v", err)
+	}
+	if got, err := DecryptSecrets(ctx, secretsPath, teammateKeyPath); err != nil || got != "KEY=value\n" {
+		t.Errorf("DecryptSecrets(teammate) after RotateKey = %q, %v", got, err)
+	}
+}
+
+func TestReadEscrow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "escrow")
+	if got, err := ReadEscrow(path); err != nil || got != "" {
+		t.Fatalf("ReadEscrow(missing) = %q, %v; want none", got, err)
+	}
+
+	_, admin := teammateKey(t)
+	_, other := teammateKey(t)
+	tests := []struct {
+		content string
+		want    string
+		wantErr bool
+	}{
+		{"# escrow\n" + admin + "\n", admin, false},
+		{"# escrow\n", "", true},
+		{admin + "\n" + other + "\n", "", true},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadEscrow(path)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ReadEscrow(%q) = %q, %v", tt.content, got, err)
+		}
+	}
+}
+
+func TestEncryptSecrets_Escrow(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "age.key")
+	secretsPath := filepath.Join(dir, "secrets.age")
+	if err := GenerateKey(ctx, keyPath); err != nil {
+		t.Fatal(err)
+	}
+	adminKeyPath, admin := teammateKey(t)
+	if err := os.WriteFile(EscrowPath(keyPath), []byte(admin+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EncryptSecrets(ctx, secretsPath, keyPath, "KEY=value\n"); err != nil {
+		t.Fatalf("EncryptSecrets() error = %v", err)
+	}
+	if got, err := DecryptSecrets(ctx, secretsPath, adminKeyPath); err != nil || got != "KEY=value\n" {
+		t.Errorf("DecryptSecrets(escrow) = %q, %v", got, err)
+	}
+
+	// A damaged escrow file stops the write instead of leaving it out.
+	if err := os.WriteFile(EscrowPath(keyPath), []byte("age1broken\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := EncryptSecrets(ctx, secretsPath, keyPath, "KEY=other\n"); err == nil {
+		t.Error("EncryptSecrets() with a damaged escrow file succeeded")
+	}
+}
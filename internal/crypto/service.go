@@ -2,12 +2,16 @@ package crypto
 
 import "context"
 
+// Service encrypts and decrypts the secrets file. DefaultService uses a local
+// age key file; EnvelopeService keeps the key in a cloud KMS instead.
 type Service interface {
 	GenerateKey(ctx context.Context, keyPath string) error
 	EncryptSecrets(ctx context.Context, secretsPath, keyPath, secrets string) error
 	DecryptSecrets(ctx context.Context, secretsPath, keyPath string) (string, error)
 	DecryptSecretsBytes(ctx context.Context, secretsPath, keyPath string) ([]byte, error)
 	EnsureKeyExists(ctx context.Context, configDir string) error
+	// RotateKeyring re-encrypts the secrets file under new key material.
+	RotateKeyring(ctx context.Context, secretsPath, keyPath string) error
 }
 
 type DefaultService struct{}
@@ -31,3 +35,7 @@ func (DefaultService) DecryptSecretsBytes(ctx context.Context, secretsPath, keyP
 func (DefaultService) EnsureKeyExists(ctx context.Context, configDir string) error {
 	return EnsureKeyExists(ctx, configDir)
 }
+
+func (DefaultService) RotateKeyring(ctx context.Context, secretsPath, keyPath string) error {
+	return RotateKey(ctx, secretsPath, keyPath)
+}
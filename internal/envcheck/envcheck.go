@@ -0,0 +1,224 @@
+// Package envcheck detects environment variables defined outside kairo
+// (ambient environment, shell rc files, Claude Code settings.json) that
+// compete with the values kairo injects into a harness environment.
+package envcheck
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Effect describes how an external definition interacts with kairo's value.
+type Effect string
+
+// Effects reported for conflicts.
+const (
+	// Overridden means kairo's value replaces the external one for the harness.
+	Overridden Effect = "overridden by kairo"
+	// Overrides means the external value takes precedence over kairo's.
+	Overrides Effect = "overrides kairo"
+	// Competes means a different variable for the same setting reaches the
+	// harness alongside kairo's, and the harness picks one.
+	Competes Effect = "competes with kairo"
+)
+
+// Conflict is an externally defined variable that conflicts with kairo.
+type Conflict struct {
+	Key    string
+	Source string
+	Effect Effect
+}
+
+// String renders the conflict as a single human-readable line.
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s from %s %s", c.Key, c.Source, c.Effect)
+}
+
+// Input is the set of environments compared by Check.
+type Input struct {
+	// Injected holds the variables kairo sets for the harness.
+	Injected map[string]string
+	// Ambient is the environment kairo was started with.
+	Ambient map[string]string
+	// Settings is the env block of Claude Code's settings.json, which Claude
+	// Code applies on top of its process environment. Nil for other harnesses.
+	Settings map[string]string
+	// SettingsFrom maps each Settings variable to the settings file that
+	// defines it. Variables without an entry are reported as from
+	// settings.json.
+	SettingsFrom map[string]string
+	// RCExports maps exported variable names to the shell rc file defining them.
+	RCExports map[string]string
+}
+
+// credentialKeys are the variables Anthropic-compatible harnesses read
+// credentials from. Defining one while kairo injects another is ambiguous.
+var credentialKeys = []string{"ANTHROPIC_AUTH_TOKEN", "ANTHROPIC_API_KEY"}
+
+// Check returns the conflicts between kairo's injected variables and the
+// external definitions in in, sorted by key.
+func Check(in Input) []Conflict {
+	var out []Conflict
+
+	for key, val := range in.Settings {
+		injected, ok := in.Injected[key]
+		if ok && injected != val {
+			out = append(out, Conflict{Key: key, Source: in.settingsSource(key), Effect: Overrides})
+		}
+	}
+
+	for key, val := range in.Ambient {
+		injected, ok := in.Injected[key]
+		if !ok || injected == val {
+			continue
+		}
+		source := "environment"
+		if rc, ok := in.RCExports[key]; ok {
+			source = rc
+		}
+		out = append(out, Conflict{Key: key, Source: source, Effect: Overridden})
+	}
+
+	out = append(out, credentialConflicts(in)...)
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Key != out[j].Key {
+			return out[i].Key < out[j].Key
+		}
+
+		return out[i].Source < out[j].Source
+	})
+
+	return out
+}
+
+// settingsSource names the settings file defining key.
+func (in Input) settingsSource(key string) string {
+	if path, ok := in.SettingsFrom[key]; ok {
+		return path
+	}
+
+	return "settings.json"
+}
+
+// Value returns the external value behind c, the one kairo's value competes
+// with, from the settings or the environment it was found in.
+func (in Input) Value(c Conflict) string {
+	if v, ok := in.Settings[c.Key]; ok && c.Source == in.settingsSource(c.Key) {
+		return v
+	}
+
+	return in.Ambient[c.Key]
+}
+
+// credentialConflicts reports credential variables that reach the harness
+// alongside a different credential variable injected by kairo.
+func credentialConflicts(in Input) []Conflict {
+	injectedCred := ""
+	for _, k := range credentialKeys {
+		if _, ok := in.Injected[k]; ok {
+			injectedCred = k
+
+			break
+		}
+	}
+	if injectedCred == "" {
+		return nil
+	}
+
+	var out []Conflict
+	for _, k := range credentialKeys {
+		if k == injectedCred {
+			continue
+		}
+		if _, ok := in.Settings[k]; ok {
+			out = append(out, Conflict{Key: k, Source: in.settingsSource(k), Effect: Competes})
+		}
+		if _, ok := in.Ambient[k]; ok {
+			source := "environment"
+			if rc, ok := in.RCExports[k]; ok {
+				source = rc
+			}
+			out = append(out, Conflict{Key: k, Source: source, Effect: Competes})
+		}
+	}
+
+	return out
+}
+
+var (
+	posixExport = regexp.MustCompile(`^\s*export\s+([A-Za-z_][A-Za-z0-9_]*)=`)
+	fishExport  = regexp.MustCompile(`^\s*set\s+(?:-[a-zA-Z]*x[a-zA-Z]*\s+)([A-Za-z_][A-Za-z0-9_]*)\s`)
+)
+
+// RCFiles returns the shell startup files scanned for exports, relative to home.
+func RCFiles(home string) []string {
+	return []string{
+		filepath.Join(home, ".profile"),
+		filepath.Join(home, ".bashrc"),
+		filepath.Join(home, ".bash_profile"),
+		filepath.Join(home, ".zshrc"),
+		filepath.Join(home, ".zprofile"),
+		filepath.Join(home, ".config", "fish", "config.fish"),
+	}
+}
+
+// ScanRCExports returns the variables with the given prefix exported by the
+// files in paths, mapped to the first file defining each. Unreadable files
+// are skipped.
+func ScanRCExports(paths []string, prefix string) map[string]string {
+	out := make(map[string]string)
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			m := posixExport.FindStringSubmatch(line)
+			if m == nil {
+				m = fishExport.FindStringSubmatch(line)
+			}
+			if m == nil || !strings.HasPrefix(m[1], prefix) {
+				continue
+			}
+			if _, seen := out[m[1]]; !seen {
+				out[m[1]] = path
+			}
+		}
+		f.Close()
+	}
+
+	return out
+}
+
+// sensitiveMarkers are substrings of variable names whose values are secret.
+var sensitiveMarkers = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "CREDENTIAL"}
+
+// IsSensitive reports whether the variable name suggests a secret value.
+func IsSensitive(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, m := range sensitiveMarkers {
+		if strings.Contains(upper, m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Mask hides a secret value, keeping only its last four characters for
+// values long enough that doing so reveals little.
+func Mask(value string) string {
+	if len(value) < 12 {
+		return "****"
+	}
+
+	return "****" + value[len(value)-4:]
+}
@@ -0,0 +1,163 @@
+package envcheck
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Input
+		want []Conflict
+	}{
+		{
+			name: "no external definitions",
+			in: Input{
+				Injected: map[string]string{"ANTHROPIC_BASE_URL": "https://a"},
+				Ambient:  map[string]string{"PATH": "/bin"},
+			},
+			want: nil,
+		},
+		{
+			name: "ambient value overridden",
+			in: Input{
+				Injected: map[string]string{"ANTHROPIC_BASE_URL": "https://a"},
+				Ambient:  map[string]string{"ANTHROPIC_BASE_URL": "https://b"},
+			},
+			want: []Conflict{{Key: "ANTHROPIC_BASE_URL", Source: "environment", Effect: Overridden}},
+		},
+		{
+			name: "identical ambient value ignored",
+			in: Input{
+				Injected: map[string]string{"ANTHROPIC_BASE_URL": "https://a"},
+				Ambient:  map[string]string{"ANTHROPIC_BASE_URL": "https://a"},
+			},
+			want: nil,
+		},
+		{
+			name: "rc file attributed",
+			in: Input{
+				Injected:  map[string]string{"ANTHROPIC_MODEL": "m1"},
+				Ambient:   map[string]string{"ANTHROPIC_MODEL": "m2"},
+				RCExports: map[string]string{"ANTHROPIC_MODEL": "/home/u/.zshrc"},
+			},
+			want: []Conflict{{Key: "ANTHROPIC_MODEL", Source: "/home/u/.zshrc", Effect: Overridden}},
+		},
+		{
+			name: "settings overrides kairo",
+			in: Input{
+				Injected: map[string]string{"ANTHROPIC_BASE_URL": "https://a"},
+				Settings: map[string]string{"ANTHROPIC_BASE_URL": "https://b"},
+			},
+			want: []Conflict{{Key: "ANTHROPIC_BASE_URL", Source: "settings.json", Effect: Overrides}},
+		},
+		{
+			name: "competing credential",
+			in: Input{
+				Injected: map[string]string{"ANTHROPIC_AUTH_TOKEN": "t"},
+				Ambient:  map[string]string{"ANTHROPIC_API_KEY": "k"},
+				Settings: map[string]string{"ANTHROPIC_API_KEY": "k"},
+			},
+			want: []Conflict{
+				{Key: "ANTHROPIC_API_KEY", Source: "environment", Effect: Competes},
+				{Key: "ANTHROPIC_API_KEY", Source: "settings.json", Effect: Competes},
+			},
+		},
+		{
+			name: "settings file attributed",
+			in: Input{
+				Injected:     map[string]string{"ANTHROPIC_BASE_URL": "https://a"},
+				Settings:     map[string]string{"ANTHROPIC_BASE_URL": "https://b"},
+				SettingsFrom: map[string]string{"ANTHROPIC_BASE_URL": "/repo/.claude/settings.local.json"},
+			},
+			want: []Conflict{{Key: "ANTHROPIC_BASE_URL", Source: "/repo/.claude/settings.local.json", Effect: Overrides}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Check(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanRCExports(t *testing.T) {
+	dir := t.TempDir()
+	bashrc := filepath.Join(dir, ".bashrc")
+	fish := filepath.Join(dir, "config.fish")
+	if err := os.WriteFile(bashrc, []byte(`# comment
+export ANTHROPIC_BASE_URL=https://example.com
+  export ANTHROPIC_MODEL="m"
+export OTHER=1
+# export ANTHROPIC_API_KEY=commented
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fish, []byte("set -gx ANTHROPIC_API_KEY abc\nset -gx ANTHROPIC_MODEL m\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got := ScanRCExports([]string{bashrc, filepath.Join(dir, "missing"), fish}, "ANTHROPIC_")
+	want := map[string]string{
+		"ANTHROPIC_BASE_URL": bashrc,
+		"ANTHROPIC_MODEL":    bashrc,
+		"ANTHROPIC_API_KEY":  fish,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScanRCExports() = %v, want %v", got, want)
+	}
+}
+
+func TestIsSensitive(t *testing.T) {
+	tests := map[string]bool{
+		"ANTHROPIC_AUTH_TOKEN": true,
+		"ZAI_API_KEY":          true,
+		"DB_PASSWORD":          true,
+		"ANTHROPIC_BASE_URL":   false,
+		"PATH":                 false,
+	}
+	for key, want := range tests {
+		if got := IsSensitive(key); got != want {
+			t.Errorf("IsSensitive(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", "****"},
+		{"short", "****"},
+		{"sk-ant-1234567890abcd", "****abcd"},
+	}
+	for _, tt := range tests {
+		if got := Mask(tt.in); got != tt.want {
+			t.Errorf("Mask(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestInputValue(t *testing.T) {
+	in := Input{
+		Injected:     map[string]string{"ANTHROPIC_BASE_URL": "https://a"},
+		Ambient:      map[string]string{"ANTHROPIC_BASE_URL": "https://env"},
+		Settings:     map[string]string{"ANTHROPIC_BASE_URL": "https://settings"},
+		SettingsFrom: map[string]string{"ANTHROPIC_BASE_URL": "/home/u/.claude/settings.json"},
+	}
+
+	got := map[string]string{}
+	for _, c := range Check(in) {
+		got[c.Source] = in.Value(c)
+	}
+	want := map[string]string{"environment": "https://env", "/home/u/.claude/settings.json": "https://settings"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Value() by source = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+// Package envsnapshot records the sanitized environment kairo injects for a
+// provider switch so that the same setup can be reproduced later, on another
+// machine, without carrying any secret values.
+package envsnapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/envcheck"
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// FormatVersion is the snapshot format written by this version of kairo.
+const FormatVersion = 1
+
+// Snapshot is the reproducible part of a provider switch.
+type Snapshot struct {
+	Version        int       `json:"version"`
+	CreatedAt      time.Time `json:"created_at"`
+	Provider       string    `json:"provider"`
+	ProviderName   string    `json:"provider_name"`
+	Harness        string    `json:"harness"`
+	HarnessVersion string    `json:"harness_version,omitempty"`
+	BaseURL        string    `json:"base_url"`
+	Model          string    `json:"model"`
+	// Env holds the injected variables whose values are safe to share.
+	Env map[string]string `json:"env"`
+	// Redacted lists injected variables whose values were left out because
+	// they look like credentials.
+	Redacted []string `json:"redacted,omitempty"`
+}
+
+// Sanitize splits env into variables that can be recorded and the sorted
+// names of those that look like credentials.
+func Sanitize(env map[string]string) (map[string]string, []string) {
+	kept := make(map[string]string, len(env))
+	var redacted []string
+	for k, v := range env {
+		if envcheck.IsSensitive(k) {
+			redacted = append(redacted, k)
+
+			continue
+		}
+		kept[k] = v
+	}
+	sort.Strings(redacted)
+
+	return kept, redacted
+}
+
+// EnvVars returns the recorded variables as sorted KEY=value entries.
+func (s Snapshot) EnvVars() []string {
+	vars := make([]string, 0, len(s.Env))
+	for k, v := range s.Env {
+		vars = append(vars, k+"="+v)
+	}
+	sort.Strings(vars)
+
+	return vars
+}
+
+// Write encodes s as indented JSON.
+func Write(w io.Writer, s Snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return errors.WrapError(errors.RuntimeError, "failed to write environment snapshot", err)
+	}
+
+	return nil
+}
+
+// Read decodes a snapshot written by Write. Unknown fields and newer format
+// versions are rejected rather than silently reproducing a partial setup.
+func Read(r io.Reader) (Snapshot, error) {
+	var s Snapshot
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return Snapshot{}, errors.WrapError(errors.ValidationError, "invalid environment snapshot", err)
+	}
+	if s.Version < 1 || s.Version > FormatVersion {
+		return Snapshot{}, errors.NewError(errors.ValidationError,
+			fmt.Sprintf("unsupported environment snapshot version %d", s.Version)).
+			WithContext("hint", "upgrade kairo to read snapshots from newer versions")
+	}
+	if s.Provider == "" || s.Harness == "" {
+		return Snapshot{}, errors.NewError(errors.ValidationError,
+			"environment snapshot is missing its provider or harness")
+	}
+
+	return s, nil
+}
@@ -0,0 +1,70 @@
+package envsnapshot
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSanitize(t *testing.T) {
+	kept, redacted := Sanitize(map[string]string{
+		"ANTHROPIC_BASE_URL":   "https://api.z.ai/api/anthropic",
+		"ANTHROPIC_AUTH_TOKEN": "sk-secret",
+		"ZAI_API_KEY":          "sk-secret",
+	})
+
+	if kept["ANTHROPIC_BASE_URL"] == "" || len(kept) != 1 {
+		t.Errorf("kept = %v, want only the base URL", kept)
+	}
+	if !slices.Equal(redacted, []string{"ANTHROPIC_AUTH_TOKEN", "ZAI_API_KEY"}) {
+		t.Errorf("redacted = %v", redacted)
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	want := Snapshot{
+		Version:        FormatVersion,
+		CreatedAt:      time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Provider:       "zai",
+		ProviderName:   "Z.AI",
+		Harness:        "claude",
+		HarnessVersion: "2.1.0",
+		BaseURL:        "https://api.z.ai/api/anthropic",
+		Model:          "glm-5.1",
+		Env:            map[string]string{"ANTHROPIC_MODEL": "glm-5.1"},
+		Redacted:       []string{"ANTHROPIC_AUTH_TOKEN"},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "sk-") {
+		t.Fatalf("snapshot contains a secret: %s", buf.String())
+	}
+
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if got.Provider != want.Provider || got.HarnessVersion != want.HarnessVersion || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+	if vars := got.EnvVars(); !slices.Equal(vars, []string{"ANTHROPIC_MODEL=glm-5.1"}) {
+		t.Errorf("EnvVars() = %v", vars)
+	}
+}
+
+func TestReadRejects(t *testing.T) {
+	for name, data := range map[string]string{
+		"future version": `{"version": 99, "provider": "zai", "harness": "claude"}`,
+		"unknown field":  `{"version": 1, "provider": "zai", "harness": "claude", "api_key": "x"}`,
+		"no provider":    `{"version": 1, "harness": "claude"}`,
+	} {
+		if _, err := Read(strings.NewReader(data)); err == nil {
+			t.Errorf("Read(%s) expected error", name)
+		}
+	}
+}
@@ -47,3 +47,41 @@ func Merge(envs ...[]string) []string {
 
 	return out
 }
+
+// Filter returns the entries of env whose key matches one of the names in
+// allow. A name ending in '*' matches every key with that prefix. Keys are
+// compared case-insensitively when caseFold is set, as on Windows.
+func Filter(env, allow []string, caseFold bool) []string {
+	out := make([]string, 0, len(env))
+	for _, e := range env {
+		idx := strings.IndexByte(e, '=')
+		if idx <= 0 {
+			continue
+		}
+		if allowed(e[:idx], allow, caseFold) {
+			out = append(out, e)
+		}
+	}
+
+	return out
+}
+
+func allowed(key string, allow []string, caseFold bool) bool {
+	if caseFold {
+		key = strings.ToUpper(key)
+	}
+	for _, name := range allow {
+		if caseFold {
+			name = strings.ToUpper(name)
+		}
+		if prefix, ok := strings.CutSuffix(name, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == name {
+			return true
+		}
+	}
+
+	return false
+}
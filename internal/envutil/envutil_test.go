@@ -29,3 +29,23 @@ func TestMerge(t *testing.T) {
 		})
 	}
 }
+
+func TestFilter(t *testing.T) {
+	env := []string{"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C", "LC_CTYPE=UTF-8", "AWS_SECRET_ACCESS_KEY=x", "novalue"}
+
+	got := Filter(env, []string{"PATH", "LC_*"}, false)
+	want := []string{"PATH=/usr/bin", "LC_ALL=C", "LC_CTYPE=UTF-8"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter() = %v, want %v", got, want)
+	}
+
+	if got := Filter([]string{"Path=C:\\Windows"}, []string{"PATH"}, false); len(got) != 0 {
+		t.Errorf("Filter() case-sensitive = %v, want none", got)
+	}
+	if got := Filter([]string{"Path=C:\\Windows"}, []string{"PATH"}, true); len(got) != 1 {
+		t.Errorf("Filter() case-insensitive = %v, want Path", got)
+	}
+	if got := Filter(env, nil, false); len(got) != 0 {
+		t.Errorf("Filter() with no names = %v, want none", got)
+	}
+}
@@ -30,6 +30,14 @@ var ErrConfigNotFound = errors.New("configuration file not found")
 // ErrUserCancelled is returned when the user cancels an interactive prompt.
 var ErrUserCancelled = errors.New("user canceled input")
 
+// ErrKeyExists is returned when generating an encryption key would replace
+// an existing one.
+var ErrKeyExists = errors.New("encryption key already exists")
+
+// ErrKeyLocked is returned when the encryption key file is itself encrypted
+// with a passphrase and no running agent holds the unlocked key.
+var ErrKeyLocked = errors.New("encryption key is passphrase-protected")
+
 // ErrBinaryOutdated is returned when the configuration file contains fields
 // not recognized by this binary version, indicating an upgrade is needed.
 var ErrBinaryOutdated = errors.New("your installed kairo binary is outdated")
@@ -0,0 +1,123 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// sentinelSuggestions maps well-known errors to the next step for the user.
+var sentinelSuggestions = []struct {
+	err        error
+	suggestion string
+}{
+	{ErrConfigNotFound, "run 'kairo setup' to create a configuration"},
+	{ErrBinaryOutdated, "run 'kairo update' to install the latest version"},
+	{ErrKeyExists, "run 'kairo setup --reset-secrets --force' to replace the key"},
+	{ErrKeyLocked, "run 'kairo agent start' to unlock the key for this session"},
+	{context.DeadlineExceeded, "raise --timeout, or use --timeout 0 to disable it"},
+}
+
+// typeSuggestions is the fallback next step for each error type.
+var typeSuggestions = map[ErrorType]string{
+	ConfigError:       "run 'kairo doctor' to check config.yaml",
+	CryptoError:       "run 'kairo doctor' to check age.key and secrets.age",
+	ValidationError:   "run the command with --help to see the accepted values",
+	ProviderError:     "run 'kairo list' to see configured providers",
+	FileSystemError:   "check the permissions of the config directory",
+	NetworkError:      "check your network connection, then run 'kairo status'",
+	RuntimeError:      "run the command again with --verbose for details",
+	VerificationError: "download the file again; if it still fails, do not install it",
+}
+
+// Suggest returns one actionable next step for err, or "" for nil and
+// ErrUserCancelled. A "hint" context on the outermost KairoError that has one
+// wins; otherwise the suggestion follows from a known sentinel error, then
+// from the type of the innermost KairoError and its "provider" or "path"
+// context.
+func Suggest(err error) string {
+	if err == nil || errors.Is(err, ErrUserCancelled) {
+		return ""
+	}
+
+	var innermost *KairoError
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		ke, ok := e.(*KairoError)
+		if !ok {
+			continue
+		}
+		if hint := ke.Context["hint"]; hint != "" {
+			return hint
+		}
+		innermost = ke
+	}
+
+	for _, s := range sentinelSuggestions {
+		if errors.Is(err, s.err) {
+			return s.suggestion
+		}
+	}
+
+	if innermost == nil {
+		return typeSuggestions[RuntimeError]
+	}
+	switch innermost.Type {
+	case ProviderError:
+		if p := contextValue(err, "provider"); p != "" {
+			return fmt.Sprintf("run 'kairo setup --provider %s' to configure it", p)
+		}
+	case FileSystemError:
+		if path := contextValue(err, "path"); path != "" {
+			return fmt.Sprintf("check that %s exists and that you can read and write it", path)
+		}
+	}
+	if s, ok := typeSuggestions[innermost.Type]; ok {
+		return s
+	}
+
+	return typeSuggestions[RuntimeError]
+}
+
+// contextValue returns the first value of key in the KairoErrors of err's chain.
+func contextValue(err error, key string) string {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if ke, ok := e.(*KairoError); ok && ke.Context[key] != "" {
+			return ke.Context[key]
+		}
+	}
+
+	return ""
+}
+
+// Describe returns err's message for display next to Suggest: like Error,
+// but without "hint" context and with context keys in sorted order.
+func Describe(err error) string {
+	if err == nil {
+		return ""
+	}
+	ke, ok := err.(*KairoError)
+	if !ok {
+		return err.Error()
+	}
+
+	var b strings.Builder
+	b.WriteString(ke.Message)
+	if ke.Cause != nil {
+		fmt.Fprintf(&b, ": %s", Describe(ke.Cause))
+	}
+
+	var parts []string
+	for _, k := range slices.Sorted(maps.Keys(ke.Context)) {
+		if k != "hint" {
+			parts = append(parts, k+"="+ke.Context[k])
+		}
+	}
+	if len(parts) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(parts, ", "))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	inner := WrapError(CryptoError, "failed to decrypt", errors.New("no identity matched")).
+		WithContext("hint", "inner hint")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"cancelled", fmt.Errorf("prompt: %w", ErrUserCancelled), ""},
+		{"hint", inner, "inner hint"},
+		{"outer hint wins", WrapError(CryptoError, "outer", inner).WithContext("hint", "outer hint"), "outer hint"},
+		{"inner hint through wrapper", WrapError(ConfigError, "outer", inner), "inner hint"},
+		{"sentinel", WrapError(ConfigError, "load failed", ErrConfigNotFound), "run 'kairo setup' to create a configuration"},
+		{"locked key", WrapError(CryptoError, "cannot use key", ErrKeyLocked),
+			"run 'kairo agent start' to unlock the key for this session"},
+		{"deadline", fmt.Errorf("probe: %w", context.DeadlineExceeded), "raise --timeout, or use --timeout 0 to disable it"},
+		{"provider", NewError(ProviderError, "not configured").WithContext("provider", "zai"),
+			"run 'kairo setup --provider zai' to configure it"},
+		{"path", FileError("cannot write", "/tmp/x", errors.New("denied")), "check that /tmp/x exists and that you can read and write it"},
+		{"innermost type", WrapError(RuntimeError, "outer", NewError(NetworkError, "timeout")), typeSuggestions[NetworkError]},
+		{"plain error", errors.New("boom"), typeSuggestions[RuntimeError]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Suggest(tt.err); got != tt.want {
+				t.Errorf("Suggest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	err := WrapError(ConfigError, "load failed",
+		NewError(FileSystemError, "read failed").WithContext("path", "/c").WithContext("hint", "check it")).
+		WithContext("provider", "zai").WithContext("config_dir", "/c")
+
+	got := Describe(err)
+	want := "load failed: read failed (path=/c) (config_dir=/c, provider=zai)"
+	if got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+	if strings.Contains(got, "hint") {
+		t.Error("Describe() should leave out the hint")
+	}
+	if Describe(nil) != "" || Describe(errors.New("plain")) != "plain" {
+		t.Error("Describe() should pass through nil and plain errors")
+	}
+}
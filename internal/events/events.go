@@ -0,0 +1,158 @@
+// Package events writes the lifecycle of a provider switch as a stream of
+// JSON lines, one object per event, so that IDE integrations and wrappers
+// can show kairo's progress without parsing its terminal output.
+//
+// The format is stable: fields are only ever added, and a change that
+// breaks readers would raise Version.
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// Version is the version of the event format, sent as "v" in every event.
+const Version = 1
+
+// Event names, in the order a switch emits them. A switch that stops before
+// the harness exits ends with an Error event instead of ChildExit.
+const (
+	// Prepare names the provider, harness, and model about to be used.
+	Prepare = "prepare"
+	// Decrypt follows loading the secrets file.
+	Decrypt = "decrypt"
+	// WrapperCreated follows writing the auth directory and wrapper that
+	// hand the API key to the harness. It is left out when the harness is
+	// started directly.
+	WrapperCreated = "wrapper-created"
+	// Exec is sent just before the harness starts.
+	Exec = "exec"
+	// ChildExit carries the exit code of the harness.
+	ChildExit = "child-exit"
+	// Error reports the stage that stopped the switch.
+	Error = "error"
+)
+
+// Event is one line of the stream.
+type Event struct {
+	V     int       `json:"v"`
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+
+	Provider string `json:"provider,omitempty"`
+	Harness  string `json:"harness,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// Wrapper tells on Exec whether the harness is started through the
+	// wrapper.
+	Wrapper *bool `json:"wrapper,omitempty"`
+	// Code is the exit code on ChildExit: the harness's status, or 128
+	// plus the signal number when a signal killed it.
+	Code   *int   `json:"code,omitempty"`
+	Signal string `json:"signal,omitempty"`
+	// Stage is the event that failed, on Error.
+	Stage string `json:"stage,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Emitter writes events to a stream. A nil Emitter discards them, so
+// callers need not check whether a stream was requested.
+type Emitter struct {
+	mu     sync.Mutex
+	w      io.WriteCloser
+	failed bool
+	now    func() time.Time
+	// last is the name of the last event emitted.
+	last string
+}
+
+// New returns an Emitter writing to w.
+func New(w io.WriteCloser) *Emitter {
+	return &Emitter{w: w, now: time.Now}
+}
+
+// Open returns an Emitter for target: an open file descriptor given by its
+// number, such as 3 from a shell's 3>&1, or the path of a file or named
+// pipe. Opening a named pipe waits for its reader.
+func Open(target string) (*Emitter, error) {
+	if fd, err := strconv.Atoi(target); err == nil {
+		f, err := openFD(fd)
+		if err != nil {
+			return nil, err
+		}
+
+		return New(f), nil
+	}
+
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, errors.FileError("failed to open event stream", target, err)
+	}
+
+	return New(f), nil
+}
+
+// Emit writes ev with the format version and the current time. After a
+// failed write, such as to a pipe whose reader went away, further events
+// are dropped: the stream must never get in the way of the switch.
+func (e *Emitter) Emit(ev Event) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.failed {
+		return
+	}
+	e.last = ev.Event
+
+	ev.V = Version
+	ev.Time = e.now().UTC()
+	line, err := json.Marshal(ev)
+	if err != nil {
+		e.failed = true
+
+		return
+	}
+	if _, err := e.w.Write(append(line, '\n')); err != nil {
+		e.failed = true
+	}
+}
+
+// Finish ends a stream that has not ended with ChildExit or Error with an
+// Error event for the stage after the last one emitted, giving reason.
+func (e *Emitter) Finish(reason string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	last := e.last
+	e.mu.Unlock()
+
+	stage := Exec
+	switch last {
+	case ChildExit, Error:
+		return
+	case "":
+		stage = Prepare
+	case Prepare:
+		stage = Decrypt
+	}
+	e.Emit(Event{Event: Error, Stage: stage, Error: reason})
+}
+
+// Close closes the stream.
+func (e *Emitter) Close() error {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.w.Close()
+}
@@ -0,0 +1,180 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// buffer is an io.WriteCloser over a bytes.Buffer.
+type buffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *buffer) Close() error {
+	b.closed = true
+
+	return nil
+}
+
+// failingWriter fails every write.
+type failingWriter struct{ writes int }
+
+func (f *failingWriter) Write([]byte) (int, error) {
+	f.writes++
+
+	return 0, errors.New("broken pipe")
+}
+
+func (f *failingWriter) Close() error { return nil }
+
+func decode(t *testing.T, b *buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	sc := bufio.NewScanner(&b.Buffer)
+	for sc.Scan() {
+		var m map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			t.Fatalf("line %q is not JSON: %v", sc.Text(), err)
+		}
+		out = append(out, m)
+	}
+
+	return out
+}
+
+func TestEmit(t *testing.T) {
+	var b buffer
+	em := New(&b)
+	at := time.Date(2026, 5, 4, 12, 0, 0, 0, time.FixedZone("x", 2*3600))
+	em.now = func() time.Time { return at }
+
+	wrapped, code := true, 3
+	em.Emit(Event{Event: Prepare, Provider: "zai", Harness: "claude", Model: "glm-5.1"})
+	em.Emit(Event{Event: Exec, Wrapper: &wrapped})
+	em.Emit(Event{Event: ChildExit, Code: &code})
+
+	got := decode(t, &b)
+	if len(got) != 3 {
+		t.Fatalf("got %d events, want 3", len(got))
+	}
+	for _, ev := range got {
+		if ev["v"] != float64(Version) {
+			t.Errorf("v = %v, want %d", ev["v"], Version)
+		}
+		if ev["time"] != "2026-05-04T10:00:00Z" {
+			t.Errorf("time = %v, want UTC", ev["time"])
+		}
+	}
+	if got[0]["provider"] != "zai" || got[0]["harness"] != "claude" || got[0]["model"] != "glm-5.1" {
+		t.Errorf("prepare = %v", got[0])
+	}
+	if _, ok := got[0]["code"]; ok {
+		t.Errorf("prepare carries code: %v", got[0])
+	}
+	if got[1]["wrapper"] != true {
+		t.Errorf("exec = %v, want wrapper true", got[1])
+	}
+	if got[2]["code"] != float64(3) {
+		t.Errorf("child-exit = %v, want code 3", got[2])
+	}
+}
+
+func TestEmit_ZeroCode(t *testing.T) {
+	var b buffer
+	em := New(&b)
+	code := 0
+	em.Emit(Event{Event: ChildExit, Code: &code})
+
+	got := decode(t, &b)
+	if len(got) != 1 || got[0]["code"] != float64(0) {
+		t.Errorf("got %v, want code 0", got)
+	}
+}
+
+func TestEmit_StopsAfterWriteError(t *testing.T) {
+	w := &failingWriter{}
+	em := New(w)
+	em.Emit(Event{Event: Prepare})
+	em.Emit(Event{Event: Decrypt})
+
+	if w.writes != 1 {
+		t.Errorf("writes = %d, want 1", w.writes)
+	}
+}
+
+func TestFinish(t *testing.T) {
+	tests := []struct {
+		name    string
+		emitted []string
+		want    string
+	}{
+		{"nothing emitted", nil, Prepare},
+		{"after prepare", []string{Prepare}, Decrypt},
+		{"after decrypt", []string{Prepare, Decrypt}, Exec},
+		{"after wrapper", []string{Prepare, Decrypt, WrapperCreated}, Exec},
+		{"after child exit", []string{Prepare, Decrypt, Exec, ChildExit}, ""},
+		{"after error", []string{Prepare, Error}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b buffer
+			em := New(&b)
+			for _, name := range tt.emitted {
+				em.Emit(Event{Event: name})
+			}
+			em.Finish("stopped")
+
+			got := decode(t, &b)
+			if tt.want == "" {
+				if len(got) != len(tt.emitted) {
+					t.Errorf("Finish added an event: %v", got[len(got)-1])
+				}
+
+				return
+			}
+			last := got[len(got)-1]
+			if last["event"] != Error || last["stage"] != tt.want || last["error"] != "stopped" {
+				t.Errorf("last = %v, want error at stage %s", last, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilEmitter(t *testing.T) {
+	var em *Emitter
+	em.Emit(Event{Event: Prepare})
+	em.Finish("stopped")
+	if err := em.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+}
+
+func TestOpen_Path(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	if err := os.WriteFile(path, []byte("earlier\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	em, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() = %v", err)
+	}
+	em.Emit(Event{Event: Prepare})
+	if err := em.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("earlier\n{\"v\":1,")) {
+		t.Errorf("file = %q, want the event appended", data)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+
+package events
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// openFD returns the inherited file descriptor fd, marked close-on-exec so
+// the harness does not hold the stream open after kairo exits.
+func openFD(fd int) (*os.File, error) {
+	if fd < 3 {
+		return nil, errors.NewError(errors.ValidationError,
+			fmt.Sprintf("file descriptor %d is stdin, stdout, or stderr", fd)).
+			WithContext("hint", "pass a descriptor of 3 or more, such as --events-fd 3 with 3>events.jsonl")
+	}
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fd, &st); err != nil {
+		return nil, errors.WrapError(errors.ValidationError,
+			fmt.Sprintf("file descriptor %d is not open", fd), err).
+			WithContext("hint", fmt.Sprintf("open it in the shell that runs kairo, such as %d>events.jsonl", fd))
+	}
+	syscall.CloseOnExec(fd)
+
+	return os.NewFile(uintptr(fd), fmt.Sprintf("fd %d", fd)), nil
+}
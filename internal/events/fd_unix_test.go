@@ -0,0 +1,62 @@
+//go:build !windows
+
+package events
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestOpen_FD(t *testing.T) {
+	for _, target := range []string{"0", "1", "2"} {
+		if _, err := Open(target); err == nil {
+			t.Errorf("Open(%q) succeeded, want an error", target)
+		}
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	fd, err := syscall.Dup(int(w.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	em, err := Open(strconv.Itoa(fd))
+	if err != nil {
+		t.Fatalf("Open(fd %d) = %v", fd, err)
+	}
+	em.Emit(Event{Event: Prepare})
+	if err := em.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(line, `"event":"prepare"`) {
+		t.Errorf("read %q, want a prepare event", line)
+	}
+}
+
+func TestOpen_ClosedFD(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := int(w.Fd())
+	r.Close()
+	w.Close()
+
+	if _, err := Open(strconv.Itoa(fd)); err == nil {
+		t.Errorf("Open(%d) of a closed descriptor succeeded", fd)
+	}
+}
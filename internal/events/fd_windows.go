@@ -0,0 +1,18 @@
+//go:build windows
+
+package events
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// openFD refuses file descriptor numbers, which Windows programs do not
+// inherit the way Unix ones do.
+func openFD(fd int) (*os.File, error) {
+	return nil, errors.NewError(errors.ValidationError,
+		fmt.Sprintf("file descriptor %d cannot be used on Windows", fd)).
+		WithContext("hint", `pass the path of a named pipe instead, such as \\.\pipe\kairo-events`)
+}
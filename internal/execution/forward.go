@@ -0,0 +1,112 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// ExitError reports a harness that ran and exited unsuccessfully. Code is
+// the status kairo exits with in turn: the harness's own exit status, or
+// 128 plus the signal number when a signal killed it, as a shell reports.
+type ExitError struct {
+	Code int
+	// Signal is the signal that killed the harness, or nil if it exited.
+	Signal os.Signal
+}
+
+func (e *ExitError) Error() string {
+	if e.Signal != nil {
+		return fmt.Sprintf("killed by signal: %v", e.Signal)
+	}
+
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Run starts c and waits for it, forwarding the interrupt, termination,
+// and hangup signals kairo receives meanwhile to the harness instead of
+// acting on them.
+//
+// On Linux and macOS the harness runs in its own process group, so a
+// forwarded signal also reaches the processes it started, such as the
+// commands of a nested shell. When kairo owns the terminal, that group is
+// made the terminal's foreground group for the run, so keys like Ctrl-C
+// reach the harness once, directly, and kairo takes the terminal back
+// afterwards. Job control works as if the harness had been started
+// directly: when Ctrl-Z stops it, kairo stops too so the shell sees the job
+// stop, and when the shell continues kairo with fg or bg, kairo continues
+// the harness, in the foreground only if it was given the terminal.
+//
+// On Windows the harness shares kairo's console and receives console
+// control events itself; kairo only waits for it.
+//
+// A harness that exits unsuccessfully yields an *ExitError.
+func Run(c *exec.Cmd) error {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, forwardedSignals...)
+	defer signal.Stop(ch)
+
+	return run(c, ch, controllingTerminal())
+}
+
+// run is Run with the signal source and the terminal descriptor, or -1,
+// given.
+func run(c *exec.Cmd, sigs <-chan os.Signal, tty int) error {
+	restore := prepare(c, tty)
+	if err := c.Start(); err != nil {
+		restore()
+
+		return err
+	}
+
+	done := make(chan struct{})
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for {
+			select {
+			case sig := <-sigs:
+				forward(c.Process, sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := wait(c, tty)
+	close(done)
+	<-forwarded
+	restore()
+
+	return err
+}
+
+// exitError turns a harness's unsuccessful exit, as returned by
+// exec.Cmd.Wait, into an *ExitError and passes other errors through.
+func exitError(err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+		return statusError(status)
+	}
+
+	return &ExitError{Code: exitErr.ExitCode()}
+}
+
+// statusError returns the *ExitError for a harness that ended with status,
+// or nil if it exited successfully.
+func statusError(status syscall.WaitStatus) error {
+	switch {
+	case status.Signaled():
+		return &ExitError{Code: 128 + int(status.Signal()), Signal: status.Signal()}
+	case status.ExitStatus() != 0:
+		return &ExitError{Code: status.ExitStatus()}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build !linux && !darwin && !freebsd && !openbsd && !netbsd && !dragonfly
+
+package execution
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// forwardedSignals are caught only so that kairo outlives them: Windows
+// delivers console control events to the harness directly, and elsewhere the
+// harness stays in kairo's process group and receives terminal signals too.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+func controllingTerminal() int {
+	return -1
+}
+
+func prepare(*exec.Cmd, int) func() {
+	return func() {}
+}
+
+func forward(*os.Process, os.Signal) {}
+
+func wait(c *exec.Cmd, _ int) error {
+	return exitError(c.Wait())
+}
@@ -0,0 +1,106 @@
+package execution
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func skipWithoutSh(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell and signals")
+	}
+}
+
+func TestRunExitStatus(t *testing.T) {
+	skipWithoutSh(t)
+
+	if err := run(exec.Command("sh", "-c", "exit 0"), nil, -1); err != nil {
+		t.Errorf("run() of a successful command = %v", err)
+	}
+
+	err := run(exec.Command("sh", "-c", "exit 7"), nil, -1)
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 7 || exitErr.Signal != nil {
+		t.Errorf("run() = %v, want exit status 7", err)
+	}
+
+	err = run(exec.Command("sh", "-c", "kill -TERM $$"), nil, -1)
+	if !errors.As(err, &exitErr) || exitErr.Code != 128+int(syscall.SIGTERM) || exitErr.Signal != syscall.SIGTERM {
+		t.Errorf("run() of a command killed by SIGTERM = %v, want status %d", err, 128+int(syscall.SIGTERM))
+	}
+
+	err = run(exec.Command(filepath.Join(t.TempDir(), "missing")), nil, -1)
+	if err == nil || errors.As(err, &exitErr) {
+		t.Errorf("run() of a missing binary = %v, want a start error", err)
+	}
+}
+
+// TestRunForwardsToNestedShells checks that a forwarded signal reaches a
+// process two shells below the harness, and that kairo reports how the
+// harness itself ended.
+func TestRunForwardsToNestedShells(t *testing.T) {
+	skipWithoutSh(t)
+
+	dir := t.TempDir()
+	ready := filepath.Join(dir, "ready")
+	caught := filepath.Join(dir, "caught")
+	inner := `trap 'echo "$1" > "$3"; exit 42' INT TERM HUP; : > "$2"; while :; do sleep 0.05; done`
+	tests := []struct {
+		name   string
+		sig    syscall.Signal
+		script string
+		want   int
+	}{
+		// The outer shell waits for the inner one and dies by the signal.
+		{"outer shell killed", syscall.SIGTERM, `sh -c "$0" inner "$@"; exit $?`, 128 + int(syscall.SIGTERM)},
+		// The outer shell traps the signal and passes on the inner shell's
+		// status.
+		{"outer shell exits", syscall.SIGHUP, `trap : HUP; sh -c "$0" inner "$@"; exit $?`, 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Remove(ready)
+			_ = os.Remove(caught)
+			c := exec.Command("sh", "-c", tt.script, inner, tt.sig.String(), ready, caught)
+			sigs := make(chan os.Signal, 1)
+			go func() {
+				deadline := time.Now().Add(10 * time.Second)
+				for time.Now().Before(deadline) {
+					if _, err := os.Stat(ready); err == nil {
+						sigs <- tt.sig
+
+						return
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				sigs <- syscall.SIGKILL
+			}()
+
+			err := run(c, sigs, -1)
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) || exitErr.Code != tt.want {
+				t.Fatalf("run() = %v, want status %d", err, tt.want)
+			}
+			// The innermost shell may outlive the harness by the time its trap
+			// takes to run.
+			var data []byte
+			for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+				if data, err = os.ReadFile(caught); err == nil && len(data) > 0 {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			if string(data) != tt.sig.String()+"\n" {
+				t.Errorf("innermost shell caught %q, %v; want %s", data, err, tt.sig)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd || dragonfly
+
+package execution
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+var forwardedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
+
+// suspendGrace is how long suspendSelf waits for a stop to take effect. The
+// kernel discards a stop signal sent to a process group that no job control
+// shell looks after, and then nothing would continue kairo.
+const suspendGrace = 250 * time.Millisecond
+
+// suspendSelf stops kairo with sig and returns once it is continued. The
+// stop takes effect after kill returns, so it waits for the SIGCONT. Tests
+// replace it so the test binary is not stopped.
+var suspendSelf = func(sig syscall.Signal) {
+	cont := make(chan os.Signal, 1)
+	signal.Notify(cont, syscall.SIGCONT)
+	defer signal.Stop(cont)
+
+	_ = syscall.Kill(os.Getpid(), sig)
+	select {
+	case <-cont:
+	case <-time.After(suspendGrace):
+	}
+}
+
+// controllingTerminal returns the first of stdin, stdout, and stderr that is
+// the controlling terminal with kairo's process group in the foreground, or
+// -1 if there is none, as when kairo runs in the background or without a
+// terminal.
+func controllingTerminal() int {
+	for fd := range 3 {
+		if isForeground(fd) {
+			return fd
+		}
+	}
+
+	return -1
+}
+
+// isForeground reports whether kairo's process group is the foreground
+// group of the terminal tty.
+func isForeground(tty int) bool {
+	pgrp, err := unix.IoctlGetInt(tty, unix.TIOCGPGRP)
+
+	return err == nil && pgrp == unix.Getpgrp()
+}
+
+// setForeground makes pgrp the foreground group of tty. kairo may be in
+// the background when it does, where changing the foreground group would
+// otherwise stop it with SIGTTOU.
+func setForeground(tty, pgrp int) {
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+	_ = unix.IoctlSetPointerInt(tty, unix.TIOCSPGRP, pgrp)
+}
+
+// prepare starts c in a process group of its own, in the foreground of tty
+// when there is one, and returns the function that takes the terminal back.
+func prepare(c *exec.Cmd, tty int) func() {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.Setpgid = true
+	if tty < 0 {
+		return func() {}
+	}
+	c.SysProcAttr.Foreground = true
+	c.SysProcAttr.Ctty = tty
+
+	return func() { setForeground(tty, unix.Getpgrp()) }
+}
+
+// wait waits for the harness to exit. The harness is its process group's
+// leader, so its pid is also the group id.
+//
+// When job control stops the harness, as Ctrl-Z does, kairo takes the
+// terminal back and stops itself with the same signal, so that the shell
+// that started kairo sees the job stop and reclaims the terminal. When the
+// shell continues kairo, kairo continues the harness, handing it the
+// terminal again only if the shell gave it to kairo (fg rather than bg). A
+// harness that kairo's process group cannot stop on its own, because no job
+// control shell started kairo, is continued at once.
+func wait(c *exec.Cmd, tty int) error {
+	pid := c.Process.Pid
+	for {
+		var status syscall.WaitStatus
+		_, err := syscall.Wait4(pid, &status, syscall.WUNTRACED, nil)
+		switch {
+		case errors.Is(err, syscall.EINTR):
+			continue
+		case err != nil:
+			return exitError(c.Wait())
+		case !status.Stopped():
+			// The harness is reaped already; Wait only releases what Start
+			// set up for it.
+			_ = c.Wait()
+
+			return statusError(status)
+		}
+
+		if tty >= 0 {
+			setForeground(tty, unix.Getpgrp())
+		}
+		suspendSelf(status.StopSignal())
+		if tty >= 0 && isForeground(tty) {
+			setForeground(tty, pid)
+		}
+		_ = syscall.Kill(-pid, syscall.SIGCONT)
+	}
+}
+
+// forward sends sig to the harness's process group. A group that has
+// already exited is not an error.
+func forward(p *os.Process, sig os.Signal) {
+	if s, ok := sig.(syscall.Signal); ok {
+		_ = syscall.Kill(-p.Pid, s)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd || dragonfly
+
+package execution
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestRunJobControlStop(t *testing.T) {
+	var stopped []syscall.Signal
+	orig := suspendSelf
+	suspendSelf = func(sig syscall.Signal) { stopped = append(stopped, sig) }
+	defer func() { suspendSelf = orig }()
+
+	// The harness stops itself as Ctrl-Z would, then exits once continued.
+	err := run(exec.Command("sh", "-c", "kill -TSTP $$; exit 3"), nil, -1)
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Errorf("run() = %v, want exit status 3 after the harness was continued", err)
+	}
+	if len(stopped) != 1 || stopped[0] != syscall.SIGTSTP {
+		t.Errorf("kairo stopped itself with %v, want [SIGTSTP]", stopped)
+	}
+}
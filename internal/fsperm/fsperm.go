@@ -0,0 +1,91 @@
+// Package fsperm restricts files and directories holding secrets to their
+// owner on every platform: mode 0600 or 0700 on Unix, and on Windows a
+// protected ACL, which inherits nothing from the parent directory, granting
+// access only to the current user, SYSTEM, and the Administrators group.
+package fsperm
+
+import (
+	stderrors "errors"
+	"os"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// ErrNotOwnerOnly is returned by CheckOwnerOnly when users other than the
+// owner can access a path.
+var ErrNotOwnerOnly = stderrors.New("accessible by other users")
+
+// Restrict makes the file or directory at path accessible only to its owner.
+func Restrict(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.FileError("failed to restrict permissions", path, err)
+	}
+	if err := restrict(path, info.IsDir()); err != nil {
+		return errors.FileError("failed to restrict permissions", path, err)
+	}
+
+	return nil
+}
+
+// MkdirAll creates dir and any missing parents, then restricts dir, but
+// not the parents, to its owner.
+func MkdirAll(dir string) error {
+	if err := os.MkdirAll(dir, constants.DirPermSecure); err != nil {
+		return errors.FileError("failed to create directory", dir, err)
+	}
+
+	return Restrict(dir)
+}
+
+// WriteFile writes data to path, creating it if needed, and restricts it to
+// its owner.
+func WriteFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, constants.FilePermSecure); err != nil {
+		return errors.FileError("failed to write file", path, err)
+	}
+
+	return Restrict(path)
+}
+
+// OpenFile is os.OpenFile for a file only its owner may access. A file it
+// creates is restricted before it is returned.
+func OpenFile(path string, flag int) (*os.File, error) {
+	_, statErr := os.Stat(path)
+	f, err := os.OpenFile(path, flag, constants.FilePermSecure)
+	if err != nil {
+		return nil, err
+	}
+	if os.IsNotExist(statErr) {
+		if err := Restrict(path); err != nil {
+			_ = f.Close()
+
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// CheckOwnerOnly returns an error wrapping ErrNotOwnerOnly, and saying who
+// else can access path, when it is not restricted to its owner.
+func CheckOwnerOnly(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	return checkOwnerOnly(path)
+}
+
+// Blocked reports whether err, from writing to dir or running a file in it,
+// was refused although dir's own permissions allow the current user to do
+// so. That points at endpoint security software or another policy outside
+// the file system permissions, rather than a permission kairo can fix.
+func Blocked(dir string, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return blocked(dir, err)
+}
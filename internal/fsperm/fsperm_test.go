@@ -0,0 +1,79 @@
+package fsperm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestrict(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret")
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{path, dir} {
+		if err := Restrict(p); err != nil {
+			t.Fatalf("Restrict(%s) error = %v", p, err)
+		}
+		if err := CheckOwnerOnly(p); err != nil {
+			t.Errorf("CheckOwnerOnly(%s) after Restrict error = %v", p, err)
+		}
+	}
+
+	if err := Restrict(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Restrict() of a missing path error = nil")
+	}
+}
+
+func TestMkdirAll(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "a", "b")
+	if err := MkdirAll(dir); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := CheckOwnerOnly(dir); err != nil {
+		t.Errorf("CheckOwnerOnly() error = %v", err)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := WriteFile(path, []byte("x")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "x" {
+		t.Errorf("content = %q, want x", data)
+	}
+	if err := CheckOwnerOnly(path); err != nil {
+		t.Errorf("CheckOwnerOnly() error = %v", err)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for range 2 {
+		f, err := OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY)
+		if err != nil {
+			t.Fatalf("OpenFile() error = %v", err)
+		}
+		if _, err := f.WriteString("line\n"); err != nil {
+			t.Fatal(err)
+		}
+		_ = f.Close()
+	}
+	if data, _ := os.ReadFile(path); string(data) != "line\nline\n" {
+		t.Errorf("content = %q, want both lines", data)
+	}
+	if err := CheckOwnerOnly(path); err != nil {
+		t.Errorf("CheckOwnerOnly() error = %v", err)
+	}
+}
+
+func TestCheckOwnerOnly_Missing(t *testing.T) {
+	err := CheckOwnerOnly(filepath.Join(t.TempDir(), "missing"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("CheckOwnerOnly() of a missing path error = %v, want ErrNotExist", err)
+	}
+}
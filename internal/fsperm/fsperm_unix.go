@@ -0,0 +1,49 @@
+//go:build !windows
+
+package fsperm
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"golang.org/x/sys/unix"
+)
+
+func restrict(path string, isDir bool) error {
+	if isDir {
+		return os.Chmod(path, constants.DirPermSecure)
+	}
+
+	return os.Chmod(path, constants.FilePermSecure)
+}
+
+func checkOwnerOnly(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return fmt.Errorf("%w (mode %04o)", ErrNotOwnerOnly, info.Mode().Perm())
+	}
+
+	return nil
+}
+
+// FixHint returns a command that restricts the file at path to its owner.
+func FixHint(path string) string {
+	return "fix with: chmod 600 " + path
+}
+
+// blocked reports a permission error in a directory the mode bits let the
+// user write to and search, as a mandatory access control policy (SELinux,
+// AppArmor), a noexec mount, or endpoint security software would cause.
+func blocked(dir string, err error) bool {
+	if !stderrors.Is(err, fs.ErrPermission) {
+		return false
+	}
+
+	return unix.Access(dir, unix.W_OK|unix.X_OK) == nil
+}
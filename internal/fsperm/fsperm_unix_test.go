@@ -0,0 +1,53 @@
+//go:build !windows
+
+package fsperm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestCheckOwnerOnly_GroupReadable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	err := CheckOwnerOnly(path)
+	if !errors.Is(err, ErrNotOwnerOnly) {
+		t.Fatalf("CheckOwnerOnly() error = %v, want ErrNotOwnerOnly", err)
+	}
+	if !strings.Contains(err.Error(), "0640") {
+		t.Errorf("CheckOwnerOnly() error = %v, want the mode", err)
+	}
+}
+
+func TestBlocked(t *testing.T) {
+	writable := t.TempDir()
+	readOnly := t.TempDir()
+	if err := os.Chmod(readOnly, 0o500); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(readOnly, 0o700) })
+	denied := &os.PathError{Op: "open", Path: "token-1", Err: syscall.EACCES}
+
+	if !Blocked(writable, denied) {
+		t.Error("Blocked() = false for access denied in a writable directory")
+	}
+	if Blocked(writable, &os.PathError{Op: "open", Path: "token-1", Err: syscall.ENOSPC}) {
+		t.Error("Blocked() = true for a full disk")
+	}
+	if Blocked(writable, nil) {
+		t.Error("Blocked() = true without an error")
+	}
+	if os.Geteuid() != 0 && Blocked(readOnly, denied) {
+		t.Error("Blocked() = true for access denied in a read-only directory")
+	}
+}
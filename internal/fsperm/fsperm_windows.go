@@ -0,0 +1,166 @@
+//go:build windows
+
+package fsperm
+
+import (
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// trustedSIDs returns the current user, SYSTEM, and the Administrators
+// group, the only principals an owner-only ACL grants access to.
+func trustedSIDs() ([]*windows.SID, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return nil, err
+	}
+	system, err := windows.CreateWellKnownSid(windows.WinLocalSystemSid)
+	if err != nil {
+		return nil, err
+	}
+	admins, err := windows.CreateWellKnownSid(windows.WinBuiltinAdministratorsSid)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*windows.SID{user.User.Sid, system, admins}, nil
+}
+
+func restrict(path string, isDir bool) error {
+	sids, err := trustedSIDs()
+	if err != nil {
+		return err
+	}
+	inheritance := uint32(windows.NO_INHERITANCE)
+	if isDir {
+		inheritance = windows.SUB_CONTAINERS_AND_OBJECTS_INHERIT
+	}
+	entries := make([]windows.EXPLICIT_ACCESS, len(sids))
+	for i, sid := range sids {
+		entries[i] = windows.EXPLICIT_ACCESS{
+			AccessPermissions: windows.GENERIC_ALL,
+			AccessMode:        windows.GRANT_ACCESS,
+			Inheritance:       inheritance,
+			Trustee: windows.TRUSTEE{
+				TrusteeForm:  windows.TRUSTEE_IS_SID,
+				TrusteeType:  windows.TRUSTEE_IS_UNKNOWN,
+				TrusteeValue: windows.TrusteeValueFromSID(sid),
+			},
+		}
+	}
+	acl, err := windows.ACLFromEntries(entries, nil)
+	if err != nil {
+		return err
+	}
+
+	return windows.SetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION,
+		nil, nil, acl, nil)
+}
+
+func checkOwnerOnly(path string) error {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return err
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return err
+	}
+	if dacl == nil {
+		return fmt.Errorf("%w (no access list, so everyone)", ErrNotOwnerOnly)
+	}
+	sids, err := trustedSIDs()
+	if err != nil {
+		return err
+	}
+
+	var others []string
+	for i := range uint32(dacl.AceCount) {
+		var ace *windows.ACCESS_ALLOWED_ACE
+		if err := windows.GetAce(dacl, i, &ace); err != nil {
+			return err
+		}
+		if ace.Header.AceType != windows.ACCESS_ALLOWED_ACE_TYPE {
+			continue
+		}
+		sid := (*windows.SID)(unsafe.Pointer(&ace.SidStart))
+		if !trusted(sid, sids) {
+			others = append(others, accountName(sid))
+		}
+	}
+	if len(others) > 0 {
+		return fmt.Errorf("%w (%s)", ErrNotOwnerOnly, strings.Join(others, ", "))
+	}
+
+	return nil
+}
+
+func trusted(sid *windows.SID, sids []*windows.SID) bool {
+	for _, s := range sids {
+		if windows.EqualSid(sid, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// accountName returns the DOMAIN\name of sid, or its string form when it
+// cannot be looked up.
+func accountName(sid *windows.SID) string {
+	account, domain, _, err := sid.LookupAccount("")
+	if err != nil {
+		return sid.String()
+	}
+	if domain == "" {
+		return account
+	}
+
+	return domain + `\` + account
+}
+
+// FixHint returns a command that restricts the file at path to the current
+// user.
+func FixHint(path string) string {
+	return `fix with: icacls "` + path + `" /inheritance:r /grant:r "%USERNAME%:F"`
+}
+
+// blocked reports the errors Windows returns when antivirus or application
+// control software stops a file, and access denied in a directory the
+// current user owns, which only a policy outside the ACL explains.
+func blocked(dir string, err error) bool {
+	var errno windows.Errno
+	if !stderrors.As(err, &errno) {
+		return false
+	}
+	switch errno {
+	case windows.ERROR_VIRUS_INFECTED, windows.ERROR_VIRUS_DELETED, windows.ERROR_ACCESS_DISABLED_BY_POLICY:
+		return true
+	case windows.ERROR_ACCESS_DENIED:
+		return ownedByCurrentUser(dir)
+	default:
+		return false
+	}
+}
+
+func ownedByCurrentUser(dir string) bool {
+	sd, err := windows.GetNamedSecurityInfo(dir, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		return false
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return false
+	}
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return false
+	}
+
+	return windows.EqualSid(owner, user.User.Sid)
+}
@@ -0,0 +1,64 @@
+//go:build windows
+
+package fsperm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+// grantEveryone adds read access for the Everyone group to path's ACL.
+func grantEveryone(t *testing.T, path string) {
+	t.Helper()
+	everyone, err := windows.CreateWellKnownSid(windows.WinWorldSid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, _, err := sd.DACL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	acl, err := windows.ACLFromEntries([]windows.EXPLICIT_ACCESS{{
+		AccessPermissions: windows.GENERIC_READ,
+		AccessMode:        windows.GRANT_ACCESS,
+		Inheritance:       windows.NO_INHERITANCE,
+		Trustee: windows.TRUSTEE{
+			TrusteeForm:  windows.TRUSTEE_IS_SID,
+			TrusteeType:  windows.TRUSTEE_IS_WELL_KNOWN_GROUP,
+			TrusteeValue: windows.TrusteeValueFromSID(everyone),
+		},
+	}}, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := windows.SetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION, nil, nil, acl, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckOwnerOnly_Everyone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	grantEveryone(t, path)
+
+	if err := CheckOwnerOnly(path); !errors.Is(err, ErrNotOwnerOnly) {
+		t.Fatalf("CheckOwnerOnly() error = %v, want ErrNotOwnerOnly", err)
+	}
+	if err := Restrict(path); err != nil {
+		t.Fatalf("Restrict() error = %v", err)
+	}
+	if err := CheckOwnerOnly(path); err != nil {
+		t.Errorf("CheckOwnerOnly() after Restrict error = %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package fsutil
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+const (
+	lockPollInterval = 50 * time.Millisecond
+	// lockStaleAfter is how old a lock file must be before it is assumed to
+	// belong to a process that died without releasing it.
+	lockStaleAfter = 30 * time.Second
+)
+
+// Lock acquires an exclusive lock by creating path, waiting until it is free
+// or ctx is done. The returned function releases the lock. It works across
+// processes on every platform because it relies only on O_EXCL creation.
+func Lock(ctx context.Context, path string) (func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+			_ = f.Close()
+
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !stderrors.Is(err, fs.ErrExist) {
+			return nil, errors.FileError("failed to create lock file", path, err)
+		}
+
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			_ = os.Remove(path)
+
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.WrapError(errors.FileSystemError,
+				"timed out waiting for lock", ctx.Err()).
+				WithContext("path", path).
+				WithContext("hint", "another kairo process may be running; remove the file if it is not")
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
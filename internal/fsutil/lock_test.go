@@ -0,0 +1,50 @@
+package fsutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".kairo.lock")
+
+	unlock, err := Lock(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	if _, err := Lock(ctx, path); err == nil {
+		t.Fatal("second Lock() succeeded while the lock was held")
+	}
+
+	unlock()
+	unlock2, err := Lock(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Lock() after release error = %v", err)
+	}
+	unlock2()
+}
+
+func TestLockRemovesStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".kairo.lock")
+	if err := os.WriteFile(path, []byte("12345\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * lockStaleAfter)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	unlock, err := Lock(ctx, path)
+	if err != nil {
+		t.Fatalf("Lock() with stale lock error = %v", err)
+	}
+	unlock()
+}
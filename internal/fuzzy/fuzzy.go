@@ -0,0 +1,71 @@
+// Package fuzzy finds the names closest to a mistyped one, for "did you
+// mean" hints and shell completion. Names are compared by Levenshtein
+// distance, ignoring case.
+package fuzzy
+
+import (
+	"slices"
+	"strings"
+)
+
+// Distance returns the Levenshtein distance between a and b: the fewest
+// single-rune insertions, deletions, and substitutions turning one into the
+// other.
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// Closest returns the candidates close enough to name to be what was
+// meant, nearest first and alphabetically among equals. name itself is left
+// out. A candidate is close when it is within two edits of name, or a third
+// of name's length for longer names, and not every one of its runes needs
+// an edit, so that short names do not match everything.
+func Closest(name string, candidates []string) []string {
+	lower := strings.ToLower(name)
+	limit := max(2, len([]rune(lower))/3)
+
+	type match struct {
+		name     string
+		distance int
+	}
+	var matches []match
+	for _, c := range candidates {
+		d := Distance(lower, strings.ToLower(c))
+		if c == name || d > limit || d >= len([]rune(c)) {
+			continue
+		}
+		matches = append(matches, match{c, d})
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+
+		return strings.Compare(a.name, b.name)
+	})
+
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = m.name
+	}
+
+	return names
+}
@@ -0,0 +1,50 @@
+package fuzzy
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"zai", "zai", 0},
+		{"zia", "zai", 2},
+		{"za", "zai", 1},
+		{"minimx", "minimax", 1},
+		{"", "kimi", 4},
+		{"kitten", "sitting", 3},
+		{"héllo", "hello", 1},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"zai", "minimax", "kimi", "deepseek", "anthropic", "z"}
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"zia", []string{"zai"}},
+		{"ZAI", []string{"zai"}},
+		{"zai2", []string{"zai"}},
+		{"minmax", []string{"minimax"}},
+		{"antropic", []string{"anthropic"}},
+		{"kim", []string{"kimi"}},
+		{"zai", nil},
+		{"openrouter", nil},
+		{"q", nil},
+	}
+	for _, tt := range tests {
+		if got := Closest(tt.name, candidates); !slices.Equal(got, tt.want) {
+			t.Errorf("Closest(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package gitguard finds kairo's key and secrets files inside git worktrees,
+// where a careless `git add -A` would commit them, and writes the .gitignore
+// entries that keep them out. Ignore rules are asked of git itself, so
+// global excludes, nested .gitignore files, and negations are all honored.
+package gitguard
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsutil"
+)
+
+// IgnoreFileName is the ignore file entries are added to, at the root of
+// the worktree.
+const IgnoreFileName = ".gitignore"
+
+// Runner starts a command, as exec.CommandContext does.
+type Runner func(ctx context.Context, name string, arg ...string) *exec.Cmd
+
+// Status is what git would do with a file.
+type Status int
+
+const (
+	// Ignored files are left out by the ignore rules.
+	Ignored Status = iota
+	// Exposed files are not ignored, so `git add` would pick them up.
+	Exposed
+	// Tracked files are already staged or committed.
+	Tracked
+)
+
+// Worktree returns the root of the git worktree holding dir, the nearest
+// directory at or above it with a .git entry, and false when there is none.
+// Symbolic links in dir are resolved first.
+func Worktree(dir string) (string, bool) {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Rel returns path relative to the worktree root in git's slash form,
+// resolving symbolic links in its directory the way Worktree does.
+func Rel(root, path string) (string, error) {
+	dir := filepath.Dir(path)
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	rel, err := filepath.Rel(root, filepath.Join(dir, filepath.Base(path)))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// Check returns the Status of each of paths, given relative to root in
+// slash form. Paths need not exist.
+func Check(ctx context.Context, run Runner, root string, paths []string) (map[string]Status, error) {
+	statuses := make(map[string]Status, len(paths))
+	if len(paths) == 0 {
+		return statuses, nil
+	}
+	for _, p := range paths {
+		statuses[p] = Exposed
+	}
+
+	ignored, err := git(ctx, run, root, strings.Join(paths, "\x00"), "check-ignore", "-z", "--stdin")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range ignored {
+		statuses[p] = Ignored
+	}
+	tracked, err := git(ctx, run, root, "", append([]string{"ls-files", "-z", "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range tracked {
+		statuses[p] = Tracked
+	}
+
+	return statuses, nil
+}
+
+// Find returns the files of the worktree at root, tracked or not ignored,
+// for which match reports true, with their Status. Paths are relative to
+// root in slash form.
+func Find(ctx context.Context, run Runner, root string, match func(path string) bool) (map[string]Status, error) {
+	found := make(map[string]Status)
+	for _, list := range []struct {
+		args   []string
+		status Status
+	}{
+		{[]string{"ls-files", "-z", "--others", "--exclude-standard"}, Exposed},
+		{[]string{"ls-files", "-z", "--cached"}, Tracked},
+	} {
+		paths, err := git(ctx, run, root, "", list.args...)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range paths {
+			if match(p) {
+				found[p] = list.status
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// Entries returns the .gitignore lines that ignore each of paths, relative
+// to the root, and no other file.
+func Entries(paths []string) []string {
+	entries := make([]string, 0, len(paths))
+	for _, p := range slices.Sorted(slices.Values(paths)) {
+		entries = append(entries, "/"+p)
+	}
+
+	return entries
+}
+
+// AppendIgnore adds entries to the .gitignore at the root of the worktree,
+// after a comment naming kairo, creating the file when it is missing.
+// Entries the file already holds are skipped.
+func AppendIgnore(root string, entries []string) error {
+	path := filepath.Join(root, IgnoreFileName)
+	data, err := os.ReadFile(path)
+	if err != nil && !stderrors.Is(err, fs.ErrNotExist) {
+		return errors.FileError("failed to read "+IgnoreFileName, path, err)
+	}
+
+	present := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+	var add []string
+	for _, e := range entries {
+		if !present[e] {
+			add = append(add, e)
+		}
+	}
+	if len(add) == 0 {
+		return nil
+	}
+
+	var b bytes.Buffer
+	b.Write(data)
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	if len(data) > 0 {
+		b.WriteByte('\n')
+	}
+	b.WriteString("# kairo key and secrets files\n")
+	b.WriteString(strings.Join(add, "\n") + "\n")
+
+	mode := constants.FilePermDefault
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := fsutil.WriteAtomic(path, func(f *os.File) error {
+		if err := f.Chmod(mode); err != nil {
+			return err
+		}
+		_, err := f.Write(b.Bytes())
+
+		return err
+	}); err != nil {
+		return errors.FileError("failed to write "+IgnoreFileName, path, err)
+	}
+
+	return nil
+}
+
+// git runs git in root with stdin as its input and returns the
+// NUL-separated paths it prints. The exit status 1 of check-ignore, which
+// means no path matched, is not an error.
+func git(ctx context.Context, run Runner, root, stdin string, args ...string) ([]string, error) {
+	cmd := run(ctx, "git", append([]string{"-C", root}, args...)...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if stderrors.As(err, &exitErr) && exitErr.ExitCode() == 1 && args[0] == "check-ignore" {
+		return nil, nil
+	}
+	if err != nil {
+		detail := strings.TrimSpace(stderr.String())
+		if detail == "" {
+			detail = err.Error()
+		}
+
+		return nil, errors.WrapError(errors.RuntimeError,
+			fmt.Sprintf("git %s failed in %s: %s", args[0], root, detail), err)
+	}
+
+	var paths []string
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+
+	return paths, nil
+}
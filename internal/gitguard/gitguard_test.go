@@ -0,0 +1,122 @@
+package gitguard
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// initRepo creates a git repository with the given .gitignore and returns
+// its root.
+func initRepo(t *testing.T, ignore string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not in PATH")
+	}
+	root := t.TempDir()
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	if ignore != "" {
+		if err := os.WriteFile(filepath.Join(root, IgnoreFileName), []byte(ignore), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorktree(t *testing.T) {
+	root := initRepo(t, "")
+	nested := filepath.Join(root, "home", ".config", "kairo")
+	if err := os.MkdirAll(nested, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok := Worktree(nested); !ok || got != root {
+		t.Errorf("Worktree(%s) = %q, %v; want %q", nested, got, ok, root)
+	}
+	if got, ok := Worktree(t.TempDir()); ok {
+		t.Errorf("Worktree(outside) = %q, want none", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	root := initRepo(t, "kairo/secrets.age\n")
+	writeFile(t, filepath.Join(root, "kairo", "age.key"))
+	writeFile(t, filepath.Join(root, "kairo", "secrets.age"))
+	writeFile(t, filepath.Join(root, "kairo", "audit.key"))
+	if out, err := exec.Command("git", "-C", root, "add", "kairo/audit.key").CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v: %s", err, out)
+	}
+
+	got, err := Check(ctx, exec.CommandContext, root,
+		[]string{"kairo/age.key", "kairo/secrets.age", "kairo/audit.key", "kairo/missing.age"})
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	want := map[string]Status{
+		"kairo/age.key":     Exposed,
+		"kairo/secrets.age": Ignored,
+		"kairo/audit.key":   Tracked,
+		"kairo/missing.age": Exposed,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() = %v, want %v", got, want)
+	}
+
+	found, err := Find(ctx, exec.CommandContext, root, func(p string) bool { return strings.HasSuffix(p, ".key") })
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if want := map[string]Status{"kairo/age.key": Exposed, "kairo/audit.key": Tracked}; !reflect.DeepEqual(found, want) {
+		t.Errorf("Find() = %v, want %v", found, want)
+	}
+}
+
+func TestAppendIgnore(t *testing.T) {
+	ctx := context.Background()
+	root := initRepo(t, "node_modules")
+	writeFile(t, filepath.Join(root, "kairo", "age.key"))
+
+	entries := Entries([]string{"kairo/secrets.age", "kairo/age.key"})
+	if want := []string{"/kairo/age.key", "/kairo/secrets.age"}; !reflect.DeepEqual(entries, want) {
+		t.Fatalf("Entries() = %v, want %v", entries, want)
+	}
+	for range 2 {
+		if err := AppendIgnore(root, entries); err != nil {
+			t.Fatalf("AppendIgnore() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, IgnoreFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "node_modules\n\n# kairo key and secrets files\n/kairo/age.key\n/kairo/secrets.age\n"
+	if string(data) != want {
+		t.Errorf(".gitignore = %q, want %q", data, want)
+	}
+	got, err := Check(ctx, exec.CommandContext, root, []string{"kairo/age.key"})
+	if err != nil || got["kairo/age.key"] != Ignored {
+		t.Errorf("Check() after AppendIgnore = %v, %v", got, err)
+	}
+}
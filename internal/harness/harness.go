@@ -1,6 +1,7 @@
 package harness
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
@@ -35,11 +36,13 @@ func Resolve(flagHarness, configHarness string) string {
 }
 
 // Dispatch returns the display name, environment variable name, and any extra
-// CLI arguments for the given harness configuration.
+// CLI arguments for the given harness configuration. Qwen Code's key variable
+// and arguments depend on the provider's auth type, so the caller builds them
+// with QwenEnv and QwenArgs.
 func Dispatch(h, providerName, model string) (displayName, envVarName string, extraArgs []string) {
 	switch h {
 	case Qwen:
-		return "Qwen", "ANTHROPIC_API_KEY", []string{"--auth-type", "anthropic", "--model", model}
+		return "Qwen", "", nil
 	case Crush:
 		return "Crush", APIKeyEnvVar(providerName), nil
 	case Pi:
@@ -49,6 +52,58 @@ func Dispatch(h, providerName, model string) (displayName, envVarName string, ex
 	}
 }
 
+// Qwen Code auth types, chosen per provider with qwen.auth_type.
+const (
+	QwenAuthAnthropic = "anthropic"
+	QwenAuthOpenAI    = "openai"
+)
+
+// QwenSettingsEnv names the variable Qwen Code reads a system settings file
+// path from. Settings in that file take precedence over ~/.qwen/settings.json.
+const QwenSettingsEnv = "QWEN_CODE_SYSTEM_SETTINGS_PATH"
+
+// QwenEnvVars names the variables Qwen Code reads the API key, base URL, and
+// model from for one auth type.
+type QwenEnvVars struct {
+	APIKey  string
+	BaseURL string
+	Model   string
+}
+
+// QwenEnv returns the variables Qwen Code reads for authType, and false for
+// an auth type kairo does not support.
+func QwenEnv(authType string) (QwenEnvVars, bool) {
+	switch authType {
+	case QwenAuthAnthropic:
+		return QwenEnvVars{APIKey: "ANTHROPIC_API_KEY", BaseURL: "ANTHROPIC_BASE_URL", Model: "ANTHROPIC_MODEL"}, true
+	case QwenAuthOpenAI:
+		return QwenEnvVars{APIKey: "OPENAI_API_KEY", BaseURL: "OPENAI_BASE_URL", Model: "OPENAI_MODEL"}, true
+	default:
+		return QwenEnvVars{}, false
+	}
+}
+
+// QwenArgs returns the Qwen Code arguments selecting authType and model.
+func QwenArgs(authType, model string) []string {
+	return []string{"--auth-type", authType, "--model", model}
+}
+
+// QwenSettings returns a Qwen Code settings.json selecting authType, baseURL,
+// and model. The API key is left out; Qwen Code reads it from the
+// environment.
+func QwenSettings(authType, baseURL, model string) ([]byte, error) {
+	type auth struct {
+		SelectedType string `json:"selectedType"`
+		BaseURL      string `json:"baseUrl,omitempty"`
+	}
+	settings := map[string]any{
+		"security": map[string]any{"auth": auth{SelectedType: authType, BaseURL: baseURL}},
+		"model":    map[string]string{"name": model},
+	}
+
+	return json.MarshalIndent(settings, "", "  ")
+}
+
 // YoloFlag returns the harness-specific flag for skipping permission prompts.
 func YoloFlag(h string) string {
 	switch h {
@@ -61,6 +116,29 @@ func YoloFlag(h string) string {
 	}
 }
 
+// ResumeArgs returns the arguments that make harness h resume the session
+// sessionID, or continue its most recent session in the working directory
+// when sessionID is empty. It returns false for a harness that cannot resume
+// a session from its command line.
+func ResumeArgs(h, sessionID string) ([]string, bool) {
+	switch h {
+	case Claude, Qwen:
+		if sessionID == "" {
+			return []string{"--continue"}, true
+		}
+
+		return []string{"--resume", sessionID}, true
+	case Pi:
+		if sessionID == "" {
+			return []string{"--continue"}, true
+		}
+
+		return []string{"--session", sessionID}, true
+	default:
+		return nil, false
+	}
+}
+
 // PiEnvVars returns environment variables for the Pi harness.
 func PiEnvVars(providerName, model string) []string {
 	return []string{
@@ -78,3 +156,26 @@ func APIKeyEnvVar(providerName string) string {
 
 	return fmt.Sprintf("%s_API_KEY", sanitized)
 }
+
+// InstallHint returns how to install harness h on the operating system goos.
+func InstallHint(h, goos string) string {
+	switch h {
+	case Claude:
+		return "install Claude Code with: npm install -g @anthropic-ai/claude-code"
+	case Qwen:
+		return "install Qwen Code with: npm install -g @qwen-code/qwen-code@latest"
+	case Pi:
+		return "install Pi with: npm install -g @earendil-works/pi-coding-agent"
+	case Crush:
+		switch goos {
+		case "darwin":
+			return "install Crush with: brew install charmbracelet/tap/crush"
+		case "windows":
+			return "install Crush with: winget install charmbracelet.crush"
+		default:
+			return "install Crush from https://github.com/charmbracelet/crush#installation"
+		}
+	default:
+		return fmt.Sprintf("install '%s' and make sure it is in your PATH", h)
+	}
+}
@@ -1,6 +1,9 @@
 package harness
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestIsValid(t *testing.T) {
 	tests := []struct {
@@ -68,7 +71,7 @@ func TestDispatch(t *testing.T) {
 		},
 		{
 			name: "qwen", harness: Qwen, providerName: "test", model: "qwen-plus",
-			wantDisplay: "Qwen", wantEnv: "ANTHROPIC_API_KEY", wantExtraLen: 4,
+			wantDisplay: "Qwen", wantEnv: "", wantExtraLen: 0,
 		},
 		{
 			name: "pi", harness: Pi, providerName: "test",
@@ -118,6 +121,32 @@ func TestYoloFlag(t *testing.T) {
 	}
 }
 
+func TestResumeArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		harness   string
+		sessionID string
+		want      []string
+		wantOK    bool
+	}{
+		{"claude session", Claude, "abc", []string{"--resume", "abc"}, true},
+		{"claude latest", Claude, "", []string{"--continue"}, true},
+		{"qwen session", Qwen, "abc", []string{"--resume", "abc"}, true},
+		{"pi session", Pi, "abc", []string{"--session", "abc"}, true},
+		{"pi latest", Pi, "", []string{"--continue"}, true},
+		{"crush", Crush, "abc", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ResumeArgs(tt.harness, tt.sessionID)
+			if ok != tt.wantOK || strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("ResumeArgs(%q, %q) = %q, %v, want %q, %v", tt.harness, tt.sessionID, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
 func TestPiEnvVars(t *testing.T) {
 	vars := PiEnvVars("zai", "glm-5")
 	if len(vars) != 2 {
@@ -142,3 +171,45 @@ func TestAPIKeyEnvVar_Hyphenated(t *testing.T) {
 		t.Errorf("APIKeyEnvVar = %q, want CLOUDFLARE_WORKERS_AI_API_KEY", got)
 	}
 }
+
+func TestInstallHint(t *testing.T) {
+	if got := InstallHint(Claude, "linux"); !strings.Contains(got, "@anthropic-ai/claude-code") {
+		t.Errorf("InstallHint(claude) = %q", got)
+	}
+	if InstallHint(Crush, "darwin") == InstallHint(Crush, "windows") {
+		t.Error("InstallHint(crush) should differ between macOS and Windows")
+	}
+	if got := InstallHint("aider", "linux"); !strings.Contains(got, "'aider'") {
+		t.Errorf("InstallHint(unknown) = %q", got)
+	}
+}
+
+func TestQwenEnv(t *testing.T) {
+	tests := []struct {
+		authType string
+		wantKey  string
+		wantOK   bool
+	}{
+		{QwenAuthAnthropic, "ANTHROPIC_API_KEY", true},
+		{QwenAuthOpenAI, "OPENAI_API_KEY", true},
+		{"gemini", "", false},
+	}
+	for _, tt := range tests {
+		vars, ok := QwenEnv(tt.authType)
+		if ok != tt.wantOK || vars.APIKey != tt.wantKey {
+			t.Errorf("QwenEnv(%q) = %+v, %v; want key %q, %v", tt.authType, vars, ok, tt.wantKey, tt.wantOK)
+		}
+	}
+}
+
+func TestQwenSettings(t *testing.T) {
+	data, err := QwenSettings(QwenAuthOpenAI, "https://api.example.com/v1", "qwen3-coder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"selectedType": "openai"`, `"baseUrl": "https://api.example.com/v1"`, `"name": "qwen3-coder"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("QwenSettings() = %s, missing %s", data, want)
+		}
+	}
+}
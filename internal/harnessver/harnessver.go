@@ -0,0 +1,174 @@
+// Package harnessver tracks the installed version of each harness binary so
+// kairo can notice upgrades between switches and enforce minimum versions.
+package harnessver
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsutil"
+)
+
+// StateFileName is the file in the config directory recording the last seen
+// binary of each harness.
+const StateFileName = "harness-versions.json"
+
+// Record identifies a harness binary. Path, Size, and ModTime are cheap to
+// read on every switch; Version is only refreshed when they change.
+type Record struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Version string    `json:"version,omitempty"`
+}
+
+// Stat returns the record for the binary at path, following symlinks so
+// package-manager upgrades that swap the link target are noticed.
+func Stat(path string) (Record, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		resolved = path
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return Record{}, errors.FileError("failed to stat harness binary", resolved, err)
+	}
+
+	return Record{Path: resolved, Size: info.Size(), ModTime: info.ModTime().UTC()}, nil
+}
+
+// SameBinary reports whether r and o describe the same file contents.
+func (r Record) SameBinary(o Record) bool {
+	return r.Path == o.Path && r.Size == o.Size && r.ModTime.Equal(o.ModTime)
+}
+
+// Load reads the recorded binaries from dir, keyed by harness name. A missing
+// state file yields an empty map.
+func Load(dir string) (map[string]Record, error) {
+	path := filepath.Join(dir, StateFileName)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return map[string]Record{}, nil
+		}
+
+		return nil, errors.FileError("failed to read harness version state", path, err)
+	}
+
+	state := map[string]Record{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		// A corrupt state file only costs one missed notice; start over.
+		return map[string]Record{}, nil
+	}
+
+	return state, nil
+}
+
+// Save writes state to dir atomically.
+func Save(dir string, state map[string]Record) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return errors.WrapError(errors.RuntimeError, "failed to encode harness version state", err)
+	}
+
+	return fsutil.WriteAtomic(filepath.Join(dir, StateFileName), func(f *os.File) error {
+		_, err := f.Write(append(data, '\n'))
+
+		return err
+	})
+}
+
+// Change describes the outcome of Observe.
+type Change struct {
+	Previous Record
+	Current  Record
+	// Updated is set when a previously recorded version differs from the
+	// current one.
+	Updated bool
+}
+
+// Observe compares cur with the record stored for harness in dir. When the
+// binary changed (or was never seen) version is called to fill in
+// cur.Version and the state is saved.
+func Observe(dir, harness string, cur Record, version func() string) (Change, error) {
+	state, err := Load(dir)
+	if err != nil {
+		return Change{}, err
+	}
+
+	prev, seen := state[harness]
+	if seen && prev.SameBinary(cur) {
+		return Change{Previous: prev, Current: prev}, nil
+	}
+
+	cur.Version = version()
+	state[harness] = cur
+	if err := Save(dir, state); err != nil {
+		return Change{}, err
+	}
+
+	return Change{
+		Previous: prev,
+		Current:  cur,
+		Updated:  seen && prev.Version != "" && cur.Version != "" && prev.Version != cur.Version,
+	}, nil
+}
+
+var versionPattern = regexp.MustCompile(`\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.]+)?`)
+
+// ParseVersion extracts the first dotted version number from the output of a
+// harness's --version flag, or "" if there is none.
+func ParseVersion(output string) string {
+	return versionPattern.FindString(output)
+}
+
+// Compare compares two dotted versions numerically, ignoring any pre-release
+// suffix. It returns -1, 0, or 1. Missing components count as zero.
+func Compare(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := range max(len(pa), len(pb)) {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+ "); i >= 0 {
+		v = v[:i]
+	}
+
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+
+	return parts
+}
@@ -0,0 +1,90 @@
+package harnessver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"2.0.14 (Claude Code)", "2.0.14"},
+		{"qwen version v0.1.2\n", "0.1.2"},
+		{"crush 1.4.0-beta.1", "1.4.0-beta.1"},
+		{"no version here", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ParseVersion(tt.output); got != tt.want {
+			t.Errorf("ParseVersion(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.0.14", "2.0.14", 0},
+		{"2.0.9", "2.0.14", -1},
+		{"2.1", "2.0.14", 1},
+		{"v1.2.0", "1.2", 0},
+		{"1.4.0-beta.1", "1.4.0", 0},
+	}
+
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestObserve(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "claude")
+	if err := os.WriteFile(bin, []byte("v1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	version := func(v string) func() string {
+		return func() string { calls++; return v }
+	}
+
+	cur, err := Stat(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change, err := Observe(dir, "claude", cur, version("1.0.0"))
+	if err != nil || change.Updated || change.Current.Version != "1.0.0" {
+		t.Fatalf("first Observe() = %+v, %v", change, err)
+	}
+
+	change, err = Observe(dir, "claude", cur, version("ignored"))
+	if err != nil || change.Updated || calls != 1 {
+		t.Fatalf("unchanged Observe() = %+v, %v (version calls %d)", change, err, calls)
+	}
+
+	if err := os.WriteFile(bin, []byte("v2-longer"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(bin, time.Now(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	cur, err = Stat(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change, err = Observe(dir, "claude", cur, version("1.1.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !change.Updated || change.Previous.Version != "1.0.0" || change.Current.Version != "1.1.0" {
+		t.Errorf("upgrade Observe() = %+v", change)
+	}
+}
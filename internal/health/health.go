@@ -0,0 +1,144 @@
+// Package health probes provider endpoints and keeps a bounded per-provider
+// history of the results so flaky providers can be told apart from one-off
+// failures.
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Status classifies the outcome of a health check.
+type Status string
+
+// Health check statuses.
+const (
+	StatusOK        Status = "ok"
+	StatusAuthError Status = "auth_error"
+	StatusError     Status = "error"
+)
+
+// AuthStyle selects which header carries the API key. Anthropic-compatible
+// gateways differ: some read x-api-key, others Authorization: Bearer.
+type AuthStyle string
+
+// Auth styles. AuthStyleBoth sends the key in both headers and is the
+// default when a provider does not set one.
+const (
+	AuthStyleBoth   AuthStyle = ""
+	AuthStyleAPIKey AuthStyle = "x-api-key"
+	AuthStyleBearer AuthStyle = "bearer"
+)
+
+// ParseAuthStyle returns the AuthStyle named by s. The empty string and
+// "both" select AuthStyleBoth.
+func ParseAuthStyle(s string) (AuthStyle, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "both":
+		return AuthStyleBoth, nil
+	case string(AuthStyleAPIKey):
+		return AuthStyleAPIKey, nil
+	case string(AuthStyleBearer):
+		return AuthStyleBearer, nil
+	default:
+		return AuthStyleBoth, fmt.Errorf("unknown auth_style %q (want x-api-key, bearer, or both)", s)
+	}
+}
+
+// SetAuthHeaders sets the credential headers for apiKey on h according to
+// style. It does nothing when apiKey is empty.
+func SetAuthHeaders(h http.Header, style AuthStyle, apiKey string) {
+	if apiKey == "" {
+		return
+	}
+	if style != AuthStyleBearer {
+		h.Set("x-api-key", apiKey)
+	}
+	if style != AuthStyleAPIKey {
+		h.Set("Authorization", "Bearer "+apiKey)
+	}
+}
+
+// AnthropicBaseURL is probed for providers that use the native Anthropic API
+// and therefore have no configured base URL.
+const AnthropicBaseURL = "https://api.anthropic.com"
+
+// AnthropicVersion is the anthropic-version header sent with probes.
+const AnthropicVersion = "2023-06-01"
+
+// ModelsEndpoint returns the models endpoint Check probes for baseURL, or for
+// AnthropicBaseURL when baseURL is empty.
+func ModelsEndpoint(baseURL string) string {
+	if baseURL == "" {
+		baseURL = AnthropicBaseURL
+	}
+
+	return strings.TrimRight(baseURL, "/") + "/v1/models"
+}
+
+// Result is the outcome of a single health check. Quota is shown but not
+// kept in the history.
+type Result struct {
+	Time       time.Time     `json:"time"`
+	Latency    time.Duration `json:"latency_ns"`
+	Status     Status        `json:"status"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Quota      Quota         `json:"-"`
+}
+
+// OK reports whether the check succeeded.
+func (r Result) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Check probes the provider's models endpoint with apiKey, sent in the
+// headers style selects, and classifies the response. Any HTTP response below
+// 500 other than 401, 403, and 429 counts as healthy: the endpoint is
+// reachable and accepted the credentials.
+func Check(ctx context.Context, client *http.Client, baseURL, apiKey string, style AuthStyle) Result {
+	endpoint := ModelsEndpoint(baseURL)
+
+	start := time.Now()
+	res := Result{Time: start}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		res.Status = StatusError
+		res.Error = err.Error()
+
+		return res
+	}
+	SetAuthHeaders(req.Header, style, apiKey)
+	req.Header.Set("anthropic-version", AnthropicVersion)
+
+	resp, err := client.Do(req)
+	res.Latency = time.Since(start)
+	if err != nil {
+		res.Status = StatusError
+		res.Error = err.Error()
+
+		return res
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	res.StatusCode = resp.StatusCode
+	res.Quota = ParseQuota(resp.Header)
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		res.Status = StatusAuthError
+		res.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		res.Status = StatusError
+		res.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	default:
+		res.Status = StatusOK
+	}
+
+	return res
+}
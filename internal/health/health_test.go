@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus Status
+	}{
+		{"ok", http.StatusOK, StatusOK},
+		{"not found still reachable", http.StatusNotFound, StatusOK},
+		{"unauthorized", http.StatusUnauthorized, StatusAuthError},
+		{"forbidden", http.StatusForbidden, StatusAuthError},
+		{"rate limited", http.StatusTooManyRequests, StatusError},
+		{"overloaded", 529, StatusError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotKey string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				gotKey = r.Header.Get("x-api-key")
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			res := Check(context.Background(), srv.Client(), srv.URL+"/", "key", AuthStyleBoth)
+			if res.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", res.Status, tt.wantStatus)
+			}
+			if res.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", res.StatusCode, tt.status)
+			}
+			if gotPath != "/v1/models" || gotKey != "key" {
+				t.Errorf("request path=%q key=%q", gotPath, gotKey)
+			}
+			if res.Time.IsZero() {
+				t.Error("Time not set")
+			}
+		})
+	}
+}
+
+func TestCheckAuthStyle(t *testing.T) {
+	tests := []struct {
+		style      AuthStyle
+		wantAPIKey string
+		wantBearer string
+	}{
+		{AuthStyleBoth, "key", "Bearer key"},
+		{AuthStyleAPIKey, "key", ""},
+		{AuthStyleBearer, "", "Bearer key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.style), func(t *testing.T) {
+			var gotAPIKey, gotBearer string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAPIKey = r.Header.Get("x-api-key")
+				gotBearer = r.Header.Get("Authorization")
+			}))
+			defer srv.Close()
+
+			Check(context.Background(), srv.Client(), srv.URL, "key", tt.style)
+			if gotAPIKey != tt.wantAPIKey || gotBearer != tt.wantBearer {
+				t.Errorf("x-api-key=%q Authorization=%q, want %q and %q",
+					gotAPIKey, gotBearer, tt.wantAPIKey, tt.wantBearer)
+			}
+		})
+	}
+}
+
+func TestParseAuthStyle(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    AuthStyle
+		wantErr bool
+	}{
+		{"", AuthStyleBoth, false},
+		{"both", AuthStyleBoth, false},
+		{"x-api-key", AuthStyleAPIKey, false},
+		{"Bearer", AuthStyleBearer, false},
+		{"basic", AuthStyleBoth, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAuthStyle(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseAuthStyle(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	res := Check(context.Background(), http.DefaultClient, url, "", AuthStyleBoth)
+	if res.Status != StatusError || res.Error == "" {
+		t.Errorf("Check() = %+v, want error result", res)
+	}
+	if res.OK() {
+		t.Error("OK() = true for unreachable endpoint")
+	}
+}
@@ -0,0 +1,153 @@
+package health
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsutil"
+)
+
+// HistoryDirName is the directory under the config directory holding one
+// history file per provider.
+const HistoryDirName = "health"
+
+// HistorySize is the number of results kept per provider; older entries are
+// dropped when new ones are appended.
+const HistorySize = 100
+
+// HistoryPath returns the history file for provider under dir.
+func HistoryPath(dir, provider string) string {
+	return filepath.Join(dir, HistoryDirName, provider+".jsonl")
+}
+
+// LoadHistory returns the recorded results for provider, oldest first.
+// A missing history file yields no results and no error. Lines that fail to
+// parse are skipped.
+func LoadHistory(dir, provider string) ([]Result, error) {
+	path := HistoryPath(dir, provider)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, errors.FileError("failed to read health history", path, err)
+	}
+
+	var out []Result
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var r Result
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		out = append(out, r)
+	}
+
+	return out, nil
+}
+
+// AppendHistory records r for provider, keeping at most HistorySize entries.
+func AppendHistory(dir, provider string, r Result) error {
+	results, err := LoadHistory(dir, provider)
+	if err != nil {
+		return err
+	}
+
+	results = append(results, r)
+	if len(results) > HistorySize {
+		results = results[len(results)-HistorySize:]
+	}
+
+	path := HistoryPath(dir, provider)
+	if err := os.MkdirAll(filepath.Dir(path), constants.DirPermSecure); err != nil {
+		return errors.FileError("failed to create health history directory", filepath.Dir(path), err)
+	}
+
+	return fsutil.WriteAtomic(path, func(f *os.File) error {
+		enc := json.NewEncoder(f)
+		for _, res := range results {
+			if err := enc.Encode(res); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// sparkBlocks are the glyphs used by Sparkline, from lowest to highest.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the latencies of results as a line of block glyphs,
+// scaled between the fastest and slowest successful check. Failed checks
+// are drawn as '✗'.
+func Sparkline(results []Result) string {
+	var lo, hi time.Duration
+	first := true
+	for _, r := range results {
+		if !r.OK() {
+			continue
+		}
+		if first || r.Latency < lo {
+			lo = r.Latency
+		}
+		if first || r.Latency > hi {
+			hi = r.Latency
+		}
+		first = false
+	}
+
+	var b strings.Builder
+	for _, r := range results {
+		if !r.OK() {
+			b.WriteRune('✗')
+
+			continue
+		}
+		idx := 0
+		if hi > lo {
+			idx = int(float64(r.Latency-lo) / float64(hi-lo) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+
+	return b.String()
+}
+
+// Summary aggregates a slice of results.
+type Summary struct {
+	Checks     int
+	Failures   int
+	AvgLatency time.Duration
+}
+
+// Summarize computes the failure count and the mean latency of successful
+// checks in results.
+func Summarize(results []Result) Summary {
+	s := Summary{Checks: len(results)}
+	var total time.Duration
+	for _, r := range results {
+		if !r.OK() {
+			s.Failures++
+
+			continue
+		}
+		total += r.Latency
+	}
+	if ok := s.Checks - s.Failures; ok > 0 {
+		s.AvgLatency = total / time.Duration(ok)
+	}
+
+	return s
+}
@@ -0,0 +1,104 @@
+package health
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAppendAndLoadHistory(t *testing.T) {
+	dir := t.TempDir()
+
+	results, err := LoadHistory(dir, "zai")
+	if err != nil || results != nil {
+		t.Fatalf("LoadHistory() on empty dir = %v, %v; want nil, nil", results, err)
+	}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range HistorySize + 5 {
+		r := Result{Time: base.Add(time.Duration(i) * time.Minute), Latency: time.Duration(i) * time.Millisecond, Status: StatusOK}
+		if err := AppendHistory(dir, "zai", r); err != nil {
+			t.Fatalf("AppendHistory() error = %v", err)
+		}
+	}
+
+	results, err = LoadHistory(dir, "zai")
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	if len(results) != HistorySize {
+		t.Fatalf("len(results) = %d, want %d", len(results), HistorySize)
+	}
+	if want := base.Add(5 * time.Minute); !results[0].Time.Equal(want) {
+		t.Errorf("oldest kept = %v, want %v", results[0].Time, want)
+	}
+
+	info, err := os.Stat(HistoryPath(dir, "zai"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		t.Errorf("history file permissions = %o, want owner-only", perm)
+	}
+}
+
+func TestLoadHistorySkipsCorruptLines(t *testing.T) {
+	dir := t.TempDir()
+	if err := AppendHistory(dir, "zai", Result{Status: StatusOK}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.OpenFile(HistoryPath(dir, "zai"), os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("not json\n")
+	f.Close()
+
+	results, err := LoadHistory(dir, "zai")
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("len(results) = %d, want 1", len(results))
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []Result
+		want    string
+	}{
+		{"empty", nil, ""},
+		{"flat", []Result{{Status: StatusOK, Latency: 5}, {Status: StatusOK, Latency: 5}}, "▁▁"},
+		{
+			"scaled with failure",
+			[]Result{
+				{Status: StatusOK, Latency: 100},
+				{Status: StatusError},
+				{Status: StatusOK, Latency: 800},
+			},
+			"▁✗█",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sparkline(tt.results); got != tt.want {
+				t.Errorf("Sparkline() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	s := Summarize([]Result{
+		{Status: StatusOK, Latency: 100 * time.Millisecond},
+		{Status: StatusError},
+		{Status: StatusOK, Latency: 300 * time.Millisecond},
+	})
+	if s.Checks != 3 || s.Failures != 1 || s.AvgLatency != 200*time.Millisecond {
+		t.Errorf("Summarize() = %+v", s)
+	}
+}
@@ -0,0 +1,114 @@
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quota is what a provider reported in the headers of one response about the
+// request and its rate limits, kept as sent. Anthropic and gateways modeled
+// on it send anthropic-ratelimit-* headers; OpenAI-style gateways send
+// x-ratelimit-*. Fields the provider did not send are empty.
+type Quota struct {
+	// RequestID identifies the request to the provider's support.
+	RequestID         string
+	RequestsLimit     string
+	RequestsRemaining string
+	// RequestsReset is when the request limit is restored: an RFC 3339
+	// time, or a duration such as 6m0s.
+	RequestsReset   string
+	TokensRemaining string
+	TokensReset     string
+	// RetryAfter is the retry-after header of a rate-limited or overloaded
+	// response, in seconds.
+	RetryAfter string
+}
+
+// ParseQuota returns the request ID and rate-limit headers of h.
+func ParseQuota(h http.Header) Quota {
+	first := func(names ...string) string {
+		for _, name := range names {
+			if v := strings.TrimSpace(h.Get(name)); v != "" {
+				return v
+			}
+		}
+
+		return ""
+	}
+
+	return Quota{
+		RequestID:         first("request-id", "x-request-id", "anthropic-request-id"),
+		RequestsLimit:     first("anthropic-ratelimit-requests-limit", "x-ratelimit-limit-requests"),
+		RequestsRemaining: first("anthropic-ratelimit-requests-remaining", "x-ratelimit-remaining-requests"),
+		RequestsReset:     first("anthropic-ratelimit-requests-reset", "x-ratelimit-reset-requests"),
+		TokensRemaining: first("anthropic-ratelimit-tokens-remaining", "anthropic-ratelimit-output-tokens-remaining",
+			"x-ratelimit-remaining-tokens"),
+		TokensReset: first("anthropic-ratelimit-tokens-reset", "anthropic-ratelimit-output-tokens-reset",
+			"x-ratelimit-reset-tokens"),
+		RetryAfter: first("retry-after"),
+	}
+}
+
+// IsZero reports whether the provider sent none of the headers.
+func (q Quota) IsZero() bool {
+	return q == Quota{}
+}
+
+// Summary describes q in a few comma-separated parts, such as
+// "request req_011, 49 of 50 requests left, resets in 12s", with reset times
+// relative to now. It is empty when q is.
+func (q Quota) Summary(now time.Time) string {
+	var parts []string
+	if q.RequestID != "" {
+		parts = append(parts, "request "+q.RequestID)
+	}
+	if q.RequestsRemaining != "" {
+		left := q.RequestsRemaining + " requests left"
+		if q.RequestsLimit != "" {
+			left = q.RequestsRemaining + " of " + q.RequestsLimit + " requests left"
+		}
+		if reset := formatReset(q.RequestsReset, now); reset != "" {
+			left += ", resets " + reset
+		}
+		parts = append(parts, left)
+	}
+	if q.TokensRemaining != "" {
+		left := q.TokensRemaining + " tokens left"
+		if reset := formatReset(q.TokensReset, now); reset != "" && q.TokensReset != q.RequestsReset {
+			left += ", resets " + reset
+		}
+		parts = append(parts, left)
+	}
+	if q.RetryAfter != "" {
+		if secs, err := strconv.Atoi(q.RetryAfter); err == nil {
+			parts = append(parts, "retry after "+(time.Duration(secs)*time.Second).String())
+		} else {
+			parts = append(parts, "retry after "+q.RetryAfter)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// formatReset returns a reset header value as "in 12s", or as sent when it
+// is neither an RFC 3339 time nor a duration.
+func formatReset(s string, now time.Time) string {
+	if s == "" {
+		return ""
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return fmt.Sprintf("in %s", d.Round(time.Second))
+		}
+
+		return "now"
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return fmt.Sprintf("in %s", d)
+	}
+
+	return s
+}
@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseQuota(t *testing.T) {
+	anthropic := http.Header{}
+	anthropic.Set("request-id", "req_011")
+	anthropic.Set("anthropic-ratelimit-requests-limit", "50")
+	anthropic.Set("anthropic-ratelimit-requests-remaining", "49")
+	anthropic.Set("anthropic-ratelimit-requests-reset", "2026-01-02T03:04:17Z")
+	anthropic.Set("anthropic-ratelimit-tokens-remaining", "39000")
+	anthropic.Set("anthropic-ratelimit-tokens-reset", "2026-01-02T03:04:17Z")
+
+	openai := http.Header{}
+	openai.Set("x-request-id", "abc")
+	openai.Set("x-ratelimit-limit-requests", "500")
+	openai.Set("x-ratelimit-remaining-requests", "499")
+	openai.Set("x-ratelimit-reset-requests", "120ms")
+	openai.Set("x-ratelimit-remaining-tokens", "149984")
+	openai.Set("x-ratelimit-reset-tokens", "6m0s")
+	openai.Set("retry-after", "1")
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{"anthropic", anthropic, "request req_011, 49 of 50 requests left, resets in 12s, 39000 tokens left"},
+		{"openai", openai, "request abc, 499 of 500 requests left, resets in 120ms, 149984 tokens left, resets in 6m0s, " +
+			"retry after 1s"},
+		{"none", http.Header{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := ParseQuota(tt.header)
+			if got := q.Summary(now); got != tt.want {
+				t.Errorf("Summary() = %q, want %q", got, tt.want)
+			}
+			if q.IsZero() != (tt.want == "") {
+				t.Errorf("IsZero() = %v", q.IsZero())
+			}
+		})
+	}
+}
+
+func TestFormatReset(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := map[string]string{
+		"":                     "",
+		"2026-01-02T03:05:05Z": "in 1m0s",
+		"2026-01-02T03:04:00Z": "now",
+		"30s":                  "in 30s",
+		"tomorrow":             "tomorrow",
+	}
+	for in, want := range tests {
+		if got := formatReset(in, now); got != want {
+			t.Errorf("formatReset(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCheckQuota(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("request-id", "req_429")
+		w.Header().Set("retry-after", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	res := Check(context.Background(), srv.Client(), srv.URL, "key", AuthStyleBoth)
+	if res.Quota.RequestID != "req_429" || res.Quota.RetryAfter != "30" {
+		t.Errorf("Quota = %+v", res.Quota)
+	}
+}
@@ -0,0 +1,145 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// Webhook event names.
+const (
+	EventProviderDown = "provider_down"
+	EventProviderUp   = "provider_up"
+	EventTest         = "test"
+)
+
+// Event is the JSON document posted to the health webhook. Text makes it a
+// valid Slack incoming webhook message; the other fields are for receivers
+// that parse it.
+type Event struct {
+	Text           string `json:"text"`
+	Event          string `json:"event"`
+	Provider       string `json:"provider"`
+	Host           string `json:"host,omitempty"`
+	Status         Status `json:"status"`
+	PreviousStatus Status `json:"previous_status,omitempty"`
+	StatusCode     int    `json:"status_code,omitempty"`
+	Error          string `json:"error,omitempty"`
+	LatencyMS      int64  `json:"latency_ms,omitempty"`
+	Time           string `json:"time"`
+}
+
+// TransitionEvent returns the event for provider going from prev to cur, and
+// false when both are up or both are down. Auth errors count as down.
+func TransitionEvent(provider, host string, prev, cur Result) (Event, bool) {
+	if prev.OK() == cur.OK() {
+		return Event{}, false
+	}
+
+	e := Event{
+		Event:          EventProviderUp,
+		Provider:       provider,
+		Host:           host,
+		Status:         cur.Status,
+		PreviousStatus: prev.Status,
+		StatusCode:     cur.StatusCode,
+		Error:          cur.Error,
+		LatencyMS:      cur.Latency.Milliseconds(),
+		Time:           cur.Time.UTC().Format(time.RFC3339),
+	}
+	if cur.OK() {
+		e.Text = fmt.Sprintf("kairo: %s is back up (%s)", provider, cur.Latency.Round(time.Millisecond))
+	} else {
+		e.Event = EventProviderDown
+		e.Text = fmt.Sprintf("kairo: %s is down (%s)", provider, cur.Error)
+	}
+	if host != "" {
+		e.Text += " on " + host
+	}
+
+	return e, true
+}
+
+// Webhook posts events to URL, trying up to Attempts times and waiting
+// Backoff, doubled after each try, between them. Network errors, 429, and
+// 5xx responses are retried; other responses are not.
+type Webhook struct {
+	URL      string
+	Client   *http.Client
+	Attempts int
+	Backoff  time.Duration
+}
+
+// Send posts e to the webhook.
+func (w Webhook) Send(ctx context.Context, e Event) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.NewError(errors.ValidationError, "hooks.health_webhook must be an http or https URL")
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	backoff := w.Backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.Attempts {
+			// The URL often carries the token that authorizes posting, so
+			// only its host is reported.
+			return errors.WrapError(errors.NetworkError, "health webhook failed", err).
+				WithContext("host", u.Host).
+				WithContext("hint", "check hooks.health_webhook in config.yaml and that its receiver is reachable")
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.WrapError(errors.NetworkError, "health webhook failed", ctx.Err()).
+				WithContext("host", u.Host)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post sends body once and reports whether a failure is worth retrying.
+func (w Webhook) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		// A *url.Error repeats the URL, and with it the token.
+		var uerr *url.Error
+		if stderrors.As(err, &uerr) {
+			err = uerr.Err
+		}
+
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("HTTP %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransitionEvent(t *testing.T) {
+	up := Result{Time: time.Now(), Latency: 120 * time.Millisecond, Status: StatusOK}
+	down := Result{Time: time.Now(), Status: StatusError, StatusCode: 503, Error: "HTTP 503"}
+	denied := Result{Time: time.Now(), Status: StatusAuthError, StatusCode: 401, Error: "HTTP 401"}
+
+	tests := []struct {
+		name      string
+		prev, cur Result
+		wantEvent string
+		wantText  string
+	}{
+		{name: "still up", prev: up, cur: up},
+		{name: "still down", prev: down, cur: denied},
+		{name: "went down", prev: up, cur: down, wantEvent: EventProviderDown, wantText: "kairo: zai is down (HTTP 503) on gw1"},
+		{name: "key rejected", prev: up, cur: denied, wantEvent: EventProviderDown, wantText: "kairo: zai is down (HTTP 401) on gw1"},
+		{name: "came back", prev: down, cur: up, wantEvent: EventProviderUp, wantText: "kairo: zai is back up (120ms) on gw1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := TransitionEvent("zai", "gw1", tt.prev, tt.cur)
+			if ok != (tt.wantEvent != "") {
+				t.Fatalf("TransitionEvent() ok = %v, want %v", ok, tt.wantEvent != "")
+			}
+			if e.Event != tt.wantEvent || e.Text != tt.wantText {
+				t.Errorf("TransitionEvent() = %q, %q; want %q, %q", e.Event, e.Text, tt.wantEvent, tt.wantText)
+			}
+			if ok && (e.Status != tt.cur.Status || e.PreviousStatus != tt.prev.Status) {
+				t.Errorf("TransitionEvent() statuses = %q from %q", e.Status, e.PreviousStatus)
+			}
+		})
+	}
+}
+
+func TestWebhookSend_Retries(t *testing.T) {
+	var got []Event
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request = %s with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		var e Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Error(err)
+		}
+		got = append(got, e)
+	}))
+	defer srv.Close()
+
+	w := Webhook{URL: srv.URL + "/hook", Client: srv.Client(), Attempts: 3, Backoff: time.Millisecond}
+	if err := w.Send(context.Background(), Event{Text: "kairo: zai is down", Event: EventProviderDown}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if calls != 2 || len(got) != 1 || got[0].Text != "kairo: zai is down" {
+		t.Errorf("Send() made %d requests delivering %+v, want a retry delivering the event", calls, got)
+	}
+}
+
+func TestWebhookSend_Fails(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if strings.Contains(r.URL.Path, "gone") {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		url       string
+		wantCalls int
+	}{
+		{name: "not retried", url: srv.URL + "/gone/T0KEN", wantCalls: 1},
+		{name: "retries exhausted", url: srv.URL + "/busy/T0KEN", wantCalls: 3},
+		{name: "not a URL", url: "hooks.example.com/T0KEN", wantCalls: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			w := Webhook{URL: tt.url, Client: srv.Client(), Attempts: 3, Backoff: time.Millisecond}
+			err := w.Send(context.Background(), Event{Event: EventTest})
+			if err == nil {
+				t.Fatal("Send() succeeded, want an error")
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Send() made %d requests, want %d", calls, tt.wantCalls)
+			}
+			if strings.Contains(err.Error(), "T0KEN") {
+				t.Errorf("Send() error %q reveals the webhook path", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+// Package journal makes operations that replace several files in the config
+// directory, such as rotating the encryption key, crash-consistent.
+//
+// An operation lists the renames and removals it will make, then calls Begin,
+// which records them in a journal file before anything is written. The
+// operation then writes each new file under a staged name next to the one it
+// replaces and calls Commit, which marks the journal prepared and carries out
+// the steps, recording each as it is done. If kairo dies part way, Recover
+// settles the operation on the next start: a prepared one is rolled forward
+// by finishing its remaining steps, and one that never finished staging is
+// rolled back by removing its staged files, leaving the old files in place.
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsutil"
+)
+
+// Step is one rename or removal of an operation. From is renamed to To; when
+// From is empty, To is removed.
+type Step struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+	// Staged marks From as written by the operation, so that rolling it
+	// back removes it.
+	Staged bool `json:"staged,omitempty"`
+	Done   bool `json:"done,omitempty"`
+}
+
+// Replace returns a step renaming the staged file over target.
+func Replace(staged, target string) Step {
+	return Step{From: staged, To: target, Staged: true}
+}
+
+// Move returns a step renaming an existing file from to to.
+func Move(from, to string) Step {
+	return Step{From: from, To: to}
+}
+
+// Remove returns a step removing path.
+func Remove(path string) Step {
+	return Step{To: path}
+}
+
+// Journal is an operation in progress, as recorded in the journal file.
+type Journal struct {
+	Operation string    `json:"operation"`
+	Started   time.Time `json:"started"`
+	// Prepared is set by Commit once every staged file is written. Until
+	// then the operation can only be rolled back.
+	Prepared bool   `json:"prepared"`
+	Steps    []Step `json:"steps"`
+
+	path   string
+	unlock func()
+}
+
+// Outcome says how Recover settled an interrupted operation.
+type Outcome string
+
+const (
+	// RolledForward means the remaining steps were carried out.
+	RolledForward Outcome = "rolled_forward"
+	// RolledBack means the staged files were removed and nothing replaced.
+	RolledBack Outcome = "rolled_back"
+)
+
+// Recovery describes an interrupted operation settled by Recover.
+type Recovery struct {
+	Operation string
+	Started   time.Time
+	Outcome   Outcome
+}
+
+// Path returns the journal file of dir.
+func Path(dir string) string {
+	return filepath.Join(dir, constants.JournalFileName)
+}
+
+// Begin records that operation is about to carry out steps in dir and holds
+// the journal lock until Commit or Abort, so no other kairo process takes
+// the operation for an interrupted one. An operation left interrupted in dir
+// is settled first.
+func Begin(ctx context.Context, dir, operation string, steps []Step) (*Journal, error) {
+	unlock, err := lock(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := recoverLocked(dir); err != nil {
+		unlock()
+
+		return nil, err
+	}
+
+	j := &Journal{
+		Operation: operation,
+		Started:   time.Now().UTC(),
+		Steps:     steps,
+		path:      Path(dir),
+		unlock:    unlock,
+	}
+	if err := j.save(); err != nil {
+		unlock()
+
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Abort removes the staged files and the journal, leaving every file the
+// operation would have replaced as it was.
+func (j *Journal) Abort() {
+	defer j.unlock()
+
+	j.rollBack()
+}
+
+// Commit marks the operation prepared and carries out its steps, recording
+// each as it is done, then removes the journal. A missing staged file aborts
+// the operation instead. If a step fails, the journal is kept, so that
+// Recover finishes the operation.
+func (j *Journal) Commit() error {
+	defer j.unlock()
+
+	for _, s := range j.Steps {
+		if !s.Staged {
+			continue
+		}
+		if _, err := os.Stat(s.From); err != nil {
+			j.rollBack()
+
+			return errors.FileError("staged file is missing", s.From, err)
+		}
+	}
+	j.Prepared = true
+	if err := j.save(); err != nil {
+		j.rollBack()
+
+		return err
+	}
+
+	if err := j.rollForward(); err != nil {
+		return errors.WrapError(errors.FileSystemError, "failed to finish "+j.Operation, err).
+			WithContext("hint", "the next kairo command finishes it")
+	}
+
+	return nil
+}
+
+// Recover settles an operation left interrupted in dir, and returns nil when
+// there was none.
+func Recover(ctx context.Context, dir string) (*Recovery, error) {
+	if _, err := os.Stat(Path(dir)); stderrors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	unlock, err := lock(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return recoverLocked(dir)
+}
+
+func recoverLocked(dir string) (*Recovery, error) {
+	path := Path(dir)
+	data, err := os.ReadFile(path)
+	if stderrors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.FileError("failed to read journal", path, err)
+	}
+	j := &Journal{path: path}
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, errors.FileError("journal is corrupted", path, err).
+			WithContext("hint", "check the files in "+dir+", then remove the journal")
+	}
+
+	rec := &Recovery{Operation: j.Operation, Started: j.Started, Outcome: RolledBack}
+	if !j.Prepared {
+		j.rollBack()
+
+		return rec, nil
+	}
+	if err := j.rollForward(); err != nil {
+		return nil, errors.WrapError(errors.FileSystemError, "failed to finish "+j.Operation, err)
+	}
+	rec.Outcome = RolledForward
+
+	return rec, nil
+}
+
+func (j *Journal) rollForward() error {
+	for i := range j.Steps {
+		s := &j.Steps[i]
+		if s.Done {
+			continue
+		}
+		if err := s.apply(); err != nil {
+			return err
+		}
+		s.Done = true
+		if err := j.save(); err != nil {
+			return err
+		}
+	}
+
+	return j.remove()
+}
+
+func (j *Journal) rollBack() {
+	for _, s := range j.Steps {
+		if s.Staged {
+			_ = os.Remove(s.From)
+		}
+	}
+	_ = j.remove()
+}
+
+// apply carries out s. A From that no longer exists was renamed before the
+// step could be recorded as done.
+func (s Step) apply() error {
+	if s.From == "" {
+		if err := os.Remove(s.To); err != nil && !stderrors.Is(err, fs.ErrNotExist) {
+			return errors.FileError("failed to remove file", s.To, err)
+		}
+
+		return nil
+	}
+	if err := os.Rename(s.From, s.To); err != nil {
+		if _, statErr := os.Stat(s.From); stderrors.Is(statErr, fs.ErrNotExist) {
+			return nil
+		}
+
+		return errors.FileError("failed to replace file", s.To, err)
+	}
+
+	return nil
+}
+
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return errors.WrapError(errors.FileSystemError, "failed to encode journal", err)
+	}
+
+	return fsutil.WriteAtomic(j.path, func(f *os.File) error {
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+
+		return f.Sync()
+	})
+}
+
+func (j *Journal) remove() error {
+	if err := os.Remove(j.path); err != nil && !stderrors.Is(err, fs.ErrNotExist) {
+		return errors.FileError("failed to remove journal", j.path, err)
+	}
+
+	return nil
+}
+
+func lock(ctx context.Context, dir string) (func(), error) {
+	lockCtx, cancel := context.WithTimeout(ctx, constants.LockTimeout)
+	defer cancel()
+
+	return fsutil.Lock(lockCtx, Path(dir)+".lock")
+}
@@ -0,0 +1,209 @@
+package journal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "<missing>"
+	}
+
+	return string(data)
+}
+
+func TestCommit(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	secrets := filepath.Join(dir, "secrets.age")
+	aside := filepath.Join(dir, "secrets.age.old")
+	stale := filepath.Join(dir, "stale")
+	writeFile(t, config, "old config")
+	writeFile(t, secrets, "old secrets")
+	writeFile(t, stale, "stale")
+
+	j, err := Begin(context.Background(), dir, "import", []Step{
+		Replace(config+".new", config),
+		Move(secrets, aside),
+		Replace(secrets+".new", secrets),
+		Remove(stale),
+	})
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if _, err := os.Stat(Path(dir)); err != nil {
+		t.Fatalf("Begin() did not write the journal: %v", err)
+	}
+	writeFile(t, config+".new", "new config")
+	writeFile(t, secrets+".new", "new secrets")
+
+	if err := j.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	for path, want := range map[string]string{
+		config: "new config", secrets: "new secrets", aside: "old secrets",
+		stale: "<missing>", Path(dir): "<missing>", Path(dir) + ".lock": "<missing>",
+	} {
+		if got := readFile(t, path); got != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), got, want)
+		}
+	}
+}
+
+func TestCommit_MissingStagedFile(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	writeFile(t, config, "old config")
+
+	j, err := Begin(context.Background(), dir, "import", []Step{
+		Replace(config+".new", config),
+		Replace(filepath.Join(dir, "secrets.age.new"), filepath.Join(dir, "secrets.age")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, config+".new", "new config")
+
+	if err := j.Commit(); err == nil {
+		t.Fatal("Commit() with a staged file missing error = nil")
+	}
+	if got := readFile(t, config); got != "old config" {
+		t.Errorf("config = %q, want it untouched", got)
+	}
+	if got := readFile(t, config+".new"); got != "<missing>" {
+		t.Error("Commit() kept a staged file after aborting")
+	}
+}
+
+func TestAbort(t *testing.T) {
+	dir := t.TempDir()
+	secrets := filepath.Join(dir, "secrets.age")
+	writeFile(t, secrets, "old secrets")
+
+	j, err := Begin(context.Background(), dir, "rotate", []Step{
+		Move(secrets, secrets+".old"),
+		Replace(secrets+".new", secrets),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, secrets+".new", "new secrets")
+	j.Abort()
+
+	for path, want := range map[string]string{
+		secrets: "old secrets", secrets + ".new": "<missing>", Path(dir): "<missing>",
+	} {
+		if got := readFile(t, path); got != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), got, want)
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("nothing to recover", func(t *testing.T) {
+		rec, err := Recover(ctx, t.TempDir())
+		if rec != nil || err != nil {
+			t.Errorf("Recover() = %+v, %v; want nil", rec, err)
+		}
+	})
+
+	t.Run("rolls back an operation cut short while staging", func(t *testing.T) {
+		dir := t.TempDir()
+		key := filepath.Join(dir, "age.key")
+		writeFile(t, key, "old key")
+		j, err := Begin(ctx, dir, "rotate", []Step{Replace(key+".new", key)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, key+".new", "half-written key")
+		j.unlock()
+
+		rec, err := Recover(ctx, dir)
+		if err != nil || rec == nil || rec.Outcome != RolledBack || rec.Operation != "rotate" {
+			t.Fatalf("Recover() = %+v, %v; want a rolled back rotate", rec, err)
+		}
+		if got := readFile(t, key); got != "old key" {
+			t.Errorf("key = %q, want the old key", got)
+		}
+		if got := readFile(t, key+".new"); got != "<missing>" {
+			t.Error("Recover() kept the staged key")
+		}
+	})
+
+	t.Run("rolls forward a prepared operation", func(t *testing.T) {
+		dir := t.TempDir()
+		secrets := filepath.Join(dir, "secrets.age")
+		key := filepath.Join(dir, "age.key")
+		writeFile(t, secrets, "old secrets")
+		writeFile(t, key, "old key")
+		j, err := Begin(ctx, dir, "rotate", []Step{Replace(secrets+".new", secrets), Replace(key+".new", key)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, secrets+".new", "new secrets")
+		writeFile(t, key+".new", "new key")
+		// Stop after the first rename, before it was recorded as done.
+		j.Prepared = true
+		if err := j.save(); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(secrets+".new", secrets); err != nil {
+			t.Fatal(err)
+		}
+		j.unlock()
+
+		rec, err := Recover(ctx, dir)
+		if err != nil || rec == nil || rec.Outcome != RolledForward {
+			t.Fatalf("Recover() = %+v, %v; want a rolled forward rotate", rec, err)
+		}
+		if readFile(t, secrets) != "new secrets" || readFile(t, key) != "new key" {
+			t.Errorf("secrets = %q, key = %q; want both new", readFile(t, secrets), readFile(t, key))
+		}
+		if got := readFile(t, Path(dir)); got != "<missing>" {
+			t.Error("Recover() kept the journal")
+		}
+	})
+
+	t.Run("corrupted journal", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, Path(dir), "{not json")
+		if _, err := Recover(ctx, dir); err == nil {
+			t.Error("Recover() of a corrupted journal error = nil")
+		}
+	})
+}
+
+func TestBegin_SettlesInterruptedOperation(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	key := filepath.Join(dir, "age.key")
+	j, err := Begin(ctx, dir, "rotate", []Step{Replace(key+".new", key)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, key+".new", "half-written key")
+	j.unlock()
+
+	next, err := Begin(ctx, dir, "import", nil)
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	defer next.Abort()
+	if got := readFile(t, key+".new"); got != "<missing>" {
+		t.Error("Begin() did not roll back the interrupted operation")
+	}
+}
@@ -0,0 +1,229 @@
+// Package keyentry serves a short-lived local HTTPS page for pasting an API
+// key from a browser, for hosts where typing or pasting into the terminal is
+// impractical (for example over SSH with a forwarded port).
+package keyentry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// maxKeyBytes bounds the size of a submitted form.
+const maxKeyBytes = 64 * 1024
+
+// Session is a running key entry page. The page accepts exactly one
+// submission; afterwards every request is rejected.
+type Session struct {
+	// URL includes the one-time token and must be opened as-is.
+	URL string
+	// Fingerprint is the SHA-256 fingerprint of the self-signed certificate,
+	// so the user can compare it with the one shown by the browser.
+	Fingerprint string
+
+	server *http.Server
+	token  string
+	label  string
+	keys   chan string
+
+	mu   sync.Mutex
+	used bool
+}
+
+// Start listens on addr and serves the key entry page until a key is
+// submitted or Close is called. label names the provider on the page.
+func Start(ctx context.Context, addr, label string) (*Session, error) {
+	cert, fingerprint, err := selfSignedCert()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, errors.WrapError(errors.NetworkError, "failed to listen", err).
+			WithContext("address", addr)
+	}
+
+	s := &Session{
+		URL:         fmt.Sprintf("https://%s/%s", listener.Addr(), token),
+		Fingerprint: fingerprint,
+		token:       token,
+		label:       label,
+		keys:        make(chan string, 1),
+	}
+	s.server = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		},
+	}
+
+	go func() { _ = s.server.ServeTLS(listener, "", "") }()
+
+	return s, nil
+}
+
+// Wait blocks until a key is submitted or ctx is done.
+func (s *Session) Wait(ctx context.Context) (string, error) {
+	select {
+	case key := <-s.keys:
+		return key, nil
+	case <-ctx.Done():
+		return "", errors.WrapError(errors.RuntimeError,
+			"no key was submitted before the page expired", ctx.Err())
+	}
+}
+
+// Close stops the server.
+func (s *Session) Close() error {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	return s.server.Shutdown(shutdownCtx)
+}
+
+var page = template.Must(template.New("page").Parse(`<!doctype html>
+<html><head><meta charset="utf-8"><title>kairo</title>
+<meta name="referrer" content="no-referrer"></head>
+<body style="font-family:sans-serif;max-width:40em;margin:3em auto">
+{{if .Done}}<p>Key received. You can close this tab.</p>
+{{else}}<h1>API key for {{.Label}}</h1>
+<form method="post" autocomplete="off">
+<p><textarea name="key" rows="4" cols="60" autofocus spellcheck="false"></textarea></p>
+<p><button type="submit">Send to kairo</button></p>
+</form>{{end}}
+</body></html>`))
+
+// ServeHTTP implements http.Handler.
+func (s *Session) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+
+	if strings.TrimPrefix(r.URL.Path, "/") != s.token || s.isUsed() {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = page.Execute(w, map[string]any{"Label": s.label})
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, maxKeyBytes)
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid form", http.StatusBadRequest)
+
+			return
+		}
+		key := strings.TrimSpace(r.PostForm.Get("key"))
+		if key == "" {
+			http.Error(w, "key is empty", http.StatusBadRequest)
+
+			return
+		}
+		if !s.markUsed() {
+			http.NotFound(w, r)
+
+			return
+		}
+		s.keys <- key
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = page.Execute(w, map[string]any{"Done": true})
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Session) isUsed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.used
+}
+
+// markUsed consumes the one-time token, reporting false if it was already
+// consumed.
+func (s *Session) markUsed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.used {
+		return false
+	}
+	s.used = true
+
+	return true
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WrapError(errors.CryptoError, "failed to generate token", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// selfSignedCert creates a throwaway certificate for localhost valid for one
+// hour and returns it with its SHA-256 fingerprint.
+func selfSignedCert() (tls.Certificate, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, "", errors.WrapError(errors.CryptoError, "failed to generate TLS key", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, "", errors.WrapError(errors.CryptoError, "failed to generate serial", err)
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "kairo key entry"},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, "", errors.WrapError(errors.CryptoError, "failed to create certificate", err)
+	}
+
+	sum := sha256.Sum256(der)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, strings.Join(parts, ":"), nil
+}
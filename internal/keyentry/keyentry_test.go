@@ -0,0 +1,103 @@
+package keyentry
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func insecureClient() *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // self-signed test server
+	}}
+}
+
+func TestSessionAcceptsOneKey(t *testing.T) {
+	s, err := Start(context.Background(), "127.0.0.1:0", "Z.AI")
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer s.Close()
+
+	if s.Fingerprint == "" || !strings.HasPrefix(s.URL, "https://127.0.0.1:") {
+		t.Fatalf("session = %+v", s)
+	}
+
+	client := insecureClient()
+
+	resp, err := client.Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET status = %d, want 200", resp.StatusCode)
+	}
+
+	base := s.URL[:strings.LastIndex(s.URL, "/")]
+	resp, err = client.Get(base + "/wrong-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("wrong token status = %d, want 404", resp.StatusCode)
+	}
+
+	resp, err = client.PostForm(s.URL, url.Values{"key": {"  sk-test-key  "}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	key, err := s.Wait(ctx)
+	if err != nil || key != "sk-test-key" {
+		t.Fatalf("Wait() = %q, %v", key, err)
+	}
+
+	resp, err = client.PostForm(s.URL, url.Values{"key": {"second"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("reused token status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestSessionRejectsEmptyKey(t *testing.T) {
+	s, err := Start(context.Background(), "127.0.0.1:0", "Z.AI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	resp, err := insecureClient().PostForm(s.URL, url.Values{"key": {" "}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestWaitExpires(t *testing.T) {
+	s, err := Start(context.Background(), "127.0.0.1:0", "Z.AI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.Wait(ctx); err == nil {
+		t.Error("Wait() expected error after timeout")
+	}
+}
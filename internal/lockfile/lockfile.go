@@ -0,0 +1,143 @@
+// Package lockfile pins the provider, base URL, and model a project runs
+// with, so that CI pipelines using 'kairo run --locked' get the same model
+// on every run or fail loudly when the configuration changed underneath
+// them.
+package lockfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// FileName is the lock file kairo reads and writes in the current
+// directory by default, meant to be committed next to the project.
+const FileName = "kairo.lock"
+
+// FormatVersion is the lock format written by this version of kairo.
+const FormatVersion = 1
+
+// checksumPrefix names the hash in Lock.Checksum.
+const checksumPrefix = "sha256:"
+
+// Lock is the provider setup a project is pinned to. It holds the
+// fingerprint of the API key, never the key.
+type Lock struct {
+	Version        int       `json:"version"`
+	CreatedAt      time.Time `json:"created_at"`
+	Provider       string    `json:"provider"`
+	BaseURL        string    `json:"base_url"`
+	Model          string    `json:"model"`
+	KeyFingerprint string    `json:"key_fingerprint,omitempty"`
+	// Checksum is the SHA-256 of the other fields, so that a lock edited
+	// by hand, or damaged in a merge, is refused rather than trusted.
+	Checksum string `json:"checksum"`
+}
+
+// Drift is one field whose configured value differs from the lock.
+type Drift struct {
+	Field      string
+	Locked     string
+	Configured string
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf("%s: locked %s, configured %s", d.Field, display(d.Locked), display(d.Configured))
+}
+
+func display(v string) string {
+	if v == "" {
+		return "(none)"
+	}
+
+	return v
+}
+
+// Compare returns the fields of current that differ from l: the base URL and
+// the model. A trailing slash on a base URL is not a difference. The key
+// fingerprint is left out: see KeyChanged.
+func (l Lock) Compare(current Lock) []Drift {
+	var drift []Drift
+	if strings.TrimRight(l.BaseURL, "/") != strings.TrimRight(current.BaseURL, "/") {
+		drift = append(drift, Drift{Field: "base_url", Locked: l.BaseURL, Configured: current.BaseURL})
+	}
+	if l.Model != current.Model {
+		drift = append(drift, Drift{Field: "model", Locked: l.Model, Configured: current.Model})
+	}
+
+	return drift
+}
+
+// KeyChanged reports whether the API key fingerprint differs from the one
+// locked, when both are known. A rotated key still reaches the same model,
+// so this is worth a warning, not a refusal.
+func (l Lock) KeyChanged(fingerprint string) bool {
+	return l.KeyFingerprint != "" && fingerprint != "" && l.KeyFingerprint != fingerprint
+}
+
+// Write sets the version and checksum of l and encodes it as indented JSON.
+func Write(w io.Writer, l Lock) error {
+	l.Version = FormatVersion
+	sum, err := checksum(l)
+	if err != nil {
+		return err
+	}
+	l.Checksum = sum
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(l); err != nil {
+		return errors.WrapError(errors.RuntimeError, "failed to write "+FileName, err)
+	}
+
+	return nil
+}
+
+// Read decodes and verifies a lock written by Write. Unknown fields, newer
+// format versions, and a checksum that does not match are rejected.
+func Read(r io.Reader) (Lock, error) {
+	var l Lock
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&l); err != nil {
+		return Lock{}, errors.WrapError(errors.ValidationError, "invalid "+FileName, err)
+	}
+	if l.Version < 1 || l.Version > FormatVersion {
+		return Lock{}, errors.NewError(errors.ValidationError,
+			fmt.Sprintf("unsupported %s version %d", FileName, l.Version)).
+			WithContext("hint", "upgrade kairo to read lock files from newer versions")
+	}
+	if l.Provider == "" {
+		return Lock{}, errors.NewError(errors.ValidationError, FileName+" does not name a provider")
+	}
+	sum, err := checksum(l)
+	if err != nil {
+		return Lock{}, err
+	}
+	if l.Checksum != sum {
+		return Lock{}, errors.NewError(errors.ValidationError,
+			FileName+" does not match its checksum; it was edited or damaged").
+			WithContext("hint", "run 'kairo lock' to write it again from the current configuration")
+	}
+
+	return l, nil
+}
+
+// checksum returns the checksum of l's fields other than Checksum.
+func checksum(l Lock) (string, error) {
+	l.Checksum = ""
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(l); err != nil {
+		return "", errors.WrapError(errors.RuntimeError, "failed to encode "+FileName, err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+
+	return checksumPrefix + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,101 @@
+package lockfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testLock() Lock {
+	return Lock{
+		CreatedAt:      time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Provider:       "zai",
+		BaseURL:        "https://api.z.ai/api/anthropic",
+		Model:          "glm-4.7",
+		KeyFingerprint: "sha256:0123abcd",
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testLock()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"checksum": "sha256:`) {
+		t.Fatalf("lock has no checksum: %s", buf.String())
+	}
+
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	want := testLock()
+	if got.Version != FormatVersion || got.Provider != want.Provider || got.Model != want.Model ||
+		got.KeyFingerprint != want.KeyFingerprint || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadRejectsEdits(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testLock()); err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(buf.String(), "glm-4.7", "glm-5", 1)
+
+	_, err := Read(strings.NewReader(edited))
+	if err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("Read() of an edited lock error = %v, want a checksum error", err)
+	}
+}
+
+func TestReadRejects(t *testing.T) {
+	for name, data := range map[string]string{
+		"future version": `{"version": 99, "provider": "zai", "checksum": ""}`,
+		"unknown field":  `{"version": 1, "provider": "zai", "api_key": "x", "checksum": ""}`,
+		"no provider":    `{"version": 1, "checksum": ""}`,
+		"no checksum":    `{"version": 1, "provider": "zai"}`,
+	} {
+		if _, err := Read(strings.NewReader(data)); err == nil {
+			t.Errorf("Read(%s) expected error", name)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	locked := testLock()
+
+	current := testLock()
+	current.BaseURL += "/"
+	current.KeyFingerprint = "sha256:ffff"
+	if drift := locked.Compare(current); len(drift) != 0 {
+		t.Errorf("Compare() = %v, want no drift for a trailing slash or another key", drift)
+	}
+
+	current.Model = "glm-5"
+	current.BaseURL = ""
+	drift := locked.Compare(current)
+	if len(drift) != 2 {
+		t.Fatalf("Compare() = %v, want base_url and model", drift)
+	}
+	if got := drift[0].String(); got != "base_url: locked https://api.z.ai/api/anthropic, configured (none)" {
+		t.Errorf("drift[0] = %q", got)
+	}
+	if got := drift[1].String(); got != "model: locked glm-4.7, configured glm-5" {
+		t.Errorf("drift[1] = %q", got)
+	}
+}
+
+func TestKeyChanged(t *testing.T) {
+	locked := testLock()
+	if locked.KeyChanged(locked.KeyFingerprint) || locked.KeyChanged("") {
+		t.Error("KeyChanged() = true for the same or an unknown fingerprint")
+	}
+	if !locked.KeyChanged("sha256:ffff") {
+		t.Error("KeyChanged() = false for another fingerprint")
+	}
+	if (Lock{}).KeyChanged("sha256:ffff") {
+		t.Error("KeyChanged() = true for a lock without a fingerprint")
+	}
+}
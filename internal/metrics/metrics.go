@@ -0,0 +1,154 @@
+// Package metrics serves kairo's counters in the Prometheus text exposition
+// format on a loopback address, so that a long-lived kairo process can be
+// scraped into an existing dashboard. Metrics are gathered afresh for each
+// scrape; nothing is kept between them.
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// Metric types.
+const (
+	Counter = "counter"
+	Gauge   = "gauge"
+)
+
+// Path is the URL path metrics are served on.
+const Path = "/metrics"
+
+// contentType is the media type of the text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Family is one metric and its samples.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Sample is one series of a Family. Labels holds name and value pairs.
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Label is a label of a sample.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Write writes families in the text exposition format. Families without
+// samples are left out.
+func Write(w io.Writer, families []Family) error {
+	var buf bytes.Buffer
+	for _, f := range families {
+		if len(f.Samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", f.Name, f.Type)
+		for _, s := range f.Samples {
+			buf.WriteString(f.Name)
+			if len(s.Labels) > 0 {
+				pairs := make([]string, len(s.Labels))
+				for i, l := range s.Labels {
+					pairs[i] = l.Name + `="` + escapeLabel(l.Value) + `"`
+				}
+				buf.WriteString("{" + strings.Join(pairs, ",") + "}")
+			}
+			buf.WriteString(" " + formatValue(s.Value) + "\n")
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+// formatValue writes whole numbers, such as timestamps, without an exponent.
+func formatValue(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+// Handler serves the families gather returns on Path.
+func Handler(gather func(ctx context.Context) ([]Family, error)) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+Path, func(w http.ResponseWriter, r *http.Request) {
+		families, err := gather(r.Context())
+		if err != nil {
+			http.Error(w, errors.Describe(err), http.StatusInternalServerError)
+
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		_ = Write(w, families)
+	})
+
+	return mux
+}
+
+// Serve serves h on addr until ctx ends. addr must be a loopback address,
+// such as 127.0.0.1:9464, since the metrics name the providers in use. It
+// returns once the address is listened on.
+func Serve(ctx context.Context, addr string, h http.Handler) error {
+	if err := CheckLoopback(addr); err != nil {
+		return err
+	}
+	l, err := (&net.ListenConfig{}).Listen(ctx, "tcp", addr)
+	if err != nil {
+		return errors.WrapError(errors.NetworkError, "failed to listen for metrics scrapes on "+addr, err)
+	}
+
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	go func() { _ = srv.Serve(l) }()
+
+	return nil
+}
+
+// CheckLoopback returns an error unless addr is a host:port whose host is
+// localhost or a loopback IP.
+func CheckLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return errors.WrapError(errors.ValidationError, fmt.Sprintf("invalid metrics address %q", addr), err).
+			WithContext("hint", "use host:port, such as 127.0.0.1:9464")
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return errors.NewError(errors.ValidationError,
+			fmt.Sprintf("metrics address %q is not a loopback address", addr)).
+			WithContext("hint", "metrics are only served on localhost; use 127.0.0.1:<port> and scrape through a tunnel")
+	}
+
+	return nil
+}
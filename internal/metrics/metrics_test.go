@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	families := []Family{
+		{
+			Name: "kairo_sessions_total", Help: "Sessions per provider.", Type: Counter,
+			Samples: []Sample{
+				{Labels: []Label{{Name: "provider", Value: "zai"}}, Value: 3},
+				{Labels: []Label{{Name: "provider", Value: `we"ird\`}}, Value: 0.5},
+			},
+		},
+		{Name: "kairo_empty", Help: "Left out.", Type: Gauge},
+		{Name: "kairo_up", Help: "Line one\nline two.", Type: Gauge, Samples: []Sample{{Value: 1}}},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, families); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP kairo_sessions_total Sessions per provider.
+# TYPE kairo_sessions_total counter
+kairo_sessions_total{provider="zai"} 3
+kairo_sessions_total{provider="we\"ird\\"} 0.5
+# HELP kairo_up Line one\nline two.
+# TYPE kairo_up gauge
+kairo_up 1
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := Handler(func(context.Context) ([]Family, error) {
+		return []Family{{Name: "kairo_up", Help: "Up.", Type: Gauge, Samples: []Sample{{Value: 1}}}}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "kairo_up 1") ||
+		!strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("GET %s = %d %q %q", Path, rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/other", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /other = %d, want 404", rec.Code)
+	}
+
+	failing := Handler(func(context.Context) ([]Family, error) { return nil, errors.New("state unreadable") })
+	rec = httptest.NewRecorder()
+	failing.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("GET %s with a failing gather = %d, want 500", Path, rec.Code)
+	}
+}
+
+func TestCheckLoopback(t *testing.T) {
+	for addr, ok := range map[string]bool{
+		"127.0.0.1:9464": true,
+		"[::1]:9464":     true,
+		"localhost:9464": true,
+		"0.0.0.0:9464":   false,
+		":9464":          false,
+		"10.0.0.5:9464":  false,
+		"example.com:80": false,
+		"127.0.0.1":      false,
+	} {
+		if err := CheckLoopback(addr); (err == nil) != ok {
+			t.Errorf("CheckLoopback(%q) = %v, want ok %v", addr, err, ok)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := Serve(ctx, "0.0.0.0:0", http.NotFoundHandler()); err == nil {
+		t.Error("Serve() on a non-loopback address: want an error")
+	}
+	if err := Serve(ctx, "127.0.0.1:0", http.NotFoundHandler()); err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+// Package mockprovider implements a minimal Anthropic-compatible Messages API
+// that returns canned responses, with optional injected latency and errors.
+// It backs `kairo mock-provider` and the kairotest fake provider.
+package mockprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultReply is the assistant text returned when Options.Reply is empty.
+const DefaultReply = "Hello from the kairo mock provider."
+
+// Options configures the mock provider behavior.
+type Options struct {
+	// Latency delays every response.
+	Latency time.Duration
+	// ErrorRate is the fraction (0 to 1) of requests answered with a 529
+	// overloaded error instead of a reply.
+	ErrorRate float64
+	// Reply is the assistant text returned by /v1/messages.
+	Reply string
+	// Log, when set, receives one line per request.
+	Log io.Writer
+	// Rand returns a float in [0, 1) used for error injection. Defaults to
+	// math/rand/v2; tests inject a deterministic source.
+	Rand func() float64
+}
+
+// NewHandler returns an http.Handler serving the mock Messages API.
+func NewHandler(opts Options) http.Handler {
+	if opts.Reply == "" {
+		opts.Reply = DefaultReply
+	}
+	if opts.Rand == nil {
+		opts.Rand = rand.Float64
+	}
+
+	return &handler{opts: opts}
+}
+
+type handler struct {
+	opts     Options
+	requests atomic.Int64
+}
+
+// statusRecorder captures the status code written by a handler for logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	w.Header().Set("request-id", fmt.Sprintf("req_mock_%06d", h.requests.Add(1)))
+
+	h.serve(rec, r)
+
+	if h.opts.Log != nil {
+		fmt.Fprintf(h.opts.Log, "%s %s %d (%s)\n", r.Method, r.URL.Path, rec.status,
+			time.Since(start).Round(time.Millisecond))
+	}
+}
+
+func (h *handler) serve(w http.ResponseWriter, r *http.Request) {
+	if h.opts.Latency > 0 {
+		select {
+		case <-time.After(h.opts.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if r.Header.Get("x-api-key") == "" && r.Header.Get("Authorization") == "" {
+		WriteError(w, http.StatusUnauthorized, "authentication_error", "missing API key")
+
+		return
+	}
+
+	if h.opts.ErrorRate > 0 && h.opts.Rand() < h.opts.ErrorRate {
+		w.Header().Set("retry-after", "1")
+		WriteError(w, 529, "overloaded_error", "Overloaded (injected by mock provider)")
+
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/v1/messages" && r.Method == http.MethodPost:
+		h.serveMessages(w, r)
+	case r.URL.Path == "/v1/messages/count_tokens" && r.Method == http.MethodPost:
+		writeJSON(w, map[string]int{"input_tokens": 1})
+	case r.URL.Path == "/v1/models" && r.Method == http.MethodGet:
+		writeJSON(w, map[string]any{
+			"data":     []map[string]string{{"type": "model", "id": "mock-model", "display_name": "Mock Model"}},
+			"has_more": false,
+		})
+	default:
+		WriteError(w, http.StatusNotFound, "not_found_error", "unknown endpoint "+r.URL.Path)
+	}
+}
+
+type messagesRequest struct {
+	Model  string `json:"model"`
+	Stream bool   `json:"stream"`
+}
+
+func (h *handler) serveMessages(w http.ResponseWriter, r *http.Request) {
+	var req messagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "invalid_request_error", "invalid JSON body")
+
+		return
+	}
+	if req.Model == "" {
+		req.Model = "mock-model"
+	}
+
+	if req.Stream {
+		h.streamMessage(w, req.Model)
+
+		return
+	}
+
+	writeJSON(w, Message(req.Model, h.opts.Reply))
+}
+
+// Message returns a complete (non-streaming) Messages API response body.
+func Message(model, text string) map[string]any {
+	return map[string]any{
+		"id":            "msg_mock",
+		"type":          "message",
+		"role":          "assistant",
+		"model":         model,
+		"content":       []map[string]string{{"type": "text", "text": text}},
+		"stop_reason":   "end_turn",
+		"stop_sequence": nil,
+		"usage":         map[string]int{"input_tokens": 1, "output_tokens": len(strings.Fields(text))},
+	}
+}
+
+func (h *handler) streamMessage(w http.ResponseWriter, model string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	start := Message(model, "")
+	start["content"] = []any{}
+	events := []struct {
+		name string
+		data any
+	}{
+		{"message_start", map[string]any{"type": "message_start", "message": start}},
+		{"content_block_start", map[string]any{
+			"type": "content_block_start", "index": 0,
+			"content_block": map[string]string{"type": "text", "text": ""},
+		}},
+		{"content_block_delta", map[string]any{
+			"type": "content_block_delta", "index": 0,
+			"delta": map[string]string{"type": "text_delta", "text": h.opts.Reply},
+		}},
+		{"content_block_stop", map[string]any{"type": "content_block_stop", "index": 0}},
+		{"message_delta", map[string]any{
+			"type":  "message_delta",
+			"delta": map[string]any{"stop_reason": "end_turn", "stop_sequence": nil},
+			"usage": map[string]int{"output_tokens": len(strings.Fields(h.opts.Reply))},
+		}},
+		{"message_stop", map[string]string{"type": "message_stop"}},
+	}
+
+	flusher, _ := w.(http.Flusher)
+	for _, ev := range events {
+		data, _ := json.Marshal(ev.data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// WriteError writes an Anthropic-style error response.
+func WriteError(w http.ResponseWriter, status int, errType, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"type":  "error",
+		"error": map[string]string{"type": errType, "message": message},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
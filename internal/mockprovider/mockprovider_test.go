@@ -0,0 +1,131 @@
+package mockprovider
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func doRequest(t *testing.T, h http.Handler, method, path, body string, withKey bool) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if withKey {
+		req.Header.Set("x-api-key", "test")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestHandlerMessages(t *testing.T) {
+	h := NewHandler(Options{Reply: "canned reply"})
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/messages", `{"model":"m1"}`, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var msg struct {
+		Type    string `json:"type"`
+		Model   string `json:"model"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != "message" || msg.Model != "m1" || msg.Content[0].Text != "canned reply" {
+		t.Errorf("message = %+v", msg)
+	}
+}
+
+func TestHandlerStreaming(t *testing.T) {
+	h := NewHandler(Options{Reply: "streamed"})
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/messages", `{"model":"m1","stream":true}`, true)
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	body := rec.Body.String()
+	for _, event := range []string{"message_start", "content_block_delta", "message_stop"} {
+		if !strings.Contains(body, "event: "+event+"\n") {
+			t.Errorf("stream missing %s event:\n%s", event, body)
+		}
+	}
+	if !strings.Contains(body, `"text":"streamed"`) {
+		t.Errorf("stream missing reply text:\n%s", body)
+	}
+}
+
+func TestHandlerRoutes(t *testing.T) {
+	h := NewHandler(Options{})
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		withKey bool
+		want    int
+	}{
+		{"missing key", http.MethodPost, "/v1/messages", `{}`, false, http.StatusUnauthorized},
+		{"invalid body", http.MethodPost, "/v1/messages", `{`, true, http.StatusBadRequest},
+		{"models", http.MethodGet, "/v1/models", "", true, http.StatusOK},
+		{"count tokens", http.MethodPost, "/v1/messages/count_tokens", `{}`, true, http.StatusOK},
+		{"unknown", http.MethodGet, "/v1/unknown", "", true, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, h, tt.method, tt.path, tt.body, tt.withKey)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerErrorRate(t *testing.T) {
+	rolls := []float64{0.05, 0.5}
+	h := NewHandler(Options{
+		ErrorRate: 0.1,
+		Rand: func() float64 {
+			r := rolls[0]
+			rolls = rolls[1:]
+
+			return r
+		},
+	})
+
+	rec := doRequest(t, h, http.MethodPost, "/v1/messages", `{}`, true)
+	if rec.Code != 529 || !strings.Contains(rec.Body.String(), "overloaded_error") {
+		t.Errorf("first request = %d %s, want injected 529", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodPost, "/v1/messages", `{}`, true)
+	if rec.Code != http.StatusOK {
+		t.Errorf("second request = %d, want 200", rec.Code)
+	}
+}
+
+func TestHandlerLatencyAndLog(t *testing.T) {
+	var log bytes.Buffer
+	h := NewHandler(Options{Latency: 20 * time.Millisecond, Log: &log})
+
+	start := time.Now()
+	doRequest(t, h, http.MethodGet, "/v1/models", "", true)
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("request took %v, want at least 20ms", elapsed)
+	}
+	if !strings.HasPrefix(log.String(), "GET /v1/models 200") {
+		t.Errorf("log = %q, want request line", log.String())
+	}
+}
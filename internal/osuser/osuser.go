@@ -0,0 +1,77 @@
+// Package osuser identifies the user kairo runs as from the process's own
+// uid and gids rather than from os/user.Current, which, in binaries built
+// without cgo (such as those for musl Linux and the BSDs), falls back to
+// $USER when the user database has no entry for the uid.
+package osuser
+
+import (
+	"os"
+	"os/user"
+	"runtime"
+	"slices"
+	"strconv"
+)
+
+// Name returns the login name of the user the process runs as, or their
+// numeric uid when the user database has no entry for it, as in a container
+// run with an arbitrary uid.
+func Name() (string, error) {
+	if runtime.GOOS == "windows" {
+		u, err := user.Current()
+		if err != nil {
+			return "", err
+		}
+
+		return u.Username, nil
+	}
+
+	uid := strconv.Itoa(os.Getuid())
+	if u, err := user.LookupId(uid); err == nil {
+		return u.Username, nil
+	}
+
+	return uid, nil
+}
+
+// Groups returns the names of the process's primary and supplementary
+// groups, each as its numeric gid when the group database has no entry for
+// it.
+func Groups() []string {
+	if runtime.GOOS == "windows" {
+		return windowsGroups()
+	}
+
+	gids, _ := os.Getgroups()
+	if gid := os.Getgid(); !slices.Contains(gids, gid) {
+		gids = append([]int{gid}, gids...)
+	}
+	names := make([]string, 0, len(gids))
+	for _, gid := range gids {
+		id := strconv.Itoa(gid)
+		if g, err := user.LookupGroupId(id); err == nil {
+			id = g.Name
+		}
+		names = append(names, id)
+	}
+
+	return names
+}
+
+// windowsGroups returns the names of the current user's groups, leaving out
+// those that cannot be looked up. Windows has no numeric gids to fall back
+// to.
+func windowsGroups() []string {
+	u, err := user.Current()
+	if err != nil {
+		return nil
+	}
+	gids, _ := u.GroupIds()
+	var names []string
+	for _, gid := range gids {
+		if g, err := user.LookupGroupId(gid); err == nil {
+			names = append(names, g.Name)
+		}
+	}
+
+	return names
+}
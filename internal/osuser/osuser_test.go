@@ -0,0 +1,46 @@
+package osuser
+
+import (
+	"os"
+	"os/user"
+	"runtime"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestName_IgnoresEnvironment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows looks the user up by token, not by uid")
+	}
+	t.Setenv("USER", "kairo-spoofed-user")
+	t.Setenv("LOGNAME", "kairo-spoofed-user")
+
+	got, err := Name()
+	if err != nil {
+		t.Fatalf("Name() error = %v", err)
+	}
+	uid := strconv.Itoa(os.Getuid())
+	want := uid
+	if u, err := user.LookupId(uid); err == nil {
+		want = u.Username
+	}
+	if got != want {
+		t.Errorf("Name() = %q, want %q", got, want)
+	}
+}
+
+func TestGroups_IncludesPrimaryGroup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no numeric gids")
+	}
+
+	gid := strconv.Itoa(os.Getgid())
+	want := gid
+	if g, err := user.LookupGroupId(gid); err == nil {
+		want = g.Name
+	}
+	if got := Groups(); !slices.Contains(got, want) {
+		t.Errorf("Groups() = %q, want it to include %q", got, want)
+	}
+}
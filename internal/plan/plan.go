@@ -0,0 +1,113 @@
+// Package plan describes what a kairo command would do without doing it: the
+// files it reads, writes, and removes, the environment variables it sets, the
+// processes it runs, the endpoints it contacts, and the secrets it touches.
+// Plans name secrets and variables but never hold their values.
+package plan
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+)
+
+// Process is a process a command would run.
+type Process struct {
+	Path    string   `json:"path"`
+	Args    []string `json:"args,omitempty"`
+	Purpose string   `json:"purpose"`
+}
+
+// Plan is the machine-readable description printed by --explain. Every list
+// is present in the JSON output, empty when the command does nothing of that
+// kind, so consumers can rely on the keys.
+type Plan struct {
+	Command      string    `json:"command"`
+	Args         []string  `json:"args"`
+	FilesRead    []string  `json:"files_read"`
+	FilesWritten []string  `json:"files_written"`
+	FilesRemoved []string  `json:"files_removed"`
+	EnvSet       []string  `json:"env_set"`
+	Processes    []Process `json:"processes"`
+	Network      []string  `json:"network"`
+	Secrets      []string  `json:"secrets"`
+	Notes        []string  `json:"notes"`
+}
+
+// New returns an empty plan for the command path, such as "kairo secrets set",
+// run with args.
+func New(command string, args []string) *Plan {
+	return &Plan{
+		Command:      command,
+		Args:         append([]string{}, args...),
+		FilesRead:    []string{},
+		FilesWritten: []string{},
+		FilesRemoved: []string{},
+		EnvSet:       []string{},
+		Processes:    []Process{},
+		Network:      []string{},
+		Secrets:      []string{},
+		Notes:        []string{},
+	}
+}
+
+// Read records files the command reads.
+func (p *Plan) Read(paths ...string) {
+	p.FilesRead = appendNew(p.FilesRead, paths)
+}
+
+// Write records files the command creates or replaces.
+func (p *Plan) Write(paths ...string) {
+	p.FilesWritten = appendNew(p.FilesWritten, paths)
+}
+
+// Remove records files or directories the command deletes.
+func (p *Plan) Remove(paths ...string) {
+	p.FilesRemoved = appendNew(p.FilesRemoved, paths)
+}
+
+// SetEnv records the names of environment variables the command sets for a
+// process it runs.
+func (p *Plan) SetEnv(names ...string) {
+	p.EnvSet = appendNew(p.EnvSet, names)
+}
+
+// Exec records a process the command runs and why.
+func (p *Plan) Exec(purpose, path string, args ...string) {
+	p.Processes = append(p.Processes, Process{Path: path, Args: args, Purpose: purpose})
+}
+
+// Connect records network endpoints the command contacts or listens on.
+func (p *Plan) Connect(endpoints ...string) {
+	p.Network = appendNew(p.Network, endpoints)
+}
+
+// Secret records the names of secrets the command decrypts, stores, or
+// removes.
+func (p *Plan) Secret(names ...string) {
+	p.Secrets = appendNew(p.Secrets, names)
+}
+
+// Note records a condition the rest of the plan depends on, such as a step
+// that only happens when a file exists.
+func (p *Plan) Note(note string) {
+	p.Notes = append(p.Notes, note)
+}
+
+// Encode writes the plan to w as indented JSON.
+func (p *Plan) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(p)
+}
+
+// appendNew appends the non-empty values not already in list.
+func appendNew(list, values []string) []string {
+	for _, v := range values {
+		if v != "" && !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,41 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestPlanEncode(t *testing.T) {
+	p := New("kairo secrets set", []string{"zai"})
+	p.Read("/cfg/config.yaml", "/cfg/age.key", "/cfg/config.yaml")
+	p.Write("/cfg/secrets.age", "")
+	p.Secret("ZAI_API_KEY")
+	p.Exec("secret_access hook", "sh", "-c", "notify")
+
+	var buf bytes.Buffer
+	if err := p.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	for _, key := range []string{"files_read", "files_written", "files_removed", "env_set", "processes", "network", "secrets", "notes"} {
+		if _, ok := got[key].([]any); !ok {
+			t.Errorf("%s = %#v, want a list even when empty", key, got[key])
+		}
+	}
+
+	if !slices.Equal(p.FilesRead, []string{"/cfg/config.yaml", "/cfg/age.key"}) {
+		t.Errorf("FilesRead = %v, want duplicates dropped", p.FilesRead)
+	}
+	if !slices.Equal(p.FilesWritten, []string{"/cfg/secrets.age"}) {
+		t.Errorf("FilesWritten = %v, want empty paths dropped", p.FilesWritten)
+	}
+	if len(p.Processes) != 1 || p.Processes[0].Path != "sh" || !slices.Equal(p.Processes[0].Args, []string{"-c", "notify"}) {
+		t.Errorf("Processes = %+v", p.Processes)
+	}
+}
@@ -0,0 +1,195 @@
+// Package policy reads the optional policy.yaml an administrator deploys on
+// a shared machine to restrict which kairo commands and providers its users
+// may use, overall or by user and group.
+package policy
+
+import (
+	"bytes"
+	stderrors "errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/osuser"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the policy file.
+const FileName = "policy.yaml"
+
+// Path is where kairo looks for the policy file: a system directory that
+// users of a shared machine cannot write to. A variable so tests can move
+// it.
+var Path = func() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join("/Library/Application Support/kairo", FileName)
+	case "windows":
+		return filepath.Join(os.Getenv("ProgramData"), "kairo", FileName)
+	default:
+		return filepath.Join("/etc/kairo", FileName)
+	}
+}()
+
+// List allows and denies names. A name is denied if it matches Deny, or if
+// Allow is not empty and it matches nothing in Allow.
+type List struct {
+	Allow []string `yaml:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty"`
+}
+
+// Rule restricts commands and providers further for the users it names and
+// the members of the groups it names.
+type Rule struct {
+	Users     []string `yaml:"users,omitempty"`
+	Groups    []string `yaml:"groups,omitempty"`
+	Commands  List     `yaml:"commands,omitempty"`
+	Providers List     `yaml:"providers,omitempty"`
+}
+
+// Policy is the content of policy.yaml. Commands and Providers apply to
+// everyone; each matching rule adds its own restrictions on top, so a rule
+// can only narrow what is allowed.
+//
+// Commands are named by their path without "kairo", such as "secrets set";
+// an entry also covers the subcommands below it, so "secrets" covers
+// "secrets set". Providers are matched as shell patterns, such as "team-*".
+type Policy struct {
+	Commands  List   `yaml:"commands,omitempty"`
+	Providers List   `yaml:"providers,omitempty"`
+	Rules     []Rule `yaml:"rules,omitempty"`
+}
+
+// Identity is who kairo runs as, for matching rules.
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+// CurrentIdentity returns the user kairo runs as and the names of their
+// groups. A user or group missing from the system's databases is named by
+// its numeric id, never by $USER, which the user could set to anything.
+func CurrentIdentity() (Identity, error) {
+	name, err := osuser.Name()
+	if err != nil {
+		return Identity{}, errors.WrapError(errors.RuntimeError, "cannot determine the current user", err)
+	}
+
+	return Identity{User: name, Groups: osuser.Groups()}, nil
+}
+
+// Load reads the policy file at file. A missing file means no policy and
+// returns nil. A file that cannot be read or parsed is an error rather than
+// no policy, so a broken policy does not lift its restrictions.
+func Load(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if stderrors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.FileError("failed to read policy file", file, err)
+	}
+
+	var p Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&p); err != nil && !stderrors.Is(err, io.EOF) {
+		return nil, errors.WrapError(errors.ConfigError, "invalid policy file", err).
+			WithContext("path", file)
+	}
+	for _, pattern := range p.providerPatterns() {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.NewError(errors.ConfigError, "invalid provider pattern in policy file: "+pattern).
+				WithContext("path", file)
+		}
+	}
+
+	return &p, nil
+}
+
+func (p *Policy) providerPatterns() []string {
+	patterns := slices.Concat(p.Providers.Allow, p.Providers.Deny)
+	for _, r := range p.Rules {
+		patterns = slices.Concat(patterns, r.Providers.Allow, r.Providers.Deny)
+	}
+
+	return patterns
+}
+
+// applies reports whether r names id's user or one of its groups.
+func (r Rule) applies(id Identity) bool {
+	if slices.Contains(r.Users, id.User) {
+		return true
+	}
+	for _, g := range id.Groups {
+		if slices.Contains(r.Groups, g) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lists returns the command or provider lists that apply to id.
+func (p *Policy) lists(id Identity, pick func(Rule) List) []List {
+	lists := []List{pick(Rule{Commands: p.Commands, Providers: p.Providers})}
+	for _, r := range p.Rules {
+		if r.applies(id) {
+			lists = append(lists, pick(r))
+		}
+	}
+
+	return lists
+}
+
+// AllowsCommand reports whether id may run the command with the given path,
+// such as "secrets set". A nil policy allows everything.
+func (p *Policy) AllowsCommand(id Identity, command string) bool {
+	if p == nil {
+		return true
+	}
+
+	return allowed(p.lists(id, func(r Rule) List { return r.Commands }), command, commandMatches)
+}
+
+// AllowsProvider reports whether id may use the named provider. A nil
+// policy allows everything.
+func (p *Policy) AllowsProvider(id Identity, name string) bool {
+	if p == nil {
+		return true
+	}
+
+	return allowed(p.lists(id, func(r Rule) List { return r.Providers }), name, providerMatches)
+}
+
+func allowed(lists []List, name string, matches func(entry, name string) bool) bool {
+	match := func(entries []string) bool {
+		return slices.ContainsFunc(entries, func(e string) bool { return matches(e, name) })
+	}
+	for _, l := range lists {
+		if match(l.Deny) || (len(l.Allow) > 0 && !match(l.Allow)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// commandMatches reports whether entry names command or a command above it.
+func commandMatches(entry, command string) bool {
+	entry = strings.Join(strings.Fields(entry), " ")
+
+	return command == entry || strings.HasPrefix(command, entry+" ")
+}
+
+func providerMatches(pattern, name string) bool {
+	ok, _ := path.Match(pattern, name)
+
+	return ok
+}
@@ -0,0 +1,131 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestLoad_Missing(t *testing.T) {
+	p, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil || p != nil {
+		t.Fatalf("Load() = %v, %v, want no policy", p, err)
+	}
+	if !p.AllowsCommand(Identity{User: "alice"}, "secrets export") || !p.AllowsProvider(Identity{}, "zai") {
+		t.Error("a nil policy should allow everything")
+	}
+}
+
+func TestLoad_Empty(t *testing.T) {
+	p, err := Load(writePolicy(t, ""))
+	if err != nil || p == nil {
+		t.Fatalf("Load() = %v, %v, want an empty policy", p, err)
+	}
+	if !p.AllowsCommand(Identity{}, "delete") {
+		t.Error("an empty policy should allow everything")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"syntax":          "commands: [",
+		"unknown field":   "comands:\n  deny: [delete]\n",
+		"invalid pattern": "providers:\n  allow: [\"team-[\"]\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(writePolicy(t, content)); err == nil {
+				t.Error("Load() should fail rather than apply no policy")
+			}
+		})
+	}
+}
+
+func TestPolicy_Rules(t *testing.T) {
+	p, err := Load(writePolicy(t, `commands:
+  deny: [update]
+rules:
+  - groups: [interns]
+    providers:
+      allow: [minimax]
+    commands:
+      deny: [secrets export, providers export]
+  - users: [bob]
+    providers:
+      deny: ["team-*"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice := Identity{User: "alice", Groups: []string{"staff"}}
+	intern := Identity{User: "carol", Groups: []string{"staff", "interns"}}
+	bob := Identity{User: "bob"}
+
+	commands := []struct {
+		id      Identity
+		command string
+		want    bool
+	}{
+		{alice, "secrets export", true},
+		{alice, "update", false},
+		{intern, "update", false},
+		{intern, "secrets export", false},
+		{intern, "secrets set", true},
+		{intern, "providers export", false},
+		{intern, "providers", true},
+		{intern, "secrets", true},
+	}
+	for _, tt := range commands {
+		if got := p.AllowsCommand(tt.id, tt.command); got != tt.want {
+			t.Errorf("AllowsCommand(%s, %q) = %v, want %v", tt.id.User, tt.command, got, tt.want)
+		}
+	}
+
+	providers := []struct {
+		id   Identity
+		name string
+		want bool
+	}{
+		{alice, "zai", true},
+		{intern, "minimax", true},
+		{intern, "zai", false},
+		{bob, "team-eu", false},
+		{bob, "zai", true},
+	}
+	for _, tt := range providers {
+		if got := p.AllowsProvider(tt.id, tt.name); got != tt.want {
+			t.Errorf("AllowsProvider(%s, %q) = %v, want %v", tt.id.User, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPolicy_CommandEntryCoversSubcommands(t *testing.T) {
+	p := &Policy{Commands: List{Deny: []string{"secrets"}}}
+	for _, command := range []string{"secrets", "secrets set"} {
+		if p.AllowsCommand(Identity{}, command) {
+			t.Errorf("AllowsCommand(%q) = true, want denied by \"secrets\"", command)
+		}
+	}
+	if !p.AllowsCommand(Identity{}, "secretsx") {
+		t.Error("\"secrets\" should not cover \"secretsx\"")
+	}
+
+	p = &Policy{Commands: List{Allow: []string{"list", "  providers   show "}}}
+	if !p.AllowsCommand(Identity{}, "providers show") {
+		t.Error("entries should be matched with their spacing normalized")
+	}
+	if p.AllowsCommand(Identity{}, "providers export") {
+		t.Error("an allow list should deny what it does not name")
+	}
+}
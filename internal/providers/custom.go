@@ -3,23 +3,28 @@ package providers
 // CustomProviderDefinition is the YAML-deserializable form of a provider
 // definition. Users define these under custom_providers in config.yaml.
 type CustomProviderDefinition struct {
-	Name           string   `yaml:"name"`
-	BaseURL        string   `yaml:"base_url"`
-	Model          string   `yaml:"model"`
-	EnvVars        []string `yaml:"env_vars"`
-	RequiresAPIKey bool     `yaml:"requires_api_key"`
-	APIKeyEnvVar   string   `yaml:"api_key_env_var"`
-	MinKeyLength   int      `yaml:"min_key_length"`
-	KeyPrefix      string   `yaml:"key_prefix"`
-	KeyPattern     string   `yaml:"key_pattern"`
+	Name             string   `yaml:"name" doc:"Display name shown in setup and list commands"`
+	BaseURL          string   `yaml:"base_url" doc:"Anthropic-compatible endpoint (HTTPS only)"`
+	Model            string   `yaml:"model" doc:"Default model"`
+	EnvVars          []string `yaml:"env_vars" doc:"Extra KEY=value environment variables for the harness"`
+	RequiresAPIKey   bool     `yaml:"requires_api_key" doc:"Whether an API key is required" default:"true"`
+	APIKeyEnvVar     string   `yaml:"api_key_env_var" doc:"Environment variable name for the API key"`
+	MinKeyLength     int      `yaml:"min_key_length" doc:"Minimum API key length" default:"20"`
+	KeyPrefix        string   `yaml:"key_prefix" doc:"Prefix every API key starts with, such as sk-"`
+	KeyPattern       string   `yaml:"key_pattern" doc:"Regular expression the API key must match"`
+	TypicalKeyLength int      `yaml:"typical_key_length,omitempty" doc:"Usual key length; shorter keys get a may-be-truncated warning"`
+	MinKeyEntropy    float64  `yaml:"min_key_entropy,omitempty" doc:"Bits of entropy per character below which a key is reported as weak" default:"3.0"`
+	AuthStyle        string   `yaml:"auth_style,omitempty" doc:"Header that carries the API key" default:"both" enum:"x-api-key,bearer,both"`
 }
 
 // ToProviderDefinition converts the YAML form into the internal ProviderDefinition.
 func (c CustomProviderDefinition) ToProviderDefinition() ProviderDefinition {
 	kf := KeyFormat{
-		MinLength: c.MinKeyLength,
-		Prefix:    c.KeyPrefix,
-		Pattern:   c.KeyPattern,
+		MinLength:     c.MinKeyLength,
+		Prefix:        c.KeyPrefix,
+		Pattern:       c.KeyPattern,
+		TypicalLength: c.TypicalKeyLength,
+		MinEntropy:    c.MinKeyEntropy,
 	}
 	if kf.MinLength == 0 {
 		kf.MinLength = DefaultMinKeyLength
@@ -33,5 +38,6 @@ func (c CustomProviderDefinition) ToProviderDefinition() ProviderDefinition {
 		RequiresAPIKey: c.RequiresAPIKey,
 		APIKeyEnvVar:   c.APIKeyEnvVar,
 		KeyFormat:      kf,
+		AuthStyle:      c.AuthStyle,
 	}
 }
@@ -25,11 +25,14 @@ const (
 	DefaultMinKeyLength = 20
 )
 
-// KeyFormat holds minimum length, prefix, and pattern rules for API key validation.
+// KeyFormat holds minimum length, prefix, and pattern rules for API key
+// validation, plus the strength heuristics used to warn about weak keys.
 type KeyFormat struct {
-	MinLength int    `json:"min_length"`
-	Prefix    string `json:"prefix"`
-	Pattern   string `json:"pattern"`
+	MinLength     int     `json:"min_length"`
+	Prefix        string  `json:"prefix"`
+	Pattern       string  `json:"pattern"`
+	TypicalLength int     `json:"typical_length,omitempty"`
+	MinEntropy    float64 `json:"min_entropy,omitempty"`
 }
 
 // compiledCache caches compiled regexps by pattern to avoid races on
@@ -83,6 +86,15 @@ type catalogProvider struct {
 	RequiresAPIKey bool      `json:"requires_api_key"`
 	APIKeyEnvVar   string    `json:"api_key_env_var"`
 	KeyFormat      KeyFormat `json:"key_format"`
+	AuthStyle      string    `json:"auth_style,omitempty"`
+	Info           Info      `json:"info,omitzero"`
+}
+
+// Info is a provider's quick-start documentation, shown by `kairo providers
+// info` and at the setup API key prompt so that it is available offline.
+type Info struct {
+	KeyURL string   `json:"key_url,omitempty"`
+	Notes  []string `json:"notes,omitempty"`
 }
 
 // loadEmbeddedCatalog parses the embedded catalog.json into a map of providers.
@@ -100,12 +112,25 @@ func loadEmbeddedCatalog() map[string]ProviderDefinition {
 	return result
 }
 
+// cachedDefinition converts a provider of a downloaded catalog, keeping the
+// embedded quick-start info when the catalog predates it.
+func cachedDefinition(name string, p catalogProvider) ProviderDefinition {
+	def := ProviderDefinition(p)
+	if def.Info.KeyURL == "" && len(def.Info.Notes) == 0 {
+		def.Info = builtInProviders[name].Info
+	}
+
+	return def
+}
+
 // builtInProviders maps provider short names to their definitions.
 // Populated at init from the embedded catalog.json.
 var builtInProviders = loadEmbeddedCatalog()
 
 // ProviderDefinition describes a built-in provider's display name, default
-// base URL, model, environment variables, API key requirements, and key format.
+// base URL, model, environment variables, API key requirements, key format,
+// the auth header style its endpoint expects (empty means either), and its
+// quick-start documentation.
 type ProviderDefinition struct {
 	Name           string
 	BaseURL        string
@@ -114,6 +139,8 @@ type ProviderDefinition struct {
 	RequiresAPIKey bool
 	APIKeyEnvVar   string
 	KeyFormat      KeyFormat
+	AuthStyle      string
+	Info           Info
 }
 
 // ValidateAPIKey checks the given key against this provider's key format rules.
@@ -131,6 +158,32 @@ func (d ProviderDefinition) ValidateAPIKey(key string) error {
 	return nil
 }
 
+// Models lists the model identifiers the definition names: the default
+// model, then the values of its *_MODEL environment variables, such as the
+// small fast model.
+func (d ProviderDefinition) Models() []string {
+	return AppendModels(nil, d.Model, d.EnvVars)
+}
+
+// AppendModels appends model and the values of the *_MODEL entries in
+// envVars to models, skipping empty names and names already present.
+func AppendModels(models []string, model string, envVars []string) []string {
+	add := func(m string) {
+		if m != "" && !slices.Contains(models, m) {
+			models = append(models, m)
+		}
+	}
+
+	add(model)
+	for _, kv := range envVars {
+		if key, value, ok := strings.Cut(kv, "="); ok && strings.HasSuffix(key, "_MODEL") {
+			add(strings.TrimSpace(value))
+		}
+	}
+
+	return models
+}
+
 // providerPriority defines the preferred display order for providers.
 // Providers not listed here appear after these, in alphabetical order.
 var providerPriority = []string{
@@ -345,7 +398,7 @@ func (r *ProviderRegistry) LoadCache(path string) error {
 
 	r.cached = make(map[string]ProviderDefinition, len(raw))
 	for k := range raw {
-		r.cached[k] = ProviderDefinition(raw[k])
+		r.cached[k] = cachedDefinition(k, raw[k])
 	}
 
 	return nil
@@ -362,7 +415,7 @@ func (r *ProviderRegistry) RefreshCacheFromBytes(data []byte, path string) (int,
 
 	cached := make(map[string]ProviderDefinition, len(raw))
 	for k := range raw {
-		cached[k] = ProviderDefinition(raw[k])
+		cached[k] = cachedDefinition(k, raw[k])
 	}
 
 	// Write to disk atomically.
@@ -829,6 +829,15 @@ func TestProviderRegistry_RefreshCacheFromBytes(t *testing.T) {
 		t.Error("cache file should exist on disk")
 	}
 
+	// A catalog without quick-start info keeps the embedded info
+	data = []byte(`{"zai":{"name":"Z.AI","requires_api_key":true,"api_key_env_var":"ZAI_API_KEY","key_format":{"min_length":32}}}`)
+	if _, err := r.RefreshCacheFromBytes(data, cachePath); err != nil {
+		t.Fatalf("RefreshCacheFromBytes: %v", err)
+	}
+	if def, _ := r.BuiltInProvider("zai"); def.Info.KeyURL != builtInProviders["zai"].Info.KeyURL {
+		t.Errorf("cached zai KeyURL = %q, want the embedded one", def.Info.KeyURL)
+	}
+
 	// Bad JSON should error
 	_, err = r.RefreshCacheFromBytes([]byte(`{bad`), cachePath)
 	if err == nil {
@@ -898,3 +907,47 @@ var (
 	validOpenRouterKey = "sk-or-" + strings.Repeat("x", 27)
 	validZAIKey        = strings.Repeat("y", 32)
 )
+
+func TestProviderDefinitionModels(t *testing.T) {
+	def := ProviderDefinition{
+		Model: "deepseek-v4-pro",
+		EnvVars: []string{
+			"ANTHROPIC_DEFAULT_HAIKU_MODEL=deepseek-v4-flash",
+			"CLAUDE_CODE_SUBAGENT_MODEL=deepseek-v4-flash",
+			"ANTHROPIC_SMALL_FAST_MODEL_TIMEOUT=120",
+			"API_TIMEOUT_MS=600000",
+		},
+	}
+
+	got := def.Models()
+	want := []string{"deepseek-v4-pro", "deepseek-v4-flash"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Models() = %v, want %v", got, want)
+	}
+
+	got = AppendModels([]string{"deepseek-v4-flash"}, "", []string{"X_MODEL=", "Y_MODEL=custom"})
+	if want := []string{"deepseek-v4-flash", "custom"}; !slices.Equal(got, want) {
+		t.Errorf("AppendModels() = %v, want %v", got, want)
+	}
+}
+
+func TestBuiltInProviderInfo(t *testing.T) {
+	for _, name := range ProviderList() {
+		def, _ := BuiltInProvider(name)
+		if name == "custom" {
+			if def.Info.KeyURL != "" {
+				t.Errorf("custom KeyURL = %q, want none", def.Info.KeyURL)
+			}
+
+			continue
+		}
+		if !strings.HasPrefix(def.Info.KeyURL, "https://") {
+			t.Errorf("%s KeyURL = %q, want an https URL", name, def.Info.KeyURL)
+		}
+	}
+
+	def, _ := BuiltInProvider("zai")
+	if len(def.Info.Notes) == 0 {
+		t.Error("zai has no notes")
+	}
+}
@@ -0,0 +1,116 @@
+package providers
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultMinKeyEntropy is the Shannon entropy, in bits per character, below
+// which a key is reported as weak. Random base64 or hex keys score well
+// above it; repeated characters and dictionary words fall below.
+const DefaultMinKeyEntropy = 3.0
+
+// maxKeyRun is the longest run of one repeated character accepted before a
+// key is reported as weak.
+const maxKeyRun = 6
+
+// placeholderMarkers are substrings found in sample keys copied from
+// documentation rather than real credentials.
+var placeholderMarkers = []string{
+	"your-api-key", "your_api_key", "yourapikey", "api-key-here", "apikeyhere",
+	"changeme", "example", "placeholder", "xxxxxx", "redacted",
+}
+
+// KeyEntropy returns the Shannon entropy of key in bits per character.
+func KeyEntropy(key string) float64 {
+	if key == "" {
+		return 0
+	}
+
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range key {
+		counts[r]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
+// strengthWarnings returns heuristic warnings for a key that already passed
+// validateForKey. Warnings do not block storing the key.
+func (kf *KeyFormat) strengthWarnings(key string) []string {
+	var warnings []string
+
+	if strings.TrimSpace(key) != key {
+		warnings = append(warnings, "key has leading or trailing whitespace")
+	}
+	if len(key) >= 2 && strings.ContainsRune(`"'`+"`", rune(key[0])) && key[len(key)-1] == key[0] {
+		warnings = append(warnings, "key is wrapped in quotes")
+	}
+
+	if kf.TypicalLength > 0 && len(key) < kf.TypicalLength {
+		warnings = append(warnings, fmt.Sprintf(
+			"key is shorter than usual (%d characters, keys for this provider are typically %d); it may be truncated",
+			len(key), kf.TypicalLength))
+	}
+
+	lower := strings.ToLower(key)
+	for _, marker := range placeholderMarkers {
+		if strings.Contains(lower, marker) {
+			warnings = append(warnings, fmt.Sprintf("key contains %q and looks like a placeholder", marker))
+
+			break
+		}
+	}
+
+	if run := longestRun(key); run > maxKeyRun {
+		warnings = append(warnings, fmt.Sprintf("key repeats one character %d times in a row", run))
+	}
+
+	minEntropy := kf.MinEntropy
+	if minEntropy == 0 {
+		minEntropy = DefaultMinKeyEntropy
+	}
+	if e := KeyEntropy(strings.TrimPrefix(key, kf.Prefix)); e < minEntropy {
+		warnings = append(warnings, fmt.Sprintf(
+			"key has low entropy (%.1f bits per character, expected at least %.1f)", e, minEntropy))
+	}
+
+	return warnings
+}
+
+func longestRun(s string) int {
+	longest, run := 0, 0
+	var prev rune
+	for i, r := range s {
+		if i > 0 && r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		prev = r
+		longest = max(longest, run)
+	}
+
+	return longest
+}
+
+// KeyStrengthWarnings reports heuristic problems with key for this provider:
+// low entropy, long repeated runs, placeholder text, paste artifacts, and a
+// length below the provider's typical key length. It returns nil when the key
+// looks fine or is empty.
+func (d ProviderDefinition) KeyStrengthWarnings(key string) []string {
+	if strings.TrimSpace(key) == "" {
+		return nil
+	}
+
+	return d.KeyFormat.strengthWarnings(key)
+}
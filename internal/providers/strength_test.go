@@ -0,0 +1,64 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyEntropy(t *testing.T) {
+	if got := KeyEntropy(""); got != 0 {
+		t.Errorf("KeyEntropy(\"\") = %v, want 0", got)
+	}
+	if got := KeyEntropy("aaaaaaaa"); got != 0 {
+		t.Errorf("KeyEntropy(aaaaaaaa) = %v, want 0", got)
+	}
+	if got := KeyEntropy("abcd"); got != 2 {
+		t.Errorf("KeyEntropy(abcd) = %v, want 2", got)
+	}
+}
+
+func TestKeyStrengthWarnings(t *testing.T) {
+	def := ProviderDefinition{
+		Name:      "Test",
+		KeyFormat: KeyFormat{MinLength: 20, Prefix: "sk-", TypicalLength: 48},
+	}
+	good := "sk-Zq8vN3pLr7Tx2KmW9cYd4HbF6gJs1QaE5uXo0iRtVyBnMk"
+
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{"good key", good, ""},
+		{"empty key", "", ""},
+		{"truncated", good[:30], "may be truncated"},
+		{"placeholder", "sk-your-api-key-goes-here-Zq8vN3pLr7Tx2KmW9cYd4Hb", "placeholder"},
+		{"repeated run", "sk-Zq8vN3pLr7Tx2KmW9cYd4HbF6gJs1Qaaaaaaaaaaaaaaa", "repeats one character"},
+		{"low entropy", "sk-ababababababababababababababababababababababab", "low entropy"},
+		{"trailing newline", good + "\n", "whitespace"},
+		{"quoted", `"` + good + `"`, "quotes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := def.KeyStrengthWarnings(tt.key)
+			if tt.want == "" {
+				if len(got) != 0 {
+					t.Errorf("KeyStrengthWarnings() = %v, want none", got)
+				}
+
+				return
+			}
+			if !strings.Contains(strings.Join(got, "\n"), tt.want) {
+				t.Errorf("KeyStrengthWarnings() = %v, want one containing %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyStrengthWarningsCustomMinEntropy(t *testing.T) {
+	def := CustomProviderDefinition{Name: "Test", MinKeyEntropy: 6}.ToProviderDefinition()
+	if got := def.KeyStrengthWarnings("Zq8vN3pLr7Tx2KmW9cYd4HbF6gJs1QaE"); len(got) != 1 {
+		t.Errorf("KeyStrengthWarnings() = %v, want one low entropy warning", got)
+	}
+}
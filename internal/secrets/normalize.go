@@ -0,0 +1,70 @@
+package secrets
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NormalizeKey cleans an API key as it was typed, pasted, or piped in: it
+// removes a byte order mark, one trailing line ending, and whitespace or
+// invisible characters around the key. It returns the clean key and a note
+// for each change made, such as "trimmed trailing newline", so that the
+// user sees what was stored.
+//
+// A key that is not UTF-8, or that holds whitespace or invisible characters
+// inside it, is rejected rather than repaired: many gateways refuse such
+// keys, and there is no telling which part was meant.
+func NormalizeKey(key string) (string, []string, error) {
+	if strings.HasPrefix(key, "\xff\xfe") || strings.HasPrefix(key, "\xfe\xff") {
+		return "", nil, fmt.Errorf("the key is UTF-16 encoded; save it as UTF-8 and try again")
+	}
+	if !utf8.ValidString(key) {
+		for i, r := range key {
+			if r == utf8.RuneError {
+				return "", nil, fmt.Errorf("the key is not valid UTF-8 (invalid byte at offset %d)", i)
+			}
+		}
+	}
+
+	var notes []string
+	if rest, ok := strings.CutPrefix(key, "\uFEFF"); ok {
+		key = rest
+		notes = append(notes, "removed byte order mark")
+	}
+	switch {
+	case strings.HasSuffix(key, "\r\n"):
+		key = strings.TrimSuffix(key, "\r\n")
+		notes = append(notes, "trimmed trailing CRLF line ending")
+	case strings.HasSuffix(key, "\n"):
+		key = strings.TrimSuffix(key, "\n")
+		notes = append(notes, "trimmed trailing newline")
+	case strings.HasSuffix(key, "\r"):
+		key = strings.TrimSuffix(key, "\r")
+		notes = append(notes, "trimmed trailing carriage return")
+	}
+	if trimmed := strings.TrimLeftFunc(key, invisible); trimmed != key {
+		key = trimmed
+		notes = append(notes, "trimmed leading whitespace")
+	}
+	if trimmed := strings.TrimRightFunc(key, invisible); trimmed != key {
+		key = trimmed
+		notes = append(notes, "trimmed trailing whitespace")
+	}
+
+	for i, r := range key {
+		if invisible(r) {
+			return "", nil, fmt.Errorf("the key contains the invisible character %U at offset %d", r, i)
+		}
+	}
+
+	return key, notes, nil
+}
+
+// invisible reports whether r is whitespace, a control character, or a
+// formatting character such as a zero-width space, none of which belong in
+// a key.
+func invisible(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r) || unicode.Is(unicode.Cf, r)
+}
@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		wantNotes []string
+	}{
+		{name: "clean key", input: "sk-abc123", want: "sk-abc123"},
+		{name: "trailing newline", input: "sk-abc123\n", want: "sk-abc123", wantNotes: []string{"trimmed trailing newline"}},
+		{name: "CRLF", input: "sk-abc123\r\n", want: "sk-abc123", wantNotes: []string{"trimmed trailing CRLF line ending"}},
+		{name: "carriage return", input: "sk-abc123\r", want: "sk-abc123", wantNotes: []string{"trimmed trailing carriage return"}},
+		{
+			name:      "byte order mark and CRLF",
+			input:     "\uFEFFsk-abc123\r\n",
+			want:      "sk-abc123",
+			wantNotes: []string{"removed byte order mark", "trimmed trailing CRLF line ending"},
+		},
+		{
+			name:      "surrounding whitespace",
+			input:     "  sk-abc123 \t\n",
+			want:      "sk-abc123",
+			wantNotes: []string{"trimmed trailing newline", "trimmed leading whitespace", "trimmed trailing whitespace"},
+		},
+		{
+			name:      "trailing zero-width space",
+			input:     "sk-abc123\u200B",
+			want:      "sk-abc123",
+			wantNotes: []string{"trimmed trailing whitespace"},
+		},
+		{name: "non-breaking space", input: "sk-abc123\u00A0", want: "sk-abc123", wantNotes: []string{"trimmed trailing whitespace"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, notes, err := NormalizeKey(tt.input)
+			if err != nil {
+				t.Fatalf("NormalizeKey(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want || !slices.Equal(notes, tt.wantNotes) {
+				t.Errorf("NormalizeKey(%q) = %q, %q; want %q, %q", tt.input, got, notes, tt.want, tt.wantNotes)
+			}
+		})
+	}
+}
+
+func TestNormalizeKey_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "UTF-16", input: "\xff\xfes\x00k\x00", wantErr: "UTF-16"},
+		{name: "invalid UTF-8", input: "sk-abc\xe9123", wantErr: "offset 6"},
+		{name: "inner space", input: "sk-abc 123", wantErr: "U+0020"},
+		{name: "inner zero-width space", input: "sk-abc\u200B123", wantErr: "U+200B"},
+		{name: "two lines", input: "sk-abc\nsk-def\n", wantErr: "U+000A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := NormalizeKey(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NormalizeKey(%q) error = %v, want one mentioning %q", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,11 +2,85 @@
 package secrets
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 )
 
+// CommandPrefix marks a secret value as a command whose standard output is
+// the secret, so that the credential itself is never stored.
+const CommandPrefix = "command:"
+
+// Command returns the command a value names when it starts with
+// CommandPrefix.
+func Command(value string) (string, bool) {
+	command, ok := strings.CutPrefix(value, CommandPrefix)
+	if !ok {
+		return "", false
+	}
+	command = strings.TrimSpace(command)
+
+	return command, command != ""
+}
+
+// MaxFileSize is the largest secrets file kairo reads or writes. A few
+// dozen keys take a few kilobytes, so anything bigger is a sign of a
+// corrupted or foreign file.
+const MaxFileSize = 1 << 20
+
+// Payload format versions. Version 1 starts the plaintext with a header
+// line holding the version and a SHA-256 checksum of the rest. Files written
+// before the header was introduced have none and are read as they are.
+const (
+	headerPrefix   = "# kairo-secrets v"
+	currentVersion = 1
+)
+
+// ErrCorrupted is returned by Open when the payload does not match its
+// checksum or has an unreadable header.
+var ErrCorrupted = errors.New("secrets payload failed its integrity check")
+
+// Seal prepends the version and checksum header to content, the formatted
+// secrets, before it is encrypted.
+func Seal(content string) string {
+	sum := sha256.Sum256([]byte(content))
+
+	return fmt.Sprintf("%s%d sha256=%s\n%s", headerPrefix, currentVersion, hex.EncodeToString(sum[:]), content)
+}
+
+// Open verifies and strips the header written by Seal from decrypted
+// plaintext. Plaintext without a header is returned unchanged.
+func Open(plaintext []byte) ([]byte, error) {
+	if !bytes.HasPrefix(plaintext, []byte(headerPrefix)) {
+		return plaintext, nil
+	}
+
+	header, body, ok := bytes.Cut(plaintext, []byte("\n"))
+	if !ok {
+		return nil, fmt.Errorf("%w: header is not terminated", ErrCorrupted)
+	}
+	var version int
+	var checksum string
+	if _, err := fmt.Sscanf(string(header), headerPrefix+"%d sha256=%s", &version, &checksum); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrCorrupted)
+	}
+	if version > currentVersion {
+		return nil, fmt.Errorf("secrets file format v%d is newer than this kairo supports (v%d); upgrade kairo",
+			version, currentVersion)
+	}
+
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorrupted)
+	}
+
+	return body, nil
+}
+
 // Result holds parsed secrets along with parsing metadata.
 type Result struct {
 	Secrets      map[string]string
@@ -94,3 +168,11 @@ func EnvVars(secrets map[string]string) []string {
 
 	return envVars
 }
+
+// Fingerprint returns a short, non-reversible identifier for a secret value
+// so that audit entries can tell keys apart without recording them.
+func Fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -1,6 +1,7 @@
 package secrets
 
 import (
+	"errors"
 	"os"
 	"strings"
 	"testing"
@@ -301,3 +302,79 @@ func TestParseStillReturnsWarnings(t *testing.T) {
 		})
 	}
 }
+
+func TestFingerprint(t *testing.T) {
+	a := Fingerprint("sk-old-key")
+	if len(a) != 12 {
+		t.Errorf("Fingerprint() length = %d, want 12", len(a))
+	}
+	if a != Fingerprint("sk-old-key") {
+		t.Error("Fingerprint() is not deterministic")
+	}
+	if a == Fingerprint("sk-new-key") {
+		t.Error("Fingerprint() collides for different keys")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"command:op read op://vault/zai/key", "op read op://vault/zai/key", true},
+		{"command:  pass show zai  ", "pass show zai", true},
+		{"command:", "", false},
+		{"sk-ant-1234", "", false},
+		{"Command:op read x", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Command(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Command(%q) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	parsed := Parse("ZAI_API_KEY=command:aws secretsmanager get-secret-value --secret-id a=b\n")
+	if got, _ := Command(parsed["ZAI_API_KEY"]); got != "aws secretsmanager get-secret-value --secret-id a=b" {
+		t.Errorf("Parse() lost the command: %q", parsed["ZAI_API_KEY"])
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	content := Format(map[string]string{"ZAI_API_KEY": "sk-zai", "KIMI_API_KEY": "sk-kimi"})
+	sealed := Seal(content)
+	if !strings.HasPrefix(sealed, "# kairo-secrets v1 sha256=") {
+		t.Fatalf("Seal() = %q, want a version header", sealed)
+	}
+	if got := Parse(sealed); len(got) != 2 {
+		t.Errorf("Parse(sealed) = %v, want the header skipped as a comment", got)
+	}
+
+	body, err := Open([]byte(sealed))
+	if err != nil || string(body) != content {
+		t.Fatalf("Open() = %q, %v, want the original content", body, err)
+	}
+
+	legacy := []byte("ZAI_API_KEY=sk-zai\n")
+	if body, err := Open(legacy); err != nil || string(body) != string(legacy) {
+		t.Errorf("Open(legacy) = %q, %v, want it unchanged", body, err)
+	}
+
+	tests := map[string]string{
+		"flipped byte": strings.Replace(sealed, "sk-zai", "sk-zaj", 1),
+		"truncated":    sealed[:len(sealed)-5],
+		"bad header":   "# kairo-secrets vX\nZAI_API_KEY=sk-zai\n",
+		"no newline":   "# kairo-secrets v1 sha256=abc",
+	}
+	for name, payload := range tests {
+		if _, err := Open([]byte(payload)); !errors.Is(err, ErrCorrupted) {
+			t.Errorf("Open(%s) error = %v, want ErrCorrupted", name, err)
+		}
+	}
+
+	newer := strings.Replace(sealed, "v1 ", "v2 ", 1)
+	if _, err := Open([]byte(newer)); err == nil || errors.Is(err, ErrCorrupted) || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("Open(v2) error = %v, want a newer-format error", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Package session holds state that lives for one kairo process. Its Secrets
+// memo lets every part of a command share a single decryption of the
+// secrets file, which matters when the key sits behind a slow disk, an
+// agent, or a hardware token.
+package session
+
+import (
+	"os"
+	"sync"
+
+	"github.com/dkmnx/kairo/internal/crypto"
+)
+
+// secretsEntry is one decrypted file and the file it was decrypted from.
+type secretsEntry struct {
+	keyPath string
+	info    os.FileInfo
+	payload []byte
+}
+
+// Secrets memoizes decrypted secrets files by path. An entry is used only
+// while the file on disk is the one it was decrypted from: the same file,
+// size, and modification time. It is safe for concurrent use.
+type Secrets struct {
+	mu      sync.Mutex
+	entries map[string]*secretsEntry
+}
+
+// NewSecrets creates an empty Secrets memo.
+func NewSecrets() *Secrets {
+	return &Secrets{entries: make(map[string]*secretsEntry)}
+}
+
+// Load returns the decrypted contents of the secrets file at path, calling
+// decrypt only if no entry for the file as it is now exists. Failures are
+// not remembered. The caller owns the returned slice and may clear it. A nil
+// Secrets always calls decrypt.
+func (s *Secrets) Load(path, keyPath string, decrypt func() ([]byte, error)) ([]byte, error) {
+	if s == nil {
+		return decrypt()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, statErr := os.Stat(path)
+	if e, ok := s.entries[path]; ok {
+		if statErr == nil && e.keyPath == keyPath && sameFile(e.info, info) {
+			return append([]byte(nil), e.payload...), nil
+		}
+		s.drop(path)
+	}
+
+	payload, err := decrypt()
+	if err != nil {
+		return nil, err
+	}
+	if statErr == nil {
+		s.entries[path] = &secretsEntry{
+			keyPath: keyPath,
+			info:    info,
+			payload: append([]byte(nil), payload...),
+		}
+	}
+
+	return payload, nil
+}
+
+// Invalidate forgets the entry for path, so the next Load decrypts it again.
+func (s *Secrets) Invalidate(path string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.drop(path)
+}
+
+// Clear forgets every entry and wipes the plaintext it held.
+func (s *Secrets) Clear() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for path := range s.entries {
+		s.drop(path)
+	}
+}
+
+func (s *Secrets) drop(path string) {
+	if e, ok := s.entries[path]; ok {
+		crypto.ClearMemory(e.payload)
+		delete(s.entries, path)
+	}
+}
+
+// sameFile reports whether b is still the file a was, unchanged. Secrets are
+// replaced by renaming a new file over the old one, which os.SameFile sees;
+// size and time catch files rewritten in place.
+func sameFile(a, b os.FileInfo) bool {
+	return os.SameFile(a, b) && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
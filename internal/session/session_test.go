@@ -0,0 +1,160 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/crypto"
+)
+
+// counter returns a decrypt func that yields payload and counts its calls.
+func counter(payload string, calls *int) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		*calls++
+
+		return []byte(payload), nil
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSecretsLoad_DecryptsOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.age")
+	writeFile(t, path, "ciphertext")
+
+	s := NewSecrets()
+	calls := 0
+	for range 3 {
+		got, err := s.Load(path, "age.key", counter("KEY=value", &calls))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "KEY=value" {
+			t.Errorf("Load() = %q, want %q", got, "KEY=value")
+		}
+		// Callers clear what they are given; the memo must keep its own copy.
+		clear(got)
+	}
+	if calls != 1 {
+		t.Errorf("decrypt called %d times, want 1", calls)
+	}
+}
+
+func TestSecretsLoad_FileReplaced(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets.age")
+	writeFile(t, path, "ciphertext")
+
+	s := NewSecrets()
+	calls := 0
+	if _, err := s.Load(path, "age.key", counter("OLD=1", &calls)); err != nil {
+		t.Fatal(err)
+	}
+
+	staged := filepath.Join(dir, "secrets.age.new")
+	writeFile(t, staged, "ciphertext")
+	if err := os.Rename(staged, path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Load(path, "age.key", counter("NEW=1", &calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "NEW=1" || calls != 2 {
+		t.Errorf("Load() after replace = %q with %d decrypts, want %q with 2", got, calls, "NEW=1")
+	}
+
+	if _, err := s.Load(path, "other.key", counter("NEW=1", &calls)); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("decrypt called %d times after the key path changed, want 3", calls)
+	}
+}
+
+func TestSecretsInvalidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.age")
+	writeFile(t, path, "ciphertext")
+
+	s := NewSecrets()
+	calls := 0
+	load := func() {
+		t.Helper()
+		if _, err := s.Load(path, "age.key", counter("KEY=value", &calls)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load()
+	s.Invalidate(path)
+	load()
+	s.Clear()
+	load()
+	if calls != 3 {
+		t.Errorf("decrypt called %d times, want 3", calls)
+	}
+}
+
+func TestSecretsLoad_ErrorNotRemembered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.age")
+	writeFile(t, path, "ciphertext")
+
+	s := NewSecrets()
+	wantErr := errors.New("wrong key")
+	if _, err := s.Load(path, "age.key", func() ([]byte, error) { return nil, wantErr }); !errors.Is(err, wantErr) {
+		t.Fatalf("Load() error = %v, want %v", err, wantErr)
+	}
+	calls := 0
+	if _, err := s.Load(path, "age.key", counter("KEY=value", &calls)); err != nil || calls != 1 {
+		t.Errorf("Load() after a failure = %v with %d decrypts, want a fresh decrypt", err, calls)
+	}
+}
+
+func TestSecretsLoad_Nil(t *testing.T) {
+	var s *Secrets
+	calls := 0
+	for range 2 {
+		if _, err := s.Load("secrets.age", "age.key", counter("KEY=value", &calls)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("nil Secrets decrypted %d times, want 2", calls)
+	}
+	s.Invalidate("secrets.age")
+	s.Clear()
+}
+
+// BenchmarkSecretsLoad is the cost of a repeated read of the secrets file in
+// one run; compare it with crypto's BenchmarkDecryptSecrets.
+func BenchmarkSecretsLoad(b *testing.B) {
+	tmpDir := b.TempDir()
+	keyPath := filepath.Join(tmpDir, "age.key")
+	if err := crypto.GenerateKey(context.Background(), keyPath); err != nil {
+		b.Fatal(err)
+	}
+	secretsPath := filepath.Join(tmpDir, "secrets.age")
+	secrets := "ZAI_API_KEY=sk-test-key-1234567890\nMINIMAX_API_KEY=sk-another-key-0987654321\n"
+	if err := crypto.EncryptSecrets(context.Background(), secretsPath, keyPath, secrets); err != nil {
+		b.Fatal(err)
+	}
+
+	s := NewSecrets()
+	decrypt := func() ([]byte, error) {
+		return crypto.DecryptSecretsBytes(context.Background(), secretsPath, keyPath)
+	}
+	for b.Loop() {
+		payload, err := s.Load(secretsPath, keyPath, decrypt)
+		if err != nil {
+			b.Fatal(err)
+		}
+		crypto.ClearMemory(payload)
+	}
+}
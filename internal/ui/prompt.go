@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	kairoerrors "github.com/dkmnx/kairo/internal/errors"
@@ -43,14 +44,43 @@ func ClearScreen() {
 	_ = cmd.Run()
 }
 
-// PrintSuccess prints a green success message to stdout.
+// Status messages go to stderr so that stdout only carries a command's
+// actual output and can be piped or captured by scripts.
+
+// quiet suppresses success and informational messages. Warnings and errors
+// are always printed.
+var (
+	quiet   bool
+	quietMu sync.RWMutex
+)
+
+// SetQuiet enables or disables quiet mode (--quiet).
+func SetQuiet(enabled bool) {
+	quietMu.Lock()
+	defer quietMu.Unlock()
+
+	quiet = enabled
+}
+
+// Quiet reports whether quiet mode is enabled.
+func Quiet() bool {
+	quietMu.RLock()
+	defer quietMu.RUnlock()
+
+	return quiet
+}
+
+// PrintSuccess prints a green success message to stderr.
 func PrintSuccess(msg string) {
-	fmt.Printf("%s✓%s %s%s\n", Green, Reset, msg, Reset)
+	if Quiet() {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s✓%s %s%s\n", Green, Reset, msg, Reset)
 }
 
-// PrintWarn prints a yellow warning message to stdout.
+// PrintWarn prints a yellow warning message to stderr.
 func PrintWarn(msg string) {
-	fmt.Printf("%s⚠%s %s%s\n", Yellow, Reset, msg, Reset)
+	fmt.Fprintf(os.Stderr, "%s⚠%s %s%s\n", Yellow, Reset, msg, Reset)
 }
 
 // PrintWarnings prints each warning string as a yellow warning message.
@@ -65,16 +95,42 @@ func PrintError(msg string) {
 	fmt.Fprintf(os.Stderr, "%s✗%s %s%s\n", Red, Reset, msg, Reset)
 }
 
-// PrintInfo prints a blue informational message to stdout.
+// PrintSuggestion prints the suggested next step after an error to stderr.
+// Like errors, it is shown in quiet mode.
+func PrintSuggestion(msg string) {
+	fmt.Fprintf(os.Stderr, "%s  → %s%s\n", Blue, msg, Reset)
+}
+
+// PrintInfo prints a blue informational message to stderr.
 func PrintInfo(msg string) {
-	fmt.Printf("%s%s\n", Blue, msg)
+	if Quiet() {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s%s\n", Blue, msg)
 }
 
-// PrintWhite prints a white message to stdout.
+// PrintWhite prints a white line of command output to stdout.
 func PrintWhite(msg string) {
 	fmt.Printf("%s%s%s\n", White, msg, Reset)
 }
 
+// PrintGray prints a gray line of command output to stdout, for entries
+// that are kept but not in use.
+func PrintGray(msg string) {
+	fmt.Printf("%s%s%s\n", Gray, msg, Reset)
+}
+
+// PrintValue prints a command's result to w: "label: value" normally, or
+// just value in quiet mode so that scripts can capture it.
+func PrintValue(w io.Writer, label, value string) {
+	if Quiet() {
+		fmt.Fprintln(w, value)
+
+		return
+	}
+	fmt.Fprintf(w, "%s: %s\n", label, value)
+}
+
 func isInterrupted(err error) bool {
 	if err == nil {
 		return false
@@ -99,8 +155,13 @@ type Banner struct {
 	Harness      string
 }
 
-// PrintBanner displays the kairo startup banner with version and provider info.
+// PrintBanner displays the kairo startup banner with version and provider
+// info on stderr, keeping the harness's stdout clean. Quiet mode skips it.
 func PrintBanner(b Banner) {
+	if Quiet() {
+		return
+	}
+
 	info := ""
 	if b.Harness == "pi" {
 		banner := `
@@ -112,12 +173,12 @@ func PrintBanner(b Banner) {
      \/     \/`
 
 		info = fmt.Sprintf("\n\n%s\n", b.Version)
-		fmt.Printf("%s%s%s", Gray, banner, Reset)
+		fmt.Fprintf(os.Stderr, "%s%s%s", Gray, banner, Reset)
 	} else {
 		info = fmt.Sprintf("%s · %s · %s\n\n", b.Version, b.ModelName, b.ProviderName)
 	}
 
-	fmt.Printf("%s%s%s", Gray, info, Reset)
+	fmt.Fprintf(os.Stderr, "%s%s%s", Gray, info, Reset)
 }
 
 // Confirm prompts the user for a y/N confirmation reading from stdin.
@@ -127,7 +188,7 @@ func Confirm(prompt string) (bool, error) {
 
 // ConfirmReader prompts the user for a y/N confirmation reading from r.
 func ConfirmReader(prompt string, r io.Reader) (bool, error) {
-	fmt.Printf("%s [y/N]: ", prompt)
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
 	var input string
 	_, err := fmt.Fscanln(r, &input)
 	if err != nil {
@@ -15,15 +15,15 @@ import (
 
 func TestPrintSuccess(t *testing.T) {
 	buf := new(bytes.Buffer)
-	originalStdout := os.Stdout
+	originalStderr := os.Stderr
 	r, w, _ := os.Pipe()
-	os.Stdout = w
+	os.Stderr = w
 
 	PrintSuccess("test message")
 
 	w.Close()
 	_, _ = buf.ReadFrom(r)
-	os.Stdout = originalStdout
+	os.Stderr = originalStderr
 
 	if !bytes.Contains(buf.Bytes(), []byte("✓")) {
 		t.Error("PrintSuccess should contain checkmark")
@@ -35,15 +35,15 @@ func TestPrintSuccess(t *testing.T) {
 
 func TestPrintWarn(t *testing.T) {
 	buf := new(bytes.Buffer)
-	originalStdout := os.Stdout
+	originalStderr := os.Stderr
 	r, w, _ := os.Pipe()
-	os.Stdout = w
+	os.Stderr = w
 
 	PrintWarn("test warning")
 
 	w.Close()
 	_, _ = buf.ReadFrom(r)
-	os.Stdout = originalStdout
+	os.Stderr = originalStderr
 
 	if !bytes.Contains(buf.Bytes(), []byte("⚠")) {
 		t.Error("PrintWarn should contain warning symbol")
@@ -69,15 +69,15 @@ func TestPrintError(t *testing.T) {
 
 func TestPrintInfo(t *testing.T) {
 	buf := new(bytes.Buffer)
-	originalStdout := os.Stdout
+	originalStderr := os.Stderr
 	r, w, _ := os.Pipe()
-	os.Stdout = w
+	os.Stderr = w
 
 	PrintInfo("info message")
 
 	w.Close()
 	_, _ = buf.ReadFrom(r)
-	os.Stdout = originalStdout
+	os.Stderr = originalStderr
 
 	if !bytes.Contains(buf.Bytes(), []byte("info message")) {
 		t.Error("PrintInfo should contain message")
@@ -124,9 +124,9 @@ func TestColorReset(t *testing.T) {
 func TestPrintBanner(t *testing.T) {
 	t.Run("prints banner with version model and provider", func(t *testing.T) {
 		buf := new(bytes.Buffer)
-		originalStdout := os.Stdout
+		originalStderr := os.Stderr
 		r, w, _ := os.Pipe()
-		os.Stdout = w
+		os.Stderr = w
 
 		PrintBanner(Banner{
 			Version:      "1.0.0-dev",
@@ -137,7 +137,7 @@ func TestPrintBanner(t *testing.T) {
 
 		w.Close()
 		_, _ = buf.ReadFrom(r)
-		os.Stdout = originalStdout
+		os.Stderr = originalStderr
 
 		output := buf.String()
 
@@ -160,9 +160,9 @@ func TestPrintBanner(t *testing.T) {
 
 	t.Run("contains ASCII art banner for pi harness", func(t *testing.T) {
 		buf := new(bytes.Buffer)
-		originalStdout := os.Stdout
+		originalStderr := os.Stderr
 		r, w, _ := os.Pipe()
-		os.Stdout = w
+		os.Stderr = w
 
 		PrintBanner(Banner{
 			Version:      "1.0.0-dev",
@@ -173,7 +173,7 @@ func TestPrintBanner(t *testing.T) {
 
 		w.Close()
 		_, _ = buf.ReadFrom(r)
-		os.Stdout = originalStdout
+		os.Stderr = originalStderr
 
 		output := buf.String()
 
@@ -184,9 +184,9 @@ func TestPrintBanner(t *testing.T) {
 
 	t.Run("does not show ASCII art for non-pi harness", func(t *testing.T) {
 		buf := new(bytes.Buffer)
-		originalStdout := os.Stdout
+		originalStderr := os.Stderr
 		r, w, _ := os.Pipe()
-		os.Stdout = w
+		os.Stderr = w
 
 		PrintBanner(Banner{
 			Version:      "1.0.0-dev",
@@ -197,7 +197,7 @@ func TestPrintBanner(t *testing.T) {
 
 		w.Close()
 		_, _ = buf.ReadFrom(r)
-		os.Stdout = originalStdout
+		os.Stderr = originalStderr
 
 		output := buf.String()
 
@@ -208,9 +208,9 @@ func TestPrintBanner(t *testing.T) {
 
 	t.Run("handles custom provider and model", func(t *testing.T) {
 		buf := new(bytes.Buffer)
-		originalStdout := os.Stdout
+		originalStderr := os.Stderr
 		r, w, _ := os.Pipe()
-		os.Stdout = w
+		os.Stderr = w
 
 		PrintBanner(Banner{
 			Version:      "2.0.0",
@@ -221,7 +221,7 @@ func TestPrintBanner(t *testing.T) {
 
 		w.Close()
 		_, _ = buf.ReadFrom(r)
-		os.Stdout = originalStdout
+		os.Stderr = originalStderr
 
 		output := buf.String()
 
@@ -273,9 +273,9 @@ func TestConfirm(t *testing.T) {
 		defer func() { os.Stdin = originalStdin }()
 
 		buf := new(bytes.Buffer)
-		originalStdout := os.Stdout
+		originalStderr := os.Stderr
 		r, w, _ := os.Pipe()
-		os.Stdout = w
+		os.Stderr = w
 
 		result, err := Confirm("Are you sure?")
 		if err != nil {
@@ -284,7 +284,7 @@ func TestConfirm(t *testing.T) {
 
 		w.Close()
 		_, _ = buf.ReadFrom(r)
-		os.Stdout = originalStdout
+		os.Stderr = originalStderr
 
 		if !result {
 			t.Error("Confirm() should return true for 'yes' input")
@@ -560,3 +560,44 @@ func TestIsEmptyInput(t *testing.T) {
 		})
 	}
 }
+
+func TestQuiet(t *testing.T) {
+	SetQuiet(true)
+	defer SetQuiet(false)
+
+	originalStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	PrintSuccess("done")
+	PrintInfo("note")
+	PrintWarn("careful")
+
+	w.Close()
+	buf := new(bytes.Buffer)
+	_, _ = buf.ReadFrom(r)
+	os.Stderr = originalStderr
+
+	if strings.Contains(buf.String(), "done") || strings.Contains(buf.String(), "note") {
+		t.Errorf("quiet mode printed status messages: %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "careful") {
+		t.Error("quiet mode should still print warnings")
+	}
+}
+
+func TestPrintValue(t *testing.T) {
+	var buf bytes.Buffer
+	PrintValue(&buf, "Default provider", "zai")
+	if buf.String() != "Default provider: zai\n" {
+		t.Errorf("PrintValue() = %q", buf.String())
+	}
+
+	SetQuiet(true)
+	defer SetQuiet(false)
+	buf.Reset()
+	PrintValue(&buf, "Default provider", "zai")
+	if buf.String() != "zai\n" {
+		t.Errorf("PrintValue() in quiet mode = %q, want the bare value", buf.String())
+	}
+}
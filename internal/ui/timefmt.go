@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeLayout is the absolute timestamp layout used in command output. It is
+// numeric so it reads the same in every locale, and includes the zone name so
+// local and UTC output cannot be confused.
+const TimeLayout = "2006-01-02 15:04:05 MST"
+
+// FormatTime renders t in the local time zone, or in UTC when utc is set.
+func FormatTime(t time.Time, utc bool) string {
+	if utc {
+		return t.UTC().Format(TimeLayout)
+	}
+
+	return t.Local().Format(TimeLayout)
+}
+
+// RelativeTime describes t relative to now in the largest whole unit, such
+// as "just now", "5m ago", "2h ago", "3d ago", or "in 10m" for future times.
+func RelativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var s string
+	switch {
+	case d < 10*time.Second:
+		return "just now"
+	case d < time.Minute:
+		s = fmt.Sprintf("%ds", int(d/time.Second))
+	case d < time.Hour:
+		s = fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		s = fmt.Sprintf("%dh", int(d/time.Hour))
+	default:
+		s = fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+
+	if future {
+		return "in " + s
+	}
+
+	return s + " ago"
+}
+
+// FormatTimeWithAge renders t like FormatTime followed by its age in
+// parentheses, e.g. "2026-03-01 14:05:00 CET (2h ago)".
+func FormatTimeWithAge(t, now time.Time, utc bool) string {
+	return fmt.Sprintf("%s (%s)", FormatTime(t, utc), RelativeTime(t, now))
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{3 * time.Second, "just now"},
+		{45 * time.Second, "45s ago"},
+		{5*time.Minute + 30*time.Second, "5m ago"},
+		{2*time.Hour + 59*time.Minute, "2h ago"},
+		{72 * time.Hour, "3d ago"},
+		{-10 * time.Minute, "in 10m"},
+	}
+
+	for _, tt := range tests {
+		if got := RelativeTime(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("RelativeTime(-%s) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	ts := time.Date(2026, 3, 1, 12, 0, 5, 0, time.FixedZone("X", 3600))
+
+	if got := FormatTime(ts, true); got != "2026-03-01 11:00:05 UTC" {
+		t.Errorf("FormatTime(utc) = %q", got)
+	}
+	if got := FormatTime(ts, false); got != ts.Local().Format(TimeLayout) {
+		t.Errorf("FormatTime(local) = %q", got)
+	}
+
+	got := FormatTimeWithAge(ts, ts.Add(2*time.Hour), true)
+	if !strings.HasSuffix(got, "UTC (2h ago)") {
+		t.Errorf("FormatTimeWithAge() = %q", got)
+	}
+}
@@ -0,0 +1,143 @@
+package update
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Minisign signature algorithms: "Ed" signs the file itself, "ED" signs its
+// BLAKE2b-512 hash (the default since minisign 0.8).
+const (
+	minisignAlgLegacy    = "Ed"
+	minisignAlgPrehashed = "ED"
+)
+
+// MinisignPublicKey is a minisign Ed25519 public key.
+type MinisignPublicKey struct {
+	KeyID [8]byte
+	Key   ed25519.PublicKey
+}
+
+// ParseMinisignPublicKey parses a minisign public key, either the bare base64
+// line or the contents of a .pub file with its untrusted comment.
+func ParseMinisignPublicKey(data string) (MinisignPublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(lastNonCommentLine(data))
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != minisignAlgLegacy {
+		return MinisignPublicKey{}, errors.NewError(errors.ValidationError, "invalid minisign public key")
+	}
+
+	var pk MinisignPublicKey
+	copy(pk.KeyID[:], raw[2:10])
+	pk.Key = ed25519.PublicKey(raw[10:])
+
+	return pk, nil
+}
+
+// ID returns the key ID as minisign prints it: 16 upper-case hex digits.
+func (pk MinisignPublicKey) ID() string {
+	var id [8]byte
+	for i, b := range pk.KeyID {
+		id[len(id)-1-i] = b
+	}
+
+	return fmt.Sprintf("%X", id)
+}
+
+// String returns the key in the base64 form of a .pub file's second line.
+func (pk MinisignPublicKey) String() string {
+	raw := append(append([]byte(minisignAlgLegacy), pk.KeyID[:]...), pk.Key...)
+
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// VerifyMinisignWith checks a minisign signature over message with the one
+// of pks whose key ID the signature names, and returns that key.
+func VerifyMinisignWith(pks []MinisignPublicKey, message, sig []byte) (MinisignPublicKey, error) {
+	keyID, err := minisignKeyID(sig)
+	if err != nil {
+		return MinisignPublicKey{}, err
+	}
+	for _, pk := range pks {
+		if pk.KeyID == keyID {
+			return pk, VerifyMinisign(pk, message, sig)
+		}
+	}
+
+	return MinisignPublicKey{}, errors.NewError(errors.VerificationError,
+		fmt.Sprintf("signature was made with key %s, which is not trusted", MinisignPublicKey{KeyID: keyID}.ID()))
+}
+
+// minisignKeyID returns the key ID a minisign signature file names.
+func minisignKeyID(sig []byte) ([8]byte, error) {
+	var keyID [8]byte
+	lines := strings.Split(strings.ReplaceAll(string(sig), "\r\n", "\n"), "\n")
+	if len(lines) < 2 {
+		return keyID, errors.NewError(errors.VerificationError, "malformed minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return keyID, errors.NewError(errors.VerificationError, "malformed minisign signature")
+	}
+	copy(keyID[:], raw[2:10])
+
+	return keyID, nil
+}
+
+// VerifyMinisign checks a minisign signature file over message, including
+// the global signature that protects its trusted comment.
+func VerifyMinisign(pk MinisignPublicKey, message, sig []byte) error {
+	lines := strings.Split(strings.ReplaceAll(string(sig), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "untrusted comment:") ||
+		!strings.HasPrefix(lines[2], "trusted comment:") {
+		return errors.NewError(errors.VerificationError, "malformed minisign signature").
+			WithContext("hint", "cosign bundles cannot be checked offline; use 'cosign verify-blob'")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return errors.NewError(errors.VerificationError, "malformed minisign signature")
+	}
+	alg, keyID, signature := string(raw[:2]), raw[2:10], raw[10:]
+	if !bytes.Equal(keyID, pk.KeyID[:]) {
+		return errors.NewError(errors.VerificationError, "signature was made with a different key")
+	}
+
+	switch alg {
+	case minisignAlgPrehashed:
+		sum := blake2b.Sum512(message)
+		message = sum[:]
+	case minisignAlgLegacy:
+	default:
+		return errors.NewError(errors.VerificationError, "unsupported minisign signature algorithm "+alg)
+	}
+	if !ed25519.Verify(pk.Key, message, signature) {
+		return errors.NewError(errors.VerificationError, "signature verification failed")
+	}
+
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize ||
+		!ed25519.Verify(pk.Key, append(bytes.Clone(signature), trusted...), globalSig) {
+		return errors.NewError(errors.VerificationError, "trusted comment signature verification failed")
+	}
+
+	return nil
+}
+
+func lastNonCommentLine(data string) string {
+	var last string
+	for line := range strings.Lines(data) {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "untrusted comment:") {
+			last = line
+		}
+	}
+
+	return last
+}
@@ -3,8 +3,6 @@
 package update
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
@@ -213,22 +211,7 @@ func (c *Client) DownloadAndParseChecksums(ctx context.Context, url string) (map
 			"failed to download checksums", err)
 	}
 
-	checksums := make(map[string]string)
-	scanner := bufio.NewScanner(bytes.NewReader(body))
-	for scanner.Scan() {
-		line := scanner.Text()
-		hash, filename, ok := ParseChecksumLine(line)
-		if ok {
-			checksums[filename] = hash
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, errors.WrapError(errors.NetworkError,
-			"failed to parse checksums file", err)
-	}
-
-	return checksums, nil
+	return ParseChecksums(body), nil
 }
 
 // VerifyChecksum verifies that the file at scriptPath matches the expected SHA256 hash.
@@ -0,0 +1,89 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// ParseChecksums parses sha256sum-style lines into a filename-to-hash map.
+func ParseChecksums(data []byte) map[string]string {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if hash, filename, ok := ParseChecksumLine(scanner.Text()); ok {
+			checksums[filename] = hash
+		}
+	}
+
+	return checksums
+}
+
+// LookupChecksum finds the expected hash for name, matching either the exact
+// entry or one whose base name is name. sha256sum's binary-mode "*" marker
+// is ignored.
+func LookupChecksum(checksums map[string]string, name string) (string, bool) {
+	var found string
+	for entry, hash := range checksums {
+		entry = strings.TrimPrefix(entry, "*")
+		if entry == name {
+			return hash, true
+		}
+		if path.Base(strings.ReplaceAll(entry, `\`, "/")) == name {
+			if found != "" && found != hash {
+				return "", false
+			}
+			found = hash
+		}
+	}
+
+	return found, found != ""
+}
+
+// FileSHA256 returns the hex SHA-256 of the file at path.
+func FileSHA256(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.FileError("failed to open file for hashing", filePath, err)
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", errors.FileError("failed to compute file hash", filePath, err)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// VerifyReleaseFile checks the file at filePath against the entry for name in
+// a checksums file. It returns the matching hash.
+func VerifyReleaseFile(filePath, name string, checksumsData []byte) (string, error) {
+	expected, ok := LookupChecksum(ParseChecksums(checksumsData), name)
+	if !ok {
+		return "", errors.NewError(errors.VerificationError,
+			fmt.Sprintf("no checksum for %s in checksums file", name))
+	}
+
+	actual, err := FileSHA256(filePath)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(actual, expected) {
+		return "", errors.VerificationErr(
+			fmt.Sprintf("checksum mismatch for %s (expected: %.8s..., got: %.8s...)", name, expected, actual),
+			nil,
+		).WithContext("expected", expected).
+			WithContext("actual", actual)
+	}
+
+	return actual, nil
+}
@@ -0,0 +1,127 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// minisignSign produces a minisign public key and signature file for message
+// in the given algorithm.
+func minisignSign(t *testing.T, alg string, message []byte) (string, []byte) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	signed := message
+	if alg == minisignAlgPrehashed {
+		sum := blake2b.Sum512(message)
+		signed = sum[:]
+	}
+	sig := ed25519.Sign(priv, signed)
+	trusted := "timestamp:1700000000\tfile:checksums.txt"
+	global := ed25519.Sign(priv, append(append([]byte{}, sig...), trusted...))
+
+	pubKey := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+	sigFile := "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte(alg), keyID...), sig...)) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+
+	return "untrusted comment: minisign public key\n" + pubKey + "\n", []byte(sigFile)
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	message := []byte("abc  kairo_linux_amd64.tar.gz\n")
+
+	for _, alg := range []string{minisignAlgPrehashed, minisignAlgLegacy} {
+		t.Run(alg, func(t *testing.T) {
+			pubFile, sig := minisignSign(t, alg, message)
+			pk, err := ParseMinisignPublicKey(pubFile)
+			if err != nil {
+				t.Fatalf("ParseMinisignPublicKey() error = %v", err)
+			}
+
+			if err := VerifyMinisign(pk, message, sig); err != nil {
+				t.Errorf("VerifyMinisign() error = %v", err)
+			}
+			if err := VerifyMinisign(pk, []byte("tampered"), sig); err == nil {
+				t.Error("VerifyMinisign() expected error for a tampered message")
+			}
+		})
+	}
+
+	pubFile, _ := minisignSign(t, minisignAlgPrehashed, message)
+	_, otherSig := minisignSign(t, minisignAlgPrehashed, message)
+	pk, _ := ParseMinisignPublicKey(pubFile)
+	if err := VerifyMinisign(pk, message, otherSig); err == nil {
+		t.Error("VerifyMinisign() expected error for a signature from another key")
+	}
+	if err := VerifyMinisign(pk, message, []byte(`{"mediaType": "application/vnd.dev.sigstore.bundle+json"}`)); err == nil {
+		t.Error("VerifyMinisign() expected error for a cosign bundle")
+	}
+}
+
+func TestVerifyMinisignWith(t *testing.T) {
+	message := []byte("providers:\n  - provider: acme\n")
+	pubFile, sig := minisignSign(t, minisignAlgPrehashed, message)
+	pk, err := ParseMinisignPublicKey(pubFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk.ID() != "0807060504030201" {
+		t.Errorf("ID() = %s, want the key ID as minisign prints it", pk.ID())
+	}
+	if again, err := ParseMinisignPublicKey(pk.String()); err != nil || again.ID() != pk.ID() || !again.Key.Equal(pk.Key) {
+		t.Errorf("ParseMinisignPublicKey(String()) = %v, %v", again, err)
+	}
+
+	other := MinisignPublicKey{KeyID: [8]byte{9, 9, 9, 9, 9, 9, 9, 9}, Key: pk.Key}
+	signer, err := VerifyMinisignWith([]MinisignPublicKey{other, pk}, message, sig)
+	if err != nil || signer.ID() != pk.ID() {
+		t.Errorf("VerifyMinisignWith() = %s, %v, want the matching key", signer.ID(), err)
+	}
+	if _, err := VerifyMinisignWith([]MinisignPublicKey{pk}, []byte("tampered"), sig); err == nil {
+		t.Error("VerifyMinisignWith() expected error for a tampered message")
+	}
+	_, err = VerifyMinisignWith([]MinisignPublicKey{other}, message, sig)
+	if err == nil || !strings.Contains(err.Error(), "0807060504030201, which is not trusted") {
+		t.Errorf("VerifyMinisignWith() error = %v, want the untrusted key named", err)
+	}
+}
+
+func TestVerifyReleaseFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "kairo_windows_amd64.zip")
+	content := []byte("release archive")
+	if err := os.WriteFile(file, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	checksums := []byte(hash + " *dist/kairo_windows_amd64.zip\n" +
+		"0000000000000000000000000000000000000000000000000000000000000000  kairo_linux_amd64.tar.gz\n")
+
+	got, err := VerifyReleaseFile(file, "kairo_windows_amd64.zip", checksums)
+	if err != nil || got != hash {
+		t.Errorf("VerifyReleaseFile() = %q, %v; want %q", got, err, hash)
+	}
+	if _, err := VerifyReleaseFile(file, "kairo_linux_amd64.tar.gz", checksums); err == nil {
+		t.Error("VerifyReleaseFile() expected checksum mismatch")
+	}
+	if _, err := VerifyReleaseFile(file, "missing.zip", checksums); err == nil {
+		t.Error("VerifyReleaseFile() expected error for a name without a checksum")
+	}
+}
@@ -0,0 +1,244 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// TokenMetric is the counter Claude Code exports for token usage. Its type
+// attribute is input, output, cacheRead, or cacheCreation.
+const TokenMetric = "claude_code.token.usage"
+
+// maxExportSize bounds one OTLP export request.
+const maxExportSize = 4 * 1024 * 1024
+
+// ClaudeTelemetryEnv returns the variables that make Claude Code export its
+// metrics as OTLP/HTTP JSON to endpoint, with delta temporality so that each
+// export carries only the tokens used since the last one, and often enough
+// that little is lost if the session is killed.
+func ClaudeTelemetryEnv(endpoint string) []string {
+	return []string{
+		"CLAUDE_CODE_ENABLE_TELEMETRY=1",
+		"OTEL_METRICS_EXPORTER=otlp",
+		"OTEL_EXPORTER_OTLP_METRICS_PROTOCOL=http/json",
+		"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT=" + endpoint,
+		"OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE=delta",
+		"OTEL_METRIC_EXPORT_INTERVAL=10000",
+	}
+}
+
+// Collector is an OTLP/HTTP metrics receiver that totals the tokens reported
+// by TokenMetric. It accepts the JSON encoding only.
+type Collector struct {
+	mu sync.Mutex
+	// delta sums delta data points per series; cumulative keeps the latest
+	// value of each cumulative series, which already includes earlier ones.
+	delta      map[string]int64
+	cumulative map[string]int64
+	types      map[string]string
+}
+
+// NewCollector returns an empty Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		delta:      make(map[string]int64),
+		cumulative: make(map[string]int64),
+		types:      make(map[string]string),
+	}
+}
+
+// OTLP JSON encoding of ExportMetricsServiceRequest, reduced to the fields
+// the collector reads.
+type exportRequest struct {
+	ResourceMetrics []struct {
+		ScopeMetrics []struct {
+			Metrics []struct {
+				Name string `json:"name"`
+				Sum  *struct {
+					DataPoints             []dataPoint     `json:"dataPoints"`
+					AggregationTemporality json.RawMessage `json:"aggregationTemporality"`
+				} `json:"sum"`
+			} `json:"metrics"`
+		} `json:"scopeMetrics"`
+	} `json:"resourceMetrics"`
+}
+
+type dataPoint struct {
+	Attributes []struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+		} `json:"value"`
+	} `json:"attributes"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	AsInt             json.RawMessage `json:"asInt"`
+	AsDouble          *float64        `json:"asDouble"`
+}
+
+// ServeHTTP accepts POST /v1/metrics.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/metrics" {
+		http.NotFound(w, r)
+
+		return
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "application/json") {
+		http.Error(w, "only OTLP/HTTP JSON is supported", http.StatusUnsupportedMediaType)
+
+		return
+	}
+
+	var req exportRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxExportSize)).Decode(&req); err != nil {
+		http.Error(w, "invalid OTLP JSON", http.StatusBadRequest)
+
+		return
+	}
+	c.add(req)
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte("{}"))
+}
+
+func (c *Collector) add(req exportRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, rm := range req.ResourceMetrics {
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name != TokenMetric || m.Sum == nil {
+					continue
+				}
+				cumulative := isCumulative(m.Sum.AggregationTemporality)
+				for _, dp := range m.Sum.DataPoints {
+					key, tokenType := seriesKey(dp)
+					c.types[key] = tokenType
+					if cumulative {
+						c.cumulative[key] = dp.value()
+					} else {
+						c.delta[key] += dp.value()
+					}
+				}
+			}
+		}
+	}
+}
+
+// Tokens returns the tokens reported so far.
+func (c *Collector) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var t Tokens
+	for _, series := range []map[string]int64{c.delta, c.cumulative} {
+		for key, n := range series {
+			switch c.types[key] {
+			case "input":
+				t.Input += n
+			case "output":
+				t.Output += n
+			case "cacheRead":
+				t.CacheRead += n
+			case "cacheCreation":
+				t.CacheCreation += n
+			}
+		}
+	}
+
+	return t
+}
+
+// isCumulative reports whether an aggregationTemporality, encoded as the
+// enum number or name, is cumulative.
+func isCumulative(raw json.RawMessage) bool {
+	s := strings.Trim(string(raw), `"`)
+
+	return s == "2" || s == "AGGREGATION_TEMPORALITY_CUMULATIVE"
+}
+
+// seriesKey identifies the series of dp by its attributes and start time,
+// and returns its token type.
+func seriesKey(dp dataPoint) (string, string) {
+	attrs := make([]string, 0, len(dp.Attributes))
+	tokenType := ""
+	for _, a := range dp.Attributes {
+		attrs = append(attrs, a.Key+"="+a.Value.StringValue)
+		if a.Key == "type" {
+			tokenType = a.Value.StringValue
+		}
+	}
+	slices.Sort(attrs)
+
+	return strings.Join(attrs, ",") + "@" + dp.StartTimeUnixNano, tokenType
+}
+
+// value returns the data point's value; asInt is an int64, which the OTLP
+// JSON encoding writes as a string.
+func (dp dataPoint) value() int64 {
+	if len(dp.AsInt) > 0 {
+		n, _ := strconv.ParseInt(strings.Trim(string(dp.AsInt), `"`), 10, 64)
+
+		return n
+	}
+	if dp.AsDouble != nil {
+		return int64(*dp.AsDouble)
+	}
+
+	return 0
+}
+
+// Server runs a Collector on a loopback port for the length of a session.
+type Server struct {
+	collector *Collector
+	srv       *http.Server
+	endpoint  string
+	done      chan struct{}
+}
+
+// Listen starts a Collector on a free loopback port.
+func Listen() (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.WrapError(errors.NetworkError, "failed to listen for harness telemetry", err)
+	}
+
+	s := &Server{
+		collector: NewCollector(),
+		endpoint:  "http://" + l.Addr().String() + "/v1/metrics",
+		done:      make(chan struct{}),
+	}
+	s.srv = &http.Server{Handler: s.collector, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		defer close(s.done)
+		_ = s.srv.Serve(l)
+	}()
+
+	return s, nil
+}
+
+// Endpoint is the OTLP metrics URL to export to.
+func (s *Server) Endpoint() string {
+	return s.endpoint
+}
+
+// Stop waits briefly for exports in flight, shuts the server down, and
+// returns the tokens reported.
+func (s *Server) Stop() Tokens {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.srv.Shutdown(ctx)
+	<-s.done
+
+	return s.collector.Tokens()
+}
@@ -0,0 +1,79 @@
+package usage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// tokenExport is an OTLP/HTTP JSON metrics export of one token.usage data
+// point per type given.
+func tokenExport(temporality string, points map[string]string) string {
+	var dps []string
+	for typ, value := range points {
+		dps = append(dps, `{"attributes":[{"key":"session.id","value":{"stringValue":"s1"}},`+
+			`{"key":"type","value":{"stringValue":"`+typ+`"}}],"startTimeUnixNano":"1","asInt":`+value+`}`)
+	}
+
+	return `{"resourceMetrics":[{"scopeMetrics":[{"metrics":[` +
+		`{"name":"claude_code.cost.usage","sum":{"dataPoints":[{"asDouble":0.5}]}},` +
+		`{"name":"` + TokenMetric + `","sum":{"aggregationTemporality":` + temporality +
+		`,"dataPoints":[` + strings.Join(dps, ",") + `]}}]}]}]}`
+}
+
+func TestCollector(t *testing.T) {
+	post := func(c *Collector, contentType, body string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/metrics", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		c.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	delta := NewCollector()
+	for _, body := range []string{
+		tokenExport("1", map[string]string{"input": `"100"`, "output": "20"}),
+		tokenExport(`"AGGREGATION_TEMPORALITY_DELTA"`, map[string]string{"input": `"30"`, "cacheRead": `"7"`}),
+	} {
+		if code := post(delta, "application/json", body); code != http.StatusOK {
+			t.Fatalf("export status = %d", code)
+		}
+	}
+	if got := delta.Tokens(); got != (Tokens{Input: 130, Output: 20, CacheRead: 7}) {
+		t.Errorf("delta Tokens() = %+v", got)
+	}
+
+	cumulative := NewCollector()
+	post(cumulative, "application/json", tokenExport("2", map[string]string{"input": `"100"`}))
+	post(cumulative, "application/json", tokenExport("2", map[string]string{"input": `"140"`, "output": `"9"`}))
+	if got := cumulative.Tokens(); got != (Tokens{Input: 140, Output: 9}) {
+		t.Errorf("cumulative Tokens() = %+v, want the latest value of each series", got)
+	}
+
+	if code := post(NewCollector(), "application/x-protobuf", "\x0a"); code != http.StatusUnsupportedMediaType {
+		t.Errorf("protobuf export status = %d, want 415", code)
+	}
+}
+
+func TestListen(t *testing.T) {
+	srv, err := Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(srv.Endpoint(), "http://127.0.0.1:") {
+		t.Errorf("Endpoint() = %s, want a loopback URL", srv.Endpoint())
+	}
+
+	resp, err := http.Post(srv.Endpoint(), "application/json",
+		strings.NewReader(tokenExport("1", map[string]string{"output": `"42"`})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got := srv.Stop(); got.Output != 42 {
+		t.Errorf("Stop() = %+v, want 42 output tokens", got)
+	}
+}
@@ -0,0 +1,153 @@
+// Package usage records the tokens each harness session used, as reported by
+// the harness's OpenTelemetry metrics, and summarizes them per provider.
+package usage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// FileName is the usage log under the state directory, one JSON record per
+// line.
+const FileName = "usage.jsonl"
+
+// Tokens counts the tokens of one session or a sum of sessions.
+type Tokens struct {
+	Input         int64 `json:"input_tokens"`
+	Output        int64 `json:"output_tokens"`
+	CacheRead     int64 `json:"cache_read_tokens,omitempty"`
+	CacheCreation int64 `json:"cache_creation_tokens,omitempty"`
+}
+
+// Add returns the sum of t and o.
+func (t Tokens) Add(o Tokens) Tokens {
+	return Tokens{
+		Input:         t.Input + o.Input,
+		Output:        t.Output + o.Output,
+		CacheRead:     t.CacheRead + o.CacheRead,
+		CacheCreation: t.CacheCreation + o.CacheCreation,
+	}
+}
+
+// Total returns every token counted.
+func (t Tokens) Total() int64 {
+	return t.Input + t.Output + t.CacheRead + t.CacheCreation
+}
+
+// Record is the usage of one harness session.
+type Record struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Provider string    `json:"provider"`
+	Harness  string    `json:"harness"`
+	Model    string    `json:"model,omitempty"`
+	Tokens
+}
+
+// Path returns the usage log under dir.
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Append adds r to the usage log under dir.
+func Append(dir string, r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, constants.DirPermSecure); err != nil {
+		return errors.FileError("failed to create state directory", dir, err)
+	}
+
+	path := Path(dir)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, constants.FilePermSecure)
+	if err != nil {
+		return errors.FileError("failed to open usage log", path, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return errors.FileError("failed to write usage log", path, err)
+	}
+
+	return nil
+}
+
+// Load returns the recorded sessions under dir, oldest first. A missing log
+// yields no records and no error. Lines that fail to parse are skipped.
+func Load(dir string) ([]Record, error) {
+	path := Path(dir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, errors.FileError("failed to read usage log", path, err)
+	}
+
+	var out []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		out = append(out, r)
+	}
+
+	return out, nil
+}
+
+// Summary is the usage of one provider over several sessions.
+type Summary struct {
+	Provider string
+	Sessions int
+	Last     time.Time
+	Tokens
+}
+
+// Summarize totals records per provider, most tokens first.
+func Summarize(records []Record) []Summary {
+	byProvider := make(map[string]*Summary)
+	var order []*Summary
+	for _, r := range records {
+		s, ok := byProvider[r.Provider]
+		if !ok {
+			s = &Summary{Provider: r.Provider}
+			byProvider[r.Provider] = s
+			order = append(order, s)
+		}
+		s.Sessions++
+		s.Tokens = s.Tokens.Add(r.Tokens)
+		if r.End.After(s.Last) {
+			s.Last = r.End
+		}
+	}
+
+	out := make([]Summary, 0, len(order))
+	for _, s := range order {
+		out = append(out, *s)
+	}
+	slices.SortStableFunc(out, func(a, b Summary) int {
+		switch {
+		case a.Total() > b.Total():
+			return -1
+		case a.Total() < b.Total():
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return out
+}
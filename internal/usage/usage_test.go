@@ -0,0 +1,46 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+	if recs, err := Load(dir); err != nil || recs != nil {
+		t.Fatalf("Load() of a missing log = %v, %v", recs, err)
+	}
+
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	for _, r := range []Record{
+		{Start: start, End: start.Add(time.Hour), Provider: "zai", Harness: "claude", Tokens: Tokens{Input: 100, Output: 20}},
+		{Start: start, End: start.Add(2 * time.Hour), Provider: "minimax", Harness: "claude", Tokens: Tokens{Input: 900}},
+		{Start: start, End: start.Add(3 * time.Hour), Provider: "zai", Harness: "claude", Tokens: Tokens{Input: 50, CacheRead: 5}},
+	} {
+		if err := Append(dir, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.OpenFile(Path(dir), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("not json\n")
+	f.Close()
+
+	recs, err := Load(dir)
+	if err != nil || len(recs) != 3 {
+		t.Fatalf("Load() = %d records, %v; want 3 with the bad line skipped", len(recs), err)
+	}
+
+	got := Summarize(recs)
+	if len(got) != 2 || got[0].Provider != "minimax" || got[1].Provider != "zai" {
+		t.Fatalf("Summarize() = %+v, want minimax then zai by tokens", got)
+	}
+	zai := got[1]
+	if zai.Sessions != 2 || zai.Input != 150 || zai.Output != 20 || zai.CacheRead != 5 || !zai.Last.Equal(start.Add(3*time.Hour)) {
+		t.Errorf("zai summary = %+v", zai)
+	}
+}
@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/url"
@@ -49,6 +50,13 @@ func mustParseCIDRs(cidrs []string) []*net.IPNet {
 
 // ValidateURL checks that the given URL is a valid HTTPS URL without blocked hosts.
 func ValidateURL(rawURL, providerName string) error {
+	return ValidateBaseURL(rawURL, providerName, false)
+}
+
+// ValidateBaseURL checks a provider base URL like ValidateURL. With
+// allowInsecure, set by a provider's allow_insecure for an intentional local
+// gateway, plain HTTP and localhost or private hosts are accepted as well.
+func ValidateBaseURL(rawURL, providerName string, allowInsecure bool) error {
 	if rawURL == "" {
 		return errors.NewError(errors.ValidationError,
 			fmt.Sprintf("%s: base URL cannot be empty", providerName))
@@ -60,7 +68,14 @@ func ValidateURL(rawURL, providerName string) error {
 			fmt.Sprintf("%s: base URL is not a valid URL: %v", providerName, err))
 	}
 
-	if parsed.Scheme != "https" {
+	switch {
+	case parsed.Scheme == "https":
+	case parsed.Scheme == "http" && allowInsecure:
+	case parsed.Scheme == "http":
+		return errors.NewError(errors.ValidationError,
+			fmt.Sprintf("%s: base URL must use HTTPS protocol", providerName)).
+			WithContext("hint", "set 'allow_insecure: true' on the provider if this is an intentional local gateway")
+	default:
 		return errors.NewError(errors.ValidationError,
 			fmt.Sprintf("%s: base URL must use HTTPS protocol", providerName))
 	}
@@ -71,14 +86,51 @@ func ValidateURL(rawURL, providerName string) error {
 			fmt.Sprintf("%s: base URL missing host component", providerName))
 	}
 
-	if isBlockedHost(host) {
+	if !allowInsecure && isBlockedHost(host) {
 		return errors.NewError(errors.ValidationError,
-			fmt.Sprintf("%s: base URL cannot use blocked host: %s (localhost/private IPs not allowed)", providerName, host))
+			fmt.Sprintf("%s: base URL cannot use blocked host: %s (localhost/private IPs not allowed)", providerName, host)).
+			WithContext("hint", "set 'allow_insecure: true' on the provider if this is an intentional local gateway")
 	}
 
 	return nil
 }
 
+// Resolver looks up the addresses of a host. *net.Resolver implements it.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// PrivateAddrs resolves the host of a base URL and returns the loopback,
+// private, and link-local addresses among the answers. A public gateway
+// whose name resolves to one of them may be the target of a DNS hijack. A
+// host given as an IP literal is not looked up, since ValidateURL already
+// judges it.
+func PrivateAddrs(ctx context.Context, r Resolver, rawURL string) ([]net.IP, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.WrapError(errors.ValidationError, "base URL is not a valid URL", err)
+	}
+	host := parsed.Hostname()
+	if host == "" || net.ParseIP(host) != nil || slices.Contains(blockedHosts, host) {
+		return nil, nil
+	}
+
+	addrs, err := r.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, errors.WrapError(errors.NetworkError, fmt.Sprintf("failed to resolve %s", host), err).
+			WithContext("host", host)
+	}
+
+	var out []net.IP
+	for _, a := range addrs {
+		if a.IP.IsLoopback() || a.IP.IsUnspecified() || isPrivateIP(a.IP) {
+			out = append(out, a.IP)
+		}
+	}
+
+	return out, nil
+}
+
 func isBlockedHost(host string) bool {
 	if slices.Contains(blockedHosts, host) {
 		return true
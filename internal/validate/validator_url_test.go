@@ -1,6 +1,9 @@
 package validate
 
 import (
+	"context"
+	"errors"
+	"net"
 	"net/url"
 	"strings"
 	"testing"
@@ -64,6 +67,86 @@ func TestValidateURL_EmptyHost(t *testing.T) {
 	}
 }
 
+func TestValidateBaseURL_AllowInsecure(t *testing.T) {
+	tests := []struct {
+		url           string
+		allowInsecure bool
+		wantErr       bool
+	}{
+		{"http://api.example.com", false, true},
+		{"http://api.example.com", true, false},
+		{"http://127.0.0.1:8899", true, false},
+		{"https://192.168.1.20/anthropic", true, false},
+		{"https://192.168.1.20/anthropic", false, true},
+		{"ftp://api.example.com", true, true},
+		{"http:///path", true, true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateBaseURL(tt.url, "TestProvider", tt.allowInsecure)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateBaseURL(%q, %v) error = %v, wantErr %v", tt.url, tt.allowInsecure, err, tt.wantErr)
+		}
+	}
+}
+
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := f[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	addrs := make([]net.IPAddr, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(ip)}
+	}
+
+	return addrs, nil
+}
+
+func TestPrivateAddrs(t *testing.T) {
+	r := fakeResolver{
+		"api.example.com":    {"203.0.113.7"},
+		"hijacked.example":   {"203.0.113.8", "10.0.0.5"},
+		"loopback.example":   {"127.0.0.1"},
+		"linklocal.example":  {"fe80::1"},
+		"gateway.corp.local": {"192.168.4.2"},
+	}
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{"https://api.example.com/anthropic", nil},
+		{"https://hijacked.example", []string{"10.0.0.5"}},
+		{"https://loopback.example", []string{"127.0.0.1"}},
+		{"https://linklocal.example", []string{"fe80::1"}},
+		{"https://gateway.corp.local:8443", []string{"192.168.4.2"}},
+		{"https://10.0.0.1/api", nil},
+		{"https://localhost/api", nil},
+	}
+
+	for _, tt := range tests {
+		got, err := PrivateAddrs(context.Background(), r, tt.url)
+		if err != nil {
+			t.Errorf("PrivateAddrs(%q) error = %v", tt.url, err)
+
+			continue
+		}
+		var gotStr []string
+		for _, ip := range got {
+			gotStr = append(gotStr, ip.String())
+		}
+		if strings.Join(gotStr, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("PrivateAddrs(%q) = %v, want %v", tt.url, gotStr, tt.want)
+		}
+	}
+
+	if _, err := PrivateAddrs(context.Background(), r, "https://unknown.example"); err == nil {
+		t.Error("PrivateAddrs() should fail when the host does not resolve")
+	}
+}
+
 // FuzzValidateURL fuzzes the ValidateURL function with random inputs.
 func FuzzValidateURL(f *testing.F) {
 	// Seed with some initial values
@@ -0,0 +1,126 @@
+// Package warnlimit keeps warnings that would repeat on every command, such
+// as an environment variable competing with kairo's, from being printed
+// more than once per interval. The time each warning was last shown is kept
+// in the state directory, so the limit holds across kairo processes that
+// share a config directory.
+package warnlimit
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsutil"
+)
+
+// StateFileName is the file in the state directory recording when each
+// warning was last shown.
+const StateFileName = "warnings.json"
+
+// DefaultInterval is how long a warning stays quiet after it was shown.
+const DefaultInterval = 24 * time.Hour
+
+// Warning classes. A class names a kind of warning; the subject passed
+// with it to Allow tells its instances apart, so a newly appearing instance
+// is shown even while an older one of the same class is quiet.
+const (
+	// EnvConflict is an environment variable or Claude Code setting that
+	// competes with one kairo sets; the subject is the conflict.
+	EnvConflict = "env-conflict"
+	// PiProviderKey is a key Pi would get for another provider that could
+	// not be loaded; the subject is that provider.
+	PiProviderKey = "pi-provider-key"
+	// UsageExporter is usage capture skipped because an OpenTelemetry
+	// metrics exporter is already configured.
+	UsageExporter = "usage-exporter"
+)
+
+// Limiter decides whether a warning is shown. A nil Limiter shows every
+// warning.
+type Limiter struct {
+	dir      string
+	interval time.Duration
+	now      func() time.Time
+}
+
+// New returns a Limiter keeping its state in dir that shows each warning at
+// most once per interval.
+func New(dir string, interval time.Duration) *Limiter {
+	return &Limiter{dir: dir, interval: interval, now: time.Now}
+}
+
+// Allow reports whether the warning of class about subject should be shown,
+// and if so records that it was. Subject may be empty for a class with a
+// single instance. State that cannot be read or written only means the
+// warning is shown, never that it is lost.
+func (l *Limiter) Allow(class, subject string) bool {
+	if l == nil || l.interval <= 0 {
+		return true
+	}
+
+	id := class
+	if subject != "" {
+		id += "/" + subject
+	}
+	now := l.now().UTC()
+
+	state, err := Load(l.dir)
+	if err != nil {
+		return true
+	}
+	if last, ok := state[id]; ok && now.Sub(last) < l.interval && !last.After(now) {
+		return false
+	}
+
+	state[id] = now
+	// Entries past the interval no longer quiet anything.
+	for k, last := range state {
+		if now.Sub(last) >= l.interval {
+			delete(state, k)
+		}
+	}
+	_ = Save(l.dir, state)
+
+	return true
+}
+
+// Load reads the time each warning was last shown from dir, keyed by class
+// and subject. A missing state file yields an empty map.
+func Load(dir string) (map[string]time.Time, error) {
+	path := filepath.Join(dir, StateFileName)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return map[string]time.Time{}, nil
+		}
+
+		return nil, errors.FileError("failed to read warning state", path, err)
+	}
+
+	state := map[string]time.Time{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		// A corrupt state file only costs repeating a warning; start over.
+		return map[string]time.Time{}, nil
+	}
+
+	return state, nil
+}
+
+// Save writes state to dir atomically.
+func Save(dir string, state map[string]time.Time) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return errors.WrapError(errors.RuntimeError, "failed to encode warning state", err)
+	}
+
+	return fsutil.WriteAtomic(filepath.Join(dir, StateFileName), func(f *os.File) error {
+		_, err := f.Write(append(data, '\n'))
+
+		return err
+	})
+}
@@ -0,0 +1,103 @@
+package warnlimit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	l := New(dir, DefaultInterval)
+	l.now = func() time.Time { return now }
+
+	if !l.Allow(EnvConflict, "ANTHROPIC_API_KEY") {
+		t.Fatal("first warning was quieted")
+	}
+	if l.Allow(EnvConflict, "ANTHROPIC_API_KEY") {
+		t.Error("repeated warning was shown within the interval")
+	}
+	if !l.Allow(EnvConflict, "ANTHROPIC_BASE_URL") {
+		t.Error("warning about another subject was quieted")
+	}
+	if !l.Allow(UsageExporter, "") {
+		t.Error("warning of another class was quieted")
+	}
+
+	// Another process sharing the config directory sees the same state.
+	other := New(dir, DefaultInterval)
+	other.now = func() time.Time { return now.Add(time.Hour) }
+	if other.Allow(EnvConflict, "ANTHROPIC_API_KEY") {
+		t.Error("warning was shown again by another limiter within the interval")
+	}
+
+	now = now.Add(DefaultInterval)
+	if !l.Allow(EnvConflict, "ANTHROPIC_API_KEY") {
+		t.Error("warning was still quiet after the interval")
+	}
+}
+
+func TestAllow_PrunesExpired(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	l := New(dir, time.Hour)
+	l.now = func() time.Time { return now }
+
+	l.Allow(EnvConflict, "A")
+	now = now.Add(2 * time.Hour)
+	l.Allow(EnvConflict, "B")
+
+	state, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state[EnvConflict+"/A"]; ok {
+		t.Errorf("expired entry kept: %v", state)
+	}
+	if _, ok := state[EnvConflict+"/B"]; !ok {
+		t.Errorf("new entry missing: %v", state)
+	}
+}
+
+func TestAllow_ClockMovedBack(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	l := New(dir, DefaultInterval)
+	l.now = func() time.Time { return now }
+
+	l.Allow(EnvConflict, "A")
+	now = now.Add(-time.Hour)
+	if !l.Allow(EnvConflict, "A") {
+		t.Error("a warning recorded in the future kept it quiet")
+	}
+}
+
+func TestAllow_ShowsWithoutState(t *testing.T) {
+	var nilLimiter *Limiter
+	if !nilLimiter.Allow(EnvConflict, "A") {
+		t.Error("nil Limiter quieted a warning")
+	}
+
+	off := New(t.TempDir(), 0)
+	if !off.Allow(EnvConflict, "A") || !off.Allow(EnvConflict, "A") {
+		t.Error("a zero interval quieted a warning")
+	}
+
+	missing := New(filepath.Join(t.TempDir(), "missing"), DefaultInterval)
+	if !missing.Allow(EnvConflict, "A") || !missing.Allow(EnvConflict, "A") {
+		t.Error("a warning was quieted although its state could not be saved")
+	}
+}
+
+func TestLoad_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, StateFileName), []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	state, err := Load(dir)
+	if err != nil || len(state) != 0 {
+		t.Errorf("Load() = %v, %v; want an empty state", state, err)
+	}
+}
@@ -0,0 +1,135 @@
+package wrapper
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsperm"
+)
+
+// AuthDirPrefix is the name prefix of the temporary auth directories created
+// by CreateTempAuthDir.
+const AuthDirPrefix = "kairo-auth-"
+
+// ownerFileName records the pid and uid of the kairo process that created an
+// auth directory, so stale directories can be told apart from live sessions.
+const ownerFileName = ".owner"
+
+// DefaultStaleAuthDirAge is how old an auth directory must be before it is
+// considered left over from a killed kairo process.
+const DefaultStaleAuthDirAge = 10 * time.Minute
+
+// writeOwnerFile records the current process as the owner of authDir.
+func writeOwnerFile(authDir string) error {
+	content := fmt.Sprintf("%d %d\n", os.Getpid(), os.Getuid())
+
+	return fsperm.WriteFile(filepath.Join(authDir, ownerFileName), []byte(content))
+}
+
+// readOwnerFile returns the pid and uid recorded in authDir.
+func readOwnerFile(authDir string) (pid, uid int, err error) {
+	data, err := os.ReadFile(filepath.Join(authDir, ownerFileName))
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(data)), "%d %d", &pid, &uid); err != nil {
+		return 0, 0, err
+	}
+
+	return pid, uid, nil
+}
+
+// processAlive reports whether a process with the given pid is running.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == constants.WindowsGOOS {
+		// FindProcess opens a handle on Windows and fails for exited processes.
+		_ = p.Release()
+
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+
+	return err == nil || stderrors.Is(err, os.ErrPermission)
+}
+
+// StaleAuthDirs returns the auth directories in tmpDir that were left behind
+// by kairo processes that no longer run: older than maxAge, owned by the
+// current user, and not belonging to a live process. Directories without an
+// owner record (created by older versions) are judged by age alone, except
+// when running as root, where ownership cannot be told.
+func StaleAuthDirs(tmpDir string, maxAge time.Duration, now time.Time) ([]string, error) {
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		return nil, errors.FileError("failed to read temp directory", tmpDir, err)
+	}
+
+	var stale []string
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), AuthDirPrefix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || now.Sub(info.ModTime()) < maxAge {
+			continue
+		}
+
+		dir := filepath.Join(tmpDir, e.Name())
+		pid, uid, err := readOwnerFile(dir)
+		switch {
+		case err == nil:
+			if uid != os.Getuid() || pid == os.Getpid() || processAlive(pid) {
+				continue
+			}
+		case stderrors.Is(err, fs.ErrNotExist):
+			if os.Getuid() == 0 {
+				continue
+			}
+		default:
+			// Unreadable: another user's directory or a corrupt record.
+			continue
+		}
+		stale = append(stale, dir)
+	}
+
+	return stale, nil
+}
+
+// CleanStaleAuthDirs removes the directories reported by StaleAuthDirs and
+// returns the ones it removed. With dryRun set nothing is removed.
+func CleanStaleAuthDirs(tmpDir string, maxAge time.Duration, now time.Time, dryRun bool) ([]string, error) {
+	stale, err := StaleAuthDirs(tmpDir, maxAge, now)
+	if err != nil || dryRun {
+		return stale, err
+	}
+
+	removed := make([]string, 0, len(stale))
+	var firstErr error
+	for _, dir := range stale {
+		if err := os.RemoveAll(dir); err != nil {
+			if firstErr == nil {
+				firstErr = errors.FileError("failed to remove stale auth directory", dir, err)
+			}
+
+			continue
+		}
+		removed = append(removed, dir)
+	}
+
+	return removed, firstErr
+}
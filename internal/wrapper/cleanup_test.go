@@ -0,0 +1,123 @@
+package wrapper
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// exitedPid returns the pid of a child process that has already exited.
+func exitedPid(t *testing.T) int {
+	t.Helper()
+	c := exec.Command(os.Args[0], "-test.run=^$")
+	if err := c.Run(); err != nil {
+		t.Fatalf("running child process: %v", err)
+	}
+
+	return c.ProcessState.Pid()
+}
+
+func makeAuthDir(t *testing.T, tmp, name, owner string, age time.Duration) string {
+	t.Helper()
+	dir := filepath.Join(tmp, name)
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if owner != "" {
+		if err := os.WriteFile(filepath.Join(dir, ownerFileName), []byte(owner), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mtime := time.Now().Add(-age)
+	if err := os.Chtimes(dir, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestCleanStaleAuthDirs(t *testing.T) {
+	tmp := t.TempDir()
+	uid := os.Getuid()
+	dead := exitedPid(t)
+
+	stale := makeAuthDir(t, tmp, AuthDirPrefix+"dead", fmt.Sprintf("%d %d", dead, uid), time.Hour)
+	recent := makeAuthDir(t, tmp, AuthDirPrefix+"recent", fmt.Sprintf("%d %d", dead, uid), time.Minute)
+	live := makeAuthDir(t, tmp, AuthDirPrefix+"live", fmt.Sprintf("%d %d", os.Getpid(), uid), time.Hour)
+	other := makeAuthDir(t, tmp, AuthDirPrefix+"other", fmt.Sprintf("%d %d", dead, uid+1), time.Hour)
+	unrelated := makeAuthDir(t, tmp, "something-else", "", time.Hour)
+
+	found, err := CleanStaleAuthDirs(tmp, DefaultStaleAuthDirAge, time.Now(), true)
+	if err != nil {
+		t.Fatalf("CleanStaleAuthDirs(dryRun) error = %v", err)
+	}
+	if !slices.Equal(found, []string{stale}) {
+		t.Errorf("CleanStaleAuthDirs(dryRun) = %v, want [%s]", found, stale)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Errorf("dry run removed %s", stale)
+	}
+
+	removed, err := CleanStaleAuthDirs(tmp, DefaultStaleAuthDirAge, time.Now(), false)
+	if err != nil {
+		t.Fatalf("CleanStaleAuthDirs() error = %v", err)
+	}
+	if !slices.Equal(removed, []string{stale}) {
+		t.Errorf("CleanStaleAuthDirs() = %v, want [%s]", removed, stale)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("%s was not removed", stale)
+	}
+	for _, dir := range []string{recent, live, other, unrelated} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("%s should have been kept: %v", dir, err)
+		}
+	}
+}
+
+func TestCleanStaleAuthDirs_Unmarked(t *testing.T) {
+	tmp := t.TempDir()
+	dir := makeAuthDir(t, tmp, AuthDirPrefix+"legacy", "", time.Hour)
+
+	removed, err := CleanStaleAuthDirs(tmp, DefaultStaleAuthDirAge, time.Now(), false)
+	if err != nil {
+		t.Fatalf("CleanStaleAuthDirs() error = %v", err)
+	}
+
+	// Without an owner record, root cannot tell whose directory it is.
+	want := []string{dir}
+	if os.Getuid() == 0 {
+		want = []string{}
+	}
+	if !slices.Equal(removed, want) {
+		t.Errorf("CleanStaleAuthDirs() = %v, want %v", removed, want)
+	}
+}
+
+func TestCreateTempAuthDir_RecordsOwner(t *testing.T) {
+	dir, err := CreateTempAuthDir("")
+	if err != nil {
+		t.Fatalf("CreateTempAuthDir() error = %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pid, uid, err := readOwnerFile(dir)
+	if err != nil {
+		t.Fatalf("readOwnerFile() error = %v", err)
+	}
+	if pid != os.Getpid() || uid != os.Getuid() {
+		t.Errorf("owner = (%d, %d), want (%d, %d)", pid, uid, os.Getpid(), os.Getuid())
+	}
+
+	stale, err := StaleAuthDirs(filepath.Dir(dir), 0, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(stale, dir) {
+		t.Error("directory of the running process reported as stale")
+	}
+}
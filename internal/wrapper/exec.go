@@ -0,0 +1,125 @@
+package wrapper
+
+import (
+	"context"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/health"
+)
+
+// ExecCommand is the hidden kairo command that takes the place of a wrapper
+// script on Unix. Its arguments are built by ExecArgs and read back by
+// ParseExecArgs.
+const ExecCommand = "__wrapper-exec"
+
+// ErrProbeFailed is returned by Exec when the provider probe failed. Exec has
+// already printed why on stderr.
+var ErrProbeFailed = stderrors.New("provider probe failed")
+
+// ExecArgs returns the arguments of ExecCommand that launch cfg. The token
+// itself is never among them, only the path of the file holding it.
+func ExecArgs(cfg ScriptConfig) []string {
+	args := []string{ExecCommand, "--token-file", cfg.TokenPath, "--env", cfg.EnvVarName}
+	if cfg.StdinPath != "" {
+		args = append(args, "--stdin-file", cfg.StdinPath)
+	}
+	if p := cfg.Ping; p != nil {
+		args = append(args, "--ping-provider", p.Provider, "--ping-url", p.URL)
+		if p.AuthStyle != health.AuthStyleBoth {
+			args = append(args, "--ping-auth-style", string(p.AuthStyle))
+		}
+	}
+
+	return append(append(args, "--", cfg.CliPath), cfg.CliArgs...)
+}
+
+// ParseExecArgs returns the launch described by args, the arguments that
+// follow ExecCommand.
+func ParseExecArgs(args []string) (ScriptConfig, error) {
+	fs := flag.NewFlagSet(ExecCommand, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var cfg ScriptConfig
+	var ping Ping
+	var style string
+	fs.StringVar(&cfg.TokenPath, "token-file", "", "")
+	fs.StringVar(&cfg.EnvVarName, "env", "", "")
+	fs.StringVar(&cfg.StdinPath, "stdin-file", "", "")
+	fs.StringVar(&ping.Provider, "ping-provider", "", "")
+	fs.StringVar(&ping.URL, "ping-url", "", "")
+	fs.StringVar(&style, "ping-auth-style", "", "")
+	if err := fs.Parse(args); err != nil {
+		return cfg, errors.WrapError(errors.ValidationError, "invalid "+ExecCommand+" arguments", err)
+	}
+	rest := fs.Args()
+	if cfg.TokenPath == "" || cfg.EnvVarName == "" || len(rest) == 0 || rest[0] == "" {
+		return cfg, errors.NewError(errors.ValidationError,
+			ExecCommand+" needs --token-file, --env, and the CLI to run after --")
+	}
+	cfg.AuthDir = filepath.Dir(cfg.TokenPath)
+	cfg.CliPath, cfg.CliArgs = rest[0], rest[1:]
+
+	if ping.URL != "" {
+		authStyle, err := health.ParseAuthStyle(style)
+		if err != nil {
+			return cfg, errors.WrapError(errors.ValidationError, "invalid "+ExecCommand+" arguments", err)
+		}
+		ping.AuthStyle = authStyle
+		cfg.Ping = &ping
+	}
+
+	return cfg, nil
+}
+
+// probe requests p.URL with token and, when the provider is unreachable or
+// rejects the token, prints the message a Windows wrapper script prints to
+// stderr and returns ErrProbeFailed.
+func probe(ctx context.Context, stderr io.Writer, p *Ping, token string) error {
+	unreachable, unauthorized := pingMessages(p)
+
+	ctx, cancel := context.WithTimeout(ctx, pingTimeoutSeconds*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		fmt.Fprintln(stderr, unreachable)
+
+		return ErrProbeFailed
+	}
+	req.Header.Set("anthropic-version", health.AnthropicVersion)
+	health.SetAuthHeaders(req.Header, p.AuthStyle, token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Fprintln(stderr, unreachable)
+
+		return ErrProbeFailed
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		fmt.Fprintf(stderr, "%s%d)\n", unauthorized, resp.StatusCode)
+
+		return ErrProbeFailed
+	}
+
+	return nil
+}
+
+// withEnv returns environ with name set to value, replacing any entry for
+// name already there.
+func withEnv(environ []string, name, value string) []string {
+	env := make([]string, 0, len(environ)+1)
+	for _, kv := range environ {
+		if !strings.HasPrefix(kv, name+"=") {
+			env = append(env, kv)
+		}
+	}
+
+	return append(env, name+"="+value)
+}
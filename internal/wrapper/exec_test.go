@@ -0,0 +1,124 @@
+package wrapper
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/health"
+)
+
+func TestExecArgs_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ScriptConfig
+	}{
+		{
+			name: "plain",
+			cfg: ScriptConfig{
+				AuthDir:    "/tmp/kairo-auth-1",
+				TokenPath:  "/tmp/kairo-auth-1/token-1",
+				CliPath:    "/usr/bin/claude",
+				EnvVarName: "ANTHROPIC_AUTH_TOKEN",
+			},
+		},
+		{
+			name: "hostile arguments are passed through untouched",
+			cfg: ScriptConfig{
+				AuthDir:    "/tmp/it's here",
+				TokenPath:  "/tmp/it's here/token-1",
+				CliPath:    "/opt/my cli/claude",
+				CliArgs:    []string{"--token-file", "x", "--", "$(rm -rf ~)", "it's `fine`", "-p"},
+				EnvVarName: "ANTHROPIC_API_KEY",
+				StdinPath:  "/tmp/it's here/stdin-1",
+			},
+		},
+		{
+			name: "ping",
+			cfg: ScriptConfig{
+				AuthDir:    "/tmp/kairo-auth-1",
+				TokenPath:  "/tmp/kairo-auth-1/token-1",
+				CliPath:    "/usr/bin/claude",
+				EnvVarName: "ANTHROPIC_AUTH_TOKEN",
+				Ping:       &Ping{Provider: "zai", URL: "https://api.z.ai/v1/models", AuthStyle: health.AuthStyleBearer},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := ExecArgs(tt.cfg)
+			if args[0] != ExecCommand {
+				t.Fatalf("ExecArgs()[0] = %q, want %q", args[0], ExecCommand)
+			}
+			got, err := ParseExecArgs(args[1:])
+			if err != nil {
+				t.Fatalf("ParseExecArgs() error = %v", err)
+			}
+			if len(got.CliArgs) == 0 {
+				got.CliArgs = tt.cfg.CliArgs
+			}
+			if !reflect.DeepEqual(got, tt.cfg) {
+				t.Errorf("ParseExecArgs(ExecArgs()) = %+v, want %+v", got, tt.cfg)
+			}
+		})
+	}
+}
+
+func TestParseExecArgs_Invalid(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"--env", "ANTHROPIC_AUTH_TOKEN", "--", "/usr/bin/claude"},
+		{"--token-file", "/tmp/token", "--env", "ANTHROPIC_AUTH_TOKEN"},
+		{"--token-file", "/tmp/token", "--bogus", "--", "/usr/bin/claude"},
+		{"--token-file", "/tmp/token", "--env", "X", "--ping-url", "https://x", "--ping-auth-style", "basic", "--", "/c"},
+	} {
+		if _, err := ParseExecArgs(args); err == nil {
+			t.Errorf("ParseExecArgs(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestPrepareLaunch_Unix(t *testing.T) {
+	authDir := t.TempDir()
+	cfg := ScriptConfig{AuthDir: authDir, TokenPath: authDir + "/token", CliPath: "/usr/bin/claude", CliArgs: []string{"-p", "hi"}}
+
+	launch, err := prepareLaunch(false, cfg, "/usr/local/bin/kairo")
+	if err != nil {
+		t.Fatalf("prepareLaunch() error = %v", err)
+	}
+	if launch.Name != "/usr/local/bin/kairo" || launch.Args[0] != ExecCommand {
+		t.Errorf("launch = %+v, want kairo %s", launch, ExecCommand)
+	}
+	if !slices.Contains(launch.Args, "ANTHROPIC_AUTH_TOKEN") {
+		t.Errorf("launch args %q do not default the variable name", launch.Args)
+	}
+
+	if _, err := prepareLaunch(false, cfg, ""); err == nil {
+		t.Error("prepareLaunch() without the kairo executable succeeded, want an error")
+	}
+	if _, err := prepareLaunch(false, ScriptConfig{AuthDir: authDir, CliPath: "/usr/bin/claude"}, "kairo"); err == nil {
+		t.Error("prepareLaunch() without a token path succeeded, want an error")
+	}
+}
+
+func TestPrepareLaunch_Windows(t *testing.T) {
+	authDir := t.TempDir()
+	cfg := ScriptConfig{AuthDir: authDir, TokenPath: authDir + `\token`, CliPath: `C:\bin\claude.exe`}
+
+	launch, err := prepareLaunch(true, cfg, "")
+	if err != nil {
+		t.Fatalf("prepareLaunch() error = %v", err)
+	}
+	if launch.Name != "powershell" || !strings.HasSuffix(launch.Args[len(launch.Args)-1], ".ps1") {
+		t.Errorf("launch = %+v, want powershell running a .ps1 script", launch)
+	}
+}
+
+func TestWithEnv(t *testing.T) {
+	got := withEnv([]string{"PATH=/bin", "ANTHROPIC_API_KEY=old", "ANTHROPIC_API_KEY_EXTRA=keep"}, "ANTHROPIC_API_KEY", "new")
+	want := []string{"PATH=/bin", "ANTHROPIC_API_KEY_EXTRA=keep", "ANTHROPIC_API_KEY=new"}
+	if !slices.Equal(got, want) {
+		t.Errorf("withEnv() = %q, want %q", got, want)
+	}
+}
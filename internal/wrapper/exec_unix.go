@@ -0,0 +1,62 @@
+//go:build !windows
+
+package wrapper
+
+import (
+	"context"
+	"os"
+	"syscall"
+
+	"github.com/dkmnx/kairo/internal/errors"
+	"golang.org/x/sys/unix"
+)
+
+// Exec reads the token from cfg.TokenPath and deletes the file, probes the
+// provider when cfg.Ping is set, feeds cfg.StdinPath to stdin, and replaces
+// the current process with the CLI, adding the token to environ as
+// cfg.EnvVarName. The CLI keeps the process ID, so signals sent to the
+// launch reach it. Exec returns only on failure.
+func Exec(ctx context.Context, cfg ScriptConfig, environ []string) error {
+	token, err := os.ReadFile(cfg.TokenPath)
+	if err != nil {
+		return errors.WrapError(errors.FileSystemError, "failed to read token file", err)
+	}
+	if err := os.Remove(cfg.TokenPath); err != nil {
+		return errors.WrapError(errors.FileSystemError, "failed to delete token file", err)
+	}
+
+	if cfg.Ping != nil {
+		if err := probe(ctx, os.Stderr, cfg.Ping, string(token)); err != nil {
+			return err
+		}
+	}
+	if cfg.StdinPath != "" {
+		if err := redirectStdin(cfg.StdinPath); err != nil {
+			return err
+		}
+	}
+
+	argv := append([]string{cfg.CliPath}, cfg.CliArgs...)
+	err = syscall.Exec(cfg.CliPath, argv, withEnv(environ, cfg.EnvVarName, string(token)))
+
+	return errors.FileError("failed to run the CLI", cfg.CliPath, err)
+}
+
+// redirectStdin makes path the process's stdin and deletes it; the open
+// descriptor keeps the content readable by the CLI.
+func redirectStdin(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.WrapError(errors.FileSystemError, "failed to open stdin file", err)
+	}
+	defer f.Close()
+
+	if err := unix.Dup2(int(f.Fd()), int(os.Stdin.Fd())); err != nil {
+		return errors.WrapError(errors.FileSystemError, "failed to redirect stdin", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return errors.WrapError(errors.FileSystemError, "failed to delete stdin file", err)
+	}
+
+	return nil
+}
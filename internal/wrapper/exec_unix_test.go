@@ -0,0 +1,125 @@
+//go:build !windows
+
+package wrapper
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// execHelperEnv makes the test binary run Exec with the arguments after
+// "--" instead of the tests, standing in for `kairo __wrapper-exec`.
+const execHelperEnv = "KAIRO_WRAPPER_EXEC_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(execHelperEnv) == "1" {
+		for i, arg := range os.Args {
+			if arg != "--" {
+				continue
+			}
+			cfg, err := ParseExecArgs(os.Args[i+2:])
+			if err == nil {
+				err = Exec(context.Background(), cfg, os.Environ())
+			}
+			os.Stderr.WriteString(err.Error() + "\n")
+			os.Exit(1)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// runExec runs Exec for cfg in a child process and returns its output.
+func runExec(t *testing.T, cfg ScriptConfig) (string, error) {
+	t.Helper()
+	args := append([]string{"-test.run=^$", "--"}, ExecArgs(cfg)...)
+	cmd := exec.CommandContext(context.Background(), os.Args[0], args...)
+	cmd.Env = append(os.Environ(), execHelperEnv+"=1", cfg.EnvVarName+"=stale")
+	out, err := cmd.CombinedOutput()
+
+	return string(out), err
+}
+
+func TestExec(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	authDir := t.TempDir()
+	tokenPath, err := WriteTempTokenFile(authDir, "sk-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runExec(t, ScriptConfig{
+		AuthDir:    authDir,
+		TokenPath:  tokenPath,
+		CliPath:    sh,
+		CliArgs:    []string{"-c", `printf '%s|%s' "$ANTHROPIC_API_KEY" "$1"`, "sh", "it's $HOME `id`"},
+		EnvVarName: "ANTHROPIC_API_KEY",
+	})
+	if err != nil {
+		t.Fatalf("Exec error = %v, output:\n%s", err, out)
+	}
+	if want := "sk-test|it's $HOME `id`"; out != want {
+		t.Errorf("CLI saw %q, want %q", out, want)
+	}
+	if _, err := os.Stat(tokenPath); !os.IsNotExist(err) {
+		t.Errorf("token file not removed: %v", err)
+	}
+}
+
+func TestExec_OversizedArgGoesToStdin(t *testing.T) {
+	wc, err := exec.LookPath("wc")
+	if err != nil {
+		t.Skip("wc not available")
+	}
+	authDir := t.TempDir()
+	tokenPath, err := WriteTempTokenFile(authDir, "tok")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	launch, err := PrepareLaunch(ScriptConfig{
+		AuthDir:   authDir,
+		TokenPath: tokenPath,
+		CliPath:   wc,
+		CliArgs:   []string{"-c", strings.Repeat("p", 1536*1024)},
+	}, "kairo")
+	if err != nil {
+		t.Fatalf("PrepareLaunch() error = %v", err)
+	}
+	cfg, err := ParseExecArgs(launch.Args[1:])
+	if err != nil {
+		t.Fatalf("ParseExecArgs() error = %v", err)
+	}
+	if cfg.StdinPath == "" {
+		t.Fatal("oversized argument was not moved to stdin")
+	}
+
+	out, err := runExec(t, cfg)
+	if err != nil {
+		t.Fatalf("Exec error = %v, output:\n%s", err, out)
+	}
+	if got := strings.TrimSpace(out); got != "1572864" {
+		t.Errorf("CLI read %s bytes from stdin, want 1572864", got)
+	}
+	matches, _ := filepath.Glob(filepath.Join(authDir, oversizedArgPrefix+"*"))
+	if len(matches) != 0 {
+		t.Errorf("stdin file not removed: %v", matches)
+	}
+}
+
+func TestExec_MissingTokenFile(t *testing.T) {
+	out, err := runExec(t, ScriptConfig{
+		TokenPath:  filepath.Join(t.TempDir(), "token"),
+		CliPath:    "/bin/true",
+		EnvVarName: "ANTHROPIC_AUTH_TOKEN",
+	})
+	if err == nil || !strings.Contains(out, "failed to read token file") {
+		t.Errorf("Exec error = %v, output:\n%s; want a token file error", err, out)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package wrapper
+
+import (
+	"context"
+
+	"github.com/dkmnx/kairo/internal/errors"
+)
+
+// Exec is only used on Unix; Windows launches run a PowerShell wrapper
+// script instead (see PrepareLaunch).
+func Exec(context.Context, ScriptConfig, []string) error {
+	return errors.NewError(errors.RuntimeError, ExecCommand+" is not supported on Windows")
+}
@@ -0,0 +1,71 @@
+package wrapper
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsperm"
+)
+
+// SettingsData is the data available to a provider's settings file template.
+type SettingsData struct {
+	Provider string
+	APIKey   string
+	BaseURL  string
+	Model    string
+}
+
+// RenderSettings executes tmpl, a text/template, against data. The template
+// can use {{json .APIKey}} to emit a quoted and escaped JSON string. Unknown
+// fields are an error rather than an empty string, so a typo cannot produce
+// a settings file with a blank key.
+func RenderSettings(tmpl string, data SettingsData) (string, error) {
+	t, err := template.New("settings").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"json": jsonString}).
+		Parse(tmpl)
+	if err != nil {
+		return "", errors.WrapError(errors.ConfigError,
+			"invalid settings file template", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", errors.WrapError(errors.ConfigError,
+			"failed to render settings file template", err)
+	}
+
+	return buf.String(), nil
+}
+
+func jsonString(s string) (string, error) {
+	b, err := json.Marshal(s)
+
+	return string(b), err
+}
+
+// WriteSettingsFile writes content to a file called name in authDir with
+// restricted permissions and returns its path. name must be a plain file
+// name so the file cannot land outside authDir, which is removed on exit.
+func WriteSettingsFile(authDir, name, content string) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", errors.NewError(errors.ValidationError,
+			"settings file name must be a plain file name").
+			WithContext("name", name)
+	}
+
+	path := filepath.Join(authDir, name)
+	if err := os.WriteFile(path, []byte(content), constants.FilePermSecure); err != nil {
+		return "", errors.FileError("failed to write settings file", path, err)
+	}
+	if err := fsperm.Restrict(path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
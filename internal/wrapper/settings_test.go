@@ -0,0 +1,59 @@
+package wrapper
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/fsperm"
+)
+
+func TestRenderSettings(t *testing.T) {
+	data := SettingsData{Provider: "zai", APIKey: `sk-"quoted"`, BaseURL: "https://api.z.ai", Model: "glm-5.1"}
+
+	got, err := RenderSettings(`{"apiKey": {{json .APIKey}}, "model": "{{.Model}}"}`, data)
+	if err != nil {
+		t.Fatalf("RenderSettings() error = %v", err)
+	}
+	if want := `{"apiKey": "sk-\"quoted\"", "model": "glm-5.1"}`; got != want {
+		t.Errorf("RenderSettings() = %s, want %s", got, want)
+	}
+
+	if _, err := RenderSettings(`{{.Token}}`, data); err == nil {
+		t.Error("RenderSettings() expected error for unknown field")
+	}
+	if _, err := RenderSettings(`{{.APIKey`, data); err == nil {
+		t.Error("RenderSettings() expected error for malformed template")
+	}
+}
+
+func TestWriteSettingsFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := WriteSettingsFile(dir, "settings.json", "{}")
+	if err != nil {
+		t.Fatalf("WriteSettingsFile() error = %v", err)
+	}
+	if path != filepath.Join(dir, "settings.json") {
+		t.Errorf("WriteSettingsFile() path = %q", path)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm()&0077 != 0 {
+			t.Errorf("settings file mode = %o, want no group/other access", info.Mode().Perm())
+		}
+	}
+	if err := fsperm.CheckOwnerOnly(path); err != nil {
+		t.Errorf("CheckOwnerOnly() error = %v", err)
+	}
+
+	for _, name := range []string{"", "..", "../escape.json", "sub/settings.json"} {
+		if _, err := WriteSettingsFile(dir, name, "{}"); err == nil {
+			t.Errorf("WriteSettingsFile(%q) expected error", name)
+		}
+	}
+}
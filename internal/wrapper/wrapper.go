@@ -1,5 +1,7 @@
-// Package wrapper generates platform-specific shell scripts that securely
-// pass authentication tokens to CLI harnesses.
+// Package wrapper hands authentication tokens to CLI harnesses without
+// putting them on a command line: on Unix kairo re-runs itself to read the
+// token and exec the harness, and on Windows it generates a PowerShell
+// script that does the same.
 package wrapper
 
 import (
@@ -12,24 +14,42 @@ import (
 
 	"github.com/dkmnx/kairo/internal/constants"
 	"github.com/dkmnx/kairo/internal/errors"
+	"github.com/dkmnx/kairo/internal/fsperm"
+	"github.com/dkmnx/kairo/internal/health"
 )
 
 // CreateTempAuthDir creates a temporary directory with restricted permissions
-// for storing authentication tokens.
-func CreateTempAuthDir() (string, error) {
-	authDir, err := os.MkdirTemp("", "kairo-auth-")
+// for storing authentication tokens in parent, or in the system temp
+// directory when parent is empty, recording the current process as its owner
+// for CleanStaleAuthDirs. A missing parent is created.
+func CreateTempAuthDir(parent string) (string, error) {
+	if parent != "" {
+		if err := os.MkdirAll(parent, constants.DirPermSecure); err != nil {
+			return "", errors.WrapError(errors.FileSystemError,
+				"failed to create temp directory", err)
+		}
+	}
+
+	authDir, err := os.MkdirTemp(parent, AuthDirPrefix)
 	if err != nil {
 		return "", errors.WrapError(errors.FileSystemError,
 			"failed to create temp auth directory", err)
 	}
 
-	if err := os.Chmod(authDir, constants.DirPermSecure); err != nil {
+	if err := fsperm.Restrict(authDir); err != nil {
 		_ = os.RemoveAll(authDir)
 
 		return "", errors.WrapError(errors.FileSystemError,
 			"failed to set auth directory permissions", err)
 	}
 
+	if err := writeOwnerFile(authDir); err != nil {
+		_ = os.RemoveAll(authDir)
+
+		return "", errors.WrapError(errors.FileSystemError,
+			"failed to record auth directory owner", err)
+	}
+
 	return authDir, nil
 }
 
@@ -59,7 +79,7 @@ func WriteTempTokenFile(authDir, token string) (string, error) {
 			"failed to close temp token file", err)
 	}
 
-	if err := os.Chmod(f.Name(), constants.FilePermSecure); err != nil {
+	if err := fsperm.Restrict(f.Name()); err != nil {
 		return "", errors.WrapError(errors.FileSystemError,
 			"failed to set temp file permissions", err)
 	}
@@ -96,86 +116,203 @@ func EscapePowerShellArg(arg string) string {
 	return "'" + arg + "'"
 }
 
-// ScriptConfig holds the parameters for generating a wrapper script.
+// ScriptConfig holds the parameters of a launch: the token file handed over
+// in EnvVarName and the CLI run with it.
 type ScriptConfig struct {
 	AuthDir    string
 	TokenPath  string
 	CliPath    string
 	CliArgs    []string
 	EnvVarName string
+	// StdinPath, when set, is a file fed to the CLI's stdin and then deleted.
+	// PrepareLaunch sets it for an oversized argument.
+	StdinPath string
+	// Ping, when set, probes the provider before the CLI runs.
+	Ping *Ping
+}
+
+// Ping describes the provider probe run before the CLI. The token is sent in
+// the headers AuthStyle selects, and the launch exits with status 1 after
+// printing a one-line message when the provider is unreachable or answers 401
+// or 403. Any other response lets the CLI start.
+type Ping struct {
+	// Provider is the provider name shown in messages.
+	Provider string
+	// URL is the endpoint probed, normally health.ModelsEndpoint.
+	URL       string
+	AuthStyle health.AuthStyle
+}
+
+// pingTimeoutSeconds bounds the provider probe.
+const pingTimeoutSeconds = 10
+
+// Limits on what the final exec of the CLI can carry. Linux rejects any single
+// argument or environment string over 128 KiB (MAX_ARG_STRLEN) and all of
+// argv plus the environment over ARG_MAX, typically 2 MiB; Windows limits the
+// whole command line to 32767 characters. Writing arguments to a file does not
+// help because the launch still has to exec the CLI with them, and the
+// environment has the same per-string limit, so the only lossless channel for
+// a huge prompt is the CLI's stdin.
+const (
+	maxArgBytesUnix    = 128*1024 - 1
+	maxArgvBytesUnix   = 1024 * 1024
+	maxCmdLineWindows  = 32767
+	oversizedArgPrefix = "stdin-"
+)
+
+// argsFit reports whether cliPath and args can be exec'd directly.
+func argsFit(isWindows bool, cliPath string, args []string) bool {
+	total := len(cliPath) + 1
+	for _, arg := range args {
+		if !isWindows && len(arg) > maxArgBytesUnix {
+			return false
+		}
+		total += len(arg) + 1
+	}
+	if isWindows {
+		// Quoting can add a few characters per argument; leave headroom.
+		return total+2*len(args) < maxCmdLineWindows
+	}
+
+	return total < maxArgvBytesUnix
+}
+
+// moveOversizedArg writes the final argument to a file in authDir when the
+// arguments are too large to exec directly. Harnesses accept the prompt on
+// stdin, so the final argument (the prompt in `kairo <provider> -- "..."`) is
+// moved there. It is an error if the remaining arguments are still too large.
+func moveOversizedArg(isWindows bool, cfg ScriptConfig) (ScriptConfig, error) {
+	if argsFit(isWindows, cfg.CliPath, cfg.CliArgs) {
+		return cfg, nil
+	}
+
+	n := len(cfg.CliArgs)
+	if n == 0 || !argsFit(isWindows, cfg.CliPath, cfg.CliArgs[:n-1]) {
+		return cfg, errors.NewError(errors.ValidationError,
+			"harness arguments are too large to pass on the command line").
+			WithContext("hint", "pass large prompts as the last argument or on stdin")
+	}
+
+	f, err := os.CreateTemp(cfg.AuthDir, oversizedArgPrefix)
+	if err != nil {
+		return cfg, errors.WrapError(errors.FileSystemError,
+			"failed to create stdin file for oversized argument", err)
+	}
+	if _, err := f.WriteString(cfg.CliArgs[n-1]); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return cfg, errors.WrapError(errors.FileSystemError,
+			"failed to write oversized argument", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+
+		return cfg, errors.WrapError(errors.FileSystemError,
+			"failed to close stdin file", err)
+	}
+
+	cfg.CliArgs = cfg.CliArgs[:n-1]
+	cfg.StdinPath = f.Name()
+
+	return cfg, nil
+}
+
+// Launch is the process that hands the token to the CLI and runs it.
+type Launch struct {
+	Name string
+	Args []string
+}
+
+// PrepareLaunch returns the process that reads the token from cfg.TokenPath,
+// deletes the file, and runs the CLI with the token in its environment. On
+// Unix that is the kairo binary at exe run with ExecCommand, which does so in
+// Go and then execs the CLI, so no shell ever parses a path or argument. On
+// Windows it is PowerShell running a script GenerateWrapperScript writes to
+// cfg.AuthDir.
+func PrepareLaunch(cfg ScriptConfig, exe string) (Launch, error) {
+	return prepareLaunch(runtime.GOOS == constants.WindowsGOOS, cfg, exe)
 }
 
-// GenerateWrapperScript creates a platform-appropriate wrapper script that
-// loads the auth token, deletes the token file, and execs the CLI.
-// Returns the script path, whether it is a Windows script, and any error.
-func GenerateWrapperScript(cfg ScriptConfig) (string, bool, error) {
+func prepareLaunch(isWindows bool, cfg ScriptConfig, exe string) (Launch, error) {
+	if isWindows {
+		script, err := GenerateWrapperScript(cfg)
+		if err != nil {
+			return Launch{}, err
+		}
+
+		return Launch{Name: "powershell", Args: []string{"-NoProfile", "-ExecutionPolicy", "Bypass", "-File", script}}, nil
+	}
+
+	cfg, err := checkScriptConfig(false, cfg)
+	if err != nil {
+		return Launch{}, err
+	}
+	if exe == "" {
+		return Launch{}, errors.NewError(errors.ValidationError,
+			"wrapper: kairo executable path cannot be empty")
+	}
+
+	return Launch{Name: exe, Args: ExecArgs(cfg)}, nil
+}
+
+// checkScriptConfig validates cfg, fills in the default variable name, and
+// moves an oversized final argument to stdin.
+func checkScriptConfig(isWindows bool, cfg ScriptConfig) (ScriptConfig, error) {
 	if cfg.TokenPath == "" {
-		return "", false, errors.NewError(errors.ValidationError,
+		return cfg, errors.NewError(errors.ValidationError,
 			"wrapper: token path cannot be empty")
 	}
 	if cfg.CliPath == "" {
-		return "", false, errors.NewError(errors.ValidationError,
+		return cfg, errors.NewError(errors.ValidationError,
 			"wrapper: CLI path cannot be empty")
 	}
-
-	envVar := constants.EnvAuthToken
-	if cfg.EnvVarName != "" {
-		envVar = cfg.EnvVarName
+	if cfg.EnvVarName == "" {
+		cfg.EnvVarName = constants.EnvAuthToken
 	}
 
-	isWindows := runtime.GOOS == constants.WindowsGOOS
+	return moveOversizedArg(isWindows, cfg)
+}
+
+// GenerateWrapperScript writes the PowerShell wrapper script used on Windows
+// to cfg.AuthDir: it loads the auth token, deletes the token file, and runs
+// the CLI. It returns the path of the .ps1 script.
+func GenerateWrapperScript(cfg ScriptConfig) (string, error) {
+	cfg, err := checkScriptConfig(true, cfg)
+	if err != nil {
+		return "", err
+	}
 
 	f, err := os.CreateTemp(cfg.AuthDir, "wrapper-")
 	if err != nil {
-		return "", false, errors.WrapError(errors.FileSystemError,
+		return "", errors.WrapError(errors.FileSystemError,
 			"failed to create temp wrapper script", err)
 	}
 
-	scriptContent := generateScriptContent(isWindows, envVar, cfg)
-
-	if _, err := f.WriteString(scriptContent); err != nil {
+	if _, err := f.WriteString(GenerateWindowsScript(cfg.EnvVarName, cfg)); err != nil {
 		_ = f.Close()
 		_ = os.Remove(f.Name())
 
-		return "", false, errors.WrapError(errors.FileSystemError,
+		return "", errors.WrapError(errors.FileSystemError,
 			"failed to write wrapper script", err)
 	}
 
 	if err := f.Close(); err != nil {
 		_ = os.Remove(f.Name())
 
-		return "", false, errors.WrapError(errors.FileSystemError,
+		return "", errors.WrapError(errors.FileSystemError,
 			"failed to close wrapper script", err)
 	}
 
-	if isWindows {
-		ps1Path := f.Name() + ".ps1"
-		if err := os.Rename(f.Name(), ps1Path); err != nil {
-			_ = os.Remove(f.Name())
-
-			return "", false, errors.WrapError(errors.FileSystemError,
-				"failed to rename wrapper script", err)
-		}
-
-		return ps1Path, true, nil
-	}
-
-	if err := os.Chmod(f.Name(), constants.FilePermExec); err != nil {
+	ps1Path := f.Name() + ".ps1"
+	if err := os.Rename(f.Name(), ps1Path); err != nil {
 		_ = os.Remove(f.Name())
 
-		return "", false, errors.WrapError(errors.FileSystemError,
-			"failed to make wrapper script executable", err)
-	}
-
-	return f.Name(), false, nil
-}
-
-func generateScriptContent(isWindows bool, envVar string, cfg ScriptConfig) string {
-	if isWindows {
-		return GenerateWindowsScript(envVar, cfg)
+		return "", errors.WrapError(errors.FileSystemError,
+			"failed to rename wrapper script", err)
 	}
 
-	return generateUnixScript(envVar, cfg)
+	return ps1Path, nil
 }
 
 // GenerateWindowsScript returns the PowerShell script content for the wrapper.
@@ -185,6 +322,14 @@ func GenerateWindowsScript(envVar string, cfg ScriptConfig) string {
 	sb.WriteString("# This script will be automatically deleted after execution\r\n")
 	fmt.Fprintf(&sb, "$env:%s = Get-Content -Path %q -Raw\r\n", envVar, cfg.TokenPath)
 	fmt.Fprintf(&sb, "Remove-Item -Path %q -Force\r\n", cfg.TokenPath)
+	if cfg.Ping != nil {
+		writeWindowsPing(&sb, envVar, cfg.Ping)
+	}
+	if cfg.StdinPath != "" {
+		fmt.Fprintf(&sb, "$kairoStdin = Get-Content -Path %q -Raw\r\n", cfg.StdinPath)
+		fmt.Fprintf(&sb, "Remove-Item -Path %q -Force\r\n", cfg.StdinPath)
+		sb.WriteString("$kairoStdin | ")
+	}
 	fmt.Fprintf(&sb, "& %q", cfg.CliPath)
 	for _, arg := range cfg.CliArgs {
 		fmt.Fprintf(&sb, " %s", EscapePowerShellArg(arg))
@@ -194,31 +339,51 @@ func GenerateWindowsScript(envVar string, cfg ScriptConfig) string {
 	return sb.String()
 }
 
-// shellQuotePOSIX wraps s in single quotes safe for /bin/sh.
-// A single quote inside the string is escaped as '\” (end quote,
-// literal single quote, resume quote). This defeats all shell
-// expansions ($, `, \, !) because single quotes are the strongest
-// quoting mechanism in POSIX sh.
-func shellQuotePOSIX(s string) string {
-	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
+// pingMessages returns the messages a wrapper prints when the provider is
+// unreachable and when it rejects the key. The unauthorized message ends
+// just before the HTTP status, which the wrapper appends.
+func pingMessages(p *Ping) (unreachable, unauthorized string) {
+	unreachable = fmt.Sprintf("kairo: provider %s unreachable at %s", p.Provider, p.URL)
+	unauthorized = fmt.Sprintf("kairo: provider %s unauthorized; run 'kairo secrets set %s' to store a valid key (HTTP ",
+		p.Provider, p.Provider)
+
+	return unreachable, unauthorized
 }
 
-func generateUnixScript(envVar string, cfg ScriptConfig) string {
-	var sb strings.Builder
-	sb.WriteString("#!/bin/sh\n")
-	sb.WriteString("# Generated by kairo - DO NOT EDIT\n")
-	sb.WriteString("# This script will be automatically deleted after execution\n")
-	fmt.Fprintf(&sb, "export %s=$(cat %s)\n", envVar, shellQuotePOSIX(cfg.TokenPath))
-	fmt.Fprintf(&sb, "rm -f %s\n", shellQuotePOSIX(cfg.TokenPath))
-	sb.WriteString("exec ")
-	sb.WriteString(shellQuotePOSIX(cfg.CliPath))
-	for _, arg := range cfg.CliArgs {
-		sb.WriteString(" ")
-		sb.WriteString(shellQuotePOSIX(arg))
+// writeWindowsPing writes the Invoke-WebRequest probe, which throws for any
+// response that is not a success.
+func writeWindowsPing(sb *strings.Builder, envVar string, p *Ping) {
+	unreachable, unauthorized := pingMessages(p)
+
+	fmt.Fprintf(sb, "$kairoHeaders = @{ 'anthropic-version' = %s }\r\n", quotePowerShellLiteral(health.AnthropicVersion))
+	if p.AuthStyle != health.AuthStyleBearer {
+		fmt.Fprintf(sb, "$kairoHeaders['x-api-key'] = $env:%s\r\n", envVar)
+	}
+	if p.AuthStyle != health.AuthStyleAPIKey {
+		fmt.Fprintf(sb, "$kairoHeaders['Authorization'] = 'Bearer ' + $env:%s\r\n", envVar)
 	}
-	sb.WriteString("\n")
+	sb.WriteString("try {\r\n")
+	fmt.Fprintf(sb, "  $null = Invoke-WebRequest -Uri %s -Headers $kairoHeaders -Method Get -TimeoutSec %d -UseBasicParsing\r\n",
+		quotePowerShellLiteral(p.URL), pingTimeoutSeconds)
+	sb.WriteString("} catch {\r\n")
+	sb.WriteString("  $kairoStatus = 0\r\n")
+	sb.WriteString("  if ($_.Exception.Response) { $kairoStatus = [int]$_.Exception.Response.StatusCode }\r\n")
+	sb.WriteString("  if ($kairoStatus -eq 401 -or $kairoStatus -eq 403) {\r\n")
+	fmt.Fprintf(sb, "    [Console]::Error.WriteLine(%s + $kairoStatus + ')')\r\n", quotePowerShellLiteral(unauthorized))
+	sb.WriteString("    exit 1\r\n")
+	sb.WriteString("  }\r\n")
+	sb.WriteString("  if ($kairoStatus -eq 0) {\r\n")
+	fmt.Fprintf(sb, "    [Console]::Error.WriteLine(%s)\r\n", quotePowerShellLiteral(unreachable))
+	sb.WriteString("    exit 1\r\n")
+	sb.WriteString("  }\r\n")
+	sb.WriteString("}\r\n")
+}
 
-	return sb.String()
+// quotePowerShellLiteral wraps s in single quotes for PowerShell, doubling
+// any single quote. Unlike EscapePowerShellArg it adds no backticks, which
+// would be kept literally inside single quotes.
+func quotePowerShellLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
 }
 
 // ExecCommandContext creates an exec.Cmd for the given command and arguments.
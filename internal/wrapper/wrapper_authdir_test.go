@@ -2,12 +2,16 @@ package wrapper
 
 import (
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
+
+	"github.com/dkmnx/kairo/internal/fsperm"
 )
 
 func TestCreateTempAuthDir_Success(t *testing.T) {
-	dir, err := CreateTempAuthDir()
+	dir, err := CreateTempAuthDir("")
 	if err != nil {
 		t.Fatalf("CreateTempAuthDir() error = %v", err)
 	}
@@ -38,16 +42,19 @@ func TestCreateTempAuthDir_Success(t *testing.T) {
 			t.Errorf("Directory should have owner read permission")
 		}
 	}
+	if err := fsperm.CheckOwnerOnly(dir); err != nil {
+		t.Errorf("CheckOwnerOnly() error = %v", err)
+	}
 }
 
 func TestCreateTempAuthDir_ReturnsUniqueDirs(t *testing.T) {
-	dir1, err := CreateTempAuthDir()
+	dir1, err := CreateTempAuthDir("")
 	if err != nil {
 		t.Fatalf("CreateTempAuthDir() error = %v", err)
 	}
 	defer os.RemoveAll(dir1)
 
-	dir2, err := CreateTempAuthDir()
+	dir2, err := CreateTempAuthDir("")
 	if err != nil {
 		t.Fatalf("CreateTempAuthDir() error = %v", err)
 	}
@@ -58,6 +65,19 @@ func TestCreateTempAuthDir_ReturnsUniqueDirs(t *testing.T) {
 	}
 }
 
+func TestCreateTempAuthDir_Parent(t *testing.T) {
+	parent := filepath.Join(t.TempDir(), "kairo-tmp")
+	dir, err := CreateTempAuthDir(parent)
+	if err != nil {
+		t.Fatalf("CreateTempAuthDir(%q) error = %v", parent, err)
+	}
+	defer os.RemoveAll(dir)
+
+	if filepath.Dir(dir) != parent || !strings.HasPrefix(filepath.Base(dir), AuthDirPrefix) {
+		t.Errorf("CreateTempAuthDir(%q) = %q, want a %s* directory in it", parent, dir, AuthDirPrefix)
+	}
+}
+
 func TestWriteTempTokenFile_Success(t *testing.T) {
 	authDir := t.TempDir()
 	token := "test-api-key-12345"
@@ -89,6 +109,9 @@ func TestWriteTempTokenFile_Success(t *testing.T) {
 			t.Errorf("File permissions = %o, want %o", info.Mode(), expectedPerms)
 		}
 	}
+	if err := fsperm.CheckOwnerOnly(path); err != nil {
+		t.Errorf("CheckOwnerOnly() error = %v", err)
+	}
 }
 
 func TestWriteTempTokenFile_EmptyToken(t *testing.T) {
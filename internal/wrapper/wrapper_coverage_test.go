@@ -24,7 +24,7 @@ func TestCreateTempAuthDir_ChmodFailsOnReadOnly(t *testing.T) {
 	t.Cleanup(func() { _ = os.Chmod(ro, 0o700) })
 
 	t.Setenv("TMPDIR", ro)
-	if _, err := CreateTempAuthDir(); err == nil {
+	if _, err := CreateTempAuthDir(""); err == nil {
 		t.Error("CreateTempAuthDir() should fail when chmod on the new dir fails")
 	}
 }
@@ -55,16 +55,6 @@ func TestWriteTempTokenFile_ChmodFailsOnReadOnly(t *testing.T) {
 	}
 }
 
-// TestGenerateWrapperScript_ChmodFailsOnUnix is intentionally omitted:
-// on a properly-permissioned filesystem, a process can always chmod a file
-// it owns in a directory it owns, so we cannot construct a state where
-// GenerateWrapperScript's chmod fails from a unit test. The error branch
-// is covered by integration-style tests that run as non-root on read-only
-// mounts in CI.
-func TestGenerateWrapperScript_ChmodFailsOnUnix(t *testing.T) {
-	t.Skip("cannot reliably construct chmod-fails state from a unit test")
-}
-
 // TestEscapePowerShellArg_AdditionalMetachars covers the remaining characters.
 func TestEscapePowerShellArg_AdditionalMetachars(t *testing.T) {
 	tests := []struct {
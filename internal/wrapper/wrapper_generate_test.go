@@ -24,7 +24,7 @@ func containsAt(s, substr string) bool {
 
 func TestGenerateWrapperScript_EmptyTokenPath(t *testing.T) {
 	authDir := t.TempDir()
-	_, _, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: "", CliPath: "/usr/bin/claude", CliArgs: []string{"--help"}})
+	_, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: "", CliPath: "/usr/bin/claude", CliArgs: []string{"--help"}})
 	if err == nil {
 		t.Error("GenerateWrapperScript() should error on empty token path")
 	}
@@ -33,7 +33,7 @@ func TestGenerateWrapperScript_EmptyTokenPath(t *testing.T) {
 func TestGenerateWrapperScript_EmptyClaudePath(t *testing.T) {
 	authDir := t.TempDir()
 	tokenPath := filepath.Join(authDir, "token")
-	_, _, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: "", CliArgs: []string{"--help"}})
+	_, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: "", CliArgs: []string{"--help"}})
 	if err == nil {
 		t.Error("GenerateWrapperScript() should error on empty claude path")
 	}
@@ -49,16 +49,12 @@ func TestGenerateWrapperScript_WindowsPath(t *testing.T) {
 	claudePath := `C:\Program Files\claude\claude.exe`
 	args := []string{"--help", "--verbose"}
 
-	scriptPath, useCmdExe, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: claudePath, CliArgs: args})
+	scriptPath, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: claudePath, CliArgs: args})
 	if err != nil {
 		t.Fatalf("GenerateWrapperScript() error = %v", err)
 	}
 	defer os.Remove(scriptPath)
 
-	if !useCmdExe {
-		t.Error("Windows script should require cmdExe")
-	}
-
 	if filepath.Ext(scriptPath) != ".ps1" {
 		t.Errorf("Windows script should have .ps1 extension, got %s", filepath.Ext(scriptPath))
 	}
@@ -83,93 +79,6 @@ func TestGenerateWrapperScript_WindowsPath(t *testing.T) {
 	}
 }
 
-func TestGenerateWrapperScript_UnixPath(t *testing.T) {
-	if runtime.GOOS == "windows" {
-		t.Skip("Skipping Unix test on Windows")
-	}
-
-	authDir := t.TempDir()
-	tokenPath := filepath.Join(authDir, "token")
-	claudePath := "/usr/local/bin/claude"
-	args := []string{"--help", "--verbose"}
-
-	scriptPath, useCmdExe, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: claudePath, CliArgs: args})
-	if err != nil {
-		t.Fatalf("GenerateWrapperScript() error = %v", err)
-	}
-	defer os.Remove(scriptPath)
-
-	if useCmdExe {
-		t.Error("Unix script should not require cmdExe")
-	}
-
-	info, err := os.Stat(scriptPath)
-	if err != nil {
-		t.Fatalf("Stat() error = %v", err)
-	}
-
-	// Unix scripts should be executable
-	if info.Mode()&0111 == 0 {
-		t.Error("Unix wrapper script should be executable")
-	}
-
-	content, err := os.ReadFile(scriptPath)
-	if err != nil {
-		t.Fatalf("ReadFile() error = %v", err)
-	}
-
-	if len(content) == 0 {
-		t.Error("Wrapper script should not be empty")
-	}
-
-	if !contains(string(content), tokenPath) {
-		t.Error("Wrapper script should contain token path")
-	}
-
-	if !contains(string(content), "#!/bin/sh") {
-		t.Error("Unix wrapper script should have shebang")
-	}
-}
-
-func TestGenerateWrapperScript_WithSpecialArgs(t *testing.T) {
-	if runtime.GOOS == "windows" {
-		t.Skip("Skipping Unix test on Windows")
-	}
-
-	authDir := t.TempDir()
-	tokenPath := filepath.Join(authDir, "token")
-	claudePath := "/usr/bin/claude"
-	args := []string{"--prompt", "Hello 'World' and $HOME", `--option="value with spaces"`}
-
-	scriptPath, _, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: claudePath, CliArgs: args})
-	if err != nil {
-		t.Fatalf("GenerateWrapperScript() error = %v", err)
-	}
-	defer os.Remove(scriptPath)
-
-	content, err := os.ReadFile(scriptPath)
-	if err != nil {
-		t.Fatalf("ReadFile() error = %v", err)
-	}
-
-	scriptStr := string(content)
-
-	// $HOME must appear inside POSIX single quotes (not expanded by sh)
-	if !strings.Contains(scriptStr, "and $HOME'") {
-		t.Error("$HOME should be inside single quotes in the script, not expanded to a path")
-	}
-
-	// Single quotes inside args must be escaped with POSIX quoting (end quote, \', resume quote)
-	if !strings.Contains(scriptStr, "'\\''") {
-		t.Error("single quotes inside args should be POSIX-escaped")
-	}
-
-	// The flag=value pair should be preserved inside single quotes
-	if !strings.Contains(scriptStr, `'--option="value with spaces"'`) {
-		t.Error("flag with spaces should be POSIX single-quoted")
-	}
-}
-
 func TestGenerateWrapperScript_WindowsWithSpecialArgs(t *testing.T) {
 	if runtime.GOOS != "windows" {
 		t.Skip("Skipping Windows-specific test on non-Windows platform")
@@ -187,7 +96,7 @@ func TestGenerateWrapperScript_WindowsWithSpecialArgs(t *testing.T) {
 		"--model", "sonnet-4-20250514",
 	}
 
-	scriptPath, _, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: claudePath, CliArgs: args})
+	scriptPath, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: claudePath, CliArgs: args})
 	if err != nil {
 		t.Fatalf("GenerateWrapperScript() error = %v", err)
 	}
@@ -224,7 +133,7 @@ func TestGenerateWrapperScript_CustomEnvVar(t *testing.T) {
 	args := []string{"--help"}
 
 	t.Run("uses custom env var when provided", func(t *testing.T) {
-		scriptPath, _, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: cliPath, CliArgs: args, EnvVarName: "ANTHROPIC_API_KEY"})
+		scriptPath, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: cliPath, CliArgs: args, EnvVarName: "ANTHROPIC_API_KEY"})
 		if err != nil {
 			t.Fatalf("GenerateWrapperScript() error = %v", err)
 		}
@@ -245,7 +154,7 @@ func TestGenerateWrapperScript_CustomEnvVar(t *testing.T) {
 	})
 
 	t.Run("uses default auth token when not provided", func(t *testing.T) {
-		scriptPath, _, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: cliPath, CliArgs: args})
+		scriptPath, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: cliPath, CliArgs: args})
 		if err != nil {
 			t.Fatalf("GenerateWrapperScript() error = %v", err)
 		}
@@ -263,7 +172,7 @@ func TestGenerateWrapperScript_CustomEnvVar(t *testing.T) {
 	})
 
 	t.Run("uses empty string as default", func(t *testing.T) {
-		scriptPath, _, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: cliPath, CliArgs: args, EnvVarName: ""})
+		scriptPath, err := GenerateWrapperScript(ScriptConfig{AuthDir: authDir, TokenPath: tokenPath, CliPath: cliPath, CliArgs: args, EnvVarName: ""})
 		if err != nil {
 			t.Fatalf("GenerateWrapperScript() error = %v", err)
 		}
@@ -282,7 +191,7 @@ func TestGenerateWrapperScript_CustomEnvVar(t *testing.T) {
 }
 
 func TestGenerateWrapperScript_NonExistentAuthDir(t *testing.T) {
-	_, _, err := GenerateWrapperScript(ScriptConfig{
+	_, err := GenerateWrapperScript(ScriptConfig{
 		AuthDir:    "/nonexistent/auth/dir",
 		TokenPath:  "/nonexistent/auth/dir/token",
 		CliPath:    "/usr/bin/claude",
@@ -311,7 +220,7 @@ func TestGenerateWrapperScript_ControlCharacterEscaping(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			scriptPath, _, err := GenerateWrapperScript(ScriptConfig{
+			scriptPath, err := GenerateWrapperScript(ScriptConfig{
 				AuthDir:    authDir,
 				TokenPath:  tokenPath,
 				CliPath:    cliPath,
@@ -352,7 +261,7 @@ func TestGenerateWrapperScript_WithArgs(t *testing.T) {
 	cliPath := "/usr/bin/claude"
 	args := []string{"--model", "sonnet-4-20250514", "--temperature", "0.7"}
 
-	scriptPath, isWindows, err := GenerateWrapperScript(ScriptConfig{
+	scriptPath, err := GenerateWrapperScript(ScriptConfig{
 		AuthDir:    authDir,
 		TokenPath:  tokenPath,
 		CliPath:    cliPath,
@@ -378,9 +287,8 @@ func TestGenerateWrapperScript_WithArgs(t *testing.T) {
 		}
 	}
 
-	isWindowsExpected := runtime.GOOS == "windows"
-	if isWindows != isWindowsExpected {
-		t.Errorf("isWindows = %v, want %v", isWindows, isWindowsExpected)
+	if filepath.Ext(scriptPath) != ".ps1" {
+		t.Errorf("script path = %s, want a .ps1 script", scriptPath)
 	}
 }
 
@@ -389,7 +297,7 @@ func TestGenerateWrapperScript_ScriptIsDeletedAfterUse(t *testing.T) {
 	tokenPath := filepath.Join(authDir, "token")
 	cliPath := "/usr/bin/claude"
 
-	scriptPath, _, err := GenerateWrapperScript(ScriptConfig{
+	scriptPath, err := GenerateWrapperScript(ScriptConfig{
 		AuthDir:    authDir,
 		TokenPath:  tokenPath,
 		CliPath:    cliPath,
@@ -403,15 +311,39 @@ func TestGenerateWrapperScript_ScriptIsDeletedAfterUse(t *testing.T) {
 	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
 		t.Error("Script should exist after creation")
 	}
+}
 
-	// On Unix, the script should have been created with execute permissions
-	if runtime.GOOS != "windows" {
-		info, err := os.Stat(scriptPath)
-		if err != nil {
-			t.Fatalf("Stat() error = %v", err)
-		}
-		if info.Mode()&0100 == 0 {
-			t.Error("Unix script should have execute permission")
+func TestMoveOversizedArg(t *testing.T) {
+	huge := strings.Repeat("x", maxArgBytesUnix+1)
+
+	cfg, err := moveOversizedArg(false, ScriptConfig{AuthDir: t.TempDir(), CliPath: "/bin/cli", CliArgs: []string{"-p"}})
+	if err != nil || cfg.StdinPath != "" {
+		t.Errorf("moveOversizedArg(small) = %+v, %v; want unchanged", cfg, err)
+	}
+
+	if _, err := moveOversizedArg(false, ScriptConfig{
+		AuthDir: t.TempDir(),
+		CliPath: "/bin/cli",
+		CliArgs: []string{huge, "last"},
+	}); err == nil {
+		t.Error("moveOversizedArg() expected error when a non-final argument is oversized")
+	}
+
+	if argsFit(true, `C:\cli.exe`, []string{strings.Repeat("w", maxCmdLineWindows)}) {
+		t.Error("argsFit(windows) accepted a command line over the limit")
+	}
+}
+
+func TestGenerateWindowsScript_StdinPath(t *testing.T) {
+	content := GenerateWindowsScript("ANTHROPIC_AUTH_TOKEN", ScriptConfig{
+		TokenPath: `C:\auth\token`,
+		CliPath:   `C:\bin\claude.exe`,
+		StdinPath: `C:\auth\stdin-1`,
+	})
+
+	for _, want := range []string{`Get-Content -Path "C:\\auth\\stdin-1" -Raw`, `Remove-Item -Path "C:\\auth\\stdin-1"`, `$kairoStdin | & `} {
+		if !strings.Contains(content, want) {
+			t.Errorf("script missing %q:\n%s", want, content)
 		}
 	}
 }
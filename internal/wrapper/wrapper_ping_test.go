@@ -0,0 +1,107 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/health"
+)
+
+// runProbe probes url with the token sk-test and returns what it printed.
+func runProbe(t *testing.T, url string, style health.AuthStyle) (string, error) {
+	t.Helper()
+	var stderr strings.Builder
+	err := probe(context.Background(), &stderr, &Ping{Provider: "zai", URL: url, AuthStyle: style}, "sk-test")
+
+	return stderr.String(), err
+}
+
+func TestProbe(t *testing.T) {
+	var gotAPIKey, gotAuth, gotVersion string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAPIKey = r.Header.Get("x-api-key")
+		gotAuth = r.Header.Get("Authorization")
+		gotVersion = r.Header.Get("anthropic-version")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	url := health.ModelsEndpoint(srv.URL)
+
+	t.Run("reachable", func(t *testing.T) {
+		if out, err := runProbe(t, url, health.AuthStyleBoth); err != nil || out != "" {
+			t.Fatalf("probe error = %v, output:\n%s", err, out)
+		}
+		if gotAPIKey != "sk-test" || gotAuth != "Bearer sk-test" || gotVersion != health.AnthropicVersion {
+			t.Errorf("headers = x-api-key %q, Authorization %q, anthropic-version %q", gotAPIKey, gotAuth, gotVersion)
+		}
+	})
+
+	t.Run("bearer only", func(t *testing.T) {
+		gotAPIKey = ""
+		if out, err := runProbe(t, url, health.AuthStyleBearer); err != nil {
+			t.Fatalf("probe error = %v, output:\n%s", err, out)
+		}
+		if gotAPIKey != "" || gotAuth != "Bearer sk-test" {
+			t.Errorf("headers = x-api-key %q, Authorization %q", gotAPIKey, gotAuth)
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		status = http.StatusUnauthorized
+		defer func() { status = http.StatusOK }()
+
+		out, err := runProbe(t, url, health.AuthStyleBoth)
+		if !errors.Is(err, ErrProbeFailed) {
+			t.Fatalf("probe error = %v, want ErrProbeFailed", err)
+		}
+		if !strings.Contains(out, "kairo: provider zai unauthorized") || !strings.Contains(out, "(HTTP 401)") {
+			t.Errorf("output = %q", out)
+		}
+	})
+
+	t.Run("other errors start the harness", func(t *testing.T) {
+		status = http.StatusNotFound
+		defer func() { status = http.StatusOK }()
+
+		if out, err := runProbe(t, url, health.AuthStyleBoth); err != nil {
+			t.Fatalf("probe error = %v, output:\n%s", err, out)
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+
+		out, err := runProbe(t, health.ModelsEndpoint(closed.URL), health.AuthStyleBoth)
+		if !errors.Is(err, ErrProbeFailed) || !strings.Contains(out, "kairo: provider zai unreachable at "+closed.URL) {
+			t.Fatalf("probe error = %v, output:\n%s", err, out)
+		}
+	})
+}
+
+func TestGenerateWindowsScript_Ping(t *testing.T) {
+	content := GenerateWindowsScript("ANTHROPIC_AUTH_TOKEN", ScriptConfig{
+		TokenPath: `C:\auth\token`,
+		CliPath:   `C:\bin\claude.exe`,
+		Ping:      &Ping{Provider: "o'brien", URL: "https://gw.example/v1/models?a=1&b=2", AuthStyle: health.AuthStyleBearer},
+	})
+
+	for _, want := range []string{
+		"Invoke-WebRequest -Uri 'https://gw.example/v1/models?a=1&b=2'",
+		"$kairoHeaders['Authorization'] = 'Bearer ' + $env:ANTHROPIC_AUTH_TOKEN",
+		"'kairo: provider o''brien unreachable at https://gw.example/v1/models?a=1&b=2'",
+		"exit 1",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("script missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "x-api-key") {
+		t.Errorf("bearer style sends x-api-key:\n%s", content)
+	}
+}
@@ -39,6 +39,7 @@ detect_os() {
     case "$(uname -s)" in
         Linux*)     echo "linux" ;;
         Darwin*)    echo "darwin" ;;
+        FreeBSD*)   echo "freebsd" ;;
         CYGWIN*|MINGW*|MSYS*) echo "windows" ;;
         *)          error "Unsupported OS: $(uname -s)" ;;
     esac
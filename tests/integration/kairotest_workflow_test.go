@@ -0,0 +1,251 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/tests/kairotest"
+)
+
+func TestSwitchInjectsProviderIntoFakeHarness(t *testing.T) {
+	t.Parallel()
+
+	e := kairotest.NewEnv(t)
+	provider := kairotest.NewFakeProvider(t)
+	h := e.InstallFakeHarness(t, "claude", kairotest.HarnessOptions{Stdout: "fake claude ran"})
+
+	e.WriteConfig(t, &config.Config{
+		DefaultProvider: "fake",
+		Providers: map[string]config.Provider{
+			"fake": {Name: "Fake", BaseURL: provider.URL, Model: "kairotest-model", AllowInsecure: true},
+		},
+	})
+	e.WriteSecrets(t, map[string]string{"FAKE_API_KEY": "kairotest-secret-key-0001"})
+
+	res := e.Run(t, "", "fake", "--", "hello")
+	if res.ExitCode != 0 {
+		t.Fatalf("kairo exit = %d\nstdout: %s\nstderr: %s", res.ExitCode, res.Stdout, res.Stderr)
+	}
+	if !strings.Contains(res.Stdout, "fake claude ran") {
+		t.Errorf("harness output missing from stdout: %s", res.Stdout)
+	}
+
+	inv := h.Invocations(t)
+	if len(inv) != 1 {
+		t.Fatalf("harness invocations = %d, want 1", len(inv))
+	}
+	if got := inv[0].Env["ANTHROPIC_BASE_URL"]; got != provider.URL {
+		t.Errorf("ANTHROPIC_BASE_URL = %q, want %q", got, provider.URL)
+	}
+	if got := inv[0].Env["ANTHROPIC_MODEL"]; got != "kairotest-model" {
+		t.Errorf("ANTHROPIC_MODEL = %q, want %q", got, "kairotest-model")
+	}
+	if got := inv[0].Env["ANTHROPIC_AUTH_TOKEN"]; got != "kairotest-secret-key-0001" {
+		t.Errorf("ANTHROPIC_AUTH_TOKEN = %q, want injected secret", got)
+	}
+	if strings.Join(inv[0].Args, " ") != "hello" {
+		t.Errorf("harness args = %q, want [hello]", inv[0].Args)
+	}
+}
+
+func TestSwitchModelFlagOverridesModel(t *testing.T) {
+	t.Parallel()
+
+	e := kairotest.NewEnv(t)
+	provider := kairotest.NewFakeProvider(t)
+	h := e.InstallFakeHarness(t, "claude", kairotest.HarnessOptions{})
+
+	e.WriteConfig(t, &config.Config{
+		DefaultProvider: "fake",
+		Providers: map[string]config.Provider{
+			"fake": {Name: "Fake", BaseURL: provider.URL, Model: "kairotest-model", AllowInsecure: true},
+		},
+	})
+	e.WriteSecrets(t, map[string]string{"FAKE_API_KEY": "kairotest-secret-key-0001"})
+
+	res := e.Run(t, "", "fake", "--model", "kairotest-other", "--", "hello")
+	if res.ExitCode != 0 {
+		t.Fatalf("kairo exit = %d\nstdout: %s\nstderr: %s", res.ExitCode, res.Stdout, res.Stderr)
+	}
+
+	inv := h.Invocations(t)
+	if len(inv) != 1 {
+		t.Fatalf("harness invocations = %d, want 1", len(inv))
+	}
+	if got := inv[0].Env["ANTHROPIC_MODEL"]; got != "kairotest-other" {
+		t.Errorf("ANTHROPIC_MODEL = %q, want the --model value", got)
+	}
+
+	cfg := e.Config(t)
+	if got := cfg.Providers["fake"].Model; got != "kairotest-model" {
+		t.Errorf("configured model = %q, want it unchanged", got)
+	}
+}
+
+func TestSwitchPropagatesHarnessFailure(t *testing.T) {
+	t.Parallel()
+
+	e := kairotest.NewEnv(t)
+	e.InstallFakeHarness(t, "claude", kairotest.HarnessOptions{ExitCode: 2})
+
+	e.WriteConfig(t, &config.Config{
+		DefaultProvider: "fake",
+		Providers: map[string]config.Provider{
+			"fake": {Name: "Fake", BaseURL: "https://fake.invalid", Model: "m"},
+		},
+	})
+	e.WriteSecrets(t, map[string]string{"FAKE_API_KEY": "kairotest-secret-key-0002"})
+
+	res := e.Run(t, "", "fake")
+	if res.ExitCode == 0 {
+		t.Errorf("kairo exit = 0, want non-zero when the harness fails\nstdout: %s", res.Stdout)
+	}
+}
+
+func TestSwitchNoticesHarnessUpdate(t *testing.T) {
+	t.Parallel()
+
+	e := kairotest.NewEnv(t)
+	e.InstallFakeHarness(t, "claude", kairotest.HarnessOptions{Version: "1.0.0"})
+
+	e.WriteConfig(t, &config.Config{
+		DefaultProvider: "fake",
+		Providers: map[string]config.Provider{
+			"fake": {Name: "Fake", BaseURL: "https://fake.invalid", Model: "m"},
+		},
+	})
+	e.WriteSecrets(t, map[string]string{"FAKE_API_KEY": "kairotest-secret-key-0003"})
+
+	if res := e.Run(t, "", "fake"); strings.Contains(res.Stderr, "updated from") {
+		t.Fatalf("first switch reported an update: %s", res.Stderr)
+	}
+
+	e.InstallFakeHarness(t, "claude", kairotest.HarnessOptions{Version: "1.10.0"})
+	res := e.Run(t, "", "fake")
+	if !strings.Contains(res.Stderr, "claude updated from 1.0.0 to 1.10.0") {
+		t.Errorf("upgrade notice missing from stderr: %s", res.Stderr)
+	}
+}
+
+func TestSwitchQwenAnthropicAuth(t *testing.T) {
+	t.Parallel()
+
+	e := kairotest.NewEnv(t)
+	h := e.InstallFakeHarness(t, "qwen", kairotest.HarnessOptions{})
+
+	e.WriteConfig(t, &config.Config{
+		DefaultProvider: "fake",
+		DefaultHarness:  "qwen",
+		Providers: map[string]config.Provider{
+			"fake": {Name: "Fake", BaseURL: "https://fake.invalid/anthropic", Model: "qwen-model"},
+		},
+	})
+	e.WriteSecrets(t, map[string]string{"FAKE_API_KEY": "kairotest-secret-key-0004"})
+
+	res := e.Run(t, "", "fake", "--", "hello")
+	if res.ExitCode != 0 {
+		t.Fatalf("kairo exit = %d\nstdout: %s\nstderr: %s", res.ExitCode, res.Stdout, res.Stderr)
+	}
+
+	inv := h.Invocations(t)
+	if len(inv) != 1 {
+		t.Fatalf("harness invocations = %d, want 1", len(inv))
+	}
+	if got := strings.Join(inv[0].Args, " "); got != "--auth-type anthropic --model qwen-model hello" {
+		t.Errorf("harness args = %q", got)
+	}
+	if got := inv[0].Env["ANTHROPIC_API_KEY"]; got != "kairotest-secret-key-0004" {
+		t.Errorf("ANTHROPIC_API_KEY = %q, want injected secret", got)
+	}
+	if got := inv[0].Env["ANTHROPIC_BASE_URL"]; got != "https://fake.invalid/anthropic" {
+		t.Errorf("ANTHROPIC_BASE_URL = %q", got)
+	}
+}
+
+func TestSwitchQwenOpenAIAuthWritesSettings(t *testing.T) {
+	t.Parallel()
+
+	e := kairotest.NewEnv(t)
+	h := e.InstallFakeHarness(t, "qwen", kairotest.HarnessOptions{
+		CaptureFiles: []string{"QWEN_CODE_SYSTEM_SETTINGS_PATH"},
+	})
+
+	e.WriteConfig(t, &config.Config{
+		DefaultProvider: "fake",
+		Providers: map[string]config.Provider{
+			"fake": {
+				Name:    "Fake",
+				BaseURL: "https://fake.invalid/anthropic",
+				Model:   "qwen-model",
+				Qwen: &config.QwenConfig{
+					AuthType:      "openai",
+					BaseURL:       "https://fake.invalid/v1",
+					WriteSettings: true,
+				},
+			},
+		},
+	})
+	e.WriteSecrets(t, map[string]string{"FAKE_API_KEY": "kairotest-secret-key-0005"})
+
+	res := e.Run(t, "", "--harness", "qwen", "fake")
+	if res.ExitCode != 0 {
+		t.Fatalf("kairo exit = %d\nstdout: %s\nstderr: %s", res.ExitCode, res.Stdout, res.Stderr)
+	}
+
+	inv := h.Invocations(t)
+	if len(inv) != 1 {
+		t.Fatalf("harness invocations = %d, want 1", len(inv))
+	}
+	if got := strings.Join(inv[0].Args, " "); got != "--auth-type openai --model qwen-model" {
+		t.Errorf("harness args = %q", got)
+	}
+	want := map[string]string{
+		"OPENAI_API_KEY":  "kairotest-secret-key-0005",
+		"OPENAI_BASE_URL": "https://fake.invalid/v1",
+		"OPENAI_MODEL":    "qwen-model",
+	}
+	for k, v := range want {
+		if got := inv[0].Env[k]; got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+
+	settings := inv[0].Files["QWEN_CODE_SYSTEM_SETTINGS_PATH"]
+	if !strings.Contains(settings, `"selectedType": "openai"`) || !strings.Contains(settings, `"name": "qwen-model"`) {
+		t.Errorf("settings.json = %q", settings)
+	}
+	if strings.Contains(settings, "kairotest-secret-key-0005") {
+		t.Error("settings.json contains the API key")
+	}
+}
+
+func TestSwitchClaudeAPIKeyAuthStyle(t *testing.T) {
+	t.Parallel()
+
+	e := kairotest.NewEnv(t)
+	h := e.InstallFakeHarness(t, "claude", kairotest.HarnessOptions{})
+
+	e.WriteConfig(t, &config.Config{
+		DefaultProvider: "fake",
+		Providers: map[string]config.Provider{
+			"fake": {Name: "Fake", BaseURL: "https://fake.invalid", Model: "m", AuthStyle: "x-api-key"},
+		},
+	})
+	e.WriteSecrets(t, map[string]string{"FAKE_API_KEY": "kairotest-secret-key-0006"})
+
+	if res := e.Run(t, "", "fake"); res.ExitCode != 0 {
+		t.Fatalf("kairo exit = %d\nstderr: %s", res.ExitCode, res.Stderr)
+	}
+
+	inv := h.Invocations(t)
+	if len(inv) != 1 {
+		t.Fatalf("harness invocations = %d, want 1", len(inv))
+	}
+	if got := inv[0].Env["ANTHROPIC_API_KEY"]; got != "kairotest-secret-key-0006" {
+		t.Errorf("ANTHROPIC_API_KEY = %q, want injected secret", got)
+	}
+	if _, ok := inv[0].Env["ANTHROPIC_AUTH_TOKEN"]; ok {
+		t.Error("ANTHROPIC_AUTH_TOKEN set for an x-api-key provider")
+	}
+}
@@ -0,0 +1,143 @@
+package kairotest
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/constants"
+)
+
+// HarnessOptions scripts the behavior of a fake harness binary.
+type HarnessOptions struct {
+	// Stdout is printed by the harness on every invocation.
+	Stdout string
+	// ExitCode is the status the harness exits with.
+	ExitCode int
+	// Version is printed for --version, which is answered without being
+	// recorded as an invocation. Defaults to DefaultHarnessVersion.
+	Version string
+	// CaptureFiles names environment variables holding file paths, such as
+	// a settings file kairo points the harness at. The content of each file
+	// is recorded in Invocation.Files, since kairo deletes it afterwards.
+	CaptureFiles []string
+}
+
+// DefaultHarnessVersion is reported by fake harnesses that set no Version.
+const DefaultHarnessVersion = "1.0.0"
+
+// Invocation is a single recorded run of a fake harness.
+type Invocation struct {
+	Args []string
+	Env  map[string]string
+	// Files maps each HarnessOptions.CaptureFiles variable that pointed at
+	// a readable file to the file's content.
+	Files map[string]string
+}
+
+// FakeHarness is a fake harness binary installed into an Env's BinDir.
+type FakeHarness struct {
+	Path       string
+	recordPath string
+}
+
+// InstallFakeHarness writes an executable named name (e.g. "claude") into
+// e.BinDir that records its arguments and environment, prints opts.Stdout,
+// and exits with opts.ExitCode. A lone --version argument prints
+// opts.Version instead. It requires a POSIX shell and skips the
+// test on Windows.
+func (e *Env) InstallFakeHarness(tb testing.TB, name string, opts HarnessOptions) *FakeHarness {
+	tb.Helper()
+
+	if runtime.GOOS == constants.WindowsGOOS {
+		tb.Skip("kairotest: fake harness requires a POSIX shell")
+	}
+
+	h := &FakeHarness{
+		Path:       filepath.Join(e.BinDir, name),
+		recordPath: filepath.Join(tb.TempDir(), name+".record"),
+	}
+
+	version := opts.Version
+	if version == "" {
+		version = DefaultHarnessVersion
+	}
+
+	var capture strings.Builder
+	for _, name := range opts.CaptureFiles {
+		fmt.Fprintf(&capture, "  if [ -f \"${%[1]s:-}\" ]; then sed 's/^/FILE %[1]s /' \"$%[1]s\"; echo; fi\n", name)
+	}
+
+	script := fmt.Sprintf(`#!/bin/sh
+if [ "$#" -eq 1 ] && [ "$1" = "--version" ]; then
+  printf '%%s\n' %s
+  exit 0
+fi
+{
+  echo '--- invocation'
+  for a in "$@"; do printf 'ARG %%s\n' "$a"; done
+  env | sed 's/^/ENV /'
+%s} >> %s
+printf '%%s' %s
+exit %d
+`, shellQuote(version), capture.String(), shellQuote(h.recordPath), shellQuote(opts.Stdout), opts.ExitCode)
+
+	if err := os.WriteFile(h.Path, []byte(script), constants.FilePermExec); err != nil {
+		tb.Fatalf("kairotest: writing fake harness: %v", err)
+	}
+
+	return h
+}
+
+// Invocations returns the recorded runs of the harness, oldest first.
+func (h *FakeHarness) Invocations(tb testing.TB) []Invocation {
+	tb.Helper()
+
+	f, err := os.Open(h.recordPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		tb.Fatalf("kairotest: reading harness record: %v", err)
+	}
+	defer f.Close()
+
+	var out []Invocation
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "--- invocation":
+			out = append(out, Invocation{Env: map[string]string{}, Files: map[string]string{}})
+		case len(out) == 0:
+		case strings.HasPrefix(line, "ARG "):
+			cur := &out[len(out)-1]
+			cur.Args = append(cur.Args, strings.TrimPrefix(line, "ARG "))
+		case strings.HasPrefix(line, "FILE "):
+			if k, v, ok := strings.Cut(strings.TrimPrefix(line, "FILE "), " "); ok {
+				files := out[len(out)-1].Files
+				if prev, seen := files[k]; seen {
+					v = prev + "\n" + v
+				}
+				files[k] = v
+			}
+		case strings.HasPrefix(line, "ENV "):
+			if k, v, ok := strings.Cut(strings.TrimPrefix(line, "ENV "), "="); ok {
+				out[len(out)-1].Env[k] = v
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		tb.Fatalf("kairotest: reading harness record: %v", err)
+	}
+
+	return out
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,224 @@
+// Package kairotest provides helpers for integration-testing the kairo binary:
+// a once-per-process build of the binary, isolated config directories with
+// encrypted secrets, fake Anthropic-compatible provider servers, and a
+// scriptable fake harness binary that records how kairo invoked it.
+package kairotest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+	"github.com/dkmnx/kairo/internal/constants"
+	"github.com/dkmnx/kairo/internal/crypto"
+	"github.com/dkmnx/kairo/internal/secrets"
+)
+
+var (
+	buildOnce sync.Once
+	buildPath string
+	buildErr  error
+)
+
+// Binary builds the kairo binary once per test process and returns its path.
+// Parallel tests share the same binary. The build output lives in a temp
+// directory that is not removed, so it survives individual test cleanup.
+func Binary(tb testing.TB) string {
+	tb.Helper()
+
+	buildOnce.Do(func() {
+		root, err := ProjectRoot()
+		if err != nil {
+			buildErr = err
+
+			return
+		}
+
+		dir, err := os.MkdirTemp("", "kairotest-bin-*")
+		if err != nil {
+			buildErr = err
+
+			return
+		}
+
+		buildPath = filepath.Join(dir, "kairo")
+		if runtime.GOOS == constants.WindowsGOOS {
+			buildPath += ".exe"
+		}
+
+		cmd := exec.CommandContext(context.Background(), "go", "build", "-o", buildPath, ".")
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			buildErr = errors.New("go build failed: " + err.Error() + "\n" + string(out))
+		}
+	})
+
+	if buildErr != nil {
+		tb.Fatalf("kairotest: %v", buildErr)
+	}
+
+	return buildPath
+}
+
+// ProjectRoot returns the nearest ancestor of the working directory that
+// contains go.mod.
+func ProjectRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", os.ErrNotExist
+		}
+		dir = parent
+	}
+}
+
+// Env is an isolated kairo environment: a config directory, a home
+// directory, and a bin directory that is placed first on PATH.
+type Env struct {
+	ConfigDir string
+	HomeDir   string
+	BinDir    string
+}
+
+// NewEnv creates an Env with an encryption key and an empty configuration.
+func NewEnv(tb testing.TB) *Env {
+	tb.Helper()
+
+	e := &Env{
+		ConfigDir: tb.TempDir(),
+		HomeDir:   tb.TempDir(),
+		BinDir:    tb.TempDir(),
+	}
+
+	if err := crypto.EnsureKeyExists(context.Background(), e.ConfigDir); err != nil {
+		tb.Fatalf("kairotest: creating key: %v", err)
+	}
+
+	e.WriteConfig(tb, &config.Config{Providers: map[string]config.Provider{}})
+
+	return e
+}
+
+// WriteConfig saves cfg as the environment's config.yaml.
+func (e *Env) WriteConfig(tb testing.TB, cfg *config.Config) {
+	tb.Helper()
+
+	if cfg.Providers == nil {
+		cfg.Providers = map[string]config.Provider{}
+	}
+	if err := config.SaveConfig(context.Background(), e.ConfigDir, cfg); err != nil {
+		tb.Fatalf("kairotest: saving config: %v", err)
+	}
+}
+
+// Config loads the environment's current config.yaml.
+func (e *Env) Config(tb testing.TB) *config.Config {
+	tb.Helper()
+
+	cfg, err := config.LoadConfig(context.Background(), e.ConfigDir)
+	if err != nil {
+		tb.Fatalf("kairotest: loading config: %v", err)
+	}
+
+	return cfg
+}
+
+// WriteSecrets encrypts values as the environment's secrets.age.
+func (e *Env) WriteSecrets(tb testing.TB, values map[string]string) {
+	tb.Helper()
+
+	err := crypto.EncryptSecrets(context.Background(),
+		filepath.Join(e.ConfigDir, constants.SecretsFileName),
+		filepath.Join(e.ConfigDir, constants.KeyFileName),
+		secrets.Format(values))
+	if err != nil {
+		tb.Fatalf("kairotest: writing secrets: %v", err)
+	}
+}
+
+// Secrets decrypts the environment's secrets.age.
+func (e *Env) Secrets(tb testing.TB) map[string]string {
+	tb.Helper()
+
+	content, err := crypto.DecryptSecrets(context.Background(),
+		filepath.Join(e.ConfigDir, constants.SecretsFileName),
+		filepath.Join(e.ConfigDir, constants.KeyFileName))
+	if err != nil {
+		tb.Fatalf("kairotest: reading secrets: %v", err)
+	}
+
+	return secrets.Parse(content)
+}
+
+// Environ returns the process environment for running kairo in e: HOME and
+// KAIRO_CONFIG_DIR point into e, PATH starts with e.BinDir, and ambient
+// ANTHROPIC_* variables are dropped so the host cannot leak into tests.
+func (e *Env) Environ() []string {
+	env := []string{
+		"HOME=" + e.HomeDir,
+		"USERPROFILE=" + e.HomeDir,
+		"KAIRO_CONFIG_DIR=" + e.ConfigDir,
+		"CLAUDE_CONFIG_DIR=" + filepath.Join(e.HomeDir, ".claude"),
+		"PATH=" + e.BinDir + string(os.PathListSeparator) + os.Getenv("PATH"),
+		"TERM=dumb",
+	}
+	for _, key := range []string{"TMPDIR", "TEMP", "TMP", "SYSTEMROOT", "GOCOVERDIR"} {
+		if v, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+v)
+		}
+	}
+
+	return env
+}
+
+// Result is the outcome of a kairo invocation.
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Run executes the kairo binary in e with args and optional stdin, and
+// returns its output. A non-zero exit is reported in Result, not as a failure.
+func (e *Env) Run(tb testing.TB, stdin string, args ...string) Result {
+	tb.Helper()
+
+	cmd := exec.CommandContext(context.Background(), Binary(tb), args...)
+	cmd.Env = e.Environ()
+	cmd.Dir = e.HomeDir
+	cmd.Stdin = bytes.NewBufferString(stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+
+	res := Result{Stdout: stdout.String(), Stderr: stderr.String()}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	default:
+		tb.Fatalf("kairotest: running kairo: %v", err)
+	}
+
+	return res
+}
@@ -0,0 +1,127 @@
+package kairotest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/config"
+)
+
+func TestEnvConfigAndSecretsRoundTrip(t *testing.T) {
+	e := NewEnv(t)
+
+	e.WriteConfig(t, &config.Config{
+		DefaultProvider: "zai",
+		Providers:       map[string]config.Provider{"zai": {Name: "Z.AI", Model: "glm-5.1"}},
+	})
+	if got := e.Config(t).DefaultProvider; got != "zai" {
+		t.Errorf("Config().DefaultProvider = %q, want %q", got, "zai")
+	}
+
+	e.WriteSecrets(t, map[string]string{"ZAI_API_KEY": "test-key"})
+	if got := e.Secrets(t)["ZAI_API_KEY"]; got != "test-key" {
+		t.Errorf("Secrets()[ZAI_API_KEY] = %q, want %q", got, "test-key")
+	}
+}
+
+func TestEnvironIsolatesHost(t *testing.T) {
+	t.Setenv("ANTHROPIC_BASE_URL", "https://host.example.com")
+	e := NewEnv(t)
+
+	env := strings.Join(e.Environ(), "\n")
+	if strings.Contains(env, "ANTHROPIC_BASE_URL") {
+		t.Error("Environ() leaked host ANTHROPIC_BASE_URL")
+	}
+	if !strings.Contains(env, "KAIRO_CONFIG_DIR="+e.ConfigDir) {
+		t.Error("Environ() missing KAIRO_CONFIG_DIR")
+	}
+	if !strings.Contains(env, "PATH="+e.BinDir) {
+		t.Error("Environ() does not put BinDir first on PATH")
+	}
+}
+
+func TestFakeProvider(t *testing.T) {
+	p := NewFakeProvider(t)
+	p.SetReply("canned")
+
+	post := func(withKey bool) *http.Response {
+		t.Helper()
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, p.URL+"/v1/messages",
+			strings.NewReader(`{"model":"m1","messages":[]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if withKey {
+			req.Header.Set("x-api-key", "k")
+		}
+		resp, err := p.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+
+		return resp
+	}
+
+	if resp := post(false); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unauthenticated status = %d, want 401", resp.StatusCode)
+	}
+
+	resp := post(true)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var msg struct {
+		Model   string `json:"model"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Model != "m1" || len(msg.Content) != 1 || msg.Content[0].Text != "canned" {
+		t.Errorf("reply = %+v", msg)
+	}
+
+	p.SetStatus(http.StatusTooManyRequests)
+	if resp := post(true); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status after SetStatus = %d, want 429", resp.StatusCode)
+	}
+
+	if got := len(p.Requests()); got != 3 {
+		t.Errorf("len(Requests()) = %d, want 3", got)
+	}
+}
+
+func TestFakeHarnessRecordsInvocations(t *testing.T) {
+	e := NewEnv(t)
+	h := e.InstallFakeHarness(t, "claude", HarnessOptions{Stdout: "hi", ExitCode: 3})
+
+	cmd := exec.CommandContext(context.Background(), h.Path, "--flag", "it's spaced")
+	cmd.Env = append(e.Environ(), "MARKER=1")
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("harness exit = %v, want exit status 3", err)
+	}
+	if string(out) != "hi" {
+		t.Errorf("harness stdout = %q, want %q", out, "hi")
+	}
+
+	inv := h.Invocations(t)
+	if len(inv) != 1 {
+		t.Fatalf("len(Invocations()) = %d, want 1", len(inv))
+	}
+	if strings.Join(inv[0].Args, "|") != "--flag|it's spaced" {
+		t.Errorf("Args = %q", inv[0].Args)
+	}
+	if inv[0].Env["MARKER"] != "1" {
+		t.Errorf("Env[MARKER] = %q, want %q", inv[0].Env["MARKER"], "1")
+	}
+}
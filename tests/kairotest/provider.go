@@ -0,0 +1,100 @@
+package kairotest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/dkmnx/kairo/internal/mockprovider"
+)
+
+// Request is a request received by a FakeProvider.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// FakeProvider is an HTTPS server speaking the Anthropic Messages API via
+// internal/mockprovider. It records every request and returns a
+// configurable canned reply.
+type FakeProvider struct {
+	// URL is the base URL to configure as a provider's base_url.
+	URL string
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	requests []Request
+	status   int
+	reply    string
+}
+
+// NewFakeProvider starts a FakeProvider that is shut down when the test ends.
+func NewFakeProvider(tb testing.TB) *FakeProvider {
+	tb.Helper()
+
+	p := &FakeProvider{status: http.StatusOK, reply: "Hello from kairotest"}
+	p.server = httptest.NewTLSServer(http.HandlerFunc(p.serveHTTP))
+	p.URL = p.server.URL
+	tb.Cleanup(p.server.Close)
+
+	return p
+}
+
+// Client returns an HTTP client that trusts the provider's certificate.
+func (p *FakeProvider) Client() *http.Client {
+	return p.server.Client()
+}
+
+// SetStatus makes subsequent requests fail with the given HTTP status.
+// http.StatusOK restores normal replies.
+func (p *FakeProvider) SetStatus(code int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status = code
+}
+
+// SetReply sets the assistant text returned by /v1/messages.
+func (p *FakeProvider) SetReply(text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.reply = text
+}
+
+// Requests returns a copy of the requests received so far.
+func (p *FakeProvider) Requests() []Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Request(nil), p.requests...)
+}
+
+func (p *FakeProvider) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	p.mu.Lock()
+	p.requests = append(p.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	status, reply := p.status, p.reply
+	p.mu.Unlock()
+
+	if status != http.StatusOK {
+		mockprovider.WriteError(w, status, "api_error", http.StatusText(status))
+
+		return
+	}
+
+	mockprovider.NewHandler(mockprovider.Options{Reply: reply}).ServeHTTP(w, r)
+}